@@ -3,6 +3,9 @@ package agent
 import (
 	"context"
 	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
 	"golang.org/x/sync/errgroup"
@@ -12,6 +15,7 @@ import (
 	"github.com/valocode/bubbly/agent/component/datastore"
 	"github.com/valocode/bubbly/agent/component/natsserver"
 	"github.com/valocode/bubbly/agent/component/worker"
+	"github.com/valocode/bubbly/agent/standalone"
 	"github.com/valocode/bubbly/config"
 	"github.com/valocode/bubbly/env"
 )
@@ -70,6 +74,8 @@ func (a *Agent) Run(bCtx *env.BubblyContext) error {
 	switch a.DeploymentType {
 	case config.SingleDeployment:
 		return a.runAsSingle(bCtx)
+	case config.StandaloneDeployment:
+		return a.runAsStandalone(bCtx)
 	default:
 		return fmt.Errorf(
 			`deployment type "%s" not implemented`,
@@ -175,3 +181,17 @@ func (a *Agent) runAsSingle(bCtx *env.BubblyContext) error {
 	return nil
 
 }
+
+// runAsStandalone runs only the API server, without a NATS server or any
+// other components. The API server talks to an in-process data store directly.
+// The agent's context is cancelled when the process is interrupted or
+// terminated, which shuts the API server down
+func (a *Agent) runAsStandalone(bCtx *env.BubblyContext) error {
+	agentContext, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := standalone.Run(bCtx, agentContext); err != nil {
+		return fmt.Errorf("error running standalone agent: %w", err)
+	}
+	return nil
+}
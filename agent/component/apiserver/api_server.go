@@ -47,21 +47,12 @@ func (a *APIServer) Run(bCtx *env.BubblyContext, agentContext context.Context) e
 
 	bCtx.Logger.Debug().Str("component", string(a.Type)).Interface("subscriptions", a.Subscriptions).Msg("component is listening for subscriptions")
 
-	ch := make(chan error, 1)
-	defer close(ch)
-
-	// run the actual api server in a separate goroutine, but track its
-	// performance using a channel
-	go a.run(bCtx, ch)
-
-	select {
-	// if the api server fails, error
-	case err := <-ch:
+	// Run the API server until the agent context is done, at which point the
+	// server is shutdown gracefully
+	if err := a.Server.Run(agentContext); err != nil {
 		return fmt.Errorf("error while running API server: %w", err)
-	// if another agent component fails, error
-	case <-agentContext.Done():
-		return agentContext.Err()
 	}
+	return agentContext.Err()
 }
 
 // Close overrides the ComponentCore Close() so that it can also close the server
@@ -71,10 +62,3 @@ func (a *APIServer) Close() {
 	// Also close the server's connection
 	a.Server.Close()
 }
-
-func (a *APIServer) run(bCtx *env.BubblyContext, ch chan error) {
-	if err := a.Server.ListenAndServe(); err != nil {
-		bCtx.Logger.Debug().Err(err).Msg("API Server finished due to error")
-		ch <- fmt.Errorf("API Server failed: %w", err)
-	}
-}
@@ -3,6 +3,7 @@ package component
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"sync"
 	"time"
@@ -14,13 +15,6 @@ import (
 	"github.com/valocode/bubbly/env"
 )
 
-const (
-	// wait a maximum of 2 seconds for responses to NATS Requests.
-	// A timeout is indication that there is no subscriber listening on the
-	// given subject.
-	defaultRequestTimeout = 2
-)
-
 // ComponentCore provides a minimal-viable implementation of a bubbly
 // component. It:
 // - provides a default implementation of the various methods
@@ -118,15 +112,25 @@ func (c ComponentCore) Request(bCtx *env.BubblyContext, req *Request) error {
 		req.Reply = &Reply{}
 	}
 
-	var reply []byte
+	var (
+		reply []byte
+		// A timeout is indication that there is no subscriber listening on
+		// the given subject
+		timeout = bCtx.AgentConfig.NATSRequestTimeout()
+	)
+	// Let the handler know when we stop waiting for the reply
+	req.Data.Deadline = time.Now().Add(timeout)
 	// Publish the data containing within the Publication
 	if err := c.EConn.Request(
 		string(req.Subject),
 		req.Data,
 		&reply,
-		defaultRequestTimeout*time.Second,
+		timeout,
 	); err != nil {
-		// just return the err unwrapped: this lets us assert the nats.Err type upstream
+		// wrap the err with %w: this lets us assert the nats.Err type upstream
+		if errors.Is(err, nats.ErrTimeout) {
+			return fmt.Errorf("no reply to request on subject %s within %s, check that a component is subscribed to it: %w", req.Subject, timeout, err)
+		}
 		return err
 	}
 
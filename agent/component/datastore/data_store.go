@@ -63,23 +63,71 @@ func (d *DataStore) defaultSubscriptions() component.DesiredSubscriptions {
 			Reply:   true,
 			Handler: d.getResourcesByKindHandler,
 		},
+		component.DesiredSubscription{
+			Subject: component.StoreDeleteResource,
+			Queue:   component.StoreQueue,
+			Reply:   true,
+			Handler: d.deleteResourceHandler,
+		},
+		component.DesiredSubscription{
+			Subject: component.StoreGetSchema,
+			Queue:   component.StoreQueue,
+			Reply:   true,
+			Handler: d.getSchemaHandler,
+		},
+		component.DesiredSubscription{
+			Subject: component.StoreDescribeSchema,
+			Queue:   component.StoreQueue,
+			Reply:   true,
+			Handler: d.describeSchemaHandler,
+		},
+		component.DesiredSubscription{
+			Subject: component.StoreGetSchemaSDL,
+			Queue:   component.StoreQueue,
+			Reply:   true,
+			Handler: d.getSchemaSDLHandler,
+		},
+		component.DesiredSubscription{
+			Subject: component.StoreGetStatus,
+			Queue:   component.StoreQueue,
+			Reply:   true,
+			Handler: d.getStatusHandler,
+		},
 		component.DesiredSubscription{
 			Subject: component.StorePostSchema,
 			Queue:   component.StoreQueue,
 			Reply:   true,
 			Handler: d.postSchemaHandler,
 		},
+		component.DesiredSubscription{
+			Subject: component.StorePreviewSchema,
+			Queue:   component.StoreQueue,
+			Reply:   true,
+			Handler: d.previewSchemaHandler,
+		},
 		component.DesiredSubscription{
 			Subject: component.StoreQuery,
 			Queue:   component.StoreQueue,
 			Reply:   true,
 			Handler: d.queryHandler,
 		},
+		component.DesiredSubscription{
+			Subject: component.StoreQueryExplain,
+			Queue:   component.StoreQueue,
+			Reply:   true,
+			Handler: d.queryExplainHandler,
+		},
 		component.DesiredSubscription{
 			Subject: component.StoreUpload,
 			Queue:   component.StoreQueue,
 			Reply:   true,
 			Handler: d.uploadHandler,
 		},
+		component.DesiredSubscription{
+			Subject: component.StoreUploadOnce,
+			Queue:   component.StoreQueue,
+			Reply:   true,
+			Handler: d.uploadOnceHandler,
+		},
 	}
 }
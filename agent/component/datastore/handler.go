@@ -1,6 +1,7 @@
 package datastore
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 
@@ -29,7 +30,24 @@ func (d *DataStore) getResourcesByKindHandler(bCtx *env.BubblyContext, subject s
 	if data.Auth != nil {
 		tenant = data.Auth.Organization
 	}
-	return d.Store.Query(tenant, string(data.Data))
+	ctx, cancel := data.Context(store.ContextWithAuth(context.Background(), data.Auth))
+	defer cancel()
+	return d.Store.QueryContext(ctx, tenant, string(data.Data))
+}
+
+// deleteResourceHandler deletes the resource with the ID in the data, and
+// replies with whether the resource existed
+func (d *DataStore) deleteResourceHandler(bCtx *env.BubblyContext, subject string, reply string, data component.MessageData) (interface{}, error) {
+	bCtx.Logger.Debug().
+		Str("subject", subject).
+		Str("component", string(d.Type)).
+		Msg("processing message")
+
+	var tenant = store.DefaultTenantName
+	if data.Auth != nil {
+		tenant = data.Auth.Organization
+	}
+	return d.Store.DeleteResource(tenant, string(data.Data))
 }
 
 func (d *DataStore) postSchemaHandler(bCtx *env.BubblyContext, subject string, reply string, data component.MessageData) (interface{}, error) {
@@ -54,6 +72,97 @@ func (d *DataStore) postSchemaHandler(bCtx *env.BubblyContext, subject string, r
 	return nil, nil
 }
 
+func (d *DataStore) previewSchemaHandler(bCtx *env.BubblyContext, subject string, reply string, data component.MessageData) (interface{}, error) {
+	bCtx.Logger.Debug().
+		Str("subject", subject).
+		Str("component", string(d.Type)).
+		Msg("processing message")
+
+	var (
+		tenant = store.DefaultTenantName
+		schema core.Tables
+	)
+	if err := json.Unmarshal(data.Data, &schema); err != nil {
+		return nil, fmt.Errorf("failed to decode schema into core.Tables: %w", err)
+	}
+	if data.Auth != nil {
+		tenant = data.Auth.Organization
+	}
+	stmts, err := d.Store.PreviewSchema(tenant, schema)
+	if err != nil {
+		return nil, fmt.Errorf("failed to preview schema: %w", err)
+	}
+	return stmts, nil
+}
+
+func (d *DataStore) getSchemaHandler(bCtx *env.BubblyContext, subject string, reply string, data component.MessageData) (interface{}, error) {
+	bCtx.Logger.Debug().
+		Str("subject", subject).
+		Str("component", string(d.Type)).
+		Msg("processing message")
+
+	var tenant = store.DefaultTenantName
+	if data.Auth != nil {
+		tenant = data.Auth.Organization
+	}
+	tables, err := d.Store.SchemaTables(tenant)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get schema: %w", err)
+	}
+	return tables, nil
+}
+
+func (d *DataStore) getSchemaSDLHandler(bCtx *env.BubblyContext, subject string, reply string, data component.MessageData) (interface{}, error) {
+	bCtx.Logger.Debug().
+		Str("subject", subject).
+		Str("component", string(d.Type)).
+		Msg("processing message")
+
+	var tenant = store.DefaultTenantName
+	if data.Auth != nil {
+		tenant = data.Auth.Organization
+	}
+	sdl, err := d.Store.SchemaSDL(tenant)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get schema SDL: %w", err)
+	}
+	return sdl, nil
+}
+
+func (d *DataStore) describeSchemaHandler(bCtx *env.BubblyContext, subject string, reply string, data component.MessageData) (interface{}, error) {
+	bCtx.Logger.Debug().
+		Str("subject", subject).
+		Str("component", string(d.Type)).
+		Msg("processing message")
+
+	var tenant = store.DefaultTenantName
+	if data.Auth != nil {
+		tenant = data.Auth.Organization
+	}
+	desc, err := d.Store.DescribeSchema(tenant)
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe schema: %w", err)
+	}
+	return desc, nil
+}
+
+func (d *DataStore) getStatusHandler(bCtx *env.BubblyContext, subject string, reply string, data component.MessageData) (interface{}, error) {
+	bCtx.Logger.Debug().
+		Str("subject", subject).
+		Str("component", string(d.Type)).
+		Msg("processing message")
+
+	var tenant = store.DefaultTenantName
+	if data.Auth != nil {
+		tenant = data.Auth.Organization
+	}
+	status, err := d.Store.Status(tenant)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get status: %w", err)
+	}
+	return status, nil
+}
+
 func (d *DataStore) queryHandler(bCtx *env.BubblyContext, subject string, reply string, data component.MessageData) (interface{}, error) {
 	bCtx.Logger.Debug().
 		Str("subject", subject).
@@ -64,13 +173,38 @@ func (d *DataStore) queryHandler(bCtx *env.BubblyContext, subject string, reply
 	if data.Auth != nil {
 		tenant = data.Auth.Organization
 	}
-	result, err := d.Store.Query(tenant, string(data.Data))
+	ctx, cancel := data.Context(store.ContextWithAuth(context.Background(), data.Auth))
+	defer cancel()
+	result, err := d.Store.QueryContext(ctx, tenant, string(data.Data))
 	if err != nil {
 		return nil, fmt.Errorf("failed to query the data store: %w", err)
 	}
 	return result, nil
 }
 
+func (d *DataStore) queryExplainHandler(bCtx *env.BubblyContext, subject string, reply string, data component.MessageData) (interface{}, error) {
+	bCtx.Logger.Debug().
+		Str("subject", subject).
+		Str("component", string(d.Type)).
+		Msg("processing message")
+
+	var (
+		tenant = store.DefaultTenantName
+		req    component.QueryExplain
+	)
+	if err := json.Unmarshal(data.Data, &req); err != nil {
+		return nil, fmt.Errorf("failed to decode query to explain: %w", err)
+	}
+	if data.Auth != nil {
+		tenant = data.Auth.Organization
+	}
+	result, err := d.Store.QueryExplain(tenant, req.Query, req.Analyze)
+	if err != nil {
+		return nil, fmt.Errorf("failed to explain query on the data store: %w", err)
+	}
+	return result, nil
+}
+
 func (d *DataStore) uploadHandler(bCtx *env.BubblyContext, subject string, reply string, data component.MessageData) (interface{}, error) {
 	bCtx.Logger.Debug().
 		Str("subject", subject).
@@ -93,3 +227,30 @@ func (d *DataStore) uploadHandler(bCtx *env.BubblyContext, subject string, reply
 
 	return nil, nil
 }
+
+func (d *DataStore) uploadOnceHandler(bCtx *env.BubblyContext, subject string, reply string, data component.MessageData) (interface{}, error) {
+	bCtx.Logger.Debug().
+		Str("subject", subject).
+		Str("component", string(d.Type)).
+		Msg("processing message")
+
+	var (
+		tenant = store.DefaultTenantName
+		req    component.UploadOnce
+		dbs    core.DataBlocks
+	)
+	if err := json.Unmarshal(data.Data, &req); err != nil {
+		return nil, fmt.Errorf("failed to decode upload: %w", err)
+	}
+	if err := json.Unmarshal(req.Data, &dbs); err != nil {
+		return nil, fmt.Errorf("failed to decode data into core.DataBlocks: %w", err)
+	}
+	if data.Auth != nil {
+		tenant = data.Auth.Organization
+	}
+	if _, err := d.Store.SaveOnce(tenant, req.ID, dbs); err != nil {
+		return nil, fmt.Errorf("failed to save data to data store: %w", err)
+	}
+
+	return nil, nil
+}
@@ -0,0 +1,59 @@
+package datastore
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/graphql-go/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/valocode/bubbly/agent/component"
+	"github.com/valocode/bubbly/env"
+	"github.com/valocode/bubbly/store"
+	"github.com/valocode/bubbly/test"
+)
+
+// TestQueryHandlerDeadline checks that the query of a request is cancelled
+// when the deadline of the request has passed, as the requester is no longer
+// waiting for its reply
+func TestQueryHandlerDeadline(t *testing.T) {
+	bCtx := env.NewBubblyContext()
+	resource := test.RunPostgresDocker(bCtx, t)
+	bCtx.StoreConfig.PostgresAddr = fmt.Sprintf("localhost:%s", resource.GetPort("5432/tcp"))
+
+	s, err := store.New(bCtx)
+	require.NoError(t, err)
+	defer s.Close()
+	d := &DataStore{
+		ComponentCore: &component.ComponentCore{Type: component.DataStoreComponent},
+		Store:         s,
+	}
+
+	tcs := []struct {
+		desc      string
+		deadline  time.Time
+		cancelled bool
+	}{
+		{desc: "no deadline"},
+		{desc: "deadline to come", deadline: time.Now().Add(time.Minute)},
+		{desc: "deadline passed", deadline: time.Now().Add(-time.Second), cancelled: true},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.desc, func(t *testing.T) {
+			val, err := d.queryHandler(bCtx, string(component.StoreQuery), "", component.MessageData{
+				Data:     []byte(`{ _resource { name } }`),
+				Deadline: tc.deadline,
+			})
+			require.NoError(t, err)
+			result := val.(*graphql.Result)
+			if !tc.cancelled {
+				assert.Empty(t, result.Errors)
+				return
+			}
+			require.NotEmpty(t, result.Errors)
+			assert.Contains(t, result.Errors[0].Message, "context deadline exceeded")
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package component
 
 import (
+	"context"
 	"time"
 )
 
@@ -19,6 +20,20 @@ type Request struct {
 type MessageData struct {
 	Auth *MessageAuth `json:"auth"`
 	Data []byte       `json:"data"`
+	// Deadline is when the requester stops waiting for the reply, after which
+	// there is no point in handling the request. It is zero if there is no
+	// deadline
+	Deadline time.Time `json:"deadline"`
+}
+
+// Context returns a context derived from the parent which is done at the
+// deadline of the message, if it has one, so that the work of handling the
+// message is cancelled when the requester has given up on it
+func (m MessageData) Context(parent context.Context) (context.Context, context.CancelFunc) {
+	if m.Deadline.IsZero() {
+		return context.WithCancel(parent)
+	}
+	return context.WithDeadline(parent, m.Deadline)
 }
 
 // MessageAuth contains information about the user making the request and the
@@ -35,3 +50,17 @@ type Reply struct {
 	Data  []byte `json:"data"`
 	Error string `json:"error"`
 }
+
+// QueryExplain is the data of a StoreQueryExplain request, which queries the
+// store and also returns the SQL that was run, and optionally its query plan
+type QueryExplain struct {
+	Query   string `json:"query"`
+	Analyze bool   `json:"analyze"`
+}
+
+// UploadOnce is the data of a StoreUploadOnce request, which saves the data
+// blocks only if no data with the same ID has been saved
+type UploadOnce struct {
+	ID   string `json:"id"`
+	Data []byte `json:"data"`
+}
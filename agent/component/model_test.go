@@ -0,0 +1,44 @@
+package component
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMessageDataContext(t *testing.T) {
+	t.Run("no deadline", func(t *testing.T) {
+		ctx, cancel := MessageData{}.Context(context.Background())
+		defer cancel()
+		_, ok := ctx.Deadline()
+		assert.False(t, ok)
+		assert.NoError(t, ctx.Err())
+	})
+	t.Run("deadline", func(t *testing.T) {
+		deadline := time.Now().Add(time.Minute)
+		ctx, cancel := MessageData{Deadline: deadline}.Context(context.Background())
+		defer cancel()
+		d, ok := ctx.Deadline()
+		require.True(t, ok)
+		assert.True(t, deadline.Equal(d))
+		assert.NoError(t, ctx.Err())
+	})
+	t.Run("passed deadline", func(t *testing.T) {
+		ctx, cancel := MessageData{Deadline: time.Now().Add(-time.Second)}.Context(context.Background())
+		defer cancel()
+		assert.Equal(t, context.DeadlineExceeded, ctx.Err())
+	})
+	t.Run("sent deadline", func(t *testing.T) {
+		// The deadline is sent to the handler as JSON
+		deadline := time.Now().Add(time.Minute)
+		b, err := json.Marshal(MessageData{Deadline: deadline})
+		require.NoError(t, err)
+		var data MessageData
+		require.NoError(t, json.Unmarshal(b, &data))
+		assert.True(t, deadline.Equal(data.Deadline))
+	})
+}
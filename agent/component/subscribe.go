@@ -42,10 +42,18 @@ type Subject string
 // defined centrally here
 const (
 	StoreCreateTenant       Subject = "store.CreateTenant"
+	StoreDeleteResource     Subject = "store.DeleteResource"
+	StoreDescribeSchema     Subject = "store.DescribeSchema"
 	StoreGetResourcesByKind Subject = "store.GetResourcesByKind"
+	StoreGetSchema          Subject = "store.GetSchema"
+	StoreGetSchemaSDL       Subject = "store.GetSchemaSDL"
+	StoreGetStatus          Subject = "store.GetStatus"
 	StorePostSchema         Subject = "store.PostSchema"
+	StorePreviewSchema      Subject = "store.PreviewSchema"
 	StoreQuery              Subject = "store.Query"
+	StoreQueryExplain       Subject = "store.QueryExplain"
 	StoreUpload             Subject = "store.Upload"
+	StoreUploadOnce         Subject = "store.UploadOnce"
 	WorkerPostRunResource   Subject = "worker.PostRunResource"
 )
 
@@ -31,6 +31,7 @@ func New(bCtx *env.BubblyContext) *Worker {
 			},
 			WorkerChannels: nil,
 			Context:        interval.ChannelContext{},
+			MaxConcurrency: bCtx.AgentConfig.WorkerMaxConcurrency,
 		},
 	}
 
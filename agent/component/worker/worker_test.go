@@ -0,0 +1,71 @@
+package worker
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	natsserver "github.com/nats-io/nats-server/v2/test"
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/valocode/bubbly/agent/component"
+	"github.com/valocode/bubbly/client"
+	"github.com/valocode/bubbly/config"
+	"github.com/valocode/bubbly/env"
+	"github.com/valocode/bubbly/server"
+)
+
+// Just some random value that probably won't be in use. It can be changed
+const testPort = 8132
+
+// TestWorkerRunEvent checks that the worker reacts to a run resource that is
+// posted to it, which the store does when a run resource is saved, without
+// waiting to poll for it
+func TestWorkerRunEvent(t *testing.T) {
+	opts := natsserver.DefaultTestOptions
+	opts.Port = testPort
+	s := natsserver.RunServer(&opts)
+	defer s.Shutdown()
+
+	bCtx := env.NewBubblyContext()
+	bCtx.ClientConfig.ClientType = config.NATSClientType
+	bCtx.ClientConfig.NATSAddr = fmt.Sprintf("nats://127.0.0.1:%d", testPort)
+
+	// Fake the store, which receives the query for the run resource from the
+	// worker
+	nc, err := nats.Connect(bCtx.ClientConfig.NATSAddr)
+	require.NoError(t, err)
+	ec, err := nats.NewEncodedConn(nc, nats.JSON_ENCODER)
+	require.NoError(t, err)
+	defer ec.Close()
+	queries := make(chan string, 1)
+	_, err = ec.Subscribe(string(component.StoreQuery), func(subject string, reply string, data component.MessageData) {
+		queries <- string(data.Data)
+		ec.Publish(reply, component.Reply{Error: "no store in this test"})
+	})
+	require.NoError(t, err)
+
+	w := New(bCtx)
+	require.NoError(t, w.Connect(bCtx))
+	defer w.Close()
+	w.Subscriptions, err = w.BulkSubscribe(bCtx)
+	require.NoError(t, err)
+
+	// Post the run resource to the worker, as the store does
+	c, err := client.New(bCtx)
+	require.NoError(t, err)
+	defer c.Close()
+	b, err := json.Marshal(server.WorkerRun{Name: "my_run"})
+	require.NoError(t, err)
+	require.NoError(t, c.PostResourceToWorker(bCtx, nil, b))
+
+	select {
+	case query := <-queries:
+		assert.Contains(t, query, "run/my_run")
+	case <-time.After(time.Second):
+		t.Fatal("the worker did not get the run resource")
+	}
+}
@@ -0,0 +1,275 @@
+package standalone
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/graphql-go/graphql"
+
+	"github.com/valocode/bubbly/agent/component"
+	"github.com/valocode/bubbly/api/core"
+	"github.com/valocode/bubbly/client"
+	"github.com/valocode/bubbly/env"
+	"github.com/valocode/bubbly/store"
+)
+
+var _ client.Client = (*storeClient)(nil)
+
+// storeClient is a bubbly client which talks directly to an in-process store,
+// rather than to the data store component via NATS. It handles requests in
+// the same way as the data store component handles them
+type storeClient struct {
+	store *store.Store
+}
+
+func (s *storeClient) GetResource(bCtx *env.BubblyContext, auth *component.MessageAuth, resID string, opts ...client.ResourceOption) ([]byte, error) {
+	bCtx.Logger.Debug().
+		Str("resource_id", resID).
+		Msg("Getting resource from store")
+
+	result, err := s.query(auth, client.ResourceQuery(resID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get resource from query: %w", err)
+	}
+	return client.ResourceFromQueryResult(resID, result, opts...)
+}
+
+func (s *storeClient) GetResourceVersions(bCtx *env.BubblyContext, auth *component.MessageAuth, resID string) ([]byte, error) {
+	bCtx.Logger.Debug().
+		Str("resource_id", resID).
+		Msg("Getting resource versions from store")
+
+	result, err := s.query(auth, client.ResourceVersionsQuery(resID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get resource versions from query: %w", err)
+	}
+	return client.ResourceVersionsFromQueryResult(resID, result)
+}
+
+func (s *storeClient) GetResourceVersion(bCtx *env.BubblyContext, auth *component.MessageAuth, resID string, version int, opts ...client.ResourceOption) ([]byte, error) {
+	bCtx.Logger.Debug().
+		Str("resource_id", resID).
+		Int("version", version).
+		Msg("Getting resource version from store")
+
+	result, err := s.query(auth, client.ResourceVersionsQuery(resID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get resource versions from query: %w", err)
+	}
+	return client.ResourceVersionFromQueryResult(resID, version, result, opts...)
+}
+
+func (s *storeClient) GetResourcesByKind(bCtx *env.BubblyContext, auth *component.MessageAuth, namespace string, kind string) ([]byte, error) {
+	bCtx.Logger.Debug().
+		Str("namespace", namespace).
+		Str("kind", kind).
+		Msg("Getting resources by kind from store")
+
+	result, err := s.query(auth, client.ResourcesByKindQuery(kind))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get resources by kind from query: %w", err)
+	}
+	return client.ResourcesFromQueryResult(namespace, result)
+}
+
+func (s *storeClient) PostResource(bCtx *env.BubblyContext, auth *component.MessageAuth, data []byte) error {
+	if err := s.Load(bCtx, auth, data); err != nil {
+		return fmt.Errorf("failed to post resource: %w", err)
+	}
+	return nil
+}
+
+func (s *storeClient) PostResources(bCtx *env.BubblyContext, auth *component.MessageAuth, resources []byte) error {
+	data, err := client.ResourcesData(resources)
+	if err != nil {
+		return err
+	}
+	return s.PostResource(bCtx, auth, data)
+}
+
+func (s *storeClient) DeleteResource(bCtx *env.BubblyContext, auth *component.MessageAuth, id string) error {
+	deleted, err := s.store.DeleteResource(tenant(auth), id)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return fmt.Errorf("%w: %s", client.ErrResourceNotFound, id)
+	}
+	return nil
+}
+
+func (s *storeClient) PatchResource(bCtx *env.BubblyContext, auth *component.MessageAuth, id string, patch []byte) error {
+	return client.PatchStoredResource(bCtx, s, auth, id, patch)
+}
+
+func (s *storeClient) PostResourceToWorker(*env.BubblyContext, *component.MessageAuth, []byte) error {
+	return errors.New("unsupported operation for the standalone client: PostResourceToWorker")
+}
+
+func (s *storeClient) Load(bCtx *env.BubblyContext, auth *component.MessageAuth, data []byte, opts ...client.LoadOption) error {
+	var dbs core.DataBlocks
+	if err := json.Unmarshal(data, &dbs); err != nil {
+		return fmt.Errorf("failed to decode data into core.DataBlocks: %w", err)
+	}
+	if saveID := client.SaveID(opts...); saveID != "" {
+		if _, err := s.store.SaveOnce(tenant(auth), saveID, dbs); err != nil {
+			return fmt.Errorf("failed to save data to data store: %w", err)
+		}
+		return nil
+	}
+	if err := s.store.Save(tenant(auth), dbs); err != nil {
+		return fmt.Errorf("failed to save data to data store: %w", err)
+	}
+	return nil
+}
+
+func (s *storeClient) Query(bCtx *env.BubblyContext, auth *component.MessageAuth, query string, opts ...client.QueryOption) ([]byte, error) {
+	explain, ok := client.ExplainRequest(query, opts...)
+	if !ok {
+		return s.query(auth, query)
+	}
+	result, err := s.store.QueryExplain(tenant(auth), query, explain.Analyze)
+	if err != nil {
+		return nil, fmt.Errorf("failed to explain query on the data store: %w", err)
+	}
+	return marshalResult(result)
+}
+
+func (s *storeClient) QueryType(bCtx *env.BubblyContext, auth *component.MessageAuth, query string, ptr interface{}) error {
+	body, err := s.query(auth, query)
+	if err != nil {
+		return err
+	}
+	var result graphql.Result
+	// Assign the ptr to Data so that it gets unmarshalled automatically
+	result.Data = ptr
+	if err := json.Unmarshal(body, &result); err != nil {
+		return fmt.Errorf("error decoding GraphQL result: %w", err)
+	}
+	if result.HasErrors() {
+		return fmt.Errorf("graphql returned errors: %v", result.Errors)
+	}
+	return nil
+}
+
+func (s *storeClient) QueryCSV(bCtx *env.BubblyContext, auth *component.MessageAuth, query string) ([]byte, error) {
+	body, err := s.query(auth, query)
+	if err != nil {
+		return nil, err
+	}
+	return client.ResultCSV(body)
+}
+
+func (s *storeClient) PostSchema(bCtx *env.BubblyContext, auth *component.MessageAuth, data []byte) error {
+	var schema core.Tables
+	if err := json.Unmarshal(data, &schema); err != nil {
+		return fmt.Errorf("failed to decode schema into core.Tables: %w", err)
+	}
+	if err := s.store.Apply(tenant(auth), schema, false); err != nil {
+		return fmt.Errorf("failed to apply schema: %w", err)
+	}
+	return nil
+}
+
+func (s *storeClient) PreviewSchema(bCtx *env.BubblyContext, auth *component.MessageAuth, data []byte) ([]byte, error) {
+	var schema core.Tables
+	if err := json.Unmarshal(data, &schema); err != nil {
+		return nil, fmt.Errorf("failed to decode schema into core.Tables: %w", err)
+	}
+	stmts, err := s.store.PreviewSchema(tenant(auth), schema)
+	if err != nil {
+		return nil, fmt.Errorf("failed to preview schema: %w", err)
+	}
+	data, err = json.Marshal(stmts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode schema preview: %w", err)
+	}
+	return data, nil
+}
+
+func (s *storeClient) GetSchemaSDL(bCtx *env.BubblyContext, auth *component.MessageAuth) ([]byte, error) {
+	sdl, err := s.store.SchemaSDL(tenant(auth))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get schema SDL: %w", err)
+	}
+	return []byte(sdl), nil
+}
+
+func (s *storeClient) GetSchema(bCtx *env.BubblyContext, auth *component.MessageAuth) ([]byte, error) {
+	tables, err := s.store.SchemaTables(tenant(auth))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get schema: %w", err)
+	}
+	data, err := json.Marshal(tables)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode schema: %w", err)
+	}
+	return data, nil
+}
+
+func (s *storeClient) DescribeSchema(bCtx *env.BubblyContext, auth *component.MessageAuth) ([]byte, error) {
+	desc, err := s.store.DescribeSchema(tenant(auth))
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe schema: %w", err)
+	}
+	data, err := json.Marshal(desc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode schema description: %w", err)
+	}
+	return data, nil
+}
+
+func (s *storeClient) GetStatus(bCtx *env.BubblyContext, auth *component.MessageAuth) ([]byte, error) {
+	status, err := s.store.Status(tenant(auth))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get status: %w", err)
+	}
+	data, err := json.Marshal(status)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode status: %w", err)
+	}
+	return data, nil
+}
+
+func (s *storeClient) Version(bCtx *env.BubblyContext) (string, error) {
+	return env.Version, nil
+}
+
+func (s *storeClient) CreateTenant(bCtx *env.BubblyContext, auth *component.MessageAuth, name string) error {
+	return s.store.CreateTenant(name)
+}
+
+// Close closes the store, as it is owned by the client
+func (s *storeClient) Close() {
+	s.store.Close()
+}
+
+// query queries the store and returns the JSON encoded graphql.Result, which
+// is the same as what the data store component replies with
+func (s *storeClient) query(auth *component.MessageAuth, query string) ([]byte, error) {
+	ctx := store.ContextWithAuth(context.Background(), auth)
+	result, err := s.store.QueryContext(ctx, tenant(auth), query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query the data store: %w", err)
+	}
+	return marshalResult(result)
+}
+
+func marshalResult(result *graphql.Result) ([]byte, error) {
+	data, err := json.Marshal(result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode query result: %w", err)
+	}
+	return data, nil
+}
+
+// tenant returns the tenant to use for the given auth, which is the default
+// tenant if there is no auth
+func tenant(auth *component.MessageAuth) string {
+	if auth != nil {
+		return auth.Organization
+	}
+	return store.DefaultTenantName
+}
@@ -0,0 +1,55 @@
+// Package standalone runs bubbly as a single process without NATS, where the
+// API server talks directly to an in-process data store. It is meant for
+// simple, single node deployments that do not need any workers.
+package standalone
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/valocode/bubbly/client"
+	"github.com/valocode/bubbly/env"
+	"github.com/valocode/bubbly/server"
+	"github.com/valocode/bubbly/store"
+)
+
+// New creates a new API server which uses an in-process store to handle
+// requests. The store is closed when the server is closed
+func New(bCtx *env.BubblyContext) (*server.Server, error) {
+	s, err := store.New(bCtx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialise data store: %w", err)
+	}
+	return server.NewWithClient(bCtx, &storeClient{store: s}), nil
+}
+
+// NewWithStores creates a new API server which routes requests to the named
+// stores of the manager, and uses the store named defaultStore for requests
+// that do not name a store. The stores are closed when the server is closed
+func NewWithStores(bCtx *env.BubblyContext, m *store.StoreManager, defaultStore string) (*server.Server, error) {
+	if _, err := m.Get(defaultStore); err != nil {
+		return nil, fmt.Errorf("failed to get default store: %w", err)
+	}
+	stores := make(map[string]client.Client)
+	for _, name := range m.Names() {
+		s, err := m.Get(name)
+		if err != nil {
+			return nil, err
+		}
+		stores[name] = &storeClient{store: s}
+	}
+	return server.NewWithStores(bCtx, stores[defaultStore], stores), nil
+}
+
+// Run creates and runs a standalone API server until the given context is
+// cancelled, or the process is interrupted
+func Run(bCtx *env.BubblyContext, ctx context.Context) error {
+	s, err := New(bCtx)
+	if err != nil {
+		return err
+	}
+	if err := s.Run(ctx); err != nil {
+		return fmt.Errorf("error while running API server: %w", err)
+	}
+	return nil
+}
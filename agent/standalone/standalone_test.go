@@ -0,0 +1,205 @@
+package standalone
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/valocode/bubbly/api/core"
+	"github.com/valocode/bubbly/bubbly/builtin"
+	"github.com/valocode/bubbly/client"
+	"github.com/valocode/bubbly/config"
+	"github.com/valocode/bubbly/env"
+	"github.com/valocode/bubbly/store"
+	"github.com/valocode/bubbly/test"
+)
+
+func TestStandalone(t *testing.T) {
+	bCtx := env.NewBubblyContext()
+	resource := test.RunPostgresDocker(bCtx, t)
+	bCtx.StoreConfig.PostgresAddr = fmt.Sprintf("localhost:%s", resource.GetPort("5432/tcp"))
+
+	// Get a free port for the server to listen on
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	port := l.Addr().(*net.TCPAddr).Port
+	require.NoError(t, l.Close())
+	bCtx.ServerConfig.Port = strconv.Itoa(port)
+
+	// Make sure nothing can talk to NATS, as there should be no need
+	bCtx.ClientConfig.NATSAddr = "localhost:1"
+
+	ctx, cancel := context.WithCancel(context.Background())
+	runErr := make(chan error, 1)
+	go func() {
+		runErr <- Run(bCtx, ctx)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		require.NoError(t, <-runErr)
+	})
+
+	// Talk to the standalone server using the HTTP client
+	bCtx.ClientConfig.ClientType = config.HTTPClientType
+	bCtx.ClientConfig.BubblyAddr = fmt.Sprintf("http://localhost:%d/api/v1", port)
+	c, err := client.New(bCtx)
+	require.NoError(t, err)
+	defer c.Close()
+
+	// Wait for the server to be up
+	require.Eventually(t, func() bool {
+		conn, err := net.Dial("tcp", l.Addr().String())
+		if err != nil {
+			return false
+		}
+		conn.Close()
+		return true
+	}, 10*time.Second, 100*time.Millisecond, "standalone server did not start")
+
+	schema, err := json.Marshal(core.Tables{
+		{
+			Name: "standalone",
+			Fields: []core.TableField{
+				{Name: "name", Type: cty.String, Unique: true},
+			},
+		},
+	})
+	require.NoError(t, err)
+	require.NoError(t, c.PostSchema(bCtx, nil, schema))
+
+	// The tables and the GraphQL schema have the posted table
+	tablesData, err := c.GetSchema(bCtx, nil)
+	require.NoError(t, err)
+	var tables map[string]core.Table
+	require.NoError(t, json.Unmarshal(tablesData, &tables))
+	require.Contains(t, tables, "standalone")
+	assert.Equal(t, "name", tables["standalone"].Fields[0].Name)
+	sdl, err := c.GetSchemaSDL(bCtx, nil)
+	require.NoError(t, err)
+	assert.Contains(t, string(sdl), "type standalone {\n")
+	descData, err := c.DescribeSchema(bCtx, nil)
+	require.NoError(t, err)
+	var desc store.SchemaDescription
+	require.NoError(t, json.Unmarshal(descData, &desc))
+	assert.Contains(t, desc.Tables, store.TableDescription{
+		Name:   "standalone",
+		Fields: []store.FieldDescription{{Name: "name", Type: "string", Unique: true}},
+		Edges:  []store.EdgeDescription{},
+	})
+
+	// The status reports the builtin tables and the posted table
+	statusData, err := c.GetStatus(bCtx, nil)
+	require.NoError(t, err)
+	var status store.Status
+	require.NoError(t, json.Unmarshal(statusData, &status))
+	assert.Equal(t, len(store.FlattenTables(builtin.BuiltinTables, nil))+1, status.Tables)
+	assert.Equal(t, string(config.PostgresStore), status.Provider)
+
+	data, err := json.Marshal(core.DataBlocks{
+		{
+			TableName: "standalone",
+			Fields: &core.DataFields{Values: map[string]cty.Value{
+				"name": cty.StringVal("no_nats"),
+			}},
+		},
+	})
+	require.NoError(t, err)
+	require.NoError(t, c.Load(bCtx, nil, data))
+
+	var result map[string]interface{}
+	require.NoError(t, c.QueryType(bCtx, nil, "{ standalone { name } }", &result))
+	assert.Equal(t, map[string]interface{}{
+		"standalone": []interface{}{
+			map[string]interface{}{"name": "no_nats"},
+		},
+	}, result)
+}
+
+func TestStandaloneStores(t *testing.T) {
+	bCtx := env.NewBubblyContext()
+
+	// Each store has its own database, with a different schema
+	m := store.NewStoreManager()
+	for _, name := range []string{"staging", "prod"} {
+		storeCtx := env.NewBubblyContext()
+		resource := test.RunPostgresDocker(storeCtx, t)
+		storeCtx.StoreConfig.PostgresAddr = fmt.Sprintf("localhost:%s", resource.GetPort("5432/tcp"))
+		s, err := store.New(storeCtx)
+		require.NoError(t, err)
+		require.NoError(t, s.Apply(store.DefaultTenantName, core.Tables{
+			{
+				Name:   name + "_table",
+				Fields: []core.TableField{{Name: "name", Type: cty.String, Unique: true}},
+			},
+		}, false))
+		require.NoError(t, s.Save(store.DefaultTenantName, core.DataBlocks{
+			{
+				TableName: name + "_table",
+				Fields: &core.DataFields{Values: map[string]cty.Value{
+					"name": cty.StringVal(name),
+				}},
+			},
+		}))
+		require.NoError(t, m.Add(name, s))
+	}
+
+	s, err := NewWithStores(bCtx, m, "staging")
+	require.NoError(t, err)
+	defer s.Close()
+	router := s.Server.Handler
+
+	// query queries the server, naming the store in the header
+	query := func(storeName string, q string) map[string]interface{} {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/graphql", strings.NewReader(`{"query":"`+q+`"}`))
+		req.Header.Set("Content-Type", "application/json")
+		if storeName != "" {
+			req.Header.Set("X-Bubbly-Store", storeName)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var result map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+		return result
+	}
+
+	tcs := []struct {
+		desc      string
+		store     string
+		table     string
+		otherName string
+	}{
+		{desc: "default", store: "", table: "staging_table", otherName: "prod_table"},
+		{desc: "staging", store: "staging", table: "staging_table", otherName: "prod_table"},
+		{desc: "prod", store: "prod", table: "prod_table", otherName: "staging_table"},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.desc, func(t *testing.T) {
+			result := query(tc.store, "{ "+tc.table+" { name } }")
+			require.Empty(t, result["errors"])
+			assert.Equal(t, map[string]interface{}{
+				tc.table: []interface{}{
+					map[string]interface{}{"name": strings.TrimSuffix(tc.table, "_table")},
+				},
+			}, result["data"])
+			// The table of the other store does not exist in this store
+			result = query(tc.store, "{ "+tc.otherName+" { name } }")
+			assert.NotEmpty(t, result["errors"])
+		})
+	}
+
+	_, err = NewWithStores(bCtx, m, "dev")
+	assert.Error(t, err)
+}
@@ -2,6 +2,7 @@ package common
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"strings"
 
@@ -16,9 +17,18 @@ import (
 	"github.com/valocode/bubbly/parser"
 )
 
+// ErrMissingInputs is returned when a resource is decoded without some of the
+// inputs that it declares without a default value. The inputs are validated
+// before the resource is decoded, so that the error lists the missing inputs
+// instead of failing on the first reference to one of them
+var ErrMissingInputs = errors.New("missing required inputs")
+
 // RunResourceByID takes a given resource ID as string and ResourceContext, with
-// the input values as cty.Value and runs the resource referenced by the id
+// the input values as cty.Value and runs the resource referenced by the id.
+// An id of the form kind/name references a resource in the namespace of the
+// ResourceContext
 func RunResourceByID(bCtx *env.BubblyContext, ctx *core.ResourceContext, id string, inputs cty.Value) (core.Resource, core.ResourceOutput) {
+	id = core.ResourceIDInNamespace(ctx.Namespace, id)
 	resource, err := GetResource(bCtx, ctx, id)
 	if err != nil {
 		return nil, core.ResourceOutput{
@@ -34,6 +44,11 @@ func RunResourceByID(bCtx *env.BubblyContext, ctx *core.ResourceContext, id stri
 // the input values as cty.Value and runs the resource referenced by the id
 func RunResource(bCtx *env.BubblyContext, ctx *core.ResourceContext, resource core.SubResource, inputs cty.Value) core.ResourceOutput {
 	runCtx := core.SubResourceContext(inputs, ctx)
+	// The resources referenced by a resource are in its namespace, whereas
+	// sub resources, such as tasks, are in the namespace of their resource
+	if res, ok := resource.(core.Resource); ok {
+		runCtx.Namespace = res.Namespace()
+	}
 	output := resource.Run(bCtx, runCtx)
 	// Log the resource run as an event.
 	// We don't want to fail the entire process if the logging of an event fails
@@ -121,6 +136,15 @@ func DecodeBody(bCtx *env.BubblyContext, body hcl.Body, val interface{}, ctx *co
 	return nil
 }
 
+// ValidateBody decodes a body without any inputs, to validate it without
+// running the resource. See parser.ValidateBody for which errors are returned
+func ValidateBody(bCtx *env.BubblyContext, body hcl.Body, val interface{}) error {
+	if err := parser.ValidateBody(body, val); err != nil {
+		return fmt.Errorf("failed to validate resource: %w", err)
+	}
+	return nil
+}
+
 // ValidateResourceInputs takes the body of a resource and the given inputs and
 // validates whether all the provided inputs have been given, and returns the
 // inputs with any default values from the input declaration added.
@@ -130,7 +154,7 @@ func DecodeBody(bCtx *env.BubblyContext, body hcl.Body, val interface{}, ctx *co
 func ValidateResourceInputs(bCtx *env.BubblyContext, body hcl.Body, inputs cty.Value) (cty.Value, error) {
 	var inputDeclsWrap core.InputDeclarationHCLWrapper
 	if diags := gohcl.DecodeBody(body, nil, &inputDeclsWrap); diags.HasErrors() {
-		return cty.NilVal, fmt.Errorf("failed to get input declarations: %v", diags.Errs())
+		return cty.NilVal, fmt.Errorf("failed to get input declarations: %w", parser.NewParserError(&inputDeclsWrap, diags))
 	}
 	return compareInputsWithDecls(inputDeclsWrap.InputDeclarations, inputs)
 }
@@ -168,7 +192,7 @@ func compareInputsWithDecls(decls core.InputDeclarations, inputs cty.Value) (cty
 		} else {
 			// If the input was not provided and no default is given, add it to
 			// the list so that we can give a complete list at the end
-			if decl.Default.IsNull() {
+			if !decl.HasDefault() {
 				undefinedInputs = append(undefinedInputs, decl.Name)
 			}
 			// else, add the default value to the return input values
@@ -176,7 +200,7 @@ func compareInputsWithDecls(decls core.InputDeclarations, inputs cty.Value) (cty
 		}
 	}
 	if len(undefinedInputs) > 0 {
-		return cty.NilVal, fmt.Errorf("inputs do not have a default value and were not provided: %s", strings.Join(undefinedInputs, ", "))
+		return cty.NilVal, fmt.Errorf("%w (they have no default value): %s", ErrMissingInputs, strings.Join(undefinedInputs, ", "))
 	}
 
 	return cty.ObjectVal(map[string]cty.Value{
@@ -1,10 +1,14 @@
 package common
 
 import (
+	"errors"
 	"testing"
 
+	"github.com/hashicorp/hcl/v2/hclparse"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/valocode/bubbly/api/core"
+	"github.com/valocode/bubbly/env"
 	"github.com/zclconf/go-cty/cty"
 )
 
@@ -15,6 +19,7 @@ func TestCompareInputs(t *testing.T) {
 		inputs        cty.Value
 		expectError   bool
 		expectedValue cty.Value
+		errMsg        string
 	}{
 		{
 			name: "basic test",
@@ -46,6 +51,19 @@ func TestCompareInputs(t *testing.T) {
 				}),
 			}),
 		},
+		{
+			name: "use null default test",
+			decls: core.InputDeclarations{
+				&core.InputDeclaration{Name: "input1", Default: cty.NullVal(cty.DynamicPseudoType)},
+			},
+			inputs:      cty.EmptyObjectVal,
+			expectError: false,
+			expectedValue: cty.ObjectVal(map[string]cty.Value{
+				"input": cty.ObjectVal(map[string]cty.Value{
+					"input1": cty.NullVal(cty.DynamicPseudoType),
+				}),
+			}),
+		},
 		{
 			name: "expect error",
 			decls: core.InputDeclarations{
@@ -55,6 +73,18 @@ func TestCompareInputs(t *testing.T) {
 			expectError:   true,
 			expectedValue: cty.NilVal,
 		},
+		{
+			name: "all missing inputs in error",
+			decls: core.InputDeclarations{
+				&core.InputDeclaration{Name: "input1"},
+				&core.InputDeclaration{Name: "input2", Default: cty.StringVal("empty")},
+				&core.InputDeclaration{Name: "input3"},
+			},
+			inputs:        cty.EmptyObjectVal,
+			expectError:   true,
+			expectedValue: cty.NilVal,
+			errMsg:        "missing required inputs (they have no default value): input1, input3",
+		},
 	}
 
 	for _, tt := range tests {
@@ -62,10 +92,65 @@ func TestCompareInputs(t *testing.T) {
 			retInputs, err := compareInputsWithDecls(tt.decls, tt.inputs)
 			assert.Equalf(t, tt.expectedValue, retInputs, "returned inputs did not match expected inputs")
 			if tt.expectError {
-				assert.Errorf(t, err, "expected an error but did not receive one")
+				require.Errorf(t, err, "expected an error but did not receive one")
+				assert.True(t, errors.Is(err, ErrMissingInputs))
+				if tt.errMsg != "" {
+					assert.Equal(t, tt.errMsg, err.Error())
+				}
 			} else {
 				assert.NoErrorf(t, err, "unexpected error")
 			}
 		})
 	}
 }
+
+// TestValidateResourceInputs checks the defaults of input declarations that
+// are decoded from HCL
+func TestValidateResourceInputs(t *testing.T) {
+	const src = `
+input "required" {}
+input "defaulted" {
+	default = "default"
+}
+input "optional" {
+	default = null
+}
+`
+	file, diags := hclparse.NewParser().ParseHCL([]byte(src), "testing")
+	require.Falsef(t, diags.HasErrors(), diags.Error())
+
+	t.Run("omitted inputs use defaults", func(t *testing.T) {
+		inputs := cty.ObjectVal(map[string]cty.Value{
+			"input": cty.ObjectVal(map[string]cty.Value{
+				"required": cty.StringVal("given"),
+			}),
+		})
+		retInputs, err := ValidateResourceInputs(env.NewBubblyContext(), file.Body, inputs)
+		require.NoError(t, err)
+		values := retInputs.GetAttr("input")
+		assert.Equal(t, cty.StringVal("given"), values.GetAttr("required"))
+		assert.Equal(t, cty.StringVal("default"), values.GetAttr("defaulted"))
+		assert.True(t, values.GetAttr("optional").IsNull())
+	})
+
+	t.Run("given inputs override defaults", func(t *testing.T) {
+		inputs := cty.ObjectVal(map[string]cty.Value{
+			"input": cty.ObjectVal(map[string]cty.Value{
+				"required":  cty.StringVal("given"),
+				"defaulted": cty.StringVal("given"),
+			}),
+		})
+		retInputs, err := ValidateResourceInputs(env.NewBubblyContext(), file.Body, inputs)
+		require.NoError(t, err)
+		assert.Equal(t, cty.StringVal("given"), retInputs.GetAttr("input").GetAttr("defaulted"))
+	})
+
+	t.Run("omitted required input", func(t *testing.T) {
+		_, err := ValidateResourceInputs(env.NewBubblyContext(), file.Body, cty.EmptyObjectVal)
+		require.Error(t, err)
+		assert.True(t, errors.Is(err, ErrMissingInputs))
+		assert.Contains(t, err.Error(), "required")
+		assert.NotContains(t, err.Error(), "defaulted")
+		assert.NotContains(t, err.Error(), "optional")
+	})
+}
@@ -28,6 +28,9 @@ func SubResourceContext(inputs cty.Value, ctx *ResourceContext) *ResourceContext
 		State:       make(ResourceState),
 		NewResource: ctx.NewResource,
 		Auth:        ctx.Auth,
+		RunID:       ctx.RunID,
+		Namespace:   ctx.Namespace,
+		Progress:    ctx.Progress,
 	}
 }
 
@@ -43,6 +46,16 @@ type ResourceContext struct {
 	State       ResourceState
 	NewResource NewResourceFn
 	Auth        *component.MessageAuth
+	// RunID identifies the run of a worker that the resource is applied in,
+	// if any, so that the data that the run loads is saved only once, also
+	// if loading it is retried
+	RunID string
+	// Namespace is the namespace of the resource being run, in which the
+	// resources that it references by kind/name are
+	Namespace string
+	// Progress is called with the progress of the resources reading their
+	// input, and is nil if progress is not reported
+	Progress ProgressFunc
 }
 
 type ResourceState map[string]cty.Value
@@ -50,6 +50,10 @@ const (
 	// ReferenceIfExistsPolicy is the same as ReferencePolicy but it does not
 	// error in case a reference does not exist
 	ReferenceIfExistsPolicy DataBlockPolicy = "reference_if_exists"
+	// DeletePolicy means delete the already saved data block with the matching
+	// field values, if there is one. If the store is configured with soft
+	// deletes, the data block is only marked as deleted
+	DeletePolicy DataBlockPolicy = "delete"
 )
 
 // DataFields contains a map of values that can be assigned to, e.g.
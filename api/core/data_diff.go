@@ -0,0 +1,184 @@
+package core
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+)
+
+// DataRow is a row of a table that is already stored, with the values of its
+// fields
+type DataRow map[string]cty.Value
+
+// DataDiff is the difference between data blocks for a table and the rows
+// already stored in that table, so that only the changes need to be saved
+type DataDiff struct {
+	// Inserts are the data blocks with no stored row
+	Inserts DataBlocks
+	// Updates are the data blocks whose fields differ from the stored row
+	Updates DataBlocks
+	// Unchanged are the data blocks whose fields are the same as the stored
+	// row. They might still have nested data blocks that need to be saved
+	Unchanged DataBlocks
+	// Deletes are the stored rows with no data block
+	Deletes []DataRow
+}
+
+// DiffDataBlocks compares the data blocks for a table with the rows that are
+// stored in the table. Data blocks and rows are matched by the values of the
+// table's unique fields. If more than one data block has the same unique
+// values, they are merged into one, with the later data block's fields taking
+// precedence.
+// Only the fields set in a data block are compared, so a data block is an
+// update if any of its fields differ from the stored row. Fields that cannot
+// be compared, such as references to other data blocks, are always treated as
+// changed
+func DiffDataBlocks(table string, unique []string, blocks DataBlocks, rows []DataRow) (*DataDiff, error) {
+	if len(unique) == 0 {
+		return nil, fmt.Errorf("cannot diff data for table %s without unique fields", table)
+	}
+
+	var (
+		diff = &DataDiff{}
+		// keys keeps the order of the data blocks
+		keys    []string
+		merged  = make(map[string]*Data)
+		rowKeys = make(map[string]DataRow, len(rows))
+	)
+	for idx := range blocks {
+		block := blocks[idx]
+		if block.TableName != table {
+			return nil, fmt.Errorf("cannot diff data block for table %s with data for table %s", block.TableName, table)
+		}
+		key, err := dataKey(unique, block.fieldValues())
+		if err != nil {
+			return nil, fmt.Errorf("cannot diff data block for table %s: %w", table, err)
+		}
+		existing, ok := merged[key]
+		if !ok {
+			keys = append(keys, key)
+			merged[key] = &block
+			continue
+		}
+		mergeData(existing, block)
+	}
+	for _, row := range rows {
+		key, err := dataKey(unique, row)
+		if err != nil {
+			return nil, fmt.Errorf("cannot diff stored row for table %s: %w", table, err)
+		}
+		rowKeys[key] = row
+	}
+
+	for _, key := range keys {
+		block := merged[key]
+		row, ok := rowKeys[key]
+		switch {
+		case !ok:
+			diff.Inserts = append(diff.Inserts, *block)
+		case dataChanged(block, row):
+			diff.Updates = append(diff.Updates, *block)
+		default:
+			diff.Unchanged = append(diff.Unchanged, *block)
+		}
+	}
+	for _, row := range rows {
+		// Errors have already been checked above
+		key, _ := dataKey(unique, row)
+		if _, ok := merged[key]; !ok {
+			diff.Deletes = append(diff.Deletes, row)
+		}
+	}
+	return diff, nil
+}
+
+// fieldValues returns the field values of the data block
+func (d *Data) fieldValues() map[string]cty.Value {
+	if d.Fields == nil {
+		return nil
+	}
+	return d.Fields.Values
+}
+
+// mergeData merges the fields, joins and nested data blocks of src into dst,
+// with the fields of src taking precedence
+func mergeData(dst *Data, src Data) {
+	values := make(map[string]cty.Value)
+	for name, val := range dst.fieldValues() {
+		values[name] = val
+	}
+	for name, val := range src.fieldValues() {
+		values[name] = val
+	}
+	dst.Fields = &DataFields{Values: values}
+
+	joins := make(map[string]struct{}, len(dst.Joins))
+	for _, join := range dst.Joins {
+		joins[join] = struct{}{}
+	}
+	for _, join := range src.Joins {
+		if _, ok := joins[join]; !ok {
+			dst.Joins = append(dst.Joins, join)
+		}
+	}
+	if src.Policy != EmptyPolicy {
+		dst.Policy = src.Policy
+	}
+	dst.Data = append(dst.Data, src.Data...)
+}
+
+// dataKey returns a key for the values of the unique fields, which is equal
+// for equal values
+func dataKey(unique []string, values map[string]cty.Value) (string, error) {
+	parts := make([]string, 0, len(unique))
+	for _, name := range unique {
+		val, ok := values[name]
+		if !ok {
+			return "", fmt.Errorf("missing unique field %s", name)
+		}
+		if !val.IsWhollyKnown() || val.Type().IsCapsuleType() {
+			return "", fmt.Errorf("unique field %s does not have a known value", name)
+		}
+		b, err := ctyjson.SimpleJSONValue{Value: val}.MarshalJSON()
+		if err != nil {
+			return "", fmt.Errorf("invalid value for unique field %s: %w", name, err)
+		}
+		parts = append(parts, string(b))
+	}
+	return strings.Join(parts, "\x00"), nil
+}
+
+// dataChanged returns whether any of the fields of the data block differ from
+// the stored row
+func dataChanged(block *Data, row DataRow) bool {
+	for name, val := range block.fieldValues() {
+		stored, ok := row[name]
+		if !ok {
+			return true
+		}
+		if !valuesEqual(val, stored) {
+			return true
+		}
+	}
+	return false
+}
+
+// valuesEqual returns whether the value of a data block field is equal to the
+// stored value. If they cannot be compared, they are not equal
+func valuesEqual(val cty.Value, stored cty.Value) bool {
+	if val.Type().IsCapsuleType() || !val.IsWhollyKnown() || !stored.IsWhollyKnown() {
+		return false
+	}
+	if !stored.Type().Equals(val.Type()) {
+		var err error
+		stored, err = convert.Convert(stored, val.Type())
+		if err != nil {
+			return false
+		}
+	}
+	eq := val.Equals(stored)
+	return eq.IsKnown() && eq.True()
+}
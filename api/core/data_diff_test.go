@@ -0,0 +1,171 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/valocode/bubbly/parser"
+	"github.com/zclconf/go-cty/cty"
+)
+
+func testDataBlock(name string, version int64) Data {
+	return Data{
+		TableName: "release",
+		Fields: &DataFields{Values: map[string]cty.Value{
+			"name":    cty.StringVal(name),
+			"version": cty.NumberIntVal(version),
+		}},
+	}
+}
+
+func testDataRow(name string, version int64) DataRow {
+	return DataRow{
+		"name":    cty.StringVal(name),
+		"version": cty.NumberIntVal(version),
+	}
+}
+
+func TestDiffDataBlocks(t *testing.T) {
+	tcs := []struct {
+		desc      string
+		blocks    DataBlocks
+		rows      []DataRow
+		inserts   DataBlocks
+		updates   DataBlocks
+		unchanged DataBlocks
+		deletes   []DataRow
+	}{
+		{
+			desc:    "added row",
+			blocks:  DataBlocks{testDataBlock("a", 1)},
+			inserts: DataBlocks{testDataBlock("a", 1)},
+		},
+		{
+			desc:    "changed row",
+			blocks:  DataBlocks{testDataBlock("a", 2)},
+			rows:    []DataRow{testDataRow("a", 1)},
+			updates: DataBlocks{testDataBlock("a", 2)},
+		},
+		{
+			desc:      "unchanged row",
+			blocks:    DataBlocks{testDataBlock("a", 1)},
+			rows:      []DataRow{testDataRow("a", 1)},
+			unchanged: DataBlocks{testDataBlock("a", 1)},
+		},
+		{
+			desc:    "removed row",
+			rows:    []DataRow{testDataRow("a", 1)},
+			deletes: []DataRow{testDataRow("a", 1)},
+		},
+		{
+			desc:   "stored value of a different type",
+			blocks: DataBlocks{testDataBlock("a", 1)},
+			rows: []DataRow{{
+				"name":    cty.StringVal("a"),
+				"version": cty.StringVal("1"),
+			}},
+			unchanged: DataBlocks{testDataBlock("a", 1)},
+		},
+		{
+			desc: "reference is always changed",
+			blocks: DataBlocks{{
+				TableName: "release",
+				Fields: &DataFields{Values: map[string]cty.Value{
+					"name": cty.StringVal("a"),
+					"project_id": cty.CapsuleVal(parser.DataRefType, &parser.DataRef{
+						TableName: "project", Field: "id",
+					}),
+				}},
+			}},
+			rows: []DataRow{{
+				"name":       cty.StringVal("a"),
+				"project_id": cty.NumberIntVal(1),
+			}},
+			updates: DataBlocks{{
+				TableName: "release",
+				Fields: &DataFields{Values: map[string]cty.Value{
+					"name": cty.StringVal("a"),
+					"project_id": cty.CapsuleVal(parser.DataRefType, &parser.DataRef{
+						TableName: "project", Field: "id",
+					}),
+				}},
+			}},
+		},
+		{
+			desc:      "duplicate blocks are merged",
+			blocks:    DataBlocks{testDataBlock("a", 1), testDataBlock("a", 2)},
+			rows:      []DataRow{testDataRow("a", 2)},
+			unchanged: DataBlocks{testDataBlock("a", 2)},
+		},
+		{
+			desc: "all changes",
+			blocks: DataBlocks{
+				testDataBlock("added", 1),
+				testDataBlock("changed", 2),
+				testDataBlock("unchanged", 1),
+			},
+			rows: []DataRow{
+				testDataRow("changed", 1),
+				testDataRow("unchanged", 1),
+				testDataRow("removed", 1),
+			},
+			inserts:   DataBlocks{testDataBlock("added", 1)},
+			updates:   DataBlocks{testDataBlock("changed", 2)},
+			unchanged: DataBlocks{testDataBlock("unchanged", 1)},
+			deletes:   []DataRow{testDataRow("removed", 1)},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.desc, func(t *testing.T) {
+			diff, err := DiffDataBlocks("release", []string{"name"}, tc.blocks, tc.rows)
+			require.NoError(t, err)
+			assert.Equal(t, tc.inserts, diff.Inserts, "inserts")
+			assert.Equal(t, tc.updates, diff.Updates, "updates")
+			assert.Equal(t, tc.unchanged, diff.Unchanged, "unchanged")
+			assert.Equal(t, tc.deletes, diff.Deletes, "deletes")
+		})
+	}
+}
+
+func TestDiffDataBlocksErrors(t *testing.T) {
+	tcs := []struct {
+		desc   string
+		unique []string
+		blocks DataBlocks
+		rows   []DataRow
+	}{
+		{
+			desc:   "no unique fields",
+			blocks: DataBlocks{testDataBlock("a", 1)},
+		},
+		{
+			desc:   "different table",
+			unique: []string{"name"},
+			blocks: DataBlocks{{TableName: "project"}},
+		},
+		{
+			desc:   "missing unique field in block",
+			unique: []string{"tag"},
+			blocks: DataBlocks{testDataBlock("a", 1)},
+		},
+		{
+			desc:   "missing unique field in row",
+			unique: []string{"name"},
+			rows:   []DataRow{{"version": cty.NumberIntVal(1)}},
+		},
+		{
+			desc:   "unknown unique field",
+			unique: []string{"name"},
+			rows:   []DataRow{{"name": cty.UnknownVal(cty.String)}},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.desc, func(t *testing.T) {
+			_, err := DiffDataBlocks("release", tc.unique, tc.blocks, tc.rows)
+			assert.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,123 @@
+package core
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
+
+	"github.com/valocode/bubbly/parser"
+)
+
+// DataFieldError is an error in a data block that does not conform to the
+// schema, such as a field that does not exist in the data block's table
+type DataFieldError struct {
+	// Path is the location of the error in the data blocks, such as
+	// "data[0].data[1].fields.name"
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+func (e DataFieldError) Error() string {
+	return e.Path + ": " + e.Message
+}
+
+// ValidateData checks that the data blocks conform to the schema tables,
+// which are given by name. The tables of the data blocks, their fields and
+// their joins must exist in the schema, and the values of the fields must be
+// convertible to the types of the fields. Values that reference other data
+// blocks are resolved when the data is saved, so only their field is checked.
+// It returns an error for each field that does not conform to the schema, or
+// none if all the data blocks are valid
+func ValidateData(tables map[string]Table, data DataBlocks) []DataFieldError {
+	return validateDataBlocks(tables, data, nil, "data")
+}
+
+func validateDataBlocks(tables map[string]Table, data DataBlocks, parent *Data, path string) []DataFieldError {
+	var errs []DataFieldError
+	for idx := range data {
+		d := &data[idx]
+		dPath := fmt.Sprintf("%s[%d]", path, idx)
+		table, ok := tables[d.TableName]
+		if !ok {
+			errs = append(errs, DataFieldError{
+				Path:    dPath + ".table",
+				Message: fmt.Sprintf("table %q does not exist", d.TableName),
+			})
+			continue
+		}
+
+		joins := append([]string{}, d.Joins...)
+		// Nested data blocks are implicitly joined to their parent
+		if parent != nil && !d.IgnoreNesting {
+			joins = append(joins, parent.TableName)
+		}
+		for _, join := range joins {
+			if !dataHasJoin(table, join) {
+				errs = append(errs, DataFieldError{
+					Path:    dPath + ".joins",
+					Message: fmt.Sprintf("table %q has no join to table %q", table.Name, join),
+				})
+			}
+		}
+
+		if d.Fields != nil {
+			// Sort the fields so that the errors are in a stable order
+			names := make([]string, 0, len(d.Fields.Values))
+			for name := range d.Fields.Values {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			for _, name := range names {
+				if msg := validateDataField(table, name, d.Fields.Values[name]); msg != "" {
+					errs = append(errs, DataFieldError{
+						Path:    dPath + ".fields." + name,
+						Message: msg,
+					})
+				}
+			}
+		}
+
+		errs = append(errs, validateDataBlocks(tables, d.Data, d, dPath+".data")...)
+	}
+	return errs
+}
+
+// validateDataField returns why a field of a data block does not conform to
+// its table, or an empty string if it does
+func validateDataField(table Table, name string, val cty.Value) string {
+	var field *TableField
+	for idx := range table.Fields {
+		if table.Fields[idx].Name == name {
+			field = &table.Fields[idx]
+			break
+		}
+	}
+	if field == nil {
+		// The foreign key of a join can also be given as a field
+		for _, join := range table.Joins {
+			if join.Table+"_id" == name {
+				return ""
+			}
+		}
+		return fmt.Sprintf("field does not exist in table %q", table.Name)
+	}
+	if val.IsNull() || !val.IsKnown() || val.Type() == parser.DataRefType {
+		return ""
+	}
+	if _, err := convert.Convert(val, field.Type); err != nil {
+		return fmt.Sprintf("expected value of type %s, got %s", field.Type.FriendlyName(), val.Type().FriendlyName())
+	}
+	return ""
+}
+
+// dataHasJoin returns whether the table has a join to the other table
+func dataHasJoin(table Table, other string) bool {
+	for _, join := range table.Joins {
+		if join.Table == other {
+			return true
+		}
+	}
+	return false
+}
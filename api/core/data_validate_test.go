@@ -0,0 +1,118 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/valocode/bubbly/parser"
+)
+
+func TestValidateData(t *testing.T) {
+	tables := map[string]Table{
+		"product": {
+			Name: "product",
+			Fields: []TableField{
+				{Name: "name", Type: cty.String},
+			},
+		},
+		"release": {
+			Name: "release",
+			Fields: []TableField{
+				{Name: "version", Type: cty.String},
+				{Name: "count", Type: cty.Number},
+			},
+			Joins: []TableJoin{{Table: "product"}},
+		},
+	}
+	tcs := []struct {
+		desc     string
+		data     DataBlocks
+		expected []DataFieldError
+	}{
+		{
+			desc: "valid nested data",
+			data: DataBlocks{
+				{
+					TableName: "product",
+					Fields:    &DataFields{Values: map[string]cty.Value{"name": cty.StringVal("bubbly")}},
+					Data: DataBlocks{
+						{
+							TableName: "release",
+							Fields: &DataFields{Values: map[string]cty.Value{
+								"version": cty.StringVal("v1"),
+								// Numbers as strings can be converted
+								"count": cty.StringVal("1"),
+							}},
+						},
+					},
+				},
+			},
+		},
+		{
+			desc: "valid join field and references",
+			data: DataBlocks{
+				{
+					TableName: "release",
+					Fields: &DataFields{Values: map[string]cty.Value{
+						"version":    cty.CapsuleVal(parser.DataRefType, &parser.DataRef{TableName: "other", Field: "version"}),
+						"count":      cty.NullVal(cty.Number),
+						"product_id": cty.CapsuleVal(parser.DataRefType, &parser.DataRef{TableName: "product", Field: "_id"}),
+					}},
+				},
+			},
+		},
+		{
+			desc: "unknown table",
+			data: DataBlocks{
+				{TableName: "artifact"},
+			},
+			expected: []DataFieldError{
+				{Path: "data[0].table", Message: `table "artifact" does not exist`},
+			},
+		},
+		{
+			desc: "unknown fields and wrong types",
+			data: DataBlocks{
+				{
+					TableName: "release",
+					Fields: &DataFields{Values: map[string]cty.Value{
+						"version": cty.StringVal("v1"),
+						"count":   cty.True,
+						"date":    cty.StringVal("today"),
+					}},
+				},
+			},
+			expected: []DataFieldError{
+				{Path: "data[0].fields.count", Message: "expected value of type number, got bool"},
+				{Path: "data[0].fields.date", Message: `field does not exist in table "release"`},
+			},
+		},
+		{
+			desc: "unknown joins",
+			data: DataBlocks{
+				{
+					TableName: "release",
+					Data: DataBlocks{
+						{TableName: "product"},
+						{TableName: "product", IgnoreNesting: true},
+					},
+				},
+				{
+					TableName: "product",
+					Joins:     []string{"release"},
+				},
+			},
+			expected: []DataFieldError{
+				{Path: "data[0].data[0].joins", Message: `table "product" has no join to table "release"`},
+				{Path: "data[1].joins", Message: `table "product" has no join to table "release"`},
+			},
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.desc, func(t *testing.T) {
+			assert.Equal(t, tc.expected, ValidateData(tables, tc.data))
+		})
+	}
+}
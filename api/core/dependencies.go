@@ -0,0 +1,101 @@
+package core
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// resourceRefAttr is the name of the attribute used by resources (e.g. run
+// resources and pipeline tasks) to reference other resources by ID
+const resourceRefAttr = "resource"
+
+// Dependencies returns the IDs of the resources that this resource references
+// in its spec, in the order they appear. Only references which are known
+// before decoding (i.e. string literals) are returned. References to kind/name
+// are to resources in the namespace of this resource
+func (r ResourceBlock) Dependencies() []string {
+	body, ok := r.SpecHCL.Body.(*hclsyntax.Body)
+	if !ok {
+		return nil
+	}
+	var (
+		deps []string
+		seen = make(map[string]struct{})
+	)
+	bodyDependencies(body, r.Namespace(), &deps, seen)
+	return deps
+}
+
+// bodyDependencies recursively walks the body and its blocks and adds any
+// resource references to deps
+func bodyDependencies(body *hclsyntax.Body, namespace string, deps *[]string, seen map[string]struct{}) {
+	if attr, ok := body.Attributes[resourceRefAttr]; ok {
+		val, diags := attr.Expr.Value(nil)
+		// If the value cannot be evaluated without a context then it depends
+		// on inputs and cannot be known yet, so ignore it
+		if !diags.HasErrors() && val.Type() == cty.String && val.IsKnown() && !val.IsNull() {
+			id := ResourceIDInNamespace(namespace, val.AsString())
+			if _, ok := seen[id]; !ok {
+				seen[id] = struct{}{}
+				*deps = append(*deps, id)
+			}
+		}
+	}
+	for _, block := range body.Blocks {
+		bodyDependencies(block.Body, namespace, deps, seen)
+	}
+}
+
+// SortResourceBlocks returns the resource blocks sorted topologically based on
+// their dependencies, so that a resource always comes after the resources it
+// depends on. Dependencies on resources that are not in blocks are ignored, as
+// those are expected to already exist. The order of the given blocks is kept
+// where there are no dependencies between them.
+// An error is returned if there is a dependency cycle.
+func SortResourceBlocks(blocks ResourceBlocks) (ResourceBlocks, error) {
+	var (
+		index  = make(map[string]*ResourceBlock, len(blocks))
+		sorted = make(ResourceBlocks, 0, len(blocks))
+		// visited stores the state of each resource when visiting, with false
+		// meaning the resource is being visited and true meaning it is done
+		visited = make(map[string]bool, len(blocks))
+		visit   func(block *ResourceBlock, path []string) error
+	)
+	for _, block := range blocks {
+		index[block.ID()] = block
+	}
+
+	visit = func(block *ResourceBlock, path []string) error {
+		id := block.ID()
+		path = append(path, id)
+		if done, ok := visited[id]; ok {
+			if !done {
+				return fmt.Errorf("dependency cycle detected between resources: %s", strings.Join(path, " -> "))
+			}
+			return nil
+		}
+		visited[id] = false
+		for _, dep := range block.Dependencies() {
+			depBlock, ok := index[dep]
+			if !ok {
+				continue
+			}
+			if err := visit(depBlock, path); err != nil {
+				return err
+			}
+		}
+		visited[id] = true
+		sorted = append(sorted, block)
+		return nil
+	}
+
+	for _, block := range blocks {
+		if err := visit(block, nil); err != nil {
+			return nil, err
+		}
+	}
+	return sorted, nil
+}
@@ -0,0 +1,122 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/valocode/bubbly/env"
+	"github.com/valocode/bubbly/parser"
+)
+
+type testResourceBlocksWrapper struct {
+	ResourceBlocks ResourceBlocks `hcl:"resource,block"`
+}
+
+func TestSortResourceBlocks(t *testing.T) {
+	tcs := []struct {
+		desc     string
+		src      string
+		expected []string
+		err      string
+	}{
+		{
+			desc: "dependency chain",
+			src: `
+resource "run" "b" {
+	spec {
+		resource = "pipeline/a"
+	}
+}
+resource "pipeline" "a" {
+	spec {
+		task "extract" {
+			resource = "extract/existing"
+		}
+	}
+}
+`,
+			expected: []string{"pipeline/a", "run/b"},
+		},
+		{
+			desc: "nested task dependency",
+			src: `
+resource "pipeline" "b" {
+	spec {
+		task "extract" {
+			resource = "extract/a"
+		}
+	}
+}
+resource "extract" "a" {
+	spec {
+		type = "json"
+	}
+}
+`,
+			expected: []string{"extract/a", "pipeline/b"},
+		},
+		{
+			desc: "namespace",
+			src: `
+resource "run" "b" {
+	metadata {
+		namespace = "team-a"
+	}
+	spec {
+		resource = "pipeline/a"
+	}
+}
+resource "pipeline" "a" {
+	spec {}
+}
+resource "pipeline" "a" {
+	metadata {
+		namespace = "team-a"
+	}
+	spec {}
+}
+`,
+			// The run depends on the pipeline in its own namespace, and the
+			// pipeline in the default namespace keeps its place
+			expected: []string{"team-a/pipeline/a", "team-a/run/b", "pipeline/a"},
+		},
+		{
+			desc: "dependency cycle",
+			src: `
+resource "run" "a" {
+	spec {
+		resource = "run/b"
+	}
+}
+resource "run" "b" {
+	spec {
+		resource = "run/a"
+	}
+}
+`,
+			err: "dependency cycle detected between resources: run/a -> run/b -> run/a",
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.desc, func(t *testing.T) {
+			var wrapper testResourceBlocksWrapper
+			err := parser.ParseBytes(env.NewBubblyContext(), "test.bubbly", []byte(tc.src), &wrapper)
+			require.NoError(t, err)
+
+			sorted, err := SortResourceBlocks(wrapper.ResourceBlocks)
+			if tc.err != "" {
+				require.Error(t, err)
+				assert.Equal(t, tc.err, err.Error())
+				return
+			}
+			require.NoError(t, err)
+			var ids []string
+			for _, block := range sorted {
+				ids = append(ids, block.ID())
+			}
+			assert.Equal(t, tc.expected, ids)
+		})
+	}
+}
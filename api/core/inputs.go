@@ -1,7 +1,13 @@
 package core
 
 import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
 	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
 	"github.com/zclconf/go-cty/cty"
 )
 
@@ -31,6 +37,14 @@ type InputDeclaration struct {
 	Type        cty.Type  `hcl:"type,optional"`
 }
 
+// HasDefault returns whether the input declaration has a default value, which
+// makes the input optional. A default of null is still a default, so that an
+// input can be optional without having a value
+func (i *InputDeclaration) HasDefault() bool {
+	// The default is cty.NilVal if the attribute is omitted
+	return i.Default.Type() != cty.NilType
+}
+
 // InputDefinitions is a wrapper for a slice of InputDefinition
 type InputDefinitions []*InputDefinition
 
@@ -48,6 +62,133 @@ func (i *InputDefinitions) Value() cty.Value {
 	)
 }
 
+// numberRegexp matches the input values that are parsed as numbers by
+// ParseInputValues
+var numberRegexp = regexp.MustCompile(`^-?[0-9]+(\.[0-9]+)?([eE][-+]?[0-9]+)?$`)
+
+// ParseInputValues parses input values given as key=value assignments, e.g.
+// with the --set flag of the CLI, into a cty.Value like the one returned by
+// InputDefinitions.Value. A dotted key, such as repo.branch=main, sets the
+// attribute of an object input. Values that are numbers or bools are parsed as
+// such, and other values are strings. A value in double quotes is always a
+// string, e.g. version="1.0". If a key is given more than once, the last value
+// is used
+func ParseInputValues(assignments []string) (cty.Value, error) {
+	inputs := make(map[string]interface{})
+	for _, assignment := range assignments {
+		key, rawVal, ok := splitInputAssignment(assignment)
+		if !ok {
+			return cty.NilVal, fmt.Errorf(`invalid input value "%s": expected key=value`, assignment)
+		}
+		path := strings.Split(key, ".")
+		for _, name := range path {
+			if !hclsyntax.ValidIdentifier(name) {
+				return cty.NilVal, fmt.Errorf(`invalid input value "%s": "%s" is not a valid input name`, assignment, name)
+			}
+		}
+		val, err := parseInputValue(rawVal)
+		if err != nil {
+			return cty.NilVal, fmt.Errorf(`invalid input value "%s": %w`, assignment, err)
+		}
+		// Create the objects for the dotted key, and set the value
+		obj := inputs
+		for idx, name := range path[:len(path)-1] {
+			switch attr := obj[name].(type) {
+			case map[string]interface{}:
+				obj = attr
+			case nil:
+				nested := make(map[string]interface{})
+				obj[name] = nested
+				obj = nested
+			default:
+				return cty.NilVal, fmt.Errorf(`invalid input value "%s": input %s is not an object`, assignment, strings.Join(path[:idx+1], "."))
+			}
+		}
+		obj[path[len(path)-1]] = val
+	}
+	return cty.ObjectVal(map[string]cty.Value{
+		"input": inputValuesObject(inputs),
+	}), nil
+}
+
+// splitInputAssignment splits a key=value assignment into the key and value
+func splitInputAssignment(assignment string) (string, string, bool) {
+	idx := strings.Index(assignment, "=")
+	if idx <= 0 {
+		return "", "", false
+	}
+	return assignment[:idx], assignment[idx+1:], true
+}
+
+// parseInputValue parses the value of a key=value assignment
+func parseInputValue(rawVal string) (cty.Value, error) {
+	switch {
+	case len(rawVal) >= 2 && strings.HasPrefix(rawVal, `"`) && strings.HasSuffix(rawVal, `"`):
+		str, err := strconv.Unquote(rawVal)
+		if err != nil {
+			return cty.NilVal, fmt.Errorf("invalid quoted string: %w", err)
+		}
+		return cty.StringVal(str), nil
+	case rawVal == "true":
+		return cty.True, nil
+	case rawVal == "false":
+		return cty.False, nil
+	case numberRegexp.MatchString(rawVal):
+		return cty.ParseNumberVal(rawVal)
+	default:
+		return cty.StringVal(rawVal), nil
+	}
+}
+
+// inputValuesObject converts the parsed input values into an object
+func inputValuesObject(inputs map[string]interface{}) cty.Value {
+	vals := make(map[string]cty.Value, len(inputs))
+	for name, val := range inputs {
+		switch v := val.(type) {
+		case map[string]interface{}:
+			vals[name] = inputValuesObject(v)
+		case cty.Value:
+			vals[name] = v
+		}
+	}
+	return cty.ObjectVal(vals)
+}
+
+// MergeInputs returns the inputs with the values of the overrides, where both
+// are values like the one returned by InputDefinitions.Value. Objects are
+// merged, so that overriding an attribute of an object input keeps the other
+// attributes of the object
+func MergeInputs(inputs cty.Value, overrides cty.Value) cty.Value {
+	if overrides == cty.NilVal {
+		return inputs
+	}
+	if inputs == cty.NilVal {
+		return overrides
+	}
+	return mergeInputObjects(inputs, overrides)
+}
+
+func mergeInputObjects(val cty.Value, override cty.Value) cty.Value {
+	isObject := func(v cty.Value) bool {
+		return v.Type().IsObjectType() && v.IsKnown() && !v.IsNull()
+	}
+	if !isObject(val) || !isObject(override) {
+		return override
+	}
+	vals := val.AsValueMap()
+	if vals == nil {
+		vals = make(map[string]cty.Value)
+	}
+	for name, attr := range override.AsValueMap() {
+		if existing, ok := vals[name]; ok {
+			vals[name] = mergeInputObjects(existing, attr)
+			continue
+		}
+		vals[name] = attr
+	}
+	return cty.ObjectVal(vals)
+}
+
 // InputDefinition is the type representing any "input {...}" definition
 // blocks in HCL
 type InputDefinition struct {
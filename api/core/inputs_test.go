@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/zclconf/go-cty/cty"
 )
@@ -46,3 +47,122 @@ func TestValue(t *testing.T) {
 		})
 	}
 }
+
+func TestParseInputValues(t *testing.T) {
+	tcs := []struct {
+		desc        string
+		assignments []string
+		expected    map[string]cty.Value
+		wantErr     bool
+	}{
+		{
+			desc:        "typed values",
+			assignments: []string{"name=bubbly", "retries=3", "ratio=-0.5", "enabled=true", "verbose=false"},
+			expected: map[string]cty.Value{
+				"name":    cty.StringVal("bubbly"),
+				"retries": cty.NumberIntVal(3),
+				"ratio":   cty.NumberFloatVal(-0.5),
+				"enabled": cty.True,
+				"verbose": cty.False,
+			},
+		},
+		{
+			desc:        "strings",
+			assignments: []string{`version="1.0"`, `flag="true"`, "url=https://bubbly.dev/?a=b", "empty=", "inf=Inf"},
+			expected: map[string]cty.Value{
+				"version": cty.StringVal("1.0"),
+				"flag":    cty.StringVal("true"),
+				"url":     cty.StringVal("https://bubbly.dev/?a=b"),
+				"empty":   cty.StringVal(""),
+				"inf":     cty.StringVal("Inf"),
+			},
+		},
+		{
+			desc:        "dotted keys",
+			assignments: []string{"repo.url=github.com/valocode/bubbly", "repo.ref.branch=main", "name=bubbly"},
+			expected: map[string]cty.Value{
+				"repo": cty.ObjectVal(map[string]cty.Value{
+					"url": cty.StringVal("github.com/valocode/bubbly"),
+					"ref": cty.ObjectVal(map[string]cty.Value{
+						"branch": cty.StringVal("main"),
+					}),
+				}),
+				"name": cty.StringVal("bubbly"),
+			},
+		},
+		{
+			desc:        "last value is used",
+			assignments: []string{"name=a", "name=b"},
+			expected:    map[string]cty.Value{"name": cty.StringVal("b")},
+		},
+		{
+			desc:        "no value",
+			assignments: []string{"name"},
+			wantErr:     true,
+		},
+		{
+			desc:        "no key",
+			assignments: []string{"=value"},
+			wantErr:     true,
+		},
+		{
+			desc:        "invalid name",
+			assignments: []string{"repo..url=value"},
+			wantErr:     true,
+		},
+		{
+			desc:        "attribute of a value that is not an object",
+			assignments: []string{"repo=bubbly", "repo.url=value"},
+			wantErr:     true,
+		},
+		{
+			desc:        "invalid quoted string",
+			assignments: []string{`name="a"b"`},
+			wantErr:     true,
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.desc, func(t *testing.T) {
+			actual, err := ParseInputValues(tc.assignments)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			expected := cty.ObjectVal(map[string]cty.Value{
+				"input": cty.ObjectVal(tc.expected),
+			})
+			assert.True(t, expected.Equals(actual).True(), "expected %s, got %s", expected.GoString(), actual.GoString())
+		})
+	}
+}
+
+func TestMergeInputs(t *testing.T) {
+	inputs := InputDefinitions{
+		{Name: "name", Value: cty.StringVal("bubbly")},
+		{Name: "retries", Value: cty.NumberIntVal(1)},
+		{Name: "repo", Value: cty.ObjectVal(map[string]cty.Value{
+			"url":    cty.StringVal("github.com/valocode/bubbly"),
+			"branch": cty.StringVal("main"),
+		})},
+	}
+	overrides, err := ParseInputValues([]string{"retries=3", "repo.branch=dev", "extra=true"})
+	require.NoError(t, err)
+
+	expected := cty.ObjectVal(map[string]cty.Value{
+		"input": cty.ObjectVal(map[string]cty.Value{
+			"name":    cty.StringVal("bubbly"),
+			"retries": cty.NumberIntVal(3),
+			"repo": cty.ObjectVal(map[string]cty.Value{
+				"url":    cty.StringVal("github.com/valocode/bubbly"),
+				"branch": cty.StringVal("dev"),
+			}),
+			"extra": cty.True,
+		}),
+	})
+	actual := MergeInputs(inputs.Value(), overrides)
+	assert.True(t, expected.Equals(actual).True(), "expected %s, got %s", expected.GoString(), actual.GoString())
+
+	// Without overrides the inputs are unchanged
+	assert.Equal(t, inputs.Value(), MergeInputs(inputs.Value(), cty.NilVal))
+}
@@ -11,6 +11,8 @@ type Resource interface {
 	// Kind returns the ResourceKind
 	Kind() ResourceKind
 	APIVersion() APIVersion
+	// Namespace returns the namespace of the resource
+	Namespace() string
 	ID() string
 	// Return a string representation of the resource, mainly for diagnostics
 	String() string
@@ -19,6 +21,13 @@ type Resource interface {
 	Data() (Data, error)
 }
 
+// Validator is implemented by resources whose spec can be validated without
+// running the resource, i.e. without any inputs or a bubbly server
+type Validator interface {
+	// Validate decodes the spec of the resource and returns any errors
+	Validate(*env.BubblyContext) error
+}
+
 type SubResource interface {
 	// Run is the method called when a Resource (or SubResource) is run.
 	// This can be considered the "main" function or entrypoint for a resource
@@ -0,0 +1,47 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// MergePatch applies a JSON Merge Patch (RFC 7386) to a JSON document and
+// returns the patched document.
+// The members of objects in the patch are merged into the document
+// recursively, members that are null in the patch are removed from the
+// document, and any other value in the patch replaces the value in the
+// document
+func MergePatch(doc []byte, patch []byte) ([]byte, error) {
+	var target, patchVal interface{}
+	if err := json.Unmarshal(doc, &target); err != nil {
+		return nil, fmt.Errorf("failed to decode the document to patch: %w", err)
+	}
+	if err := json.Unmarshal(patch, &patchVal); err != nil {
+		return nil, fmt.Errorf("failed to decode the merge patch: %w", err)
+	}
+	patched, err := json.Marshal(mergePatch(target, patchVal))
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode the patched document: %w", err)
+	}
+	return patched, nil
+}
+
+// mergePatch implements the MergePatch function of RFC 7386 on decoded JSON
+func mergePatch(target interface{}, patch interface{}) interface{} {
+	patchObj, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+	targetObj, ok := target.(map[string]interface{})
+	if !ok {
+		targetObj = make(map[string]interface{}, len(patchObj))
+	}
+	for name, value := range patchObj {
+		if value == nil {
+			delete(targetObj, name)
+			continue
+		}
+		targetObj[name] = mergePatch(targetObj[name], value)
+	}
+	return targetObj
+}
@@ -0,0 +1,47 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// tests MergePatch with the examples from RFC 7386
+func TestMergePatch(t *testing.T) {
+	tcs := []struct {
+		desc     string
+		doc      string
+		patch    string
+		expected string
+	}{
+		{desc: "replace member", doc: `{"a":"b"}`, patch: `{"a":"c"}`, expected: `{"a":"c"}`},
+		{desc: "add member", doc: `{"a":"b"}`, patch: `{"b":"c"}`, expected: `{"a":"b","b":"c"}`},
+		{desc: "remove member", doc: `{"a":"b"}`, patch: `{"a":null}`, expected: `{}`},
+		{desc: "remove one of members", doc: `{"a":"b","b":"c"}`, patch: `{"a":null}`, expected: `{"b":"c"}`},
+		{desc: "replace array", doc: `{"a":["b"]}`, patch: `{"a":"c"}`, expected: `{"a":"c"}`},
+		{desc: "replace with array", doc: `{"a":"c"}`, patch: `{"a":["b"]}`, expected: `{"a":["b"]}`},
+		{desc: "nested object", doc: `{"a":{"b":"c"}}`, patch: `{"a":{"b":"d","c":null}}`, expected: `{"a":{"b":"d"}}`},
+		{desc: "arrays are not merged", doc: `{"a":[{"b":"c"}]}`, patch: `{"a":[1]}`, expected: `{"a":[1]}`},
+		{desc: "replace array document", doc: `["a","b"]`, patch: `["c","d"]`, expected: `["c","d"]`},
+		{desc: "object patch on array", doc: `{"a":"b"}`, patch: `["c"]`, expected: `["c"]`},
+		{desc: "null patch", doc: `{"a":"foo"}`, patch: `null`, expected: `null`},
+		{desc: "string patch", doc: `{"a":"foo"}`, patch: `"bar"`, expected: `"bar"`},
+		{desc: "null in document kept", doc: `{"e":null}`, patch: `{"a":1}`, expected: `{"a":1,"e":null}`},
+		{desc: "patch non-object member", doc: `[1,2]`, patch: `{"a":"b","c":null}`, expected: `{"a":"b"}`},
+		{desc: "nested null ignored", doc: `{}`, patch: `{"a":{"bb":{"ccc":null}}}`, expected: `{"a":{"bb":{}}}`},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.desc, func(t *testing.T) {
+			patched, err := MergePatch([]byte(tc.doc), []byte(tc.patch))
+			require.NoError(t, err)
+			assert.JSONEq(t, tc.expected, string(patched))
+		})
+	}
+
+	t.Run("invalid patch", func(t *testing.T) {
+		_, err := MergePatch([]byte(`{"a":"b"}`), []byte(`{"a":`))
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,24 @@
+package core
+
+// Progress is the progress of a resource reading its input, such as an
+// extract reading a (large) file, which is reported while the input is read
+type Progress struct {
+	// Resource is the ID of the resource reading the input
+	Resource string
+	// Source identifies the input, e.g. its filename
+	Source string
+	// BytesRead is the number of bytes of the input read so far
+	BytesRead int64
+	// TotalBytes is the size of the input, or -1 if it is not known
+	TotalBytes int64
+	// Rows is the number of rows parsed from the input, which is only known
+	// once the input has been read, i.e. when Done is true
+	Rows int
+	// Done is whether the whole input has been read and parsed
+	Done bool
+}
+
+// ProgressFunc is called with the progress of a resource reading its input.
+// It is called from the goroutine running the resource, and should return
+// quickly, e.g. by only updating a progress bar
+type ProgressFunc func(Progress)
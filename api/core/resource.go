@@ -6,12 +6,15 @@ import (
 	"fmt"
 	"os"
 	"reflect"
+	"strings"
 
 	"github.com/hashicorp/hcl/v2"
 	"github.com/hashicorp/hcl/v2/hclsyntax"
 	"github.com/zclconf/go-cty/cty"
 	"github.com/zclconf/go-cty/cty/gocty"
+	"gopkg.in/yaml.v2"
 
+	"github.com/valocode/bubbly/config"
 	"github.com/valocode/bubbly/env"
 	"github.com/valocode/bubbly/parser"
 )
@@ -53,8 +56,26 @@ func (r ResourceBlock) Name() string {
 	return r.ResourceName
 }
 
+// Namespace returns the namespace of the resource, which is DefaultNamespace
+// unless the metadata of the resource gives one
+func (r ResourceBlock) Namespace() string {
+	if md := r.Metadata; md != nil && md.Namespace != "" {
+		return md.Namespace
+	}
+	return DefaultNamespace
+}
+
+// SetNamespace sets the namespace of the resource in its metadata
+func (r *ResourceBlock) SetNamespace(namespace string) {
+	if r.Metadata == nil {
+		r.Metadata = &Metadata{}
+	}
+	r.Metadata.Namespace = namespace
+}
+
+// ID returns the ID of the resource, see ResourceID
 func (r ResourceBlock) ID() string {
-	return fmt.Sprintf("%s/%s", r.Kind(), r.Name())
+	return ResourceID(r.Namespace(), r.ResourceKind, r.ResourceName)
 }
 
 // Labels returns the labels of the resource
@@ -72,10 +93,56 @@ func (r ResourceBlock) APIVersion() APIVersion {
 
 // String returns a human-friendly string ID for the resource
 func (r ResourceBlock) String() string {
-	return fmt.Sprintf(
-		"%s/%s",
-		r.ResourceKind, r.ResourceName,
-	)
+	return r.ID()
+}
+
+// DefaultNamespace is the namespace of the resources that are not given one
+const DefaultNamespace = config.DefaultNamespace
+
+// ResourceID returns the ID of the resource with the given namespace, kind and
+// name. The resources in the default namespace (or no namespace) have the ID
+// kind/name, so that their IDs are the same as before namespaces existed, and
+// the resources in other namespaces have the ID namespace/kind/name
+func ResourceID(namespace string, kind string, name string) string {
+	if namespace == "" || namespace == DefaultNamespace {
+		return kind + "/" + name
+	}
+	return namespace + "/" + kind + "/" + name
+}
+
+// ResourceIDInNamespace returns the ID of the resource referenced by ref, which
+// is either kind/name, for a resource in the given namespace, or
+// namespace/kind/name. References of any other form are returned unchanged
+func ResourceIDInNamespace(namespace string, ref string) string {
+	parts := strings.Split(ref, "/")
+	switch len(parts) {
+	case 2:
+		return ResourceID(namespace, parts[0], parts[1])
+	case 3:
+		return ResourceID(parts[0], parts[1], parts[2])
+	}
+	return ref
+}
+
+// ResourceIDNamespace returns the namespace of the resource with the given ID
+func ResourceIDNamespace(id string) string {
+	parts := strings.Split(id, "/")
+	if len(parts) == 3 {
+		return parts[0]
+	}
+	return DefaultNamespace
+}
+
+// ValidateNamespace returns an error if the namespace is not a valid name for
+// a namespace, i.e. if it is empty or contains a "/"
+func ValidateNamespace(namespace string) error {
+	if namespace == "" {
+		return errors.New("namespace cannot be empty")
+	}
+	if strings.Contains(namespace, "/") {
+		return fmt.Errorf(`namespace "%s" cannot contain "/"`, namespace)
+	}
+	return nil
 }
 
 // MarshalJSON is customized to marshal a ResourceBlock, and thereby a resource
@@ -111,30 +178,75 @@ func (r *ResourceBlock) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
+// MIMEApplicationYAML is the MIME type used for requesting resources as YAML
+const MIMEApplicationYAML = "application/x-yaml"
+
+// ResourceJSONToYAML converts the JSON representation of a resource block to
+// YAML. The order of the keys is preserved so that the conversion is lossless
+func ResourceJSONToYAML(b []byte) ([]byte, error) {
+	// JSON is a subset of YAML, so we can read the JSON directly using YAML
+	var resMap yaml.MapSlice
+	if err := yaml.Unmarshal(b, &resMap); err != nil {
+		return nil, fmt.Errorf("failed to read resource JSON: %w", err)
+	}
+	yamlBytes, err := yaml.Marshal(resMap)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal resource to YAML: %w", err)
+	}
+	return yamlBytes, nil
+}
+
 func (r ResourceBlock) specBytes() ([]byte, error) {
-	// get the source range of the hcl spec{} block, so that we can extract it
-	// as raw text
-	var srcRange hcl.Range
-	switch body := r.SpecHCL.Body.(type) {
-	case *hclsyntax.Body:
-		srcRange = body.SrcRange
-	default:
-		return nil, fmt.Errorf("cannot get src range for unknown hcl.Body type %s", reflect.TypeOf(body).String())
+	srcRange, err := r.specRange()
+	if err != nil {
+		return nil, err
 	}
 	// read the bubbly file containing the HCL
 	fileBytes, err := os.ReadFile(srcRange.Filename)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read resource file: %w", err)
 	}
-	if !srcRange.CanSliceBytes(fileBytes) {
+	return r.specBytesFromSource(fileBytes)
+}
+
+// SetSpecFromSource sets the raw spec of the resource from src, which should
+// be the source that the resource was parsed from. This is needed when the
+// resource was not parsed from a file, as otherwise the raw spec is read from
+// the file containing the resource
+func (r *ResourceBlock) SetSpecFromSource(src []byte) error {
+	specBytes, err := r.specBytesFromSource(src)
+	if err != nil {
+		return err
+	}
+	r.SpecRaw = string(specBytes)
+	return nil
+}
+
+func (r ResourceBlock) specBytesFromSource(src []byte) ([]byte, error) {
+	srcRange, err := r.specRange()
+	if err != nil {
+		return nil, err
+	}
+	if !srcRange.CanSliceBytes(src) {
 		return nil, fmt.Errorf("cannot slice bytes for resource %s in filename %s", r.String(), srcRange.Filename)
 	}
-	specBytes := srcRange.SliceBytes(fileBytes)
+	specBytes := srcRange.SliceBytes(src)
 	// specBytes contains the block paranthesis "{" and "}". Remove them
 	return specBytes[1 : len(specBytes)-1], nil
 
 }
 
+// specRange gets the source range of the hcl spec{} block, so that we can
+// extract it as raw text
+func (r ResourceBlock) specRange() (hcl.Range, error) {
+	switch body := r.SpecHCL.Body.(type) {
+	case *hclsyntax.Body:
+		return body.SrcRange, nil
+	default:
+		return hcl.Range{}, fmt.Errorf("cannot get src range for unknown hcl.Body type %s", reflect.TypeOf(body).String())
+	}
+}
+
 // Data produces a core.Data type of this resource.
 // The Data type is produced so that it can be sent to the store as any other
 // piece of data, and therefore the store does not need to implement anything
@@ -151,6 +263,9 @@ func (r *ResourceBlock) Data() (Data, error) {
 		r.SpecRaw = string(spec)
 	}
 	if r.Metadata != nil {
+		if ns := r.Metadata.Namespace; ns != "" && ns != DefaultNamespace {
+			metaMap["namespace"] = cty.StringVal(ns)
+		}
 		var metaLabels = make(map[string]cty.Value, len(r.Metadata.Labels))
 		for k, v := range r.Metadata.Labels {
 			metaLabels[k] = cty.StringVal(v)
@@ -252,9 +367,14 @@ func ResourceKindPriority() []ResourceKind {
 	}
 }
 
+// ResourceRunKinds returns the resource kinds that are run when they are
+// applied, in the order that they are run. Queries are only run if they have
+// an expected result, and after the runs so that they can check the data that
+// the runs have loaded
 func ResourceRunKinds() []ResourceKind {
 	return []ResourceKind{
 		RunResourceKind,
+		QueryResourceKind,
 	}
 }
 
@@ -268,7 +388,9 @@ func (a *APIVersion) String() string {
 // Metadata represents the metadata{} block in a resource... This could
 // probably also be versioned?
 type Metadata struct {
-	Labels map[string]string `json:"labels,omitempty" hcl:"labels,attr"`
+	Labels map[string]string `json:"labels,omitempty" hcl:"labels,optional"`
+	// Namespace is the namespace of the resource, if not DefaultNamespace
+	Namespace string `json:"namespace,omitempty" hcl:"namespace,optional"`
 }
 
 // ResourceBlockSpec represents the spec{} block within a resource
@@ -0,0 +1,55 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResourceIDInNamespace(t *testing.T) {
+	tcs := []struct {
+		namespace string
+		ref       string
+		expected  string
+		// expectedNamespace is the namespace of the expected ID
+		expectedNamespace string
+	}{
+		{namespace: DefaultNamespace, ref: "extract/junit", expected: "extract/junit", expectedNamespace: DefaultNamespace},
+		{namespace: "", ref: "extract/junit", expected: "extract/junit", expectedNamespace: DefaultNamespace},
+		{namespace: "team-a", ref: "extract/junit", expected: "team-a/extract/junit", expectedNamespace: "team-a"},
+		{namespace: "team-a", ref: "team-b/extract/junit", expected: "team-b/extract/junit", expectedNamespace: "team-b"},
+		{namespace: "team-a", ref: "default/extract/junit", expected: "extract/junit", expectedNamespace: DefaultNamespace},
+		{namespace: "team-a", ref: "junit", expected: "junit", expectedNamespace: DefaultNamespace},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.namespace+" "+tc.ref, func(t *testing.T) {
+			id := ResourceIDInNamespace(tc.namespace, tc.ref)
+			assert.Equal(t, tc.expected, id)
+			assert.Equal(t, tc.expectedNamespace, ResourceIDNamespace(id))
+		})
+	}
+}
+
+func TestResourceBlockNamespace(t *testing.T) {
+	res := ResourceBlock{ResourceKind: "extract", ResourceName: "junit"}
+	assert.Equal(t, DefaultNamespace, res.Namespace())
+	assert.Equal(t, "extract/junit", res.ID())
+
+	res.SetNamespace("team-a")
+	assert.Equal(t, "team-a", res.Namespace())
+	assert.Equal(t, "team-a/extract/junit", res.ID())
+	assert.Equal(t, "team-a/extract/junit", res.String())
+
+	res.SpecRaw = `type = "xml"`
+	d, err := res.Data()
+	require.NoError(t, err)
+	assert.Equal(t, "team-a/extract/junit", d.Fields.Values["id"].AsString())
+	assert.Equal(t, "team-a", d.Fields.Values["metadata"].GetAttr("namespace").AsString())
+}
+
+func TestValidateNamespace(t *testing.T) {
+	assert.NoError(t, ValidateNamespace("team-a"))
+	assert.Error(t, ValidateNamespace(""))
+	assert.Error(t, ValidateNamespace("team/a"))
+}
@@ -1,6 +1,17 @@
 package core
 
-import "github.com/zclconf/go-cty/cty"
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+)
+
+// TableFieldDefaultNow is the default value of a string field which defaults
+// to the time at which the data is saved, in RFC3339 format
+const TableFieldDefaultNow = "now()"
 
 // Tables holds a slice of table
 type Tables []Table
@@ -18,8 +29,22 @@ type Table struct {
 	// single & unique), these implicit joins also need to have them
 	Single bool `hcl:"single,optional" json:"single,omitempty"`
 	// Unique makes an implicit join part of the unique constraint
-	Unique bool    `hcl:"unique,optional" json:"unique,omitempty"`
-	Tables []Table `hcl:"table,block" json:"tables,omitempty"`
+	Unique bool `hcl:"unique,optional" json:"unique,omitempty"`
+	// UniqueFields is a list of field names that are unique together, such
+	// as a field and a join (e.g. ["test_set_id", "name"]). Joins are named
+	// by the table they join to with an "_id" suffix. The fields are combined
+	// with any other fields and joins marked as unique into the table's
+	// unique constraint
+	UniqueFields []string `hcl:"unique_fields,optional" json:"unique_fields,omitempty"`
+	// Indexes is a list of the sets of fields that are indexed together, such
+	// as [["status"], ["test_set_id", "name"]], to speed up the queries that
+	// filter on them. Joins are named like in UniqueFields, and are always
+	// indexed on their own
+	Indexes [][]string `hcl:"indexes,optional" json:"indexes,omitempty"`
+	// DerivedFields are read-only fields that are computed from the other
+	// fields of the table when queried, and are not stored
+	DerivedFields []TableDerivedField `hcl:"derived_field,block" json:"derived_fields,omitempty"`
+	Tables        []Table             `hcl:"table,block" json:"tables,omitempty"`
 }
 
 // TableField is a schema field.
@@ -27,10 +52,127 @@ type TableField struct {
 	Name   string   `hcl:",label" json:"name"`
 	Unique bool     `hcl:"unique,optional" json:"unique,omitempty"`
 	Type   cty.Type `hcl:"type,attr" json:"type"`
+	// Default is the value of the field when a data block does not provide
+	// it, or provides null. It should be of the field's type, or
+	// TableFieldDefaultNow for string fields
+	Default cty.Value `hcl:"default,optional" json:"-"`
+	// Enum is the set of values that a string field is expected to have,
+	// such as ["PASS", "FAIL", "SKIP"]. The field is filtered on with a
+	// GraphQL enum of these values, so that queries filtering on any other
+	// value are rejected instead of returning no rows
+	Enum []string `hcl:"enum,optional" json:"enum,omitempty"`
+	// RenamedFrom is the name that the field had before it was renamed. When
+	// the schema is applied, a field with this name is renamed instead of
+	// being dropped and the field created, so that its values are kept
+	RenamedFrom string `hcl:"renamed_from,optional" json:"renamed_from,omitempty"`
+	// Hidden fields are not in the GraphQL schema, so they cannot be queried,
+	// filtered or ordered on. They are still saved, and can be used in the
+	// unique fields, indexes and derived fields of the table
+	Hidden bool `hcl:"hidden,optional" json:"hidden,omitempty"`
+}
+
+// HasDefault returns whether the field has a default value
+func (f TableField) HasDefault() bool {
+	return f.Default != cty.NilVal && !f.Default.IsNull()
+}
+
+// DefaultValue returns the default value of the field converted to the
+// field's type, or an error if it cannot be converted
+func (f TableField) DefaultValue() (cty.Value, error) {
+	if !f.HasDefault() {
+		return cty.NullVal(f.Type), nil
+	}
+	val, err := convert.Convert(f.Default, f.Type)
+	if err != nil {
+		return cty.NilVal, fmt.Errorf("default value of field %s is not of type %s: %w", f.Name, f.Type.FriendlyName(), err)
+	}
+	return val, nil
+}
+
+// tableFieldJSON is the JSON representation of a TableField, with the default
+// value encoded as JSON of the field's type
+type tableFieldJSON struct {
+	tableField
+	Default json.RawMessage `json:"default,omitempty"`
+}
+
+// tableField has the same fields as TableField, but not its methods, so that
+// it does not recurse when marshalling
+type tableField TableField
+
+// MarshalJSON implements json.Marshaler
+func (f TableField) MarshalJSON() ([]byte, error) {
+	fieldJSON := tableFieldJSON{tableField: tableField(f)}
+	if f.HasDefault() {
+		val, err := f.DefaultValue()
+		if err != nil {
+			return nil, err
+		}
+		fieldJSON.Default, err = ctyjson.Marshal(val, f.Type)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal default value of field %s: %w", f.Name, err)
+		}
+	}
+	return json.Marshal(fieldJSON)
+}
+
+// UnmarshalJSON implements json.Unmarshaler
+func (f *TableField) UnmarshalJSON(data []byte) error {
+	var fieldJSON tableFieldJSON
+	if err := json.Unmarshal(data, &fieldJSON); err != nil {
+		return err
+	}
+	*f = TableField(fieldJSON.tableField)
+	if len(fieldJSON.Default) == 0 {
+		return nil
+	}
+	val, err := ctyjson.Unmarshal(fieldJSON.Default, f.Type)
+	if err != nil {
+		return fmt.Errorf("failed to unmarshal default value of field %s: %w", f.Name, err)
+	}
+	f.Default = val
+	return nil
+}
+
+// Lookup returns the table with the given name, searching also any nested
+// tables
+func (t Tables) Lookup(name string) (*Table, bool) {
+	for idx := range t {
+		if t[idx].Name == name {
+			return &t[idx], true
+		}
+		if table, ok := Tables(t[idx].Tables).Lookup(name); ok {
+			return table, true
+		}
+	}
+	return nil, false
+}
+
+// FieldsType returns the cty.Type of the table's fields, as an object with an
+// attribute for each field
+func (t Table) FieldsType() cty.Type {
+	var attrs = make(map[string]cty.Type, len(t.Fields))
+	for _, field := range t.Fields {
+		attrs[field.Name] = field.Type
+	}
+	return cty.Object(attrs)
+}
+
+// TableDerivedField is a field whose value is computed by a SQL expression of
+// the other fields of a table, such as "passed * 100 / nullif(total, 0)".
+// The expression can only refer to the fields and joins of the table, and use
+// basic operators and functions
+type TableDerivedField struct {
+	Name string   `hcl:",label" json:"name"`
+	Type cty.Type `hcl:"type,attr" json:"type"`
+	Expr string   `hcl:"expr,attr" json:"expr"`
 }
 
 type TableJoin struct {
 	Table  string `hcl:",label" json:"name"`
 	Unique bool   `hcl:"unique,optional" json:"unique,omitempty"`
 	Single bool   `hcl:"single,optional" json:"single,omitempty"`
+	// Hidden joins do not have their foreign key field (e.g. team_id) in the
+	// GraphQL schema. The related table can still be queried through the join
+	Hidden bool `hcl:"hidden,optional" json:"hidden,omitempty"`
 }
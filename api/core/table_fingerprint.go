@@ -0,0 +1,96 @@
+package core
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+)
+
+// Fingerprint returns a hash of the definition of the table, including its
+// nested tables. Tables that are defined the same have the same fingerprint,
+// whatever the order in which their fields, joins, derived fields, nested
+// tables, unique fields, indexes and enum values are given. The RenamedFrom
+// of the fields is not part of the definition, as it only says how to migrate
+// a schema to the table
+func (t Table) Fingerprint() string {
+	var lines []string
+	add := func(format string, args ...interface{}) {
+		lines = append(lines, fmt.Sprintf(format, args...))
+	}
+	for _, field := range t.Fields {
+		add("field %q %s unique=%t default=%s enum=%q",
+			field.Name, field.Type.GoString(), field.Unique, fieldDefaultString(field), sortedStrings(field.Enum))
+	}
+	for _, join := range t.Joins {
+		add("join %q unique=%t single=%t", join.Table, join.Unique, join.Single)
+	}
+	add("unique_fields %q", sortedStrings(t.UniqueFields))
+	for _, index := range t.Indexes {
+		// The order of the fields of an index matters to the database
+		add("index %q", index)
+	}
+	for _, field := range t.DerivedFields {
+		add("derived_field %q %s %q", field.Name, field.Type.GoString(), field.Expr)
+	}
+	for _, table := range t.Tables {
+		add("table %s", table.Fingerprint())
+	}
+	sort.Strings(lines)
+
+	h := sha256.New()
+	fmt.Fprintf(h, "table %q single=%t unique=%t\n", t.Name, t.Single, t.Unique)
+	for _, line := range lines {
+		fmt.Fprintln(h, line)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Equal returns whether the table has the same definition as another table,
+// see Fingerprint
+func (t Table) Equal(other Table) bool {
+	return t.Fingerprint() == other.Fingerprint()
+}
+
+// Fingerprint returns a hash of the definitions of the tables, which does not
+// depend on the order of the tables, see Table.Fingerprint
+func (t Tables) Fingerprint() string {
+	fingerprints := make([]string, 0, len(t))
+	for _, table := range t {
+		fingerprints = append(fingerprints, table.Fingerprint())
+	}
+	sort.Strings(fingerprints)
+
+	h := sha256.New()
+	for _, fingerprint := range fingerprints {
+		fmt.Fprintln(h, fingerprint)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Equal returns whether the tables have the same definitions as other tables,
+// see Fingerprint
+func (t Tables) Equal(other Tables) bool {
+	return t.Fingerprint() == other.Fingerprint()
+}
+
+// fieldDefaultString returns the default value of a field for its fingerprint,
+// converted to the type of the field so that e.g. "1" and 1 are the same
+// default of a number field
+func fieldDefaultString(field TableField) string {
+	if !field.HasDefault() {
+		return "null"
+	}
+	val, err := field.DefaultValue()
+	if err != nil {
+		return field.Default.GoString()
+	}
+	return val.GoString()
+}
+
+// sortedStrings returns a sorted copy of the strings
+func sortedStrings(s []string) []string {
+	sorted := append([]string{}, s...)
+	sort.Strings(sorted)
+	return sorted
+}
@@ -0,0 +1,142 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// fingerprintTable returns a table with some of everything that is part of
+// its fingerprint, for the tests to change
+func fingerprintTable() Table {
+	return Table{
+		Name: "team",
+		Fields: []TableField{
+			{Name: "name", Type: cty.String, Unique: true},
+			{Name: "size", Type: cty.Number, Default: cty.NumberIntVal(1)},
+			{Name: "status", Type: cty.String, Enum: []string{"ACTIVE", "LEFT"}},
+		},
+		Joins:        []TableJoin{{Table: "org"}, {Table: "site", Single: true}},
+		UniqueFields: []string{"org_id", "name"},
+		Indexes:      [][]string{{"status"}, {"org_id", "name"}},
+		DerivedFields: []TableDerivedField{
+			{Name: "big", Type: cty.Bool, Expr: "size > 10"},
+		},
+		Tables: []Table{
+			{Name: "member", Fields: []TableField{{Name: "email", Type: cty.String}}},
+			{Name: "profile", Single: true, Fields: []TableField{{Name: "bio", Type: cty.String}}},
+		},
+	}
+}
+
+func TestTableFingerprint(t *testing.T) {
+	tcs := []struct {
+		desc   string
+		change func(t *Table)
+		equal  bool
+	}{
+		{
+			desc:   "same",
+			change: func(t *Table) {},
+			equal:  true,
+		},
+		{
+			desc: "different order",
+			change: func(t *Table) {
+				t.Fields[0], t.Fields[2] = t.Fields[2], t.Fields[0]
+				t.Fields[0].Enum = []string{"LEFT", "ACTIVE"}
+				t.Joins[0], t.Joins[1] = t.Joins[1], t.Joins[0]
+				t.UniqueFields = []string{"name", "org_id"}
+				t.Indexes[0], t.Indexes[1] = t.Indexes[1], t.Indexes[0]
+				t.Tables[0], t.Tables[1] = t.Tables[1], t.Tables[0]
+			},
+			equal: true,
+		},
+		{
+			desc:   "default of another type",
+			change: func(t *Table) { t.Fields[1].Default = cty.StringVal("1") },
+			equal:  true,
+		},
+		{
+			desc:   "renamed from",
+			change: func(t *Table) { t.Fields[0].RenamedFrom = "title" },
+			equal:  true,
+		},
+		{
+			desc:   "name",
+			change: func(t *Table) { t.Name = "group" },
+		},
+		{
+			desc:   "field name",
+			change: func(t *Table) { t.Fields[0].Name = "title" },
+		},
+		{
+			desc:   "field type",
+			change: func(t *Table) { t.Fields[1].Type = cty.String },
+		},
+		{
+			desc:   "field unique",
+			change: func(t *Table) { t.Fields[0].Unique = false },
+		},
+		{
+			desc:   "field default",
+			change: func(t *Table) { t.Fields[1].Default = cty.NumberIntVal(2) },
+		},
+		{
+			desc:   "field enum",
+			change: func(t *Table) { t.Fields[2].Enum = []string{"ACTIVE"} },
+		},
+		{
+			desc:   "extra field",
+			change: func(t *Table) { t.Fields = append(t.Fields, TableField{Name: "x", Type: cty.Bool}) },
+		},
+		{
+			desc:   "join",
+			change: func(t *Table) { t.Joins[1].Single = false },
+		},
+		{
+			desc:   "unique fields",
+			change: func(t *Table) { t.UniqueFields = []string{"name"} },
+		},
+		{
+			desc:   "index field order",
+			change: func(t *Table) { t.Indexes[1] = []string{"name", "org_id"} },
+		},
+		{
+			desc:   "derived field",
+			change: func(t *Table) { t.DerivedFields[0].Expr = "size > 20" },
+		},
+		{
+			desc:   "nested table",
+			change: func(t *Table) { t.Tables[0].Fields[0].Type = cty.List(cty.String) },
+		},
+		{
+			desc:   "nested table single",
+			change: func(t *Table) { t.Tables[1].Single = false },
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.desc, func(t *testing.T) {
+			table := fingerprintTable()
+			changed := fingerprintTable()
+			tc.change(&changed)
+			assert.Equal(t, tc.equal, table.Fingerprint() == changed.Fingerprint())
+			assert.Equal(t, tc.equal, table.Equal(changed))
+			// The fingerprint is stable
+			assert.Equal(t, table.Fingerprint(), fingerprintTable().Fingerprint())
+		})
+	}
+}
+
+func TestTablesFingerprint(t *testing.T) {
+	var (
+		team    = fingerprintTable()
+		product = Table{Name: "product", Fields: []TableField{{Name: "name", Type: cty.String}}}
+	)
+	assert.True(t, Tables{team, product}.Equal(Tables{product, team}))
+	assert.Equal(t, Tables{team, product}.Fingerprint(), Tables{product, team}.Fingerprint())
+	assert.False(t, Tables{team, product}.Equal(Tables{team}))
+	assert.False(t, Tables{team}.Equal(Tables{team.Tables[0]}))
+	assert.True(t, Tables{}.Equal(nil))
+}
@@ -1,8 +1,11 @@
 package core
 
 import (
+	"encoding/json"
 	"testing"
 
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/zclconf/go-cty/cty"
 )
 
@@ -109,3 +112,43 @@ func TestIssueTable(t *testing.T) {
 
 	t.Logf("Is there a test needed here for table %s?", issueTable.Name)
 }
+
+func TestTableFieldDefaultJSON(t *testing.T) {
+	tcs := []struct {
+		desc     string
+		field    TableField
+		expected string
+	}{
+		{
+			desc:     "no default",
+			field:    TableField{Name: "f", Type: cty.String},
+			expected: `{"name":"f","type":"string"}`,
+		},
+		{
+			desc:     "string default",
+			field:    TableField{Name: "f", Type: cty.String, Default: cty.StringVal("UNKNOWN")},
+			expected: `{"name":"f","type":"string","default":"UNKNOWN"}`,
+		},
+		{
+			desc:     "number default",
+			field:    TableField{Name: "f", Type: cty.Number, Default: cty.NumberIntVal(1)},
+			expected: `{"name":"f","type":"number","default":1}`,
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.desc, func(t *testing.T) {
+			b, err := json.Marshal(tc.field)
+			require.NoError(t, err)
+			assert.JSONEq(t, tc.expected, string(b))
+
+			var field TableField
+			require.NoError(t, json.Unmarshal(b, &field))
+			assert.Equal(t, tc.field.Name, field.Name)
+			assert.True(t, tc.field.Type.Equals(field.Type))
+			assert.Equal(t, tc.field.HasDefault(), field.HasDefault())
+			if tc.field.HasDefault() {
+				assert.True(t, tc.field.Default.RawEquals(field.Default))
+			}
+		})
+	}
+}
@@ -14,6 +14,9 @@ const (
 	ResourceTableName = "_resource"
 	SchemaTableName   = "_schema"
 	EventTableName    = "_event"
+	// ResourceVersionTableName is the table with a row for each time that a
+	// resource is saved, so that the previous versions of a resource are kept
+	ResourceVersionTableName = "_resource_version"
 )
 
 // Tasks stores a map of Task by name
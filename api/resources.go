@@ -2,35 +2,83 @@ package api
 
 import (
 	"fmt"
+	"sync"
 
 	"github.com/valocode/bubbly/api/core"
 	v1 "github.com/valocode/bubbly/api/v1"
 )
 
-// NewResource creates a new resource from the given ResourceBlock
+// resourceRegistry holds the constructors of the resource kinds, by kind
+type resourceRegistry struct {
+	mu    sync.RWMutex
+	kinds map[core.ResourceKind]core.NewResourceFn
+}
+
+// registry contains the built in resource kinds, and any resource kinds that
+// are registered with RegisterResource
+var registry = &resourceRegistry{
+	// TODO: use resBlock.APIVersion to get version of resource...
+	kinds: map[core.ResourceKind]core.NewResourceFn{
+		core.ExtractResourceKind: func(resBlock *core.ResourceBlock) (core.Resource, error) {
+			return v1.NewExtract(resBlock), nil
+		},
+		core.TransformResourceKind: func(resBlock *core.ResourceBlock) (core.Resource, error) {
+			return v1.NewTransform(resBlock), nil
+		},
+		core.LoadResourceKind: func(resBlock *core.ResourceBlock) (core.Resource, error) {
+			return v1.NewLoad(resBlock), nil
+		},
+		core.PipelineResourceKind: func(resBlock *core.ResourceBlock) (core.Resource, error) {
+			return v1.NewPipeline(resBlock), nil
+		},
+		core.RunResourceKind: func(resBlock *core.ResourceBlock) (core.Resource, error) {
+			return v1.NewRun(resBlock), nil
+		},
+		core.QueryResourceKind: func(resBlock *core.ResourceBlock) (core.Resource, error) {
+			return v1.NewQuery(resBlock), nil
+		},
+		core.CriteriaResourceKind: func(resBlock *core.ResourceBlock) (core.Resource, error) {
+			return v1.NewCriteria(resBlock), nil
+		},
+	},
+}
+
+// RegisterResource registers the constructor for a new kind of resource, so
+// that resource blocks of that kind can be created with NewResource, and
+// therefore parsed, validated and applied like the built in resource kinds.
+// A kind can only be registered once, and the built in kinds cannot be
+// replaced
+func RegisterResource(kind core.ResourceKind, newRes core.NewResourceFn) error {
+	if kind == "" {
+		return fmt.Errorf("cannot register a resource without a kind")
+	}
+	if newRes == nil {
+		return fmt.Errorf(`cannot register resource "%s" without a constructor`, kind)
+	}
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	if _, ok := registry.kinds[kind]; ok {
+		return fmt.Errorf(`resource already registered: "%s"`, kind)
+	}
+	registry.kinds[kind] = newRes
+	return nil
+}
+
+// NewResource creates a new resource from the given ResourceBlock, using the
+// constructor registered for the kind of the resource
 // If successful, returns a pointer to the new resource
 // If unsuccessful, returns an error
 func NewResource(resBlock *core.ResourceBlock) (core.Resource, error) {
-	var resource core.Resource
-	switch resBlock.Kind() {
-	// TODO: use resBlock.APIVersion to get version of resource...
-	case core.ExtractResourceKind:
-		resource = v1.NewExtract(resBlock)
-	case core.TransformResourceKind:
-		resource = v1.NewTransform(resBlock)
-	case core.LoadResourceKind:
-		resource = v1.NewLoad(resBlock)
-	case core.PipelineResourceKind:
-		resource = v1.NewPipeline(resBlock)
-	case core.RunResourceKind:
-		resource = v1.NewRun(resBlock)
-	case core.QueryResourceKind:
-		resource = v1.NewQuery(resBlock)
-	case core.CriteriaResourceKind:
-		resource = v1.NewCriteria(resBlock)
-	default:
+	registry.mu.RLock()
+	newRes, ok := registry.kinds[resBlock.Kind()]
+	registry.mu.RUnlock()
+	if !ok {
 		return nil, fmt.Errorf(`resource not supported: "%s"`, resBlock.Kind())
 	}
 
+	resource, err := newRes(resBlock)
+	if err != nil {
+		return nil, fmt.Errorf(`failed to create resource "%s": %w`, resBlock.String(), err)
+	}
 	return resource, nil
 }
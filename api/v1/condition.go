@@ -37,7 +37,7 @@ func (c *Condition) Run(bCtx *env.BubblyContext, ctx *core.ResourceContext) core
 	if err := common.DecodeBody(bCtx, c.conditionBlockSpec.Body, c, ctx); err != nil {
 		return core.ResourceOutput{
 			Status: events.ResourceRunFailure,
-			Error:  fmt.Errorf(`failed to decode condition "%s" body spec: %s`, c.Name, err.Error()),
+			Error:  fmt.Errorf(`failed to decode condition "%s" body spec: %w`, c.Name, err),
 			Value:  cty.NilVal,
 		}
 	}
@@ -15,6 +15,7 @@ import (
 )
 
 var _ core.Criteria = (*Criteria)(nil)
+var _ core.Validator = (*Criteria)(nil)
 
 type Criteria struct {
 	*core.ResourceBlock
@@ -35,7 +36,7 @@ func (c *Criteria) Run(bCtx *env.BubblyContext, ctx *core.ResourceContext) core.
 		return core.ResourceOutput{
 			ID:     c.ID(),
 			Status: events.ResourceRunFailure,
-			Error:  fmt.Errorf(`failed to decode "%s" body spec: %s`, c.String(), err.Error()),
+			Error:  fmt.Errorf(`failed to decode "%s" body spec: %w`, c.String(), err),
 			Value:  cty.NilVal,
 		}
 	}
@@ -119,3 +120,9 @@ type conditionSpec struct {
 	Message string         `hcl:"message,optional"`
 	Value   hcl.Expression `hcl:"value,attr"`
 }
+
+// Validate decodes the spec of the criteria without any inputs
+func (c *Criteria) Validate(bCtx *env.BubblyContext) error {
+	var spec criteriaSpec
+	return common.ValidateBody(bCtx, c.SpecHCL.Body, &spec)
+}
@@ -1,16 +1,20 @@
 package v1
 
 import (
+	"bufio"
 	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"math/big"
 	"net/http"
 	"net/url"
 	"os"
 	"path/filepath"
 	"reflect"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
@@ -22,9 +26,13 @@ import (
 	"github.com/hashicorp/hcl/v2"
 
 	"github.com/valocode/bubbly/api/common"
+	"github.com/valocode/bubbly/agent/component"
 	"github.com/valocode/bubbly/api/core"
+	"github.com/valocode/bubbly/bubbly/builtin"
+	"github.com/valocode/bubbly/client"
 	"github.com/valocode/bubbly/env"
 	"github.com/valocode/bubbly/events"
+	"github.com/valocode/bubbly/parser"
 
 	"github.com/zclconf/go-cty/cty"
 	"github.com/zclconf/go-cty/cty/gocty"
@@ -32,6 +40,7 @@ import (
 
 // Compiler check to see that v1.Extract implements the Extract interface
 var _ core.Extract = (*Extract)(nil)
+var _ core.Validator = (*Extract)(nil)
 
 // Extract represents an extract type
 type Extract struct {
@@ -83,6 +92,9 @@ func (e *Extract) Run(bCtx *env.BubblyContext, ctx *core.ResourceContext) core.R
 
 	vals := make([]cty.Value, 0, len(e.Spec.Source))
 	for _, src := range e.Spec.Source {
+		if ps, ok := src.(progressSource); ok && ctx.Progress != nil {
+			ps.setProgress(e.String(), ctx.Progress)
+		}
 		val, err := src.Resolve(bCtx)
 		if err != nil {
 			return core.ResourceOutput{
@@ -230,24 +242,16 @@ func (e *Extract) decode(bCtx *env.BubblyContext, ctx *core.ResourceContext) err
 	}
 
 	e.Spec.Source = make(SourceBlocks, len(e.Spec.SourceHCL))
+	tables := schemaTables(bCtx, ctx.Auth)
 
 	for idx, source := range e.Spec.SourceHCL {
 
 		// Initiate the Extract's Source structure
-		switch e.Spec.Type {
-		case jsonExtractType:
-			e.Spec.Source[idx] = new(jsonSource)
-		case xmlExtractType:
-			e.Spec.Source[idx] = new(xmlSource)
-		case gitExtractType:
-			e.Spec.Source[idx] = new(gitSource)
-		case restExtractType:
-			e.Spec.Source[idx] = new(restSource)
-		case graphQLExtractType:
-			e.Spec.Source[idx] = new(graphqlSource)
-		default:
-			return fmt.Errorf("unsupported extract resource type: %s", e.Spec.Type)
+		src, err := newSource(e.Spec.Type)
+		if err != nil {
+			return err
 		}
+		e.Spec.Source[idx] = src
 
 		// decode the source HCL into the extract's Source
 		if err := common.DecodeBody(bCtx, source.Body, e.Spec.Source[idx], ctx); err != nil {
@@ -265,10 +269,210 @@ func (e *Extract) decode(bCtx *env.BubblyContext, ctx *core.ResourceContext) err
 				return fmt.Errorf("failed to decode extract: %w", err)
 			}
 		}
+
+		// Resolve and validate the format of the source before the source
+		// is resolved, so that the user gets a clear error on invalid formats
+		if fs, ok := e.Spec.Source[idx].(formatSource); ok {
+			if err := fs.decodeFormat(ctx.Inputs); err != nil {
+				return fmt.Errorf("invalid format for extract source: %w", err)
+			}
+			if err := fs.resolveFormat(tables); err != nil {
+				return fmt.Errorf("invalid format for extract source: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+// Validate decodes the spec of the extract and its sources without any inputs
+func (e *Extract) Validate(bCtx *env.BubblyContext) error {
+	var spec extractSpec
+	if err := common.ValidateBody(bCtx, e.SpecHCL.Body, &spec); err != nil {
+		return err
+	}
+	for _, source := range spec.SourceHCL {
+		src, err := newSource(spec.Type)
+		if err != nil {
+			return err
+		}
+		if err := common.ValidateBody(bCtx, source.Body, src); err != nil {
+			return fmt.Errorf("failed to decode extract source: %w", err)
+		}
+		if fs, ok := src.(formatSource); ok {
+			// There are no inputs, so a format that depends on them is
+			// validated for each of the types that it can be
+			if err := fs.decodeFormat(cty.DynamicVal); err != nil {
+				return fmt.Errorf("invalid format for extract source: %w", err)
+			}
+			// There is no bubbly server to get the applied schema from,
+			// so a format_table that is not a built-in table is not checked
+			if err := fs.resolveFormat(unknownTables); err != nil {
+				return fmt.Errorf("invalid format for extract source: %w", err)
+			}
+		}
 	}
 	return nil
 }
 
+// newSource returns a new, empty source for the extract type
+func newSource(ty extractType) (source, error) {
+	switch ty {
+	case jsonExtractType:
+		return new(jsonSource), nil
+	case xmlExtractType:
+		return new(xmlSource), nil
+	case gitExtractType:
+		return new(gitSource), nil
+	case restExtractType:
+		return new(restSource), nil
+	case graphQLExtractType:
+		return new(graphqlSource), nil
+	default:
+		return nil, fmt.Errorf("unsupported extract resource type: %s", ty)
+	}
+}
+
+// formatSource is implemented by sources which have a format that describes
+// the data that the source returns
+type formatSource interface {
+	// decodeFormat decodes the format from its HCL attribute, if there is
+	// one, with the inputs that the format can depend on
+	decodeFormat(inputs cty.Value) error
+	// resolveFormat resolves the format, looking up the table named by
+	// format_table in tables if it is not a built-in table, and validates it
+	resolveFormat(tables tableLookup) error
+}
+
+// tableLookup looks up a table of the schema applied to bubbly by its name.
+// It returns a nil table, and no error, if the schema cannot be known
+type tableLookup func(name string) (*core.Table, error)
+
+// schemaTables returns a tableLookup for the schema applied to bubbly, which
+// it gets from the bubbly server the first time that a table is looked up
+func schemaTables(bCtx *env.BubblyContext, auth *component.MessageAuth) tableLookup {
+	var tables core.Tables
+	return func(name string) (*core.Table, error) {
+		if tables == nil {
+			c, err := client.New(bCtx)
+			if err != nil {
+				return nil, fmt.Errorf("failed to establish new client for format_table: %w", err)
+			}
+			defer c.Close()
+
+			schemaBytes, err := c.GetSchema(bCtx, auth)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get the schema for format_table %q: %w", name, err)
+			}
+			var schema map[string]core.Table
+			if err := json.Unmarshal(schemaBytes, &schema); err != nil {
+				return nil, fmt.Errorf("failed to decode the schema for format_table %q: %w", name, err)
+			}
+			tables = make(core.Tables, 0, len(schema))
+			for _, table := range schema {
+				tables = append(tables, table)
+			}
+		}
+		table, ok := tables.Lookup(name)
+		if !ok {
+			return nil, fmt.Errorf("format_table must refer to a built-in table, such as _event, or a table of the applied schema, but got %q", name)
+		}
+		return table, nil
+	}
+}
+
+// unknownTables is the tableLookup to use when the applied schema cannot be
+// known, such as when validating a resource without a bubbly server
+func unknownTables(string) (*core.Table, error) {
+	return nil, nil
+}
+
+// decodeFormat decodes the type expression of a format attribute into format,
+// unless attr is nil, e.g. because the source has no format attribute.
+// The type expression can be a conditional expression on the inputs, such as
+// self.input.version == "v2" ? list(object({...})) : object({...}), so that
+// the format depends on the inputs
+func decodeFormat(attr *hcl.Attribute, format *cty.Type, inputs cty.Value) error {
+	if attr == nil {
+		return nil
+	}
+	ty, err := parser.TypeExpression(attr.Expr, inputs)
+	if err != nil {
+		return err
+	}
+	*format = ty
+	return nil
+}
+
+// resolveFormat returns the format to use for a source, which is either the
+// format provided, or the format of the table named by formatTable.
+// The table is either a built-in table, or a table of the applied schema that
+// is looked up in tables. If tables cannot know the table, the format is a
+// list of any rows.
+// Only one of format and formatTable can be provided
+func resolveFormat(format cty.Type, formatTable string, tables tableLookup) (cty.Type, error) {
+	if formatTable != "" {
+		if format != cty.NilType {
+			return cty.NilType, errors.New("cannot provide both format and format_table")
+		}
+		table, ok := builtin.BuiltinTables.Lookup(formatTable)
+		if !ok {
+			var err error
+			if table, err = tables(formatTable); err != nil {
+				return cty.NilType, err
+			}
+			if table == nil {
+				return cty.List(cty.DynamicPseudoType), nil
+			}
+		}
+		// A table is a list of rows, so the format is a list of objects
+		return cty.List(table.FieldsType()), nil
+	}
+	if err := validateFormat(format); err != nil {
+		return cty.NilType, err
+	}
+	return format, nil
+}
+
+// validateFormat checks that the format is a concrete type that is supported
+// for decoding data into
+func validateFormat(ty cty.Type) error {
+	switch {
+	case ty == cty.NilType:
+		return errors.New("one of format or format_table must be provided")
+	case ty == cty.DynamicPseudoType:
+		return errors.New(`type "any" is not supported, the format must be a concrete type, e.g. object({...}), list(...), map(...), string, number or bool`)
+	case ty.IsPrimitiveType():
+		return nil
+	case ty.IsListType(), ty.IsSetType(), ty.IsMapType():
+		if err := validateFormat(ty.ElementType()); err != nil {
+			return fmt.Errorf("invalid element type for %s: %w", ty.FriendlyName(), err)
+		}
+		return nil
+	case ty.IsObjectType():
+		attrTypes := ty.AttributeTypes()
+		names := make([]string, 0, len(attrTypes))
+		for name := range attrTypes {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if err := validateFormat(attrTypes[name]); err != nil {
+				return fmt.Errorf("invalid type for attribute %q: %w", name, err)
+			}
+		}
+		return nil
+	case ty.IsTupleType():
+		for idx, elemTy := range ty.TupleElementTypes() {
+			if err := validateFormat(elemTy); err != nil {
+				return fmt.Errorf("invalid type for tuple element %d: %w", idx, err)
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported type: %s", ty.FriendlyName())
+	}
+}
+
 // SourceBlocks stores the HCL for the `source` block in `extract` type resource
 type SourceBlocks []source
 
@@ -302,6 +506,83 @@ type source interface {
 	Resolve(*env.BubblyContext) (cty.Value, error)
 }
 
+// progressSource is implemented by the sources that read a (large) input,
+// and report the progress of reading it
+type progressSource interface {
+	// setProgress sets the function to report the progress to, and the ID of
+	// the resource that the progress is reported for
+	setProgress(resource string, progress core.ProgressFunc)
+}
+
+// sourceProgress reports the progress of a source reading its input. The zero
+// value does not report any progress
+type sourceProgress struct {
+	resource string
+	progress core.ProgressFunc
+}
+
+func (p *sourceProgress) setProgress(resource string, progress core.ProgressFunc) {
+	p.resource = resource
+	p.progress = progress
+}
+
+// reader returns a reader that reports the number of bytes read from r, which
+// reads the input identified by source of the given size (or -1 if unknown)
+func (p *sourceProgress) reader(r io.Reader, source string, size int64) io.Reader {
+	if p.progress == nil {
+		return r
+	}
+	return &progressReader{
+		r:  r,
+		fn: p.progress,
+		progress: core.Progress{
+			Resource:   p.resource,
+			Source:     source,
+			TotalBytes: size,
+		},
+	}
+}
+
+// done reports that the input read by r, as returned by reader, has been read
+// and parsed into val
+func (p *sourceProgress) done(r io.Reader, val cty.Value) {
+	pr, ok := r.(*progressReader)
+	if !ok {
+		return
+	}
+	pr.progress.Done = true
+	pr.progress.Rows = 1
+	if ty := val.Type(); ty.IsListType() || ty.IsSetType() || ty.IsTupleType() {
+		pr.progress.Rows = val.LengthInt()
+	}
+	pr.fn(pr.progress)
+}
+
+// progressReader is a reader that reports the number of bytes read from r
+type progressReader struct {
+	r        io.Reader
+	fn       core.ProgressFunc
+	progress core.Progress
+}
+
+func (r *progressReader) Read(b []byte) (int, error) {
+	n, err := r.r.Read(b)
+	if n > 0 {
+		r.progress.BytesRead += int64(n)
+		r.fn(r.progress)
+	}
+	return n, err
+}
+
+// fileSize returns the size of the file f, or -1 if it is not known
+func fileSize(f *os.File) int64 {
+	info, err := f.Stat()
+	if err != nil {
+		return -1
+	}
+	return info.Size()
+}
+
 // Compiler check to see that the source interface is implemented
 var _ source = (*graphqlSource)(nil)
 
@@ -347,9 +628,21 @@ type graphqlSource struct {
 	// trying to extract the data from this resource.
 	Timeout uint `hcl:"timeout,optional"`
 
+	// FormatHCL is the HCL attribute of the type expression of Format
+	FormatHCL *hcl.Attribute `hcl:"format"`
 	// Format is is a dynamic type, usually built from an HCL type expression.
 	// It defines what is expected in response to the GraphQL API query.
-	Format cty.Type `hcl:"format"`
+	Format cty.Type
+}
+
+// decodeFormat decodes the format of the GraphQL source
+func (s *graphqlSource) decodeFormat(inputs cty.Value) error {
+	return decodeFormat(s.FormatHCL, &s.Format, inputs)
+}
+
+// resolveFormat validates the format of the GraphQL source
+func (s *graphqlSource) resolveFormat(tableLookup) error {
+	return validateFormat(s.Format)
 }
 
 // Resolve performs a GraphQL query, parses the response, and returns a corresponding cty.Value
@@ -485,8 +778,8 @@ func (s *graphqlSource) Resolve(bCtx *env.BubblyContext) (cty.Value, error) {
 	defer httpResponse.Body.Close()
 
 	// Parse the content of response body into `interface{}` for further processing later
-	var graphQLresponse interface{}
-	if err := json.NewDecoder(httpResponse.Body).Decode(&graphQLresponse); err != nil {
+	graphQLresponse, err := decodeJSON(httpResponse.Body)
+	if err != nil {
 		return cty.NilVal, fmt.Errorf("failed to decode GraphQL response: %w", err)
 	}
 
@@ -601,9 +894,50 @@ type restSource struct {
 	// trying to extract the data from this resource.
 	Timeout *uint `hcl:"timeout"`
 
+	// FormatHCL is the HCL attribute of the type expression of Format
+	FormatHCL *hcl.Attribute `hcl:"format,optional"`
 	// Format is a dynamic type, usually built from an HCL type expression.
 	// It defines what is expected in response to the REST API query.
-	Format cty.Type `hcl:"format"`
+	Format cty.Type
+
+	// FormatTable is the name of a built-in table, or a table of the applied
+	// schema, whose fields define the format, as a list of objects.
+	// It can be provided instead of Format.
+	FormatTable string `hcl:"format_table,optional"`
+
+	// Path is the path to the value to extract from the response, as
+	// attribute names and list indexes separated by dots, e.g. "data.items".
+	// By default, the whole response is extracted
+	Path string `hcl:"path,optional"`
+
+	// Next is the path to the URL of the next page in the response, for APIs
+	// that return their results in pages. The value at Path in each page
+	// must then be a list, and the lists of all the pages are concatenated.
+	// Requests stop when there is no URL at Next in the response
+	Next string `hcl:"next,optional"`
+
+	// MaxPages is the maximum number of pages requested when Next is given.
+	// The default is defaultRestMaxPages
+	MaxPages *uint `hcl:"max_pages"`
+}
+
+// defaultRestMaxPages is the default maximum number of pages requested by a
+// REST source, so that a misbehaving API cannot make it request forever
+const defaultRestMaxPages = 100
+
+// decodeFormat decodes the format of the REST source
+func (s *restSource) decodeFormat(inputs cty.Value) error {
+	return decodeFormat(s.FormatHCL, &s.Format, inputs)
+}
+
+// resolveFormat resolves and validates the format of the REST source
+func (s *restSource) resolveFormat(tables tableLookup) error {
+	format, err := resolveFormat(s.Format, s.FormatTable, tables)
+	if err != nil {
+		return err
+	}
+	s.Format = format
+	return nil
 }
 
 // Resolve performs a REST query, parses the response, and returns a corresponding dynamic value.
@@ -699,19 +1033,7 @@ func (s *restSource) Resolve(bCtx *env.BubblyContext) (cty.Value, error) {
 		return cty.NilVal, fmt.Errorf("failed to parse endpoint url %s: %w", us, err)
 	}
 
-	// Create an object representing a HTTP request
-	var body io.Reader
-	httpRequest, err := http.NewRequest(method, u.String(), body)
-	if err != nil {
-		return cty.NilVal, fmt.Errorf("failed to craft HTTP request object: %w", err)
-	}
-
-	// Authentication, if requested
-	if s.BasicAuth != nil {
-		httpRequest.SetBasicAuth(username, password)
-	}
-
-	// Add a bearer token, if requests. Adds a Header.
+	// Add a bearer token, if requested
 	var bearerToken string
 	switch {
 	case s.BearerToken != nil:
@@ -723,23 +1045,106 @@ func (s *restSource) Resolve(bCtx *env.BubblyContext) (cty.Value, error) {
 		}
 		bearerToken = strings.TrimSpace(string(bt))
 	}
-	if bearerToken != "" {
-		httpRequest.Header.Set("Authorization", fmt.Sprint("Bearer ", bearerToken))
-	}
 
-	// Any other headers, if reqested
-	for k, v := range *s.Headers {
-		httpRequest.Header.Add(k, v)
+	// newRequest creates the HTTP request for a page of the response
+	newRequest := func(u *url.URL) (*http.Request, error) {
+		// The query is the body of a POST request
+		var body io.Reader
+		if method == http.MethodPost && s.Query != nil {
+			body = strings.NewReader(*s.Query)
+		}
+		httpRequest, err := http.NewRequest(method, u.String(), body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to craft HTTP request object: %w", err)
+		}
+
+		// Authentication, if requested
+		if s.BasicAuth != nil {
+			httpRequest.SetBasicAuth(username, password)
+		}
+		if bearerToken != "" {
+			httpRequest.Header.Set("Authorization", fmt.Sprint("Bearer ", bearerToken))
+		}
+
+		// Any other headers, if reqested
+		for k, v := range *s.Headers {
+			httpRequest.Header.Add(k, v)
+		}
+		return httpRequest, nil
 	}
 
-	bCtx.Logger.Debug().Str("url", httpRequest.URL.String()).Str("timeout", timeout.String()).Msg("Making HTTP request")
 	// Initiate the HTTP client
 	c := http.Client{Timeout: timeout}
+
+	// Without pagination there is only a single request
+	if s.Next == "" {
+		val, err := s.resolvePage(bCtx, c, newRequest, u, kind)
+		if err != nil {
+			return cty.NilVal, err
+		}
+		return ctyPathValue(val, s.Path)
+	}
+
+	maxPages := uint(defaultRestMaxPages)
+	if s.MaxPages != nil {
+		maxPages = *s.MaxPages
+	}
+	var (
+		pages []cty.Value
+		// elemType is the type of the elements in the list of each page
+		elemType = cty.DynamicPseudoType
+	)
+	for page := uint(1); u != nil; page++ {
+		if page > maxPages {
+			return cty.NilVal, fmt.Errorf("exceeded the maximum of %d pages", maxPages)
+		}
+		val, err := s.resolvePage(bCtx, c, newRequest, u, kind)
+		if err != nil {
+			return cty.NilVal, fmt.Errorf("failed to get page %d: %w", page, err)
+		}
+		pageVal, err := ctyPathValue(val, s.Path)
+		if err != nil {
+			return cty.NilVal, fmt.Errorf("failed to get page %d: %w", page, err)
+		}
+		if !pageVal.IsNull() {
+			ty := pageVal.Type()
+			if !ty.IsListType() {
+				return cty.NilVal, fmt.Errorf("value of page %d must be a list, got %s", page, ty.FriendlyName())
+			}
+			elemType = ty.ElementType()
+			pages = append(pages, pageVal)
+		}
+		if u, err = nextPageURL(val, s.Next, u); err != nil {
+			return cty.NilVal, fmt.Errorf("failed to get the next page after page %d: %w", page, err)
+		}
+	}
+
+	// Concatenate the lists of all the pages
+	var elems []cty.Value
+	for _, pageVal := range pages {
+		elems = append(elems, pageVal.AsValueSlice()...)
+	}
+	if len(elems) == 0 {
+		return cty.ListValEmpty(elemType), nil
+	}
+	return cty.ListVal(elems), nil
+}
+
+// resolvePage makes the request for a single page of the response, and
+// decodes the response
+func (s *restSource) resolvePage(bCtx *env.BubblyContext, c http.Client, newRequest func(*url.URL) (*http.Request, error), u *url.URL, kind string) (cty.Value, error) {
+	httpRequest, err := newRequest(u)
+	if err != nil {
+		return cty.NilVal, err
+	}
+
+	bCtx.Logger.Debug().Str("url", httpRequest.URL.String()).Str("timeout", c.Timeout.String()).Msg("Making HTTP request")
 	// Make REST API request
 	httpResponse, err := c.Do(httpRequest)
 	if err != nil {
 		return cty.NilVal, fmt.Errorf("failed to make HTTP request: %w", err)
 	}
+	defer httpResponse.Body.Close()
 
 	if httpResponse.StatusCode != http.StatusOK {
 		body, err := io.ReadAll(httpResponse.Body)
@@ -748,7 +1153,6 @@ func (s *restSource) Resolve(bCtx *env.BubblyContext) (cty.Value, error) {
 		}
 		return cty.NilVal, fmt.Errorf("HTTP response status code: %d: %s", httpResponse.StatusCode, body)
 	}
-	defer httpResponse.Body.Close()
 
 	// Decode the body
 	switch kind {
@@ -761,6 +1165,70 @@ func (s *restSource) Resolve(bCtx *env.BubblyContext) (cty.Value, error) {
 	return cty.NilVal, fmt.Errorf("unsupported format: %s", kind)
 }
 
+// nextPageURL returns the URL of the next page at the path in the value of a
+// page, resolved relative to the URL of that page. It returns nil if there is
+// no next page
+func nextPageURL(val cty.Value, path string, pageURL *url.URL) (*url.URL, error) {
+	nextVal, err := ctyPathValue(val, path)
+	if err != nil {
+		return nil, err
+	}
+	if nextVal.IsNull() {
+		return nil, nil
+	}
+	if nextVal.Type() != cty.String {
+		return nil, fmt.Errorf("the URL of the next page must be a string, got %s", nextVal.Type().FriendlyName())
+	}
+	if nextVal.AsString() == "" {
+		return nil, nil
+	}
+	next, err := url.Parse(nextVal.AsString())
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse URL of next page %s: %w", nextVal.AsString(), err)
+	}
+	return pageURL.ResolveReference(next), nil
+}
+
+// ctyPathValue returns the value at the path in val. The path consists of
+// attribute names and list indexes, separated by dots, e.g. "data.items.0".
+// An empty path returns val itself, and a null value on the path returns null
+func ctyPathValue(val cty.Value, path string) (cty.Value, error) {
+	if path == "" {
+		return val, nil
+	}
+	for _, step := range strings.Split(path, ".") {
+		if val.IsNull() {
+			return val, nil
+		}
+		ty := val.Type()
+		switch {
+		case ty.IsObjectType():
+			if !ty.HasAttribute(step) {
+				return cty.NilVal, fmt.Errorf("invalid path %s: no attribute %q in the format", path, step)
+			}
+			val = val.GetAttr(step)
+		case ty.IsMapType():
+			key := cty.StringVal(step)
+			if !val.HasIndex(key).True() {
+				return cty.NullVal(ty.ElementType()), nil
+			}
+			val = val.Index(key)
+		case ty.IsListType(), ty.IsTupleType():
+			idx, err := strconv.Atoi(step)
+			if err != nil {
+				return cty.NilVal, fmt.Errorf("invalid path %s: index %q of %s is not a number", path, step, ty.FriendlyName())
+			}
+			if idx < 0 || idx >= val.LengthInt() {
+				return cty.NilVal, fmt.Errorf("invalid path %s: index %d out of range", path, idx)
+			}
+			val = val.Index(cty.NumberIntVal(int64(idx)))
+		default:
+			return cty.NilVal, fmt.Errorf("invalid path %s: cannot get %q of %s", path, step, ty.FriendlyName())
+		}
+	}
+	return val, nil
+}
+
 // Compiler check to see that the source interface is implemented
 var _ source = (*gitSource)(nil)
 
@@ -918,15 +1386,45 @@ var _ source = (*jsonSource)(nil)
 type jsonSource struct {
 	File     string `hcl:"file,optional"`
 	Contents string `hcl:"contents,optional"`
+	// the HCL attribute of the type expression of Format
+	FormatHCL *hcl.Attribute `hcl:"format,optional"`
 	// the format of the raw input data defined as a cty.Type
-	Format cty.Type `hcl:"format,attr"`
+	Format cty.Type
+	// the name of a built-in table, or a table of the applied schema, whose
+	// fields define the format, as a list of objects. Can be provided instead
+	// of Format
+	FormatTable string `hcl:"format_table,optional"`
+	// NDJSON is whether the input is newline-delimited JSON (also known as
+	// JSONL), with a JSON value on each line instead of a single JSON value.
+	// The values are returned as a list, so the format must be a list
+	NDJSON bool `hcl:"ndjson,optional"`
+
+	sourceProgress
+}
+
+// decodeFormat decodes the format of the JSON source
+func (s *jsonSource) decodeFormat(inputs cty.Value) error {
+	return decodeFormat(s.FormatHCL, &s.Format, inputs)
+}
+
+// resolveFormat resolves and validates the format of the JSON source
+func (s *jsonSource) resolveFormat(tables tableLookup) error {
+	format, err := resolveFormat(s.Format, s.FormatTable, tables)
+	if err != nil {
+		return err
+	}
+	if s.NDJSON && !format.IsListType() {
+		return fmt.Errorf("the format of newline-delimited JSON must be a list, not %s", format.FriendlyName())
+	}
+	s.Format = format
+	return nil
 }
 
 // readJSON reads in, decodes, and validates the format of data
 func readJSON(r io.Reader, ty cty.Type) (cty.Value, error) {
 
-	var data interface{}
-	if err := json.NewDecoder(r).Decode(&data); err != nil {
+	data, err := decodeJSON(r)
+	if err != nil {
 		return cty.NilVal, fmt.Errorf("failed to decode JSON: %w", err)
 	}
 	val, err := gocty.ToCtyValue(data, ty)
@@ -937,6 +1435,106 @@ func readJSON(r io.Reader, ty cty.Type) (cty.Value, error) {
 	return val, nil
 }
 
+// readJSONLines reads in and decodes newline-delimited JSON, with a JSON value
+// on each line, into a list of the values of the format, which is a list.
+// Blank lines are skipped
+func readJSONLines(r io.Reader, ty cty.Type) (cty.Value, error) {
+	if !ty.IsListType() {
+		return cty.NilVal, fmt.Errorf("the format of newline-delimited JSON must be a list, not %s", ty.FriendlyName())
+	}
+	var (
+		br     = bufio.NewReader(r)
+		values = make([]interface{}, 0)
+	)
+	for lineNum := 1; ; lineNum++ {
+		// Lines are read whole, as a line can be longer than the buffer of
+		// a bufio.Scanner
+		line, readErr := br.ReadBytes('\n')
+		if readErr != nil && readErr != io.EOF {
+			return cty.NilVal, fmt.Errorf("failed to read line %d: %w", lineNum, readErr)
+		}
+		if len(bytes.TrimSpace(line)) > 0 {
+			data, err := decodeJSONLine(line)
+			if err != nil {
+				return cty.NilVal, fmt.Errorf("failed to decode JSON on line %d: %w", lineNum, err)
+			}
+			values = append(values, data)
+		}
+		if readErr == io.EOF {
+			break
+		}
+	}
+	val, err := gocty.ToCtyValue(values, ty)
+	if err != nil {
+		return cty.NilVal, err
+	}
+	return val, nil
+}
+
+// decodeJSONLine decodes the JSON value on a line of newline-delimited JSON,
+// which must not be followed by anything else, like decodeJSON
+func decodeJSONLine(line []byte) (interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(line))
+	dec.UseNumber()
+
+	var data interface{}
+	if err := dec.Decode(&data); err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, errors.New("unexpected data after the JSON value")
+	}
+	return convertJSONNumbers(data)
+}
+
+// decodeJSON decodes JSON into a Go value that can be converted to a
+// cty.Value. JSON numbers are decoded as big numbers instead of float64, so
+// that large integers, such as 64-bit IDs, do not lose precision
+func decodeJSON(r io.Reader) (interface{}, error) {
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+
+	var data interface{}
+	if err := dec.Decode(&data); err != nil {
+		return nil, err
+	}
+	return convertJSONNumbers(data)
+}
+
+// convertJSONNumbers replaces the json.Number values in data, decoded with
+// UseNumber, with a *big.Int for integers, or a *big.Float otherwise, which
+// gocty converts to a cty.Number without rounding
+func convertJSONNumbers(data interface{}) (interface{}, error) {
+	switch v := data.(type) {
+	case json.Number:
+		if i, ok := new(big.Int).SetString(v.String(), 10); ok {
+			return i, nil
+		}
+		n, err := cty.ParseNumberVal(v.String())
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %s: %w", v, err)
+		}
+		return n.AsBigFloat(), nil
+	case map[string]interface{}:
+		for key, elem := range v {
+			val, err := convertJSONNumbers(elem)
+			if err != nil {
+				return nil, err
+			}
+			v[key] = val
+		}
+	case []interface{}:
+		for i, elem := range v {
+			val, err := convertJSONNumbers(elem)
+			if err != nil {
+				return nil, err
+			}
+			v[i] = val
+		}
+	}
+	return data, nil
+}
+
 // Resolve returns a cty.Value representation of the parsed JSON file
 func (s *jsonSource) Resolve(bCtx *env.BubblyContext) (cty.Value, error) {
 
@@ -949,16 +1547,30 @@ func (s *jsonSource) Resolve(bCtx *env.BubblyContext) (cty.Value, error) {
 
 	var r io.Reader
 	if s.File != "" {
-		var err error
-		r, err = os.Open(s.File)
+		f, size, err := openSourceFile(bCtx, s.File)
 		if err != nil {
 			return cty.NilVal, fmt.Errorf("error opening file %s: %w", s.File, err)
 		}
+		defer f.Close()
+		r = s.reader(f, s.File, size)
 	} else {
-		r = strings.NewReader(s.Contents)
+		r = s.reader(strings.NewReader(s.Contents), "contents", int64(len(s.Contents)))
 	}
 
-	return readJSON(r, s.Format)
+	var (
+		val cty.Value
+		err error
+	)
+	if s.NDJSON {
+		val, err = readJSONLines(r, s.Format)
+	} else {
+		val, err = readJSON(r, s.Format)
+	}
+	if err != nil {
+		return cty.NilVal, err
+	}
+	s.done(r, val)
+	return val, nil
 }
 
 // Compiler check to see that v1.XMLSource implements the Source interface
@@ -967,8 +1579,31 @@ var _ source = (*xmlSource)(nil)
 // xmlSource represents the extract type for using an XML file as the input
 type xmlSource struct {
 	File string `hcl:"file,attr"`
+	// the HCL attribute of the type expression of Format
+	FormatHCL *hcl.Attribute `hcl:"format,optional"`
 	// the format of the raw input data defined as a cty.Type
-	Format cty.Type `hcl:"format,attr"`
+	Format cty.Type
+	// the name of a built-in table, or a table of the applied schema, whose
+	// fields define the format, as a list of objects. Can be provided instead
+	// of Format
+	FormatTable string `hcl:"format_table,optional"`
+
+	sourceProgress
+}
+
+// decodeFormat decodes the format of the XML source
+func (s *xmlSource) decodeFormat(inputs cty.Value) error {
+	return decodeFormat(s.FormatHCL, &s.Format, inputs)
+}
+
+// resolveFormat resolves and validates the format of the XML source
+func (s *xmlSource) resolveFormat(tables tableLookup) error {
+	format, err := resolveFormat(s.Format, s.FormatTable, tables)
+	if err != nil {
+		return err
+	}
+	s.Format = format
+	return nil
 }
 
 // readXML reads in, decodes, and validates the format of data
@@ -997,13 +1632,19 @@ func (s *xmlSource) Resolve(bCtx *env.BubblyContext) (cty.Value, error) {
 	mxj.PrependAttrWithHyphen(false) // no "-" prefix on attributes
 	mxj.CastNanInf(true)             // use float64, not string for extremes
 
-	f, err := os.Open(s.File)
+	f, size, err := openSourceFile(bCtx, s.File)
 	if err != nil {
 		return cty.NilVal, fmt.Errorf("failed to open file %s: %w", s.File, err)
 	}
 	defer f.Close()
 
-	return readXML(f, s.Format)
+	r := s.reader(f, s.File, size)
+	val, err := readXML(r, s.Format)
+	if err != nil {
+		return cty.NilVal, err
+	}
+	s.done(r, val)
+	return val, nil
 }
 
 // TODO: fixListsInXML could do with extensive unit testing of edge cases and better documentation
@@ -1,14 +1,20 @@
 package v1
 
 import (
+	"errors"
 	"fmt"
+	"math/big"
 	"os"
 
 	"net/http"
 	"path/filepath"
+	"strings"
 	"testing"
 
+	"github.com/hashicorp/hcl/v2/hclparse"
 	"github.com/rs/zerolog"
+	"github.com/valocode/bubbly/api/common"
+	"github.com/valocode/bubbly/api/core"
 	"github.com/valocode/bubbly/env"
 
 	"github.com/stretchr/testify/assert"
@@ -63,6 +69,182 @@ func TestExtractJSON(t *testing.T) {
 // does not have syntax for lists. So the XML parser does not
 // know whether an element is by itself, or it's in a list of length one.
 // This information is available only in cty.Type data structure we build from HCL
+func TestExtractJSONNumbers(t *testing.T) {
+	bCtx := env.NewBubblyContext()
+
+	source := jsonSource{
+		// 9007199254740993 is 2^53+1, which is rounded to 2^53 as a float64
+		Contents: `{
+			"id": 9007199254740993,
+			"ids": [9223372036854775807, 18446744073709551615],
+			"score": 0.1,
+			"count": 3
+		}`,
+		Format: cty.Object(map[string]cty.Type{
+			"id":    cty.Number,
+			"ids":   cty.List(cty.Number),
+			"score": cty.Number,
+			"count": cty.Number,
+		}),
+	}
+
+	val, err := source.Resolve(bCtx)
+	require.NoError(t, err)
+
+	// intString returns the integer of a cty.Number as a string
+	intString := func(v cty.Value) string {
+		i, acc := v.AsBigFloat().Int(nil)
+		require.Equal(t, big.Exact, acc, "number is not an integer")
+		return i.String()
+	}
+	assert.Equal(t, "9007199254740993", intString(val.GetAttr("id")))
+	assert.Equal(t, "9223372036854775807", intString(val.GetAttr("ids").Index(cty.NumberIntVal(0))))
+	assert.Equal(t, "18446744073709551615", intString(val.GetAttr("ids").Index(cty.NumberIntVal(1))))
+	assert.Equal(t, cty.True, val.GetAttr("score").Equals(cty.MustParseNumberVal("0.1")))
+	assert.Equal(t, cty.True, val.GetAttr("count").Equals(cty.NumberIntVal(3)))
+}
+
+func TestExtractJSONProgress(t *testing.T) {
+	bCtx := env.NewBubblyContext()
+
+	// Write a file that is large enough to be read in many chunks
+	const numRows = 5000
+	rows := make([]string, 0, numRows)
+	for i := 0; i < numRows; i++ {
+		rows = append(rows, fmt.Sprintf(`{"name": "row_%d"}`, i))
+	}
+	file := filepath.Join(t.TempDir(), "rows.json")
+	require.NoError(t, os.WriteFile(file, []byte("["+strings.Join(rows, ",\n")+"]"), 0644))
+	info, err := os.Stat(file)
+	require.NoError(t, err)
+
+	var progress []core.Progress
+	source := jsonSource{
+		File:   file,
+		Format: cty.List(cty.Object(map[string]cty.Type{"name": cty.String})),
+	}
+	source.setProgress("extract/rows", func(p core.Progress) {
+		progress = append(progress, p)
+	})
+	val, err := source.Resolve(bCtx)
+	require.NoError(t, err)
+	require.Equal(t, numRows, val.LengthInt())
+
+	require.Greater(t, len(progress), 2, "progress should be reported while reading")
+	for i, p := range progress {
+		assert.Equal(t, "extract/rows", p.Resource)
+		assert.Equal(t, file, p.Source)
+		assert.Equal(t, info.Size(), p.TotalBytes)
+		if i > 0 {
+			assert.GreaterOrEqual(t, p.BytesRead, progress[i-1].BytesRead, "progress should increase")
+		}
+		assert.Equal(t, i == len(progress)-1, p.Done, "only the last progress should be done")
+	}
+	assert.Greater(t, progress[1].BytesRead, progress[0].BytesRead)
+	last := progress[len(progress)-1]
+	assert.Equal(t, info.Size(), last.BytesRead)
+	assert.Equal(t, numRows, last.Rows)
+}
+
+func TestExtractJSONLines(t *testing.T) {
+	bCtx := env.NewBubblyContext()
+	format := cty.List(cty.Object(map[string]cty.Type{
+		"level": cty.String,
+		"msg":   cty.String,
+		"took":  cty.Number,
+	}))
+	event := func(level string, msg string, took int64) cty.Value {
+		return cty.ObjectVal(map[string]cty.Value{
+			"level": cty.StringVal(level),
+			"msg":   cty.StringVal(msg),
+			"took":  cty.NumberIntVal(took),
+		})
+	}
+
+	tcs := []struct {
+		desc     string
+		source   jsonSource
+		expected cty.Value
+		errMsg   string
+	}{
+		{
+			desc: "file with blank lines",
+			source: jsonSource{
+				File: filepath.FromSlash("testdata/extract/jsonl/events.jsonl"),
+			},
+			expected: cty.ListVal([]cty.Value{
+				event("info", "started", 3),
+				event("warn", "slow request", 1500),
+				event("error", "failed", 12),
+			}),
+		},
+		{
+			desc: "without trailing newline",
+			source: jsonSource{
+				Contents: `{"level": "info", "msg": "started", "took": 3}` + "\r\n" + `{"level": "info", "msg": "done", "took": 4}`,
+			},
+			expected: cty.ListVal([]cty.Value{
+				event("info", "started", 3),
+				event("info", "done", 4),
+			}),
+		},
+		{
+			desc:     "empty",
+			source:   jsonSource{Contents: "\n\n"},
+			expected: cty.ListValEmpty(format.ElementType()),
+		},
+		{
+			desc: "malformed line",
+			source: jsonSource{
+				File: filepath.FromSlash("testdata/extract/jsonl/malformed.jsonl"),
+			},
+			errMsg: "failed to decode JSON on line 2",
+		},
+		{
+			desc: "two values on a line",
+			source: jsonSource{
+				Contents: `{"level": "info", "msg": "started", "took": 3}` + "\n" + `{"level": "info"} {"level": "info"}`,
+			},
+			errMsg: "failed to decode JSON on line 2: unexpected data after the JSON value",
+		},
+		{
+			desc: "value not of the format",
+			source: jsonSource{
+				Contents: `["info", "started", 3]`,
+			},
+			errMsg: "can't convert",
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.desc, func(t *testing.T) {
+			source := tc.source
+			source.Format = format
+			source.NDJSON = true
+			require.NoError(t, source.resolveFormat(unknownTables))
+
+			val, err := source.Resolve(bCtx)
+			if tc.errMsg != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tc.errMsg)
+				return
+			}
+			require.NoError(t, err)
+			assert.True(t, val.RawEquals(tc.expected), "unexpected value: %#v", val)
+		})
+	}
+
+	t.Run("format not a list", func(t *testing.T) {
+		source := jsonSource{
+			Contents: `{"level": "info"}`,
+			Format:   cty.Object(map[string]cty.Type{"level": cty.String}),
+			NDJSON:   true,
+		}
+		err := source.resolveFormat(unknownTables)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "must be a list")
+	})
+}
+
 func TestExtractXML(t *testing.T) {
 
 	// Helper function that runs the test defined by its arguments
@@ -769,3 +951,567 @@ func TestExtractRestParams(t *testing.T) {
 		assert.Equal(t, cty.BoolVal(true), val.Equals(expected), "the extract returned unexpected value")
 	})
 }
+
+func TestExtractRestBody(t *testing.T) {
+
+	defer gock.Off()
+	bCtx := env.NewBubblyContext()
+
+	route := "search"
+	url := fmt.Sprint("https://localhost:8080/", route)
+	query := `{"name": "bubbly"}`
+
+	source := restSource{
+		URL:    url,
+		Method: http.MethodPost,
+		Query:  &query,
+		Format: cty.Object(map[string]cty.Type{
+			"data": cty.Object(map[string]cty.Type{
+				"items": cty.List(cty.String),
+			}),
+		}),
+		Path: "data.items",
+	}
+	setRestSourceDefaults(bCtx, &source)
+
+	gockResponse := gock.New(url).
+		Post(route).
+		BodyString(query).
+		Reply(http.StatusOK).
+		JSON(map[string]interface{}{
+			"data": map[string]interface{}{
+				"items": []string{"a", "b"},
+			},
+		})
+
+	val, err := source.Resolve(bCtx)
+	require.NoError(t, err)
+	require.True(t, gockResponse.Done(), "mock is not done")
+	assert.Equal(t, cty.ListVal([]cty.Value{cty.StringVal("a"), cty.StringVal("b")}), val)
+}
+
+func TestExtractRestPagination(t *testing.T) {
+
+	defer gock.Off()
+	bCtx := env.NewBubblyContext()
+
+	route := "items"
+	url := fmt.Sprint("https://localhost:8080/", route)
+
+	source := restSource{
+		URL: url,
+		Format: cty.Object(map[string]cty.Type{
+			"items": cty.List(cty.Object(map[string]cty.Type{
+				"id": cty.Number,
+			})),
+			"links": cty.Object(map[string]cty.Type{
+				"next": cty.String,
+			}),
+		}),
+		Path: "items",
+		Next: "links.next",
+	}
+	setRestSourceDefaults(bCtx, &source)
+
+	// page returns the body of a page with the given ids and next URL
+	page := func(next interface{}, ids ...int) map[string]interface{} {
+		var items []map[string]interface{}
+		for _, id := range ids {
+			items = append(items, map[string]interface{}{"id": id})
+		}
+		return map[string]interface{}{
+			"items": items,
+			"links": map[string]interface{}{"next": next},
+		}
+	}
+
+	t.Run("all pages", func(t *testing.T) {
+		defer gock.Off()
+		// The first page has an absolute URL to the second page, and the
+		// second page a relative URL to the third page
+		first := gock.New(url).
+			Get(route).
+			Reply(http.StatusOK).
+			JSON(page(url+"?page=2", 1, 2))
+		second := gock.New(url).
+			Get(route).
+			MatchParam("page", "2").
+			Reply(http.StatusOK).
+			JSON(page("/items?page=3", 3, 4))
+		third := gock.New(url).
+			Get(route).
+			MatchParam("page", "3").
+			Reply(http.StatusOK).
+			JSON(page(nil, 5))
+
+		val, err := source.Resolve(bCtx)
+		require.NoError(t, err)
+		require.True(t, first.Done(), "first page is not done")
+		require.True(t, second.Done(), "second page is not done")
+		require.True(t, third.Done(), "third page is not done")
+
+		var ids []cty.Value
+		for _, id := range []int64{1, 2, 3, 4, 5} {
+			ids = append(ids, cty.ObjectVal(map[string]cty.Value{"id": cty.NumberIntVal(id)}))
+		}
+		assert.Equal(t, cty.BoolVal(true), val.Equals(cty.ListVal(ids)), "unexpected value unmarshaled")
+	})
+
+	t.Run("max pages", func(t *testing.T) {
+		defer gock.Off()
+		gock.New(url).
+			Get(route).
+			Persist().
+			Reply(http.StatusOK).
+			JSON(page(url, 1))
+
+		s := source
+		maxPages := uint(3)
+		s.MaxPages = &maxPages
+		_, err := s.Resolve(bCtx)
+		assert.EqualError(t, err, "exceeded the maximum of 3 pages")
+	})
+
+	t.Run("path not a list", func(t *testing.T) {
+		defer gock.Off()
+		gock.New(url).
+			Get(route).
+			Reply(http.StatusOK).
+			JSON(page(nil, 1))
+
+		s := source
+		s.Path = "links"
+		_, err := s.Resolve(bCtx)
+		assert.EqualError(t, err, "value of page 1 must be a list, got object")
+	})
+}
+
+func TestCtyPathValue(t *testing.T) {
+	val := cty.ObjectVal(map[string]cty.Value{
+		"data": cty.ObjectVal(map[string]cty.Value{
+			"items": cty.ListVal([]cty.Value{cty.StringVal("a"), cty.StringVal("b")}),
+			"next":  cty.NullVal(cty.String),
+		}),
+		"labels": cty.MapVal(map[string]cty.Value{"team": cty.StringVal("bubbly")}),
+	})
+	tcs := []struct {
+		desc     string
+		path     string
+		expected cty.Value
+		err      string
+	}{
+		{
+			desc:     "empty path",
+			path:     "",
+			expected: val,
+		},
+		{
+			desc:     "attribute",
+			path:     "data.items",
+			expected: cty.ListVal([]cty.Value{cty.StringVal("a"), cty.StringVal("b")}),
+		},
+		{
+			desc:     "list index",
+			path:     "data.items.1",
+			expected: cty.StringVal("b"),
+		},
+		{
+			desc:     "map key",
+			path:     "labels.team",
+			expected: cty.StringVal("bubbly"),
+		},
+		{
+			desc:     "missing map key",
+			path:     "labels.owner",
+			expected: cty.NullVal(cty.String),
+		},
+		{
+			desc:     "null value",
+			path:     "data.next.href",
+			expected: cty.NullVal(cty.String),
+		},
+		{
+			desc: "missing attribute",
+			path: "data.total",
+			err:  `invalid path data.total: no attribute "total" in the format`,
+		},
+		{
+			desc: "invalid index",
+			path: "data.items.first",
+			err:  `invalid path data.items.first: index "first" of list of string is not a number`,
+		},
+		{
+			desc: "index out of range",
+			path: "data.items.2",
+			err:  "invalid path data.items.2: index 2 out of range",
+		},
+		{
+			desc: "primitive value",
+			path: "data.items.0.name",
+			err:  `invalid path data.items.0.name: cannot get "name" of string`,
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.desc, func(t *testing.T) {
+			actual, err := ctyPathValue(val, tc.path)
+			if tc.err != "" {
+				assert.EqualError(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.True(t, tc.expected.RawEquals(actual), "expected %#v, got %#v", tc.expected, actual)
+		})
+	}
+}
+
+func TestExtractResolveFormat(t *testing.T) {
+	// schema is a stand-in for the schema applied to bubbly
+	schema := core.Tables{
+		{
+			Name: "vulnerability",
+			Fields: []core.TableField{
+				{Name: "cve", Type: cty.String},
+				{Name: "score", Type: cty.Number},
+			},
+		},
+	}
+	schemaLookup := func(name string) (*core.Table, error) {
+		table, ok := schema.Lookup(name)
+		if !ok {
+			return nil, fmt.Errorf("no table %q", name)
+		}
+		return table, nil
+	}
+	tcs := []struct {
+		desc        string
+		format      cty.Type
+		formatTable string
+		tables      tableLookup
+		expected    cty.Type
+		err         string
+	}{
+		{
+			desc:     "valid format",
+			format:   cty.List(cty.Object(map[string]cty.Type{"name": cty.String})),
+			expected: cty.List(cty.Object(map[string]cty.Type{"name": cty.String})),
+		},
+		{
+			desc:   "missing format",
+			format: cty.NilType,
+			err:    "one of format or format_table must be provided",
+		},
+		{
+			desc:   "any format",
+			format: cty.DynamicPseudoType,
+			err:    `type "any" is not supported`,
+		},
+		{
+			desc:   "nested any format",
+			format: cty.Object(map[string]cty.Type{"issues": cty.List(cty.DynamicPseudoType)}),
+			err:    `invalid type for attribute "issues": invalid element type for list of dynamic: type "any" is not supported`,
+		},
+		{
+			desc:        "format table",
+			formatTable: "_event",
+			expected: cty.List(cty.Object(map[string]cty.Type{
+				"status": cty.String,
+				"error":  cty.String,
+				"time":   cty.String,
+			})),
+		},
+		{
+			desc:        "unknown format table",
+			formatTable: "typo",
+			tables:      schemaLookup,
+			err:         `no table "typo"`,
+		},
+		{
+			desc:        "schema format table",
+			formatTable: "vulnerability",
+			tables:      schemaLookup,
+			expected: cty.List(cty.Object(map[string]cty.Type{
+				"cve":   cty.String,
+				"score": cty.Number,
+			})),
+		},
+		{
+			desc:        "schema format table without a schema",
+			formatTable: "vulnerability",
+			tables:      unknownTables,
+			expected:    cty.List(cty.DynamicPseudoType),
+		},
+		{
+			desc:        "format and format table",
+			format:      cty.String,
+			formatTable: "_event",
+			err:         "cannot provide both format and format_table",
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.desc, func(t *testing.T) {
+			source := jsonSource{
+				Format:      tc.format,
+				FormatTable: tc.formatTable,
+			}
+			if tc.tables == nil {
+				tc.tables = unknownTables
+			}
+			err := source.resolveFormat(tc.tables)
+			if tc.err != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tc.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.True(t, tc.expected.Equals(source.Format), "unexpected format: %s", source.Format.GoString())
+		})
+	}
+}
+
+// TestExtractSchemaTables tests that the tables of the applied schema are
+// looked up from the bubbly server, which is only asked for the schema once
+func TestExtractSchemaTables(t *testing.T) {
+	defer gock.Off()
+
+	bCtx := env.NewBubblyContext()
+	bCtx.ClientConfig.BubblyAddr = "http://bubbly.test"
+
+	gockResponse := gock.New(bCtx.ClientConfig.BubblyAddr).
+		Get("/schema").
+		Reply(http.StatusOK).
+		JSON(map[string]core.Table{
+			"vulnerability": {
+				Name:   "vulnerability",
+				Fields: []core.TableField{{Name: "cve", Type: cty.String}},
+			},
+		})
+
+	tables := schemaTables(bCtx, nil)
+	table, err := tables("vulnerability")
+	require.NoError(t, err)
+	assert.Equal(t, "vulnerability", table.Name)
+	require.True(t, gockResponse.Done(), "mock is not done")
+
+	_, err = tables("typo")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `format_table must refer to a built-in table, such as _event, or a table of the applied schema, but got "typo"`)
+
+	// A table of the schema resolves to the format of its fields
+	source := jsonSource{FormatTable: "vulnerability"}
+	require.NoError(t, source.resolveFormat(tables))
+	assert.True(t, cty.List(cty.Object(map[string]cty.Type{"cve": cty.String})).Equals(source.Format), "unexpected format: %s", source.Format.GoString())
+}
+
+// TestExtractSourceTemplates tests that the attributes of a source are
+// evaluated as HCL expressions with the inputs of the extract, so that
+// templates and heredocs can interpolate the inputs and call functions
+func TestExtractSourceTemplates(t *testing.T) {
+	bCtx := env.NewBubblyContext()
+	inputs := cty.ObjectVal(map[string]cty.Value{
+		"input": cty.ObjectVal(map[string]cty.Value{
+			"dir":  cty.StringVal("testdata/extract/json"),
+			"name": cty.StringVal("bubbly"),
+		}),
+	})
+
+	tcs := []struct {
+		desc  string
+		spec  string
+		check func(t *testing.T, src source)
+	}{
+		{
+			desc: "interpolated file",
+			spec: `
+input "dir" {}
+type = "json"
+source {
+	file = "${self.input.dir}/sonarqube-example.json"
+	format = list(string)
+}
+`,
+			check: func(t *testing.T, src source) {
+				assert.Equal(t, "testdata/extract/json/sonarqube-example.json", src.(*jsonSource).File)
+			},
+		},
+		{
+			desc: "function",
+			spec: `
+input "dir" {}
+type = "json"
+source {
+	file = join("/", [self.input.dir, "sonarqube-example.json"])
+	format = list(string)
+}
+`,
+			check: func(t *testing.T, src source) {
+				assert.Equal(t, "testdata/extract/json/sonarqube-example.json", src.(*jsonSource).File)
+			},
+		},
+		{
+			desc: "heredoc template",
+			spec: `
+input "name" {}
+type = "rest"
+source {
+	url = "https://example.com/${self.input.name}/search"
+	method = "POST"
+	query = <<-EOT
+		{"name": "${self.input.name}", "upper": "${upper(self.input.name)}"}
+	EOT
+	format = object({name = string})
+}
+`,
+			check: func(t *testing.T, src source) {
+				rest := src.(*restSource)
+				assert.Equal(t, "https://example.com/bubbly/search", rest.URL)
+				require.NotNil(t, rest.Query)
+				assert.Equal(t, `{"name": "bubbly", "upper": "BUBBLY"}`+"\n", *rest.Query)
+			},
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.desc, func(t *testing.T) {
+			file, diags := hclparse.NewParser().ParseHCL([]byte(tc.spec), "spec.hcl")
+			require.False(t, diags.HasErrors(), diags.Error())
+			e := NewExtract(&core.ResourceBlock{
+				ResourceKind: string(core.ExtractResourceKind),
+				ResourceName: "templates",
+				SpecHCL:      core.ResourceBlockSpec{Body: file.Body},
+			})
+
+			ctx := core.NewResourceContext(inputs, nil, nil)
+			require.NoError(t, e.decode(bCtx, ctx))
+			require.Len(t, e.Spec.Source, 1)
+			tc.check(t, e.Spec.Source[0])
+		})
+	}
+}
+
+// TestExtractConditionalFormat checks that the format of a source can be
+// chosen with a conditional expression on the inputs
+func TestExtractConditionalFormat(t *testing.T) {
+	const spec = `
+input "version" {}
+input "report" {}
+type = "json"
+source {
+	contents = self.input.report
+	format = (self.input.version == "v2" ?
+		object({results = list(object({name = string, passed = bool}))}) :
+		list(object({name = string, passed = bool}))
+	)
+}
+`
+	file, diags := hclparse.NewParser().ParseHCL([]byte(spec), "spec.hcl")
+	require.Falsef(t, diags.HasErrors(), diags.Error())
+	result := cty.ObjectVal(map[string]cty.Value{
+		"name":   cty.StringVal("login"),
+		"passed": cty.True,
+	})
+
+	tcs := []struct {
+		desc     string
+		version  string
+		report   string
+		expected cty.Value
+	}{
+		{
+			desc:     "v1 report",
+			version:  "v1",
+			report:   `[{"name": "login", "passed": true}]`,
+			expected: cty.ListVal([]cty.Value{result}),
+		},
+		{
+			desc:    "v2 report",
+			version: "v2",
+			report:  `{"results": [{"name": "login", "passed": true}]}`,
+			expected: cty.ObjectVal(map[string]cty.Value{
+				"results": cty.ListVal([]cty.Value{result}),
+			}),
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.desc, func(t *testing.T) {
+			e := NewExtract(&core.ResourceBlock{
+				ResourceKind: string(core.ExtractResourceKind),
+				ResourceName: "conditional_format",
+				SpecHCL:      core.ResourceBlockSpec{Body: file.Body},
+			})
+			inputs := cty.ObjectVal(map[string]cty.Value{
+				"input": cty.ObjectVal(map[string]cty.Value{
+					"version": cty.StringVal(tc.version),
+					"report":  cty.StringVal(tc.report),
+				}),
+			})
+			out := e.Run(env.NewBubblyContext(), core.NewResourceContext(inputs, nil, nil))
+			require.NoError(t, out.Error)
+			assert.True(t, tc.expected.RawEquals(out.Value), "expected %s, got %s", tc.expected.GoString(), out.Value.GoString())
+		})
+	}
+
+	t.Run("validate", func(t *testing.T) {
+		e := NewExtract(&core.ResourceBlock{
+			ResourceKind: string(core.ExtractResourceKind),
+			ResourceName: "conditional_format",
+			SpecHCL:      core.ResourceBlockSpec{Body: file.Body},
+		})
+		assert.NoError(t, e.Validate(env.NewBubblyContext()))
+	})
+}
+
+// TestExtractMissingInputs checks that the inputs of an extract are validated
+// before it is decoded, so that a missing input is reported by name
+func TestExtractMissingInputs(t *testing.T) {
+	const src = `
+input "file" {}
+input "format" {
+	default = "ignored"
+}
+type = "json"
+source {
+	file = self.input.file
+	format = object({ id: number })
+}
+`
+	file, diags := hclparse.NewParser().ParseHCL([]byte(src), "testing")
+	require.Falsef(t, diags.HasErrors(), diags.Error())
+
+	tcs := []struct {
+		desc   string
+		inputs cty.Value
+		err    string
+	}{
+		{
+			desc: "all inputs",
+			inputs: cty.ObjectVal(map[string]cty.Value{
+				"input": cty.ObjectVal(map[string]cty.Value{
+					"file": cty.StringVal("report.json"),
+				}),
+			}),
+		},
+		{
+			desc:   "missing required input",
+			inputs: cty.EmptyObjectVal,
+			err:    "missing required inputs (they have no default value): file",
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.desc, func(t *testing.T) {
+			e := NewExtract(&core.ResourceBlock{
+				ResourceKind: string(core.ExtractResourceKind),
+				ResourceName: "missing_inputs",
+				SpecHCL:      core.ResourceBlockSpec{Body: file.Body},
+			})
+			err := e.decode(env.NewBubblyContext(), core.NewResourceContext(tc.inputs, nil, nil))
+			if tc.err != "" {
+				require.Error(t, err)
+				assert.True(t, errors.Is(err, common.ErrMissingInputs))
+				assert.Contains(t, err.Error(), tc.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, e.Spec.Source, 1)
+			assert.Equal(t, "report.json", e.Spec.Source[0].(*jsonSource).File)
+		})
+	}
+}
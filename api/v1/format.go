@@ -0,0 +1,216 @@
+package v1
+
+import (
+	"fmt"
+	"io"
+	"math/big"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// InferJSONFormat reads a sample of JSON data and infers a format that the
+// data can be extracted with.
+//
+// The inference is conservative: the elements of an array must all have the
+// same type, and objects in an array are merged so that the format has every
+// attribute of every object. As any value can be null, values that are only
+// ever null (or empty arrays) are given the type string, as their type cannot
+// be known from the sample
+func InferJSONFormat(r io.Reader) (cty.Type, error) {
+	data, err := decodeJSON(r)
+	if err != nil {
+		return cty.NilType, fmt.Errorf("failed to decode JSON: %w", err)
+	}
+	ty, err := inferType(data, cty.Path{})
+	if err != nil {
+		return cty.NilType, err
+	}
+	return concreteFormat(ty), nil
+}
+
+// inferType infers the type of a value decoded by decodeJSON. The type of
+// null values is cty.DynamicPseudoType, which is replaced by concreteFormat
+func inferType(data interface{}, path cty.Path) (cty.Type, error) {
+	switch v := data.(type) {
+	case nil:
+		return cty.DynamicPseudoType, nil
+	case bool:
+		return cty.Bool, nil
+	case string:
+		return cty.String, nil
+	case *big.Int, *big.Float:
+		return cty.Number, nil
+	case map[string]interface{}:
+		attrTypes := make(map[string]cty.Type, len(v))
+		for name, elem := range v {
+			if !hclsyntax.ValidIdentifier(name) {
+				return cty.NilType, fmt.Errorf("%s: attribute %q cannot be used in a format, as it is not a valid identifier", pathString(path), name)
+			}
+			ty, err := inferType(elem, path.GetAttr(name))
+			if err != nil {
+				return cty.NilType, err
+			}
+			attrTypes[name] = ty
+		}
+		return cty.Object(attrTypes), nil
+	case []interface{}:
+		elemType := cty.DynamicPseudoType
+		for i, elem := range v {
+			elemPath := path.Index(cty.NumberIntVal(int64(i)))
+			ty, err := inferType(elem, elemPath)
+			if err != nil {
+				return cty.NilType, err
+			}
+			elemType, err = unifyTypes(elemType, ty, elemPath)
+			if err != nil {
+				return cty.NilType, err
+			}
+		}
+		return cty.List(elemType), nil
+	default:
+		return cty.NilType, fmt.Errorf("%s: unsupported JSON value of type %T", pathString(path), data)
+	}
+}
+
+// unifyTypes returns a type that values of both types can be extracted with.
+// Objects are merged, as the attributes missing from a value are null
+func unifyTypes(a, b cty.Type, path cty.Path) (cty.Type, error) {
+	switch {
+	case a == cty.DynamicPseudoType:
+		return b, nil
+	case b == cty.DynamicPseudoType:
+		return a, nil
+	case a.IsObjectType() && b.IsObjectType():
+		attrTypes := a.AttributeTypes()
+		merged := make(map[string]cty.Type, len(attrTypes))
+		for name, ty := range attrTypes {
+			merged[name] = ty
+		}
+		for name, ty := range b.AttributeTypes() {
+			if aTy, ok := merged[name]; ok {
+				var err error
+				ty, err = unifyTypes(aTy, ty, path.GetAttr(name))
+				if err != nil {
+					return cty.NilType, err
+				}
+			}
+			merged[name] = ty
+		}
+		return cty.Object(merged), nil
+	case a.IsListType() && b.IsListType():
+		elemType, err := unifyTypes(a.ElementType(), b.ElementType(), path)
+		if err != nil {
+			return cty.NilType, err
+		}
+		return cty.List(elemType), nil
+	case a.Equals(b):
+		return a, nil
+	default:
+		return cty.NilType, fmt.Errorf("%s: values have different types, %s and %s", pathString(path), a.FriendlyName(), b.FriendlyName())
+	}
+}
+
+// concreteFormat replaces the unknown types of values that are only ever
+// null with string, as a format must be a concrete type
+func concreteFormat(ty cty.Type) cty.Type {
+	switch {
+	case ty == cty.DynamicPseudoType:
+		return cty.String
+	case ty.IsListType():
+		return cty.List(concreteFormat(ty.ElementType()))
+	case ty.IsObjectType():
+		attrTypes := make(map[string]cty.Type)
+		for name, attrTy := range ty.AttributeTypes() {
+			attrTypes[name] = concreteFormat(attrTy)
+		}
+		return cty.Object(attrTypes)
+	default:
+		return ty
+	}
+}
+
+// FormatString returns the HCL type expression of a format, which can be
+// used as the value of the format attribute of a source
+func FormatString(ty cty.Type) string {
+	var sb strings.Builder
+	writeFormat(&sb, ty, 0)
+	return sb.String()
+}
+
+func writeFormat(sb *strings.Builder, ty cty.Type, depth int) {
+	switch {
+	case ty == cty.String:
+		sb.WriteString("string")
+	case ty == cty.Number:
+		sb.WriteString("number")
+	case ty == cty.Bool:
+		sb.WriteString("bool")
+	case ty == cty.DynamicPseudoType:
+		sb.WriteString("any")
+	case ty.IsListType():
+		sb.WriteString("list(")
+		writeFormat(sb, ty.ElementType(), depth)
+		sb.WriteString(")")
+	case ty.IsSetType():
+		sb.WriteString("set(")
+		writeFormat(sb, ty.ElementType(), depth)
+		sb.WriteString(")")
+	case ty.IsMapType():
+		sb.WriteString("map(")
+		writeFormat(sb, ty.ElementType(), depth)
+		sb.WriteString(")")
+	case ty.IsObjectType():
+		attrTypes := ty.AttributeTypes()
+		if len(attrTypes) == 0 {
+			sb.WriteString("object({})")
+			return
+		}
+		names := make([]string, 0, len(attrTypes))
+		for name := range attrTypes {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		indent := strings.Repeat("\t", depth+1)
+		sb.WriteString("object({\n")
+		for _, name := range names {
+			sb.WriteString(indent + name + ": ")
+			writeFormat(sb, attrTypes[name], depth+1)
+			sb.WriteString(",\n")
+		}
+		sb.WriteString(strings.Repeat("\t", depth) + "})")
+	case ty.IsTupleType():
+		sb.WriteString("tuple([")
+		for i, elemTy := range ty.TupleElementTypes() {
+			if i > 0 {
+				sb.WriteString(", ")
+			}
+			writeFormat(sb, elemTy, depth)
+		}
+		sb.WriteString("])")
+	default:
+		sb.WriteString(ty.FriendlyName())
+	}
+}
+
+// pathString returns a path in a value as a string, e.g. "a.b[0]"
+func pathString(path cty.Path) string {
+	if len(path) == 0 {
+		return "value"
+	}
+	var sb strings.Builder
+	for _, step := range path {
+		switch s := step.(type) {
+		case cty.GetAttrStep:
+			if sb.Len() > 0 {
+				sb.WriteString(".")
+			}
+			sb.WriteString(s.Name)
+		case cty.IndexStep:
+			sb.WriteString(fmt.Sprintf("[%s]", s.Key.AsBigFloat().String()))
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,148 @@
+package v1
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/hcl/v2/gohcl"
+	"github.com/hashicorp/hcl/v2/hclparse"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/valocode/bubbly/env"
+)
+
+func TestInferJSONFormat(t *testing.T) {
+	tcs := []struct {
+		desc     string
+		json     string
+		expected cty.Type
+		err      string
+	}{
+		{
+			desc: "nested object",
+			json: `{"name": "bubbly", "stars": 10, "private": false, "owner": {"login": "valocode"}}`,
+			expected: cty.Object(map[string]cty.Type{
+				"name":    cty.String,
+				"stars":   cty.Number,
+				"private": cty.Bool,
+				"owner": cty.Object(map[string]cty.Type{
+					"login": cty.String,
+				}),
+			}),
+		},
+		{
+			desc:     "array of primitives",
+			json:     `[1, 2.5, null]`,
+			expected: cty.List(cty.Number),
+		},
+		{
+			desc: "array of objects with different attributes",
+			json: `[{"id": 1, "tags": []}, {"id": 2, "name": "second", "tags": ["a"]}]`,
+			expected: cty.List(cty.Object(map[string]cty.Type{
+				"id":   cty.Number,
+				"name": cty.String,
+				"tags": cty.List(cty.String),
+			})),
+		},
+		{
+			desc: "null and empty values",
+			json: `{"nothing": null, "empty": [], "nulls": [null]}`,
+			expected: cty.Object(map[string]cty.Type{
+				"nothing": cty.String,
+				"empty":   cty.List(cty.String),
+				"nulls":   cty.List(cty.String),
+			}),
+		},
+		{
+			desc:     "nullable attribute",
+			json:     `[{"value": null}, {"value": true}]`,
+			expected: cty.List(cty.Object(map[string]cty.Type{"value": cty.Bool})),
+		},
+		{
+			desc: "mixed array",
+			json: `[1, "two"]`,
+			err:  "[1]: values have different types, number and string",
+		},
+		{
+			desc: "mixed attribute",
+			json: `{"items": [{"value": 1}, {"value": "two"}]}`,
+			err:  "items[1].value: values have different types, number and string",
+		},
+		{
+			desc: "invalid attribute name",
+			json: `{"a.b": 1}`,
+			err:  `attribute "a.b" cannot be used in a format`,
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.desc, func(t *testing.T) {
+			ty, err := InferJSONFormat(strings.NewReader(tc.json))
+			if tc.err != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tc.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Truef(t, tc.expected.Equals(ty), "expected %s but got %s", FormatString(tc.expected), FormatString(ty))
+			require.NoError(t, validateFormat(ty))
+		})
+	}
+}
+
+// TestInferJSONFormatRoundTrip checks that the inferred format of a JSON file
+// can be used to extract the same file
+func TestInferJSONFormatRoundTrip(t *testing.T) {
+	files := []string{
+		filepath.FromSlash("testdata/extract/json/sonarqube-example.json"),
+	}
+	for _, file := range files {
+		t.Run(filepath.Base(file), func(t *testing.T) {
+			f, err := os.Open(file)
+			require.NoError(t, err)
+			defer f.Close()
+			ty, err := InferJSONFormat(f)
+			require.NoError(t, err)
+
+			// Decode a source with the format the same way that an extract is
+			// decoded, to check that the format is a valid type expression
+			src := fmt.Sprintf("file = %q\nformat = %s\n", filepath.ToSlash(file), FormatString(ty))
+			hclFile, diags := hclparse.NewParser().ParseHCL([]byte(src), "testing")
+			require.Falsef(t, diags.HasErrors(), diags.Error())
+			var source jsonSource
+			diags = gohcl.DecodeBody(hclFile.Body, nil, &source)
+			require.Falsef(t, diags.HasErrors(), diags.Error())
+			// The format is decoded from its attribute once the inputs are
+			// known, and it does not depend on any
+			require.NoError(t, source.decodeFormat(cty.EmptyObjectVal))
+			assert.Truef(t, ty.Equals(source.Format), "decoded format %s does not match", source.Format.FriendlyName())
+
+			require.NoError(t, source.resolveFormat(unknownTables))
+			val, err := source.Resolve(env.NewBubblyContext())
+			require.NoError(t, err)
+			assert.False(t, val.IsNull())
+		})
+	}
+}
+
+func TestFormatString(t *testing.T) {
+	ty := cty.Object(map[string]cty.Type{
+		"name": cty.String,
+		"tags": cty.List(cty.String),
+		"owner": cty.Object(map[string]cty.Type{
+			"login": cty.String,
+		}),
+	})
+	expected := "object({\n" +
+		"\tname: string,\n" +
+		"\towner: object({\n" +
+		"\t\tlogin: string,\n" +
+		"\t}),\n" +
+		"\ttags: list(string),\n" +
+		"})"
+	assert.Equal(t, expected, FormatString(ty))
+}
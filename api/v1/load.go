@@ -1,6 +1,8 @@
 package v1
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 
@@ -15,6 +17,7 @@ import (
 )
 
 var _ core.Load = (*Load)(nil)
+var _ core.Validator = (*Load)(nil)
 
 type Load struct {
 	*core.ResourceBlock
@@ -33,7 +36,7 @@ func (l *Load) Run(bCtx *env.BubblyContext, ctx *core.ResourceContext) core.Reso
 		return core.ResourceOutput{
 			ID:     l.String(),
 			Status: events.ResourceRunFailure,
-			Error:  fmt.Errorf(`failed to decode "%s" body spec: %s`, l.String(), err.Error()),
+			Error:  fmt.Errorf(`failed to decode "%s" body spec: %w`, l.String(), err),
 			Value:  cty.NilVal,
 		}
 	}
@@ -90,7 +93,11 @@ func (l *Load) load(bCtx *env.BubblyContext, ctx *core.ResourceContext, data cor
 		return fmt.Errorf("error marshalling data blocks: %w", err)
 	}
 
-	err = c.Load(bCtx, ctx.Auth, bytes)
+	var opts []client.LoadOption
+	if ctx.RunID != "" {
+		opts = append(opts, client.WithSaveID(l.saveID(ctx.RunID, bytes)))
+	}
+	err = c.Load(bCtx, ctx.Auth, bytes, opts...)
 	if err != nil {
 		return fmt.Errorf("failed to load spec data: %w", err)
 	}
@@ -98,8 +105,22 @@ func (l *Load) load(bCtx *env.BubblyContext, ctx *core.ResourceContext, data cor
 	return nil
 }
 
+// saveID returns the ID of the data that the load saves in the run with the
+// given ID. A run can load different data with the same load resource, so the
+// ID also includes a hash of the data
+func (l *Load) saveID(runID string, data []byte) string {
+	sum := sha256.Sum256(data)
+	return runID + "/" + l.String() + "/" + hex.EncodeToString(sum[:])
+}
+
 type loadSpec struct {
 	Inputs core.InputDeclarations `hcl:"input,block"`
 	Data   string                 `hcl:"data,attr"`
 	// GitItem gitItem                `hcl:"git,block"`
 }
+
+// Validate decodes the spec of the load without any inputs
+func (l *Load) Validate(bCtx *env.BubblyContext) error {
+	var spec loadSpec
+	return common.ValidateBody(bCtx, l.SpecHCL.Body, &spec)
+}
@@ -0,0 +1,224 @@
+package v1
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/valocode/bubbly/env"
+)
+
+// The schemes of the files of extract sources that are objects in a cloud
+// object storage, instead of local files
+const (
+	s3Scheme  = "s3://"
+	gcsScheme = "gs://"
+)
+
+// emptyPayloadHash is the SHA256 hash of an empty request body, which is the
+// payload of the signed request for an S3 object
+const emptyPayloadHash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+// openSourceFile opens the file of a source, which is either a local file, or
+// an object in S3 (s3://bucket/key) or Google Cloud Storage (gs://bucket/key),
+// and returns it with its size (or -1 if it is not known)
+func openSourceFile(bCtx *env.BubblyContext, file string) (io.ReadCloser, int64, error) {
+	switch {
+	case strings.HasPrefix(file, s3Scheme):
+		return getObject(bCtx, file, newS3Request)
+	case strings.HasPrefix(file, gcsScheme):
+		return getObject(bCtx, file, newGCSRequest)
+	}
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, 0, err
+	}
+	return f, fileSize(f), nil
+}
+
+// getObject gets an object from an object storage, with the request created
+// by newRequest for the bucket and key of the object
+func getObject(bCtx *env.BubblyContext, file string, newRequest func(bucket, key string) (*http.Request, error)) (io.ReadCloser, int64, error) {
+	bucket, key, err := parseObjectURL(file)
+	if err != nil {
+		return nil, 0, err
+	}
+	req, err := newRequest(bucket, key)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to create request for object %s: %w", file, err)
+	}
+
+	bCtx.Logger.Debug().Str("object", file).Str("url", req.URL.String()).Msg("Getting object")
+	// There is no timeout for reading the object, which can be large
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get object %s: %w", file, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return nil, 0, fmt.Errorf("error getting body of response: %w", err)
+		}
+		return nil, 0, fmt.Errorf("failed to get object %s: HTTP response status code: %d: %s", file, resp.StatusCode, body)
+	}
+	return resp.Body, resp.ContentLength, nil
+}
+
+// parseObjectURL returns the bucket and key of the object at the given URL,
+// such as s3://bucket/path/to/key
+func parseObjectURL(file string) (string, string, error) {
+	path := file[strings.Index(file, "://")+len("://"):]
+	parts := strings.SplitN(path, "/", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", fmt.Errorf("invalid object %s: must be of the form <scheme>://<bucket>/<key>", file)
+	}
+	return parts[0], parts[1], nil
+}
+
+// newS3Request returns the request to get an object from S3.
+// The region, endpoint and credentials are taken from the environment, like
+// the AWS CLI does:
+//   - AWS_REGION or AWS_DEFAULT_REGION, which defaults to us-east-1
+//   - AWS_ENDPOINT_URL_S3 or AWS_ENDPOINT_URL for an S3 compatible storage,
+//     such as MinIO, whose buckets are in the path of the URL
+//   - AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and AWS_SESSION_TOKEN, which
+//     sign the request with AWS Signature Version 4. Without them the request
+//     is anonymous, which works for public objects
+func newS3Request(bucket, key string) (*http.Request, error) {
+	region := firstEnv("AWS_REGION", "AWS_DEFAULT_REGION")
+	if region == "" {
+		region = "us-east-1"
+	}
+	var u string
+	if endpoint := firstEnv("AWS_ENDPOINT_URL_S3", "AWS_ENDPOINT_URL"); endpoint != "" {
+		u = strings.TrimSuffix(endpoint, "/") + "/" + bucket + "/" + objectKeyPath(key)
+	} else {
+		u = fmt.Sprintf("https://%s.s3.%s.amazonaws.com/%s", bucket, region, objectKeyPath(key))
+	}
+	req, err := http.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	accessKey, secretKey := os.Getenv("AWS_ACCESS_KEY_ID"), os.Getenv("AWS_SECRET_ACCESS_KEY")
+	if accessKey == "" || secretKey == "" {
+		return req, nil
+	}
+	signS3Request(req, region, accessKey, secretKey, os.Getenv("AWS_SESSION_TOKEN"), time.Now().UTC())
+	return req, nil
+}
+
+// signS3Request adds the Authorization header for AWS Signature Version 4 to
+// a request for S3 without a body, see
+// https://docs.aws.amazon.com/AmazonS3/latest/API/sig-v4-header-based-auth.html
+func signS3Request(req *http.Request, region, accessKey, secretKey, sessionToken string, now time.Time) {
+	var (
+		amzDate = now.Format("20060102T150405Z")
+		date    = now.Format("20060102")
+		scope   = date + "/" + region + "/s3/aws4_request"
+	)
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", emptyPayloadHash)
+	if sessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", sessionToken)
+	}
+
+	// The signed headers are sorted by name
+	headers := []string{"host", "x-amz-content-sha256", "x-amz-date"}
+	if sessionToken != "" {
+		headers = append(headers, "x-amz-security-token")
+	}
+	var canonicalHeaders strings.Builder
+	for _, h := range headers {
+		val := req.Header.Get(h)
+		if h == "host" {
+			val = req.URL.Host
+		}
+		canonicalHeaders.WriteString(h + ":" + strings.TrimSpace(val) + "\n")
+	}
+	signedHeaders := strings.Join(headers, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.Query().Encode(),
+		canonicalHeaders.String(),
+		signedHeaders,
+		emptyPayloadHash,
+	}, "\n")
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		hex.EncodeToString(requestHash[:]),
+	}, "\n")
+
+	key := []byte("AWS4" + secretKey)
+	for _, part := range []string{date, region, "s3", "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s", accessKey, scope, signedHeaders, signature))
+}
+
+// newGCSRequest returns the request to get an object from Google Cloud
+// Storage. The endpoint and credentials are taken from the environment:
+//   - STORAGE_EMULATOR_HOST for an emulator of the storage, like the Google
+//     Cloud SDK does
+//   - GOOGLE_OAUTH_ACCESS_TOKEN, such as the output of
+//     `gcloud auth print-access-token`, which is sent as a bearer token.
+//     Without it the request is anonymous, which works for public objects
+func newGCSRequest(bucket, key string) (*http.Request, error) {
+	endpoint := "https://storage.googleapis.com"
+	if host := os.Getenv("STORAGE_EMULATOR_HOST"); host != "" {
+		endpoint = strings.TrimSuffix(host, "/")
+		if !strings.Contains(endpoint, "://") {
+			endpoint = "http://" + endpoint
+		}
+	}
+	req, err := http.NewRequest(http.MethodGet, endpoint+"/"+bucket+"/"+objectKeyPath(key), nil)
+	if err != nil {
+		return nil, err
+	}
+	if token := os.Getenv("GOOGLE_OAUTH_ACCESS_TOKEN"); token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	return req, nil
+}
+
+// objectKeyPath escapes the key of an object for the path of a URL, which
+// keeps only the unreserved characters of RFC 3986 and the slashes
+func objectKeyPath(key string) string {
+	segments := strings.Split(key, "/")
+	for i, segment := range segments {
+		segments[i] = strings.ReplaceAll(url.QueryEscape(segment), "+", "%20")
+	}
+	return strings.Join(segments, "/")
+}
+
+// hmacSHA256 returns the HMAC-SHA256 of data with the given key
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// firstEnv returns the value of the first of the environment variables that
+// is set
+func firstEnv(keys ...string) string {
+	for _, key := range keys {
+		if val := os.Getenv(key); val != "" {
+			return val
+		}
+	}
+	return ""
+}
@@ -38,7 +38,7 @@ func (o *Operation) Run(bCtx *env.BubblyContext, ctx *core.ResourceContext) core
 	if err := common.DecodeBody(bCtx, o.operationBlockSpec.Body, o, ctx); err != nil {
 		return core.ResourceOutput{
 			Status: events.ResourceRunFailure,
-			Error:  fmt.Errorf(`failed to decode operation "%s" body spec: %s`, o.Name(), err.Error()),
+			Error:  fmt.Errorf(`failed to decode operation "%s" body spec: %w`, o.Name(), err),
 			Value:  cty.NilVal,
 		}
 	}
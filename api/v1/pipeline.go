@@ -12,6 +12,7 @@ import (
 )
 
 var _ core.Pipeline = (*Pipeline)(nil)
+var _ core.Validator = (*Pipeline)(nil)
 
 type Pipeline struct {
 	*core.ResourceBlock
@@ -33,7 +34,7 @@ func (p *Pipeline) Run(bCtx *env.BubblyContext, ctx *core.ResourceContext) core.
 		return core.ResourceOutput{
 			ID:     p.String(),
 			Status: events.ResourceRunFailure,
-			Error:  fmt.Errorf(`failed to decode "%s" body spec: %s`, p.String(), err.Error()),
+			Error:  fmt.Errorf(`failed to decode "%s" body spec: %w`, p.String(), err),
 			Value:  cty.NilVal,
 		}
 	}
@@ -73,3 +74,18 @@ type pipelineSpec struct {
 	Inputs     core.InputDeclarations `hcl:"input,block"`
 	TaskBlocks []*taskBlockSpec       `hcl:"task,block"`
 }
+
+// Validate decodes the spec of the pipeline and its tasks without any inputs
+func (p *Pipeline) Validate(bCtx *env.BubblyContext) error {
+	var spec pipelineSpec
+	if err := common.ValidateBody(bCtx, p.SpecHCL.Body, &spec); err != nil {
+		return err
+	}
+	for _, taskSpec := range spec.TaskBlocks {
+		t := NewTask(taskSpec)
+		if err := common.ValidateBody(bCtx, taskSpec.Body, t); err != nil {
+			return fmt.Errorf(`invalid task "%s": %w`, taskSpec.Name, err)
+		}
+	}
+	return nil
+}
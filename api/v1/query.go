@@ -1,11 +1,15 @@
 package v1
 
 import (
+	"encoding/json"
 	"fmt"
+	"reflect"
 
+	"github.com/hashicorp/hcl/v2"
 	"github.com/valocode/bubbly/events"
 
 	"github.com/zclconf/go-cty/cty"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
 
 	"github.com/valocode/bubbly/api/common"
 	"github.com/valocode/bubbly/api/core"
@@ -13,6 +17,7 @@ import (
 )
 
 var _ core.Query = (*Query)(nil)
+var _ core.Validator = (*Query)(nil)
 
 type Query struct {
 	*core.ResourceBlock
@@ -32,7 +37,7 @@ func (q *Query) Run(bCtx *env.BubblyContext, ctx *core.ResourceContext) core.Res
 		return core.ResourceOutput{
 			ID:     q.String(),
 			Status: events.ResourceRunFailure,
-			Error:  fmt.Errorf(`failed to decode "%s" body spec: %s`, q.String(), err.Error()),
+			Error:  fmt.Errorf(`failed to decode "%s" body spec: %w`, q.String(), err),
 			Value:  cty.NilVal,
 		}
 	}
@@ -47,6 +52,17 @@ func (q *Query) Run(bCtx *env.BubblyContext, ctx *core.ResourceContext) core.Res
 		}
 	}
 
+	if q.Spec.Expected != cty.NilVal {
+		if err := assertQueryResult(q.Spec.Expected, queryVal); err != nil {
+			return core.ResourceOutput{
+				ID:     q.String(),
+				Status: events.ResourceRunFailure,
+				Error:  fmt.Errorf(`query "%s" failed: %w`, q.String(), err),
+				Value:  queryVal,
+			}
+		}
+	}
+
 	return core.ResourceOutput{
 		ID:     q.String(),
 		Status: events.ResourceRunSuccess,
@@ -55,8 +71,63 @@ func (q *Query) Run(bCtx *env.BubblyContext, ctx *core.ResourceContext) core.Res
 	}
 }
 
+// Asserts returns whether the query has an expected result, which makes it a
+// check that is run when the query is applied. It does not decode the spec, as
+// that requires the inputs given to the query
+func (q *Query) Asserts() bool {
+	content, _, diags := q.SpecHCL.Body.PartialContent(&hcl.BodySchema{
+		Attributes: []hcl.AttributeSchema{{Name: queryExpectedAttr}},
+	})
+	if diags.HasErrors() {
+		return false
+	}
+	_, ok := content.Attributes[queryExpectedAttr]
+	return ok
+}
+
+// assertQueryResult returns an error if the result of a query is not equal to
+// the expected result. They are compared as JSON, so that e.g. a list and a
+// tuple with the same elements are equal
+func assertQueryResult(expected cty.Value, result cty.Value) error {
+	expectedJSON, err := queryResultJSON(expected)
+	if err != nil {
+		return fmt.Errorf("invalid expected result: %w", err)
+	}
+	resultJSON, err := queryResultJSON(result)
+	if err != nil {
+		return fmt.Errorf("invalid result: %w", err)
+	}
+	var expectedData, resultData interface{}
+	if err := json.Unmarshal(expectedJSON, &expectedData); err != nil {
+		return fmt.Errorf("invalid expected result: %w", err)
+	}
+	if err := json.Unmarshal(resultJSON, &resultData); err != nil {
+		return fmt.Errorf("invalid result: %w", err)
+	}
+	if !reflect.DeepEqual(expectedData, resultData) {
+		return fmt.Errorf("result does not match the expected result:\nexpected: %s\nactual:   %s", expectedJSON, resultJSON)
+	}
+	return nil
+}
+
+// queryResultJSON returns the JSON encoding of a query result
+func queryResultJSON(val cty.Value) ([]byte, error) {
+	if val == cty.NilVal {
+		return []byte("null"), nil
+	}
+	return ctyjson.Marshal(val, val.Type())
+}
+
+// queryExpectedAttr is the attribute of the spec of a query with its expected
+// result
+const queryExpectedAttr = "expected"
+
 type querySpec struct {
 	Query string `hcl:"query,attr"`
+	// Expected is the expected result of the query, which is the data of the
+	// GraphQL response. If it is given, running the query fails if the result
+	// is not equal to it
+	Expected cty.Value `hcl:"expected,optional"`
 }
 
 // QueryDeclarations is a wrapper for a slice of QueryDeclaration
@@ -67,3 +138,9 @@ type QueryDeclarations []*QueryDeclaration
 type QueryDeclaration struct {
 	Name string `hcl:",label"`
 }
+
+// Validate decodes the spec of the query without any inputs
+func (q *Query) Validate(bCtx *env.BubblyContext) error {
+	var spec querySpec
+	return common.ValidateBody(bCtx, q.SpecHCL.Body, &spec)
+}
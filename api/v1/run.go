@@ -12,6 +12,7 @@ import (
 )
 
 var _ core.Run = (*Run)(nil)
+var _ core.Validator = (*Run)(nil)
 
 type Run struct {
 	*core.ResourceBlock
@@ -30,12 +31,15 @@ func (p *Run) Run(bCtx *env.BubblyContext, ctx *core.ResourceContext) core.Resou
 		return core.ResourceOutput{
 			ID:     p.String(),
 			Status: events.ResourceRunFailure,
-			Error:  fmt.Errorf(`failed to decode "%s" body spec: %s`, p.String(), err.Error()),
+			Error:  fmt.Errorf(`failed to decode "%s" body spec: %w`, p.String(), err),
 			Value:  cty.NilVal,
 		}
 	}
 
-	_, output := common.RunResourceByID(bCtx, ctx, p.Spec.ResourceID, p.Spec.Inputs.Value())
+	// The inputs given to the run, e.g. with the --set flag of apply,
+	// override the input values of its spec
+	inputs := core.MergeInputs(p.Spec.Inputs.Value(), ctx.Inputs)
+	_, output := common.RunResourceByID(bCtx, ctx, p.Spec.ResourceID, inputs)
 	if output.Error != nil {
 		return core.ResourceOutput{
 			ID:     p.String(),
@@ -62,3 +66,9 @@ type runSpec struct {
 type RemoteBlockSpec struct {
 	Interval string `hcl:"interval,optional"`
 }
+
+// Validate decodes the spec of the run without any inputs
+func (p *Run) Validate(bCtx *env.BubblyContext) error {
+	var spec runSpec
+	return common.ValidateBody(bCtx, p.SpecHCL.Body, &spec)
+}
@@ -37,7 +37,7 @@ func (t *Task) Run(bCtx *env.BubblyContext, ctx *core.ResourceContext) core.Reso
 	if err := common.DecodeBody(bCtx, t.taskBlockSpec.Body, t, ctx); err != nil {
 		return core.ResourceOutput{
 			Status: events.ResourceRunFailure,
-			Error:  fmt.Errorf(`failed to decode task "%s" body spec: %s`, t.Name(), err.Error()),
+			Error:  fmt.Errorf(`failed to decode task "%s" body spec: %w`, t.Name(), err),
 			Value:  cty.NilVal,
 		}
 	}
@@ -13,6 +13,7 @@ import (
 )
 
 var _ core.Transform = (*Transform)(nil)
+var _ core.Validator = (*Transform)(nil)
 
 type Transform struct {
 	*core.ResourceBlock
@@ -31,7 +32,7 @@ func (t *Transform) Run(bCtx *env.BubblyContext, ctx *core.ResourceContext) core
 		return core.ResourceOutput{
 			ID:     t.String(),
 			Status: events.ResourceRunFailure,
-			Error:  fmt.Errorf(`failed to decode "%s" body spec: %s`, t.String(), err.Error()),
+			Error:  fmt.Errorf(`failed to decode "%s" body spec: %w`, t.String(), err),
 			Value:  cty.NilVal,
 		}
 	}
@@ -65,3 +66,9 @@ type transformSpec struct {
 	Locals *core.LocalsDefinition `hcl:"locals,block"`
 	Data   core.DataBlocks        `hcl:"data,block"`
 }
+
+// Validate decodes the spec of the transform without any inputs
+func (t *Transform) Validate(bCtx *env.BubblyContext) error {
+	var spec transformSpec
+	return common.ValidateBody(bCtx, t.SpecHCL.Body, &spec)
+}
@@ -4,47 +4,239 @@ import (
 	"encoding/json"
 	"fmt"
 
+	"github.com/valocode/bubbly/api/core"
 	"github.com/valocode/bubbly/client"
 	"github.com/valocode/bubbly/env"
 	"github.com/valocode/bubbly/parser"
+	"github.com/zclconf/go-cty/cty"
 )
 
-// Apply applies the resources in the file/directory filename
-func Apply(bCtx *env.BubblyContext, filename string) error {
+// ApplyStatus is the status of applying a single resource
+type ApplyStatus string
+
+const (
+	// ApplySucceeded means the resource was applied
+	ApplySucceeded ApplyStatus = "applied"
+	// ApplyFailed means the resource failed to apply
+	ApplyFailed ApplyStatus = "failed"
+)
+
+// ResourceApplyResult is the outcome of applying a single resource
+type ResourceApplyResult struct {
+	Name   string
+	Kind   core.ResourceKind
+	Status ApplyStatus
+	// Err is the reason the resource failed to apply, if it failed
+	Err error
+}
+
+// ApplyReport contains the outcome of applying each resource, in the order
+// that they were applied
+type ApplyReport struct {
+	Resources []ResourceApplyResult
+}
+
+// Failed returns the number of resources that failed to apply
+func (r *ApplyReport) Failed() int {
+	var failed int
+	for _, res := range r.Resources {
+		if res.Status == ApplyFailed {
+			failed++
+		}
+	}
+	return failed
+}
+
+// ApplyOption is an option for applying resources
+type ApplyOption func(*applyOptions)
+
+// applyOptions contains the options for applying resources
+type applyOptions struct {
+	atomic   bool
+	inputs   cty.Value
+	progress core.ProgressFunc
+}
+
+// WithAtomic applies the resources atomically: either all the resources are
+// applied or, if any of them fails, none of them are
+func WithAtomic() ApplyOption {
+	return func(o *applyOptions) {
+		o.atomic = true
+	}
+}
+
+// WithInputs gives input values, as returned by core.ParseInputValues, to the
+// resources that are run locally by run resources. They override the input
+// values given by the run resources and the defaults of the inputs
+func WithInputs(inputs cty.Value) ApplyOption {
+	return func(o *applyOptions) {
+		o.inputs = inputs
+	}
+}
+
+// WithProgress reports the progress of the resources that are run locally
+// reading their input, such as extracts reading large files, to fn
+func WithProgress(fn core.ProgressFunc) ApplyOption {
+	return func(o *applyOptions) {
+		o.progress = fn
+	}
+}
+
+func newApplyOptions(opts []ApplyOption) *applyOptions {
+	var options applyOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+	return &options
+}
+
+// Apply applies the resources in the file/directory filename, and returns a
+// report of the outcome for each resource. If any resource fails to apply,
+// the others are still applied, unless applying atomically (see WithAtomic),
+// and both the report and an error are returned
+func Apply(bCtx *env.BubblyContext, filename string, opts ...ApplyOption) (*ApplyReport, error) {
 
 	var fileParser BubblyFileParser
 	if err := parser.ParseFilename(bCtx, filename, &fileParser); err != nil {
-		return fmt.Errorf("failed to run parser: %w", err)
+		return nil, fmt.Errorf("failed to run parser: %w", err)
 	}
+	report, err := applyResources(bCtx, fileParser, newApplyOptions(opts))
+	if err != nil {
+		return report, fmt.Errorf(`failed to apply resources in file/directory "%s": %w`, filename, err)
+	}
+	return report, nil
+}
+
+// ApplyBytes applies the resources in the HCL source src, like Apply. The name
+// is used to identify the source, e.g. in error messages, and does not need to
+// exist on disk
+func ApplyBytes(bCtx *env.BubblyContext, name string, src []byte, opts ...ApplyOption) (*ApplyReport, error) {
+
+	var fileParser BubblyFileParser
+	if err := parser.ParseBytes(bCtx, name, src, &fileParser); err != nil {
+		return nil, fmt.Errorf("failed to run parser: %w", err)
+	}
+	// The resources were not parsed from a file, so take the raw spec of each
+	// resource from the source
+	for _, resBlock := range fileParser.ResourceBlocks {
+		if err := resBlock.SetSpecFromSource(src); err != nil {
+			return nil, fmt.Errorf("failed to get spec for resource %s: %w", resBlock.String(), err)
+		}
+	}
+	report, err := applyResources(bCtx, fileParser, newApplyOptions(opts))
+	if err != nil {
+		return report, fmt.Errorf(`failed to apply resources in "%s": %w`, name, err)
+	}
+	return report, nil
+}
+
+// applyResources creates the resources from the parsed file, posts them to
+// bubbly and runs any run resources. The run resources are only run if all
+// the resources were applied
+func applyResources(bCtx *env.BubblyContext, fileParser BubblyFileParser, options *applyOptions) (*ApplyReport, error) {
+	setNamespace(bCtx, fileParser.ResourceBlocks)
+
+	// Sort the resources so that they are applied (and run) after the
+	// resources that they depend on
+	resBlocks, err := core.SortResourceBlocks(fileParser.ResourceBlocks)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve resource dependencies: %w", err)
+	}
+	fileParser.ResourceBlocks = resBlocks
+
 	resources, err := CreateResources(bCtx, fileParser)
 	if err != nil {
-		return fmt.Errorf("failed to parse resources: %w", err)
+		return nil, fmt.Errorf("failed to parse resources: %w", err)
 	}
 
-	client, err := client.New(bCtx)
+	bubblyClient, err := client.New(bCtx)
 	if err != nil {
-		return fmt.Errorf("failed to create bubbly client: %w", err)
+		return nil, fmt.Errorf("failed to create bubbly client: %w", err)
 	}
-	defer client.Close()
+	defer bubblyClient.Close()
+	// Fail fast if bubbly cannot be reached or has an incompatible version,
+	// rather than when posting the first resource
+	if err := client.CheckVersion(bCtx, bubblyClient); err != nil {
+		return nil, err
+	}
+
+	var report *ApplyReport
+	if options.atomic {
+		report = postResourcesAtomic(bCtx, bubblyClient, resources)
+	} else {
+		report = postResources(bCtx, bubblyClient, resources)
+	}
+	if failed := report.Failed(); failed > 0 {
+		return report, fmt.Errorf("%d of %d resources failed to apply", failed, len(report.Resources))
+	}
+
+	if err := runResources(bCtx, resources, options.inputs, options.progress); err != nil {
+		return report, fmt.Errorf("failed to run resources: %w", err)
+	}
+
+	return report, nil
+}
 
+// postResources posts the resources to bubbly one by one, so that a resource
+// that fails to apply does not stop the others from being applied
+func postResources(bCtx *env.BubblyContext, bubblyClient client.Client, resources []core.Resource) *ApplyReport {
+	report := &ApplyReport{
+		Resources: make([]ResourceApplyResult, 0, len(resources)),
+	}
 	for _, res := range resources {
 		bCtx.Logger.Debug().Msgf("Applying resource %s", res.String())
-		resByte, err := json.Marshal(res)
-		if err != nil {
-			return fmt.Errorf("failed to convert resource %s to json: %w", res.String(), err)
+		result := ResourceApplyResult{
+			Name:   res.Name(),
+			Kind:   res.Kind(),
+			Status: ApplySucceeded,
 		}
-		err = client.PostResource(bCtx, nil, resByte)
-		if err != nil {
-			return fmt.Errorf("failed to post resource: %w", err)
+		if err := postResource(bCtx, bubblyClient, res); err != nil {
+			result.Status = ApplyFailed
+			result.Err = err
 		}
-		// Print the name of the resource that has just been applied to give
-		// user feedback
-		fmt.Println(res.ID())
+		report.Resources = append(report.Resources, result)
+	}
+	return report
+}
+
+// postResourcesAtomic posts the resources to bubbly in a single request, so
+// that either all of them are applied or none are. If posting fails, every
+// resource has failed to apply
+func postResourcesAtomic(bCtx *env.BubblyContext, bubblyClient client.Client, resources []core.Resource) *ApplyReport {
+	bCtx.Logger.Debug().Msgf("Applying %d resources atomically", len(resources))
+	resByte, err := json.Marshal(resources)
+	if err != nil {
+		err = fmt.Errorf("failed to convert resources to json: %w", err)
+	} else if err = bubblyClient.PostResources(bCtx, nil, resByte); err != nil {
+		err = fmt.Errorf("failed to post resources: %w", err)
 	}
 
-	if err := runResources(bCtx, resources); err != nil {
-		return fmt.Errorf(`failed to run resources in file/directory "%s": %w`, filename, err)
+	report := &ApplyReport{
+		Resources: make([]ResourceApplyResult, 0, len(resources)),
+	}
+	for _, res := range resources {
+		result := ResourceApplyResult{
+			Name:   res.Name(),
+			Kind:   res.Kind(),
+			Status: ApplySucceeded,
+		}
+		if err != nil {
+			result.Status = ApplyFailed
+			result.Err = err
+		}
+		report.Resources = append(report.Resources, result)
 	}
+	return report
+}
 
+// postResource posts a single resource to bubbly
+func postResource(bCtx *env.BubblyContext, bubblyClient client.Client, res core.Resource) error {
+	resByte, err := json.Marshal(res)
+	if err != nil {
+		return fmt.Errorf("failed to convert resource %s to json: %w", res.String(), err)
+	}
+	if err := bubblyClient.PostResource(bCtx, nil, resByte); err != nil {
+		return fmt.Errorf("failed to post resource: %w", err)
+	}
 	return nil
 }
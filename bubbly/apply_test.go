@@ -0,0 +1,159 @@
+package bubbly
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/h2non/gock.v1"
+
+	"github.com/valocode/bubbly/api"
+	"github.com/valocode/bubbly/api/core"
+	"github.com/valocode/bubbly/env"
+	"github.com/valocode/bubbly/events"
+)
+
+const greetingResourceKind core.ResourceKind = "greeting"
+
+// greeting is a resource kind that is not built in to bubbly
+type greeting struct {
+	*core.ResourceBlock
+}
+
+func (g *greeting) Run(bCtx *env.BubblyContext, ctx *core.ResourceContext) core.ResourceOutput {
+	return core.ResourceOutput{
+		ID:     g.String(),
+		Status: events.ResourceRunSuccess,
+	}
+}
+
+func TestApplyRegisteredResource(t *testing.T) {
+	src := []byte(`
+resource "greeting" "hello" {
+    spec {
+        message = "hello"
+    }
+}
+`)
+	bCtx := env.NewBubblyContext()
+
+	_, err := ApplyBytes(bCtx, "greeting.bubbly", src)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `resource not supported: "greeting"`)
+
+	err = api.RegisterResource(greetingResourceKind, func(resBlock *core.ResourceBlock) (core.Resource, error) {
+		return &greeting{ResourceBlock: resBlock}, nil
+	})
+	require.NoError(t, err)
+	err = api.RegisterResource(greetingResourceKind, func(resBlock *core.ResourceBlock) (core.Resource, error) {
+		return &greeting{ResourceBlock: resBlock}, nil
+	})
+	assert.Error(t, err, "a kind can only be registered once")
+	assert.Error(t, api.RegisterResource(core.ExtractResourceKind, func(resBlock *core.ResourceBlock) (core.Resource, error) {
+		return &greeting{ResourceBlock: resBlock}, nil
+	}), "built in kinds cannot be replaced")
+
+	defer gock.Off()
+	gock.New(bCtx.ClientConfig.BubblyAddr).
+		Get("/api/v1/version").
+		Reply(http.StatusOK).
+		JSON(map[string]string{"version": env.Version})
+	gock.New(bCtx.ClientConfig.BubblyAddr).
+		Post("/api/v1/resource").
+		Reply(http.StatusOK)
+
+	report, err := ApplyBytes(bCtx, "greeting.bubbly", src)
+	require.NoError(t, err)
+	assert.True(t, gock.IsDone())
+	require.Len(t, report.Resources, 1)
+	assert.Equal(t, ResourceApplyResult{
+		Name:   "hello",
+		Kind:   greetingResourceKind,
+		Status: ApplySucceeded,
+	}, report.Resources[0])
+}
+
+func TestApplyQuery(t *testing.T) {
+	const queryResult = `{"data": {"test_run": [{"name": "unit", "passed": true}]}}`
+	tcs := []struct {
+		desc string
+		src  string
+		// queried is whether the query is run when it is applied
+		queried bool
+		err     string
+	}{
+		{
+			desc: "without expected result",
+			src: `
+resource "query" "test_runs" {
+    spec {
+        query = "{ test_run { name passed } }"
+    }
+}
+`,
+		},
+		{
+			desc: "expected result matches",
+			src: `
+resource "query" "test_runs" {
+    spec {
+        query = "{ test_run { name passed } }"
+        expected = {
+            test_run = [{name = "unit", passed = true}]
+        }
+    }
+}
+`,
+			queried: true,
+		},
+		{
+			desc: "expected result does not match",
+			src: `
+resource "query" "test_runs" {
+    spec {
+        query = "{ test_run { name passed } }"
+        expected = {
+            test_run = [{name = "unit", passed = false}]
+        }
+    }
+}
+`,
+			queried: true,
+			err:     "result does not match the expected result",
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.desc, func(t *testing.T) {
+			bCtx := env.NewBubblyContext()
+			defer gock.Off()
+			gock.New(bCtx.ClientConfig.BubblyAddr).
+				Get("/api/v1/version").
+				Reply(http.StatusOK).
+				JSON(map[string]string{"version": env.Version})
+			gock.New(bCtx.ClientConfig.BubblyAddr).
+				Post("/api/v1/resource").
+				Reply(http.StatusOK)
+			query := gock.New(bCtx.ClientConfig.BubblyAddr).
+				Post("/api/v1/graphql")
+			query.Reply(http.StatusOK).BodyString(queryResult)
+			// The run of the query is logged as an event
+			gock.New(bCtx.ClientConfig.BubblyAddr).
+				Post("/api/v1/upload").
+				Persist().
+				Reply(http.StatusOK)
+
+			report, err := ApplyBytes(bCtx, "query.bubbly", []byte(tc.src))
+			assert.Equal(t, tc.queried, query.Mock.Done(), "query was run")
+			require.NotNil(t, report)
+			require.Len(t, report.Resources, 1)
+			assert.Equal(t, ApplySucceeded, report.Resources[0].Status)
+			if tc.err != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tc.err)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
@@ -19,17 +19,18 @@ type Schema_Wrap struct {
 // _RESOURCE
 // #######################################
 type Resource struct {
-	DBlock_Table  string                 `json:"_resource,omitempty"`
-	DBlock_Policy core.DataBlockPolicy   `json:"-"`
-	DBlock_Joins  []string               `json:"-"`
-	Id            string                 `json:"id,omitempty"`
-	Name          string                 `json:"name,omitempty"`
-	Kind          string                 `json:"kind,omitempty"`
-	ApiVersion    string                 `json:"api_version,omitempty"`
-	Spec          string                 `json:"spec,omitempty"`
-	Metadata      map[string]interface{} `json:"metadata,omitempty"`
-	Event         []Event                `json:"_event,omitempty"`
-	ReleaseEntry  []ReleaseEntry         `json:"release_entry,omitempty"`
+	DBlock_Table    string                 `json:"_resource,omitempty"`
+	DBlock_Policy   core.DataBlockPolicy   `json:"-"`
+	DBlock_Joins    []string               `json:"-"`
+	Id              string                 `json:"id,omitempty"`
+	Name            string                 `json:"name,omitempty"`
+	Kind            string                 `json:"kind,omitempty"`
+	ApiVersion      string                 `json:"api_version,omitempty"`
+	Spec            string                 `json:"spec,omitempty"`
+	Metadata        map[string]interface{} `json:"metadata,omitempty"`
+	Event           []Event                `json:"_event,omitempty"`
+	ResourceVersion []ResourceVersion      `json:"_resource_version,omitempty"`
+	ReleaseEntry    []ReleaseEntry         `json:"release_entry,omitempty"`
 }
 type Resource_Wrap struct {
 	Resource []Resource `json:"_resource,omitempty"`
@@ -51,6 +52,25 @@ type Event_Wrap struct {
 	Event []Event `json:"_event,omitempty"`
 }
 
+// #######################################
+// _RESOURCE_VERSION
+// #######################################
+type ResourceVersion struct {
+	DBlock_Table  string                 `json:"_resource_version,omitempty"`
+	DBlock_Policy core.DataBlockPolicy   `json:"-"`
+	DBlock_Joins  []string               `json:"-"`
+	Name          string                 `json:"name,omitempty"`
+	Kind          string                 `json:"kind,omitempty"`
+	ApiVersion    string                 `json:"api_version,omitempty"`
+	Spec          string                 `json:"spec,omitempty"`
+	Metadata      map[string]interface{} `json:"metadata,omitempty"`
+	Time          string                 `json:"time,omitempty"`
+	Resource      *Resource              `json:"_resource,omitempty"`
+}
+type ResourceVersion_Wrap struct {
+	ResourceVersion []ResourceVersion `json:"_resource_version,omitempty"`
+}
+
 // #######################################
 // RELEASE_ENTRY
 // #######################################
@@ -19,6 +19,7 @@ export interface _resource {
 	spec?: string;
 	metadata?: object;
 	_event?: _event[];
+	_resource_version?: _resource_version[];
 	release_entry?: release_entry[];
 }
 export interface _resource_wrap {
@@ -38,6 +39,22 @@ export interface _event_wrap {
 	_event?: _event[];
 }
 
+// #######################################
+// _RESOURCE_VERSION
+// #######################################
+export interface _resource_version {
+	name?: string;
+	kind?: string;
+	api_version?: string;
+	spec?: string;
+	metadata?: object;
+	time?: string;
+	_resource?: _resource;
+}
+export interface _resource_version_wrap {
+	_resource_version?: _resource_version[];
+}
+
 // #######################################
 // RELEASE_ENTRY
 // #######################################
@@ -44,6 +44,22 @@ var BuiltinTables = core.Tables{
 		),
 	),
 	// #######################################
+	// _RESOURCE_VERSION
+	// #######################################
+	table("_resource_version",
+		fields(
+			field("name", cty.String, false),
+			field("kind", cty.String, false),
+			field("api_version", cty.String, false),
+			field("spec", cty.String, false),
+			field("metadata", cty.Object(map[string]cty.Type{"labels": cty.Map(cty.String)}), false),
+			field("time", cty.String, false),
+		),
+		joins(
+			join("_resource", false, false),
+		),
+	),
+	// #######################################
 	// RELEASE_ENTRY
 	// #######################################
 	table("release_entry",
@@ -0,0 +1,71 @@
+package bubbly
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/valocode/bubbly/api/core"
+	"github.com/valocode/bubbly/client"
+	"github.com/valocode/bubbly/env"
+	"github.com/valocode/bubbly/parser"
+)
+
+// Delete deletes the resources declared in the file/directory filename from
+// bubbly. If recursive is true, the resources in subdirectories of a directory
+// are also deleted.
+// Resources which do not exist are skipped with a warning, unless strict is
+// true, in which case they produce an error
+func Delete(bCtx *env.BubblyContext, filename string, recursive bool, strict bool) error {
+	var fileParser BubblyFileParser
+	parse := parser.ParseFilename
+	if recursive {
+		parse = parser.ParseFilenameRecursive
+	}
+	if err := parse(bCtx, filename, &fileParser); err != nil {
+		return fmt.Errorf("failed to run parser: %w", err)
+	}
+	if err := deleteResources(bCtx, fileParser, strict); err != nil {
+		return fmt.Errorf(`failed to delete resources in file/directory "%s": %w`, filename, err)
+	}
+	return nil
+}
+
+// deleteResources deletes the resources from the parsed file, deleting those
+// that depend on other resources first
+func deleteResources(bCtx *env.BubblyContext, fileParser BubblyFileParser, strict bool) error {
+	setNamespace(bCtx, fileParser.ResourceBlocks)
+	resBlocks, err := core.SortResourceBlocks(fileParser.ResourceBlocks)
+	if err != nil {
+		return fmt.Errorf("failed to resolve resource dependencies: %w", err)
+	}
+
+	bubblyClient, err := client.New(bCtx)
+	if err != nil {
+		return fmt.Errorf("failed to create bubbly client: %w", err)
+	}
+	defer bubblyClient.Close()
+
+	var notFound []string
+	for idx := len(resBlocks) - 1; idx >= 0; idx-- {
+		resID := resBlocks[idx].ID()
+		bCtx.Logger.Debug().Msgf("Deleting resource %s", resID)
+		if err := bubblyClient.DeleteResource(bCtx, nil, resID); err != nil {
+			if !errors.Is(err, client.ErrResourceNotFound) {
+				return fmt.Errorf("failed to delete resource: %w", err)
+			}
+			if !strict {
+				bCtx.Logger.Warn().Msgf("Resource %s does not exist, skipping", resID)
+				continue
+			}
+			notFound = append(notFound, resID)
+			continue
+		}
+		// Print the name of the resource that has just been deleted to give
+		// user feedback
+		fmt.Println(resID)
+	}
+	if len(notFound) > 0 {
+		return fmt.Errorf("resources do not exist: %v", notFound)
+	}
+	return nil
+}
@@ -0,0 +1,190 @@
+package bubbly
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+const gitSourcePrefix = "git::"
+
+// GitSource is a Git URL pointing at bubbly configuration, in the style of
+// go-getter: git::<repository URL>//<path in repository>?ref=<branch or tag>
+type GitSource struct {
+	// Repo is the URL of the repository to clone
+	Repo string
+	// Path is the file or directory within the repository, which is the root
+	// of the repository if empty
+	Path string
+	// Ref is the branch or tag to clone, which is the default branch if empty
+	Ref string
+}
+
+func (s GitSource) String() string {
+	src := gitSourcePrefix + s.Repo
+	if s.Path != "" {
+		src += "//" + s.Path
+	}
+	if s.Ref != "" {
+		src += "?ref=" + s.Ref
+	}
+	return src
+}
+
+// Getter fetches a Git source into a local directory
+type Getter interface {
+	Get(dst string, src GitSource) error
+}
+
+// GitGetter is a Getter which shallow clones the repository using git
+type GitGetter struct{}
+
+// Get shallow clones the repository of src into dst
+func (GitGetter) Get(dst string, src GitSource) error {
+	args := []string{"clone", "--quiet", "--depth", "1"}
+	if src.Ref != "" {
+		args = append(args, "--branch", src.Ref)
+	}
+	args = append(args, "--", src.Repo, dst)
+	out, err := exec.Command("git", args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("git clone failed: %s: %w", strings.TrimSpace(string(out)), err)
+	}
+	return nil
+}
+
+// IsGitSource returns whether filename is a Git URL rather than a local
+// file or directory
+func IsGitSource(filename string) bool {
+	return strings.HasPrefix(filename, gitSourcePrefix)
+}
+
+// ParseGitSource parses a go-getter style Git URL, such as
+// git::https://host/repo//path?ref=v1
+func ParseGitSource(src string) (GitSource, error) {
+	if !IsGitSource(src) {
+		return GitSource{}, fmt.Errorf(`git source must start with "%s": %s`, gitSourcePrefix, src)
+	}
+	repo, subPath := splitSubPath(strings.TrimPrefix(src, gitSourcePrefix))
+
+	// Split the query parameters from the repository URL, rather than parsing
+	// it as a URL, so that scp-like URLs (git@host:repo) also work
+	var rawQuery string
+	if idx := strings.Index(repo, "?"); idx > -1 {
+		repo, rawQuery = repo[:idx], repo[idx+1:]
+	}
+	query, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return GitSource{}, fmt.Errorf("invalid git source %s: %w", src, err)
+	}
+	ref := query.Get("ref")
+	if _, ok := query["ref"]; ok {
+		if err := validateGitRef(ref); err != nil {
+			return GitSource{}, fmt.Errorf("invalid git source %s: %w", src, err)
+		}
+	}
+	// The ref is not part of the repository URL, but any other parameters are
+	query.Del("ref")
+	if len(query) > 0 {
+		repo += "?" + query.Encode()
+	}
+	if repo == "" {
+		return GitSource{}, fmt.Errorf("invalid git source %s: no repository", src)
+	}
+
+	if subPath != "" {
+		subPath = path.Clean(subPath)
+		if path.IsAbs(subPath) || subPath == ".." || strings.HasPrefix(subPath, "../") {
+			return GitSource{}, fmt.Errorf("invalid git source %s: path %s is outside of the repository", src, subPath)
+		}
+	}
+
+	return GitSource{
+		Repo: repo,
+		Path: subPath,
+		Ref:  ref,
+	}, nil
+}
+
+// splitSubPath splits the path within the repository, which follows a double
+// slash, from the repository URL. Query parameters belong to the repository
+// URL
+func splitSubPath(src string) (string, string) {
+	// Skip the "://" of the scheme, so that it is not taken as the separator
+	var offset int
+	if idx := strings.Index(src, "://"); idx > -1 {
+		offset = idx + len("://")
+	}
+	idx := strings.Index(src[offset:], "//")
+	if idx == -1 {
+		return src, ""
+	}
+	idx += offset
+
+	repo, subPath := src[:idx], src[idx+len("//"):]
+	if queryIdx := strings.Index(subPath, "?"); queryIdx > -1 {
+		repo += subPath[queryIdx:]
+		subPath = subPath[:queryIdx]
+	}
+	return repo, subPath
+}
+
+// validateGitRef checks that ref can be a branch or tag name, following the
+// rules of git check-ref-format
+func validateGitRef(ref string) error {
+	switch {
+	case ref == "":
+		return fmt.Errorf("ref must not be empty")
+	case strings.HasPrefix(ref, "-"), strings.HasPrefix(ref, "/"), strings.HasSuffix(ref, "/"),
+		strings.HasSuffix(ref, "."), strings.HasSuffix(ref, ".lock"),
+		strings.Contains(ref, ".."), strings.Contains(ref, "//"), strings.Contains(ref, "@{"),
+		strings.ContainsAny(ref, " ~^:?*[\\"):
+		return fmt.Errorf("invalid ref %q", ref)
+	}
+	for _, r := range ref {
+		if r < 0x20 || r == 0x7f {
+			return fmt.Errorf("invalid ref %q", ref)
+		}
+	}
+	return nil
+}
+
+// FetchFilename returns the local file or directory for filename. If filename
+// is a Git URL, the repository is fetched with getter into a temporary
+// directory and the path to the referenced file or directory is returned.
+// The returned cleanup function removes anything that was fetched
+func FetchFilename(getter Getter, filename string) (string, func(), error) {
+	if !IsGitSource(filename) {
+		return filename, func() {}, nil
+	}
+	src, err := ParseGitSource(filename)
+	if err != nil {
+		return "", nil, err
+	}
+
+	dir, err := os.MkdirTemp("", "bubbly-git-")
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to create directory for git source: %w", err)
+	}
+	cleanup := func() {
+		os.RemoveAll(dir)
+	}
+	if err := getter.Get(dir, src); err != nil {
+		cleanup()
+		if src.Ref != "" {
+			return "", nil, fmt.Errorf("failed to fetch ref %s of %s: %w", src.Ref, src.Repo, err)
+		}
+		return "", nil, fmt.Errorf("failed to fetch %s: %w", src.Repo, err)
+	}
+
+	localPath := filepath.Join(dir, filepath.FromSlash(src.Path))
+	if _, err := os.Stat(localPath); err != nil {
+		cleanup()
+		return "", nil, fmt.Errorf("path %s does not exist in %s: %w", src.Path, src.Repo, err)
+	}
+	return localPath, cleanup, nil
+}
@@ -0,0 +1,155 @@
+package bubbly
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/valocode/bubbly/env"
+	"github.com/valocode/bubbly/parser"
+)
+
+func TestParseGitSource(t *testing.T) {
+	tcs := []struct {
+		desc   string
+		src    string
+		source GitSource
+		err    string
+	}{
+		{
+			desc:   "repository",
+			src:    "git::https://example.com/org/repo.git",
+			source: GitSource{Repo: "https://example.com/org/repo.git"},
+		},
+		{
+			desc: "path and ref",
+			src:  "git::https://example.com/org/repo.git//resources/main?ref=v1",
+			source: GitSource{
+				Repo: "https://example.com/org/repo.git",
+				Path: "resources/main",
+				Ref:  "v1",
+			},
+		},
+		{
+			desc: "scp-like url",
+			src:  "git::git@example.com:org/repo.git//resources?ref=main",
+			source: GitSource{
+				Repo: "git@example.com:org/repo.git",
+				Path: "resources",
+				Ref:  "main",
+			},
+		},
+		{
+			desc: "other query parameters",
+			src:  "git::https://example.com/org/repo.git?ref=v1&token=abc",
+			source: GitSource{
+				Repo: "https://example.com/org/repo.git?token=abc",
+				Ref:  "v1",
+			},
+		},
+		{
+			desc: "not a git source",
+			src:  "./resources",
+			err:  `git source must start with "git::": ./resources`,
+		},
+		{
+			desc: "empty ref",
+			src:  "git::https://example.com/org/repo.git?ref=",
+			err:  "invalid git source git::https://example.com/org/repo.git?ref=: ref must not be empty",
+		},
+		{
+			desc: "invalid ref",
+			src:  "git::https://example.com/org/repo.git?ref=v1..v2",
+			err:  `invalid git source git::https://example.com/org/repo.git?ref=v1..v2: invalid ref "v1..v2"`,
+		},
+		{
+			desc: "path outside repository",
+			src:  "git::https://example.com/org/repo.git//../other",
+			err:  "invalid git source git::https://example.com/org/repo.git//../other: path ../other is outside of the repository",
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.desc, func(t *testing.T) {
+			source, err := ParseGitSource(tc.src)
+			if tc.err != "" {
+				require.EqualError(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.source, source)
+		})
+	}
+}
+
+const fetchTestResource = `
+resource "extract" "remote" {
+	spec {
+		type = "json"
+	}
+}
+`
+
+var errUnknownRef = errors.New("remote branch not found")
+
+// fakeGetter writes a bubbly file into the destination instead of cloning a
+// repository, and only knows about the ref v1
+type fakeGetter struct {
+	src GitSource
+}
+
+func (g *fakeGetter) Get(dst string, src GitSource) error {
+	g.src = src
+	if src.Ref != "v1" {
+		return errUnknownRef
+	}
+	dir := filepath.Join(dst, "resources")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, "main.bubbly"), []byte(fetchTestResource), 0644)
+}
+
+func TestFetchFilename(t *testing.T) {
+	bCtx := env.NewBubblyContext()
+
+	t.Run("local file", func(t *testing.T) {
+		filename, cleanup, err := FetchFilename(&fakeGetter{}, "./resources")
+		require.NoError(t, err)
+		defer cleanup()
+		assert.Equal(t, "./resources", filename)
+	})
+
+	t.Run("git source", func(t *testing.T) {
+		getter := &fakeGetter{}
+		filename, cleanup, err := FetchFilename(getter, "git::https://example.com/org/repo.git//resources?ref=v1")
+		require.NoError(t, err)
+		assert.Equal(t, GitSource{Repo: "https://example.com/org/repo.git", Path: "resources", Ref: "v1"}, getter.src)
+
+		// The fetched path should be parsed like any local directory
+		var fileParser BubblyFileParser
+		require.NoError(t, parser.ParseFilename(bCtx, filename, &fileParser))
+		require.Len(t, fileParser.ResourceBlocks, 1)
+		assert.Equal(t, "extract/remote", fileParser.ResourceBlocks[0].ID())
+
+		cleanup()
+		_, err = os.Stat(filename)
+		assert.True(t, os.IsNotExist(err), "fetched files should be removed")
+	})
+
+	t.Run("unknown ref", func(t *testing.T) {
+		_, _, err := FetchFilename(&fakeGetter{}, "git::https://example.com/org/repo.git//resources?ref=v2")
+		require.Error(t, err)
+		assert.True(t, errors.Is(err, errUnknownRef))
+		assert.Contains(t, err.Error(), "failed to fetch ref v2 of https://example.com/org/repo.git")
+	})
+
+	t.Run("missing path", func(t *testing.T) {
+		_, _, err := FetchFilename(&fakeGetter{}, "git::https://example.com/org/repo.git//other?ref=v1")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "path other does not exist in https://example.com/org/repo.git")
+	})
+}
@@ -0,0 +1,28 @@
+package bubbly
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/zclconf/go-cty/cty"
+
+	v1 "github.com/valocode/bubbly/api/v1"
+	"github.com/valocode/bubbly/env"
+)
+
+// InferFormat reads the sample JSON file and infers the format of a json
+// extract source for it. See v1.InferJSONFormat for how the format is inferred
+func InferFormat(bCtx *env.BubblyContext, file string) (cty.Type, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return cty.NilType, fmt.Errorf(`failed to open file "%s": %w`, filepath.ToSlash(file), err)
+	}
+	defer f.Close()
+
+	ty, err := v1.InferJSONFormat(f)
+	if err != nil {
+		return cty.NilType, fmt.Errorf(`failed to infer the format of file "%s": %w`, filepath.ToSlash(file), err)
+	}
+	return ty, nil
+}
@@ -24,9 +24,12 @@ func CreateResources(bCtx *env.BubblyContext, fileParser BubblyFileParser) ([]co
 }
 
 // runResources runs all resources of ResourceRun kind provided by the
-// resource parser. On failure/success, it sends the ResourceRun kind's
-// resource output to the bubbly event store
-func runResources(bCtx *env.BubblyContext, allResources []core.Resource) error {
+// resource parser, and the queries with an expected result. On
+// failure/success, it sends the ResourceRun kind's resource output to the
+// bubbly event store. The inputs, if any, override the
+// input values of the runs, and progress, if not nil, is called with the
+// progress of the resources reading their input
+func runResources(bCtx *env.BubblyContext, allResources []core.Resource, inputs cty.Value, progress core.ProgressFunc) error {
 	for _, kind := range core.ResourceRunKinds() {
 		bCtx.Logger.Debug().Msgf("Running resource kinds %s", kind)
 		resources := resourcesByKind(allResources, kind)
@@ -45,15 +48,24 @@ func runResources(bCtx *env.BubblyContext, allResources []core.Resource) error {
 
 				if r.Spec.Remote != nil {
 					bCtx.Logger.Debug().Str("resource", r.String()).Msg("run is of type remote and therefore should only be run by a bubbly worker")
+					if inputs != cty.NilVal {
+						bCtx.Logger.Warn().Str("resource", r.String()).Msg("input values are not given to remote runs")
+					}
 					continue
 				} else {
 					bCtx.Logger.Debug().Str("resource", r.String()).Msg("run is of type local")
 				}
 			}
+			// Queries are also run by criteria, and are only run on their
+			// own if they check their result
+			if kind == core.QueryResourceKind && !resource.(*v1.Query).Asserts() {
+				continue
+			}
 
 			bCtx.Logger.Debug().Msgf("Running resource %s ...", resource.String())
 			ctx := core.NewResourceContext(cty.NilVal, api.NewResource, nil)
-			output := common.RunResource(bCtx, ctx, resource, cty.NilVal)
+			ctx.Progress = progress
+			output := common.RunResource(bCtx, ctx, resource, inputs)
 			if output.Error != nil {
 				return output.Error
 			}
@@ -62,6 +74,20 @@ func runResources(bCtx *env.BubblyContext, allResources []core.Resource) error {
 	return nil
 }
 
+// setNamespace puts the resource blocks which are not given a namespace in
+// their metadata in the namespace of the bubbly client config
+func setNamespace(bCtx *env.BubblyContext, resBlocks core.ResourceBlocks) {
+	namespace := bCtx.ClientConfig.Namespace
+	if namespace == "" || namespace == core.DefaultNamespace {
+		return
+	}
+	for _, resBlock := range resBlocks {
+		if resBlock.Metadata == nil || resBlock.Metadata.Namespace == "" {
+			resBlock.SetNamespace(namespace)
+		}
+	}
+}
+
 func resourcesByKind(resources []core.Resource, kind core.ResourceKind) []core.Resource {
 	resByKind := []core.Resource{}
 	for _, res := range resources {
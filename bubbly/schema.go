@@ -5,10 +5,12 @@ import (
 	"fmt"
 	"path/filepath"
 
+	"github.com/valocode/bubbly/api/core"
 	"github.com/valocode/bubbly/bubbly/builtin"
 	"github.com/valocode/bubbly/client"
 	"github.com/valocode/bubbly/env"
 	"github.com/valocode/bubbly/parser"
+	"github.com/valocode/bubbly/store"
 )
 
 // Schema is the Go-native struct representation of a bubbly
@@ -44,3 +46,82 @@ func ApplySchema(bCtx *env.BubblyContext, file string) error {
 
 	return nil
 }
+
+// ExportSchema gets the GraphQL schema from the bubbly store as SDL
+func ExportSchema(bCtx *env.BubblyContext) ([]byte, error) {
+	c, err := client.New(bCtx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create bubbly HTTP client: %w", err)
+	}
+	defer c.Close()
+
+	sdl, err := c.GetSchemaSDL(bCtx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get schema from bubbly server: %w", err)
+	}
+	return sdl, nil
+}
+
+// DiffSchema parses the .bubbly schema file, or the schema files in a
+// directory, and returns the changes that applying it would make to the schema
+// currently applied in the bubbly store
+func DiffSchema(bCtx *env.BubblyContext, file string) ([]store.SchemaChange, error) {
+	var schema builtin.SchemaWrapper
+	if err := parser.ParseFilename(bCtx, file, &schema); err != nil {
+		return nil, fmt.Errorf(
+			`failed to parse schema at "%s": %w`,
+			filepath.ToSlash(file),
+			err)
+	}
+
+	c, err := client.New(bCtx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create bubbly HTTP client: %w", err)
+	}
+	defer c.Close()
+
+	currentBytes, err := c.GetSchema(bCtx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get schema from bubbly server: %w", err)
+	}
+	var current map[string]core.Table
+	if err := json.Unmarshal(currentBytes, &current); err != nil {
+		return nil, fmt.Errorf("failed to decode schema from bubbly server: %w", err)
+	}
+
+	return store.DiffSchema(current, schema.Tables)
+}
+
+// PreviewSchema parses the .bubbly schema file, or the schema files in a
+// directory, and returns the SQL statements that applying it would run on the
+// database of the bubbly store, without running them
+func PreviewSchema(bCtx *env.BubblyContext, file string) ([]string, error) {
+	var schema builtin.SchemaWrapper
+	if err := parser.ParseFilename(bCtx, file, &schema); err != nil {
+		return nil, fmt.Errorf(
+			`failed to parse schema at "%s": %w`,
+			filepath.ToSlash(file),
+			err)
+	}
+
+	tableBytes, err := json.Marshal(schema.Tables)
+	if err != nil {
+		return nil, fmt.Errorf("failed to json marshal schema tables: %w", err)
+	}
+
+	c, err := client.New(bCtx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create bubbly HTTP client: %w", err)
+	}
+	defer c.Close()
+
+	stmtBytes, err := c.PreviewSchema(bCtx, nil, tableBytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to preview schema on bubbly server: %w", err)
+	}
+	var stmts []string
+	if err := json.Unmarshal(stmtBytes, &stmts); err != nil {
+		return nil, fmt.Errorf("failed to decode schema preview from bubbly server: %w", err)
+	}
+	return stmts, nil
+}
@@ -0,0 +1,78 @@
+package bubbly
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/hashicorp/hcl/v2"
+
+	"github.com/valocode/bubbly/api"
+	"github.com/valocode/bubbly/api/core"
+	"github.com/valocode/bubbly/env"
+	"github.com/valocode/bubbly/parser"
+)
+
+// Validate parses and decodes the resources in the file/directory filename,
+// without applying them. It does not contact a bubbly server, and so the
+// resources are decoded without any inputs.
+// If any resource is invalid, the returned error contains a
+// *parser.ParserError with the diagnostics for all the invalid resources
+func Validate(bCtx *env.BubblyContext, filename string) error {
+	var fileParser BubblyFileParser
+	if err := parser.ParseFilename(bCtx, filename, &fileParser); err != nil {
+		return fmt.Errorf("failed to run parser: %w", err)
+	}
+	if _, err := core.SortResourceBlocks(fileParser.ResourceBlocks); err != nil {
+		return fmt.Errorf("failed to resolve resource dependencies: %w", err)
+	}
+
+	var diags hcl.Diagnostics
+	for _, resBlock := range fileParser.ResourceBlocks {
+		if err := validateResource(bCtx, resBlock); err != nil {
+			diags = append(diags, resourceDiags(resBlock, err)...)
+		}
+	}
+	if diags.HasErrors() {
+		return fmt.Errorf(`invalid resources in file/directory "%s": %w`, filename, parser.NewParserError(nil, diags))
+	}
+	return nil
+}
+
+// validateResource creates the resource from the resource block and, if the
+// resource supports it, validates its spec
+func validateResource(bCtx *env.BubblyContext, resBlock *core.ResourceBlock) error {
+	resource, err := api.NewResource(resBlock)
+	if err != nil {
+		return fmt.Errorf(`failed to create resource from resource block "%s": %w`, resBlock.String(), err)
+	}
+	validator, ok := resource.(core.Validator)
+	if !ok {
+		return nil
+	}
+	if err := validator.Validate(bCtx); err != nil {
+		return fmt.Errorf(`invalid resource "%s": %w`, resBlock.String(), err)
+	}
+	return nil
+}
+
+// resourceDiags returns the diagnostics for an error from validating a
+// resource. Errors which do not come from the parser do not have a source
+// range, so they are given the range of the resource's spec
+func resourceDiags(resBlock *core.ResourceBlock, err error) hcl.Diagnostics {
+	var parserErr *parser.ParserError
+	if errors.As(err, &parserErr) {
+		return parserErr.Diags
+	}
+	var subject *hcl.Range
+	if resBlock.SpecHCL.Body != nil {
+		subject = resBlock.SpecHCL.Body.MissingItemRange().Ptr()
+	}
+	return hcl.Diagnostics{
+		{
+			Severity: hcl.DiagError,
+			Summary:  "Invalid resource",
+			Detail:   err.Error(),
+			Subject:  subject,
+		},
+	}
+}
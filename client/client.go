@@ -14,23 +14,61 @@ var (
 
 const (
 	defaultHTTPClientTimeout = 5
-	defaultNATSClientTimeout = 2
 )
 
 // Every Client must implement the Client interface's methods
 type Client interface {
 	// Resources
-	GetResource(*env.BubblyContext, *component.MessageAuth, string) ([]byte, error)
+	GetResource(*env.BubblyContext, *component.MessageAuth, string, ...ResourceOption) ([]byte, error)
+	// GetResourceVersions returns the versions of a resource as a JSON list
+	// of ResourceVersion, oldest first
+	GetResourceVersions(*env.BubblyContext, *component.MessageAuth, string) ([]byte, error)
+	// GetResourceVersion returns a resource as it was in the given version,
+	// and returns ErrResourceNotFound if there is no such version
+	GetResourceVersion(*env.BubblyContext, *component.MessageAuth, string, int, ...ResourceOption) ([]byte, error)
+	// GetResourcesByKind returns the resources of a kind (the last argument)
+	// in a namespace as a JSON list of core.ResourceBlock, ordered by name.
+	// An empty namespace is the default namespace
+	GetResourcesByKind(*env.BubblyContext, *component.MessageAuth, string, string) ([]byte, error)
 	PostResource(*env.BubblyContext, *component.MessageAuth, []byte) error
+	// PostResources posts a JSON list of resources, which are either all
+	// saved or none are
+	PostResources(*env.BubblyContext, *component.MessageAuth, []byte) error
 	PostResourceToWorker(*env.BubblyContext, *component.MessageAuth, []byte) error
+	// DeleteResource deletes a resource, and returns ErrResourceNotFound if
+	// the resource does not exist
+	DeleteResource(*env.BubblyContext, *component.MessageAuth, string) error
+	// PatchResource applies a JSON Merge Patch (RFC 7386) to a resource, and
+	// returns ErrResourceNotFound if the resource does not exist, or
+	// ErrInvalidResource if the patched resource would not be valid
+	PatchResource(*env.BubblyContext, *component.MessageAuth, string, []byte) error
 	// Data blocks
-	Load(*env.BubblyContext, *component.MessageAuth, []byte) error
+	Load(*env.BubblyContext, *component.MessageAuth, []byte, ...LoadOption) error
 	// GraphQL Queries
-	Query(*env.BubblyContext, *component.MessageAuth, string) ([]byte, error)
+	Query(*env.BubblyContext, *component.MessageAuth, string, ...QueryOption) ([]byte, error)
 	// GraphQL Queries
 	QueryType(*env.BubblyContext, *component.MessageAuth, string, interface{}) error
+	// QueryCSV performs a GraphQL query and returns the result as CSV, see
+	// ResultCSV
+	QueryCSV(*env.BubblyContext, *component.MessageAuth, string) ([]byte, error)
 	// Applying a schema
 	PostSchema(*env.BubblyContext, *component.MessageAuth, []byte) error
+	// PreviewSchema returns the SQL statements that applying a schema would
+	// run, without running them, as a JSON list
+	PreviewSchema(*env.BubblyContext, *component.MessageAuth, []byte) ([]byte, error)
+	// Getting the GraphQL schema as SDL
+	GetSchemaSDL(*env.BubblyContext, *component.MessageAuth) ([]byte, error)
+	// GetSchema returns the tables of the schema that is currently applied
+	// as a JSON object of the tables by name
+	GetSchema(*env.BubblyContext, *component.MessageAuth) ([]byte, error)
+	// DescribeSchema returns a description of the tables of the schema, with
+	// their fields and relationships, as JSON
+	DescribeSchema(*env.BubblyContext, *component.MessageAuth) ([]byte, error)
+	// GetStatus returns the status of the data store as JSON, such as the
+	// number of tables in the schema
+	GetStatus(*env.BubblyContext, *component.MessageAuth) ([]byte, error)
+	// Version returns the version of the bubbly server
+	Version(*env.BubblyContext) (string, error)
 	// Creates a tenant in the store. Only applicable to NATS
 	CreateTenant(*env.BubblyContext, *component.MessageAuth, string) error
 	// Close closes any connections, e.g. to NATS
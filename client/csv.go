@@ -0,0 +1,180 @@
+package client
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/valocode/bubbly/agent/component"
+	"github.com/valocode/bubbly/env"
+)
+
+// csvNestedSeparator separates the names of the fields of nested objects in
+// the columns of a CSV, e.g. "repo.name"
+const csvNestedSeparator = "."
+
+// QueryCSV uses the bubbly api endpoint to perform a GraphQL query, and
+// returns the result as CSV (see ResultCSV)
+func (c *httpClient) QueryCSV(bCtx *env.BubblyContext, auth *component.MessageAuth, query string) ([]byte, error) {
+	body, err := c.Query(bCtx, auth, query)
+	if err != nil {
+		return nil, err
+	}
+	return ResultCSV(body)
+}
+
+// QueryCSV uses the NATS client to perform a GraphQL query, and returns the
+// result as CSV (see ResultCSV)
+func (n *natsClient) QueryCSV(bCtx *env.BubblyContext, auth *component.MessageAuth, query string) ([]byte, error) {
+	body, err := n.Query(bCtx, auth, query)
+	if err != nil {
+		return nil, err
+	}
+	return ResultCSV(body)
+}
+
+// ResultCSV converts the JSON of a GraphQL result to CSV, with a header row.
+// The query must have a single root field, whose rows are the rows of the
+// CSV. The columns are the fields of the rows, sorted by name, where:
+//   - the fields of nested objects are flattened into columns named by the
+//     path to them, e.g. "repo.name"
+//   - lists are encoded as JSON, with the fields of objects sorted by name
+//   - null values and missing fields are empty
+func ResultCSV(body []byte) ([]byte, error) {
+	var result struct {
+		Data   map[string]interface{} `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	dec := json.NewDecoder(bytes.NewReader(body))
+	// Keep the numbers as they are, e.g. large integers
+	dec.UseNumber()
+	if err := dec.Decode(&result); err != nil {
+		return nil, fmt.Errorf("error decoding GraphQL result: %w", err)
+	}
+	if len(result.Errors) > 0 {
+		msgs := make([]string, 0, len(result.Errors))
+		for _, err := range result.Errors {
+			msgs = append(msgs, err.Message)
+		}
+		return nil, fmt.Errorf("graphql returned errors: %s", strings.Join(msgs, "; "))
+	}
+	if len(result.Data) != 1 {
+		return nil, fmt.Errorf("query must have a single root field to be converted to CSV, not %d", len(result.Data))
+	}
+
+	var rows []interface{}
+	for _, data := range result.Data {
+		switch data := data.(type) {
+		case []interface{}:
+			rows = data
+		case map[string]interface{}:
+			// A single row, such as for a query by ID
+			rows = []interface{}{data}
+		case nil:
+		default:
+			return nil, errors.New("query result must be a list of objects to be converted to CSV")
+		}
+	}
+
+	var (
+		cells   = make([]map[string]string, 0, len(rows))
+		columns = make(map[string]struct{})
+		// nulls are the columns of null values, which are not columns if the
+		// values are objects in other rows
+		nulls = make(map[string]struct{})
+	)
+	for _, row := range rows {
+		obj, ok := row.(map[string]interface{})
+		if !ok {
+			return nil, errors.New("query result must be a list of objects to be converted to CSV")
+		}
+		rowCells := make(map[string]string)
+		if err := csvFlatten("", obj, rowCells, nulls); err != nil {
+			return nil, err
+		}
+		for column := range rowCells {
+			columns[column] = struct{}{}
+		}
+		cells = append(cells, rowCells)
+	}
+	header := make([]string, 0, len(columns))
+	for column := range columns {
+		if _, ok := nulls[column]; ok && csvHasNested(columns, column) {
+			continue
+		}
+		header = append(header, column)
+	}
+	sort.Strings(header)
+
+	var (
+		buf    bytes.Buffer
+		writer = csv.NewWriter(&buf)
+	)
+	if err := writer.Write(header); err != nil {
+		return nil, fmt.Errorf("error writing CSV: %w", err)
+	}
+	for _, rowCells := range cells {
+		record := make([]string, len(header))
+		for i, column := range header {
+			record[i] = rowCells[column]
+		}
+		if err := writer.Write(record); err != nil {
+			return nil, fmt.Errorf("error writing CSV: %w", err)
+		}
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return nil, fmt.Errorf("error writing CSV: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// csvFlatten adds the cells of the fields of an object to cells, with the
+// columns prefixed by the path to the object. The columns of null values are
+// added to nulls
+func csvFlatten(prefix string, obj map[string]interface{}, cells map[string]string, nulls map[string]struct{}) error {
+	for name, val := range obj {
+		column := prefix + name
+		switch val := val.(type) {
+		case nil:
+			cells[column] = ""
+			nulls[column] = struct{}{}
+		case map[string]interface{}:
+			if err := csvFlatten(column+csvNestedSeparator, val, cells, nulls); err != nil {
+				return err
+			}
+		case []interface{}:
+			// encoding/json sorts the keys of maps, so the JSON is the same
+			// for the same values
+			b, err := json.Marshal(val)
+			if err != nil {
+				return fmt.Errorf("error encoding field %s as JSON: %w", column, err)
+			}
+			cells[column] = string(b)
+		case string:
+			cells[column] = val
+		default:
+			// Numbers are json.Number, and booleans are formatted as
+			// true or false
+			cells[column] = fmt.Sprint(val)
+		}
+	}
+	return nil
+}
+
+// csvHasNested returns whether there are columns of the fields of a nested
+// object in the given column
+func csvHasNested(columns map[string]struct{}, column string) bool {
+	for c := range columns {
+		if strings.HasPrefix(c, column+csvNestedSeparator) {
+			return true
+		}
+	}
+	return false
+}
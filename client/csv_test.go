@@ -0,0 +1,97 @@
+package client
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/h2non/gock.v1"
+
+	"github.com/valocode/bubbly/env"
+)
+
+func TestResultCSV(t *testing.T) {
+	tcs := []struct {
+		desc     string
+		result   string
+		expected string
+		err      string
+	}{
+		{
+			desc:   "flat rows",
+			result: `{"data":{"test_run":[{"name":"unit","passed":true,"duration":12},{"name":"e2e","passed":false,"duration":3600000}]}}`,
+			expected: "duration,name,passed\n" +
+				"12,unit,true\n" +
+				"3600000,e2e,false\n",
+		},
+		{
+			desc: "nested fields",
+			result: `{"data":{"test_run":[` +
+				`{"name":"unit","repo":{"name":"bubbly","owner":{"name":"valocode"}},"labels":["a","b, c"],"test_case":[{"status":"PASS","name":"ok"}]},` +
+				`{"name":"e2e","repo":null,"labels":[],"test_case":[]}` +
+				`]}}`,
+			expected: "labels,name,repo.name,repo.owner.name,test_case\n" +
+				`"[""a"",""b, c""]",unit,bubbly,valocode,"[{""name"":""ok"",""status"":""PASS""}]"` + "\n" +
+				"[],e2e,,,[]\n",
+		},
+		{
+			desc:     "null field without nested fields",
+			result:   `{"data":{"test_run":[{"name":"unit","error":null},{"name":"e2e","error":"timeout"}]}}`,
+			expected: "error,name\n,unit\ntimeout,e2e\n",
+		},
+		{
+			desc:     "single row",
+			result:   `{"data":{"test_run_by_id":{"name":"unit"}}}`,
+			expected: "name\nunit\n",
+		},
+		{
+			desc:     "no rows",
+			result:   `{"data":{"test_run":[]}}`,
+			expected: "\n",
+		},
+		{
+			desc:   "more than one root field",
+			result: `{"data":{"test_run":[],"repo":[]}}`,
+			err:    "single root field",
+		},
+		{
+			desc:   "not a list of objects",
+			result: `{"data":{"test_run_distinct":["unit","e2e"]}}`,
+			err:    "must be a list of objects",
+		},
+		{
+			desc:   "errors",
+			result: `{"data":null,"errors":[{"message":"Cannot query field \"nope\""}]}`,
+			err:    `graphql returned errors: Cannot query field "nope"`,
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.desc, func(t *testing.T) {
+			csv, err := ResultCSV([]byte(tc.result))
+			if tc.err != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tc.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, string(csv))
+		})
+	}
+}
+
+func TestQueryCSV(t *testing.T) {
+	bCtx := env.NewBubblyContext()
+	defer gock.Off()
+	gock.New(bCtx.ClientConfig.BubblyAddr).
+		Post("/api/v1/graphql").
+		Reply(http.StatusOK).
+		BodyString(`{"data":{"test_run":[{"name":"unit","repo":{"name":"bubbly"}}]}}`)
+
+	c, err := newHTTP(bCtx)
+	require.NoError(t, err)
+	csv, err := c.QueryCSV(bCtx, nil, `{ test_run { name repo { name } } }`)
+	require.NoError(t, err)
+	assert.Equal(t, "name,repo.name\nunit,bubbly\n", string(csv))
+	assert.True(t, gock.IsDone())
+}
@@ -1,10 +1,12 @@
 package client
 
 import (
+	"compress/gzip"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/labstack/echo/v4"
@@ -16,6 +18,7 @@ func newHTTP(bCtx *env.BubblyContext) (*httpClient, error) {
 		client: &http.Client{Timeout: defaultHTTPClientTimeout * time.Second},
 		url:    bCtx.ClientConfig.BubblyAddr,
 		bCtx:   bCtx,
+		schema: newSchemaCache(time.Duration(bCtx.ClientConfig.SchemaCacheTTL) * time.Second),
 	}, nil
 }
 
@@ -23,6 +26,8 @@ type httpClient struct {
 	url    string
 	client *http.Client
 	bCtx   *env.BubblyContext
+	// schema caches the GraphQL schema SDL, see GetSchemaSDL
+	schema *schemaCache
 }
 
 func (h *httpClient) Close() {
@@ -30,12 +35,23 @@ func (h *httpClient) Close() {
 }
 
 func (h *httpClient) handleRequest(method string, path string, body io.Reader) (*http.Response, error) {
+	return h.handleRequestWithHeader(method, path, body, nil)
+}
+
+// handleRequestWithHeader is like handleRequest, but also sets the given
+// header values on the request
+func (h *httpClient) handleRequestWithHeader(method string, path string, body io.Reader, header http.Header) (*http.Response, error) {
 	url := h.url + path
 	req, err := http.NewRequest(method, url, body)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create new request: %w", err)
 	}
 	req.Header.Set(echo.HeaderContentType, "application/json")
+	for key, values := range header {
+		for _, value := range values {
+			req.Header.Add(key, value)
+		}
+	}
 	if h.bCtx.ClientConfig.AuthToken != "" {
 		// Copy the received header into the request
 		req.Header.Add(echo.HeaderAuthorization, h.bCtx.ClientConfig.AuthToken)
@@ -46,6 +62,26 @@ func (h *httpClient) handleRequest(method string, path string, body io.Reader) (
 	return h.handleResponse(h.client.Do(req))
 }
 
+// httpStatusError is the error returned for responses without the OK status,
+// so that callers can check the status code
+type httpStatusError struct {
+	statusCode int
+	msg        string
+}
+
+func (e *httpStatusError) Error() string {
+	return e.msg
+}
+
+// httpErrorResponse is the body of an error response from the API server
+type httpErrorResponse struct {
+	Error struct {
+		Code    string   `json:"code"`
+		Message string   `json:"message"`
+		Details []string `json:"details"`
+	} `json:"error"`
+}
+
 func (h *httpClient) handleResponse(resp *http.Response, err error) (*http.Response,
 	error) {
 	if err != nil {
@@ -61,12 +97,65 @@ func (h *httpClient) handleResponse(resp *http.Response, err error) (*http.Respo
 			return nil, fmt.Errorf(`failed to read body of respose with status "%s": %w`, resp.Status, err)
 		}
 
-		var httpError echo.HTTPError
-		if err := json.Unmarshal(body, &httpError); err != nil {
-			return nil, fmt.Errorf("%s: error unmarshalling HTTP error message: %w", resp.Status, err)
-		}
-		return nil, fmt.Errorf(`%s: %s`, resp.Status, httpError.Error())
+		return nil, newHTTPStatusError(resp, body)
 	}
 
 	return resp, nil
 }
+
+// newHTTPStatusError returns the error for a response without the OK status.
+// The body of the response should be an error from the API server, but the
+// response could also come from e.g. a proxy, in which case the body is used
+// as the message
+func newHTTPStatusError(resp *http.Response, body []byte) *httpStatusError {
+	statusErr := httpStatusError{statusCode: resp.StatusCode}
+	var errResp httpErrorResponse
+	if err := json.Unmarshal(body, &errResp); err != nil || errResp.Error.Message == "" {
+		statusErr.msg = fmt.Sprintf(`%s: %s`, resp.Status, strings.TrimSpace(string(body)))
+		return &statusErr
+	}
+	statusErr.msg = fmt.Sprintf(`%s: %s`, resp.Status, errResp.Error.Message)
+	if len(errResp.Error.Details) > 0 {
+		statusErr.msg += " (" + strings.Join(errResp.Error.Details, "; ") + ")"
+	}
+	return &statusErr
+}
+
+const gzipEncoding = "gzip"
+
+// Headers used to fetch a response only if it has changed since the client
+// last fetched it
+const (
+	headerETag        = "ETag"
+	headerIfNoneMatch = "If-None-Match"
+)
+
+// decodeBody returns the body of the response, decompressed if the response is
+// compressed. Setting the Accept-Encoding header on a request disables the
+// transparent decompression of the http.Transport, so requests that set it
+// must decode the response themselves
+func decodeBody(resp *http.Response) (io.ReadCloser, error) {
+	if resp.Header.Get("Content-Encoding") != gzipEncoding {
+		return resp.Body, nil
+	}
+	gz, err := gzip.NewReader(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read gzip response: %w", err)
+	}
+	return &gzipBody{Reader: gz, body: resp.Body}, nil
+}
+
+// gzipBody is the decompressed body of a response
+type gzipBody struct {
+	*gzip.Reader
+	body io.ReadCloser
+}
+
+// Close closes both the decompressor and the body of the response
+func (b *gzipBody) Close() error {
+	if err := b.Reader.Close(); err != nil {
+		b.body.Close()
+		return err
+	}
+	return b.body.Close()
+}
@@ -0,0 +1,42 @@
+package client
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestNewHTTPStatusError verifies that the error responses of the API server
+// are decoded, and that other responses keep their body as the message
+func TestNewHTTPStatusError(t *testing.T) {
+	tcs := []struct {
+		desc     string
+		body     string
+		expected string
+	}{
+		{
+			desc:     "api error",
+			body:     `{"error":{"code":"not_found","message":"resource not found: extract/junit"}}`,
+			expected: "404 Not Found: resource not found: extract/junit",
+		},
+		{
+			desc:     "api error with details",
+			body:     `{"error":{"code":"bad_request","message":"invalid query","details":["a","b"]}}`,
+			expected: "404 Not Found: invalid query (a; b)",
+		},
+		{
+			desc:     "not an api error",
+			body:     "<html>gateway timeout</html>\n",
+			expected: "404 Not Found: <html>gateway timeout</html>",
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.desc, func(t *testing.T) {
+			resp := &http.Response{StatusCode: http.StatusNotFound, Status: "404 Not Found"}
+			err := newHTTPStatusError(resp, []byte(tc.body))
+			assert.Equal(t, http.StatusNotFound, err.statusCode)
+			assert.Equal(t, tc.expected, err.Error())
+		})
+	}
+}
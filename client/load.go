@@ -2,6 +2,7 @@ package client
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"net/http"
 
@@ -9,8 +10,48 @@ import (
 	"github.com/valocode/bubbly/env"
 )
 
-// Load takes data blocks and saves them to the bubbly server
-func (c *httpClient) Load(bCtx *env.BubblyContext, _ *component.MessageAuth, data []byte) error {
+// LoadOption configures how data is loaded
+type LoadOption func(*loadOptions)
+
+type loadOptions struct {
+	saveID string
+}
+
+// WithSaveID gives the ID of the data that is loaded, so that the store saves
+// data with the same ID only once. This makes it safe to retry loading data,
+// e.g. the results of a pipeline run, which the ID should identify
+func WithSaveID(id string) LoadOption {
+	return func(o *loadOptions) {
+		o.saveID = id
+	}
+}
+
+func newLoadOptions(opts []LoadOption) *loadOptions {
+	o := &loadOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// SaveID returns the ID given to the options with WithSaveID, or an empty
+// string if there is none
+func SaveID(opts ...LoadOption) string {
+	return newLoadOptions(opts).saveID
+}
+
+// Load takes data blocks and saves them to the bubbly server. If the data has
+// a save ID, the request is retried if it fails without a response
+func (c *httpClient) Load(bCtx *env.BubblyContext, _ *component.MessageAuth, data []byte, opts ...LoadOption) error {
+	options := newLoadOptions(opts)
+	if options.saveID != "" {
+		header := make(http.Header)
+		header.Set(HeaderSaveID, options.saveID)
+		if err := c.postWithRetry(bCtx, "/upload", data, header); err != nil {
+			return fmt.Errorf("failed to save data: %w", err)
+		}
+		return nil
+	}
 
 	_, err := c.handleRequest(http.MethodPost, "/upload", bytes.NewBuffer(data))
 	if err != nil {
@@ -22,7 +63,7 @@ func (c *httpClient) Load(bCtx *env.BubblyContext, _ *component.MessageAuth, dat
 
 // Upload uses the bubbly NATS client to upload arbitrary data to be saved
 // into the data store.
-func (n *natsClient) Load(bCtx *env.BubblyContext, auth *component.MessageAuth, data []byte) error {
+func (n *natsClient) Load(bCtx *env.BubblyContext, auth *component.MessageAuth, data []byte, opts ...LoadOption) error {
 	bCtx.Logger.Debug().
 		Msg("Uploading data to the data store")
 
@@ -33,6 +74,14 @@ func (n *natsClient) Load(bCtx *env.BubblyContext, auth *component.MessageAuth,
 			Data: data,
 		},
 	}
+	if saveID := newLoadOptions(opts).saveID; saveID != "" {
+		uploadData, err := json.Marshal(component.UploadOnce{ID: saveID, Data: data})
+		if err != nil {
+			return fmt.Errorf("failed to encode upload: %w", err)
+		}
+		req.Subject = component.StoreUploadOnce
+		req.Data.Data = uploadData
+	}
 	// reply is a Publication received from a bubbly store
 	if err := n.request(bCtx, &req); err != nil {
 		return fmt.Errorf("failed during upload: %w", err)
@@ -2,6 +2,7 @@ package client
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
 
@@ -77,9 +78,17 @@ func (n *natsClient) request(bCtx *env.BubblyContext, req *component.Request) er
 	// Send a request.
 	// The response from the request should always be a []byte,
 	// which we can easily decode into our `reply.Data`.
-	var reply []byte
-	if err := n.EConn.Request(string(req.Subject), req.Data, &reply,
-		defaultNATSClientTimeout*time.Second); err != nil {
+	var (
+		reply   []byte
+		timeout = bCtx.AgentConfig.NATSRequestTimeout()
+	)
+	// Let the handler know when we stop waiting for the reply, so that it
+	// can cancel the work of handling the request
+	req.Data.Deadline = time.Now().Add(timeout)
+	if err := n.EConn.Request(string(req.Subject), req.Data, &reply, timeout); err != nil {
+		if errors.Is(err, nats.ErrTimeout) {
+			return fmt.Errorf("no reply to request on subject %s within %s, check that a component is subscribed to it: %w", req.Subject, timeout, err)
+		}
 		return fmt.Errorf("failed to make request: %w", err)
 	}
 
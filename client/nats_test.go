@@ -2,8 +2,10 @@ package client
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/nats-io/nats-server/v2/server"
 	natsserver "github.com/nats-io/nats-server/v2/test"
@@ -69,3 +71,66 @@ func TestNATS(t *testing.T) {
 	err = client.PostResource(bCtx, nil, b)
 	require.NoError(t, err)
 }
+
+// TestNATSRequestTimeout checks that a request that nothing replies to fails
+// after the configured timeout, instead of hanging
+func TestNATSRequestTimeout(t *testing.T) {
+	bCtx := env.NewBubblyContext()
+	bCtx.ClientConfig.ClientType = config.NATSClientType
+	bCtx.ClientConfig.NATSAddr = fmt.Sprintf("nats://127.0.0.1:%d", TEST_PORT)
+	bCtx.AgentConfig.RequestTimeout = 100
+
+	s := RunServerOnPort(TEST_PORT)
+	defer s.Shutdown()
+
+	client, err := New(bCtx)
+	require.NoErrorf(t, err, "failed to create NATS client")
+	defer client.Close()
+
+	// Nothing is subscribed to the subject of queries
+	start := time.Now()
+	_, err = client.Query(bCtx, nil, "{ root { name } }")
+	elapsed := time.Since(start)
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, nats.ErrTimeout), "expected a timeout error, got: %s", err)
+	assert.Contains(t, err.Error(), "within 100ms")
+	assert.GreaterOrEqual(t, int64(elapsed), int64(100*time.Millisecond))
+	assert.Less(t, int64(elapsed), int64(time.Second))
+}
+
+// TestNATSRequestDeadline checks that a request carries the deadline after
+// which the client stops waiting for the reply, so that the handler can give
+// up on it too
+func TestNATSRequestDeadline(t *testing.T) {
+	bCtx := env.NewBubblyContext()
+	bCtx.ClientConfig.ClientType = config.NATSClientType
+	bCtx.ClientConfig.NATSAddr = fmt.Sprintf("nats://127.0.0.1:%d", TEST_PORT)
+	bCtx.AgentConfig.RequestTimeout = 500
+
+	s := RunServerOnPort(TEST_PORT)
+	defer s.Shutdown()
+
+	nc, err := nats.Connect(bCtx.ClientConfig.NATSAddr)
+	require.NoError(t, err)
+	ec, err := nats.NewEncodedConn(nc, nats.JSON_ENCODER)
+	require.NoError(t, err)
+	defer ec.Close()
+
+	deadlines := make(chan time.Time, 1)
+	_, err = ec.QueueSubscribe(string(component.StoreQuery), string(component.StoreQueue), func(subject string, reply string, data component.MessageData) {
+		deadlines <- data.Deadline
+		ec.Publish(reply, component.Reply{Data: []byte(`{}`)})
+	})
+	require.NoError(t, err)
+
+	client, err := New(bCtx)
+	require.NoError(t, err)
+	defer client.Close()
+
+	start := time.Now()
+	_, err = client.Query(bCtx, nil, "{ root { name } }")
+	require.NoError(t, err)
+	deadline := <-deadlines
+	assert.False(t, deadline.Before(start.Add(500*time.Millisecond)), "deadline %s is before the timeout", deadline)
+	assert.True(t, deadline.Before(time.Now().Add(500*time.Millisecond)), "deadline %s is after the timeout", deadline)
+}
@@ -0,0 +1,187 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/parser"
+)
+
+// MarshalOrderedResult encodes the result of a GraphQL query to JSON like
+// json.Marshal, except that the fields of the objects in the data are in the
+// order that they are selected in the query, instead of sorted by name.
+// This makes the encoded result read like the query, e.g. for golden files
+func MarshalOrderedResult(query string, result *graphql.Result) ([]byte, error) {
+	doc, err := parser.Parse(parser.ParseParams{Source: query})
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse query: %w", err)
+	}
+	var (
+		selections *ast.SelectionSet
+		fragments  = make(map[string]*ast.FragmentDefinition)
+	)
+	for _, def := range doc.Definitions {
+		switch def := def.(type) {
+		case *ast.OperationDefinition:
+			if selections != nil {
+				return nil, errors.New("query must contain only one operation")
+			}
+			selections = def.SelectionSet
+		case *ast.FragmentDefinition:
+			fragments[def.Name.Value] = def
+		}
+	}
+
+	m := orderedMarshaller{fragments: fragments}
+	m.buf.WriteString(`{"data":`)
+	if err := m.marshalValue(result.Data, []*ast.SelectionSet{selections}); err != nil {
+		return nil, err
+	}
+	if len(result.Errors) > 0 {
+		m.buf.WriteString(`,"errors":`)
+		if err := m.marshalJSON(result.Errors); err != nil {
+			return nil, err
+		}
+	}
+	if len(result.Extensions) > 0 {
+		m.buf.WriteString(`,"extensions":`)
+		if err := m.marshalJSON(result.Extensions); err != nil {
+			return nil, err
+		}
+	}
+	m.buf.WriteString("}")
+	return m.buf.Bytes(), nil
+}
+
+// OrderedResult re-encodes the JSON of a GraphQL result with
+// MarshalOrderedResult, so that the fields of the objects in the data are in
+// the order that they are selected in the query
+func OrderedResult(query string, body []byte) ([]byte, error) {
+	var result graphql.Result
+	dec := json.NewDecoder(bytes.NewReader(body))
+	// Keep the numbers as they are, e.g. large integers
+	dec.UseNumber()
+	if err := dec.Decode(&result); err != nil {
+		return nil, fmt.Errorf("error decoding GraphQL result: %w", err)
+	}
+	return MarshalOrderedResult(query, &result)
+}
+
+// orderedMarshaller encodes the data of a GraphQL result with the fields of
+// the objects in the order of the selection sets of the query
+type orderedMarshaller struct {
+	buf       bytes.Buffer
+	fragments map[string]*ast.FragmentDefinition
+}
+
+// marshalValue encodes the value of a field that has the given selection
+// sets. A field can be selected more than once, in which case its selection
+// sets are merged
+func (m *orderedMarshaller) marshalValue(val interface{}, selections []*ast.SelectionSet) error {
+	switch v := val.(type) {
+	case map[string]interface{}:
+		return m.marshalObject(v, selections)
+	case []interface{}:
+		m.buf.WriteString("[")
+		for i, elem := range v {
+			if i > 0 {
+				m.buf.WriteString(",")
+			}
+			if err := m.marshalValue(elem, selections); err != nil {
+				return err
+			}
+		}
+		m.buf.WriteString("]")
+		return nil
+	default:
+		return m.marshalJSON(v)
+	}
+}
+
+// marshalObject encodes an object with its fields in the order that they are
+// selected. Fields that are not selected, which the result of a query does
+// not have, come last and are sorted by name
+func (m *orderedMarshaller) marshalObject(obj map[string]interface{}, selections []*ast.SelectionSet) error {
+	var (
+		keys    []string
+		subSels = make(map[string][]*ast.SelectionSet)
+	)
+	for _, sel := range selections {
+		m.collectFields(sel, &keys, subSels)
+	}
+	var unselected []string
+	for key := range obj {
+		if _, ok := subSels[key]; !ok {
+			unselected = append(unselected, key)
+		}
+	}
+	sort.Strings(unselected)
+	keys = append(keys, unselected...)
+
+	m.buf.WriteString("{")
+	var written int
+	for _, key := range keys {
+		val, ok := obj[key]
+		if !ok {
+			// The field was skipped, e.g. with the @skip directive
+			continue
+		}
+		if written > 0 {
+			m.buf.WriteString(",")
+		}
+		written++
+		if err := m.marshalJSON(key); err != nil {
+			return err
+		}
+		m.buf.WriteString(":")
+		if err := m.marshalValue(val, subSels[key]); err != nil {
+			return err
+		}
+	}
+	m.buf.WriteString("}")
+	return nil
+}
+
+// collectFields adds the response keys of the fields in the selection set,
+// which are their alias or name, to keys in the order that they are first
+// selected, and their selection sets to subSels. The fields of fragments are
+// collected in the place of the fragment
+func (m *orderedMarshaller) collectFields(selections *ast.SelectionSet, keys *[]string, subSels map[string][]*ast.SelectionSet) {
+	if selections == nil {
+		return
+	}
+	for _, sel := range selections.Selections {
+		switch sel := sel.(type) {
+		case *ast.Field:
+			key := sel.Name.Value
+			if sel.Alias != nil {
+				key = sel.Alias.Value
+			}
+			if _, ok := subSels[key]; !ok {
+				*keys = append(*keys, key)
+			}
+			subSels[key] = append(subSels[key], sel.SelectionSet)
+		case *ast.InlineFragment:
+			m.collectFields(sel.SelectionSet, keys, subSels)
+		case *ast.FragmentSpread:
+			if frag, ok := m.fragments[sel.Name.Value]; ok {
+				m.collectFields(frag.SelectionSet, keys, subSels)
+			}
+		}
+	}
+}
+
+// marshalJSON encodes v with json.Marshal
+func (m *orderedMarshaller) marshalJSON(v interface{}) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to encode query result: %w", err)
+	}
+	m.buf.Write(b)
+	return nil
+}
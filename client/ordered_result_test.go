@@ -0,0 +1,99 @@
+package client
+
+import (
+	"testing"
+
+	"github.com/graphql-go/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMarshalOrderedResult(t *testing.T) {
+	project := graphql.NewObject(graphql.ObjectConfig{
+		Name: "project",
+		Fields: graphql.Fields{
+			"_id":  &graphql.Field{Type: graphql.String},
+			"name": &graphql.Field{Type: graphql.String},
+			"tags": &graphql.Field{Type: graphql.NewList(graphql.String)},
+		},
+	})
+	schema, err := graphql.NewSchema(graphql.SchemaConfig{
+		Query: graphql.NewObject(graphql.ObjectConfig{
+			Name: "query",
+			Fields: graphql.Fields{
+				"project": &graphql.Field{
+					Type: graphql.NewList(project),
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return []interface{}{
+							map[string]interface{}{"_id": "1", "name": "bubbly", "tags": []interface{}{"b", "a"}},
+							map[string]interface{}{"_id": "2", "name": "other", "tags": nil},
+						}, nil
+					},
+				},
+				"version": &graphql.Field{
+					Type: graphql.String,
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return "v1", nil
+					},
+				},
+			},
+		}),
+	})
+	require.NoError(t, err)
+
+	tcs := []struct {
+		desc     string
+		query    string
+		expected string
+	}{
+		{
+			desc:     "selection order",
+			query:    `{ version project { tags name _id } }`,
+			expected: `{"data":{"version":"v1","project":[{"tags":["b","a"],"name":"bubbly","_id":"1"},{"tags":null,"name":"other","_id":"2"}]}}`,
+		},
+		{
+			desc:     "aliases",
+			query:    `{ p: project { name id: _id } version }`,
+			expected: `{"data":{"p":[{"name":"bubbly","id":"1"},{"name":"other","id":"2"}],"version":"v1"}}`,
+		},
+		{
+			desc:     "fragments and merged fields",
+			query:    `query { project { name ...ids } project { tags } } fragment ids on project { _id name }`,
+			expected: `{"data":{"project":[{"name":"bubbly","_id":"1","tags":["b","a"]},{"name":"other","_id":"2","tags":null}]}}`,
+		},
+		{
+			desc:     "skipped field",
+			query:    `{ project { name @skip(if: true) _id } }`,
+			expected: `{"data":{"project":[{"_id":"1"},{"_id":"2"}]}}`,
+		},
+		{
+			desc:     "errors",
+			query:    `{ project { unknown } }`,
+			expected: `{"data":null,"errors":[{"message":"Cannot query field \"unknown\" on type \"project\".","locations":[{"line":1,"column":13}]}]}`,
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.desc, func(t *testing.T) {
+			result := graphql.Do(graphql.Params{Schema: schema, RequestString: tc.query})
+			b, err := MarshalOrderedResult(tc.query, result)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, string(b))
+			// Encoding the same result again gives the same bytes
+			for i := 0; i < 10; i++ {
+				again, err := MarshalOrderedResult(tc.query, result)
+				require.NoError(t, err)
+				assert.Equal(t, b, again)
+			}
+		})
+	}
+}
+
+func TestOrderedResult(t *testing.T) {
+	body := []byte(`{"data":{"root":[{"_id":"1","count":12345678901234567890,"name":"bubbly"}]},"errors":[{"message":"partial","locations":[]}]}`)
+	b, err := OrderedResult(`{ root { name count _id } }`, body)
+	require.NoError(t, err)
+	assert.Equal(t, `{"data":{"root":[{"name":"bubbly","count":12345678901234567890,"_id":"1"}]},"errors":[{"message":"partial","locations":[]}]}`, string(b))
+
+	_, err = OrderedResult(`{ root { name } }`, []byte(`not json`))
+	require.Error(t, err)
+}
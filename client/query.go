@@ -12,13 +12,59 @@ import (
 	"github.com/valocode/bubbly/env"
 )
 
+// QueryOption configures how a query is made
+type QueryOption func(*queryOptions)
+
+type queryOptions struct {
+	explain bool
+	analyze bool
+}
+
+// WithExplain requests the store to also return the SQL that it runs for the
+// query, in the "explain" extension of the GraphQL result
+func WithExplain() QueryOption {
+	return func(o *queryOptions) {
+		o.explain = true
+	}
+}
+
+// WithExplainAnalyze is like WithExplain, but also requests the store to return
+// the output of EXPLAIN ANALYZE for the SQL it runs
+func WithExplainAnalyze() QueryOption {
+	return func(o *queryOptions) {
+		o.explain = true
+		o.analyze = true
+	}
+}
+
+func newQueryOptions(opts []QueryOption) *queryOptions {
+	var options queryOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+	return &options
+}
+
+// ExplainRequest returns the request to explain the query, if the options
+// request the query to be explained
+func ExplainRequest(query string, opts ...QueryOption) (*component.QueryExplain, bool) {
+	options := newQueryOptions(opts)
+	if !options.explain {
+		return nil, false
+	}
+	return &component.QueryExplain{
+		Query:   query,
+		Analyze: options.analyze,
+	}, true
+}
+
 // Query takes the query string from a query resource spec and POSTs it
 // to the bubbly server for querying against a bubbly store
 // Returns a []byte representing the interface{} returned from the graphql-go
 // request if successful
 // Returns an error if querying was unsuccessful
-func (c *httpClient) Query(bCtx *env.BubblyContext, _ *component.MessageAuth, query string) ([]byte, error) {
-	body, err := c.doQuery(bCtx, query)
+func (c *httpClient) Query(bCtx *env.BubblyContext, _ *component.MessageAuth, query string, opts ...QueryOption) ([]byte, error) {
+	body, err := c.doQuery(bCtx, query, newQueryOptions(opts))
 	if err != nil {
 		return nil, err
 	}
@@ -28,7 +74,7 @@ func (c *httpClient) Query(bCtx *env.BubblyContext, _ *component.MessageAuth, qu
 }
 
 func (c *httpClient) QueryType(bCtx *env.BubblyContext, _ *component.MessageAuth, query string, ptr interface{}) error {
-	body, err := c.doQuery(bCtx, query)
+	body, err := c.doQuery(bCtx, query, newQueryOptions(nil))
 	if err != nil {
 		return err
 	}
@@ -45,7 +91,7 @@ func (c *httpClient) QueryType(bCtx *env.BubblyContext, _ *component.MessageAuth
 	return nil
 }
 
-func (c *httpClient) doQuery(bCtx *env.BubblyContext, query string) (io.ReadCloser, error) {
+func (c *httpClient) doQuery(bCtx *env.BubblyContext, query string, options *queryOptions) (io.ReadCloser, error) {
 	// We must wrap the data with a "query" key such that it can be
 	// unmarshalled correctly by server.Query into a queryReq
 	queryData := map[string]string{
@@ -57,15 +103,48 @@ func (c *httpClient) doQuery(bCtx *env.BubblyContext, query string) (io.ReadClos
 		return nil, fmt.Errorf("failed to marshal query data for loading: %w", err)
 	}
 
-	resp, err := c.handleRequest(http.MethodPost, "/graphql", bytes.NewBuffer(jsonReq))
+	path := "/graphql"
+	switch {
+	case options.analyze:
+		path += "?explain=analyze"
+	case options.explain:
+		path += "?explain=true"
+	}
+	// Query results can be large, so accept a compressed response
+	header := http.Header{}
+	header.Set("Accept-Encoding", gzipEncoding)
+	resp, err := c.handleRequestWithHeader(http.MethodPost, path, bytes.NewBuffer(jsonReq), header)
 	if err != nil {
 		return nil, fmt.Errorf("failed to make %s request for query: %w", http.MethodPost, err)
 	}
-	return resp.Body, nil
+	body, err := decodeBody(resp)
+	if err != nil {
+		resp.Body.Close()
+		return nil, fmt.Errorf("failed to decode query response: %w", err)
+	}
+	return body, nil
 }
 
-func (n *natsClient) Query(bCtx *env.BubblyContext, auth *component.MessageAuth, query string) ([]byte, error) {
-	return n.doQuery(bCtx, auth, query)
+func (n *natsClient) Query(bCtx *env.BubblyContext, auth *component.MessageAuth, query string, opts ...QueryOption) ([]byte, error) {
+	explain, ok := ExplainRequest(query, opts...)
+	if !ok {
+		return n.doQuery(bCtx, auth, query)
+	}
+	data, err := json.Marshal(explain)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal query to explain: %w", err)
+	}
+	req := &component.Request{
+		Subject: component.StoreQueryExplain,
+		Data: component.MessageData{
+			Auth: auth,
+			Data: data,
+		},
+	}
+	if err := n.request(bCtx, req); err != nil {
+		return nil, fmt.Errorf("NATS client failed to explain query: %w", err)
+	}
+	return req.Reply.Data, nil
 }
 
 func (n *natsClient) QueryType(bCtx *env.BubblyContext, auth *component.MessageAuth, query string, ptr interface{}) error {
@@ -1,10 +1,14 @@
 package client
 
 import (
+	"bytes"
+	"compress/gzip"
 	"net/http"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/valocode/bubbly/env"
 	"gopkg.in/h2non/gock.v1"
 )
@@ -65,3 +69,32 @@ func TestQuery(t *testing.T) {
 		})
 	}
 }
+
+// TestQueryGzip verifies that c.Query accepts a compressed response, and
+// decompresses it
+func TestQueryGzip(t *testing.T) {
+	defer gock.Off()
+	bCtx := env.NewBubblyContext()
+
+	response := `{"data":{"test_run":[` + strings.Repeat(`{"name":"run"},`, 1000) + `{"name":"run"}]}}`
+	var compressed bytes.Buffer
+	gz := gzip.NewWriter(&compressed)
+	_, err := gz.Write([]byte(response))
+	require.NoError(t, err)
+	require.NoError(t, gz.Close())
+
+	gock.New(bCtx.ClientConfig.BubblyAddr).
+		Post("/api/v1/graphql").
+		MatchHeader("Accept-Encoding", "gzip").
+		Reply(http.StatusOK).
+		SetHeader("Content-Encoding", "gzip").
+		Body(&compressed)
+
+	c, err := newHTTP(bCtx)
+	require.NoError(t, err)
+
+	byteRes, err := c.Query(bCtx, nil, "{ test_run { name } }")
+	require.NoError(t, err)
+	assert.True(t, gock.IsDone())
+	assert.Equal(t, response, string(byteRes))
+}
@@ -8,23 +8,75 @@ import (
 	"io"
 	"net/http"
 
+	"github.com/google/uuid"
 	"github.com/graphql-go/graphql"
 	"github.com/hashicorp/go-multierror"
+	"github.com/labstack/echo/v4"
 
 	"github.com/valocode/bubbly/agent/component"
 	"github.com/valocode/bubbly/api/core"
 	"github.com/valocode/bubbly/env"
 )
 
+var (
+	// ErrResourceNotFound is returned when a resource that does not exist is
+	// fetched, patched or deleted
+	ErrResourceNotFound = errors.New("resource not found")
+	// ErrInvalidResource is returned when patching a resource would make it
+	// invalid, or when resources that are posted together are not valid
+	ErrInvalidResource = errors.New("invalid resource")
+)
+
+const (
+	// HeaderIdempotencyKey is the header with which the client identifies a
+	// request, so that the server can recognise a retry of the request and
+	// return the original response instead of applying it again
+	HeaderIdempotencyKey = "Idempotency-Key"
+	// HeaderSaveID is the header with the ID of the data that is uploaded,
+	// with which the store saves data with the same ID only once
+	HeaderSaveID = "X-Bubbly-Save-ID"
+
+	// postResourceAttempts is the number of times that posting a resource is
+	// attempted, if the request fails without a response, e.g. on a timeout
+	postResourceAttempts = 3
+)
+
+// ResourceOption is an option that can be provided when getting a resource
+type ResourceOption func(*resourceOptions)
+
+// resourceOptions contains the options for getting a resource
+type resourceOptions struct {
+	yaml bool
+}
+
+// WithYAML returns the resource as YAML instead of the default JSON
+func WithYAML() ResourceOption {
+	return func(o *resourceOptions) {
+		o.yaml = true
+	}
+}
+
+func newResourceOptions(opts []ResourceOption) *resourceOptions {
+	var options resourceOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+	return &options
+}
+
 // GetResource uses the bubbly api endpoint to get a resource
 // TODO: with some of the new architecture it might be possible for client
 //  to return an actual resource, and not just a byte... Don't want to create
 //  a merge hell so making a note here
-func (c *httpClient) GetResource(bCtx *env.BubblyContext, _ *component.MessageAuth, id string) ([]byte, error) {
+func (c *httpClient) GetResource(bCtx *env.BubblyContext, _ *component.MessageAuth, id string, opts ...ResourceOption) ([]byte, error) {
 
 	bCtx.Logger.Debug().Str("resource_id", id).Msg("Getting resource from bubbly API.")
 
-	resp, err := c.handleRequest(http.MethodGet, "/resource/"+id, nil)
+	var header = make(http.Header)
+	if options := newResourceOptions(opts); options.yaml {
+		header.Set(echo.HeaderAccept, core.MIMEApplicationYAML)
+	}
+	resp, err := c.handleRequestWithHeader(http.MethodGet, "/resource/"+id, nil, header)
 	if err != nil {
 		return nil, fmt.Errorf("error getting resource %s: %w", id, err)
 	}
@@ -33,13 +85,95 @@ func (c *httpClient) GetResource(bCtx *env.BubblyContext, _ *component.MessageAu
 	return io.ReadAll(resp.Body)
 }
 
-// PostResource uses the bubbly api endpoint to post a resource
+// PostResource uses the bubbly api endpoint to post a resource. If the
+// request fails without a response, it is retried with the same idempotency
+// key, so that the resource is not posted twice if the original request
+// succeeded but its response was lost
 func (c *httpClient) PostResource(bCtx *env.BubblyContext, _ *component.MessageAuth, resource []byte) error {
+	if err := c.postIdempotent(bCtx, "/resource", resource); err != nil {
+		return fmt.Errorf(`failed to post resource: %w`, err)
+	}
+	return nil
+}
+
+// PostResources uses the bubbly api endpoint to post a list of resources,
+// which are either all saved or none are. It is retried like PostResource
+func (c *httpClient) PostResources(bCtx *env.BubblyContext, _ *component.MessageAuth, resources []byte) error {
+	if err := c.postIdempotent(bCtx, "/resources", resources); err != nil {
+		return fmt.Errorf(`failed to post resources: %w`, err)
+	}
+	return nil
+}
+
+// postIdempotent posts the body to the bubbly api endpoint with an idempotency
+// key, retrying with the same key if the request fails without a response
+func (c *httpClient) postIdempotent(bCtx *env.BubblyContext, path string, body []byte) error {
+
+	header := make(http.Header)
+	header.Set(HeaderIdempotencyKey, uuid.New().String())
+	return c.postWithRetry(bCtx, path, body, header)
+}
 
-	_, err := c.handleRequest(http.MethodPost, "/resource", bytes.NewBuffer(resource))
+// postWithRetry posts the body to the bubbly api endpoint with the header,
+// retrying if the request fails without a response. The header must identify
+// the request, so that the server does not apply a retry of it twice
+func (c *httpClient) postWithRetry(bCtx *env.BubblyContext, path string, body []byte, header http.Header) error {
+	var err error
+	for attempt := 1; attempt <= postResourceAttempts; attempt++ {
+		var resp *http.Response
+		resp, err = c.handleRequestWithHeader(http.MethodPost, path, bytes.NewReader(body), header)
+		if err == nil {
+			resp.Body.Close()
+			return nil
+		}
+		// The server responded, so retrying would not change the outcome
+		var statusErr *httpStatusError
+		if errors.As(err, &statusErr) {
+			break
+		}
+		bCtx.Logger.Debug().Err(err).Int("attempt", attempt).Str("path", path).Msg("failed to post to the bubbly api")
+	}
+	return err
+}
+
+// DeleteResource uses the bubbly api endpoint to delete a resource
+func (c *httpClient) DeleteResource(bCtx *env.BubblyContext, _ *component.MessageAuth, id string) error {
+
+	bCtx.Logger.Debug().Str("resource_id", id).Msg("Deleting resource from bubbly API.")
+
+	resp, err := c.handleRequest(http.MethodDelete, "/resource/"+id, nil)
 	if err != nil {
-		return fmt.Errorf(`failed to post resource: %w`, err)
+		var statusErr *httpStatusError
+		if errors.As(err, &statusErr) && statusErr.statusCode == http.StatusNotFound {
+			return fmt.Errorf("%w: %s", ErrResourceNotFound, id)
+		}
+		return fmt.Errorf("failed to delete resource %s: %w", id, err)
+	}
+	resp.Body.Close()
+
+	return nil
+}
+
+// PatchResource uses the bubbly api endpoint to patch a resource with a JSON
+// Merge Patch
+func (c *httpClient) PatchResource(bCtx *env.BubblyContext, _ *component.MessageAuth, id string, patch []byte) error {
+
+	bCtx.Logger.Debug().Str("resource_id", id).Msg("Patching resource with bubbly API.")
+
+	resp, err := c.handleRequest(http.MethodPatch, "/resource/"+id, bytes.NewReader(patch))
+	if err != nil {
+		var statusErr *httpStatusError
+		if errors.As(err, &statusErr) {
+			switch statusErr.statusCode {
+			case http.StatusNotFound:
+				return fmt.Errorf("%w: %s", ErrResourceNotFound, id)
+			case http.StatusUnprocessableEntity:
+				return fmt.Errorf("%w: %s: %s", ErrInvalidResource, id, statusErr.msg)
+			}
+		}
+		return fmt.Errorf("failed to patch resource %s: %w", id, err)
 	}
+	resp.Body.Close()
 
 	return nil
 }
@@ -54,22 +188,9 @@ func (h *httpClient) PostResourceToWorker(bCtx *env.BubblyContext, _ *component.
 // Takes a resource ID as input, returns a []byte representing the
 // core.ResourceBlock of the resource or an error if
 // the client was unable to get the resource.
-func (n *natsClient) GetResource(bCtx *env.BubblyContext, auth *component.MessageAuth, resID string) ([]byte,
+func (n *natsClient) GetResource(bCtx *env.BubblyContext, auth *component.MessageAuth, resID string, opts ...ResourceOption) ([]byte,
 	error) {
 
-	// for the graphQL query
-	resQuery := fmt.Sprintf(`
-		{
-			%s(id: "%s") {
-				name
-				kind
-				api_version
-				metadata
-				spec
-			}
-		}
-	`, core.ResourceTableName, resID)
-
 	bCtx.Logger.Debug().
 		Str("resource_id", resID).
 		Msg("Getting resource from store")
@@ -78,19 +199,40 @@ func (n *natsClient) GetResource(bCtx *env.BubblyContext, auth *component.Messag
 		Subject: component.StoreQuery,
 		Data: component.MessageData{
 			Auth: auth,
-			Data: []byte(resQuery),
+			Data: []byte(ResourceQuery(resID)),
 		},
 	}
 	if err := n.request(bCtx, &req); err != nil {
 		return nil, fmt.Errorf("failed to get resource from query: %w", err)
 	}
+	return ResourceFromQueryResult(resID, req.Reply.Data, opts...)
+}
 
+// ResourceQuery returns the GraphQL query used to get the resource with the
+// given ID from the data store
+func ResourceQuery(resID string) string {
+	return fmt.Sprintf(`
+		{
+			%s(id: "%s") {
+				name
+				kind
+				api_version
+				metadata
+				spec
+			}
+		}
+	`, core.ResourceTableName, resID)
+}
+
+// ResourceFromQueryResult takes the JSON encoded graphql.Result of a
+// ResourceQuery and returns the resource from it
+func ResourceFromQueryResult(resID string, data []byte, opts ...ResourceOption) ([]byte, error) {
 	var (
 		result    graphql.Result
 		resources core.ResourceBlockJSONWrapper
 	)
 	result.Data = &resources
-	if err := json.Unmarshal(req.Reply.Data, &result); err != nil {
+	if err := json.Unmarshal(data, &result); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal response from query to store: %w", err)
 	}
 	if result.HasErrors() {
@@ -102,12 +244,19 @@ func (n *natsClient) GetResource(bCtx *env.BubblyContext, auth *component.Messag
 	}
 
 	if len(resources.ResourceBlocks) == 0 {
-		return nil, fmt.Errorf("no resource with ID: %s", resID)
+		return nil, fmt.Errorf("%w: %s", ErrResourceNotFound, resID)
 	}
 	// ...which we presume to be of length 1, since resources with identical
 	// IDs are upserted. Here we extract the first valid core.ResourceBlockJSON
 	// from the slice
-	return json.Marshal(resources.ResourceBlocks[0])
+	resBytes, err := json.Marshal(resources.ResourceBlocks[0])
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal resource %s: %w", resID, err)
+	}
+	if options := newResourceOptions(opts); options.yaml {
+		return core.ResourceJSONToYAML(resBytes)
+	}
+	return resBytes, nil
 }
 
 // PostResource uses the bubbly natsClient client to publish a resource to the data
@@ -132,6 +281,112 @@ func (n *natsClient) PostResource(bCtx *env.BubblyContext, auth *component.Messa
 	return nil
 }
 
+// PostResources uses the bubbly natsClient client to publish a list of
+// resources to the data store, which saves all of them or none
+func (n *natsClient) PostResources(bCtx *env.BubblyContext, auth *component.MessageAuth, resources []byte) error {
+	data, err := ResourcesData(resources)
+	if err != nil {
+		return err
+	}
+	return n.PostResource(bCtx, auth, data)
+}
+
+// ResourcesData converts a JSON list of resources into the JSON of the data
+// blocks that store them, so that they can be saved together.
+// It is meant for the clients that talk to the data store directly
+func ResourcesData(resources []byte) ([]byte, error) {
+	var resBlocks []core.ResourceBlock
+	if err := json.Unmarshal(resources, &resBlocks); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidResource, err.Error())
+	}
+	data := make(core.DataBlocks, 0, len(resBlocks))
+	for _, res := range resBlocks {
+		d, err := res.Data()
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %s", ErrInvalidResource, res.String(), err.Error())
+		}
+		data = append(data, d)
+	}
+	dBytes, err := json.Marshal(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal resources: %w", err)
+	}
+	return dBytes, nil
+}
+
+// DeleteResource uses the bubbly natsClient client to delete a resource from
+// the data store
+func (n *natsClient) DeleteResource(bCtx *env.BubblyContext, auth *component.MessageAuth, id string) error {
+	bCtx.Logger.Debug().
+		Str("resource_id", id).
+		Msg("Deleting resource from store")
+
+	req := component.Request{
+		Subject: component.StoreDeleteResource,
+		Data: component.MessageData{
+			Auth: auth,
+			Data: []byte(id),
+		},
+	}
+	if err := n.request(bCtx, &req); err != nil {
+		return fmt.Errorf("failed to delete resource %s: %w", id, err)
+	}
+	// The data store replies with whether the resource existed
+	var deleted bool
+	if err := json.Unmarshal(req.Reply.Data, &deleted); err != nil {
+		return fmt.Errorf("failed to decode reply from deleting resource %s: %w", id, err)
+	}
+	if !deleted {
+		return fmt.Errorf("%w: %s", ErrResourceNotFound, id)
+	}
+	return nil
+}
+
+// PatchResource uses the bubbly natsClient client to patch a resource in the
+// data store
+func (n *natsClient) PatchResource(bCtx *env.BubblyContext, auth *component.MessageAuth, id string, patch []byte) error {
+	return PatchStoredResource(bCtx, n, auth, id, patch)
+}
+
+// PatchStoredResource patches a resource by getting it with the client,
+// applying the JSON Merge Patch to it and posting the patched resource.
+// It returns ErrResourceNotFound if the resource does not exist, and
+// ErrInvalidResource if the patched resource is not valid, including if the
+// patch changes the namespace, kind or name of the resource.
+// It is meant for the clients that talk to the data store directly
+func PatchStoredResource(bCtx *env.BubblyContext, c Client, auth *component.MessageAuth, id string, patch []byte) error {
+	bCtx.Logger.Debug().
+		Str("resource_id", id).
+		Msg("Patching resource in store")
+
+	resJSON, err := c.GetResource(bCtx, auth, id)
+	if err != nil {
+		return err
+	}
+	patched, err := core.MergePatch(resJSON, patch)
+	if err != nil {
+		return fmt.Errorf("failed to patch resource %s: %w", id, err)
+	}
+
+	// Decoding the resource also parses its spec, which validates it
+	var res core.ResourceBlock
+	if err := json.Unmarshal(patched, &res); err != nil {
+		return fmt.Errorf("%w: %s: %s", ErrInvalidResource, id, err.Error())
+	}
+	if res.String() != id {
+		return fmt.Errorf("%w: %s: the namespace, kind and name of a resource cannot be patched", ErrInvalidResource, id)
+	}
+	d, err := res.Data()
+	if err != nil {
+		return fmt.Errorf("%w: %s: %s", ErrInvalidResource, id, err.Error())
+	}
+	dBytes, err := json.Marshal(core.DataBlocks{d})
+	if err != nil {
+		return fmt.Errorf("failed to marshal patched resource %s: %w", id, err)
+	}
+	return c.PostResource(bCtx, auth, dBytes)
+}
+
 // PostResource uses the bubbly natsClient client to publish a resource to a worker
 // The data is marshalled from a core.DataBlocks
 func (n *natsClient) PostResourceToWorker(bCtx *env.BubblyContext, auth *component.MessageAuth, data []byte) error {
@@ -0,0 +1,115 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+
+	"github.com/graphql-go/graphql"
+	"github.com/hashicorp/go-multierror"
+
+	"github.com/valocode/bubbly/agent/component"
+	"github.com/valocode/bubbly/api/core"
+	"github.com/valocode/bubbly/env"
+)
+
+// GetResourcesByKind uses the bubbly api endpoint to get the resources of a
+// kind in a namespace
+func (c *httpClient) GetResourcesByKind(bCtx *env.BubblyContext, _ *component.MessageAuth, namespace string, kind string) ([]byte, error) {
+
+	bCtx.Logger.Debug().Str("namespace", namespace).Str("kind", kind).Msg("Getting resources by kind from bubbly API.")
+
+	params := url.Values{}
+	params.Set("kind", kind)
+	if namespace != "" {
+		params.Set("namespace", namespace)
+	}
+	resp, err := c.handleRequest(http.MethodGet, "/resources?"+params.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("error getting resources of kind %s: %w", kind, err)
+	}
+
+	defer resp.Body.Close()
+	return io.ReadAll(resp.Body)
+}
+
+// GetResourcesByKind uses the bubbly NATS client to get the resources of a
+// kind in a namespace from the data store
+func (n *natsClient) GetResourcesByKind(bCtx *env.BubblyContext, auth *component.MessageAuth, namespace string, kind string) ([]byte, error) {
+	bCtx.Logger.Debug().
+		Str("namespace", namespace).
+		Str("kind", kind).
+		Msg("Getting resources by kind from store")
+
+	req := component.Request{
+		Subject: component.StoreGetResourcesByKind,
+		Data: component.MessageData{
+			Auth: auth,
+			Data: []byte(ResourcesByKindQuery(kind)),
+		},
+	}
+	if err := n.request(bCtx, &req); err != nil {
+		return nil, fmt.Errorf("failed to get resources by kind from query: %w", err)
+	}
+	return ResourcesFromQueryResult(namespace, req.Reply.Data)
+}
+
+// ResourcesByKindQuery returns the GraphQL query used to get the resources of
+// the given kind, in all namespaces, from the data store
+func ResourcesByKindQuery(kind string) string {
+	return fmt.Sprintf(`
+		{
+			%s(kind: %q) {
+				name
+				kind
+				api_version
+				metadata
+				spec
+			}
+		}
+	`, core.ResourceTableName, kind)
+}
+
+// ResourcesFromQueryResult takes the JSON encoded graphql.Result of a
+// ResourcesByKindQuery and returns the JSON list of the resources in the
+// namespace, ordered by name. An empty namespace is the default namespace
+func ResourcesFromQueryResult(namespace string, data []byte) ([]byte, error) {
+	var (
+		result    graphql.Result
+		resources core.ResourceBlockJSONWrapper
+	)
+	result.Data = &resources
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response from query to store: %w", err)
+	}
+	if result.HasErrors() {
+		var graphqlErrors error
+		for _, qlError := range result.Errors {
+			graphqlErrors = multierror.Append(graphqlErrors, qlError)
+		}
+		return nil, fmt.Errorf("failed to get resources: %w", graphqlErrors)
+	}
+
+	if namespace == "" {
+		namespace = core.DefaultNamespace
+	}
+	// The resources of all namespaces are in the same table, so keep only
+	// those in the namespace
+	inNamespace := make([]core.ResourceBlock, 0, len(resources.ResourceBlocks))
+	for _, res := range resources.ResourceBlocks {
+		if res.Namespace() == namespace {
+			inNamespace = append(inNamespace, res)
+		}
+	}
+	sort.Slice(inNamespace, func(i, j int) bool {
+		return inNamespace[i].ResourceName < inNamespace[j].ResourceName
+	})
+	resBytes, err := json.Marshal(inNamespace)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal resources: %w", err)
+	}
+	return resBytes, nil
+}
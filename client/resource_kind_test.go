@@ -0,0 +1,79 @@
+package client
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/h2non/gock.v1"
+
+	"github.com/valocode/bubbly/api/core"
+	"github.com/valocode/bubbly/env"
+)
+
+// runsResult is the result of a ResourcesByKindQuery for run resources in two
+// namespaces
+const runsResult = `{"data":{"_resource":[
+	{"name":"nightly","kind":"run","api_version":"v1","metadata":null,"spec":"interval = \"24h\"\n"},
+	{"name":"deploy","kind":"run","api_version":"v1","metadata":{"namespace":"team-a"},"spec":"remote {}\n"},
+	{"name":"coverage","kind":"run","api_version":"v1","metadata":{"namespace":"default"},"spec":"remote {}\n"}
+]}}`
+
+func TestResourcesFromQueryResult(t *testing.T) {
+	tcs := []struct {
+		desc      string
+		namespace string
+		data      string
+		names     []string
+		err       string
+	}{
+		{desc: "default namespace", namespace: "default", data: runsResult, names: []string{"coverage", "nightly"}},
+		{desc: "empty namespace", namespace: "", data: runsResult, names: []string{"coverage", "nightly"}},
+		{desc: "other namespace", namespace: "team-a", data: runsResult, names: []string{"deploy"}},
+		{desc: "no resources", namespace: "team-b", data: runsResult, names: []string{}},
+		{
+			desc: "query error",
+			data: `{"errors":[{"message":"unknown field"}]}`,
+			err:  "failed to get resources",
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.desc, func(t *testing.T) {
+			resBytes, err := ResourcesFromQueryResult(tc.namespace, []byte(tc.data))
+			if tc.err != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tc.err)
+				return
+			}
+			require.NoError(t, err)
+			var resources []core.ResourceBlock
+			require.NoError(t, json.Unmarshal(resBytes, &resources))
+			names := make([]string, 0, len(resources))
+			for _, res := range resources {
+				assert.Equal(t, "run", res.ResourceKind)
+				names = append(names, res.ResourceName)
+			}
+			assert.Equal(t, tc.names, names)
+		})
+	}
+}
+
+func TestGetResourcesByKind(t *testing.T) {
+	defer gock.Off()
+	bCtx := env.NewBubblyContext()
+	gock.New(bCtx.ClientConfig.BubblyAddr).
+		Get("/resources").
+		MatchParam("kind", "run").
+		MatchParam("namespace", "team-a").
+		Reply(http.StatusOK).
+		BodyString(`[]`)
+
+	c, err := newHTTP(bCtx)
+	require.NoError(t, err)
+	resBytes, err := c.GetResourcesByKind(bCtx, nil, "team-a", "run")
+	require.NoError(t, err)
+	assert.Equal(t, `[]`, string(resBytes))
+	assert.True(t, gock.IsDone())
+}
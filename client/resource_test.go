@@ -0,0 +1,163 @@
+package client
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/h2non/gock.v1"
+
+	"github.com/valocode/bubbly/api/core"
+	"github.com/valocode/bubbly/env"
+)
+
+// TestGetResource verifies that the client requests the resource in the
+// format given by the options
+func TestGetResource(t *testing.T) {
+	tcs := []struct {
+		desc     string
+		opts     []ResourceOption
+		accept   string
+		response string
+	}{
+		{
+			desc:     "resource as json",
+			opts:     nil,
+			accept:   "",
+			response: `{"kind":"extract","name":"junit"}`,
+		},
+		{
+			desc:     "resource as yaml",
+			opts:     []ResourceOption{WithYAML()},
+			accept:   core.MIMEApplicationYAML,
+			response: "kind: extract\nname: junit\n",
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.desc, func(t *testing.T) {
+			defer gock.Off()
+			bCtx := env.NewBubblyContext()
+
+			mock := gock.New(bCtx.ClientConfig.BubblyAddr).
+				Get("/api/v1/resource/extract/junit")
+			if tc.accept != "" {
+				mock = mock.MatchHeader("Accept", tc.accept)
+			}
+			mock.Reply(http.StatusOK).BodyString(tc.response)
+
+			c, err := newHTTP(bCtx)
+			require.NoError(t, err)
+
+			resBytes, err := c.GetResource(bCtx, nil, "extract/junit", tc.opts...)
+			require.NoError(t, err)
+			assert.Equal(t, tc.response, string(resBytes))
+			assert.True(t, gock.IsDone())
+		})
+	}
+}
+
+// TestPostResourceRetry verifies that the client retries posting a resource
+// that got no response with the same idempotency key, but not a resource that
+// the server rejected
+func TestPostResourceRetry(t *testing.T) {
+	tcs := []struct {
+		desc string
+		// replies are the replies to each attempt, where a zero status means
+		// that the request gets no response
+		replies []int
+		err     bool
+	}{
+		{
+			desc:    "first attempt",
+			replies: []int{http.StatusOK},
+		},
+		{
+			desc:    "retried after no response",
+			replies: []int{0, 0, http.StatusOK},
+		},
+		{
+			desc:    "no response to any attempt",
+			replies: []int{0, 0, 0},
+			err:     true,
+		},
+		{
+			desc:    "rejected",
+			replies: []int{http.StatusBadRequest},
+			err:     true,
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.desc, func(t *testing.T) {
+			defer gock.Off()
+			bCtx := env.NewBubblyContext()
+
+			// Record the idempotency keys of the requests
+			keys := map[string]struct{}{}
+			for _, status := range tc.replies {
+				mock := gock.New(bCtx.ClientConfig.BubblyAddr).
+					Post("/api/v1/resource").
+					AddMatcher(func(req *http.Request, _ *gock.Request) (bool, error) {
+						keys[req.Header.Get(HeaderIdempotencyKey)] = struct{}{}
+						return true, nil
+					})
+				if status == 0 {
+					mock.ReplyError(errors.New("timeout"))
+					continue
+				}
+				mock.Reply(status).JSON(map[string]interface{}{"error": map[string]string{"message": http.StatusText(status)}})
+			}
+
+			c, err := newHTTP(bCtx)
+			require.NoError(t, err)
+
+			err = c.PostResource(bCtx, nil, []byte(`{"kind":"extract","name":"junit"}`))
+			if tc.err {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.True(t, gock.IsDone())
+			require.Len(t, keys, 1)
+			assert.NotContains(t, keys, "")
+		})
+	}
+}
+
+// TestPatchResource verifies that the client returns the errors for a missing
+// resource and an invalid patch, based on the status of the response
+func TestPatchResource(t *testing.T) {
+	tcs := []struct {
+		desc   string
+		status int
+		err    error
+	}{
+		{desc: "patched", status: http.StatusOK},
+		{desc: "missing resource", status: http.StatusNotFound, err: ErrResourceNotFound},
+		{desc: "invalid resource", status: http.StatusUnprocessableEntity, err: ErrInvalidResource},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.desc, func(t *testing.T) {
+			defer gock.Off()
+			bCtx := env.NewBubblyContext()
+
+			gock.New(bCtx.ClientConfig.BubblyAddr).
+				Patch("/api/v1/resource/extract/junit").
+				JSON(map[string]interface{}{"metadata": map[string]interface{}{"labels": map[string]string{"env": "prod"}}}).
+				Reply(tc.status).
+				JSON(map[string]interface{}{"error": map[string]string{"message": http.StatusText(tc.status)}})
+
+			c, err := newHTTP(bCtx)
+			require.NoError(t, err)
+
+			err = c.PatchResource(bCtx, nil, "extract/junit", []byte(`{"metadata":{"labels":{"env":"prod"}}}`))
+			if tc.err != nil {
+				assert.True(t, errors.Is(err, tc.err), "expected %v, got: %v", tc.err, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.True(t, gock.IsDone())
+		})
+	}
+}
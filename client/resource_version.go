@@ -0,0 +1,232 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/graphql-go/graphql"
+	"github.com/hashicorp/go-multierror"
+	"github.com/labstack/echo/v4"
+
+	"github.com/valocode/bubbly/agent/component"
+	"github.com/valocode/bubbly/api/core"
+	"github.com/valocode/bubbly/env"
+)
+
+// ResourceVersion is a version of a resource. A version is saved each time
+// that a resource is saved, and the versions of a resource are numbered from
+// 1, the oldest, in the order that they were saved
+type ResourceVersion struct {
+	Version int `json:"version"`
+	// Time is when the version was saved
+	Time     string             `json:"time"`
+	Resource core.ResourceBlock `json:"resource"`
+}
+
+// GetResourceVersions uses the bubbly api endpoint to get the versions of a
+// resource
+func (c *httpClient) GetResourceVersions(bCtx *env.BubblyContext, _ *component.MessageAuth, id string) ([]byte, error) {
+
+	bCtx.Logger.Debug().Str("resource_id", id).Msg("Getting resource versions from bubbly API.")
+
+	resp, err := c.handleRequest(http.MethodGet, "/resource/"+resourceVersionsPath(id), nil)
+	if err != nil {
+		return nil, fmt.Errorf("error getting versions of resource %s: %w", id, err)
+	}
+
+	defer resp.Body.Close()
+	return io.ReadAll(resp.Body)
+}
+
+// GetResourceVersion uses the bubbly api endpoint to get a version of a
+// resource
+func (c *httpClient) GetResourceVersion(bCtx *env.BubblyContext, _ *component.MessageAuth, id string, version int, opts ...ResourceOption) ([]byte, error) {
+
+	bCtx.Logger.Debug().Str("resource_id", id).Int("version", version).Msg("Getting resource version from bubbly API.")
+
+	var header = make(http.Header)
+	if options := newResourceOptions(opts); options.yaml {
+		header.Set(echo.HeaderAccept, core.MIMEApplicationYAML)
+	}
+	resp, err := c.handleRequestWithHeader(http.MethodGet, "/resource/"+id+"?version="+strconv.Itoa(version), nil, header)
+	if err != nil {
+		return nil, fmt.Errorf("error getting version %d of resource %s: %w", version, id, err)
+	}
+
+	defer resp.Body.Close()
+	return io.ReadAll(resp.Body)
+}
+
+// resourceVersionsPath returns the path of the versions of the resource with
+// the given ID. The path always has the namespace, as a path without it would
+// be the same as that of a resource named "versions" in a namespace
+func resourceVersionsPath(id string) string {
+	if strings.Count(id, "/") == 1 {
+		id = core.DefaultNamespace + "/" + id
+	}
+	return id + "/versions"
+}
+
+// GetResourceVersions uses the bubbly NATS client to get the versions of a
+// resource from the data store
+func (n *natsClient) GetResourceVersions(bCtx *env.BubblyContext, auth *component.MessageAuth, id string) ([]byte, error) {
+	data, err := n.queryResourceVersions(bCtx, auth, id)
+	if err != nil {
+		return nil, err
+	}
+	return ResourceVersionsFromQueryResult(id, data)
+}
+
+// GetResourceVersion uses the bubbly NATS client to get a version of a
+// resource from the data store
+func (n *natsClient) GetResourceVersion(bCtx *env.BubblyContext, auth *component.MessageAuth, id string, version int, opts ...ResourceOption) ([]byte, error) {
+	data, err := n.queryResourceVersions(bCtx, auth, id)
+	if err != nil {
+		return nil, err
+	}
+	return ResourceVersionFromQueryResult(id, version, data, opts...)
+}
+
+func (n *natsClient) queryResourceVersions(bCtx *env.BubblyContext, auth *component.MessageAuth, id string) ([]byte, error) {
+	bCtx.Logger.Debug().
+		Str("resource_id", id).
+		Msg("Getting resource versions from store")
+
+	req := component.Request{
+		Subject: component.StoreQuery,
+		Data: component.MessageData{
+			Auth: auth,
+			Data: []byte(ResourceVersionsQuery(id)),
+		},
+	}
+	if err := n.request(bCtx, &req); err != nil {
+		return nil, fmt.Errorf("failed to get resource versions from query: %w", err)
+	}
+	return req.Reply.Data, nil
+}
+
+// ResourceVersionsQuery returns the GraphQL query used to get the versions of
+// the resource with the given ID from the data store
+func ResourceVersionsQuery(resID string) string {
+	return fmt.Sprintf(`
+		{
+			%s(id: "%s") {
+				%s {
+					_id
+					time
+					name
+					kind
+					api_version
+					metadata
+					spec
+				}
+			}
+		}
+	`, core.ResourceTableName, resID, core.ResourceVersionTableName)
+}
+
+// ResourceVersionsFromQueryResult takes the JSON encoded graphql.Result of a
+// ResourceVersionsQuery and returns the JSON list of the ResourceVersion of
+// the resource, oldest first
+func ResourceVersionsFromQueryResult(resID string, data []byte) ([]byte, error) {
+	versions, err := resourceVersions(resID, data)
+	if err != nil {
+		return nil, err
+	}
+	vBytes, err := json.Marshal(versions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal versions of resource %s: %w", resID, err)
+	}
+	return vBytes, nil
+}
+
+// ResourceVersionFromQueryResult takes the JSON encoded graphql.Result of a
+// ResourceVersionsQuery and returns the resource as it was in the given
+// version. It returns ErrResourceNotFound if there is no such version
+func ResourceVersionFromQueryResult(resID string, version int, data []byte, opts ...ResourceOption) ([]byte, error) {
+	versions, err := resourceVersions(resID, data)
+	if err != nil {
+		return nil, err
+	}
+	if version < 1 || version > len(versions) {
+		return nil, fmt.Errorf("%w: %s version %d", ErrResourceNotFound, resID, version)
+	}
+	resBytes, err := json.Marshal(versions[version-1].Resource)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal version %d of resource %s: %w", version, resID, err)
+	}
+	if options := newResourceOptions(opts); options.yaml {
+		return core.ResourceJSONToYAML(resBytes)
+	}
+	return resBytes, nil
+}
+
+// resourceVersions decodes the versions of a resource from the JSON encoded
+// graphql.Result of a ResourceVersionsQuery, and numbers them
+func resourceVersions(resID string, data []byte) ([]ResourceVersion, error) {
+	var (
+		result    graphql.Result
+		resources struct {
+			Resources []struct {
+				Versions []json.RawMessage `json:"_resource_version"`
+			} `json:"_resource"`
+		}
+	)
+	result.Data = &resources
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response from query to store: %w", err)
+	}
+	if result.HasErrors() {
+		var graphqlErrors error
+		for _, qlError := range result.Errors {
+			graphqlErrors = multierror.Append(graphqlErrors, qlError)
+		}
+		return nil, fmt.Errorf("failed to get resource versions: %w", graphqlErrors)
+	}
+	if len(resources.Resources) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrResourceNotFound, resID)
+	}
+
+	type versionRow struct {
+		id      int64
+		version ResourceVersion
+	}
+	rows := make([]versionRow, 0, len(resources.Resources[0].Versions))
+	for _, raw := range resources.Resources[0].Versions {
+		var row struct {
+			ID   string `json:"_id"`
+			Time string `json:"time"`
+		}
+		if err := json.Unmarshal(raw, &row); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal version of resource %s: %w", resID, err)
+		}
+		id, err := strconv.ParseInt(row.ID, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid _id of version of resource %s: %w", resID, err)
+		}
+		var res core.ResourceBlock
+		if err := json.Unmarshal(raw, &res); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal version of resource %s: %w", resID, err)
+		}
+		rows = append(rows, versionRow{
+			id:      id,
+			version: ResourceVersion{Time: row.Time, Resource: res},
+		})
+	}
+	// The versions are saved in order, so the order of their _id is the
+	// order of the versions
+	sort.Slice(rows, func(i, j int) bool {
+		return rows[i].id < rows[j].id
+	})
+	versions := make([]ResourceVersion, 0, len(rows))
+	for i, row := range rows {
+		row.version.Version = i + 1
+		versions = append(versions, row.version)
+	}
+	return versions, nil
+}
@@ -0,0 +1,116 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/h2non/gock.v1"
+
+	"github.com/valocode/bubbly/env"
+)
+
+// versionsResult is the result of a ResourceVersionsQuery for a resource with
+// two versions, which are not returned in the order that they were saved
+const versionsResult = `{"data":{"_resource":[{"_resource_version":[
+	{"_id":"12","time":"2021-06-02T10:00:00Z","kind":"extract","name":"junit","api_version":"v1","metadata":{"labels":{"env":"prod"}},"spec":"type = \"xml\"\n"},
+	{"_id":"9","time":"2021-06-01T10:00:00Z","kind":"extract","name":"junit","api_version":"v1","metadata":{"labels":{"env":"test"}},"spec":"type = \"json\"\n"}
+]}]}}`
+
+func TestResourceVersionsFromQueryResult(t *testing.T) {
+	vBytes, err := ResourceVersionsFromQueryResult("extract/junit", []byte(versionsResult))
+	require.NoError(t, err)
+
+	var versions []ResourceVersion
+	require.NoError(t, json.Unmarshal(vBytes, &versions))
+	require.Len(t, versions, 2)
+	assert.Equal(t, 1, versions[0].Version)
+	assert.Equal(t, "2021-06-01T10:00:00Z", versions[0].Time)
+	assert.Equal(t, "type = \"json\"\n", versions[0].Resource.SpecRaw)
+	assert.Equal(t, 2, versions[1].Version)
+	assert.Equal(t, "2021-06-02T10:00:00Z", versions[1].Time)
+	assert.Equal(t, "type = \"xml\"\n", versions[1].Resource.SpecRaw)
+	assert.Equal(t, map[string]string{"env": "prod"}, versions[1].Resource.Labels())
+
+	_, err = ResourceVersionsFromQueryResult("extract/nope", []byte(`{"data":{"_resource":[]}}`))
+	assert.True(t, errors.Is(err, ErrResourceNotFound))
+}
+
+func TestResourceVersionFromQueryResult(t *testing.T) {
+	tcs := []struct {
+		desc    string
+		version int
+		spec    string
+		found   bool
+	}{
+		{desc: "first version", version: 1, spec: "type = \"json\"\n", found: true},
+		{desc: "latest version", version: 2, spec: "type = \"xml\"\n", found: true},
+		{desc: "version zero", version: 0},
+		{desc: "future version", version: 3},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.desc, func(t *testing.T) {
+			resBytes, err := ResourceVersionFromQueryResult("extract/junit", tc.version, []byte(versionsResult))
+			if !tc.found {
+				require.Error(t, err)
+				assert.True(t, errors.Is(err, ErrResourceNotFound))
+				return
+			}
+			require.NoError(t, err)
+			var res map[string]interface{}
+			require.NoError(t, json.Unmarshal(resBytes, &res))
+			assert.Equal(t, tc.spec, res["spec"])
+			assert.Equal(t, "junit", res["name"])
+		})
+	}
+}
+
+// TestGetResourceVersions verifies that the client requests the versions of a
+// resource with the namespace in the path, also for the default namespace
+func TestGetResourceVersions(t *testing.T) {
+	tcs := []struct {
+		desc string
+		id   string
+		path string
+	}{
+		{desc: "default namespace", id: "extract/junit", path: "/api/v1/resource/default/extract/junit/versions"},
+		{desc: "other namespace", id: "team-a/extract/junit", path: "/api/v1/resource/team-a/extract/junit/versions"},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.desc, func(t *testing.T) {
+			defer gock.Off()
+			bCtx := env.NewBubblyContext()
+			gock.New(bCtx.ClientConfig.BubblyAddr).
+				Get(tc.path).
+				Reply(http.StatusOK).
+				BodyString(`[]`)
+
+			c, err := newHTTP(bCtx)
+			require.NoError(t, err)
+			vBytes, err := c.GetResourceVersions(bCtx, nil, tc.id)
+			require.NoError(t, err)
+			assert.Equal(t, `[]`, string(vBytes))
+			assert.True(t, gock.IsDone())
+		})
+	}
+}
+
+func TestGetResourceVersion(t *testing.T) {
+	defer gock.Off()
+	bCtx := env.NewBubblyContext()
+	gock.New(bCtx.ClientConfig.BubblyAddr).
+		Get("/api/v1/resource/extract/junit").
+		MatchParam("version", "2").
+		Reply(http.StatusOK).
+		BodyString(`{"kind":"extract","name":"junit"}`)
+
+	c, err := newHTTP(bCtx)
+	require.NoError(t, err)
+	resBytes, err := c.GetResourceVersion(bCtx, nil, "extract/junit", 2)
+	require.NoError(t, err)
+	assert.Equal(t, `{"kind":"extract","name":"junit"}`, string(resBytes))
+	assert.True(t, gock.IsDone())
+}
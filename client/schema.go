@@ -2,7 +2,10 @@ package client
 
 import (
 	"bytes"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
 
 	"github.com/valocode/bubbly/agent/component"
@@ -11,9 +14,12 @@ import (
 
 // PostSchema uses the bubbly api to post a schema
 func (c *httpClient) PostSchema(bCtx *env.BubblyContext, _ *component.MessageAuth, schema []byte) error {
-
-	_, err := c.handleRequest(http.MethodPost, "/schema", bytes.NewBuffer(schema))
-	return err
+	resp, err := c.handleRequest(http.MethodPost, "/schema", bytes.NewBuffer(schema))
+	if err != nil {
+		return fmt.Errorf("failed to post schema: %w", err)
+	}
+	resp.Body.Close()
+	return nil
 }
 
 func (n *natsClient) PostSchema(bCtx *env.BubblyContext, auth *component.MessageAuth, schema []byte) error {
@@ -35,3 +41,177 @@ func (n *natsClient) PostSchema(bCtx *env.BubblyContext, auth *component.Message
 
 	return nil
 }
+
+// PreviewSchema uses the bubbly api to get the SQL statements that posting a
+// schema would run
+func (c *httpClient) PreviewSchema(bCtx *env.BubblyContext, _ *component.MessageAuth, schema []byte) ([]byte, error) {
+	resp, err := c.handleRequest(http.MethodPost, "/schema/preview", bytes.NewBuffer(schema))
+	if err != nil {
+		return nil, fmt.Errorf("failed to preview schema: %w", err)
+	}
+	defer resp.Body.Close()
+
+	return io.ReadAll(resp.Body)
+}
+
+func (n *natsClient) PreviewSchema(bCtx *env.BubblyContext, auth *component.MessageAuth, schema []byte) ([]byte, error) {
+	bCtx.Logger.Debug().
+		Str("subject", string(component.StorePreviewSchema)).
+		Msg("Previewing schema of data store")
+
+	req := component.Request{
+		Subject: component.StorePreviewSchema,
+		Data: component.MessageData{
+			Auth: auth,
+			Data: schema,
+		},
+	}
+	if err := n.request(bCtx, &req); err != nil {
+		return nil, fmt.Errorf("failed to preview schema: %w", err)
+	}
+	// The data store replies with the statements encoded as JSON
+	return req.Reply.Data, nil
+}
+
+// GetSchemaSDL uses the bubbly api to get the GraphQL schema as SDL. The SDL
+// is cached for the client's SchemaCacheTTL, after which the api is asked
+// whether the schema has changed, and the SDL is only fetched again if it has
+func (c *httpClient) GetSchemaSDL(bCtx *env.BubblyContext, _ *component.MessageAuth) ([]byte, error) {
+	if sdl, ok := c.schema.get(); ok {
+		bCtx.Logger.Debug().Msg("Using cached schema SDL")
+		return sdl, nil
+	}
+	var header = make(http.Header)
+	if etag := c.schema.revalidate(); etag != "" {
+		header.Set(headerIfNoneMatch, etag)
+	}
+	resp, err := c.handleRequestWithHeader(http.MethodGet, "/graphql/schema.graphql", nil, header)
+	if err != nil {
+		var statusErr *httpStatusError
+		if errors.As(err, &statusErr) && statusErr.statusCode == http.StatusNotModified {
+			if sdl, ok := c.schema.refresh(); ok {
+				bCtx.Logger.Debug().Msg("Schema SDL has not changed")
+				return sdl, nil
+			}
+		}
+		return nil, fmt.Errorf("failed to get schema SDL: %w", err)
+	}
+	defer resp.Body.Close()
+
+	sdl, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema SDL: %w", err)
+	}
+	c.schema.set(sdl, resp.Header.Get(headerETag))
+	return sdl, nil
+}
+
+func (n *natsClient) GetSchemaSDL(bCtx *env.BubblyContext, auth *component.MessageAuth) ([]byte, error) {
+	bCtx.Logger.Debug().
+		Str("subject", string(component.StoreGetSchemaSDL)).
+		Msg("Getting schema SDL from data store")
+
+	req := component.Request{
+		Subject: component.StoreGetSchemaSDL,
+		Data: component.MessageData{
+			Auth: auth,
+		},
+	}
+	if err := n.request(bCtx, &req); err != nil {
+		return nil, fmt.Errorf("failed to get schema SDL: %w", err)
+	}
+	// The data store replies with the SDL encoded as a JSON string
+	var sdl string
+	if err := json.Unmarshal(req.Reply.Data, &sdl); err != nil {
+		return nil, fmt.Errorf("failed to decode schema SDL: %w", err)
+	}
+	return []byte(sdl), nil
+}
+
+// GetSchema uses the bubbly api to get the tables of the current schema
+func (c *httpClient) GetSchema(bCtx *env.BubblyContext, _ *component.MessageAuth) ([]byte, error) {
+	resp, err := c.handleRequest(http.MethodGet, "/schema", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get schema: %w", err)
+	}
+	defer resp.Body.Close()
+
+	return io.ReadAll(resp.Body)
+}
+
+func (n *natsClient) GetSchema(bCtx *env.BubblyContext, auth *component.MessageAuth) ([]byte, error) {
+	bCtx.Logger.Debug().
+		Str("subject", string(component.StoreGetSchema)).
+		Msg("Getting schema from data store")
+
+	req := component.Request{
+		Subject: component.StoreGetSchema,
+		Data: component.MessageData{
+			Auth: auth,
+		},
+	}
+	if err := n.request(bCtx, &req); err != nil {
+		return nil, fmt.Errorf("failed to get schema: %w", err)
+	}
+	// The data store replies with the tables encoded as JSON
+	return req.Reply.Data, nil
+}
+
+// DescribeSchema uses the bubbly api to get the description of the tables of
+// the current schema
+func (c *httpClient) DescribeSchema(bCtx *env.BubblyContext, _ *component.MessageAuth) ([]byte, error) {
+	resp, err := c.handleRequest(http.MethodGet, "/schema/describe", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe schema: %w", err)
+	}
+	defer resp.Body.Close()
+
+	return io.ReadAll(resp.Body)
+}
+
+func (n *natsClient) DescribeSchema(bCtx *env.BubblyContext, auth *component.MessageAuth) ([]byte, error) {
+	bCtx.Logger.Debug().
+		Str("subject", string(component.StoreDescribeSchema)).
+		Msg("Describing schema of data store")
+
+	req := component.Request{
+		Subject: component.StoreDescribeSchema,
+		Data: component.MessageData{
+			Auth: auth,
+		},
+	}
+	if err := n.request(bCtx, &req); err != nil {
+		return nil, fmt.Errorf("failed to describe schema: %w", err)
+	}
+	// The data store replies with the description encoded as JSON
+	return req.Reply.Data, nil
+}
+
+// GetStatus uses the bubbly api to get the status of the data store
+func (c *httpClient) GetStatus(bCtx *env.BubblyContext, _ *component.MessageAuth) ([]byte, error) {
+	resp, err := c.handleRequest(http.MethodGet, "/status", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get status: %w", err)
+	}
+	defer resp.Body.Close()
+
+	return io.ReadAll(resp.Body)
+}
+
+func (n *natsClient) GetStatus(bCtx *env.BubblyContext, auth *component.MessageAuth) ([]byte, error) {
+	bCtx.Logger.Debug().
+		Str("subject", string(component.StoreGetStatus)).
+		Msg("Getting status from data store")
+
+	req := component.Request{
+		Subject: component.StoreGetStatus,
+		Data: component.MessageData{
+			Auth: auth,
+		},
+	}
+	if err := n.request(bCtx, &req); err != nil {
+		return nil, fmt.Errorf("failed to get status: %w", err)
+	}
+	// The data store replies with the status encoded as JSON
+	return req.Reply.Data, nil
+}
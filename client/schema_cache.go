@@ -0,0 +1,70 @@
+package client
+
+import (
+	"sync"
+	"time"
+)
+
+// schemaCache holds the GraphQL schema SDL that the HTTP client has fetched,
+// with the ETag that the API server sent for it. Within the TTL the cached SDL
+// is used without a request. After the TTL the SDL is requested with the
+// ETag, and the API server only sends it again if the schema has changed.
+type schemaCache struct {
+	mu  sync.Mutex
+	ttl time.Duration
+
+	sdl     []byte
+	etag    string
+	expires time.Time
+	// now is used to get the current time, and can be overridden for testing
+	now func() time.Time
+}
+
+func newSchemaCache(ttl time.Duration) *schemaCache {
+	return &schemaCache{
+		ttl: ttl,
+		now: time.Now,
+	}
+}
+
+// get returns the cached SDL if it has not expired
+func (c *schemaCache) get() ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.sdl == nil || !c.now().Before(c.expires) {
+		return nil, false
+	}
+	return c.sdl, true
+}
+
+// revalidate returns the ETag of the cached SDL, which is empty if there is
+// no SDL cached or the API server did not send an ETag for it
+func (c *schemaCache) revalidate() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.sdl == nil {
+		return ""
+	}
+	return c.etag
+}
+
+// set caches the SDL with its ETag
+func (c *schemaCache) set(sdl []byte, etag string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.sdl = sdl
+	c.etag = etag
+	c.expires = c.now().Add(c.ttl)
+}
+
+// refresh returns the cached SDL after the API server responded that it has
+// not changed, and restarts its TTL
+func (c *schemaCache) refresh() ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.sdl == nil {
+		return nil, false
+	}
+	c.expires = c.now().Add(c.ttl)
+	return c.sdl, true
+}
@@ -0,0 +1,116 @@
+package client
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/h2non/gock.v1"
+
+	"github.com/valocode/bubbly/env"
+)
+
+func TestPostSchema(t *testing.T) {
+	tcs := []struct {
+		desc         string
+		responseCode int
+		response     string
+		err          string
+	}{
+		{
+			desc:         "created",
+			responseCode: http.StatusOK,
+			response:     `{"status":"schema created!"}`,
+		},
+		{
+			desc:         "invalid schema",
+			responseCode: http.StatusBadRequest,
+			response:     `{"error":{"code":"bad_request","message":"failed to decode schema into core.Tables"}}`,
+			err:          "failed to post schema: 400 Bad Request: failed to decode schema into core.Tables",
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.desc, func(t *testing.T) {
+			defer gock.Off()
+			bCtx := env.NewBubblyContext()
+			schema := `[{"name":"project","fields":[{"name":"name","type":"string"}]}]`
+
+			gock.New(bCtx.ClientConfig.BubblyAddr).
+				Post("/api/v1/schema").
+				Reply(tc.responseCode).
+				BodyString(tc.response)
+
+			c, err := newHTTP(bCtx)
+			require.NoError(t, err)
+
+			err = c.PostSchema(bCtx, nil, []byte(schema))
+			if tc.err != "" {
+				assert.EqualError(t, err, tc.err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.True(t, gock.IsDone())
+		})
+	}
+}
+
+// TestGetSchemaSDLCache verifies that the schema SDL is fetched once and then
+// used from the cache, and that after the TTL it is only fetched again if the
+// schema has changed
+func TestGetSchemaSDLCache(t *testing.T) {
+	defer gock.Off()
+	bCtx := env.NewBubblyContext()
+	bCtx.ClientConfig.SchemaCacheTTL = 60
+	const (
+		sdlV1 = "type foo {\n  bar: String\n}\n"
+		sdlV2 = "type foo {\n  bar: String\n  baz: Int\n}\n"
+	)
+
+	c, err := newHTTP(bCtx)
+	require.NoError(t, err)
+	now := time.Now()
+	c.schema.now = func() time.Time { return now }
+
+	gock.New(bCtx.ClientConfig.BubblyAddr).
+		Get("/graphql/schema.graphql").
+		Reply(http.StatusOK).
+		SetHeader("ETag", `"v1"`).
+		BodyString(sdlV1)
+	// gock fails any request without a pending mock, so the SDL must only be
+	// fetched once for all of these
+	for i := 0; i < 3; i++ {
+		sdl, err := c.GetSchemaSDL(bCtx, nil)
+		require.NoError(t, err)
+		assert.Equal(t, sdlV1, string(sdl))
+	}
+	assert.True(t, gock.IsDone())
+
+	// After the TTL the schema has not changed, so the cached SDL is used
+	now = now.Add(61 * time.Second)
+	gock.New(bCtx.ClientConfig.BubblyAddr).
+		Get("/graphql/schema.graphql").
+		MatchHeader("If-None-Match", `"v1"`).
+		Reply(http.StatusNotModified).
+		SetHeader("ETag", `"v1"`)
+	sdl, err := c.GetSchemaSDL(bCtx, nil)
+	require.NoError(t, err)
+	assert.Equal(t, sdlV1, string(sdl))
+	assert.True(t, gock.IsDone())
+
+	// After the TTL again the schema has changed, so the SDL is fetched again
+	now = now.Add(61 * time.Second)
+	gock.New(bCtx.ClientConfig.BubblyAddr).
+		Get("/graphql/schema.graphql").
+		MatchHeader("If-None-Match", `"v1"`).
+		Reply(http.StatusOK).
+		SetHeader("ETag", `"v2"`).
+		BodyString(sdlV2)
+	for i := 0; i < 2; i++ {
+		sdl, err := c.GetSchemaSDL(bCtx, nil)
+		require.NoError(t, err)
+		assert.Equal(t, sdlV2, string(sdl))
+	}
+	assert.True(t, gock.IsDone())
+}
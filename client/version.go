@@ -0,0 +1,88 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/valocode/bubbly/env"
+)
+
+// ErrIncompatibleVersion is returned when the version of the bubbly server is
+// not compatible with the version of the client
+var ErrIncompatibleVersion = errors.New("incompatible bubbly version")
+
+// Version uses the bubbly api to get the version of the bubbly server
+func (c *httpClient) Version(bCtx *env.BubblyContext) (string, error) {
+	resp, err := c.handleRequest(http.MethodGet, "/version", nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to get version: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var version struct {
+		Version string `json:"version"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&version); err != nil {
+		return "", fmt.Errorf("failed to decode version: %w", err)
+	}
+	return version.Version, nil
+}
+
+// Version returns the version of this bubbly, as the NATS client is only used
+// within a bubbly deployment
+func (n *natsClient) Version(bCtx *env.BubblyContext) (string, error) {
+	return env.Version, nil
+}
+
+// CheckVersion checks that the bubbly server can be reached by the client,
+// and that its version is compatible with the version of the client
+func CheckVersion(bCtx *env.BubblyContext, c Client) error {
+	serverVersion, err := c.Version(bCtx)
+	if err != nil {
+		return fmt.Errorf("failed to connect to bubbly at %s: %w", bCtx.ClientConfig.BubblyAddr, err)
+	}
+	if !CompatibleVersions(env.Version, serverVersion) {
+		return fmt.Errorf("%w: the client has version %s, but the server at %s has version %s",
+			ErrIncompatibleVersion, env.Version, bCtx.ClientConfig.BubblyAddr, serverVersion)
+	}
+	return nil
+}
+
+// CompatibleVersions returns whether two versions of bubbly are compatible,
+// which they are if they have the same major and minor version.
+// Development versions are compatible with any version
+func CompatibleVersions(v1, v2 string) bool {
+	if isDevVersion(v1) || isDevVersion(v2) {
+		return true
+	}
+	majorMinor1, ok1 := majorMinorVersion(v1)
+	majorMinor2, ok2 := majorMinorVersion(v2)
+	if !ok1 || !ok2 {
+		// If the versions are not semantic versions, they need to be the same
+		return v1 == v2
+	}
+	return majorMinor1 == majorMinor2
+}
+
+func isDevVersion(v string) bool {
+	return v == "" || v == env.DevVersion
+}
+
+// majorMinorVersion returns the major and minor version, e.g. "1.2" for
+// "v1.2.3", and whether the version is a semantic version
+func majorMinorVersion(v string) (string, bool) {
+	parts := strings.SplitN(strings.TrimPrefix(v, "v"), ".", 3)
+	if len(parts) < 2 {
+		return "", false
+	}
+	for _, part := range parts[:2] {
+		if _, err := strconv.Atoi(part); err != nil {
+			return "", false
+		}
+	}
+	return parts[0] + "." + parts[1], true
+}
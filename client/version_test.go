@@ -0,0 +1,52 @@
+package client
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/h2non/gock.v1"
+
+	"github.com/valocode/bubbly/env"
+)
+
+func TestVersion(t *testing.T) {
+	defer gock.Off()
+	bCtx := env.NewBubblyContext()
+
+	gock.New(bCtx.ClientConfig.BubblyAddr).
+		Get("/api/v1/version").
+		Reply(http.StatusOK).
+		JSON(map[string]string{"version": "v1.2.3"})
+
+	c, err := newHTTP(bCtx)
+	require.NoError(t, err)
+
+	version, err := c.Version(bCtx)
+	require.NoError(t, err)
+	assert.Equal(t, "v1.2.3", version)
+	assert.True(t, gock.IsDone())
+}
+
+func TestCompatibleVersions(t *testing.T) {
+	tcs := []struct {
+		desc       string
+		v1         string
+		v2         string
+		compatible bool
+	}{
+		{desc: "same", v1: "v1.2.3", v2: "v1.2.3", compatible: true},
+		{desc: "different patch", v1: "v1.2.3", v2: "1.2.0", compatible: true},
+		{desc: "different minor", v1: "v1.2.3", v2: "v1.3.3", compatible: false},
+		{desc: "different major", v1: "v1.2.3", v2: "v2.2.3", compatible: false},
+		{desc: "dev", v1: env.DevVersion, v2: "v2.2.3", compatible: true},
+		{desc: "empty", v1: "v1.2.3", v2: "", compatible: true},
+		{desc: "not semantic", v1: "v1.2.3", v2: "latest", compatible: false},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.desc, func(t *testing.T) {
+			assert.Equal(t, tc.compatible, CompatibleVersions(tc.v1, tc.v2))
+		})
+	}
+}
@@ -112,7 +112,7 @@ func NewCmdAgent(bCtx *env.BubblyContext) (*cobra.Command, *AgentOptions) {
 		(*string)(&o.bCtx.AgentConfig.DeploymentType),
 		"deployment-type",
 		o.bCtx.AgentConfig.DeploymentType.String(),
-		"the type of agent deployment. Options: single",
+		"the type of agent deployment. Options: single, standalone",
 	)
 	f.BoolVar(
 		&o.bCtx.AgentConfig.EnabledComponents.NATSServer,
@@ -138,11 +138,17 @@ func NewCmdAgent(bCtx *env.BubblyContext) (*cobra.Command, *AgentOptions) {
 		o.bCtx.AgentConfig.EnabledComponents.Worker,
 		"whether to run a bubbly worker on this agent",
 	)
-	f.StringVar(
-		(*string)(&o.bCtx.StoreConfig.Provider),
-		"data-store-provider",
-		(string)(o.bCtx.StoreConfig.Provider),
-		"provider of the bubbly data store",
+	f.IntVar(
+		&o.bCtx.AgentConfig.RequestTimeout,
+		"request-timeout",
+		o.bCtx.AgentConfig.RequestTimeout,
+		"time in milliseconds to wait for the reply to a request between the agent components",
+	)
+	f.IntVar(
+		&o.bCtx.AgentConfig.WorkerMaxConcurrency,
+		"worker-max-concurrency",
+		o.bCtx.AgentConfig.WorkerMaxConcurrency,
+		"maximum number of runs that the worker applies at the same time",
 	)
 	f.IntVar(
 		&o.bCtx.AgentConfig.NATSServerConfig.Port,
@@ -156,30 +162,7 @@ func NewCmdAgent(bCtx *env.BubblyContext) (*cobra.Command, *AgentOptions) {
 		o.bCtx.AgentConfig.NATSServerConfig.HTTPPort,
 		"HTTP Port of the NATS Server",
 	)
-	f.StringVar(
-		&o.bCtx.StoreConfig.PostgresAddr,
-		"postgres-addr",
-		o.bCtx.StoreConfig.PostgresAddr,
-		"postgres address for the data store",
-	)
-	f.StringVar(
-		&o.bCtx.StoreConfig.PostgresUser,
-		"postgres-username",
-		o.bCtx.StoreConfig.PostgresUser,
-		"postgres username for the data store",
-	)
-	f.StringVar(
-		&o.bCtx.StoreConfig.PostgresPassword,
-		"postgres-password",
-		o.bCtx.StoreConfig.PostgresPassword,
-		"postgres password for the data store",
-	)
-	f.StringVar(
-		&o.bCtx.StoreConfig.PostgresDatabase,
-		"postgres-database",
-		o.bCtx.StoreConfig.PostgresDatabase,
-		"postgres database for the data store",
-	)
+	cmdutil.AddStoreFlags(cmd, o.bCtx.StoreConfig)
 
 	return cmd, o
 }
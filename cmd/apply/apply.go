@@ -1,23 +1,39 @@
 package apply
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"syscall"
+	"time"
 
 	"github.com/fatih/color"
+	"github.com/ryanuber/columnize"
 	"github.com/spf13/cobra"
+	"github.com/zclconf/go-cty/cty"
+	"gopkg.in/yaml.v2"
 
+	"github.com/valocode/bubbly/api/core"
 	"github.com/valocode/bubbly/bubbly"
 	"github.com/valocode/bubbly/cmd/util"
 	cmdutil "github.com/valocode/bubbly/cmd/util"
 	"github.com/valocode/bubbly/env"
+	"github.com/valocode/bubbly/parser"
 )
 
 var (
 	_         cmdutil.Options = (*ApplyOptions)(nil)
 	applyLong                 = util.LongDesc(`
 		Apply bubbly resources to a bubbly API server 
+
+		The resources can also be applied from a Git repository, using a
+		go-getter style URL: git::<repository URL>//<path>?ref=<branch or tag>.
+		The repository is shallow cloned into a temporary directory
 	`)
 
 	applyExample = util.Examples(`
@@ -26,9 +42,40 @@ var (
 
 		# Apply the configuration in the directory ./resources
 		bubbly apply -f ./resources
+
+		# Apply the configuration in the directory resources of a Git
+		# repository, at the tag v1
+		bubbly apply -f "git::https://github.com/org/repo//resources?ref=v1"
+
+		# Apply the bubbly resources in the file ./main.bubbly, and print the
+		# outcome of applying each resource as JSON
+		bubbly apply -f ./main.bubbly -o json
+
+		# Apply the configuration in the directory ./resources, so that either
+		# all of the resources are applied or none of them are
+		bubbly apply -f ./resources --atomic
+
+		# Apply the bubbly resources in the file ./main.bubbly, overriding the
+		# input values of the runs
+		bubbly apply -f ./main.bubbly --set project=bubbly --set repo.branch=main
+
+		# Apply the configuration in the directory ./resources, and apply it
+		# again whenever the files change, until interrupted (Ctrl+C)
+		bubbly apply -f ./resources --watch
+
+		# Apply the bubbly resources in the file ./main.bubbly in the namespace
+		# team-a, instead of the default namespace
+		bubbly apply -f ./main.bubbly --namespace team-a
 		`)
 )
 
+// Formats in which the outcome of applying the resources can be printed
+const (
+	tableOutput = "table"
+	jsonOutput  = "json"
+	yamlOutput  = "yaml"
+)
+
 // ApplyOptions holds everything necessary to run the command.
 // Flag values received to the command are loaded into this struct
 type ApplyOptions struct {
@@ -37,8 +84,47 @@ type ApplyOptions struct {
 	Command string
 	Args    []string
 
+	// getter fetches the resources if they are given as a Git URL
+	getter bubbly.Getter
+
 	// flags
 	filename string
+	output   string
+	atomic   bool
+	set      []string
+	watch    bool
+
+	// watchInterval and watchDebounce are how often the files are checked
+	// for changes when watching them, and how long they must be unchanged
+	// before they are applied again
+	watchInterval time.Duration
+	watchDebounce time.Duration
+
+	// inputs are the input values parsed from the set flag
+	inputs cty.Value
+
+	// out is where the outcome of applying the resources is printed
+	out io.Writer
+	// errOut is where the diagnostics for invalid resources are written
+	errOut io.Writer
+
+	// Report contains the outcome of applying each resource
+	Report *bubbly.ApplyReport
+}
+
+// applyOutput is the outcome of applying the resources, as printed in the
+// json and yaml output formats
+type applyOutput struct {
+	Resources []applyOutputResource `json:"resources" yaml:"resources"`
+}
+
+// applyOutputResource is the outcome of applying a single resource, as
+// printed in the json and yaml output formats
+type applyOutputResource struct {
+	Kind   string `json:"kind" yaml:"kind"`
+	Name   string `json:"name" yaml:"name"`
+	Status string `json:"status" yaml:"status"`
+	Error  string `json:"error,omitempty" yaml:"error,omitempty"`
 }
 
 // NewCmdApply creates a new cobra.Command representing "bubbly apply"
@@ -46,17 +132,24 @@ func NewCmdApply(bCtx *env.BubblyContext) (*cobra.Command, *ApplyOptions) {
 	o := &ApplyOptions{
 		Command: "apply",
 		bCtx:    bCtx,
+		getter:  bubbly.GitGetter{},
+		out:     os.Stdout,
+		errOut:  os.Stderr,
+
+		watchInterval: defaultWatchInterval,
+		watchDebounce: defaultWatchDebounce,
 	}
 
 	// cmd represents the apply command
 	cmd := &cobra.Command{
-		Use:     "apply (-f (FILENAME | DIRECTORY)) [flags]",
+		Use:     "apply (-f (FILENAME | DIRECTORY | GIT URL)) [flags]",
 		Short:   "Apply one or more bubbly resource to a bubbly agent",
 		Long:    applyLong + "\n\n",
 		Example: applyExample,
 		RunE: func(cmd *cobra.Command, args []string) error {
 
 			o.Args = args
+			o.errOut = cmd.ErrOrStderr()
 
 			validationError := o.Validate(cmd)
 
@@ -69,13 +162,22 @@ func NewCmdApply(bCtx *env.BubblyContext) (*cobra.Command, *ApplyOptions) {
 			if resolveError != nil {
 				return resolveError
 			}
+
+			if o.watch {
+				ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+				defer stop()
+				return o.Watch(ctx)
+			}
+
 			runError := o.Run()
 
+			// Print the report even if some resources failed to apply, so
+			// that the user can see which ones
+			o.Print()
+
 			if runError != nil {
 				return runError
 			}
-
-			o.Print()
 			return nil
 		},
 	}
@@ -86,7 +188,31 @@ func NewCmdApply(bCtx *env.BubblyContext) (*cobra.Command, *ApplyOptions) {
 		"filename",
 		"f",
 		"",
-		"filename or directory that contains the bubbly resources to apply")
+		"filename, directory or Git URL that contains the bubbly resources to apply")
+
+	f.StringVarP(&o.output,
+		"output",
+		"o",
+		tableOutput,
+		"format to print the outcome of applying each resource in. Options: table, json, yaml")
+
+	f.BoolVar(&o.atomic,
+		"atomic",
+		false,
+		"apply the resources atomically: if any of them fails to apply, none of them are applied")
+
+	f.StringArrayVar(&o.set,
+		"set",
+		nil,
+		"set an input value of the runs, overriding the values in the files (can be repeated, e.g. --set name=value --set repo.branch=main)")
+
+	f.BoolVar(&o.watch,
+		"watch",
+		false,
+		"watch the file or directory, and apply the resources again whenever the files change")
+
+	cmdutil.AddNamespaceFlag(cmd, bCtx.ClientConfig,
+		"namespace of the bubbly resources that do not give one in their metadata")
 
 	cmd.MarkFlagRequired("filename")
 
@@ -100,6 +226,35 @@ func (o *ApplyOptions) Validate(cmd *cobra.Command) error {
 		return cmdutil.UsageErrorf(cmd, "Unexpected args: %v", o.Args)
 	}
 
+	switch o.output {
+	case tableOutput, jsonOutput, yamlOutput:
+	default:
+		return cmdutil.UsageErrorf(cmd, "Unsupported output format: %s", o.output)
+	}
+
+	if err := cmdutil.ValidateNamespace(cmd, o.bCtx.ClientConfig); err != nil {
+		return err
+	}
+
+	if len(o.set) > 0 {
+		inputs, err := core.ParseInputValues(o.set)
+		if err != nil {
+			return cmdutil.UsageErrorf(cmd, "Invalid --set: %s", err)
+		}
+		o.inputs = inputs
+	}
+
+	// A Git URL is checked when it is fetched
+	if bubbly.IsGitSource(o.filename) {
+		if o.watch {
+			return cmdutil.UsageErrorf(cmd, "Cannot watch a Git URL")
+		}
+		if _, err := bubbly.ParseGitSource(o.filename); err != nil {
+			return fmt.Errorf("failed to validate git source of bubbly resources: %w", err)
+		}
+		return nil
+	}
+
 	// check the file/directory is valid and fail fast if not
 	if _, err := os.Stat(o.filename); err != nil {
 		return fmt.Errorf(
@@ -119,21 +274,135 @@ func (o *ApplyOptions) Resolve() error {
 
 // Run runs the apply command over the validated ApplyOptions configuration
 func (o *ApplyOptions) Run() error {
-	if err := bubbly.Apply(o.bCtx, o.filename); err != nil {
+	filename, cleanup, err := bubbly.FetchFilename(o.getter, o.filename)
+	if err != nil {
+		return fmt.Errorf("failed to fetch configuration: %w", err)
+	}
+	defer cleanup()
+
+	var opts []bubbly.ApplyOption
+	if o.atomic {
+		opts = append(opts, bubbly.WithAtomic())
+	}
+	if o.inputs != cty.NilVal {
+		opts = append(opts, bubbly.WithInputs(o.inputs))
+	}
+	report, err := bubbly.Apply(o.bCtx, filename, opts...)
+	o.Report = report
+	if err != nil {
+		// If the error came from parsing/decoding the bubbly files, show the
+		// user the source where the error occurred, in color if they are
+		// reading it in a terminal
+		var parserErr *parser.ParserError
+		if errors.As(err, &parserErr) {
+			parserErr.WriteDiagnostics(o.errOut, o.bCtx.CLIConfig.Color && cmdutil.IsTerminal(o.errOut))
+		}
 		return fmt.Errorf("failed to apply configuration: %w", err)
 	}
 	return nil
 }
 
-// Print prints the successful outcome of applying the resource(s)
+// Watch applies the resources, and then applies them again whenever the bubbly
+// files change, until the context is done. The outcome of each apply is
+// printed, and failing to apply the resources does not stop the watching
+func (o *ApplyOptions) Watch(ctx context.Context) error {
+	w, err := newWatcher(o.filename, o.watchInterval, o.watchDebounce)
+	if err != nil {
+		return fmt.Errorf("failed to watch configuration: %w", err)
+	}
+	o.applyWatched()
+	return w.Watch(ctx, func() {
+		fmt.Fprintf(os.Stderr, "changes detected in \"%s\", applying again\n", filepath.FromSlash(o.filename))
+		o.applyWatched()
+	})
+}
+
+// applyWatched applies the resources when watching them, and prints the
+// outcome
+func (o *ApplyOptions) applyWatched() {
+	err := o.Run()
+	o.Print()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+	}
+	fmt.Fprintf(os.Stderr, "watching \"%s\" for changes\n", filepath.FromSlash(o.filename))
+}
+
+// Print prints the outcome of applying each resource in the output format.
+// The table format also prints whether they were all applied successfully
 func (o *ApplyOptions) Print() {
+	if o.Report == nil {
+		return
+	}
+	switch o.output {
+	case jsonOutput, yamlOutput:
+		if err := o.printStructured(); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to print the outcome of applying the resources: %s\n", err)
+		}
+	default:
+		o.printTable()
+	}
+}
+
+// printStructured prints the outcome of applying each resource as JSON or
+// YAML, for scripting
+func (o *ApplyOptions) printStructured() error {
+	output := applyOutput{Resources: []applyOutputResource{}}
+	for _, res := range o.Report.Resources {
+		r := applyOutputResource{
+			Kind:   string(res.Kind),
+			Name:   res.Name,
+			Status: string(res.Status),
+		}
+		if res.Err != nil {
+			r.Error = res.Err.Error()
+		}
+		output.Resources = append(output.Resources, r)
+	}
+
+	var (
+		b   []byte
+		err error
+	)
+	switch o.output {
+	case jsonOutput:
+		b, err = json.MarshalIndent(output, "", "  ")
+		b = append(b, '\n')
+	case yamlOutput:
+		b, err = yaml.Marshal(output)
+	}
+	if err != nil {
+		return err
+	}
+	_, err = o.out.Write(b)
+	return err
+}
+
+// printTable prints the outcome of applying each resource as a table, and
+// whether they were all applied successfully
+func (o *ApplyOptions) printTable() {
+	if len(o.Report.Resources) > 0 {
+		lines := []string{"Kind | Name | Status | Error"}
+		for _, res := range o.Report.Resources {
+			var errStr string
+			if res.Err != nil {
+				errStr = res.Err.Error()
+			}
+			lines = append(lines, fmt.Sprintf("%s | %s | %s | %s", res.Kind, res.Name, res.Status, errStr))
+		}
+		fmt.Fprintln(o.out, columnize.SimpleFormat(lines))
+	}
+	if o.Report.Failed() > 0 {
+		return
+	}
+
 	successString := fmt.Sprintf(
 		`resource(s) at path/directory "%s" applied successfully`,
 		filepath.FromSlash(o.filename))
 
 	if o.bCtx.CLIConfig.Color {
-		color.Green(successString)
+		color.New(color.FgGreen).Fprintln(o.out, successString)
 	} else {
-		fmt.Println(successString)
+		fmt.Fprintln(o.out, successString)
 	}
 }
@@ -0,0 +1,511 @@
+package apply
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/h2non/gock.v1"
+
+	"github.com/valocode/bubbly/api"
+	"github.com/valocode/bubbly/api/common"
+	"github.com/valocode/bubbly/api/core"
+	"github.com/valocode/bubbly/bubbly"
+	"github.com/valocode/bubbly/client"
+	"github.com/valocode/bubbly/env"
+	"github.com/valocode/bubbly/events"
+	"github.com/valocode/bubbly/parser"
+)
+
+func TestApplyVersion(t *testing.T) {
+	tcs := []struct {
+		desc          string
+		serverVersion string
+		// post is whether the resource should be posted
+		post bool
+		err  error
+	}{
+		{
+			desc:          "compatible version",
+			serverVersion: "v1.2.0",
+			post:          true,
+		},
+		{
+			desc:          "incompatible version",
+			serverVersion: "v2.0.0",
+			post:          false,
+			err:           client.ErrIncompatibleVersion,
+		},
+	}
+
+	version := env.Version
+	defer func() { env.Version = version }()
+	env.Version = "v1.2.3"
+
+	for _, tc := range tcs {
+		t.Run(tc.desc, func(t *testing.T) {
+			defer gock.Off()
+			bCtx := env.NewBubblyContext()
+			bCtx.CLIConfig.Color = false
+
+			gock.New(bCtx.ClientConfig.BubblyAddr).
+				Get("/api/v1/version").
+				Reply(http.StatusOK).
+				JSON(map[string]string{"version": tc.serverVersion})
+			if tc.post {
+				gock.New(bCtx.ClientConfig.BubblyAddr).
+					Post("/api/v1/resource").
+					Reply(http.StatusOK).
+					JSON(map[string]string{"status": "uploaded"})
+			}
+
+			cmd, _ := NewCmdApply(bCtx)
+			cmd.SetArgs([]string{"-f", "./testdata/extract.bubbly"})
+			cmd.SilenceUsage = true
+			cmd.SilenceErrors = true
+
+			err := cmd.Execute()
+			if tc.err != nil {
+				require.Error(t, err)
+				assert.True(t, errors.Is(err, tc.err))
+			} else {
+				require.NoError(t, err)
+			}
+			// The resource should be posted only if the version is compatible,
+			// and no other requests made
+			assert.True(t, gock.IsDone())
+			assert.False(t, gock.HasUnmatchedRequest())
+		})
+	}
+}
+
+func TestApplyReport(t *testing.T) {
+	tcs := []struct {
+		desc string
+		// statuses are the response statuses when posting each resource
+		statuses []int
+		expected []bubbly.ApplyStatus
+		err      bool
+	}{
+		{
+			desc:     "all applied",
+			statuses: []int{http.StatusOK, http.StatusOK},
+			expected: []bubbly.ApplyStatus{bubbly.ApplySucceeded, bubbly.ApplySucceeded},
+		},
+		{
+			desc:     "some failed",
+			statuses: []int{http.StatusBadRequest, http.StatusOK},
+			expected: []bubbly.ApplyStatus{bubbly.ApplyFailed, bubbly.ApplySucceeded},
+			err:      true,
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.desc, func(t *testing.T) {
+			defer gock.Off()
+			bCtx := env.NewBubblyContext()
+			bCtx.CLIConfig.Color = false
+
+			gock.New(bCtx.ClientConfig.BubblyAddr).
+				Get("/api/v1/version").
+				Reply(http.StatusOK).
+				JSON(map[string]string{"version": env.Version})
+			for _, status := range tc.statuses {
+				gock.New(bCtx.ClientConfig.BubblyAddr).
+					Post("/api/v1/resource").
+					Reply(status).
+					JSON(map[string]interface{}{"error": map[string]string{"message": http.StatusText(status)}})
+			}
+
+			cmd, o := NewCmdApply(bCtx)
+			cmd.SetArgs([]string{"-f", "./testdata/resources.bubbly"})
+			cmd.SilenceUsage = true
+			cmd.SilenceErrors = true
+
+			err := cmd.Execute()
+			if tc.err {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+			// Every resource should be posted, even after one fails
+			assert.True(t, gock.IsDone())
+
+			require.NotNil(t, o.Report)
+			require.Len(t, o.Report.Resources, len(tc.expected))
+			for idx, res := range o.Report.Resources {
+				assert.Equal(t, core.ExtractResourceKind, res.Kind)
+				assert.Equal(t, tc.expected[idx], res.Status)
+				if res.Status == bubbly.ApplyFailed {
+					assert.Error(t, res.Err)
+				} else {
+					assert.NoError(t, res.Err)
+				}
+			}
+			assert.Equal(t, "first", o.Report.Resources[0].Name)
+			assert.Equal(t, "second", o.Report.Resources[1].Name)
+		})
+	}
+}
+
+func TestApplyAtomic(t *testing.T) {
+	tcs := []struct {
+		desc     string
+		status   int
+		expected bubbly.ApplyStatus
+		err      bool
+	}{
+		{
+			desc:     "all applied",
+			status:   http.StatusOK,
+			expected: bubbly.ApplySucceeded,
+		},
+		{
+			desc:     "none applied",
+			status:   http.StatusBadRequest,
+			expected: bubbly.ApplyFailed,
+			err:      true,
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.desc, func(t *testing.T) {
+			defer gock.Off()
+			bCtx := env.NewBubblyContext()
+			bCtx.CLIConfig.Color = false
+
+			gock.New(bCtx.ClientConfig.BubblyAddr).
+				Get("/api/v1/version").
+				Reply(http.StatusOK).
+				JSON(map[string]string{"version": env.Version})
+			// The resources are posted together in a single request
+			gock.New(bCtx.ClientConfig.BubblyAddr).
+				Post("/api/v1/resources").
+				Reply(tc.status).
+				JSON(map[string]interface{}{"error": map[string]string{"message": http.StatusText(tc.status)}})
+
+			cmd, o := NewCmdApply(bCtx)
+			cmd.SetArgs([]string{"-f", "./testdata/resources.bubbly", "--atomic"})
+			cmd.SilenceUsage = true
+			cmd.SilenceErrors = true
+
+			err := cmd.Execute()
+			if tc.err {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+			assert.True(t, gock.IsDone())
+			assert.False(t, gock.HasUnmatchedRequest())
+
+			require.NotNil(t, o.Report)
+			require.Len(t, o.Report.Resources, 2)
+			for _, res := range o.Report.Resources {
+				assert.Equal(t, tc.expected, res.Status)
+			}
+		})
+	}
+}
+
+func TestApplyOutput(t *testing.T) {
+	report := &bubbly.ApplyReport{
+		Resources: []bubbly.ResourceApplyResult{
+			{Name: "first", Kind: core.ExtractResourceKind, Status: bubbly.ApplyFailed, Err: errors.New("bad request")},
+			{Name: "second", Kind: core.ExtractResourceKind, Status: bubbly.ApplySucceeded},
+		},
+	}
+	tcs := []struct {
+		desc     string
+		output   string
+		expected string
+	}{
+		{
+			desc:   "table",
+			output: tableOutput,
+			expected: "Kind     Name    Status   Error\n" +
+				"extract  first   failed   bad request\n" +
+				"extract  second  applied  \n",
+		},
+		{
+			desc:   "json",
+			output: jsonOutput,
+			expected: `{
+  "resources": [
+    {
+      "kind": "extract",
+      "name": "first",
+      "status": "failed",
+      "error": "bad request"
+    },
+    {
+      "kind": "extract",
+      "name": "second",
+      "status": "applied"
+    }
+  ]
+}
+`,
+		},
+		{
+			desc:   "yaml",
+			output: yamlOutput,
+			expected: `resources:
+- kind: extract
+  name: first
+  status: failed
+  error: bad request
+- kind: extract
+  name: second
+  status: applied
+`,
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.desc, func(t *testing.T) {
+			bCtx := env.NewBubblyContext()
+			bCtx.CLIConfig.Color = false
+
+			var out bytes.Buffer
+			o := &ApplyOptions{
+				bCtx:   bCtx,
+				output: tc.output,
+				out:    &out,
+				Report: report,
+			}
+			o.Print()
+			assert.Equal(t, tc.expected, out.String())
+		})
+	}
+}
+
+func TestApplyOutputInvalid(t *testing.T) {
+	bCtx := env.NewBubblyContext()
+	cmd, _ := NewCmdApply(bCtx)
+	cmd.SetArgs([]string{"-f", "./testdata/resources.bubbly", "-o", "xml"})
+	cmd.SilenceUsage = true
+	cmd.SilenceErrors = true
+
+	err := cmd.Execute()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Unsupported output format: xml")
+}
+
+const echoResourceKind core.ResourceKind = "echo"
+
+// echo is a resource that records the spec that it is run with, so that the
+// inputs of the run can be checked
+type echo struct {
+	*core.ResourceBlock
+}
+
+type echoSpec struct {
+	Inputs  core.InputDeclarations `hcl:"input,block"`
+	Message string                 `hcl:"message,attr"`
+	Retries int                    `hcl:"retries,attr"`
+}
+
+// echoed are the specs that the echo resources were run with
+var echoed []echoSpec
+
+func (e *echo) Run(bCtx *env.BubblyContext, ctx *core.ResourceContext) core.ResourceOutput {
+	var spec echoSpec
+	if err := common.DecodeBodyWithInputs(bCtx, e.SpecHCL.Body, &spec, ctx); err != nil {
+		return core.ResourceOutput{ID: e.String(), Status: events.ResourceRunFailure, Error: err}
+	}
+	echoed = append(echoed, spec)
+	return core.ResourceOutput{ID: e.String(), Status: events.ResourceRunSuccess}
+}
+
+func init() {
+	if err := api.RegisterResource(echoResourceKind, func(resBlock *core.ResourceBlock) (core.Resource, error) {
+		return &echo{ResourceBlock: resBlock}, nil
+	}); err != nil {
+		panic(err)
+	}
+}
+
+func TestApplySet(t *testing.T) {
+	const filename = "./testdata/set.bubbly"
+	// The run gets the echo resource from bubbly, as it was posted
+	var fileParser bubbly.BubblyFileParser
+	require.NoError(t, parser.ParseFilename(env.NewBubblyContext(), filename, &fileParser))
+	var echoJSON []byte
+	for _, resBlock := range fileParser.ResourceBlocks {
+		if resBlock.Kind() == echoResourceKind {
+			b, err := json.Marshal(resBlock)
+			require.NoError(t, err)
+			echoJSON = b
+		}
+	}
+	require.NotNil(t, echoJSON)
+
+	tcs := []struct {
+		desc     string
+		set      []string
+		expected echoSpec
+		wantErr  bool
+	}{
+		{
+			desc:     "input values of the run",
+			expected: echoSpec{Message: "hello from github.com/valocode/bubbly@main", Retries: 1},
+		},
+		{
+			desc:     "override input values",
+			set:      []string{"message=hi", "retries=3", "repo.branch=dev"},
+			expected: echoSpec{Message: "hi from github.com/valocode/bubbly@dev", Retries: 3},
+		},
+		{
+			desc:    "invalid value",
+			set:     []string{"message"},
+			wantErr: true,
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.desc, func(t *testing.T) {
+			defer gock.Off()
+			echoed = nil
+			bCtx := env.NewBubblyContext()
+			bCtx.CLIConfig.Color = false
+
+			if !tc.wantErr {
+				gock.New(bCtx.ClientConfig.BubblyAddr).
+					Get("/api/v1/version").
+					Reply(http.StatusOK).
+					JSON(map[string]string{"version": env.Version})
+				gock.New(bCtx.ClientConfig.BubblyAddr).
+					Post("/api/v1/resource").
+					Times(2).
+					Reply(http.StatusOK)
+				gock.New(bCtx.ClientConfig.BubblyAddr).
+					Get("/api/v1/resource/echo/greeting").
+					Reply(http.StatusOK).
+					JSON(echoJSON)
+				// The runs of the echo and run resources are logged as events
+				gock.New(bCtx.ClientConfig.BubblyAddr).
+					Post("/api/v1/upload").
+					Times(2).
+					Reply(http.StatusOK)
+			}
+
+			cmd, _ := NewCmdApply(bCtx)
+			args := []string{"-f", filename}
+			for _, set := range tc.set {
+				args = append(args, "--set", set)
+			}
+			cmd.SetArgs(args)
+			cmd.SilenceUsage = true
+			cmd.SilenceErrors = true
+
+			err := cmd.Execute()
+			if tc.wantErr {
+				require.Error(t, err)
+				assert.Empty(t, echoed)
+				return
+			}
+			require.NoError(t, err)
+			assert.True(t, gock.IsDone())
+			require.Len(t, echoed, 1)
+			assert.Equal(t, tc.expected.Message, echoed[0].Message)
+			assert.Equal(t, tc.expected.Retries, echoed[0].Retries)
+		})
+	}
+}
+
+func TestApplyNamespace(t *testing.T) {
+	const filename = "./testdata/set.bubbly"
+	var fileParser bubbly.BubblyFileParser
+	require.NoError(t, parser.ParseFilename(env.NewBubblyContext(), filename, &fileParser))
+	var echoJSON []byte
+	for _, resBlock := range fileParser.ResourceBlocks {
+		if resBlock.Kind() == echoResourceKind {
+			resBlock.SetNamespace("team-a")
+			b, err := json.Marshal(resBlock)
+			require.NoError(t, err)
+			echoJSON = b
+		}
+	}
+	require.NotNil(t, echoJSON)
+
+	defer gock.Off()
+	echoed = nil
+	bCtx := env.NewBubblyContext()
+	bCtx.CLIConfig.Color = false
+
+	// namespaces are the namespaces of the posted resources
+	var namespaces []string
+	gock.New(bCtx.ClientConfig.BubblyAddr).
+		Get("/api/v1/version").
+		Reply(http.StatusOK).
+		JSON(map[string]string{"version": env.Version})
+	gock.New(bCtx.ClientConfig.BubblyAddr).
+		Post("/api/v1/resource").
+		AddMatcher(func(req *http.Request, _ *gock.Request) (bool, error) {
+			var res core.ResourceBlock
+			if err := json.NewDecoder(req.Body).Decode(&res); err != nil {
+				return false, err
+			}
+			namespaces = append(namespaces, res.Namespace())
+			return true, nil
+		}).
+		Times(2).
+		Reply(http.StatusOK)
+	// The run references the echo resource by kind/name, which is in the
+	// namespace of the run
+	gock.New(bCtx.ClientConfig.BubblyAddr).
+		Get("/api/v1/resource/team-a/echo/greeting").
+		Reply(http.StatusOK).
+		JSON(echoJSON)
+	gock.New(bCtx.ClientConfig.BubblyAddr).
+		Post("/api/v1/upload").
+		Times(2).
+		Reply(http.StatusOK)
+
+	cmd, _ := NewCmdApply(bCtx)
+	cmd.SetArgs([]string{"-f", filename, "--namespace", "team-a"})
+	cmd.SilenceUsage = true
+	cmd.SilenceErrors = true
+
+	require.NoError(t, cmd.Execute())
+	assert.True(t, gock.IsDone())
+	assert.Equal(t, []string{"team-a", "team-a"}, namespaces)
+	require.Len(t, echoed, 1)
+}
+
+func TestApplyNamespaceInvalid(t *testing.T) {
+	cmd, _ := NewCmdApply(env.NewBubblyContext())
+	cmd.SetArgs([]string{"-f", "./testdata/extract.bubbly", "--namespace", "team/a"})
+	cmd.SilenceUsage = true
+	cmd.SilenceErrors = true
+	assert.Error(t, cmd.Execute())
+}
+
+// TestApplyDiagnostics checks that applying resources that fail to decode
+// writes the diagnostics with the source where they occurred, without color
+// when not writing to a terminal
+func TestApplyDiagnostics(t *testing.T) {
+	bCtx := env.NewBubblyContext()
+	bCtx.CLIConfig.Color = true
+
+	cmd, _ := NewCmdApply(bCtx)
+	var errOut bytes.Buffer
+	cmd.SetErr(&errOut)
+	cmd.SetArgs([]string{"-f", "./testdata/broken.bubbly"})
+	cmd.SilenceUsage = true
+	cmd.SilenceErrors = true
+
+	err := cmd.Execute()
+	require.Error(t, err)
+	var parserErr *parser.ParserError
+	assert.True(t, errors.As(err, &parserErr))
+
+	assert.Equal(t, `Error: Unsupported argument
+
+  on ./testdata/broken.bubbly line 3, in resource "extract" "broken":
+   3:     unknown_attribute = "value"
+
+An argument named "unknown_attribute" is not expected here.
+
+`, errOut.String())
+}
@@ -0,0 +1,130 @@
+package apply
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// The default intervals for watching the bubbly files
+const (
+	// defaultWatchInterval is how often the files are checked for changes
+	defaultWatchInterval = 500 * time.Millisecond
+	// defaultWatchDebounce is how long the files must be unchanged before
+	// they are applied again, so that rapid edits are applied once
+	defaultWatchDebounce = 300 * time.Millisecond
+)
+
+// fileState is the state of a watched file, which changes when the file is
+// written to
+type fileState struct {
+	modTime time.Time
+	size    int64
+}
+
+// watcher watches the bubbly files of a file or directory for changes, by
+// checking them at an interval. The files of a directory are its .bubbly
+// files, like those that are applied, so files being added and removed are
+// also changes
+type watcher struct {
+	path     string
+	interval time.Duration
+	debounce time.Duration
+
+	// files is the state of the files as last seen
+	files map[string]fileState
+}
+
+// newWatcher creates a watcher for the path, with the current state of its
+// files, so that changes made from now on are seen
+func newWatcher(path string, interval time.Duration, debounce time.Duration) (*watcher, error) {
+	w := &watcher{
+		path:     path,
+		interval: interval,
+		debounce: debounce,
+	}
+	files, err := w.snapshot()
+	if err != nil {
+		return nil, err
+	}
+	w.files = files
+	return w, nil
+}
+
+// Watch calls onChange whenever the files change, until the context is done.
+// Changes are debounced: onChange is called once the files have not changed
+// for the debounce duration. Errors reading the files, e.g. while an editor
+// replaces a file, are retried at the next interval
+func (w *watcher) Watch(ctx context.Context, onChange func()) error {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	// changedAt is when the files last changed, if they have changed since
+	// onChange was last called
+	var changedAt time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+		files, err := w.snapshot()
+		if err != nil {
+			continue
+		}
+		if !sameFiles(w.files, files) {
+			w.files = files
+			changedAt = time.Now()
+			continue
+		}
+		if !changedAt.IsZero() && time.Since(changedAt) >= w.debounce {
+			changedAt = time.Time{}
+			onChange()
+		}
+	}
+}
+
+// snapshot returns the current state of the watched files
+func (w *watcher) snapshot() (map[string]fileState, error) {
+	fi, err := os.Stat(w.path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot watch %s: %w", w.path, err)
+	}
+	paths := []string{w.path}
+	if fi.IsDir() {
+		entries, err := os.ReadDir(w.path)
+		if err != nil {
+			return nil, fmt.Errorf("cannot watch directory %s: %w", w.path, err)
+		}
+		paths = nil
+		for _, e := range entries {
+			if filepath.Ext(e.Name()) == ".bubbly" && !e.IsDir() {
+				paths = append(paths, filepath.Join(w.path, e.Name()))
+			}
+		}
+	}
+	files := make(map[string]fileState, len(paths))
+	for _, path := range paths {
+		fi, err := os.Stat(path)
+		if err != nil {
+			return nil, fmt.Errorf("cannot watch %s: %w", path, err)
+		}
+		files[path] = fileState{modTime: fi.ModTime(), size: fi.Size()}
+	}
+	return files, nil
+}
+
+func sameFiles(f1, f2 map[string]fileState) bool {
+	if len(f1) != len(f2) {
+		return false
+	}
+	for path, s1 := range f1 {
+		s2, ok := f2[path]
+		if !ok || !s1.modTime.Equal(s2.modTime) || s1.size != s2.size {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,123 @@
+package apply
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/h2non/gock.v1"
+
+	"github.com/valocode/bubbly/env"
+)
+
+func TestWatcher(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "main.bubbly")
+	require.NoError(t, ioutil.WriteFile(file, []byte("a"), 0644))
+
+	// The debounce is much longer than the time between the rapid edits,
+	// so that the edits are debounced even on a busy machine
+	w, err := newWatcher(dir, 10*time.Millisecond, 300*time.Millisecond)
+	require.NoError(t, err)
+
+	var changes int32
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- w.Watch(ctx, func() { atomic.AddInt32(&changes, 1) })
+	}()
+
+	// Rapid edits are debounced into a single change
+	for i := 0; i < 5; i++ {
+		require.NoError(t, ioutil.WriteFile(file, []byte(strings.Repeat("a", i+2)), 0644))
+		time.Sleep(15 * time.Millisecond)
+	}
+	assert.Eventually(t, func() bool { return atomic.LoadInt32(&changes) == 1 }, 5*time.Second, 10*time.Millisecond)
+	time.Sleep(500 * time.Millisecond)
+	assert.EqualValues(t, 1, atomic.LoadInt32(&changes))
+
+	// Files that are not bubbly files are not watched
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "README.md"), []byte("a"), 0644))
+	time.Sleep(500 * time.Millisecond)
+	assert.EqualValues(t, 1, atomic.LoadInt32(&changes))
+
+	// Adding and removing a bubbly file are changes
+	other := filepath.Join(dir, "other.bubbly")
+	require.NoError(t, ioutil.WriteFile(other, []byte("a"), 0644))
+	assert.Eventually(t, func() bool { return atomic.LoadInt32(&changes) == 2 }, 5*time.Second, 10*time.Millisecond)
+	require.NoError(t, os.Remove(other))
+	assert.Eventually(t, func() bool { return atomic.LoadInt32(&changes) == 3 }, 5*time.Second, 10*time.Millisecond)
+
+	cancel()
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("watcher did not stop when the context was cancelled")
+	}
+}
+
+// TestApplyWatch tests that the resources are applied again when the watched
+// file changes
+func TestApplyWatch(t *testing.T) {
+	defer gock.Off()
+	version := env.Version
+	defer func() { env.Version = version }()
+	env.Version = "v1.2.3"
+	bCtx := env.NewBubblyContext()
+	bCtx.CLIConfig.Color = false
+
+	src, err := ioutil.ReadFile("./testdata/extract.bubbly")
+	require.NoError(t, err)
+	file := filepath.Join(t.TempDir(), "extract.bubbly")
+	require.NoError(t, ioutil.WriteFile(file, src, 0644))
+
+	// The resource is applied once when the watching starts, and once more
+	// after the file changes
+	for i := 0; i < 2; i++ {
+		gock.New(bCtx.ClientConfig.BubblyAddr).
+			Get("/api/v1/version").
+			Reply(http.StatusOK).
+			JSON(map[string]string{"version": env.Version})
+		gock.New(bCtx.ClientConfig.BubblyAddr).
+			Post("/api/v1/resource").
+			Reply(http.StatusOK).
+			JSON(map[string]string{"status": "uploaded"})
+	}
+
+	cmd, o := NewCmdApply(bCtx)
+	o.watchInterval = 10 * time.Millisecond
+	o.watchDebounce = 50 * time.Millisecond
+	cmd.SetArgs([]string{"-f", file, "--watch"})
+	cmd.SilenceUsage = true
+	cmd.SilenceErrors = true
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error)
+	go func() {
+		done <- cmd.ExecuteContext(ctx)
+	}()
+
+	// Wait for the first apply before changing the file, so that the change
+	// is not part of the initial state of the watcher
+	require.Eventually(t, func() bool { return len(gock.Pending()) == 2 }, 5*time.Second, 10*time.Millisecond)
+	require.NoError(t, ioutil.WriteFile(file, append(src, '\n'), 0644))
+	assert.Eventually(t, gock.IsDone, 5*time.Second, 10*time.Millisecond)
+
+	cancel()
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("apply did not stop watching when the context was cancelled")
+	}
+}
@@ -0,0 +1,173 @@
+package delete
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+
+	"github.com/valocode/bubbly/bubbly"
+	cmdutil "github.com/valocode/bubbly/cmd/util"
+	"github.com/valocode/bubbly/env"
+	"github.com/valocode/bubbly/parser"
+)
+
+var (
+	_          cmdutil.Options = (*DeleteOptions)(nil)
+	deleteLong                 = cmdutil.LongDesc(`
+		Delete bubbly resources from a bubbly API server.
+
+		The resources declared in the given file or directory are deleted,
+		which is the inverse of bubbly apply. Resources which do not exist are
+		skipped with a warning, unless --strict is given
+	`)
+
+	deleteExample = cmdutil.Examples(`
+		# Delete the bubbly resources in the file ./main.bubbly
+		bubbly delete -f ./main.bubbly
+
+		# Delete the bubbly resources in the directory ./resources and its
+		# subdirectories
+		bubbly delete -f ./resources --recursive
+
+		# Delete the bubbly resources in the file ./main.bubbly from the
+		# namespace team-a
+		bubbly delete -f ./main.bubbly --namespace team-a
+		`)
+)
+
+// DeleteOptions holds everything necessary to run the command.
+// Flag values received to the command are loaded into this struct
+type DeleteOptions struct {
+	cmdutil.Options
+	bCtx    *env.BubblyContext
+	Command string
+	Args    []string
+
+	// flags
+	filename  string
+	recursive bool
+	strict    bool
+}
+
+// NewCmdDelete creates a new cobra.Command representing "bubbly delete"
+func NewCmdDelete(bCtx *env.BubblyContext) (*cobra.Command, *DeleteOptions) {
+	o := &DeleteOptions{
+		Command: "delete",
+		bCtx:    bCtx,
+	}
+
+	// cmd represents the delete command
+	cmd := &cobra.Command{
+		Use:     "delete (-f (FILENAME | DIRECTORY)) [flags]",
+		Short:   "Delete one or more bubbly resources from a bubbly agent",
+		Long:    deleteLong + "\n\n",
+		Example: deleteExample,
+		RunE: func(cmd *cobra.Command, args []string) error {
+
+			o.Args = args
+
+			validationError := o.Validate(cmd)
+
+			if validationError != nil {
+				return validationError
+			}
+
+			resolveError := o.Resolve()
+
+			if resolveError != nil {
+				return resolveError
+			}
+			runError := o.Run()
+
+			if runError != nil {
+				return runError
+			}
+
+			o.Print()
+			return nil
+		},
+	}
+
+	f := cmd.Flags()
+
+	f.StringVarP(&o.filename,
+		"filename",
+		"f",
+		"",
+		"filename or directory that contains the bubbly resources to delete")
+	f.BoolVarP(&o.recursive,
+		"recursive",
+		"R",
+		false,
+		"also delete the bubbly resources in subdirectories of the directory given by --filename")
+	f.BoolVar(&o.strict,
+		"strict",
+		false,
+		"fail if any of the bubbly resources to delete does not exist")
+
+	cmdutil.AddNamespaceFlag(cmd, bCtx.ClientConfig,
+		"namespace of the bubbly resources that do not give one in their metadata")
+
+	cmd.MarkFlagRequired("filename")
+
+	return cmd, o
+}
+
+// Validate checks the DeleteOptions to see if there is sufficient information
+// to run the command.
+func (o *DeleteOptions) Validate(cmd *cobra.Command) error {
+	if len(o.Args) != 0 {
+		return cmdutil.UsageErrorf(cmd, "Unexpected args: %v", o.Args)
+	}
+
+	if err := cmdutil.ValidateNamespace(cmd, o.bCtx.ClientConfig); err != nil {
+		return err
+	}
+
+	// check the file/directory is valid and fail fast if not
+	if _, err := os.Stat(o.filename); err != nil {
+		return fmt.Errorf(
+			`failed to validate file/path to bubbly resources "%s": %w`,
+			filepath.FromSlash(o.filename),
+			err)
+	}
+
+	return nil
+}
+
+// Resolve resolves various DeleteOptions attributes from the provided
+// arguments to cmd
+func (o *DeleteOptions) Resolve() error {
+	return nil
+}
+
+// Run runs the delete command over the validated DeleteOptions configuration
+func (o *DeleteOptions) Run() error {
+	if err := bubbly.Delete(o.bCtx, o.filename, o.recursive, o.strict); err != nil {
+		// If the error came from parsing/decoding the bubbly files, show the
+		// user the source where the error occurred
+		var parserErr *parser.ParserError
+		if errors.As(err, &parserErr) {
+			parserErr.WriteDiagnostics(os.Stderr, o.bCtx.CLIConfig.Color && cmdutil.IsTerminal(os.Stderr))
+		}
+		return fmt.Errorf("failed to delete configuration: %w", err)
+	}
+	return nil
+}
+
+// Print prints the successful outcome of deleting the resource(s)
+func (o *DeleteOptions) Print() {
+	successString := fmt.Sprintf(
+		`resource(s) at path/directory "%s" deleted successfully`,
+		filepath.FromSlash(o.filename))
+
+	if o.bCtx.CLIConfig.Color {
+		color.Green(successString)
+	} else {
+		fmt.Println(successString)
+	}
+}
@@ -0,0 +1,114 @@
+package delete
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/h2non/gock.v1"
+
+	"github.com/valocode/bubbly/env"
+)
+
+func TestDelete(t *testing.T) {
+	type deleteReq struct {
+		path   string
+		status int
+	}
+	tcs := []struct {
+		desc     string
+		args     []string
+		requests []deleteReq
+		err      bool
+	}{
+		{
+			desc: "file",
+			args: []string{"-f", "./testdata/resources/extract.bubbly"},
+			requests: []deleteReq{
+				{path: "/api/v1/resource/extract/junit", status: http.StatusOK},
+			},
+		},
+		{
+			desc: "directory",
+			args: []string{"-f", "./testdata/resources"},
+			requests: []deleteReq{
+				{path: "/api/v1/resource/extract/junit", status: http.StatusOK},
+			},
+		},
+		{
+			desc: "directory recursive",
+			args: []string{"-f", "./testdata/resources", "--recursive"},
+			requests: []deleteReq{
+				{path: "/api/v1/resource/extract/junit", status: http.StatusOK},
+				{path: "/api/v1/resource/transform/junit", status: http.StatusOK},
+			},
+		},
+		{
+			desc: "missing resource",
+			args: []string{"-f", "./testdata/resources", "--recursive"},
+			requests: []deleteReq{
+				{path: "/api/v1/resource/extract/junit", status: http.StatusNotFound},
+				{path: "/api/v1/resource/transform/junit", status: http.StatusOK},
+			},
+		},
+		{
+			desc: "missing resource strict",
+			args: []string{"-f", "./testdata/resources", "--recursive", "--strict"},
+			requests: []deleteReq{
+				{path: "/api/v1/resource/extract/junit", status: http.StatusNotFound},
+				{path: "/api/v1/resource/transform/junit", status: http.StatusOK},
+			},
+			err: true,
+		},
+		{
+			desc: "namespace",
+			args: []string{"-f", "./testdata/resources/extract.bubbly", "--namespace", "team-a"},
+			requests: []deleteReq{
+				{path: "/api/v1/resource/team-a/extract/junit", status: http.StatusOK},
+			},
+		},
+		{
+			desc: "invalid namespace",
+			args: []string{"-f", "./testdata/resources/extract.bubbly", "--namespace", "team/a"},
+			err:  true,
+		},
+		{
+			desc: "server error",
+			args: []string{"-f", "./testdata/resources/extract.bubbly", "--strict"},
+			requests: []deleteReq{
+				{path: "/api/v1/resource/extract/junit", status: http.StatusBadRequest},
+			},
+			err: true,
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.desc, func(t *testing.T) {
+			defer gock.Off()
+			bCtx := env.NewBubblyContext()
+			bCtx.CLIConfig.Color = false
+
+			for _, req := range tc.requests {
+				gock.New(bCtx.ClientConfig.BubblyAddr).
+					Delete(req.path).
+					Reply(req.status).
+					JSON(map[string]interface{}{"error": map[string]string{"message": http.StatusText(req.status)}})
+			}
+
+			cmd, _ := NewCmdDelete(bCtx)
+			cmd.SetArgs(tc.args)
+			cmd.SilenceUsage = true
+			cmd.SilenceErrors = true
+
+			err := cmd.Execute()
+			if tc.err {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+			// Every resource should have been deleted, and no other requests
+			// made
+			assert.True(t, gock.IsDone())
+		})
+	}
+}
@@ -0,0 +1,22 @@
+package extract
+
+import (
+	"github.com/spf13/cobra"
+
+	inferFormatCmd "github.com/valocode/bubbly/cmd/extract/inferformat"
+	"github.com/valocode/bubbly/env"
+)
+
+// NewCmdExtract creates a new cobra.Command representing "bubbly extract"
+func NewCmdExtract(bCtx *env.BubblyContext) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "extract <command>",
+		Short: "Helpers for writing extract resources",
+		Long:  `Helpers for writing extract resources`,
+	}
+
+	inferFormatCmd, _ := inferFormatCmd.NewCmdInferFormat(bCtx)
+	cmd.AddCommand(inferFormatCmd)
+
+	return cmd
+}
@@ -0,0 +1,163 @@
+package inferformat
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/spf13/cobra"
+
+	v1 "github.com/valocode/bubbly/api/v1"
+	"github.com/valocode/bubbly/bubbly"
+	cmdutil "github.com/valocode/bubbly/cmd/util"
+	"github.com/valocode/bubbly/env"
+)
+
+var (
+	_               cmdutil.Options = (*InferFormatOptions)(nil)
+	inferFormatLong                 = cmdutil.LongDesc(`
+		Infer the format of a json extract source from a sample JSON file.
+
+		The inferred format is conservative: values that are always null, and
+		empty arrays, are given the type string, and the elements of an array
+		must all have the same type. Check the format before using it, as the
+		sample may not contain every value that the data can have
+		`)
+
+	inferFormatExample = cmdutil.Examples(`
+		# Print the format of the JSON file ./data.json
+		bubbly extract infer-format -f ./data.json
+
+		# Print an extract resource named "data" for the JSON file ./data.json
+		bubbly extract infer-format -f ./data.json --name data
+		`)
+)
+
+// InferFormatOptions holds everything necessary to run the command.
+// Flag values received to the command are loaded into this struct
+type InferFormatOptions struct {
+	cmdutil.Options
+	bCtx    *env.BubblyContext
+	Command string
+	Args    []string
+
+	// out is where the format is printed
+	out io.Writer
+
+	// flags
+	filename string
+	name     string
+
+	// format is the inferred format, as an HCL type expression
+	format string
+}
+
+// NewCmdInferFormat creates a new cobra.Command representing
+// "bubbly extract infer-format"
+func NewCmdInferFormat(bCtx *env.BubblyContext) (*cobra.Command, *InferFormatOptions) {
+	o := &InferFormatOptions{
+		Command: "infer-format",
+		bCtx:    bCtx,
+		out:     os.Stdout,
+	}
+
+	// cmd represents the infer-format command
+	cmd := &cobra.Command{
+		Use:     "infer-format (-f FILENAME) [--name NAME]",
+		Short:   "Infer the format of a json extract source from a sample file",
+		Long:    inferFormatLong + "\n\n",
+		Example: inferFormatExample,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			o.Args = args
+			o.out = cmd.OutOrStdout()
+
+			validationError := o.Validate(cmd)
+
+			if validationError != nil {
+				return validationError
+			}
+
+			resolveError := o.Resolve()
+
+			if resolveError != nil {
+				return resolveError
+			}
+
+			runError := o.Run()
+
+			if runError != nil {
+				return runError
+			}
+
+			o.Print()
+
+			return nil
+		},
+	}
+
+	f := cmd.Flags()
+
+	f.StringVarP(&o.filename,
+		"filename",
+		"f",
+		"",
+		"sample JSON file to infer the format of")
+	f.StringVar(&o.name,
+		"name",
+		"",
+		"print an extract resource with this name, instead of only the format")
+
+	cmd.MarkFlagRequired("filename")
+
+	return cmd, o
+}
+
+// Validate checks the InferFormatOptions to see if there is sufficient
+// information to run the command.
+func (o *InferFormatOptions) Validate(cmd *cobra.Command) error {
+	if len(o.Args) != 0 {
+		return cmdutil.UsageErrorf(cmd, "Unexpected args: %v", o.Args)
+	}
+	if o.name != "" && !hclsyntax.ValidIdentifier(o.name) {
+		return cmdutil.UsageErrorf(cmd, "Invalid resource name: %s", o.name)
+	}
+	return nil
+}
+
+// Resolve resolves various InferFormatOptions attributes from the provided
+// arguments to cmd
+func (o *InferFormatOptions) Resolve() error {
+	return nil
+}
+
+// Run runs the infer-format command over the validated InferFormatOptions
+// configuration
+func (o *InferFormatOptions) Run() error {
+	ty, err := bubbly.InferFormat(o.bCtx, o.filename)
+	if err != nil {
+		return err
+	}
+	o.format = v1.FormatString(ty)
+	return nil
+}
+
+// Print prints the inferred format, or the extract resource with the format
+func (o *InferFormatOptions) Print() {
+	if o.name == "" {
+		fmt.Fprintf(o.out, "format = %s\n", o.format)
+		return
+	}
+	fmt.Fprintf(o.out, `resource "extract" "%s" {
+	spec {
+		type = "json"
+		source {
+			file = %q
+			format = %s
+		}
+	}
+}
+`, o.name, filepath.ToSlash(o.filename), strings.ReplaceAll(o.format, "\n", "\n\t\t\t"))
+}
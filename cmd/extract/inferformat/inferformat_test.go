@@ -0,0 +1,84 @@
+package inferformat
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/valocode/bubbly/bubbly"
+	"github.com/valocode/bubbly/env"
+)
+
+func TestInferFormat(t *testing.T) {
+	const format = `format = object({
+	issues: list(object({
+		assignee: object({
+			login: string,
+		}),
+		id: number,
+		labels: list(string),
+		title: string,
+	})),
+	repository: string,
+})
+`
+	tcs := []struct {
+		desc     string
+		args     []string
+		expected string
+		wantErr  bool
+	}{
+		{
+			desc:     "format",
+			args:     []string{"-f", "./testdata/data.json"},
+			expected: format,
+		},
+		{
+			desc:    "missing file",
+			args:    []string{"-f", "./testdata/missing.json"},
+			wantErr: true,
+		},
+		{
+			desc:    "invalid name",
+			args:    []string{"-f", "./testdata/data.json", "--name", "not a name"},
+			wantErr: true,
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.desc, func(t *testing.T) {
+			cmd, _ := NewCmdInferFormat(env.NewBubblyContext())
+			var out bytes.Buffer
+			cmd.SetOut(&out)
+			cmd.SetArgs(tc.args)
+			cmd.SilenceUsage = true
+			cmd.SilenceErrors = true
+
+			err := cmd.Execute()
+			if tc.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, out.String())
+		})
+	}
+}
+
+// TestInferFormatResource checks that the printed extract resource is valid
+func TestInferFormatResource(t *testing.T) {
+	cmd, _ := NewCmdInferFormat(env.NewBubblyContext())
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{"-f", "./testdata/data.json", "--name", "data"})
+	require.NoError(t, cmd.Execute())
+	assert.Contains(t, out.String(), `resource "extract" "data" {`)
+	assert.Contains(t, out.String(), `file = "./testdata/data.json"`)
+
+	file := filepath.Join(t.TempDir(), "data.bubbly")
+	require.NoError(t, os.WriteFile(file, out.Bytes(), 0644))
+	assert.NoError(t, bubbly.Validate(env.NewBubblyContext(), file))
+}
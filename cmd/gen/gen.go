@@ -0,0 +1,22 @@
+package gen
+
+import (
+	"github.com/spf13/cobra"
+
+	genGoCmd "github.com/valocode/bubbly/cmd/gen/golang"
+	"github.com/valocode/bubbly/env"
+)
+
+// NewCmdGen creates a new cobra.Command representing "bubbly gen"
+func NewCmdGen(bCtx *env.BubblyContext) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "gen <command>",
+		Short: "Generate typed clients for your bubbly schema",
+		Long:  `Generate typed clients for your bubbly schema`,
+	}
+
+	genGoCmd, _ := genGoCmd.NewCmdGo(bCtx)
+	cmd.AddCommand(genGoCmd)
+
+	return cmd
+}
@@ -0,0 +1,175 @@
+package golang
+
+import (
+	"fmt"
+	"go/token"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/valocode/bubbly/bubbly"
+	cmdutil "github.com/valocode/bubbly/cmd/util"
+	"github.com/valocode/bubbly/codegen"
+	"github.com/valocode/bubbly/env"
+)
+
+var (
+	_      cmdutil.Options = (*GoOptions)(nil)
+	goLong                 = cmdutil.LongDesc(`
+		Generate a typed Go client for the tables of the bubbly schema, from
+		its GraphQL SDL. For each table, the client has a struct for its rows
+		and a query builder with typed filters, e.g.
+
+		    client.TestRuns().Where(TestRunName.Eq("unit")).First(5).Do(ctx)
+
+		The SDL is read from the file given with --schema, which can be
+		exported with "bubbly schema export", or else exported from the
+		bubbly API server. The generated code only depends on the Go standard
+		library
+		`)
+
+	goExample = cmdutil.Examples(`
+		# Generate a Go client for the schema of the bubbly API server, and
+		# print it
+		bubbly gen go
+
+		# Generate a Go client in the package client for the exported schema
+		# in ./schema.graphql, and write it to a file
+		bubbly gen go --schema ./schema.graphql --package client -o ./client/bubbly_gen.go
+		`)
+)
+
+// GoOptions holds everything necessary to run the command.
+// Flag values received to the command are loaded into this struct
+type GoOptions struct {
+	cmdutil.Options
+	bCtx    *env.BubblyContext
+	Command string
+	Args    []string
+
+	// flags
+	schema string
+	pkg    string
+	output string
+
+	// src is the generated Go source
+	src []byte
+}
+
+// NewCmdGo creates a new cobra.Command representing "gen go"
+func NewCmdGo(bCtx *env.BubblyContext) (*cobra.Command, *GoOptions) {
+	o := &GoOptions{
+		Command: "go",
+		bCtx:    bCtx,
+	}
+
+	// cmd represents the go command
+	cmd := &cobra.Command{
+		Use:     "go [--schema FILENAME] [--package NAME] [-o FILENAME]",
+		Short:   "generate a typed Go client for the bubbly schema",
+		Long:    goLong + "\n\n",
+		Example: goExample,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			o.Args = args
+
+			validationError := o.Validate(cmd)
+
+			if validationError != nil {
+				return validationError
+			}
+
+			resolveError := o.Resolve()
+
+			if resolveError != nil {
+				return resolveError
+			}
+
+			runError := o.Run()
+
+			if runError != nil {
+				return runError
+			}
+
+			o.Print()
+
+			return nil
+		},
+	}
+
+	f := cmd.Flags()
+
+	f.StringVar(&o.schema,
+		"schema",
+		"",
+		"filename of the GraphQL SDL of the schema, instead of exporting it from the bubbly API server")
+
+	f.StringVar(&o.pkg,
+		"package",
+		"bubbly",
+		"name of the Go package of the generated client")
+
+	f.StringVarP(&o.output,
+		"output",
+		"o",
+		"",
+		"filename to write the generated client to, instead of stdout")
+
+	return cmd, o
+}
+
+// Validate checks the GoOptions to see if there is sufficient information run the command.
+func (o *GoOptions) Validate(cmd *cobra.Command) error {
+	if len(o.Args) != 0 {
+		return cmdutil.UsageErrorf(cmd, "Unexpected args: %v", o.Args)
+	}
+	if !token.IsIdentifier(o.pkg) {
+		return cmdutil.UsageErrorf(cmd, "Invalid Go package name: %s", o.pkg)
+	}
+	return nil
+}
+
+// Resolve resolves various GoOptions attributes from the provided arguments to cmd
+func (o *GoOptions) Resolve() error {
+	return nil
+}
+
+// Run runs the go command over the validated GoOptions configuration
+func (o *GoOptions) Run() error {
+	var (
+		sdl []byte
+		err error
+	)
+	if o.schema != "" {
+		sdl, err = os.ReadFile(o.schema)
+		if err != nil {
+			return fmt.Errorf(`failed to read schema "%s": %w`, o.schema, err)
+		}
+	} else {
+		sdl, err = bubbly.ExportSchema(o.bCtx)
+		if err != nil {
+			return fmt.Errorf("failed to export schema: %w", err)
+		}
+	}
+
+	src, err := codegen.GenerateGo(sdl, o.pkg)
+	if err != nil {
+		return fmt.Errorf("failed to generate Go client: %w", err)
+	}
+	o.src = src
+
+	if o.output != "" {
+		if err := os.WriteFile(o.output, src, 0644); err != nil {
+			return fmt.Errorf(`failed to write Go client to "%s": %w`, o.output, err)
+		}
+	}
+	return nil
+}
+
+// Print prints the generated client, or where it was written to
+func (o *GoOptions) Print() {
+	if o.output != "" {
+		fmt.Printf("Go client written to \"%s\"\n", o.output)
+		return
+	}
+	fmt.Print(string(o.src))
+}
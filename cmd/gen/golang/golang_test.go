@@ -0,0 +1,94 @@
+package golang
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/h2non/gock.v1"
+
+	"github.com/valocode/bubbly/env"
+)
+
+// sampleSchema is the schema that the example client of the codegen package
+// is generated for
+const sampleSchema = "../../../codegen/testdata/schema.graphql"
+
+func TestGo(t *testing.T) {
+	sdl, err := os.ReadFile(sampleSchema)
+	require.NoError(t, err)
+	expected, err := os.ReadFile("../../../codegen/internal/example/client_gen.go")
+	require.NoError(t, err)
+
+	tcs := []struct {
+		desc string
+		args []string
+		// export is whether the schema is exported from the server
+		export bool
+	}{
+		{
+			desc: "schema file",
+			args: []string{"--schema", sampleSchema},
+		},
+		{
+			desc:   "exported schema",
+			export: true,
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.desc, func(t *testing.T) {
+			defer gock.Off()
+			bCtx := env.NewBubblyContext()
+			if tc.export {
+				gock.New(bCtx.ClientConfig.BubblyAddr).
+					Get("/graphql/schema.graphql").
+					Reply(http.StatusOK).
+					BodyString(string(sdl))
+			}
+
+			output := filepath.Join(t.TempDir(), "client_gen.go")
+			cmd, _ := NewCmdGo(bCtx)
+			cmd.SetArgs(append(tc.args, "--package", "example", "-o", output))
+			cmd.SilenceUsage = true
+			cmd.SilenceErrors = true
+			require.NoError(t, cmd.Execute())
+			assert.True(t, gock.IsDone())
+
+			src, err := os.ReadFile(output)
+			require.NoError(t, err)
+			assert.Equal(t, string(expected), string(src))
+		})
+	}
+}
+
+func TestGoInvalid(t *testing.T) {
+	tcs := []struct {
+		desc string
+		args []string
+	}{
+		{
+			desc: "invalid package name",
+			args: []string{"--schema", sampleSchema, "--package", "my-client"},
+		},
+		{
+			desc: "missing schema file",
+			args: []string{"--schema", "./testdata/missing.graphql"},
+		},
+		{
+			desc: "unexpected args",
+			args: []string{"--schema", sampleSchema, "extra"},
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.desc, func(t *testing.T) {
+			cmd, _ := NewCmdGo(env.NewBubblyContext())
+			cmd.SetArgs(tc.args)
+			cmd.SilenceUsage = true
+			cmd.SilenceErrors = true
+			assert.Error(t, cmd.Execute())
+		})
+	}
+}
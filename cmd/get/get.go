@@ -8,6 +8,7 @@ import (
 	"github.com/ryanuber/columnize"
 	"github.com/spf13/cobra"
 
+	"github.com/valocode/bubbly/api/core"
 	"github.com/valocode/bubbly/bubbly/builtin"
 	"github.com/valocode/bubbly/client"
 	"github.com/valocode/bubbly/cmd/util"
@@ -37,9 +38,15 @@ var (
 		# Display a specific bubbly resource and associated events
 		bubbly get default/extract/sonarqube --events
 
+		# Display all bubbly resources of kind extract in the namespace team-a
+		bubbly get extract --namespace team-a
+
 		`)
 )
 
+// maxResources is the maximum number of resources displayed by "bubbly get all"
+const maxResources = 20
+
 // GetOptions holds everything necessary to run the command.
 // Flag values received to the command are loaded into this struct
 type GetOptions struct {
@@ -99,12 +106,15 @@ func NewCmdGet(bCtx *env.BubblyContext) (*cobra.Command, *GetOptions) {
 		false,
 		"specify whether to display resource events")
 
+	cmdutil.AddNamespaceFlag(cmd, bCtx.ClientConfig,
+		"namespace of the bubbly resources to display, unless the ID gives one")
+
 	return cmd, o
 }
 
 // Validate checks the GetOptions to see if there is sufficient information run the command.
 func (o *GetOptions) Validate(cmd *cobra.Command) error {
-	return nil
+	return cmdutil.ValidateNamespace(cmd, o.bCtx.ClientConfig)
 }
 
 // Resolve resolves various GetOptions attributes from the provided arguments to cmd
@@ -128,17 +138,20 @@ func (o *GetOptions) Run() error {
 		return fmt.Errorf("error creating bubbly client: %w", err)
 	}
 
+	namespace := o.bCtx.ClientConfig.Namespace
 	switch {
 	case o.arg == "all":
-		resourceFilter = "last: 20"
 	case strings.ContainsAny(o.arg, "/"):
-		resourceFilter = "id: \"" + o.arg + "\""
+		// The ID can give the namespace of the resource
+		id := core.ResourceIDInNamespace(namespace, o.arg)
+		namespace = core.ResourceIDNamespace(id)
+		resourceFilter = "(id: \"" + id + "\")"
 	default:
-		resourceFilter = "kind: \"" + o.arg + "\""
+		resourceFilter = "(kind: \"" + o.arg + "\")"
 	}
 	resourceQuery = fmt.Sprintf(`
 	{
-		_resource(%s) {
+		_resource%s {
 			id
 			_event(last: 1) {
 				status
@@ -155,7 +168,16 @@ func (o *GetOptions) Run() error {
 	if err := client.QueryType(o.bCtx, nil, resourceQuery, &resWrap); err != nil {
 		return fmt.Errorf("error executing query: %w", err)
 	}
-	o.resources = resWrap.Resource
+	// The resources of all namespaces are in the same table, so keep only
+	// those in the namespace, and the last 20 of them if getting all
+	for _, r := range resWrap.Resource {
+		if core.ResourceIDNamespace(r.Id) == namespace {
+			o.resources = append(o.resources, r)
+		}
+	}
+	if o.arg == "all" && len(o.resources) > maxResources {
+		o.resources = o.resources[len(o.resources)-maxResources:]
+	}
 
 	// Handle events
 
@@ -180,7 +202,11 @@ func (o *GetOptions) Run() error {
 			return fmt.Errorf("error executing query: %w", err)
 		}
 	}
-	o.events = eventWrap.Event
+	for _, e := range eventWrap.Event {
+		if core.ResourceIDNamespace(e.Resource.Id) == namespace {
+			o.events = append(o.events, e)
+		}
+	}
 
 	// // If we should show events, make sure we get the events
 	// if o.events {
@@ -27,9 +27,18 @@ var (
 	cmdExample = util.Examples(`
 		# Perform a GraphQL query
 		bubbly query QUERY_STRING
+
+		# Perform a GraphQL query, and save its result as CSV
+		bubbly query '{ test_run { name repo { name } } }' --output csv > test_runs.csv
 		`)
 )
 
+// Formats in which the result of the query can be printed
+const (
+	jsonOutput = "json"
+	csvOutput  = "csv"
+)
+
 // options holds everything necessary to run the command.
 // Flag values received to the command are loaded into this struct
 type options struct {
@@ -40,6 +49,9 @@ type options struct {
 
 	query  string
 	result string
+
+	// flags
+	output string
 }
 
 // New creates a new cobra command
@@ -74,12 +86,24 @@ func New(bCtx *env.BubblyContext) *cobra.Command {
 		},
 	}
 
+	f := cmd.Flags()
+
+	f.StringVarP(&o.output,
+		"output",
+		"o",
+		jsonOutput,
+		"format to print the result of the query in. Options: json, csv")
+
 	return cmd
 }
 
 // validate checks the cmd options
 func (o *options) validate(cmd *cobra.Command) error {
-	// Nothing to do
+	switch o.output {
+	case jsonOutput, csvOutput:
+	default:
+		return cmdutil.UsageErrorf(cmd, "Unsupported output format: %s", o.output)
+	}
 	return nil
 }
 
@@ -90,12 +114,24 @@ func (o *options) resolve() error {
 
 // run runs the command over the validated options
 func (o *options) run() error {
-	client, err := client.New(o.bCtx)
+	bubblyClient, err := client.New(o.bCtx)
 	if err != nil {
 		return fmt.Errorf("error creating bubbly client: %w", err)
 	}
+	defer bubblyClient.Close()
+	if err := client.CheckVersion(o.bCtx, bubblyClient); err != nil {
+		return err
+	}
 	// TODO: add authentication
-	bytes, err := client.Query(o.bCtx, nil, o.query)
+	if o.output == csvOutput {
+		csv, err := bubblyClient.QueryCSV(o.bCtx, nil, o.query)
+		if err != nil {
+			return fmt.Errorf("error making GraphQL query: %w", err)
+		}
+		o.result = string(csv)
+		return nil
+	}
+	bytes, err := bubblyClient.Query(o.bCtx, nil, o.query)
 	if err != nil {
 		return fmt.Errorf("error making GraphQL query: %w", err)
 	}
@@ -121,8 +157,13 @@ func (o *options) run() error {
 	return nil
 }
 
-// Print prints the successful outcome of the cmd
+// Print prints the successful outcome of the cmd. The CSV output is printed
+// without anything else, so that it can be redirected to a file
 func (o *options) Print() {
+	if o.output == csvOutput {
+		fmt.Print(o.result)
+		return
+	}
 	fmt.Printf("\nResult:\n%s\n\n", o.result)
 	color.Green("Query successfully handled!")
 }
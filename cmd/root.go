@@ -5,12 +5,17 @@ import (
 
 	agentCmd "github.com/valocode/bubbly/cmd/agent"
 	applyCmd "github.com/valocode/bubbly/cmd/apply"
+	deleteCmd "github.com/valocode/bubbly/cmd/delete"
+	extractCmd "github.com/valocode/bubbly/cmd/extract"
+	genCmd "github.com/valocode/bubbly/cmd/gen"
 	getCmd "github.com/valocode/bubbly/cmd/get"
 	queryCmd "github.com/valocode/bubbly/cmd/query"
 	releaseCmd "github.com/valocode/bubbly/cmd/release"
 	schemaCmd "github.com/valocode/bubbly/cmd/schema"
+	serverCmd "github.com/valocode/bubbly/cmd/server"
 	"github.com/valocode/bubbly/cmd/topics"
 	"github.com/valocode/bubbly/cmd/util"
+	validateCmd "github.com/valocode/bubbly/cmd/validate"
 	"github.com/valocode/bubbly/config"
 	"github.com/valocode/bubbly/env"
 )
@@ -37,6 +42,11 @@ func NewCmdRoot(bCtx *env.BubblyContext) *cobra.Command {
 			// where flags for child commands are provided.
 			UnknownFlags: true,
 		},
+		// Configure the logger for all the commands, e.g. apply, the server
+		// and the agent with its worker, which log using the bCtx.Logger
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			return bCtx.UpdateLogFormat(bCtx.CLIConfig.LogFormat)
+		},
 	}
 
 	initFlags(bCtx, cmd)
@@ -50,9 +60,15 @@ func initCommands(bCtx *env.BubblyContext, cmd *cobra.Command) {
 	applyCmd, _ := applyCmd.NewCmdApply(bCtx)
 	cmd.AddCommand(applyCmd)
 
+	deleteCmd, _ := deleteCmd.NewCmdDelete(bCtx)
+	cmd.AddCommand(deleteCmd)
+
 	agentCmd, _ := agentCmd.NewCmdAgent(bCtx)
 	cmd.AddCommand(agentCmd)
 
+	serverCmd, _ := serverCmd.NewCmdServer(bCtx)
+	cmd.AddCommand(serverCmd)
+
 	// help topics
 	cmd.AddCommand(topics.NewHelpTopic("environment"))
 
@@ -62,6 +78,11 @@ func initCommands(bCtx *env.BubblyContext, cmd *cobra.Command) {
 	cmd.AddCommand(releaseCmd.New(bCtx))
 	cmd.AddCommand(queryCmd.New(bCtx))
 	cmd.AddCommand(schemaCmd.NewCmdSchema(bCtx))
+	cmd.AddCommand(extractCmd.NewCmdExtract(bCtx))
+	cmd.AddCommand(genCmd.NewCmdGen(bCtx))
+
+	validateCmd, _ := validateCmd.NewCmdValidate(bCtx)
+	cmd.AddCommand(validateCmd)
 }
 
 func initFlags(bCtx *env.BubblyContext, cmd *cobra.Command) {
@@ -72,6 +93,7 @@ func initFlags(bCtx *env.BubblyContext, cmd *cobra.Command) {
 	f.StringVar(&bCtx.ServerConfig.Port, "port", config.DefaultAPIServerPort, "bubbly API server port")
 
 	f.Bool("debug", config.DefaultDebugToggle, "specify whether to enable debug logging")
+	f.StringVar((*string)(&bCtx.CLIConfig.LogFormat), "log-format", string(bCtx.CLIConfig.LogFormat), "format of the logs, either console or json")
 
 	cmd.InitDefaultHelpFlag()
 }
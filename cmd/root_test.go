@@ -1,9 +1,11 @@
 package cmd
 
 import (
+	"os"
 	"testing"
 
 	"github.com/rs/zerolog"
+	"github.com/spf13/cobra"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
@@ -99,3 +101,60 @@ func TestBubblyContextLogLevel(t *testing.T) {
 		})
 	}
 }
+
+// TestLogFormat verifies that the --log-format flag configures the logger of
+// the bubbly context for the commands
+func TestLogFormat(t *testing.T) {
+	jsonLogger, err := env.NewLogger(config.JSONLogFormat, os.Stderr)
+	require.NoError(t, err)
+
+	tcs := []struct {
+		desc     string
+		args     []string
+		expected *zerolog.Logger
+		wantErr  bool
+	}{
+		{
+			desc:     "default",
+			args:     []string{"noop"},
+			expected: env.NewDefaultLogger(),
+		},
+		{
+			desc:     "json",
+			args:     []string{"noop", "--log-format", "json"},
+			expected: jsonLogger,
+		},
+		{
+			desc:     "console",
+			args:     []string{"noop", "--log-format", "console"},
+			expected: env.NewDefaultLogger(),
+		},
+		{
+			desc:    "invalid",
+			args:    []string{"noop", "--log-format", "yaml"},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.desc, func(t *testing.T) {
+			bCtx := env.NewBubblyContext()
+			rootCmd := NewCmdRoot(bCtx)
+			// The logger is configured before any command runs
+			rootCmd.AddCommand(&cobra.Command{
+				Use: "noop",
+				Run: func(cmd *cobra.Command, args []string) {},
+			})
+			rootCmd.SetArgs(tc.args)
+			rootCmd.SilenceUsage = true
+
+			err := rootCmd.Execute()
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, bCtx.Logger)
+		})
+	}
+}
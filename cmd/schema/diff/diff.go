@@ -0,0 +1,217 @@
+package diff
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+
+	"github.com/valocode/bubbly/bubbly"
+	cmdutil "github.com/valocode/bubbly/cmd/util"
+	"github.com/valocode/bubbly/env"
+	"github.com/valocode/bubbly/store"
+)
+
+var (
+	_        cmdutil.Options = (*DiffOptions)(nil)
+	diffLong                 = cmdutil.LongDesc(`
+		Show the changes that applying a bubbly schema would make to the schema
+		of the bubbly store, i.e. the tables, fields and joins that would be
+		added, removed or changed.
+
+		The command fails if any of the changes are destructive, i.e. could
+		lose data that is stored, such as removing a table or changing the
+		type of a field.
+
+		With --sql, the SQL statements that applying the schema would run on
+		the database are printed after the changes. They are not run.
+
+		    $ bubbly schema diff -f FILENAME
+
+		`)
+
+	diffExample = cmdutil.Examples(`
+		# Show the changes of a bubbly schema located in a specific file
+		bubbly schema diff -f ./schema.bubbly
+
+		# Show the changes of the bubbly schema files in a directory
+		bubbly schema diff -f ./schema
+
+		# Show the changes and the SQL statements that would be run
+		bubbly schema diff -f ./schema.bubbly --sql
+		`)
+)
+
+// DiffOptions holds everything necessary to run the command.
+// Flag values received to the command are loaded into this struct
+type DiffOptions struct {
+	cmdutil.Options
+	bCtx    *env.BubblyContext
+	Command string
+	Args    []string
+
+	// flags
+	filename string
+	sql      bool
+
+	// out is where the changes are printed
+	out     io.Writer
+	changes []store.SchemaChange
+	stmts   []string
+}
+
+// NewCmdDiff creates a new cobra.Command representing "schema diff"
+func NewCmdDiff(bCtx *env.BubblyContext) (*cobra.Command, *DiffOptions) {
+	o := &DiffOptions{
+		Command: "diff",
+		bCtx:    bCtx,
+		out:     os.Stdout,
+	}
+
+	// cmd represents the diff command
+	cmd := &cobra.Command{
+		Use:     "diff -f FILENAME",
+		Short:   "show the changes a bubbly schema would make",
+		Long:    diffLong + "\n\n",
+		Example: diffExample,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			o.Args = args
+			o.out = cmd.OutOrStdout()
+
+			validationError := o.Validate(cmd)
+
+			if validationError != nil {
+				return validationError
+			}
+
+			resolveError := o.Resolve()
+
+			if resolveError != nil {
+				return resolveError
+			}
+
+			runError := o.Run()
+
+			if runError != nil {
+				return runError
+			}
+
+			o.Print()
+
+			// Fail on destructive changes, e.g. to stop a pipeline from
+			// applying the schema
+			if n := o.destructive(); n > 0 {
+				return fmt.Errorf("schema has %d destructive change(s)", n)
+			}
+
+			return nil
+		},
+	}
+
+	f := cmd.Flags()
+
+	f.StringVarP(&o.filename,
+		"filename",
+		"f",
+		"",
+		"filename or directory that contains the .bubbly schema file(s)")
+
+	f.BoolVar(&o.sql,
+		"sql",
+		false,
+		"print the SQL statements that applying the schema would run")
+
+	cmd.MarkFlagRequired("filename")
+
+	return cmd, o
+}
+
+// Validate checks the DiffOptions to see if there is sufficient information run the command.
+func (o *DiffOptions) Validate(cmd *cobra.Command) error {
+	if len(o.Args) != 0 {
+		return cmdutil.UsageErrorf(cmd, "Unexpected args: %v", o.Args)
+	}
+	if _, err := os.Stat(o.filename); err != nil {
+		return fmt.Errorf(`cannot read from filename "%s"`, o.filename)
+	}
+	return nil
+}
+
+// Resolve resolves various DiffOptions attributes from the provided arguments to cmd
+func (o *DiffOptions) Resolve() error {
+	return nil
+}
+
+// Run runs the diff command over the validated DiffOptions configuration
+func (o *DiffOptions) Run() error {
+	changes, err := bubbly.DiffSchema(o.bCtx, o.filename)
+	if err != nil {
+		return fmt.Errorf("failed to diff schema: %w", err)
+	}
+	o.changes = changes
+	if o.sql {
+		stmts, err := bubbly.PreviewSchema(o.bCtx, o.filename)
+		if err != nil {
+			return fmt.Errorf("failed to preview schema: %w", err)
+		}
+		o.stmts = stmts
+	}
+	return nil
+}
+
+// Print prints the changes of the schema, one per line, followed by the SQL
+// statements if they were previewed
+func (o *DiffOptions) Print() {
+	defer o.printSQL()
+	if len(o.changes) == 0 {
+		fmt.Fprintln(o.out, "no changes")
+		return
+	}
+	for _, change := range o.changes {
+		line := change.String()
+		if change.Destructive {
+			line += " (destructive)"
+		}
+		if !o.bCtx.CLIConfig.Color {
+			fmt.Fprintln(o.out, line)
+			continue
+		}
+		switch {
+		case change.Destructive:
+			color.New(color.FgRed).Fprintln(o.out, line)
+		case change.Action == "create":
+			color.New(color.FgGreen).Fprintln(o.out, line)
+		default:
+			color.New(color.FgYellow).Fprintln(o.out, line)
+		}
+	}
+}
+
+// printSQL prints the SQL statements of the schema, separated from the changes
+// by an empty line
+func (o *DiffOptions) printSQL() {
+	if len(o.stmts) == 0 {
+		return
+	}
+	fmt.Fprintln(o.out)
+	for _, stmt := range o.stmts {
+		if !strings.HasSuffix(stmt, ";") {
+			stmt += ";"
+		}
+		fmt.Fprintln(o.out, stmt)
+	}
+}
+
+// destructive returns the number of destructive changes
+func (o *DiffOptions) destructive() int {
+	var n int
+	for _, change := range o.changes {
+		if change.Destructive {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,156 @@
+package diff
+
+import (
+	"bytes"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zclconf/go-cty/cty"
+	"gopkg.in/h2non/gock.v1"
+
+	"github.com/valocode/bubbly/api/core"
+	"github.com/valocode/bubbly/bubbly/builtin"
+	"github.com/valocode/bubbly/env"
+	"github.com/valocode/bubbly/store"
+)
+
+func TestDiff(t *testing.T) {
+	product := core.Table{
+		Name: "product",
+		Fields: []core.TableField{
+			{Name: "name", Type: cty.String, Unique: true},
+			{Name: "description", Type: cty.String},
+		},
+	}
+	testResult := core.Table{
+		Name: "test_result",
+		Fields: []core.TableField{
+			{Name: "name", Type: cty.String},
+			{Name: "duration", Type: cty.Number},
+		},
+		Joins: []core.TableJoin{{Table: "product"}},
+	}
+
+	tcs := []struct {
+		desc string
+		// current are the tables of the schema applied on the server
+		current  core.Tables
+		expected string
+		wantErr  bool
+	}{
+		{
+			desc:     "no changes",
+			current:  core.Tables{product, testResult},
+			expected: "no changes\n",
+		},
+		{
+			desc:    "additive",
+			current: core.Tables{{Name: "product", Fields: product.Fields[:1]}},
+			expected: "+ field product.description (string)\n" +
+				"+ table test_result\n",
+		},
+		{
+			desc: "destructive",
+			current: core.Tables{
+				product,
+				{
+					Name: "test_result",
+					Fields: []core.TableField{
+						{Name: "name", Type: cty.String},
+						{Name: "duration", Type: cty.String},
+						{Name: "output", Type: cty.String},
+					},
+					Joins: testResult.Joins,
+				},
+			},
+			expected: "~ field test_result.duration type: string -> number (destructive)\n" +
+				"- field test_result.output (string) (destructive)\n",
+			wantErr: true,
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.desc, func(t *testing.T) {
+			defer gock.Off()
+			bCtx := env.NewBubblyContext()
+			bCtx.CLIConfig.Color = false
+
+			// The server returns the builtin tables as well
+			current := make(map[string]core.Table)
+			for _, table := range store.FlattenTables(builtin.BuiltinTables, nil) {
+				current[table.Name] = table
+			}
+			for _, table := range tc.current {
+				current[table.Name] = table
+			}
+			gock.New(bCtx.ClientConfig.BubblyAddr).
+				Get("/api/v1/schema").
+				Reply(http.StatusOK).
+				JSON(current)
+
+			cmd, _ := NewCmdDiff(bCtx)
+			var out bytes.Buffer
+			cmd.SetOut(&out)
+			cmd.SetArgs([]string{"-f", "./testdata/schema.bubbly"})
+			cmd.SilenceUsage = true
+			cmd.SilenceErrors = true
+
+			err := cmd.Execute()
+			if tc.wantErr {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), "destructive")
+			} else {
+				require.NoError(t, err)
+			}
+			assert.Equal(t, tc.expected, out.String())
+			assert.True(t, gock.IsDone())
+		})
+	}
+}
+
+func TestDiffSQL(t *testing.T) {
+	defer gock.Off()
+	bCtx := env.NewBubblyContext()
+	bCtx.CLIConfig.Color = false
+
+	current := make(map[string]core.Table)
+	for _, table := range store.FlattenTables(builtin.BuiltinTables, nil) {
+		current[table.Name] = table
+	}
+	current["product"] = core.Table{
+		Name: "product",
+		Fields: []core.TableField{
+			{Name: "name", Type: cty.String, Unique: true},
+		},
+	}
+	gock.New(bCtx.ClientConfig.BubblyAddr).
+		Get("/api/v1/schema").
+		Reply(http.StatusOK).
+		JSON(current)
+	// The statements are printed as the server returns them, with a
+	// terminating semicolon
+	gock.New(bCtx.ClientConfig.BubblyAddr).
+		Post("/api/v1/schema/preview").
+		Reply(http.StatusOK).
+		JSON([]string{
+			`ALTER TABLE IF EXISTS "default"."product" ADD COLUMN IF NOT EXISTS description TEXT;`,
+			`CREATE TABLE IF NOT EXISTS "default"."test_result" (_id SERIAL PRIMARY KEY)`,
+		})
+
+	cmd, _ := NewCmdDiff(bCtx)
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{"-f", "./testdata/schema.bubbly", "--sql"})
+	cmd.SilenceUsage = true
+	cmd.SilenceErrors = true
+
+	require.NoError(t, cmd.Execute())
+	assert.Equal(t, "+ field product.description (string)\n"+
+		"+ table test_result\n"+
+		"\n"+
+		`ALTER TABLE IF EXISTS "default"."product" ADD COLUMN IF NOT EXISTS description TEXT;`+"\n"+
+		`CREATE TABLE IF NOT EXISTS "default"."test_result" (_id SERIAL PRIMARY KEY);`+"\n",
+		out.String())
+	assert.True(t, gock.IsDone())
+}
@@ -0,0 +1,135 @@
+package export
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/valocode/bubbly/bubbly"
+	cmdutil "github.com/valocode/bubbly/cmd/util"
+	"github.com/valocode/bubbly/env"
+)
+
+var (
+	_          cmdutil.Options = (*ExportOptions)(nil)
+	exportLong                 = cmdutil.LongDesc(`
+		Export the GraphQL schema of the bubbly store as GraphQL SDL, e.g. to
+		generate typed clients
+
+		    $ bubbly schema export
+
+		`)
+
+	exportExample = cmdutil.Examples(`
+		# Print the GraphQL schema
+		bubbly schema export
+
+		# Write the GraphQL schema to a file
+		bubbly schema export -o ./schema.graphql
+		`)
+)
+
+// ExportOptions holds everything necessary to run the command.
+// Flag values received to the command are loaded into this struct
+type ExportOptions struct {
+	cmdutil.Options
+	bCtx    *env.BubblyContext
+	Command string
+	Args    []string
+
+	// flags
+	output string
+
+	// sdl is the exported schema
+	sdl []byte
+}
+
+// NewCmdExport creates a new cobra.Command representing "schema export"
+func NewCmdExport(bCtx *env.BubblyContext) (*cobra.Command, *ExportOptions) {
+	o := &ExportOptions{
+		Command: "export",
+		bCtx:    bCtx,
+	}
+
+	// cmd represents the export command
+	cmd := &cobra.Command{
+		Use:     "export [-o FILENAME]",
+		Short:   "export the GraphQL schema as SDL",
+		Long:    exportLong + "\n\n",
+		Example: exportExample,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			o.Args = args
+
+			validationError := o.Validate(cmd)
+
+			if validationError != nil {
+				return validationError
+			}
+
+			resolveError := o.Resolve()
+
+			if resolveError != nil {
+				return resolveError
+			}
+
+			runError := o.Run()
+
+			if runError != nil {
+				return runError
+			}
+
+			o.Print()
+
+			return nil
+		},
+	}
+
+	f := cmd.Flags()
+
+	f.StringVarP(&o.output,
+		"output",
+		"o",
+		"",
+		"filename to write the schema to, instead of stdout")
+
+	return cmd, o
+}
+
+// Validate checks the ExportOptions to see if there is sufficient information run the command.
+func (o *ExportOptions) Validate(cmd *cobra.Command) error {
+	if len(o.Args) != 0 {
+		return cmdutil.UsageErrorf(cmd, "Unexpected args: %v", o.Args)
+	}
+	return nil
+}
+
+// Resolve resolves various ExportOptions attributes from the provided arguments to cmd
+func (o *ExportOptions) Resolve() error {
+	return nil
+}
+
+// Run runs the export command over the validated ExportOptions configuration
+func (o *ExportOptions) Run() error {
+	sdl, err := bubbly.ExportSchema(o.bCtx)
+	if err != nil {
+		return fmt.Errorf("failed to export schema: %w", err)
+	}
+	o.sdl = sdl
+
+	if o.output != "" {
+		if err := os.WriteFile(o.output, sdl, 0644); err != nil {
+			return fmt.Errorf(`failed to write schema to "%s": %w`, o.output, err)
+		}
+	}
+	return nil
+}
+
+// Print prints the exported schema, or where it was written to
+func (o *ExportOptions) Print() {
+	if o.output != "" {
+		fmt.Printf("schema written to \"%s\"\n", o.output)
+		return
+	}
+	fmt.Print(string(o.sdl))
+}
@@ -4,6 +4,8 @@ import (
 	"github.com/spf13/cobra"
 
 	schemaApplyCmd "github.com/valocode/bubbly/cmd/schema/apply"
+	schemaDiffCmd "github.com/valocode/bubbly/cmd/schema/diff"
+	schemaExportCmd "github.com/valocode/bubbly/cmd/schema/export"
 	"github.com/valocode/bubbly/env"
 )
 
@@ -18,5 +20,11 @@ func NewCmdSchema(bCtx *env.BubblyContext) *cobra.Command {
 	schemaApplyCmd, _ := schemaApplyCmd.NewCmdApply(bCtx)
 	cmd.AddCommand(schemaApplyCmd)
 
+	schemaDiffCmd, _ := schemaDiffCmd.NewCmdDiff(bCtx)
+	cmd.AddCommand(schemaDiffCmd)
+
+	schemaExportCmd, _ := schemaExportCmd.NewCmdExport(bCtx)
+	cmd.AddCommand(schemaExportCmd)
+
 	return cmd
 }
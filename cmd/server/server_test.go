@@ -0,0 +1,102 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/valocode/bubbly/env"
+	"github.com/valocode/bubbly/test"
+)
+
+// freePort returns a port that nothing listens on
+func freePort(t *testing.T) int {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	port := l.Addr().(*net.TCPAddr).Port
+	require.NoError(t, l.Close())
+	return port
+}
+
+func TestServer(t *testing.T) {
+	bCtx := env.NewBubblyContext()
+	bCtx.CLIConfig.Color = false
+	resource := test.RunPostgresDocker(bCtx, t)
+	port := freePort(t)
+
+	cmd, _ := NewCmdServer(bCtx)
+	cmd.SetArgs([]string{
+		"--addr", "127.0.0.1",
+		"--port", strconv.Itoa(port),
+		"--postgres-addr", fmt.Sprintf("localhost:%s", resource.GetPort("5432/tcp")),
+	})
+	cmd.SilenceUsage = true
+
+	ctx, cancel := context.WithCancel(context.Background())
+	runErr := make(chan error, 1)
+	go func() {
+		runErr <- cmd.ExecuteContext(ctx)
+	}()
+
+	url := fmt.Sprintf("http://127.0.0.1:%d/healthz", port)
+	require.Eventually(t, func() bool {
+		resp, err := http.Get(url)
+		if err != nil {
+			return false
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		return err == nil && resp.StatusCode == http.StatusOK && string(body) == "pong"
+	}, 10*time.Second, 100*time.Millisecond, "server did not respond to /healthz")
+
+	// Cancelling the context shuts the server down gracefully
+	cancel()
+	select {
+	case err := <-runErr:
+		require.NoError(t, err)
+	case <-time.After(10 * time.Second):
+		t.Fatal("server did not shutdown")
+	}
+	_, err := http.Get(url)
+	assert.Error(t, err)
+}
+
+func TestServerStoreUnavailable(t *testing.T) {
+	bCtx := env.NewBubblyContext()
+	bCtx.CLIConfig.Color = false
+	bCtx.StoreConfig.RetryAttempts = 1
+	bCtx.StoreConfig.RetrySleep = 0
+	port := freePort(t)
+
+	cmd, _ := NewCmdServer(bCtx)
+	cmd.SetArgs([]string{
+		"--port", strconv.Itoa(port),
+		"--postgres-addr", "localhost:1",
+	})
+	cmd.SilenceUsage = true
+	cmd.SilenceErrors = true
+
+	// The command should fail without serving, rather than wait to be
+	// stopped
+	runErr := make(chan error, 1)
+	go func() {
+		runErr <- cmd.Execute()
+	}()
+	select {
+	case err := <-runErr:
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to start bubbly server")
+	case <-time.After(10 * time.Second):
+		t.Fatal("server did not fail to start")
+	}
+	_, err := http.Get(fmt.Sprintf("http://127.0.0.1:%d/healthz", port))
+	assert.Error(t, err)
+}
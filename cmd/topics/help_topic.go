@@ -17,6 +17,10 @@ var HelpTopics = map[string]map[string]string{
 
 			COLOR: set to false to disable CLI color output. Default: true
 
+			LOG_FORMAT: set the format of the logs, either console or json. Default: console
+
+			MAX_OPEN_FILES: set the maximum number of bubbly files read at the same time from a directory. Default: 16
+
 			# bubbly API server
 
 			BUBBLY_PROTOCOL: specify the bubbly API server protocol (http/https). Default: http
@@ -65,6 +69,10 @@ var HelpTopics = map[string]map[string]string{
 
 			AGENT_NATS_SERVER_TOGGLE: specify whether to run a NATS Server as a part of the agent. Default: false
 
+			AGENT_REQUEST_TIMEOUT: specify the time in milliseconds to wait for the reply to a NATS request. Default: 2000
+
+			AGENT_WORKER_MAX_CONCURRENCY: specify the maximum number of runs that the worker applies at the same time. Default: 1
+
 			## NATS Server
 
 			NATS_SERVER_ADDR: specify the address of the NATS server. Default: localhost:4223
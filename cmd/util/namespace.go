@@ -0,0 +1,31 @@
+package util
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/valocode/bubbly/api/core"
+	"github.com/valocode/bubbly/config"
+)
+
+// AddNamespaceFlag adds the flag that sets the namespace of the resources to a
+// command which applies, fetches or deletes resources, with the given usage
+func AddNamespaceFlag(cmd *cobra.Command, c *config.ClientConfig, usage string) {
+	f := cmd.Flags()
+
+	f.StringVarP(
+		&c.Namespace,
+		"namespace",
+		"n",
+		c.Namespace,
+		usage,
+	)
+}
+
+// ValidateNamespace returns a usage error if the namespace of the client
+// config is not valid
+func ValidateNamespace(cmd *cobra.Command, c *config.ClientConfig) error {
+	if err := core.ValidateNamespace(c.Namespace); err != nil {
+		return UsageErrorf(cmd, "Invalid --namespace: %s", err)
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package util
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/valocode/bubbly/config"
+)
+
+// AddStoreFlags adds the flags that configure the bubbly data store to a
+// command which runs a data store
+func AddStoreFlags(cmd *cobra.Command, c *config.StoreConfig) {
+	f := cmd.Flags()
+
+	f.StringVar(
+		(*string)(&c.Provider),
+		"data-store-provider",
+		(string)(c.Provider),
+		"provider of the bubbly data store",
+	)
+	f.StringVar(
+		&c.DSN,
+		"data-store-dsn",
+		c.DSN,
+		"connection string to the database of the data store, instead of the address, username, password and database",
+	)
+	f.StringVar(
+		&c.PostgresAddr,
+		"postgres-addr",
+		c.PostgresAddr,
+		"postgres address for the data store",
+	)
+	f.StringVar(
+		&c.PostgresUser,
+		"postgres-username",
+		c.PostgresUser,
+		"postgres username for the data store",
+	)
+	f.StringVar(
+		&c.PostgresPassword,
+		"postgres-password",
+		c.PostgresPassword,
+		"postgres password for the data store",
+	)
+	f.StringVar(
+		&c.PostgresDatabase,
+		"postgres-database",
+		c.PostgresDatabase,
+		"postgres database for the data store",
+	)
+	f.IntVar(
+		&c.StatementTimeout,
+		"data-store-statement-timeout",
+		c.StatementTimeout,
+		"statement timeout in milliseconds for queries on the data store (0 to disable)",
+	)
+	f.IntVar(
+		&c.QueryCacheSize,
+		"data-store-query-cache-size",
+		c.QueryCacheSize,
+		"maximum number of query results cached by the data store (0 to disable)",
+	)
+	f.IntVar(
+		&c.QueryCacheTTL,
+		"data-store-query-cache-ttl",
+		c.QueryCacheTTL,
+		"time in seconds that query results are cached by the data store (0 to never expire)",
+	)
+	f.IntVar(
+		&c.QueryDefaultLimit,
+		"data-store-query-default-limit",
+		c.QueryDefaultLimit,
+		"maximum number of results per table in queries that do not provide first or last (0 for no limit)",
+	)
+	f.IntVar(
+		&c.QueryDefaultPageSize,
+		"data-store-query-default-page-size",
+		c.QueryDefaultPageSize,
+		"number of results per table in queries that do not provide first or last, instead of the default limit (0 to use the default limit)",
+	)
+	f.IntVar(
+		&c.QueryMaxLimit,
+		"data-store-query-max-limit",
+		c.QueryMaxLimit,
+		"maximum value of first and last in queries, and maximum number of rows a query can return (0 for no maximum)",
+	)
+	f.IntVar(
+		&c.QueryMaxPageSize,
+		"data-store-query-max-page-size",
+		c.QueryMaxPageSize,
+		"maximum number of results per table in queries, larger first and last are reduced to it (0 for no maximum)",
+	)
+	f.IntVar(
+		&c.QueryMaxDepth,
+		"data-store-query-max-depth",
+		c.QueryMaxDepth,
+		"maximum depth of nested tables in queries, deeper tables are left out of the result (0 for no maximum)",
+	)
+	f.BoolVar(
+		&c.QueryExplain,
+		"data-store-query-explain",
+		c.QueryExplain,
+		"allow queries to return the SQL and query plan they run, for debugging (do not enable in production)",
+	)
+	f.BoolVar(
+		&c.LogQueryArgs,
+		"data-store-log-query-args",
+		c.LogQueryArgs,
+		"whether to log the arguments of SQL statements run by the data store, which are otherwise redacted",
+	)
+	f.BoolVar(
+		&c.SoftDelete,
+		"data-store-soft-delete",
+		c.SoftDelete,
+		"mark deleted resources and data as deleted instead of removing them, so that they can still be queried with include_deleted",
+	)
+}
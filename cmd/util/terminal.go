@@ -0,0 +1,21 @@
+package util
+
+import (
+	"io"
+	"os"
+)
+
+// IsTerminal returns whether w writes to a terminal, so that output written
+// to it, such as diagnostics, is only colored when a user reads it and not
+// when it is redirected to a file or another program
+func IsTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
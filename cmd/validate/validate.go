@@ -0,0 +1,157 @@
+package validate
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+
+	"github.com/valocode/bubbly/bubbly"
+	cmdutil "github.com/valocode/bubbly/cmd/util"
+	"github.com/valocode/bubbly/env"
+	"github.com/valocode/bubbly/parser"
+)
+
+var (
+	_            cmdutil.Options = (*ValidateOptions)(nil)
+	validateLong                 = cmdutil.LongDesc(`
+		Validate bubbly resources without applying them.
+
+		The resources are parsed and decoded, and any errors are reported with
+		the file and line where they occurred. This does not contact a bubbly
+		server, so it can be used to check resources before they are applied,
+		e.g. in a pre-commit hook
+	`)
+
+	validateExample = cmdutil.Examples(`
+		# Validate the bubbly resources in the file ./main.bubbly
+		bubbly validate -f ./main.bubbly
+
+		# Validate the bubbly resources in the directory ./resources
+		bubbly validate -f ./resources
+		`)
+)
+
+// ValidateOptions holds everything necessary to run the command.
+// Flag values received to the command are loaded into this struct
+type ValidateOptions struct {
+	cmdutil.Options
+	bCtx    *env.BubblyContext
+	Command string
+	Args    []string
+
+	// errOut is where the diagnostics for invalid resources are written
+	errOut io.Writer
+
+	// flags
+	filename string
+}
+
+// NewCmdValidate creates a new cobra.Command representing "bubbly validate"
+func NewCmdValidate(bCtx *env.BubblyContext) (*cobra.Command, *ValidateOptions) {
+	o := &ValidateOptions{
+		Command: "validate",
+		bCtx:    bCtx,
+		errOut:  os.Stderr,
+	}
+
+	// cmd represents the validate command
+	cmd := &cobra.Command{
+		Use:     "validate (-f (FILENAME | DIRECTORY)) [flags]",
+		Short:   "Validate one or more bubbly resources without applying them",
+		Long:    validateLong + "\n\n",
+		Example: validateExample,
+		RunE: func(cmd *cobra.Command, args []string) error {
+
+			o.Args = args
+			o.errOut = cmd.ErrOrStderr()
+
+			validationError := o.Validate(cmd)
+
+			if validationError != nil {
+				return validationError
+			}
+
+			resolveError := o.Resolve()
+
+			if resolveError != nil {
+				return resolveError
+			}
+			runError := o.Run()
+
+			if runError != nil {
+				return runError
+			}
+
+			o.Print()
+			return nil
+		},
+	}
+
+	f := cmd.Flags()
+
+	f.StringVarP(&o.filename,
+		"filename",
+		"f",
+		"",
+		"filename or directory that contains the bubbly resources to validate")
+
+	cmd.MarkFlagRequired("filename")
+
+	return cmd, o
+}
+
+// Validate checks the ValidateOptions to see if there is sufficient information
+// to run the command.
+func (o *ValidateOptions) Validate(cmd *cobra.Command) error {
+	if len(o.Args) != 0 {
+		return cmdutil.UsageErrorf(cmd, "Unexpected args: %v", o.Args)
+	}
+
+	// check the file/directory is valid and fail fast if not
+	if _, err := os.Stat(o.filename); err != nil {
+		return fmt.Errorf(
+			`failed to validate file/path to bubbly resources "%s": %w`,
+			filepath.FromSlash(o.filename),
+			err)
+	}
+
+	return nil
+}
+
+// Resolve resolves various ValidateOptions attributes from the provided
+// arguments to cmd
+func (o *ValidateOptions) Resolve() error {
+	return nil
+}
+
+// Run runs the validate command over the validated ValidateOptions
+// configuration
+func (o *ValidateOptions) Run() error {
+	if err := bubbly.Validate(o.bCtx, o.filename); err != nil {
+		// Show the user the source of every error that occurred
+		var parserErr *parser.ParserError
+		if errors.As(err, &parserErr) {
+			parserErr.WriteDiagnostics(o.errOut, o.bCtx.CLIConfig.Color && cmdutil.IsTerminal(o.errOut))
+		}
+		return fmt.Errorf("failed to validate configuration: %w", err)
+	}
+	return nil
+}
+
+// Print prints the successful outcome of validating the resource(s)
+func (o *ValidateOptions) Print() {
+	successString := fmt.Sprintf(
+		`resource(s) at path/directory "%s" are valid`,
+		filepath.FromSlash(o.filename))
+
+	if o.bCtx.CLIConfig.Color {
+		color.Green(successString)
+	} else {
+		fmt.Println(successString)
+	}
+}
@@ -0,0 +1,60 @@
+package validate
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/valocode/bubbly/env"
+)
+
+func TestValidate(t *testing.T) {
+	tcs := []struct {
+		desc     string
+		filename string
+		valid    bool
+		diags    []string
+	}{
+		{
+			desc:     "valid resources",
+			filename: "./testdata/valid",
+			valid:    true,
+		},
+		{
+			desc:     "invalid resources",
+			filename: "./testdata/invalid",
+			valid:    false,
+			diags: []string{
+				"on testdata/invalid/invalid.bubbly line 2",
+				"one of format or format_table must be provided",
+				`An argument named "unknown_attribute" is not expected here`,
+				"unknown variable reference other.resource",
+			},
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.desc, func(t *testing.T) {
+			bCtx := env.NewBubblyContext()
+			bCtx.CLIConfig.Color = false
+			cmd, _ := NewCmdValidate(bCtx)
+			var errOut bytes.Buffer
+			cmd.SetErr(&errOut)
+			cmd.SetArgs([]string{"-f", tc.filename})
+			cmd.SilenceUsage = true
+			cmd.SilenceErrors = true
+
+			err := cmd.Execute()
+			if tc.valid {
+				require.NoError(t, err)
+				assert.Empty(t, errOut.String())
+				return
+			}
+			require.Error(t, err)
+			for _, diag := range tc.diags {
+				assert.Contains(t, errOut.String(), diag)
+			}
+		})
+	}
+}
@@ -0,0 +1,654 @@
+// Package codegen generates typed clients for the tables of a bubbly schema,
+// from the GraphQL SDL of the schema
+package codegen
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/token"
+	"sort"
+	"strings"
+
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/parser"
+)
+
+// The arguments of the query field of a table that the generated queries use
+const (
+	filterArg  = "filter"
+	orderByArg = "order_by"
+	firstArg   = "first"
+	lastArg    = "last"
+)
+
+// filterOp is a filter on a field, which is an input field of the filter type
+// of a table named after the field and the suffix of the filter
+type filterOp struct {
+	suffix string
+	method string
+	doc    string
+}
+
+// filterOps are the filters that the generated clients support, in the order
+// their methods are generated in
+var filterOps = []filterOp{
+	{suffix: "_eq", method: "Eq", doc: "is equal to v"},
+	{suffix: "_gt", method: "Gt", doc: "is greater than v"},
+	{suffix: "_gte", method: "Gte", doc: "is greater than or equal to v"},
+	{suffix: "_lt", method: "Lt", doc: "is less than v"},
+	{suffix: "_lte", method: "Lte", doc: "is less than or equal to v"},
+	{suffix: "_in", method: "In", doc: "is one of v"},
+	{suffix: "_not_in", method: "NotIn", doc: "is none of v"},
+	{suffix: "_is_null", method: "IsNull", doc: "is null, if v is true, or is not null"},
+	{suffix: "_contains", method: "Contains", doc: "contains all of v"},
+	{suffix: "_overlaps", method: "Overlaps", doc: "contains any of v"},
+}
+
+// goScalarTypes are the Go types of the GraphQL scalars. The values of other
+// scalars are decoded into an interface{}
+var goScalarTypes = map[string]string{
+	"String":  "string",
+	"ID":      "string",
+	"Int":     "int64",
+	"Float":   "float64",
+	"Boolean": "bool",
+	"Map":     "map[string]interface{}",
+}
+
+// goInitialisms are the parts of names that are written in upper case in Go
+// names
+var goInitialisms = map[string]struct{}{
+	"api":  {},
+	"cpu":  {},
+	"html": {},
+	"http": {},
+	"id":   {},
+	"json": {},
+	"sql":  {},
+	"uri":  {},
+	"url":  {},
+	"uuid": {},
+	"xml":  {},
+}
+
+// goTable is a table of the schema, as it is generated
+type goTable struct {
+	name   string
+	goName string
+	fields []goField
+	// relations are the fields for the related tables
+	relations []goRelation
+	// kinds are the GraphQL types of the fields, sorted, with the filters
+	// that all the fields of that type support
+	kinds []goKind
+	// orderable is whether the rows can be ordered by the fields
+	orderable bool
+	// first and last are whether the number of rows can be limited
+	first bool
+	last  bool
+}
+
+// goField is a field of a table, which is a scalar or a list of scalars
+type goField struct {
+	name   string
+	goName string
+	scalar string
+	list   bool
+	// enum is the GraphQL enum that the field is filtered with, if the
+	// field has an enum
+	enum string
+}
+
+// kind returns the GraphQL type of the field, e.g. String or [String], or
+// the enum of a field with an enum
+func (f goField) kind() string {
+	if f.enum != "" {
+		return f.enum
+	}
+	if f.list {
+		return "[" + f.scalar + "]"
+	}
+	return f.scalar
+}
+
+// goType returns the Go type of the values of the field
+func (f goField) goType() string {
+	if f.list {
+		return "[]" + goScalarType(f.scalar)
+	}
+	return goScalarType(f.scalar)
+}
+
+// goRelation is the field of a table for a related table
+type goRelation struct {
+	name   string
+	goName string
+	table  string
+	list   bool
+}
+
+// goKind is a GraphQL type of the fields of a table, which has a Go type for
+// filtering and ordering the rows by those fields
+type goKind struct {
+	kind string
+	ops  []goFilterOp
+}
+
+// goFilterOp is a filter of the fields of a kind, with the Go type of its
+// value
+type goFilterOp struct {
+	filterOp
+	goType   string
+	variadic bool
+	// enum is whether the value is written as enum values
+	enum bool
+}
+
+// GenerateGo generates the Go source of a typed client for the tables of the
+// GraphQL schema in sdl, which is the SDL exported by the bubbly store.
+// For each table, the client has a struct for its rows, and a query builder
+// that filters the rows with typed filters, e.g.
+//
+//	client.TestRuns().Where(TestRunName.Eq("unit")).First(5).Do(ctx)
+//
+// The generated source only depends on the Go standard library
+func GenerateGo(sdl []byte, pkg string) ([]byte, error) {
+	if !token.IsIdentifier(pkg) {
+		return nil, fmt.Errorf("invalid Go package name: %q", pkg)
+	}
+	tables, err := parseGoTables(sdl)
+	if err != nil {
+		return nil, err
+	}
+	if len(tables) == 0 {
+		return nil, fmt.Errorf("schema has no tables")
+	}
+	if err := checkGoNames(tables); err != nil {
+		return nil, err
+	}
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "// Code generated by \"bubbly gen go\". DO NOT EDIT.\n\n")
+	fmt.Fprintf(&b, "package %s\n\n", pkg)
+	b.WriteString(goClientRuntime)
+	for _, t := range tables {
+		genGoTable(&b, t, tables)
+	}
+
+	formatted, err := format.Source(b.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("error formatting generated code: %w", err)
+	}
+	return formatted, nil
+}
+
+// parseGoTables parses the tables from the SDL. The tables are the fields of
+// the query type that return a list of an object, and can be filtered and
+// ordered, which excludes e.g. the aggregates of the tables
+func parseGoTables(sdl []byte) ([]goTable, error) {
+	doc, err := parser.Parse(parser.ParseParams{Source: string(sdl)})
+	if err != nil {
+		return nil, fmt.Errorf("error parsing GraphQL schema: %w", err)
+	}
+	var (
+		queryName = "query"
+		objects   = make(map[string]*ast.ObjectDefinition)
+		inputs    = make(map[string]*ast.InputObjectDefinition)
+		enums     = make(map[string]struct{})
+	)
+	for _, def := range doc.Definitions {
+		switch def := def.(type) {
+		case *ast.SchemaDefinition:
+			for _, op := range def.OperationTypes {
+				if op.Operation == "query" {
+					queryName = op.Type.Name.Value
+				}
+			}
+		case *ast.ObjectDefinition:
+			objects[def.Name.Value] = def
+		case *ast.InputObjectDefinition:
+			inputs[def.Name.Value] = def
+		case *ast.EnumDefinition:
+			enums[def.Name.Value] = struct{}{}
+		}
+	}
+	query, ok := objects[queryName]
+	if !ok {
+		return nil, fmt.Errorf("GraphQL schema has no query type %q", queryName)
+	}
+
+	// Find the tables first, so that the relations among them are known
+	var (
+		tableFields = make(map[string]*ast.FieldDefinition)
+		tableNames  []string
+	)
+	for _, field := range query.Fields {
+		list, ok := field.Type.(*ast.List)
+		if !ok {
+			continue
+		}
+		name := typeName(list.Type)
+		if _, ok := objects[name]; !ok || name != field.Name.Value {
+			continue
+		}
+		args := argTypes(field.Arguments)
+		if _, ok := args[filterArg]; !ok {
+			continue
+		}
+		tableFields[name] = field
+		tableNames = append(tableNames, name)
+	}
+	sort.Strings(tableNames)
+
+	tables := make([]goTable, 0, len(tableNames))
+	for _, name := range tableNames {
+		var (
+			args = argTypes(tableFields[name].Arguments)
+			t    = goTable{
+				name:   name,
+				goName: goName(name),
+				first:  typeName(args[firstArg]) == "Int",
+				last:   typeName(args[lastArg]) == "Int",
+			}
+			filter = inputFields(inputs[typeName(args[filterArg])])
+			order  = inputFields(inputs[typeName(args[orderByArg])])
+		)
+		for _, field := range objects[name].Fields {
+			fieldType := typeName(field.Type)
+			if _, ok := tableFields[fieldType]; ok {
+				_, list := field.Type.(*ast.List)
+				t.relations = append(t.relations, goRelation{
+					name:   field.Name.Value,
+					goName: goName(field.Name.Value),
+					table:  fieldType,
+					list:   list,
+				})
+				continue
+			}
+			if _, ok := objects[fieldType]; ok {
+				continue
+			}
+			_, list := field.Type.(*ast.List)
+			f := goField{
+				name:   field.Name.Value,
+				goName: goName(field.Name.Value),
+				scalar: fieldType,
+				list:   list,
+			}
+			// A field with an enum is filtered with the enum instead of its
+			// scalar
+			if enum := typeName(filter[f.name+"_eq"]); enum != "" {
+				if _, ok := enums[enum]; ok {
+					f.enum = enum
+				}
+			}
+			t.fields = append(t.fields, f)
+		}
+		t.kinds = goKinds(t.fields, filter, enums)
+		t.orderable = len(t.fields) > 0
+		for _, f := range t.fields {
+			if _, ok := order[f.name]; !ok {
+				t.orderable = false
+			}
+		}
+		tables = append(tables, t)
+	}
+	return tables, nil
+}
+
+// goKinds returns the kinds of the fields, with the filters that all the
+// fields of a kind have in the filter input
+func goKinds(fields []goField, filter map[string]ast.Type, enums map[string]struct{}) []goKind {
+	var (
+		kinds []string
+		ops   = make(map[string][]goFilterOp)
+	)
+	for _, f := range fields {
+		var fieldOps []goFilterOp
+		for _, op := range filterOps {
+			ty, ok := filter[f.name+op.suffix]
+			if !ok {
+				continue
+			}
+			_, variadic := ty.(*ast.List)
+			fop := goFilterOp{
+				filterOp: op,
+				goType:   goScalarType(typeName(ty)),
+				variadic: variadic,
+			}
+			if _, ok := enums[typeName(ty)]; ok {
+				fop.goType = "string"
+				fop.enum = true
+			}
+			fieldOps = append(fieldOps, fop)
+		}
+		kindOps, ok := ops[f.kind()]
+		if !ok {
+			kinds = append(kinds, f.kind())
+			ops[f.kind()] = fieldOps
+			continue
+		}
+		// Only keep the filters that all the fields of the kind have
+		var common []goFilterOp
+		for _, op := range kindOps {
+			for _, fop := range fieldOps {
+				if op == fop {
+					common = append(common, op)
+					break
+				}
+			}
+		}
+		ops[f.kind()] = common
+	}
+	sort.Strings(kinds)
+
+	result := make([]goKind, 0, len(kinds))
+	for _, kind := range kinds {
+		result = append(result, goKind{kind: kind, ops: ops[kind]})
+	}
+	return result
+}
+
+// checkGoNames checks that the generated names of the tables and fields do
+// not clash, e.g. because the tables test_run and testrun would both be
+// called TestRun
+func checkGoNames(tables []goTable) error {
+	var (
+		// names are the top-level declarations, and what they were
+		// generated for
+		names = map[string]string{
+			"Client":         "the client",
+			"ClientOption":   "the client",
+			"NewClient":      "the client",
+			"WithAuthToken":  "the client",
+			"WithHTTPClient": "the client",
+		}
+		// methods are the methods of the client
+		methods = map[string]string{
+			"Query": "the client",
+		}
+	)
+	declare := func(decls map[string]string, name string, what string) error {
+		if other, ok := decls[name]; ok {
+			return fmt.Errorf("the generated name %s of %s clashes with the one of %s", name, what, other)
+		}
+		decls[name] = what
+		return nil
+	}
+	for _, t := range tables {
+		what := "table " + t.name
+		for _, name := range []string{t.goName, t.goName + "Query", t.goName + "Filter", t.goName + "Order"} {
+			if err := declare(names, name, what); err != nil {
+				return err
+			}
+		}
+		for _, k := range t.kinds {
+			if err := declare(names, kindTypeName(t, k.kind), what); err != nil {
+				return err
+			}
+		}
+		if err := declare(methods, goPlural(t.goName), what); err != nil {
+			return err
+		}
+		// The fields of the struct of the table
+		fields := make(map[string]string)
+		for _, f := range t.fields {
+			fieldWhat := fmt.Sprintf("field %s of table %s", f.name, t.name)
+			if err := declare(names, t.goName+f.goName, fieldWhat); err != nil {
+				return err
+			}
+			if err := declare(fields, f.goName, fieldWhat); err != nil {
+				return err
+			}
+		}
+		for _, r := range t.relations {
+			if err := declare(fields, r.goName, fmt.Sprintf("field %s of table %s", r.name, t.name)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// genGoTable generates the struct, fields, filters and query builder of a
+// table
+func genGoTable(b *bytes.Buffer, t goTable, tables []goTable) {
+	fmt.Fprintf(b, "// #######################################\n")
+	fmt.Fprintf(b, "// %s\n", strings.ToUpper(t.name))
+	fmt.Fprintf(b, "// #######################################\n\n")
+
+	// The struct for the rows
+	fmt.Fprintf(b, "// %s is a row of the table %s\n", t.goName, t.name)
+	fmt.Fprintf(b, "type %s struct {\n", t.goName)
+	for _, f := range t.fields {
+		fmt.Fprintf(b, "\t%s\t%s\t`json:\"%s\"`\n", f.goName, f.goType(), f.name)
+	}
+	for _, r := range t.relations {
+		goType := "*" + goName(r.table)
+		if r.list {
+			goType = "[]" + goName(r.table)
+		}
+		fmt.Fprintf(b, "\t%s\t%s\t`json:\"%s,omitempty\"`\n", r.goName, goType, r.name)
+	}
+	fmt.Fprintf(b, "}\n\n")
+
+	// The fields, to filter and order the rows by
+	fmt.Fprintf(b, "// %sFilter filters the rows of the table %s\n", t.goName, t.name)
+	fmt.Fprintf(b, "type %sFilter struct {\n\tfilter filter\n}\n\n", t.goName)
+	if t.orderable {
+		fmt.Fprintf(b, "// %sOrder orders the rows of the table %s\n", t.goName, t.name)
+		fmt.Fprintf(b, "type %sOrder struct {\n\torder order\n}\n\n", t.goName)
+	}
+	fmt.Fprintf(b, "// The fields of the table %s\n", t.name)
+	fmt.Fprintf(b, "const (\n")
+	for _, f := range t.fields {
+		fmt.Fprintf(b, "\t%s%s %s = %q\n", t.goName, f.goName, kindTypeName(t, f.kind()), f.name)
+	}
+	fmt.Fprintf(b, ")\n\n")
+	for _, k := range t.kinds {
+		kindName := kindTypeName(t, k.kind)
+		fmt.Fprintf(b, "// %s is a %s field of the table %s\n", kindName, k.kind, t.name)
+		fmt.Fprintf(b, "type %s string\n\n", kindName)
+		for _, op := range k.ops {
+			param := "v " + op.goType
+			if op.variadic {
+				param = "v ..." + op.goType
+			}
+			fmt.Fprintf(b, "// %s filters the rows where the field %s\n", op.method, op.doc)
+			fmt.Fprintf(b, "func (f %s) %s(%s) %sFilter {\n", kindName, op.method, param, t.goName)
+			value := "v"
+			if op.enum {
+				value = "enum{value: v}"
+			}
+			fmt.Fprintf(b, "\treturn %sFilter{filter: filter{name: string(f) + %q, value: %s}}\n", t.goName, op.suffix, value)
+			fmt.Fprintf(b, "}\n\n")
+		}
+		if t.orderable {
+			fmt.Fprintf(b, "// Asc orders the rows by the field in ascending order\n")
+			fmt.Fprintf(b, "func (f %s) Asc() %sOrder {\n", kindName, t.goName)
+			fmt.Fprintf(b, "\treturn %sOrder{order: order{name: string(f)}}\n", t.goName)
+			fmt.Fprintf(b, "}\n\n")
+			fmt.Fprintf(b, "// Desc orders the rows by the field in descending order\n")
+			fmt.Fprintf(b, "func (f %s) Desc() %sOrder {\n", kindName, t.goName)
+			fmt.Fprintf(b, "\treturn %sOrder{order: order{name: string(f), desc: true}}\n", t.goName)
+			fmt.Fprintf(b, "}\n\n")
+		}
+	}
+
+	// The query builder
+	fmt.Fprintf(b, "// %sQuery is a query for the rows of the table %s\n", t.goName, t.name)
+	fmt.Fprintf(b, "type %sQuery struct {\n\tclient *Client\n\tquery query\n}\n\n", t.goName)
+	fmt.Fprintf(b, "// %s returns a query for the rows of the table %s\n", goPlural(t.goName), t.name)
+	fmt.Fprintf(b, "func (c *Client) %s() *%sQuery {\n", goPlural(t.goName), t.goName)
+	fmt.Fprintf(b, "\treturn &%sQuery{\n", t.goName)
+	fmt.Fprintf(b, "\t\tclient: c,\n")
+	fmt.Fprintf(b, "\t\tquery: query{\n")
+	fmt.Fprintf(b, "\t\t\ttable: %q,\n", t.name)
+	fmt.Fprintf(b, "\t\t\tfields: []string{%s},\n", quoteFields(t.fields))
+	fmt.Fprintf(b, "\t\t},\n")
+	fmt.Fprintf(b, "\t}\n")
+	fmt.Fprintf(b, "}\n\n")
+
+	fmt.Fprintf(b, "// Where filters the rows, which must match all of the filters\n")
+	fmt.Fprintf(b, "func (q *%sQuery) Where(filters ...%sFilter) *%sQuery {\n", t.goName, t.goName, t.goName)
+	fmt.Fprintf(b, "\tfor _, f := range filters {\n")
+	fmt.Fprintf(b, "\t\tq.query.filters = append(q.query.filters, f.filter)\n")
+	fmt.Fprintf(b, "\t}\n")
+	fmt.Fprintf(b, "\treturn q\n")
+	fmt.Fprintf(b, "}\n\n")
+	if t.orderable {
+		fmt.Fprintf(b, "// OrderBy orders the rows by the fields, in the order they are given\n")
+		fmt.Fprintf(b, "func (q *%sQuery) OrderBy(orders ...%sOrder) *%sQuery {\n", t.goName, t.goName, t.goName)
+		fmt.Fprintf(b, "\tfor _, o := range orders {\n")
+		fmt.Fprintf(b, "\t\tq.query.orders = append(q.query.orders, o.order)\n")
+		fmt.Fprintf(b, "\t}\n")
+		fmt.Fprintf(b, "\treturn q\n")
+		fmt.Fprintf(b, "}\n\n")
+	}
+	if t.first {
+		fmt.Fprintf(b, "// First limits the rows to the first n rows\n")
+		fmt.Fprintf(b, "func (q *%sQuery) First(n int) *%sQuery {\n", t.goName, t.goName)
+		fmt.Fprintf(b, "\tq.query.first = n\n")
+		fmt.Fprintf(b, "\treturn q\n")
+		fmt.Fprintf(b, "}\n\n")
+	}
+	if t.last {
+		fmt.Fprintf(b, "// Last limits the rows to the last n rows\n")
+		fmt.Fprintf(b, "func (q *%sQuery) Last(n int) *%sQuery {\n", t.goName, t.goName)
+		fmt.Fprintf(b, "\tq.query.last = n\n")
+		fmt.Fprintf(b, "\treturn q\n")
+		fmt.Fprintf(b, "}\n\n")
+	}
+	for _, r := range t.relations {
+		var related goTable
+		for _, other := range tables {
+			if other.name == r.table {
+				related = other
+			}
+		}
+		fmt.Fprintf(b, "// With%s also queries the fields of the related %s\n", r.goName, r.name)
+		fmt.Fprintf(b, "func (q *%sQuery) With%s() *%sQuery {\n", t.goName, r.goName, t.goName)
+		fmt.Fprintf(b, "\tq.query.fields = append(q.query.fields, %q)\n",
+			r.name+" { "+strings.Join(fieldNames(related.fields), " ")+" }")
+		fmt.Fprintf(b, "\treturn q\n")
+		fmt.Fprintf(b, "}\n\n")
+	}
+
+	fmt.Fprintf(b, "// String returns the GraphQL query\n")
+	fmt.Fprintf(b, "func (q *%sQuery) String() string {\n", t.goName)
+	fmt.Fprintf(b, "\treturn q.query.String()\n")
+	fmt.Fprintf(b, "}\n\n")
+
+	fmt.Fprintf(b, "// Do runs the query and returns the rows\n")
+	fmt.Fprintf(b, "func (q *%sQuery) Do(ctx context.Context) ([]%s, error) {\n", t.goName, t.goName)
+	fmt.Fprintf(b, "\tvar data struct {\n")
+	fmt.Fprintf(b, "\t\tRows []%s `json:%q`\n", t.goName, t.name)
+	fmt.Fprintf(b, "\t}\n")
+	fmt.Fprintf(b, "\tif err := q.client.Query(ctx, q.String(), &data); err != nil {\n")
+	fmt.Fprintf(b, "\t\treturn nil, err\n")
+	fmt.Fprintf(b, "\t}\n")
+	fmt.Fprintf(b, "\treturn data.Rows, nil\n")
+	fmt.Fprintf(b, "}\n\n")
+}
+
+// kindTypeName returns the name of the Go type of the fields of a table with
+// the GraphQL type, e.g. TestRunStringField for String and
+// TestRunStringListField for [String]
+func kindTypeName(t goTable, kind string) string {
+	// The enums of fields are named after the table and the field, e.g.
+	// test_run_status_enum, which is TestRunStatusEnumField
+	if strings.HasPrefix(kind, t.name+"_") {
+		return t.goName + goName(strings.TrimPrefix(kind, t.name+"_")) + "Field"
+	}
+	if strings.HasPrefix(kind, "[") {
+		return t.goName + goName(strings.Trim(kind, "[]")) + "ListField"
+	}
+	return t.goName + goName(kind) + "Field"
+}
+
+func fieldNames(fields []goField) []string {
+	names := make([]string, 0, len(fields))
+	for _, f := range fields {
+		names = append(names, f.name)
+	}
+	return names
+}
+
+func quoteFields(fields []goField) string {
+	quoted := make([]string, 0, len(fields))
+	for _, name := range fieldNames(fields) {
+		quoted = append(quoted, fmt.Sprintf("%q", name))
+	}
+	return strings.Join(quoted, ", ")
+}
+
+// typeName returns the name of the named type, of a list or non null type
+func typeName(ty ast.Type) string {
+	switch ty := ty.(type) {
+	case *ast.Named:
+		return ty.Name.Value
+	case *ast.List:
+		return typeName(ty.Type)
+	case *ast.NonNull:
+		return typeName(ty.Type)
+	}
+	return ""
+}
+
+func argTypes(args []*ast.InputValueDefinition) map[string]ast.Type {
+	types := make(map[string]ast.Type, len(args))
+	for _, arg := range args {
+		types[arg.Name.Value] = arg.Type
+	}
+	return types
+}
+
+func inputFields(input *ast.InputObjectDefinition) map[string]ast.Type {
+	if input == nil {
+		return nil
+	}
+	return argTypes(input.Fields)
+}
+
+// goScalarType returns the Go type of the values of a GraphQL scalar
+func goScalarType(scalar string) string {
+	if goType, ok := goScalarTypes[scalar]; ok {
+		return goType
+	}
+	return "interface{}"
+}
+
+// goName returns the exported Go name for a GraphQL name, e.g. TestRun for
+// test_run and ID for _id
+func goName(name string) string {
+	var b strings.Builder
+	for _, part := range strings.Split(name, "_") {
+		if part == "" {
+			continue
+		}
+		if _, ok := goInitialisms[strings.ToLower(part)]; ok {
+			b.WriteString(strings.ToUpper(part))
+			continue
+		}
+		b.WriteString(strings.ToUpper(part[:1]) + part[1:])
+	}
+	s := b.String()
+	if s == "" || (s[0] >= '0' && s[0] <= '9') {
+		s = "X" + s
+	}
+	return s
+}
+
+// goPlural returns the plural of a Go name, for the query methods of the
+// client, e.g. TestRuns for TestRun
+func goPlural(name string) string {
+	switch {
+	case strings.HasSuffix(name, "s"), strings.HasSuffix(name, "x"),
+		strings.HasSuffix(name, "ch"), strings.HasSuffix(name, "sh"):
+		return name + "es"
+	case strings.HasSuffix(name, "y") && len(name) > 1 && !strings.ContainsRune("aeiouAEIOU", rune(name[len(name)-2])):
+		return name[:len(name)-1] + "ies"
+	}
+	return name + "s"
+}
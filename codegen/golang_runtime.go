@@ -0,0 +1,220 @@
+package codegen
+
+// goClientRuntime is the part of the generated Go client that does not depend
+// on the tables: the client that runs the queries, and the query that the
+// query builders of the tables build
+const goClientRuntime = `
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Client queries the tables of a bubbly store through the GraphQL API of a
+// bubbly API server
+type Client struct {
+	addr       string
+	httpClient *http.Client
+	authToken  string
+}
+
+// ClientOption configures a Client
+type ClientOption func(*Client)
+
+// WithHTTPClient sets the HTTP client that makes the requests
+func WithHTTPClient(httpClient *http.Client) ClientOption {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithAuthToken sets the token that authenticates the requests
+func WithAuthToken(token string) ClientOption {
+	return func(c *Client) {
+		c.authToken = token
+	}
+}
+
+// NewClient creates a Client for the bubbly API server at addr, e.g.
+// http://localhost:8111/api/v1
+func NewClient(addr string, opts ...ClientOption) *Client {
+	c := &Client{
+		addr:       strings.TrimSuffix(addr, "/"),
+		httpClient: http.DefaultClient,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Query runs the GraphQL query, and decodes its data into ptr
+func (c *Client) Query(ctx context.Context, query string, ptr interface{}) error {
+	body, err := json.Marshal(map[string]string{"query": query})
+	if err != nil {
+		return fmt.Errorf("failed to marshal query: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.addr+"/graphql", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.authToken != "" {
+		req.Header.Set("Authorization", c.authToken)
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to query: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("failed to query: %s: %s", resp.Status, bytes.TrimSpace(msg))
+	}
+
+	var result struct {
+		Data   json.RawMessage ` + "`json:\"data\"`" + `
+		Errors []struct {
+			Message string ` + "`json:\"message\"`" + `
+		} ` + "`json:\"errors\"`" + `
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("failed to decode query result: %w", err)
+	}
+	if len(result.Errors) > 0 {
+		msgs := make([]string, 0, len(result.Errors))
+		for _, e := range result.Errors {
+			msgs = append(msgs, e.Message)
+		}
+		return fmt.Errorf("query returned errors: %s", strings.Join(msgs, "; "))
+	}
+	if err := json.Unmarshal(result.Data, ptr); err != nil {
+		return fmt.Errorf("failed to decode query data: %w", err)
+	}
+	return nil
+}
+
+// query is a query for the rows of a table
+type query struct {
+	table   string
+	fields  []string
+	filters []filter
+	orders  []order
+	first   int
+	last    int
+}
+
+// filter is a filter of a query, which is the name of the filter input field
+// and its value
+type filter struct {
+	name  string
+	value interface{}
+}
+
+// enum is the value of a filter on a field with an enum, which is written as
+// enum values instead of strings
+type enum struct {
+	value interface{}
+}
+
+// order orders the rows of a query by a field
+type order struct {
+	name string
+	desc bool
+}
+
+// String returns the GraphQL query
+func (q query) String() string {
+	var args []string
+	if len(q.filters) > 0 {
+		filters := make([]string, 0, len(q.filters))
+		for _, f := range q.filters {
+			filters = append(filters, f.name+": "+graphQLValue(f.value))
+		}
+		args = append(args, "filter: {"+strings.Join(filters, ", ")+"}")
+	}
+	if len(q.orders) > 0 {
+		orders := make([]string, 0, len(q.orders))
+		for _, o := range q.orders {
+			dir := "asc"
+			if o.desc {
+				dir = "desc"
+			}
+			orders = append(orders, o.name+": "+dir)
+		}
+		args = append(args, "order_by: {"+strings.Join(orders, ", ")+"}")
+	}
+	if q.first > 0 {
+		args = append(args, fmt.Sprintf("first: %d", q.first))
+	}
+	if q.last > 0 {
+		args = append(args, fmt.Sprintf("last: %d", q.last))
+	}
+
+	var sb strings.Builder
+	sb.WriteString("{ " + q.table)
+	if len(args) > 0 {
+		sb.WriteString("(" + strings.Join(args, ", ") + ")")
+	}
+	sb.WriteString(" { " + strings.Join(q.fields, " ") + " } }")
+	return sb.String()
+}
+
+// graphQLValue returns the value as a GraphQL literal
+func graphQLValue(v interface{}) string {
+	if e, ok := v.(enum); ok {
+		return enumValue(e.value)
+	}
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return "null"
+		}
+		rv = rv.Elem()
+	}
+	switch rv.Kind() {
+	case reflect.Invalid:
+		return "null"
+	case reflect.Slice, reflect.Array:
+		values := make([]string, 0, rv.Len())
+		for i := 0; i < rv.Len(); i++ {
+			values = append(values, graphQLValue(rv.Index(i).Interface()))
+		}
+		return "[" + strings.Join(values, ", ") + "]"
+	case reflect.Map:
+		fields := make([]string, 0, rv.Len())
+		iter := rv.MapRange()
+		for iter.Next() {
+			fields = append(fields, fmt.Sprint(iter.Key().Interface())+": "+graphQLValue(iter.Value().Interface()))
+		}
+		sort.Strings(fields)
+		return "{" + strings.Join(fields, ", ") + "}"
+	default:
+		b, err := json.Marshal(rv.Interface())
+		if err != nil {
+			return "null"
+		}
+		return string(b)
+	}
+}
+
+// enumValue returns the value, or list of values, as GraphQL enum values
+func enumValue(v interface{}) string {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Slice {
+		values := make([]string, 0, rv.Len())
+		for i := 0; i < rv.Len(); i++ {
+			values = append(values, fmt.Sprint(rv.Index(i).Interface()))
+		}
+		return "[" + strings.Join(values, ", ") + "]"
+	}
+	return fmt.Sprint(v)
+}
+
+`
@@ -0,0 +1,94 @@
+package codegen
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestGenerateGo tests that the generated client for the sample schema is the
+// one in the example package, which is compiled and tested there
+func TestGenerateGo(t *testing.T) {
+	sdl, err := os.ReadFile("testdata/schema.graphql")
+	require.NoError(t, err)
+	expected, err := os.ReadFile("internal/example/client_gen.go")
+	require.NoError(t, err)
+
+	src, err := GenerateGo(sdl, "example")
+	require.NoError(t, err)
+	assert.Equal(t, string(expected), string(src), "the example client is outdated: run go generate ./codegen/...")
+}
+
+func TestGenerateGoErrors(t *testing.T) {
+	table := func(name string) string {
+		return "type " + name + " {\n  _id: String\n}\n\n" +
+			"input " + name + "_filter {\n  _id_eq: String\n}\n\n"
+	}
+	tcs := []struct {
+		desc string
+		sdl  string
+		pkg  string
+	}{
+		{
+			desc: "invalid package name",
+			sdl:  table("project") + "type query {\n  project(filter: project_filter): [project]\n}\n",
+			pkg:  "my-client",
+		},
+		{
+			desc: "invalid SDL",
+			sdl:  "type query {",
+			pkg:  "client",
+		},
+		{
+			desc: "no query type",
+			sdl:  table("project"),
+			pkg:  "client",
+		},
+		{
+			desc: "no tables",
+			sdl:  table("project") + "type query {\n  project: project\n}\n",
+			pkg:  "client",
+		},
+		{
+			desc: "clashing names",
+			sdl: table("test_run") + table("test__run") +
+				"type query {\n" +
+				"  test_run(filter: test_run_filter): [test_run]\n" +
+				"  test__run(filter: test__run_filter): [test__run]\n" +
+				"}\n",
+			pkg: "client",
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.desc, func(t *testing.T) {
+			_, err := GenerateGo([]byte(tc.sdl), tc.pkg)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestGoName(t *testing.T) {
+	tcs := []struct {
+		name     string
+		expected string
+		plural   string
+	}{
+		{name: "test_run", expected: "TestRun", plural: "TestRuns"},
+		{name: "_id", expected: "ID", plural: "IDs"},
+		{name: "repo_url", expected: "RepoURL", plural: "RepoURLs"},
+		{name: "code_issue", expected: "CodeIssue", plural: "CodeIssues"},
+		{name: "branch", expected: "Branch", plural: "Branches"},
+		{name: "policy", expected: "Policy", plural: "Policies"},
+		{name: "release_entry", expected: "ReleaseEntry", plural: "ReleaseEntries"},
+		{name: "key", expected: "Key", plural: "Keys"},
+		{name: "2fa", expected: "X2fa", plural: "X2fas"},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, goName(tc.name))
+			assert.Equal(t, tc.plural, goPlural(goName(tc.name)))
+		})
+	}
+}
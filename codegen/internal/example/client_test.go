@@ -0,0 +1,150 @@
+package example
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockServer is a bubbly API server that replies to the GraphQL queries it
+// receives with a fixed response
+type mockServer struct {
+	t        *testing.T
+	response string
+	// queries are the queries received
+	queries []string
+}
+
+func (m *mockServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	assert.Equal(m.t, http.MethodPost, r.Method)
+	assert.Equal(m.t, "/api/v1/graphql", r.URL.Path)
+	assert.Equal(m.t, "token", r.Header.Get("Authorization"))
+	var req struct {
+		Query string `json:"query"`
+	}
+	require.NoError(m.t, json.NewDecoder(r.Body).Decode(&req))
+	m.queries = append(m.queries, req.Query)
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte(m.response))
+}
+
+func TestQuery(t *testing.T) {
+	tcs := []struct {
+		desc     string
+		query    func(c *Client) ([]TestRun, error)
+		response string
+		expected string
+		rows     []TestRun
+		wantErr  bool
+	}{
+		{
+			desc: "all rows",
+			query: func(c *Client) ([]TestRun, error) {
+				return c.TestRuns().Do(context.Background())
+			},
+			response: `{"data": {"test_run": [{"_id": "1", "name": "unit", "passed": true, "duration": 12}]}}`,
+			expected: `{ test_run { _id duration labels name passed status tags } }`,
+			rows:     []TestRun{{ID: "1", Name: "unit", Passed: true, Duration: 12}},
+		},
+		{
+			desc: "typed filters",
+			query: func(c *Client) ([]TestRun, error) {
+				return c.TestRuns().
+					Where(
+						TestRunName.In("unit", `say "hi"`),
+						TestRunDuration.Gt(5),
+						TestRunPassed.Eq(false),
+						TestRunLabels.IsNull(false),
+					).
+					OrderBy(TestRunDuration.Desc(), TestRunName.Asc()).
+					First(5).
+					Do(context.Background())
+			},
+			response: `{"data": {"test_run": [{"_id": "2", "name": "unit", "passed": false, "duration": 30, "labels": {"os": "linux"}}]}}`,
+			expected: `{ test_run(filter: {name_in: ["unit", "say \"hi\""], duration_gt: 5, passed_eq: false, labels_is_null: false}, ` +
+				`order_by: {duration: desc, name: asc}, first: 5) { _id duration labels name passed status tags } }`,
+			rows: []TestRun{{ID: "2", Name: "unit", Duration: 30, Labels: map[string]interface{}{"os": "linux"}}},
+		},
+		{
+			desc: "enum filters",
+			query: func(c *Client) ([]TestRun, error) {
+				return c.TestRuns().
+					Where(TestRunStatus.In("PASS", "SKIP"), TestRunStatus.IsNull(false)).
+					Do(context.Background())
+			},
+			response: `{"data": {"test_run": [{"_id": "6", "name": "lint", "status": "PASS"}]}}`,
+			expected: `{ test_run(filter: {status_in: [PASS, SKIP], status_is_null: false}) ` +
+				`{ _id duration labels name passed status tags } }`,
+			rows: []TestRun{{ID: "6", Name: "lint", Status: "PASS"}},
+		},
+		{
+			desc: "related table",
+			query: func(c *Client) ([]TestRun, error) {
+				return c.TestRuns().
+					Where(TestRunLabels.Eq(map[string]interface{}{"os": "linux", "arch": []string{"amd64"}})).
+					WithProject().
+					Last(1).
+					Do(context.Background())
+			},
+			response: `{"data": {"test_run": [{"_id": "3", "name": "e2e", "project": {"_id": "4", "name": "bubbly"}}]}}`,
+			expected: `{ test_run(filter: {labels_eq: {arch: ["amd64"], os: "linux"}}, last: 1) ` +
+				`{ _id duration labels name passed status tags project { _id name } } }`,
+			rows: []TestRun{{ID: "3", Name: "e2e", Project: &Project{ID: "4", Name: "bubbly"}}},
+		},
+		{
+			desc: "list filters",
+			query: func(c *Client) ([]TestRun, error) {
+				return c.TestRuns().
+					Where(TestRunTags.Contains("slow"), TestRunTags.Overlaps("linux", "darwin")).
+					Do(context.Background())
+			},
+			response: `{"data": {"test_run": [{"_id": "5", "name": "e2e", "tags": ["slow", "linux"]}]}}`,
+			expected: `{ test_run(filter: {tags_contains: ["slow"], tags_overlaps: ["linux", "darwin"]}) ` +
+				`{ _id duration labels name passed status tags } }`,
+			rows: []TestRun{{ID: "5", Name: "e2e", Tags: []string{"slow", "linux"}}},
+		},
+		{
+			desc: "errors",
+			query: func(c *Client) ([]TestRun, error) {
+				return c.TestRuns().Where(TestRunID.Eq("1")).Do(context.Background())
+			},
+			response: `{"data": null, "errors": [{"message": "no such table"}]}`,
+			expected: `{ test_run(filter: {_id_eq: "1"}) { _id duration labels name passed status tags } }`,
+			wantErr:  true,
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.desc, func(t *testing.T) {
+			m := &mockServer{t: t, response: tc.response}
+			server := httptest.NewServer(m)
+			defer server.Close()
+
+			c := NewClient(server.URL+"/api/v1/", WithAuthToken("token"))
+			rows, err := tc.query(c)
+			require.Len(t, m.queries, 1)
+			assert.Equal(t, tc.expected, m.queries[0])
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.rows, rows)
+		})
+	}
+}
+
+func TestQueryStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	_, err := NewClient(server.URL).Projects().Do(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "401")
+}
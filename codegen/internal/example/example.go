@@ -0,0 +1,6 @@
+// Package example is the Go client generated for the schema in
+// codegen/testdata/schema.graphql, which tests that the generated code
+// compiles and queries the tables
+package example
+
+//go:generate go run ../../../main.go gen go --schema ../../testdata/schema.graphql --package example -o client_gen.go
@@ -1,12 +1,28 @@
 package config
 
-import "fmt"
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
 
 // ServerConfig is a struct storing the server information.
 type ServerConfig struct {
 	Protocol string
 	Port     string
 	Host     string
+	// BindAddr is the address of the interface that the server listens on.
+	// Empty means all interfaces. Clients use Host to reach the server
+	BindAddr string
+	// ShutdownTimeout is the time in seconds that the server waits for
+	// in-flight requests to complete when shutting down
+	ShutdownTimeout int
+	// MaxRequestBodySize is the maximum size in bytes of the body of requests
+	// that upload resources, data or queries. 0 means no limit
+	MaxRequestBodySize int64
+	// CompressionMinSize is the minimum size in bytes of a response for it to
+	// be compressed, for clients that accept a compressed response
+	CompressionMinSize int
 }
 
 func (s ServerConfig) HostURL() string {
@@ -32,6 +48,18 @@ const (
 type StoreConfig struct {
 	Provider StoreProviderType
 
+	// DSN is the connection string to the database of the provider. If set,
+	// it is used instead of the discrete connection fields of the provider
+	// (address, user, password and database), which must then be left at
+	// their defaults
+	DSN string
+
+	// ApplicationName is the application_name of the connections to the
+	// database, which is shown in pg_stat_activity so that the connections
+	// of different instances of bubbly can be told apart. An application_name
+	// in the DSN takes precedence. An empty name is not set
+	ApplicationName string
+
 	PostgresAddr     string
 	PostgresUser     string
 	PostgresPassword string
@@ -44,6 +72,120 @@ type StoreConfig struct {
 
 	RetrySleep    int
 	RetryAttempts int
+
+	// StatementTimeout is the maximum time in milliseconds that any statement
+	// is allowed to run on the database before it is aborted by the database.
+	// A value of 0 disables the timeout
+	StatementTimeout int
+
+	// QueryCacheSize is the maximum number of query results that are cached
+	// by the store. Cached results are invalidated when data is saved to the
+	// tables they read, but only by the store that saves the data, so caching
+	// should only be enabled when there is a single store.
+	// A value of 0 disables caching
+	QueryCacheSize int
+	// QueryCacheTTL is the time in seconds for which a query result is cached.
+	// A value of 0 means results do not expire
+	QueryCacheTTL int
+
+	// QueryDefaultLimit is the maximum number of results returned for a table
+	// in a GraphQL query that does not have a `first` or `last` argument,
+	// unless there is a QueryDefaultPageSize.
+	// A value of 0 means no limit, unless there is a QueryMaxLimit or a
+	// QueryMaxPageSize
+	QueryDefaultLimit int
+	// QueryDefaultPageSize is the number of results returned for a table in a
+	// GraphQL query that does not have a `first` or `last` argument, instead
+	// of the QueryDefaultLimit. It cannot exceed the QueryMaxPageSize.
+	// A value of 0 means the QueryDefaultLimit is used
+	QueryDefaultPageSize int
+	// QueryMaxLimit is the maximum value of the `first` and `last` arguments
+	// in a GraphQL query, and the maximum number of rows that a query can
+	// return before it fails. A value of 0 means no maximum
+	QueryMaxLimit int
+	// QueryMaxPageSize is the maximum number of results returned for a table
+	// in a GraphQL query. A larger `first` or `last` argument is reduced to
+	// it, instead of failing, and the result notes that the table's page was
+	// reduced. It also caps the QueryDefaultPageSize and QueryDefaultLimit.
+	// A value of 0 means no maximum
+	QueryMaxPageSize int
+	// QueryMaxDepth is the maximum depth of the nested tables that are
+	// resolved in a GraphQL query, where the root tables have depth 0.
+	// The tables nested deeper are left out of the result, and the result
+	// says where it was truncated. A value of 0 means no maximum
+	QueryMaxDepth int
+
+	// QueryExplain enables returning the SQL, and optionally the query plan,
+	// of GraphQL queries when requested. It is meant for debugging and should
+	// be disabled in production, as it exposes the internals of the store
+	QueryExplain bool
+
+	// HealthCheckInterval is the time in seconds between checks that the
+	// connection to the database is alive, reconnecting if it is not.
+	// A value of 0 disables the checks
+	HealthCheckInterval int
+
+	// LogQueryArgs is whether the arguments of the SQL statements run by the
+	// store are logged. If false, the arguments are redacted
+	LogQueryArgs bool
+
+	// SoftDelete is whether deleting resources and data marks their rows as
+	// deleted, instead of removing them. The deleted rows are not returned by
+	// queries, unless they have the include_deleted argument. The tables only
+	// get the column that marks deleted rows when this is enabled
+	SoftDelete bool
+}
+
+// connFields are the discrete fields of a connection string to a database
+type connFields struct {
+	user     string
+	password string
+	addr     string
+	database string
+}
+
+// PostgresConnString returns the connection string to the Postgres database
+func (s StoreConfig) PostgresConnString() (string, error) {
+	return s.connString(
+		connFields{s.PostgresUser, s.PostgresPassword, s.PostgresAddr, s.PostgresDatabase},
+		connFields{DefaultPostgresUser, DefaultPostgresPassword, DefaultPostgresAddr, DefaultPostgresDatabase},
+	)
+}
+
+// CockroachConnString returns the connection string to the CockroachDB database
+func (s StoreConfig) CockroachConnString() (string, error) {
+	return s.connString(
+		connFields{s.CockroachUser, s.CockroachPassword, s.CockroachAddr, s.CockroachDatabase},
+		connFields{defaultCockroachUser, defaultCockroachPassword, defaultCockroachAddr, defaultCockroachDatabase},
+	)
+}
+
+// connString returns the DSN if it is set, and otherwise assembles the
+// connection string from the discrete fields. If the DSN is set, the discrete
+// fields must be empty or have their default values, as it would otherwise be
+// ambiguous which of the two should be used
+func (s StoreConfig) connString(fields, defaults connFields) (string, error) {
+	if s.DSN == "" {
+		return fmt.Sprintf(
+			"postgres://%s:%s@%s/%s",
+			fields.user,
+			fields.password,
+			fields.addr,
+			fields.database,
+		), nil
+	}
+	values := []struct{ name, value, defaultValue string }{
+		{"user", fields.user, defaults.user},
+		{"password", fields.password, defaults.password},
+		{"address", fields.addr, defaults.addr},
+		{"database", fields.database, defaults.database},
+	}
+	for _, v := range values {
+		if v.value != "" && v.value != v.defaultValue {
+			return "", fmt.Errorf("ambiguous store connection: both the DSN and the %s are set", v.name)
+		}
+	}
+	return s.DSN, nil
 }
 
 // ###########################################
@@ -54,6 +196,9 @@ type AgentDeploymentType string
 
 const (
 	SingleDeployment AgentDeploymentType = "single"
+	// StandaloneDeployment runs only the API server, which talks to an
+	// in-process data store directly instead of via NATS
+	StandaloneDeployment AgentDeploymentType = "standalone"
 	// TODO: Implement
 	// DistributedDeployment AgentDeploymentType = "distributed"
 )
@@ -62,6 +207,8 @@ func (a AgentDeploymentType) String() string {
 	switch a {
 	case SingleDeployment:
 		return "single"
+	case StandaloneDeployment:
+		return "standalone"
 	default:
 		return "unsupported"
 	}
@@ -72,6 +219,23 @@ type AgentConfig struct {
 	NATSServerConfig  *NATSServerConfig
 	EnabledComponents *AgentComponentsToggle
 	DeploymentType    AgentDeploymentType
+	// RequestTimeout is the time in milliseconds to wait for the reply to a
+	// NATS request, e.g. from the worker to the data store
+	RequestTimeout int
+	// WorkerMaxConcurrency is the maximum number of runs that the worker
+	// applies at the same time. Runs with a remote input directory are still
+	// applied one at a time
+	WorkerMaxConcurrency int
+}
+
+// NATSRequestTimeout returns the time to wait for the reply to a NATS
+// request. If the configured timeout is not positive, the default is used
+func (a *AgentConfig) NATSRequestTimeout() time.Duration {
+	timeout := a.RequestTimeout
+	if timeout <= 0 {
+		timeout, _ = strconv.Atoi(DefaultAgentRequestTimeout)
+	}
+	return time.Duration(timeout) * time.Millisecond
 }
 
 type AgentComponentsToggle struct {
@@ -119,12 +283,33 @@ type ClientConfig struct {
 	AuthToken  string
 	BubblyAddr string
 	NATSAddr   string
+	// Namespace is the namespace of the resources that are applied, fetched
+	// and deleted, unless another namespace is given
+	Namespace string
+	// SchemaCacheTTL is the time in seconds for which the HTTP client uses
+	// the GraphQL schema SDL that it has fetched, before checking with the
+	// API server whether the schema has changed
+	SchemaCacheTTL int
 }
 
 // ##########################
 // CLI
 // ##########################
 
+// LogFormat is the format in which bubbly writes its logs
+type LogFormat string
+
+const (
+	// ConsoleLogFormat writes the logs in a human readable format
+	ConsoleLogFormat LogFormat = "console"
+	// JSONLogFormat writes each log as a JSON object, e.g. for log ingestion
+	JSONLogFormat LogFormat = "json"
+)
+
 type CLIConfig struct {
-	Color bool
+	Color     bool
+	LogFormat LogFormat
+	// MaxOpenFiles is the maximum number of files that are read at the same
+	// time when parsing a directory of bubbly files
+	MaxOpenFiles int
 }
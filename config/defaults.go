@@ -9,6 +9,10 @@ import (
 const (
 	DefaultCLIColorToggle = true
 	DefaultDebugToggle    = false
+	DefaultLogFormat      = string(ConsoleLogFormat)
+	// DefaultCLIMaxOpenFiles bounds the files read at the same time, well
+	// below the common file descriptor limit of 1024
+	DefaultCLIMaxOpenFiles = "16"
 )
 
 // Default Bubbly API Server configuration
@@ -16,6 +20,14 @@ const (
 	DefaultAPIServerProtocol = "http"
 	DefaultAPIServerHost     = "127.0.0.1"
 	DefaultAPIServerPort     = "8111"
+	// DefaultAPIServerBindAddr listens on all interfaces
+	DefaultAPIServerBindAddr = ""
+	// DefaultAPIServerShutdownTimeout is in seconds
+	DefaultAPIServerShutdownTimeout = "10"
+	// DefaultAPIServerMaxRequestBodySize is in bytes (10 MiB)
+	DefaultAPIServerMaxRequestBodySize = "10485760"
+	// DefaultAPIServerCompressionMinSize is in bytes
+	DefaultAPIServerCompressionMinSize = "1024"
 )
 
 // Default store configuration
@@ -23,6 +35,33 @@ const (
 	DefaultStoreProvider = "postgres"
 	DefaultRetryAttempts = 5
 	DefaultRetrySleep    = 1
+	// DefaultStatementTimeout is in milliseconds
+	DefaultStatementTimeout = "30000"
+	// DefaultQueryCacheSize disables the query cache
+	DefaultQueryCacheSize = "0"
+	// DefaultQueryCacheTTL is in seconds
+	DefaultQueryCacheTTL = "60"
+	// DefaultQueryDefaultLimit is the number of results per table in a query
+	// without `first` or `last` arguments
+	DefaultQueryDefaultLimit = "100"
+	// DefaultQueryDefaultPageSize does not set a default page size, so the
+	// DefaultQueryDefaultLimit is used instead
+	DefaultQueryDefaultPageSize = "0"
+	// DefaultQueryMaxLimit is the maximum number of rows of a query
+	DefaultQueryMaxLimit = "10000"
+	// DefaultQueryMaxPageSize does not reduce the `first` and `last` of
+	// queries, which are only limited by the DefaultQueryMaxLimit
+	DefaultQueryMaxPageSize = "0"
+	// DefaultQueryMaxDepth does not limit the depth of nested tables
+	DefaultQueryMaxDepth = "0"
+	DefaultQueryExplain  = false
+	DefaultLogQueryArgs  = true
+	DefaultSoftDelete    = false
+	// DefaultHealthCheckInterval is in seconds
+	DefaultHealthCheckInterval = "10"
+	// DefaultApplicationName is the prefix of the application_name of the
+	// connections to the database, followed by the host name of the instance
+	DefaultApplicationName = "bubbly"
 )
 
 // Default store configuration for Postgres
@@ -54,6 +93,10 @@ const (
 	DefaultWorkerToggle     = false
 	DefaultNATSServerToggle = true
 	DefaultDeploymentType   = SingleDeployment
+	// DefaultAgentRequestTimeout is in milliseconds
+	DefaultAgentRequestTimeout = "2000"
+	// DefaultAgentWorkerMaxConcurrency of 1 applies the runs one at a time
+	DefaultAgentWorkerMaxConcurrency = "1"
 )
 
 // Default configuration for the bubbly client config
@@ -61,8 +104,24 @@ const (
 	DefaultClientAuthToken = ""
 	DefaultBubblyAddr      = "http://localhost:8111/api/v1"
 	DefaultNATSAddr        = "localhost:4223"
+	// DefaultNamespace is the namespace of the resources that are not given
+	// one
+	DefaultNamespace = "default"
+	// DefaultSchemaCacheTTL is in seconds
+	DefaultSchemaCacheTTL = "60"
 )
 
+// defaultApplicationName returns the application_name of the connections to
+// the database, which identifies this instance of bubbly by its host name,
+// e.g. the name of the pod in Kubernetes
+func defaultApplicationName() string {
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		return DefaultApplicationName
+	}
+	return DefaultApplicationName + "-" + hostname
+}
+
 func defaultEnv(key, defaultValue string) string {
 	if value, ok := os.LookupEnv(key); ok {
 		return value
@@ -73,19 +132,72 @@ func defaultEnv(key, defaultValue string) string {
 // DefaultServerConfig creates a ServerConfig struct from defaults
 // or, preferentially, from provided environment variables.
 func DefaultServerConfig() *ServerConfig {
+	shutdownTimeout, _ := strconv.Atoi(
+		defaultEnv("BUBBLY_SHUTDOWN_TIMEOUT", DefaultAPIServerShutdownTimeout),
+	)
+	maxRequestBodySize, _ := strconv.ParseInt(
+		defaultEnv("BUBBLY_MAX_REQUEST_BODY_SIZE", DefaultAPIServerMaxRequestBodySize), 10, 64,
+	)
+	compressionMinSize, _ := strconv.Atoi(
+		defaultEnv("BUBBLY_COMPRESSION_MIN_SIZE", DefaultAPIServerCompressionMinSize),
+	)
 	return &ServerConfig{
-		Protocol: defaultEnv("BUBBLY_PROTOCOL", DefaultAPIServerProtocol),
-		Host:     defaultEnv("BUBBLY_HOST", DefaultAPIServerHost),
-		Port:     defaultEnv("BUBBLY_PORT", DefaultAPIServerPort),
+		Protocol:           defaultEnv("BUBBLY_PROTOCOL", DefaultAPIServerProtocol),
+		Host:               defaultEnv("BUBBLY_HOST", DefaultAPIServerHost),
+		Port:               defaultEnv("BUBBLY_PORT", DefaultAPIServerPort),
+		BindAddr:           defaultEnv("BUBBLY_BIND_ADDR", DefaultAPIServerBindAddr),
+		ShutdownTimeout:    shutdownTimeout,
+		MaxRequestBodySize: maxRequestBodySize,
+		CompressionMinSize: compressionMinSize,
 	}
 }
 
 // DefaultStoreConfig creates a StoreConfig struct from defaults
 // or, preferentially, from provided environment variables.
 func DefaultStoreConfig() *StoreConfig {
+	statementTimeout, _ := strconv.Atoi(
+		defaultEnv("BUBBLY_STORE_STATEMENT_TIMEOUT", DefaultStatementTimeout),
+	)
+	queryCacheSize, _ := strconv.Atoi(
+		defaultEnv("BUBBLY_STORE_QUERY_CACHE_SIZE", DefaultQueryCacheSize),
+	)
+	queryCacheTTL, _ := strconv.Atoi(
+		defaultEnv("BUBBLY_STORE_QUERY_CACHE_TTL", DefaultQueryCacheTTL),
+	)
+	queryDefaultLimit, _ := strconv.Atoi(
+		defaultEnv("BUBBLY_STORE_QUERY_DEFAULT_LIMIT", DefaultQueryDefaultLimit),
+	)
+	queryDefaultPageSize, _ := strconv.Atoi(
+		defaultEnv("BUBBLY_STORE_QUERY_DEFAULT_PAGE_SIZE", DefaultQueryDefaultPageSize),
+	)
+	queryMaxLimit, _ := strconv.Atoi(
+		defaultEnv("BUBBLY_STORE_QUERY_MAX_LIMIT", DefaultQueryMaxLimit),
+	)
+	queryMaxPageSize, _ := strconv.Atoi(
+		defaultEnv("BUBBLY_STORE_QUERY_MAX_PAGE_SIZE", DefaultQueryMaxPageSize),
+	)
+	queryMaxDepth, _ := strconv.Atoi(
+		defaultEnv("BUBBLY_STORE_QUERY_MAX_DEPTH", DefaultQueryMaxDepth),
+	)
+	queryExplain, _ := strconv.ParseBool(
+		defaultEnv("BUBBLY_STORE_QUERY_EXPLAIN", strconv.FormatBool(DefaultQueryExplain)),
+	)
+	healthCheckInterval, _ := strconv.Atoi(
+		defaultEnv("BUBBLY_STORE_HEALTH_CHECK_INTERVAL", DefaultHealthCheckInterval),
+	)
+	logQueryArgs, _ := strconv.ParseBool(
+		defaultEnv("BUBBLY_STORE_LOG_QUERY_ARGS", strconv.FormatBool(DefaultLogQueryArgs)),
+	)
+	softDelete, _ := strconv.ParseBool(
+		defaultEnv("BUBBLY_STORE_SOFT_DELETE", strconv.FormatBool(DefaultSoftDelete)),
+	)
 	return &StoreConfig{
 		// Default provider
 		Provider: StoreProviderType(defaultEnv("BUBBLY_STORE_PROVIDER", DefaultStoreProvider)),
+		// A DSN, instead of the discrete connection fields of the provider
+		DSN: defaultEnv("BUBBLY_STORE_DSN", ""),
+		// The name of the connections, to tell the instances apart
+		ApplicationName: defaultEnv("BUBBLY_STORE_APPLICATION_NAME", defaultApplicationName()),
 		// Default configuration for Postgres
 		PostgresAddr:     defaultEnv("POSTGRES_ADDR", DefaultPostgresAddr),
 		PostgresUser:     defaultEnv("POSTGRES_USER", DefaultPostgresUser),
@@ -100,6 +212,23 @@ func DefaultStoreConfig() *StoreConfig {
 		// Default retry configs, so retry every 1 second up to 5 times
 		RetrySleep:    DefaultRetrySleep,
 		RetryAttempts: DefaultRetryAttempts,
+
+		StatementTimeout: statementTimeout,
+
+		QueryCacheSize: queryCacheSize,
+		QueryCacheTTL:  queryCacheTTL,
+
+		QueryDefaultLimit:    queryDefaultLimit,
+		QueryDefaultPageSize: queryDefaultPageSize,
+		QueryMaxLimit:        queryMaxLimit,
+		QueryMaxPageSize:     queryMaxPageSize,
+		QueryMaxDepth:        queryMaxDepth,
+
+		QueryExplain: queryExplain,
+		LogQueryArgs: logQueryArgs,
+		SoftDelete:   softDelete,
+
+		HealthCheckInterval: healthCheckInterval,
 	}
 }
 
@@ -110,10 +239,18 @@ func DefaultStoreConfig() *StoreConfig {
 // DefaultAgentConfig creates an AgentConfig struct from defaults
 // or, preferentially, from provided environment variables.
 func DefaultAgentConfig() *AgentConfig {
+	requestTimeout, _ := strconv.Atoi(
+		defaultEnv("AGENT_REQUEST_TIMEOUT", DefaultAgentRequestTimeout),
+	)
+	workerMaxConcurrency, _ := strconv.Atoi(
+		defaultEnv("AGENT_WORKER_MAX_CONCURRENCY", DefaultAgentWorkerMaxConcurrency),
+	)
 	return &AgentConfig{
-		NATSServerConfig:  DefaultNATSServerConfig(),
-		EnabledComponents: DefaultAgentComponentsEnabled(),
-		DeploymentType:    AgentDeploymentType(defaultEnv("AGENT_DEPLOYMENT_TYPE", DefaultDeploymentType.String())),
+		NATSServerConfig:     DefaultNATSServerConfig(),
+		EnabledComponents:    DefaultAgentComponentsEnabled(),
+		DeploymentType:       AgentDeploymentType(defaultEnv("AGENT_DEPLOYMENT_TYPE", DefaultDeploymentType.String())),
+		RequestTimeout:       requestTimeout,
+		WorkerMaxConcurrency: workerMaxConcurrency,
 	}
 }
 
@@ -170,11 +307,16 @@ func DefaultNATSServerConfig() *NATSServerConfig {
 // ###########################################
 
 func DefaultClientConfig() *ClientConfig {
+	schemaCacheTTL, _ := strconv.Atoi(
+		defaultEnv("BUBBLY_SCHEMA_CACHE_TTL", DefaultSchemaCacheTTL),
+	)
 	return &ClientConfig{
-		ClientType: HTTPClientType,
-		AuthToken:  defaultEnv("BUBBLY_TOKEN", DefaultClientAuthToken),
-		BubblyAddr: defaultEnv("BUBBLY_ADDR", DefaultBubblyAddr),
-		NATSAddr:   defaultEnv("BUBBLY_NATS_ADDR", DefaultNATSAddr),
+		ClientType:     HTTPClientType,
+		AuthToken:      defaultEnv("BUBBLY_TOKEN", DefaultClientAuthToken),
+		BubblyAddr:     defaultEnv("BUBBLY_ADDR", DefaultBubblyAddr),
+		NATSAddr:       defaultEnv("BUBBLY_NATS_ADDR", DefaultNATSAddr),
+		Namespace:      defaultEnv("BUBBLY_NAMESPACE", DefaultNamespace),
+		SchemaCacheTTL: schemaCacheTTL,
 	}
 }
 
@@ -184,7 +326,10 @@ func DefaultClientConfig() *ClientConfig {
 
 func DefaultCLIConfig() *CLIConfig {
 	color, _ := strconv.ParseBool(defaultEnv("COLOR", strconv.FormatBool(DefaultCLIColorToggle)))
+	maxOpenFiles, _ := strconv.Atoi(defaultEnv("MAX_OPEN_FILES", DefaultCLIMaxOpenFiles))
 	return &CLIConfig{
-		Color: color,
+		Color:        color,
+		LogFormat:    LogFormat(defaultEnv("LOG_FORMAT", DefaultLogFormat)),
+		MaxOpenFiles: maxOpenFiles,
 	}
 }
@@ -12,7 +12,788 @@ import (
 	"github.com/swaggo/swag"
 )
 
-var doc = ``
+var doc = `{
+	"schemes": {{ marshal .Schemes }},
+	"swagger": "2.0",
+	"info": {
+		"description": "{{.Description}}",
+		"title": "{{.Title}}",
+		"termsOfService": "https://bubbly.dev/terms/",
+		"contact": {
+			"name": "API Support",
+			"url": "https://github.com/valocode/bubbly/issues",
+			"email": "info@bubbly.dev"
+		},
+		"license": {
+			"name": "Mozilla Public License Version 2.0",
+			"url": "https://www.mozilla.org/en-US/MPL/2.0/"
+		},
+		"version": "{{.Version}}"
+	},
+	"host": "{{.Host}}",
+	"basePath": "{{.BasePath}}",
+	"paths": {
+		"/authorize": {
+			"get": {
+				"consumes": [
+					"application/json"
+				],
+				"produces": [
+					"application/json"
+				],
+				"tags": [
+					"authorize"
+				],
+				"summary": "Authorizes the request against the bubbly auth service",
+				"operationId": "authorize",
+				"responses": {
+					"200": {
+						"description": "OK",
+						"schema": {
+							"$ref": "#/definitions/component.MessageAuth"
+						}
+					},
+					"403": {
+						"description": "Forbidden",
+						"schema": {
+							"$ref": "#/definitions/server.HTTPError"
+						}
+					}
+				}
+			}
+		},
+		"/graphql": {
+			"post": {
+				"consumes": [
+					"application/json"
+				],
+				"produces": [
+					"application/json"
+				],
+				"tags": [
+					"graphql"
+				],
+				"summary": "Query performs graphql related tasks",
+				"operationId": "graphql",
+				"parameters": [
+					{
+						"description": "Query String",
+						"name": "query",
+						"in": "body",
+						"required": true,
+						"schema": {
+							"$ref": "#/definitions/server.queryReq"
+						}
+					},
+					{
+						"enum": [
+							"true",
+							"analyze"
+						],
+						"type": "string",
+						"description": "Return the SQL of the query (true), and also its plan (analyze), in the extensions of the result. Requires explain to be enabled in the store",
+						"name": "explain",
+						"in": "query"
+					},
+					{
+						"type": "boolean",
+						"description": "Return the fields of the objects in the data in the order that they are selected in the query, instead of sorted by name",
+						"name": "ordered",
+						"in": "query"
+					}
+				],
+				"responses": {
+					"200": {
+						"description": "OK",
+						"schema": {
+							"type": "object"
+						}
+					},
+					"400": {
+						"description": "Bad Request",
+						"schema": {
+							"$ref": "#/definitions/server.HTTPError"
+						}
+					},
+					"503": {
+						"description": "Service Unavailable",
+						"schema": {
+							"$ref": "#/definitions/server.HTTPError"
+						}
+					}
+				}
+			}
+		},
+		"/graphql/schema.graphql": {
+			"get": {
+				"description": "The response has an ETag of the SDL, and a request with an\nIf-None-Match header of the same ETag gets a 304 response\nwithout a body, so that clients can cache the SDL",
+				"produces": [
+					"text/plain"
+				],
+				"tags": [
+					"graphql"
+				],
+				"summary": "GetSchemaSDL returns the GraphQL schema as SDL",
+				"operationId": "schema-sdl",
+				"parameters": [
+					{
+						"type": "string",
+						"description": "ETag of the SDL that the client has",
+						"name": "If-None-Match",
+						"in": "header"
+					}
+				],
+				"responses": {
+					"200": {
+						"description": "OK",
+						"schema": {
+							"type": "string"
+						}
+					},
+					"304": {
+						"description": "Not Modified",
+						"schema": {
+							"type": "string"
+						}
+					},
+					"500": {
+						"description": "Internal Server Error",
+						"schema": {
+							"$ref": "#/definitions/server.HTTPError"
+						}
+					}
+				}
+			}
+		},
+		"/resource": {
+			"post": {
+				"description": "ATM this will only accept one resource per request",
+				"consumes": [
+					"application/json"
+				],
+				"produces": [
+					"application/json"
+				],
+				"tags": [
+					"resource"
+				],
+				"summary": "Takes a POST request to upload a new resource to the in memory database",
+				"operationId": "Post-resource",
+				"parameters": [
+					{
+						"description": "Resource Body",
+						"name": "resource",
+						"in": "body",
+						"required": true,
+						"schema": {
+							"$ref": "#/definitions/core.ResourceBlock"
+						}
+					}
+				],
+				"responses": {
+					"200": {
+						"description": "OK",
+						"schema": {
+							"$ref": "#/definitions/server.Status"
+						}
+					},
+					"400": {
+						"description": "Bad Request",
+						"schema": {
+							"$ref": "#/definitions/server.HTTPError"
+						}
+					},
+					"422": {
+						"description": "Unprocessable Entity",
+						"schema": {
+							"$ref": "#/definitions/server.HTTPError"
+						}
+					}
+				}
+			}
+		},
+		"/resource/{kind}/{name}": {
+			"get": {
+				"description": "Will fetch a resource based on the given kind and name. A resource in a namespace other than the default namespace is at /resource/{namespace}/{kind}/{name}",
+				"consumes": [
+					"application/json"
+				],
+				"produces": [
+					"application/json",
+					"application/x-yaml"
+				],
+				"tags": [
+					"resource"
+				],
+				"summary": "GetResource Fetches a resource via GET",
+				"operationId": "Get-resource",
+				"parameters": [
+					{
+						"type": "string",
+						"description": "Resource Kind",
+						"name": "kind",
+						"in": "path",
+						"required": true
+					},
+					{
+						"type": "string",
+						"description": "Resource Name",
+						"name": "name",
+						"in": "path",
+						"required": true
+					}
+				],
+				"responses": {
+					"200": {
+						"description": "OK",
+						"schema": {
+							"$ref": "#/definitions/core.ResourceBlock"
+						}
+					},
+					"400": {
+						"description": "Bad Request",
+						"schema": {
+							"$ref": "#/definitions/server.HTTPError"
+						}
+					},
+					"500": {
+						"description": "Internal Server Error",
+						"schema": {
+							"$ref": "#/definitions/server.HTTPError"
+						}
+					}
+				}
+			},
+			"delete": {
+				"description": "Will delete a resource, and its events, based on the given kind and name. A resource in a namespace other than the default namespace is at /resource/{namespace}/{kind}/{name}",
+				"produces": [
+					"application/json"
+				],
+				"tags": [
+					"resource"
+				],
+				"summary": "DeleteResource deletes a resource via DELETE",
+				"operationId": "Delete-resource",
+				"parameters": [
+					{
+						"type": "string",
+						"description": "Resource Kind",
+						"name": "kind",
+						"in": "path",
+						"required": true
+					},
+					{
+						"type": "string",
+						"description": "Resource Name",
+						"name": "name",
+						"in": "path",
+						"required": true
+					}
+				],
+				"responses": {
+					"200": {
+						"description": "OK",
+						"schema": {
+							"$ref": "#/definitions/server.Status"
+						}
+					},
+					"400": {
+						"description": "Bad Request",
+						"schema": {
+							"$ref": "#/definitions/server.HTTPError"
+						}
+					},
+					"404": {
+						"description": "Not Found",
+						"schema": {
+							"$ref": "#/definitions/server.HTTPError"
+						}
+					}
+				}
+			},
+			"patch": {
+				"description": "Will apply a JSON Merge Patch (RFC 7386) to the resource with the given kind and name. A resource in a namespace other than the default namespace is at /resource/{namespace}/{kind}/{name}.\nThe spec of a resource is a string, and is therefore replaced as a whole",
+				"consumes": [
+					"application/json"
+				],
+				"produces": [
+					"application/json"
+				],
+				"tags": [
+					"resource"
+				],
+				"summary": "PatchResource updates part of a resource via PATCH",
+				"operationId": "Patch-resource",
+				"parameters": [
+					{
+						"type": "string",
+						"description": "Resource Kind",
+						"name": "kind",
+						"in": "path",
+						"required": true
+					},
+					{
+						"type": "string",
+						"description": "Resource Name",
+						"name": "name",
+						"in": "path",
+						"required": true
+					},
+					{
+						"description": "JSON Merge Patch",
+						"name": "patch",
+						"in": "body",
+						"required": true,
+						"schema": {
+							"type": "object"
+						}
+					}
+				],
+				"responses": {
+					"200": {
+						"description": "OK",
+						"schema": {
+							"$ref": "#/definitions/server.Status"
+						}
+					},
+					"400": {
+						"description": "Bad Request",
+						"schema": {
+							"$ref": "#/definitions/server.HTTPError"
+						}
+					},
+					"404": {
+						"description": "Not Found",
+						"schema": {
+							"$ref": "#/definitions/server.HTTPError"
+						}
+					},
+					"422": {
+						"description": "Unprocessable Entity",
+						"schema": {
+							"$ref": "#/definitions/server.HTTPError"
+						}
+					}
+				}
+			}
+		},
+		"/resources": {
+			"get": {
+				"description": "Will fetch the resources of the given kind in a namespace, ordered by name. The namespace is the default namespace unless one is given",
+				"produces": [
+					"application/json"
+				],
+				"tags": [
+					"resource"
+				],
+				"summary": "GetResourcesByKind fetches the resources of a kind via GET",
+				"operationId": "Get-resources-by-kind",
+				"parameters": [
+					{
+						"type": "string",
+						"description": "Resource Kind",
+						"name": "kind",
+						"in": "query",
+						"required": true
+					},
+					{
+						"type": "string",
+						"description": "Resource Namespace",
+						"name": "namespace",
+						"in": "query"
+					}
+				],
+				"responses": {
+					"200": {
+						"description": "OK",
+						"schema": {
+							"type": "array",
+							"items": {
+								"$ref": "#/definitions/core.ResourceBlock"
+							}
+						}
+					},
+					"400": {
+						"description": "Bad Request",
+						"schema": {
+							"$ref": "#/definitions/server.HTTPError"
+						}
+					}
+				}
+			},
+			"post": {
+				"description": "The resources are saved together, so if any of them cannot be saved then none of them are",
+				"consumes": [
+					"application/json"
+				],
+				"produces": [
+					"application/json"
+				],
+				"tags": [
+					"resource"
+				],
+				"summary": "Takes a POST request to upload a list of resources, which are either all saved or none are",
+				"operationId": "Post-resources",
+				"parameters": [
+					{
+						"description": "Resources Body",
+						"name": "resources",
+						"in": "body",
+						"required": true,
+						"schema": {
+							"type": "array",
+							"items": {
+								"$ref": "#/definitions/core.ResourceBlock"
+							}
+						}
+					}
+				],
+				"responses": {
+					"200": {
+						"description": "OK",
+						"schema": {
+							"$ref": "#/definitions/server.Status"
+						}
+					},
+					"400": {
+						"description": "Bad Request",
+						"schema": {
+							"$ref": "#/definitions/server.HTTPError"
+						}
+					},
+					"422": {
+						"description": "Unprocessable Entity",
+						"schema": {
+							"$ref": "#/definitions/server.HTTPError"
+						}
+					}
+				}
+			}
+		},
+		"/run/{name}": {
+			"post": {
+				"description": "Will run the ` + "`" + `run` + "`" + ` resource specified by the provided name parameter.\nAny inputs required by the resource should be provided within the POST request.",
+				"consumes": [
+					"multipart/form-data"
+				],
+				"produces": [
+					"application/json"
+				],
+				"tags": [
+					"resource,run"
+				],
+				"summary": "Takes a POST request to run a named ` + "`" + `run` + "`" + ` resource, using content\nprovided by a multipart form in the run if provided",
+				"operationId": "Run-resource",
+				"parameters": [
+					{
+						"type": "string",
+						"description": "Run Resource Name",
+						"name": "name",
+						"in": "path",
+						"required": true
+					}
+				],
+				"responses": {
+					"200": {
+						"description": "OK",
+						"schema": {
+							"type": "string"
+						}
+					},
+					"400": {
+						"description": "Bad Request",
+						"schema": {
+							"$ref": "#/definitions/server.HTTPError"
+						}
+					},
+					"415": {
+						"description": "Unsupported Media Type",
+						"schema": {
+							"$ref": "#/definitions/server.HTTPError"
+						}
+					}
+				}
+			}
+		},
+		"/schema": {
+			"get": {
+				"description": "The tables are returned by name, including the builtin tables. If no schema has been applied there are no tables",
+				"produces": [
+					"application/json"
+				],
+				"tags": [
+					"schema"
+				],
+				"summary": "GetSchema returns the tables of the current schema",
+				"operationId": "get-schema",
+				"responses": {
+					"200": {
+						"description": "OK",
+						"schema": {
+							"type": "object"
+						}
+					},
+					"500": {
+						"description": "Internal Server Error",
+						"schema": {
+							"$ref": "#/definitions/server.HTTPError"
+						}
+					}
+				}
+			},
+			"post": {
+				"consumes": [
+					"application/json"
+				],
+				"produces": [
+					"application/json"
+				],
+				"tags": [
+					"schema"
+				],
+				"summary": "PostSchema uploads the schema for bubbly",
+				"operationId": "schema",
+				"parameters": [
+					{
+						"description": "Schema Tables",
+						"name": "schema",
+						"in": "body",
+						"required": true,
+						"schema": {
+							"type": "object"
+						}
+					}
+				],
+				"responses": {
+					"200": {
+						"description": "OK",
+						"schema": {
+							"$ref": "#/definitions/server.Status"
+						}
+					},
+					"400": {
+						"description": "Bad Request",
+						"schema": {
+							"$ref": "#/definitions/server.HTTPError"
+						}
+					}
+				}
+			}
+		},
+		"/status": {
+			"get": {
+				"produces": [
+					"application/json"
+				],
+				"tags": [
+					"status"
+				],
+				"summary": "Returns the status of the data store, such as the number of tables in the schema",
+				"operationId": "status",
+				"responses": {
+					"200": {
+						"description": "OK",
+						"schema": {
+							"$ref": "#/definitions/server.StoreStatus"
+						}
+					},
+					"500": {
+						"description": "Internal Server Error",
+						"schema": {
+							"$ref": "#/definitions/server.HTTPError"
+						}
+					}
+				}
+			}
+		},
+		"/upload": {
+			"post": {
+				"description": "The data blocks are validated against the current schema before they are saved.\nIf any table, field or join does not exist in the schema, or a value is not of its field's type, nothing is saved and the details of the error list each invalid field",
+				"consumes": [
+					"application/json"
+				],
+				"produces": [
+					"application/json"
+				],
+				"tags": [
+					"datablocks"
+				],
+				"summary": "This function will upload core.DataBlocks",
+				"operationId": "upload data",
+				"parameters": [
+					{
+						"description": "Datablocks",
+						"name": "data",
+						"in": "body",
+						"required": true,
+						"schema": {
+							"type": "object"
+						}
+					},
+					{
+						"type": "string",
+						"description": "The ID of the data, with which data with the same ID is saved only once, so that the upload can be retried",
+						"name": "X-Bubbly-Save-ID",
+						"in": "header"
+					}
+				],
+				"responses": {
+					"200": {
+						"description": "OK",
+						"schema": {
+							"$ref": "#/definitions/server.Status"
+						}
+					},
+					"400": {
+						"description": "Bad Request",
+						"schema": {
+							"$ref": "#/definitions/server.HTTPError"
+						}
+					},
+					"422": {
+						"description": "Unprocessable Entity",
+						"schema": {
+							"$ref": "#/definitions/server.HTTPError"
+						}
+					}
+				}
+			}
+		},
+		"/version": {
+			"get": {
+				"produces": [
+					"application/json"
+				],
+				"tags": [
+					"version"
+				],
+				"summary": "Returns the version of the bubbly server",
+				"operationId": "version",
+				"responses": {
+					"200": {
+						"description": "OK",
+						"schema": {
+							"$ref": "#/definitions/server.Version"
+						}
+					}
+				}
+			}
+		}
+	},
+	"definitions": {
+		"component.MessageAuth": {
+			"type": "object",
+			"properties": {
+				"organization": {
+					"type": "string"
+				},
+				"role": {
+					"type": "string"
+				},
+				"user_id": {
+					"type": "string"
+				}
+			}
+		},
+		"core.Metadata": {
+			"type": "object",
+			"properties": {
+				"labels": {
+					"type": "object",
+					"additionalProperties": {
+						"type": "string"
+					}
+				}
+			}
+		},
+		"core.ResourceBlock": {
+			"type": "object",
+			"properties": {
+				"api_version": {
+					"type": "string"
+				},
+				"kind": {
+					"type": "string"
+				},
+				"metadata": {
+					"$ref": "#/definitions/core.Metadata"
+				},
+				"name": {
+					"type": "string"
+				},
+				"spec": {
+					"type": "string"
+				}
+			}
+		},
+		"server.HTTPError": {
+			"type": "object",
+			"properties": {
+				"error": {
+					"$ref": "#/definitions/server.HTTPErrorBody"
+				}
+			}
+		},
+		"server.HTTPErrorBody": {
+			"type": "object",
+			"properties": {
+				"code": {
+					"type": "string",
+					"example": "bad_request"
+				},
+				"details": {
+					"type": "array",
+					"items": {
+						"type": "string"
+					}
+				},
+				"message": {
+					"type": "string",
+					"example": "error message"
+				}
+			}
+		},
+		"server.Status": {
+			"type": "object",
+			"properties": {
+				"status": {
+					"type": "string"
+				}
+			}
+		},
+		"server.StoreStatus": {
+			"type": "object",
+			"properties": {
+				"provider": {
+					"type": "string",
+					"example": "postgres"
+				},
+				"schema_updated_at": {
+					"type": "string"
+				},
+				"tables": {
+					"type": "integer"
+				}
+			}
+		},
+		"server.Version": {
+			"type": "object",
+			"properties": {
+				"version": {
+					"type": "string"
+				}
+			}
+		},
+		"server.queryReq": {
+			"type": "object",
+			"properties": {
+				"query": {
+					"type": "string"
+				}
+			}
+		}
+	}
+}`
 
 type swaggerInfo struct {
 	Version     string
@@ -38,9 +38,17 @@ module.exports = {
         'cli/bubbly',
         'cli/bubbly-agent',
         'cli/bubbly-apply',
+        'cli/bubbly-delete',
+        'cli/bubbly-extract',
+        'cli/extract/bubbly-extract-infer-format',
+        'cli/bubbly-gen',
+        'cli/gen/bubbly-gen-go',
         'cli/bubbly-get',
         'cli/bubbly-schema',
         'cli/schema/bubbly-schema-apply',
+        'cli/schema/bubbly-schema-export',
+        'cli/bubbly-server',
+        'cli/bubbly-validate',
       ],
     },
     {
@@ -2,6 +2,7 @@ package env
 
 import (
 	"fmt"
+	"io"
 	"os"
 
 	"github.com/rs/zerolog"
@@ -40,13 +41,31 @@ func NewBubblyContext() *BubblyContext {
 
 // NewDefaultLogger sets up a default logger
 func NewDefaultLogger() *zerolog.Logger {
-	// Initialize Logger
-	logger := zerolog.New(zerolog.ConsoleWriter{
-		Out:     os.Stderr,
-		NoColor: false,
-	}).With().Timestamp().Logger().Level(zerolog.InfoLevel)
+	// The console format is always valid
+	logger, _ := NewLogger(config.ConsoleLogFormat, os.Stderr)
+	return logger
+}
+
+// NewLogger sets up a logger that writes to w in the given format, with the
+// info log level
+func NewLogger(format config.LogFormat, w io.Writer) (*zerolog.Logger, error) {
+	var writer io.Writer
+	switch format {
+	case config.ConsoleLogFormat:
+		writer = zerolog.ConsoleWriter{
+			Out:     w,
+			NoColor: false,
+		}
+	case config.JSONLogFormat:
+		// zerolog writes JSON, unless it is given a ConsoleWriter
+		writer = w
+	default:
+		return nil, fmt.Errorf("invalid log format %q: must be one of %s, %s",
+			format, config.ConsoleLogFormat, config.JSONLogFormat)
+	}
+	logger := zerolog.New(writer).With().Timestamp().Logger().Level(zerolog.InfoLevel)
 
-	return &logger
+	return &logger, nil
 }
 
 // GetServerConfig is a convenience method to extract the bubbly server
@@ -67,3 +86,18 @@ func (bCtx *BubblyContext) UpdateLogLevel(level zerolog.Level) error {
 
 	return nil
 }
+
+// UpdateLogFormat is a convenience method for updating the format of the
+// zerolog.Logger managed by a BubblyContext instance. The logs are written to
+// stderr, and the log level is kept
+func (bCtx *BubblyContext) UpdateLogFormat(format config.LogFormat) error {
+	logger, err := NewLogger(format, os.Stderr)
+	if err != nil {
+		return err
+	}
+	leveled := logger.Level(bCtx.Logger.GetLevel())
+	bCtx.Logger = &leveled
+	bCtx.CLIConfig.LogFormat = format
+
+	return nil
+}
@@ -0,0 +1,80 @@
+package env
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/valocode/bubbly/config"
+)
+
+func TestNewLogger(t *testing.T) {
+	tcs := []struct {
+		desc    string
+		format  config.LogFormat
+		json    bool
+		wantErr bool
+	}{
+		{
+			desc:   "json",
+			format: config.JSONLogFormat,
+			json:   true,
+		},
+		{
+			desc:   "console",
+			format: config.ConsoleLogFormat,
+		},
+		{
+			desc:    "invalid format",
+			format:  "yaml",
+			wantErr: true,
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.desc, func(t *testing.T) {
+			var out bytes.Buffer
+			logger, err := NewLogger(tc.format, &out)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, zerolog.InfoLevel, logger.GetLevel())
+
+			logger.Info().Str("resource", "extract/junit").Msg("applying resource")
+			if !tc.json {
+				// The console writer writes a line of text, e.g.
+				// 10:00AM INF applying resource resource=extract/junit
+				assert.False(t, json.Valid(out.Bytes()), out.String())
+				assert.Contains(t, out.String(), "INF")
+				assert.Contains(t, out.String(), "applying resource")
+				assert.Contains(t, out.String(), "extract/junit")
+				return
+			}
+			var entry map[string]interface{}
+			require.NoError(t, json.Unmarshal(out.Bytes(), &entry), out.String())
+			assert.Equal(t, "info", entry["level"])
+			assert.Equal(t, "applying resource", entry["message"])
+			assert.Equal(t, "extract/junit", entry["resource"])
+			assert.Contains(t, entry, "time")
+		})
+	}
+}
+
+func TestUpdateLogFormat(t *testing.T) {
+	bCtx := NewBubblyContext()
+	require.NoError(t, bCtx.UpdateLogLevel(zerolog.DebugLevel))
+
+	require.NoError(t, bCtx.UpdateLogFormat(config.JSONLogFormat))
+	assert.Equal(t, config.JSONLogFormat, bCtx.CLIConfig.LogFormat)
+	// The log level is kept
+	assert.Equal(t, zerolog.DebugLevel, bCtx.Logger.GetLevel())
+
+	logger := bCtx.Logger
+	assert.Error(t, bCtx.UpdateLogFormat("yaml"))
+	assert.Same(t, logger, bCtx.Logger)
+}
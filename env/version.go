@@ -0,0 +1,9 @@
+package env
+
+// DevVersion is the version of bubbly when it is not built for a release
+const DevVersion = "dev"
+
+// Version is the version of bubbly, which is set when building a release with
+//
+//	-ldflags "-X github.com/valocode/bubbly/env.Version=<version>"
+var Version = DevVersion
@@ -26,6 +26,7 @@ require (
 	github.com/hashicorp/hcl/v2 v2.10.0
 	github.com/hashicorp/terraform v0.15.3
 	github.com/imdario/mergo v0.3.11
+	github.com/jackc/pgconn v1.8.0
 	github.com/jackc/pgx/v4 v4.10.1
 	github.com/labstack/echo/v4 v4.2.1
 	github.com/lib/pq v1.9.0 // indirect
@@ -51,6 +52,7 @@ require (
 	golang.org/x/text v0.3.6 // indirect
 	golang.org/x/tools v0.1.0 // indirect
 	gopkg.in/h2non/gock.v1 v1.0.16
+	gopkg.in/yaml.v2 v2.4.0
 )
 
 replace github.com/hashicorp/hcl/v2 => github.com/verifa/hcl/v2 v2.8.1-patch-1
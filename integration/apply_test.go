@@ -44,7 +44,7 @@ func TestApply(t *testing.T) {
 		bCtx := env.NewBubblyContext()
 		bCtx.UpdateLogLevel(zerolog.DebugLevel)
 
-		err := bubbly.Apply(bCtx, "./testdata/sonarqube")
+		_, err := bubbly.Apply(bCtx, "./testdata/sonarqube")
 		assert.NoError(t, err, "Failed to apply resource")
 
 		// test that `bubbly get all` returns valid resources from the apply
@@ -56,7 +56,7 @@ func TestApply(t *testing.T) {
 		bCtx := env.NewBubblyContext()
 		bCtx.UpdateLogLevel(zerolog.DebugLevel)
 
-		err := bubbly.Apply(bCtx, "./testdata/resources/v1/extract/spdx-licenses.bubbly")
+		_, err := bubbly.Apply(bCtx, "./testdata/resources/v1/extract/spdx-licenses.bubbly")
 		assert.NoError(t, err, "Failed to apply resource")
 	})
 
@@ -64,7 +64,7 @@ func TestApply(t *testing.T) {
 		// Create a new server route for mocking a Bubbly server response
 		bCtx := env.NewBubblyContext()
 		bCtx.UpdateLogLevel(zerolog.DebugLevel)
-		err := bubbly.Apply(bCtx, "./testdata/snyk")
+		_, err := bubbly.Apply(bCtx, "./testdata/snyk")
 		assert.NoError(t, err, "Failed to apply resource")
 
 		// test that `bubbly get all` returns valid resources from the apply
@@ -74,7 +74,7 @@ func TestApply(t *testing.T) {
 	t.Run("gosec", func(t *testing.T) {
 		bCtx := env.NewBubblyContext()
 		bCtx.UpdateLogLevel(zerolog.DebugLevel)
-		err := bubbly.Apply(bCtx, "./testdata/gosec")
+		_, err := bubbly.Apply(bCtx, "./testdata/gosec")
 		assert.NoError(t, err, "failed to apply resource")
 
 		testGet(t, bCtx, []string{"extract/gosec"})
@@ -86,9 +86,9 @@ func TestApply(t *testing.T) {
 		bCtx.UpdateLogLevel(zerolog.DebugLevel)
 
 		// inject initial bubbly test data
-		err := bubbly.Apply(bCtx, "./testdata/testautomation/golang/pipeline.bubbly")
+		_, err := bubbly.Apply(bCtx, "./testdata/testautomation/golang/pipeline.bubbly")
 		require.NoError(t, err, "failed to apply golang pipeline")
-		err = bubbly.Apply(bCtx, "./testdata/resources/v1/query/query.bubbly")
+		_, err = bubbly.Apply(bCtx, "./testdata/resources/v1/query/query.bubbly")
 		assert.NoError(t, err, "Failed to apply resource")
 	})
 
@@ -100,7 +100,7 @@ func TestApply(t *testing.T) {
 	//	bCtx := env.NewBubblyContext()
 	//	bCtx.UpdateLogLevel(zerolog.DebugLevel)
 	//
-	//	err := bubbly.Apply(bCtx, "./testdata/resources/v1/criteria/criteria.bubbly")
+	//	_, err := bubbly.Apply(bCtx, "./testdata/resources/v1/criteria/criteria.bubbly")
 	//	assert.NoError(t, err, "Failed to apply resource")
 	//})
 }
@@ -111,14 +111,14 @@ func TestApplyRun(t *testing.T) {
 		bCtx := env.NewBubblyContext()
 		bCtx.UpdateLogLevel(zerolog.DebugLevel)
 
-		err := bubbly.Apply(bCtx, "./testdata/resources/v1/run/resources.bubbly")
+		_, err := bubbly.Apply(bCtx, "./testdata/resources/v1/run/resources.bubbly")
 		assert.NoError(t, err, "Failed to apply resource")
 	})
 	t.Run("remote_run", func(t *testing.T) {
 		bCtx := env.NewBubblyContext()
 		bCtx.UpdateLogLevel(zerolog.DebugLevel)
 
-		err := bubbly.Apply(bCtx, "./testdata/resources/v1/run/remote_resources.bubbly")
+		_, err := bubbly.Apply(bCtx, "./testdata/resources/v1/run/remote_resources.bubbly")
 		require.NoError(t, err, "Failed to apply remote resource")
 
 		getQuery := fmt.Sprintf(`
@@ -158,7 +158,7 @@ func TestApplyRun(t *testing.T) {
 		// TODO: applying a remote resource which requires remote inputs
 		//  will always fail initially. Might be valuable to filter these run
 		//  resources and not auto-run them after apply to bubbly
-		err := bubbly.Apply(bCtx, "./testdata/resources/v1/run/remote_run_with_remote_input.bubbly")
+		_, err := bubbly.Apply(bCtx, "./testdata/resources/v1/run/remote_run_with_remote_input.bubbly")
 		require.NoError(t, err, "Failed to apply remote run resource")
 
 		t.Run("json", func(t *testing.T) {
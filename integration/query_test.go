@@ -48,7 +48,7 @@ func TestClientQuery(t *testing.T) {
 	bCtx.UpdateLogLevel(zerolog.DebugLevel)
 
 	// inject initial bubbly test data
-	err := bubbly.Apply(bCtx, "./testdata/testautomation/golang/pipeline.bubbly")
+	_, err := bubbly.Apply(bCtx, "./testdata/testautomation/golang/pipeline.bubbly")
 	require.NoError(t, err, "failed to apply golang pipeline")
 
 	query := `{
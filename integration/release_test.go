@@ -29,7 +29,7 @@ func TestRelease(t *testing.T) {
 	}
 	bCtx := env.NewBubblyContext()
 	// Apply all resources in the release directory
-	err := bubbly.Apply(bCtx, "./testdata/release/resources")
+	_, err := bubbly.Apply(bCtx, "./testdata/release/resources")
 	require.NoError(t, err)
 
 	t.Run("list releases", func(t *testing.T) {
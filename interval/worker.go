@@ -45,6 +45,18 @@ type ResourceWorker struct {
 	Pools          Pools
 	WorkerChannels Channels
 	Context        ChannelContext
+	// MaxConcurrency is the maximum number of runs that are applied at the
+	// same time. If it is not positive, the runs are applied one at a time.
+	// The runs with a remote input directory are always applied one at a
+	// time, because they change the working directory of the process
+	MaxConcurrency int
+
+	semOnce sync.Once
+	sem     chan struct{}
+	// dirMu is held by the runs that change the working directory
+	dirMu sync.Mutex
+	// applyOneOff applies a one-off run. If nil, Run.ApplyOneOff is used
+	applyOneOff func(*env.BubblyContext, *Run, *component.MessageAuth) error
 }
 
 type Pools struct {
@@ -140,60 +152,101 @@ func (w *ResourceWorker) RunIntervalRuns(bCtx *env.BubblyContext, auth *componen
 }
 
 // RunOneOffRuns runs all resources within the resource worker's OneOff Pool.
-// That is, all of its one-off run resources
+// That is, all of its one-off run resources.
+// The runs are applied concurrently, but no more than MaxConcurrency at a
+// time: the runs over the limit are queued until a running run has finished.
+// The runs with a remote input directory also wait for each other, so only
+// the runs without one are applied concurrently with them
 func (w *ResourceWorker) RunOneOffRuns(bCtx *env.BubblyContext, auth *component.MessageAuth) error {
+	// take the runs out of the pool, so that the runs are not applied again
+	// by another call while they are queued
 	w.Pools.OneOff.mu.Lock()
 	bCtx.Logger.Debug().Int("pool", len(w.Pools.OneOff.Runs)).Msg("number of one-off runs to run")
+	runs := make([]Run, 0, len(w.Pools.OneOff.Runs))
 	for _, run := range w.Pools.OneOff.Runs {
-		// run has been triggered from a POST to /api/v1/run/:name and
-		// therefore should be run from the root tmp directory associated
-		// with the remote input
-		if run.RemoteInput.Dir != "" {
-			if err := os.Chdir(run.RemoteInput.Dir); err != nil {
-				w.Pools.OneOff.mu.Unlock()
-				return fmt.Errorf("unable to chdir to %v: %v", run.RemoteInput.Dir, err)
-			}
-		}
-
-		dir, _ := os.Getwd()
-
-		bCtx.Logger.Debug().Str("dir", dir).Msg("running one-off run resource")
-
-		err := run.ApplyOneOff(bCtx, auth)
+		runs = append(runs, run)
+		w.Pools.OneOff.Remove(run)
+	}
+	w.Pools.OneOff.mu.Unlock()
 
-		// regardless of outcome, purge the one-off resource from the worker
-		// pool to prevent run build up
-		bCtx.Logger.Debug().
-			Int("pool_size", len(w.Pools.OneOff.Runs)).
-			Str("name", w.Pools.OneOff.Runs[run.UUID].Resource.ResourceName).
-			Msg("one-off run complete. Removing run from worker's one-off pool")
+	sem := w.semaphore()
+	var wg sync.WaitGroup
+	for _, run := range runs {
+		// wait for a free slot before starting the run
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(run Run) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			w.runOneOff(bCtx, run, auth)
+		}(run)
+	}
+	wg.Wait()
 
-		w.Pools.OneOff.Remove(run)
+	return nil
+}
 
-		bCtx.Logger.Debug().
-			Int("pool_size", len(w.Pools.OneOff.Runs)).
-			Msg("run removed")
+// semaphore returns the channel that limits the number of runs that are
+// applied at the same time to MaxConcurrency
+func (w *ResourceWorker) semaphore() chan struct{} {
+	w.semOnce.Do(func() {
+		limit := w.MaxConcurrency
+		if limit <= 0 {
+			limit = 1
+		}
+		w.sem = make(chan struct{}, limit)
+	})
+	return w.sem
+}
 
-		if err != nil {
+// runOneOff applies a single one-off run and purges its remote input.
+// Errors are logged, as the run has already been removed from the pool
+func (w *ResourceWorker) runOneOff(bCtx *env.BubblyContext, run Run, auth *component.MessageAuth) {
+	// run has been triggered from a POST to /api/v1/run/:name and
+	// therefore should be run from the root tmp directory associated
+	// with the remote input. The working directory is shared by the whole
+	// process, so only one such run can be applied at a time
+	if run.RemoteInput.Dir != "" {
+		w.dirMu.Lock()
+		defer w.dirMu.Unlock()
+		if err := os.Chdir(run.RemoteInput.Dir); err != nil {
 			bCtx.Logger.Error().
 				Err(err).
 				Str("run", run.Resource.ResourceName).
-				Msg("failed to run one-off run resource")
-		} else {
-			bCtx.Logger.Debug().
-				Str("run", run.Resource.ResourceName).
-				Msg("ran one-off run resource successfully")
+				Msgf("unable to chdir to %v", run.RemoteInput.Dir)
+			return
 		}
+	}
+
+	dir, _ := os.Getwd()
 
-		// remove the now-redundant temp directory from the Worker's local filesystem
-		if err := os.RemoveAll(run.RemoteInput.Dir); err != nil {
-			bCtx.Logger.Error().Err(err).Msg("failed to purge remote input temporary directory")
+	bCtx.Logger.Debug().Str("dir", dir).Msg("running one-off run resource")
+
+	apply := w.applyOneOff
+	if apply == nil {
+		apply = func(bCtx *env.BubblyContext, run *Run, auth *component.MessageAuth) error {
+			return run.ApplyOneOff(bCtx, auth)
 		}
 	}
+	err := apply(bCtx, &run, auth)
 
-	w.Pools.OneOff.mu.Unlock()
+	if err != nil {
+		bCtx.Logger.Error().
+			Err(err).
+			Str("run", run.Resource.ResourceName).
+			Msg("failed to run one-off run resource")
+	} else {
+		bCtx.Logger.Debug().
+			Str("run", run.Resource.ResourceName).
+			Msg("ran one-off run resource successfully")
+	}
 
-	return nil
+	// remove the now-redundant temp directory from the Worker's local filesystem
+	if err := os.RemoveAll(run.RemoteInput.Dir); err != nil {
+		bCtx.Logger.Error().Err(err).Msg("failed to purge remote input temporary directory")
+	}
 }
 
 // ParseResource writes data in the server.RemoteInput to the local
@@ -355,6 +408,9 @@ mainloop:
 func (r *Run) ApplyOneOff(bCtx *env.BubblyContext, auth *component.MessageAuth) error {
 	bCtx.Logger.Debug().Str("id", r.Resource.String()).Msg("run resource of type OneOffRun identified")
 	ctx := core.NewResourceContext(cty.NilVal, api.NewResource, auth)
+	// A one-off run is applied once, so the data it loads is identified by
+	// the run. Interval runs are not, as they load new data on every tick
+	ctx.RunID = r.UUID.String()
 	output := common.RunResource(bCtx, ctx, &r.Resource, cty.NilVal)
 	return output.Error
 }
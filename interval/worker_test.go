@@ -1,13 +1,18 @@
 package interval
 
 import (
+	"fmt"
+	"os"
 	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/rs/zerolog"
 	"github.com/stretchr/testify/require"
 
+	"github.com/valocode/bubbly/agent/component"
 	"github.com/valocode/bubbly/api/core"
 	v1 "github.com/valocode/bubbly/api/v1"
 	"github.com/valocode/bubbly/bubbly"
@@ -139,3 +144,99 @@ func TestWorkerPoolAddRemove(t *testing.T) {
 	require.Len(t, worker.Pools.OneOff.Runs, 0)
 
 }
+
+// TestWorkerMaxConcurrency tests that a worker applies no more than
+// MaxConcurrency runs at the same time, and queues the other runs. The runs
+// with a remote input directory change the working directory, so they are
+// applied one at a time
+func TestWorkerMaxConcurrency(t *testing.T) {
+	const (
+		maxConcurrency = 3
+		nRuns          = 10
+	)
+	tcs := []struct {
+		desc string
+		// dirRuns is the number of runs that have a remote input directory
+		dirRuns int
+	}{
+		{
+			desc:    "runs without directories",
+			dirRuns: 0,
+		},
+		{
+			desc:    "runs with and without directories",
+			dirRuns: 4,
+		},
+		{
+			desc:    "runs with directories",
+			dirRuns: nRuns,
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.desc, func(t *testing.T) {
+			bCtx := env.NewBubblyContext()
+			// The runs with a directory change the working directory of the
+			// test
+			wd, err := os.Getwd()
+			require.NoError(t, err)
+			defer os.Chdir(wd)
+
+			var (
+				running    int32
+				peak       int32
+				runningDir int32
+				peakDir    int32
+				applied    int32
+			)
+			// track increments a counter of running runs, and records its
+			// peak
+			track := func(counter *int32, peak *int32) {
+				n := atomic.AddInt32(counter, 1)
+				for {
+					p := atomic.LoadInt32(peak)
+					if n <= p || atomic.CompareAndSwapInt32(peak, p, n) {
+						break
+					}
+				}
+			}
+
+			worker := newTestWorker(t)
+			worker.MaxConcurrency = maxConcurrency
+			worker.applyOneOff = func(_ *env.BubblyContext, run *Run, _ *component.MessageAuth) error {
+				track(&running, &peak)
+				defer atomic.AddInt32(&running, -1)
+				if run.RemoteInput.Dir != "" {
+					track(&runningDir, &peakDir)
+					defer atomic.AddInt32(&runningDir, -1)
+				}
+				time.Sleep(20 * time.Millisecond)
+				atomic.AddInt32(&applied, 1)
+				return nil
+			}
+
+			var dirs []string
+			for i := 0; i < nRuns; i++ {
+				run := Run{
+					UUID:     uuid.New(),
+					Resource: *v1.NewRun(&core.ResourceBlock{ResourceKind: string(core.RunResourceKind), ResourceName: fmt.Sprintf("run-%d", i)}),
+					Kind:     OneOffRun,
+				}
+				if i < tc.dirRuns {
+					run.RemoteInput.Dir = t.TempDir()
+					dirs = append(dirs, run.RemoteInput.Dir)
+				}
+				worker.Pools.OneOff.Append(run)
+			}
+
+			require.NoError(t, worker.RunOneOffRuns(bCtx, nil))
+			require.Equal(t, int32(nRuns), atomic.LoadInt32(&applied))
+			require.LessOrEqual(t, atomic.LoadInt32(&peak), int32(maxConcurrency))
+			require.LessOrEqual(t, atomic.LoadInt32(&peakDir), int32(1))
+			require.Len(t, worker.Pools.OneOff.Runs, 0)
+			// The directories of the runs are removed once they are applied
+			for _, dir := range dirs {
+				require.NoDirExists(t, dir)
+			}
+		})
+	}
+}
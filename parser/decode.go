@@ -6,8 +6,11 @@ import (
 
 	"github.com/hashicorp/hcl/v2"
 	"github.com/hashicorp/hcl/v2/ext/dynblock"
+	"github.com/hashicorp/hcl/v2/ext/typeexpr"
 	"github.com/hashicorp/hcl/v2/gohcl"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
 	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
 )
 
 func DecodeBody(body hcl.Body, val interface{}, inputs cty.Value) error {
@@ -48,6 +51,57 @@ func ExpressionValue(expr hcl.Expression, inputs cty.Value) (cty.Value, error) {
 	return value, nil
 }
 
+// TypeExpression returns the type of a type expression, such as
+// list(object({name = string})). The expression can also be a conditional
+// expression whose results are type expressions, such as
+// self.input.version == "v2" ? list(string) : string, in which case the
+// condition is evaluated with the inputs. If the condition is unknown, e.g.
+// when validating without inputs, both results must be valid type expressions
+// and the type of the true result is returned
+func TypeExpression(expr hcl.Expression, inputs cty.Value) (cty.Type, error) {
+	ty, diags := typeExpression(expr, newEvalContext(inputs))
+	if diags.HasErrors() {
+		return cty.NilType, NewParserError(nil, diags)
+	}
+	return ty, nil
+}
+
+func typeExpression(expr hcl.Expression, eCtx *hcl.EvalContext) (cty.Type, hcl.Diagnostics) {
+	inner := hcl.UnwrapExpression(expr)
+	// A conditional expression across multiple lines has to be written in
+	// parentheses
+	if paren, ok := inner.(*hclsyntax.ParenthesesExpr); ok {
+		return typeExpression(paren.Expression, eCtx)
+	}
+	cond, ok := inner.(*hclsyntax.ConditionalExpr)
+	if !ok {
+		return typeexpr.TypeConstraint(expr)
+	}
+
+	condVal, diags := cond.Condition.Value(eCtx)
+	if diags.HasErrors() {
+		return cty.NilType, diags
+	}
+	condVal, err := convert.Convert(condVal, cty.Bool)
+	if err != nil || condVal.IsNull() {
+		return cty.NilType, hcl.Diagnostics{{
+			Severity: hcl.DiagError,
+			Summary:  "Incorrect condition type",
+			Detail:   "The condition of a type expression must be a bool.",
+			Subject:  cond.Condition.Range().Ptr(),
+		}}
+	}
+	if !condVal.IsKnown() {
+		ty, diags := typeExpression(cond.TrueResult, eCtx)
+		_, falseDiags := typeExpression(cond.FalseResult, eCtx)
+		return ty, append(diags, falseDiags...)
+	}
+	if condVal.True() {
+		return typeExpression(cond.TrueResult, eCtx)
+	}
+	return typeExpression(cond.FalseResult, eCtx)
+}
+
 func processVariables(inputs cty.Value, traversals []hcl.Traversal) (cty.Value, hcl.Diagnostics) {
 	var (
 		diags    hcl.Diagnostics
@@ -3,8 +3,10 @@ package parser
 import (
 	"testing"
 
+	"github.com/hashicorp/hcl/v2"
 	"github.com/hashicorp/hcl/v2/hclparse"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/zclconf/go-cty/cty"
 )
 
@@ -103,3 +105,140 @@ dynamic "data" {
 	// 	}
 	// }
 }
+
+func TestDecodeUnknownReference(t *testing.T) {
+	tcs := []struct {
+		desc string
+		src  string
+		err  string
+	}{
+		{
+			desc: "unknown task",
+			src:  "value = self.task.typo.value",
+			err:  `unknown "typo" in self.task; known: extract, transform`,
+		},
+		{
+			desc: "unknown task output",
+			src:  "value = self.task.extract.typo",
+			err:  `unknown "typo" in self.task.extract; known: value`,
+		},
+		{
+			desc: "unknown input",
+			src:  "value = self.input.typo",
+			err:  `unknown "typo" in self.input; there are no known values`,
+		},
+	}
+	inputs := cty.ObjectVal(map[string]cty.Value{
+		"input": cty.EmptyObjectVal,
+		"task": cty.ObjectVal(map[string]cty.Value{
+			"extract": cty.ObjectVal(map[string]cty.Value{
+				"value": cty.StringVal("extracted"),
+			}),
+			"transform": cty.ObjectVal(map[string]cty.Value{
+				"value": cty.StringVal("transformed"),
+			}),
+		}),
+	})
+	for _, tc := range tcs {
+		t.Run(tc.desc, func(t *testing.T) {
+			file, diags := hclparse.NewParser().ParseHCL([]byte(tc.src), "testing")
+			assert.Equalf(t, diags.HasErrors(), false, diags.Error())
+			var val testHCLValue
+			err := DecodeExpandBody(file.Body, &val, inputs)
+			if assert.Error(t, err) {
+				assert.Contains(t, err.Error(), tc.err)
+			}
+		})
+	}
+}
+
+type testHCLFormat struct {
+	Format *hcl.Attribute `hcl:"format,attr"`
+}
+
+func TestTypeExpression(t *testing.T) {
+	const conditional = `format = self.input.version == "v2" ? list(object({name = string})) : object({name = string})`
+	tcs := []struct {
+		desc    string
+		src     string
+		version cty.Value
+		ty      cty.Type
+		err     string
+	}{
+		{
+			desc:    "type expression",
+			src:     `format = list(string)`,
+			version: cty.StringVal("v1"),
+			ty:      cty.List(cty.String),
+		},
+		{
+			desc:    "false condition",
+			src:     conditional,
+			version: cty.StringVal("v1"),
+			ty:      cty.Object(map[string]cty.Type{"name": cty.String}),
+		},
+		{
+			desc:    "true condition",
+			src:     conditional,
+			version: cty.StringVal("v2"),
+			ty:      cty.List(cty.Object(map[string]cty.Type{"name": cty.String})),
+		},
+		{
+			desc:    "nested condition",
+			src:     `format = self.input.version == "v1" ? string : self.input.version == "v2" ? number : bool`,
+			version: cty.StringVal("v2"),
+			ty:      cty.Number,
+		},
+		{
+			desc: "condition in parentheses",
+			src: `format = (self.input.version == "v2" ?
+				list(string) :
+				string
+			)`,
+			version: cty.StringVal("v2"),
+			ty:      cty.List(cty.String),
+		},
+		{
+			desc:    "unknown condition",
+			src:     conditional,
+			version: cty.UnknownVal(cty.String),
+			ty:      cty.List(cty.Object(map[string]cty.Type{"name": cty.String})),
+		},
+		{
+			desc:    "invalid result of unknown condition",
+			src:     `format = self.input.version == "v2" ? string : strung`,
+			version: cty.UnknownVal(cty.String),
+			err:     `The keyword "strung" is not a valid type specification`,
+		},
+		{
+			desc:    "condition not a bool",
+			src:     `format = self.input.version ? string : number`,
+			version: cty.StringVal("v2"),
+			err:     "The condition of a type expression must be a bool",
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.desc, func(t *testing.T) {
+			file, diags := hclparse.NewParser().ParseHCL([]byte(tc.src), "testing")
+			require.Falsef(t, diags.HasErrors(), diags.Error())
+			inputs := cty.ObjectVal(map[string]cty.Value{
+				"input": cty.ObjectVal(map[string]cty.Value{
+					"version": tc.version,
+				}),
+			})
+			// The keywords of the type expression are not variables, so
+			// decoding must not fail on them
+			var val testHCLFormat
+			require.NoError(t, DecodeExpandBody(file.Body, &val, inputs))
+
+			ty, err := TypeExpression(val.Format.Expr, inputs)
+			if tc.err != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tc.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.True(t, tc.ty.Equals(ty), "expected %s, got %s", tc.ty.GoString(), ty.GoString())
+		})
+	}
+}
@@ -1,47 +1,97 @@
 package parser
 
 import (
+	"io"
 	"strings"
 
 	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclparse"
 )
 
 func NewParserError(val interface{}, diags hcl.Diagnostics) *ParserError {
+	// Improve the diagnostics for any references that could not be resolved
+	suggestReferences(diags)
 	return &ParserError{
 		Diags: diags,
 		Value: val,
 	}
 }
 
+// ParserError contains the diagnostics from parsing and decoding HCL. The
+// diagnostics contain the source ranges (filename, line and column) of where
+// the errors occurred.
 type ParserError struct {
 	Diags hcl.Diagnostics
 	Value interface{}
+	// Files contains the parsed HCL files, which are used to write the
+	// source snippets for the diagnostics. Any file which is not provided here
+	// will be read from disk when writing the diagnostics, if it exists
+	Files map[string]*hcl.File
 }
 
 func (e *ParserError) Error() string {
+	var msgs []string
+	for _, diag := range e.uniqueDiags() {
+		msgs = append(msgs, diag.Error())
+	}
+	return "\n" + strings.Join(msgs, "\n")
+}
+
+// WriteDiagnostics writes the diagnostics with the source snippets where the
+// errors occurred to w
+func (e *ParserError) WriteDiagnostics(w io.Writer, color bool) error {
+	wr := hcl.NewDiagnosticTextWriter(w, e.diagFiles(), 0, color)
+	return wr.WriteDiagnostics(e.uniqueDiags())
+}
+
+// uniqueDiags returns the error diagnostics, without any duplicates
+func (e *ParserError) uniqueDiags() hcl.Diagnostics {
 	var (
-		msgs     []string
+		diags    hcl.Diagnostics
 		prevDiag *hcl.Diagnostic
 	)
-	for _, err := range e.Diags.Errs() {
-		// This is actually a *hcl.Diagnostic, we just need to cast it, e.g.
-		if diag, ok := err.(*hcl.Diagnostic); ok {
-			// The use of HCL dynamic blocks can create a lot of duplicate messages.
-			// We only need to show one of those and they come sequentially, so
-			// compare this diagnostic with the previous one
-			if prevDiag != nil && prevDiag.Subject.String() == diag.Subject.String() &&
-				prevDiag.Detail == diag.Detail {
-				prevDiag = diag
-				continue
-			}
-			// If it's not a duplicate, add it
-			msgs = append(msgs, err.Error())
-			// msgs = append(msgs, fmt.Sprintf("%#v\n", diag.EvalContext.Variables))
+	for _, diag := range e.Diags {
+		if diag.Severity != hcl.DiagError {
+			continue
+		}
+		// The use of HCL dynamic blocks can create a lot of duplicate messages.
+		// We only need to show one of those and they come sequentially, so
+		// compare this diagnostic with the previous one
+		if prevDiag != nil && prevDiag.Subject.String() == diag.Subject.String() &&
+			prevDiag.Detail == diag.Detail {
 			prevDiag = diag
 			continue
 		}
-		// If err is not an hcl.Diagnostic just add the error message normally
-		msgs = append(msgs, err.Error())
+		// If it's not a duplicate, add it
+		diags = append(diags, diag)
+		prevDiag = diag
 	}
-	return "\n" + strings.Join(msgs, "\n")
+	return diags
+}
+
+// diagFiles returns the files that the diagnostics refer to, reading any file
+// not in e.Files from disk
+func (e *ParserError) diagFiles() map[string]*hcl.File {
+	var (
+		files     = make(map[string]*hcl.File)
+		hclParser = hclparse.NewParser()
+	)
+	for name, file := range e.Files {
+		files[name] = file
+	}
+	for _, diag := range e.Diags {
+		if diag.Subject == nil {
+			continue
+		}
+		name := diag.Subject.Filename
+		if _, ok := files[name]; ok {
+			continue
+		}
+		// Ignore any errors, as this is only used to display the source
+		// snippet and the file might not exist (e.g. if parsed from bytes)
+		if file, diags := hclParser.ParseHCLFile(name); !diags.HasErrors() {
+			files[name] = file
+		}
+	}
+	return files
 }
@@ -2,6 +2,7 @@ package parser
 
 import (
 	"os"
+	"sync"
 
 	"github.com/hashicorp/hcl/v2/ext/tryfunc"
 	ctyyaml "github.com/zclconf/go-cty-yaml"
@@ -12,8 +13,23 @@ import (
 	"github.com/hashicorp/terraform/lang/funcs"
 )
 
-// stdfunctions returns functions for the SymbolTable's EvalContext
+var (
+	stdfunctionsOnce sync.Once
+	stdfunctionsMap  map[string]function.Function
+)
+
+// stdfunctions returns functions for the EvalContext used when decoding.
+// The functions are created only once and shared by all EvalContexts, so the
+// returned map must not be modified
 func stdfunctions() map[string]function.Function {
+	stdfunctionsOnce.Do(func() {
+		stdfunctionsMap = newStdfunctions()
+	})
+	return stdfunctionsMap
+}
+
+// newStdfunctions creates the functions returned by stdfunctions
+func newStdfunctions() map[string]function.Function {
 	return map[string]function.Function{
 		// Our own custom functions here
 		"env": EnvFunc,
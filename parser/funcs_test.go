@@ -0,0 +1,47 @@
+package parser
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestStdfunctionsShared(t *testing.T) {
+	var (
+		ctx1 = newEvalContext(cty.EmptyObjectVal)
+		ctx2 = newEvalContext(cty.EmptyObjectVal)
+	)
+	// The functions should be the same map for every EvalContext
+	assert.Equal(t, reflect.ValueOf(ctx1.Functions).Pointer(), reflect.ValueOf(ctx2.Functions).Pointer())
+	// But the variables should be per EvalContext
+	assert.NotEqual(t, reflect.ValueOf(ctx1.Variables).Pointer(), reflect.ValueOf(ctx2.Variables).Pointer())
+}
+
+func BenchmarkNewEvalContext(b *testing.B) {
+	inputs := cty.ObjectVal(map[string]cty.Value{
+		"input": cty.ObjectVal(map[string]cty.Value{
+			"name": cty.StringVal("bubbly"),
+		}),
+	})
+	b.Run("shared", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			newEvalContext(inputs)
+		}
+	})
+	// uncached creates the functions for every EvalContext, to compare against
+	b.Run("uncached", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_ = &hcl.EvalContext{
+				Variables: map[string]cty.Value{
+					"self": inputs,
+				},
+				Functions: newStdfunctions(),
+			}
+		}
+	})
+}
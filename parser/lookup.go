@@ -0,0 +1,93 @@
+package parser
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// suggestReferences takes the diagnostics from evaluating expressions and, for
+// any failed variable lookups (e.g. self.task.typo.value), updates the
+// diagnostic with the known names at the path where the lookup failed so that
+// the user can see what they should be referencing instead
+func suggestReferences(diags hcl.Diagnostics) {
+	for _, diag := range diags {
+		expr, ok := diag.Expression.(*hclsyntax.ScopeTraversalExpr)
+		if !ok || diag.EvalContext == nil {
+			continue
+		}
+		if detail, ok := unknownReference(expr.Traversal, diag.EvalContext); ok {
+			diag.Summary = "Unknown reference"
+			diag.Detail = detail
+		}
+	}
+}
+
+// unknownReference walks the traversal over the variables in the EvalContext
+// and returns an error detail for the first name in the traversal which does
+// not exist.
+// If the whole traversal can be resolved, false is returned
+func unknownReference(traversal hcl.Traversal, ctx *hcl.EvalContext) (string, bool) {
+	val, ok := ctx.Variables[traversal.RootName()]
+	if !ok {
+		return "", false
+	}
+	var path = []string{traversal.RootName()}
+	for _, tr := range traversal[1:] {
+		attr, ok := tr.(hcl.TraverseAttr)
+		if !ok {
+			// We only resolve attributes, everything else we leave to HCL
+			return "", false
+		}
+		if val.IsNull() || !val.IsKnown() {
+			return "", false
+		}
+		ty := val.Type()
+		switch {
+		case ty.IsObjectType():
+			if !ty.HasAttribute(attr.Name) {
+				return unknownReferenceDetail(attr.Name, path, objectKeys(ty)), true
+			}
+			val = val.GetAttr(attr.Name)
+		case ty.IsMapType():
+			key := cty.StringVal(attr.Name)
+			if !val.HasIndex(key).True() {
+				return unknownReferenceDetail(attr.Name, path, mapKeys(val)), true
+			}
+			val = val.Index(key)
+		default:
+			return "", false
+		}
+		path = append(path, attr.Name)
+	}
+	return "", false
+}
+
+func unknownReferenceDetail(name string, path []string, known []string) string {
+	if len(known) == 0 {
+		return fmt.Sprintf("unknown %q in %s; there are no known values", name, strings.Join(path, "."))
+	}
+	return fmt.Sprintf("unknown %q in %s; known: %s", name, strings.Join(path, "."), strings.Join(known, ", "))
+}
+
+func objectKeys(ty cty.Type) []string {
+	var keys = make([]string, 0, len(ty.AttributeTypes()))
+	for key := range ty.AttributeTypes() {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func mapKeys(val cty.Value) []string {
+	var keys = make([]string, 0, val.LengthInt())
+	for key := range val.AsValueMap() {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -3,30 +3,74 @@ package parser
 import (
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"strconv"
+	"sync"
 
 	"github.com/hashicorp/hcl/v2"
 	"github.com/hashicorp/hcl/v2/hclparse"
+	"github.com/valocode/bubbly/config"
 	"github.com/valocode/bubbly/env"
 	"github.com/zclconf/go-cty/cty"
 )
 
 func ParseFilename(bCtx *env.BubblyContext, filename string, val interface{}) error {
-	files, err := bubblyFilesByFilename(filename)
+	files, err := bubblyFilesByFilename(filename, false)
 	if err != nil {
-		return fmt.Errorf("failed to get bubbly files: %s", err.Error())
+		return fmt.Errorf("failed to get bubbly files: %w", err)
 	}
-	mergedBody, err := MergedHCLBodies(bCtx, files)
+	return parseFiles(bCtx, files, val)
+}
+
+// ParseFilenameRecursive is like ParseFilename, but if filename is a directory
+// the bubbly files in all of its subdirectories are also parsed
+func ParseFilenameRecursive(bCtx *env.BubblyContext, filename string, val interface{}) error {
+	files, err := bubblyFilesByFilename(filename, true)
+	if err != nil {
+		return fmt.Errorf("failed to get bubbly files: %w", err)
+	}
+	return parseFiles(bCtx, files, val)
+}
+
+// parseFiles parses the files as one merged body and decodes it into val
+func parseFiles(bCtx *env.BubblyContext, files []string, val interface{}) error {
+	hclParser := hclparse.NewParser()
+	mergedBody, err := mergedHCLBodies(hclParser, files, maxOpenFiles(bCtx))
 	if err != nil {
 		return err
 	}
 	if err := DecodeBody(mergedBody, val, cty.NilVal); err != nil {
-		return fmt.Errorf(`failed to decode body: %s`, err.Error())
+		return fmt.Errorf(`failed to decode body: %w`, withFiles(err, hclParser))
+	}
+	return nil
+}
+
+// ParseBytes parses the HCL in src and decodes it into val. The filename is
+// used for diagnostics and does not need to exist on disk
+func ParseBytes(bCtx *env.BubblyContext, filename string, src []byte, val interface{}) error {
+	hclParser := hclparse.NewParser()
+	file, diags := hclParser.ParseHCL(src, filename)
+	if diags.HasErrors() {
+		return fmt.Errorf("failed to parse bubbly source: %s: %w", filename, withFiles(NewParserError(nil, diags), hclParser))
+	}
+	if err := DecodeBody(file.Body, val, cty.NilVal); err != nil {
+		return fmt.Errorf(`failed to decode body: %w`, withFiles(err, hclParser))
 	}
 	return nil
 }
 
+// ParseReader reads all the HCL from r and decodes it into val, in the same
+// way as ParseBytes
+func ParseReader(bCtx *env.BubblyContext, filename string, r io.Reader, val interface{}) error {
+	src, err := io.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("failed to read bubbly source: %s: %w", filename, err)
+	}
+	return ParseBytes(bCtx, filename, src, val)
+}
+
 func ParseResource(bCtx *env.BubblyContext, id string, src []byte, value interface{}) error {
 	hclParser := hclparse.NewParser()
 	file, diags := hclParser.ParseHCL(src, id)
@@ -40,17 +84,27 @@ func ParseResource(bCtx *env.BubblyContext, id string, src []byte, value interfa
 }
 
 func MergedHCLBodies(bCtx *env.BubblyContext, files []string) (hcl.Body, error) {
+	return mergedHCLBodies(hclparse.NewParser(), files, maxOpenFiles(bCtx))
+}
+
+// mergedHCLBodies reads the files, at most maxOpen at a time, and parses them
+// with the parser into one merged body. The files are parsed one by one, in
+// order, as the parser is not safe for concurrent use
+func mergedHCLBodies(parser *hclparse.Parser, files []string, maxOpen int) (hcl.Body, error) {
 
 	if len(files) == 0 {
 		return nil, errors.New("no bubbly files found")
 	}
 
-	parser := hclparse.NewParser()
-	hclFiles := []*hcl.File{}
-	for _, file := range files {
-		hclFile, diags := parser.ParseHCLFile(file)
+	srcs, err := readFiles(files, maxOpen)
+	if err != nil {
+		return nil, err
+	}
+	hclFiles := make([]*hcl.File, 0, len(files))
+	for i, file := range files {
+		hclFile, diags := parser.ParseHCL(srcs[i], file)
 		if diags.HasErrors() {
-			return nil, fmt.Errorf("failed to parse bubbly file: %s: %s", file, diags.Error())
+			return nil, fmt.Errorf("failed to parse bubbly file: %s: %w", file, withFiles(NewParserError(nil, diags), parser))
 		}
 		hclFiles = append(hclFiles, hclFile)
 	}
@@ -59,7 +113,66 @@ func MergedHCLBodies(bCtx *env.BubblyContext, files []string) (hcl.Body, error)
 	return mergedBody, nil
 }
 
-func bubblyFilesByFilename(filename string) ([]string, error) {
+// readFile reads a file, and can be overridden for testing
+var readFile = os.ReadFile
+
+// readFiles reads the files concurrently, with at most maxOpen files open at
+// the same time, and returns their contents in the order of files. If more
+// than one file cannot be read, the error of the first of them is returned
+func readFiles(files []string, maxOpen int) ([][]byte, error) {
+	if maxOpen < 1 {
+		maxOpen = 1
+	}
+	var (
+		srcs = make([][]byte, len(files))
+		errs = make([]error, len(files))
+		sem  = make(chan struct{}, maxOpen)
+		wg   sync.WaitGroup
+	)
+	for i, file := range files {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, file string) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			srcs[i], errs[i] = readFile(file)
+		}(i, file)
+	}
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			return nil, fmt.Errorf("failed to read bubbly file: %s: %w", files[i], err)
+		}
+	}
+	return srcs, nil
+}
+
+// maxOpenFiles returns the maximum number of files to read at the same time
+func maxOpenFiles(bCtx *env.BubblyContext) int {
+	if bCtx == nil || bCtx.CLIConfig == nil {
+		n, _ := strconv.Atoi(config.DefaultCLIMaxOpenFiles)
+		return n
+	}
+	return bCtx.CLIConfig.MaxOpenFiles
+}
+
+// withFiles adds the files parsed by the parser to err, if err is a
+// ParserError, so that the source of any diagnostics can be shown
+func withFiles(err error, parser *hclparse.Parser) error {
+	var parserErr *ParserError
+	if errors.As(err, &parserErr) {
+		parserErr.Files = parser.Files()
+	}
+	return err
+}
+
+// bubblyFilesByFilename returns the bubbly files at filename, which is either a
+// file or a directory. If recursive is true, the bubbly files in the
+// subdirectories of a directory are also returned
+func bubblyFilesByFilename(filename string, recursive bool) ([]string, error) {
 	var (
 		files []string
 	)
@@ -71,6 +184,19 @@ func bubblyFilesByFilename(filename string) ([]string, error) {
 	switch mode := fi.Mode(); {
 	case mode.IsRegular():
 		files = append(files, filename)
+	case mode.IsDir() && recursive:
+		err := filepath.WalkDir(filename, func(path string, d os.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if filepath.Ext(d.Name()) == ".bubbly" && !d.IsDir() {
+				files = append(files, path)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("error walking directory %s: %w", filename, err)
+		}
 	case mode.IsDir():
 		// walk the directory and get .bubbly files
 		entries, err := os.ReadDir(filename)
@@ -0,0 +1,205 @@
+package parser
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/valocode/bubbly/env"
+)
+
+type testResourceWrapper struct {
+	Resources []struct {
+		Kind string `hcl:",label"`
+		Name string `hcl:",label"`
+		Spec struct {
+			Type  string    `hcl:"type,attr"`
+			Value cty.Value `hcl:"value,attr"`
+		} `hcl:"spec,block"`
+	} `hcl:"resource,block"`
+}
+
+const testResourceSrc = `
+resource "extract" "first" {
+	spec {
+		type = "json"
+		value = "hello"
+	}
+}
+resource "transform" "second" {
+	spec {
+		type = "data"
+		value = ["a", "b"]
+	}
+}
+`
+
+func TestParseBytes(t *testing.T) {
+	bCtx := env.NewBubblyContext()
+
+	// Parse the resources from a file which is the reference
+	filename := filepath.Join(t.TempDir(), "resources.bubbly")
+	require.NoError(t, os.WriteFile(filename, []byte(testResourceSrc), 0644))
+	var fileVal testResourceWrapper
+	require.NoError(t, ParseFilename(bCtx, filename, &fileVal))
+	require.Len(t, fileVal.Resources, 2)
+
+	t.Run("bytes", func(t *testing.T) {
+		var val testResourceWrapper
+		require.NoError(t, ParseBytes(bCtx, "resources.bubbly", []byte(testResourceSrc), &val))
+		assert.Equal(t, fileVal, val)
+	})
+	t.Run("reader", func(t *testing.T) {
+		var val testResourceWrapper
+		require.NoError(t, ParseReader(bCtx, "resources.bubbly", bytes.NewBufferString(testResourceSrc), &val))
+		assert.Equal(t, fileVal, val)
+	})
+	t.Run("invalid", func(t *testing.T) {
+		var val testResourceWrapper
+		err := ParseBytes(bCtx, "invalid.bubbly", []byte(`resource "extract" {`), &val)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid.bubbly")
+	})
+}
+
+func TestParseErrorRange(t *testing.T) {
+	bCtx := env.NewBubblyContext()
+
+	// The resource is valid HCL, but the type attribute has the wrong type
+	const src = `
+resource "extract" "first" {
+	spec {
+		type = ["json"]
+		value = "hello"
+	}
+}
+`
+	filename := filepath.Join(t.TempDir(), "invalid.bubbly")
+	require.NoError(t, os.WriteFile(filename, []byte(src), 0644))
+
+	var val testResourceWrapper
+	err := ParseFilename(bCtx, filename, &val)
+	require.Error(t, err)
+	// The error should contain the filename and the line of the attribute
+	assert.Contains(t, err.Error(), filename+":4,")
+
+	var parserErr *ParserError
+	require.True(t, errors.As(err, &parserErr))
+
+	var buf bytes.Buffer
+	require.NoError(t, parserErr.WriteDiagnostics(&buf, false))
+	// The diagnostics should contain the offending source snippet
+	assert.Contains(t, buf.String(), filename)
+	assert.Contains(t, buf.String(), `type = ["json"]`)
+}
+
+func TestBubblyFilesByFilename(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a.bubbly", "b.txt", filepath.Join("sub", "c.bubbly")} {
+		filename := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(filename), 0755))
+		require.NoError(t, os.WriteFile(filename, nil, 0644))
+	}
+
+	tcs := []struct {
+		desc      string
+		filename  string
+		recursive bool
+		expected  []string
+	}{
+		{
+			desc:     "file",
+			filename: filepath.Join(dir, "a.bubbly"),
+			expected: []string{filepath.Join(dir, "a.bubbly")},
+		},
+		{
+			desc:     "directory",
+			filename: dir,
+			expected: []string{filepath.Join(dir, "a.bubbly")},
+		},
+		{
+			desc:      "directory recursive",
+			filename:  dir,
+			recursive: true,
+			expected:  []string{filepath.Join(dir, "a.bubbly"), filepath.Join(dir, "sub", "c.bubbly")},
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.desc, func(t *testing.T) {
+			files, err := bubblyFilesByFilename(tc.filename, tc.recursive)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, files)
+		})
+	}
+}
+
+// TestParseDirectoryMaxOpenFiles parses a directory with many files and checks
+// that no more than the maximum number of files are open at the same time, and
+// that the result is the same as parsing the files one by one
+func TestParseDirectoryMaxOpenFiles(t *testing.T) {
+	const (
+		numFiles = 200
+		maxOpen  = 4
+	)
+	dir := t.TempDir()
+	var files []string
+	for i := 0; i < numFiles; i++ {
+		src := fmt.Sprintf(`
+resource "extract" "e%03d" {
+	spec {
+		type = "json"
+		value = %d
+	}
+}
+`, i, i)
+		filename := filepath.Join(dir, fmt.Sprintf("r%03d.bubbly", i))
+		require.NoError(t, os.WriteFile(filename, []byte(src), 0644))
+		files = append(files, filename)
+	}
+
+	var (
+		mu          sync.Mutex
+		open, most  int
+		defaultRead = readFile
+	)
+	readFile = func(name string) ([]byte, error) {
+		mu.Lock()
+		open++
+		if open > most {
+			most = open
+		}
+		mu.Unlock()
+		defer func() {
+			mu.Lock()
+			open--
+			mu.Unlock()
+		}()
+		return defaultRead(name)
+	}
+	defer func() { readFile = defaultRead }()
+
+	bCtx := env.NewBubblyContext()
+	bCtx.CLIConfig.MaxOpenFiles = maxOpen
+	var val testResourceWrapper
+	require.NoError(t, ParseFilename(bCtx, dir, &val))
+	assert.LessOrEqual(t, most, maxOpen)
+
+	// Parse each file on its own, in order, which is the reference
+	bCtx.CLIConfig.MaxOpenFiles = 1
+	var serial testResourceWrapper
+	for _, filename := range files {
+		var fileVal testResourceWrapper
+		require.NoError(t, ParseFilename(bCtx, filename, &fileVal))
+		serial.Resources = append(serial.Resources, fileVal.Resources...)
+	}
+	require.Len(t, val.Resources, numFiles)
+	assert.Equal(t, serial, val)
+}
@@ -0,0 +1,50 @@
+package parser
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/ext/dynblock"
+	"github.com/hashicorp/hcl/v2/gohcl"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// ValidateBody decodes body into val in the same way as DecodeExpandBody, but
+// without any values for the variables, so that a body can be validated
+// without having to run it, e.g. when there are no inputs.
+// All the variables are unknown, and the errors that occur only because a
+// value is unknown are ignored. That leaves the errors in the structure of
+// the body (like unsupported or missing attributes and blocks) and in the
+// expressions that do not depend on any variables.
+func ValidateBody(body hcl.Body, val interface{}) error {
+	node := dynblock.WalkVariables(body)
+	traversals := walkVariables(node, reflect.TypeOf(val))
+	// Only the diagnostics are needed, which are for unsupported references
+	_, diags := processVariables(cty.EmptyObjectVal, traversals)
+
+	eCtx := newEvalContext(cty.DynamicVal)
+	expBody := dynblock.Expand(body, eCtx)
+	diags = append(diags, gohcl.DecodeBody(expBody, eCtx, val)...)
+
+	diags = knownDiags(diags)
+	if diags.HasErrors() {
+		return NewParserError(val, diags)
+	}
+	return nil
+}
+
+// knownDiags returns the diagnostics that are not caused by a value being
+// unknown
+func knownDiags(diags hcl.Diagnostics) hcl.Diagnostics {
+	var known hcl.Diagnostics
+	for _, diag := range diags {
+		// gohcl does not say which expression the diagnostic is for, so the
+		// only way to know is the message from cty
+		if strings.Contains(diag.Detail, "value must be known") {
+			continue
+		}
+		known = append(known, diag)
+	}
+	return known
+}
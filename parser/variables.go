@@ -30,6 +30,12 @@ func walkVariables(node dynblock.WalkVariablesNode, ty reflect.Type) []hcl.Trave
 			// we don't care about these, they complicate things
 			continue
 		}
+		if field.Type == reflect.TypeOf((*hcl.Attribute)(nil)) {
+			// the attribute is evaluated by the caller, e.g. as a type
+			// expression with TypeExpression, whose keywords are not
+			// variables
+			continue
+		}
 		name := strings.Split(tag, ",")[0]
 		fieldByTagName[name] = nestedElem(field.Type)
 	}
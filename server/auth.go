@@ -16,9 +16,9 @@ import (
 // @Tags authorize
 // @Accept json
 // @Produce json
-// @Success 200 {object} apiResponse
-// @Failure 400 {object} apiResponse
-// @Router /authorize [post]
+// @Success 200 {object} component.MessageAuth
+// @Failure 403 {object} HTTPError
+// @Router /authorize [get]
 func (s *Server) authorize(c echo.Context) error {
 	auth := s.getAuthFromContext(c)
 	// Create a new request to get the authorization information
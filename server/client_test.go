@@ -0,0 +1,103 @@
+package server
+
+import (
+	"fmt"
+
+	"github.com/valocode/bubbly/agent/component"
+	"github.com/valocode/bubbly/client"
+	"github.com/valocode/bubbly/env"
+)
+
+// fakeClient is a client.Client of an empty store, which the clients of the
+// tests embed and override the methods of. Its methods do not fail, except
+// that it has no resources, and its store has a schema so that requests are
+// not rejected as not ready
+type fakeClient struct{}
+
+var _ client.Client = fakeClient{}
+
+func (fakeClient) GetResource(_ *env.BubblyContext, _ *component.MessageAuth, id string, _ ...client.ResourceOption) ([]byte, error) {
+	return nil, fmt.Errorf("%w: %s", client.ErrResourceNotFound, id)
+}
+
+func (fakeClient) GetResourceVersions(_ *env.BubblyContext, _ *component.MessageAuth, id string) ([]byte, error) {
+	return nil, fmt.Errorf("%w: %s", client.ErrResourceNotFound, id)
+}
+
+func (fakeClient) GetResourceVersion(_ *env.BubblyContext, _ *component.MessageAuth, id string, _ int, _ ...client.ResourceOption) ([]byte, error) {
+	return nil, fmt.Errorf("%w: %s", client.ErrResourceNotFound, id)
+}
+
+func (fakeClient) GetResourcesByKind(*env.BubblyContext, *component.MessageAuth, string, string) ([]byte, error) {
+	return []byte(`[]`), nil
+}
+
+func (fakeClient) PostResource(*env.BubblyContext, *component.MessageAuth, []byte) error {
+	return nil
+}
+
+func (fakeClient) PostResources(*env.BubblyContext, *component.MessageAuth, []byte) error {
+	return nil
+}
+
+func (fakeClient) PostResourceToWorker(*env.BubblyContext, *component.MessageAuth, []byte) error {
+	return nil
+}
+
+func (fakeClient) DeleteResource(_ *env.BubblyContext, _ *component.MessageAuth, id string) error {
+	return fmt.Errorf("%w: %s", client.ErrResourceNotFound, id)
+}
+
+func (fakeClient) PatchResource(_ *env.BubblyContext, _ *component.MessageAuth, id string, _ []byte) error {
+	return fmt.Errorf("%w: %s", client.ErrResourceNotFound, id)
+}
+
+func (fakeClient) Load(*env.BubblyContext, *component.MessageAuth, []byte, ...client.LoadOption) error {
+	return nil
+}
+
+func (fakeClient) Query(*env.BubblyContext, *component.MessageAuth, string, ...client.QueryOption) ([]byte, error) {
+	return []byte(`{"data":{}}`), nil
+}
+
+func (fakeClient) QueryType(*env.BubblyContext, *component.MessageAuth, string, interface{}) error {
+	return nil
+}
+
+func (fakeClient) QueryCSV(*env.BubblyContext, *component.MessageAuth, string) ([]byte, error) {
+	return []byte{}, nil
+}
+
+func (fakeClient) PostSchema(*env.BubblyContext, *component.MessageAuth, []byte) error {
+	return nil
+}
+
+func (fakeClient) PreviewSchema(*env.BubblyContext, *component.MessageAuth, []byte) ([]byte, error) {
+	return []byte(`[]`), nil
+}
+
+func (fakeClient) GetSchemaSDL(*env.BubblyContext, *component.MessageAuth) ([]byte, error) {
+	return []byte{}, nil
+}
+
+func (fakeClient) GetSchema(*env.BubblyContext, *component.MessageAuth) ([]byte, error) {
+	return []byte(`{}`), nil
+}
+
+func (fakeClient) DescribeSchema(*env.BubblyContext, *component.MessageAuth) ([]byte, error) {
+	return []byte(`{}`), nil
+}
+
+func (fakeClient) GetStatus(*env.BubblyContext, *component.MessageAuth) ([]byte, error) {
+	return []byte(`{}`), nil
+}
+
+func (fakeClient) Version(*env.BubblyContext) (string, error) {
+	return "", nil
+}
+
+func (fakeClient) CreateTenant(*env.BubblyContext, *component.MessageAuth, string) error {
+	return nil
+}
+
+func (fakeClient) Close() {}
@@ -0,0 +1,106 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Error codes for the errors that the clients can handle specifically. Other
+// errors get a code based on their HTTP status, see statusErrorCode
+const (
+	errCodeResourceNotFound = "resource_not_found"
+	errCodeInvalidResource  = "invalid_resource"
+	errCodeInvalidData      = "invalid_data"
+	errCodeStoreNotFound    = "store_not_found"
+	errCodeStoreNotReady    = "store_not_ready"
+)
+
+// apiError is an error returned by a handler with a specific code and details
+// for the error response
+type apiError struct {
+	status  int
+	code    string
+	message string
+	details []string
+}
+
+func (e *apiError) Error() string {
+	return e.message
+}
+
+// newAPIError returns an error that the HTTP error handler writes as a
+// response with the given status, code and details
+func newAPIError(status int, code string, message string, details ...string) *apiError {
+	return &apiError{
+		status:  status,
+		code:    code,
+		message: message,
+		details: details,
+	}
+}
+
+// statusErrorCode returns the error code for an HTTP status, which is the
+// status text in snake case, e.g. "bad_request" for 400
+func statusErrorCode(status int) string {
+	text := http.StatusText(status)
+	if text == "" {
+		return "unknown_error"
+	}
+	text = strings.NewReplacer("-", " ", "'", "").Replace(strings.ToLower(text))
+	return strings.Join(strings.Fields(text), "_")
+}
+
+// errorResponse returns the status and body of the error response for an
+// error returned by a handler or middleware
+func errorResponse(err error) (int, HTTPError) {
+	var (
+		apiErr  *apiError
+		httpErr *echo.HTTPError
+	)
+	switch {
+	case errors.As(err, &apiErr):
+		return apiErr.status, HTTPError{Err: HTTPErrorBody{
+			Code:    apiErr.code,
+			Message: apiErr.message,
+			Details: apiErr.details,
+		}}
+	case errors.As(err, &httpErr):
+		body := HTTPErrorBody{
+			Code:    statusErrorCode(httpErr.Code),
+			Message: fmt.Sprint(httpErr.Message),
+		}
+		if httpErr.Internal != nil {
+			body.Details = []string{httpErr.Internal.Error()}
+		}
+		return httpErr.Code, HTTPError{Err: body}
+	default:
+		// Unexpected errors are not described to the client, as with echo's
+		// default error handler
+		return http.StatusInternalServerError, HTTPError{Err: HTTPErrorBody{
+			Code:    statusErrorCode(http.StatusInternalServerError),
+			Message: http.StatusText(http.StatusInternalServerError),
+		}}
+	}
+}
+
+// writeError writes the error response for an error, unless a response has
+// already been written
+func writeError(c echo.Context, err error) {
+	if c.Response().Committed {
+		return
+	}
+	status, body := errorResponse(err)
+	var writeErr error
+	if c.Request().Method == http.MethodHead {
+		writeErr = c.NoContent(status)
+	} else {
+		writeErr = c.JSON(status, body)
+	}
+	if writeErr != nil {
+		c.Logger().Error(writeErr)
+	}
+}
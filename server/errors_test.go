@@ -0,0 +1,150 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/valocode/bubbly/env"
+)
+
+func TestStatusErrorCode(t *testing.T) {
+	assert.Equal(t, "bad_request", statusErrorCode(http.StatusBadRequest))
+	assert.Equal(t, "not_found", statusErrorCode(http.StatusNotFound))
+	assert.Equal(t, "request_entity_too_large", statusErrorCode(http.StatusRequestEntityTooLarge))
+	assert.Equal(t, "im_a_teapot", statusErrorCode(http.StatusTeapot))
+	assert.Equal(t, "non_authoritative_information", statusErrorCode(http.StatusNonAuthoritativeInfo))
+	assert.Equal(t, "unknown_error", statusErrorCode(599))
+}
+
+func TestErrorResponse(t *testing.T) {
+	tcs := []struct {
+		desc     string
+		err      error
+		status   int
+		expected HTTPErrorBody
+	}{
+		{
+			desc:     "api error",
+			err:      newAPIError(http.StatusUnprocessableEntity, errCodeInvalidResource, "invalid", "a", "b"),
+			status:   http.StatusUnprocessableEntity,
+			expected: HTTPErrorBody{Code: errCodeInvalidResource, Message: "invalid", Details: []string{"a", "b"}},
+		},
+		{
+			desc:     "echo error",
+			err:      echo.NewHTTPError(http.StatusBadRequest, errors.New("bad query")),
+			status:   http.StatusBadRequest,
+			expected: HTTPErrorBody{Code: "bad_request", Message: "bad query"},
+		},
+		{
+			desc:     "echo error with internal error",
+			err:      echo.NewHTTPError(http.StatusBadGateway, "upstream failed").SetInternal(errors.New("connection refused")),
+			status:   http.StatusBadGateway,
+			expected: HTTPErrorBody{Code: "bad_gateway", Message: "upstream failed", Details: []string{"connection refused"}},
+		},
+		{
+			desc:     "unexpected error",
+			err:      errors.New("secret internals"),
+			status:   http.StatusInternalServerError,
+			expected: HTTPErrorBody{Code: "internal_server_error", Message: "Internal Server Error"},
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.desc, func(t *testing.T) {
+			status, body := errorResponse(tc.err)
+			assert.Equal(t, tc.status, status)
+			assert.Equal(t, tc.expected, body.Err)
+		})
+	}
+}
+
+// TestHandlerErrors checks that the errors of different handlers and
+// middleware all have the same body
+func TestHandlerErrors(t *testing.T) {
+	tcs := []struct {
+		desc   string
+		method string
+		path   string
+		body   string
+		status int
+		code   string
+	}{
+		{
+			desc:   "unknown route",
+			method: http.MethodGet,
+			path:   "/api/v1/unknown",
+			status: http.StatusNotFound,
+			code:   "not_found",
+		},
+		{
+			desc:   "invalid query",
+			method: http.MethodPost,
+			path:   "/api/v1/graphql?explain=maybe",
+			body:   `{"query":"{ _resource { id } }"}`,
+			status: http.StatusBadRequest,
+			code:   "bad_request",
+		},
+		{
+			desc:   "body too large",
+			method: http.MethodPost,
+			path:   "/api/v1/resource",
+			body:   strings.Repeat("x", 2048),
+			status: http.StatusRequestEntityTooLarge,
+			code:   "request_entity_too_large",
+		},
+		{
+			desc:   "delete missing resource",
+			method: http.MethodDelete,
+			path:   "/api/v1/resource/extract/other",
+			status: http.StatusNotFound,
+			code:   errCodeResourceNotFound,
+		},
+		{
+			desc:   "invalid patch",
+			method: http.MethodPatch,
+			path:   "/api/v1/resource/extract/junit",
+			body:   `{"name":"other"}`,
+			status: http.StatusUnprocessableEntity,
+			code:   errCodeInvalidResource,
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.desc, func(t *testing.T) {
+			bCtx := env.NewBubblyContext()
+			bCtx.ServerConfig.MaxRequestBodySize = 1024
+			s := NewWithClient(bCtx, &errorsClient{
+				patchClient: patchClient{resources: map[string][]byte{
+					"extract/junit": []byte(`{"kind":"extract","name":"junit","api_version":"v1","spec":"input \"file\" {}"}`),
+				}},
+			})
+
+			req, err := http.NewRequest(tc.method, tc.path, strings.NewReader(tc.body))
+			require.NoError(t, err)
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			s.setupRouter().ServeHTTP(w, req)
+
+			require.Equal(t, tc.status, w.Code, w.Body.String())
+			assert.Contains(t, w.Header().Get("Content-Type"), "application/json")
+
+			var body map[string]map[string]interface{}
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body), w.Body.String())
+			require.Contains(t, body, "error")
+			assert.Equal(t, tc.code, body["error"]["code"])
+			assert.NotEmpty(t, body["error"]["message"])
+		})
+	}
+}
+
+// errorsClient is a client.Client which patches resources like the
+// patchClient, and, like the fakeClient, has no resources to delete
+type errorsClient struct {
+	patchClient
+}
@@ -1,31 +1,30 @@
 package server
 
 import (
+	"fmt"
 	"net/http"
 
 	"github.com/labstack/echo/v4"
+
+	"github.com/valocode/bubbly/client"
 )
 
 type queryReq struct {
 	Query string `json:"query"`
 }
 
-// TODO: fix Swagger return types!
-type apiResponse struct {
-	Code    int    `json:"code" example:"200"`
-	Message string `json:"message" example:"data"`
-}
-
 // Query godoc
 // @Summary Query performs graphql related tasks
 // @ID graphql
 // @Tags graphql
 // @Param query body queryReq true "Query String"
+// @Param explain query string false "Return the SQL of the query (true), and also its plan (analyze), in the extensions of the result. Requires explain to be enabled in the store" Enums(true, analyze)
+// @Param ordered query bool false "Return the fields of the objects in the data in the order that they are selected in the query, instead of sorted by name"
 // @Accept json
 // @Produce json
-// @Success 200 {object} apiResponse
-// @Failure 400 {object} apiResponse
-// @Failure 404 {object} apiResponse
+// @Success 200 {object} object
+// @Failure 400 {object} HTTPError
+// @Failure 503 {object} HTTPError
 // @Router /graphql [post]
 func (s *Server) Query(c echo.Context) error {
 	var query queryReq
@@ -34,11 +33,39 @@ func (s *Server) Query(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
 	}
 
+	var opts []client.QueryOption
+	switch explain := c.QueryParam("explain"); explain {
+	case "", "false":
+	case "true":
+		opts = append(opts, client.WithExplain())
+	case "analyze":
+		opts = append(opts, client.WithExplainAnalyze())
+	default:
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("invalid value for explain: %s", explain))
+	}
+
+	var ordered bool
+	switch o := c.QueryParam("ordered"); o {
+	case "", "false":
+	case "true":
+		ordered = true
+	default:
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("invalid value for ordered: %s", o))
+	}
+
 	auth := s.getAuthFromContext(c)
-	results, err := s.Client.Query(s.bCtx, auth, query.Query)
+	results, err := s.storeClient(c).Query(s.bCtx, auth, query.Query, opts...)
 	if err != nil {
 		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
 	}
+	if ordered {
+		// The store encodes the result with the fields sorted by name, so
+		// encode it again in the order of the query
+		results, err = client.OrderedResult(query.Query, results)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+	}
 
 	return c.JSONBlob(http.StatusOK, results)
 }
@@ -7,6 +7,9 @@ import (
 	"github.com/appleboy/gofight/v2"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/valocode/bubbly/agent/component"
+	"github.com/valocode/bubbly/client"
 	"github.com/valocode/bubbly/env"
 	testData "github.com/valocode/bubbly/server/testdata/upload"
 )
@@ -53,3 +56,124 @@ func IntegrationTestQueryFail(t *testing.T) {
 			assert.Equal(t, http.StatusBadRequest, r.Code)
 		})
 }
+
+// queryClient is a client.Client that records how queries are explained,
+// and returns result for them, or an empty result
+type queryClient struct {
+	fakeClient
+	explain *component.QueryExplain
+	result  []byte
+}
+
+func (c *queryClient) Query(_ *env.BubblyContext, _ *component.MessageAuth, query string, opts ...client.QueryOption) ([]byte, error) {
+	c.explain, _ = client.ExplainRequest(query, opts...)
+	if c.result != nil {
+		return c.result, nil
+	}
+	return []byte(`{"data":{}}`), nil
+}
+
+func TestQueryExplain(t *testing.T) {
+	tcs := []struct {
+		desc    string
+		param   string
+		code    int
+		explain *component.QueryExplain
+	}{
+		{
+			desc:  "no explain",
+			param: "",
+			code:  http.StatusOK,
+		},
+		{
+			desc:    "explain",
+			param:   "?explain=true",
+			code:    http.StatusOK,
+			explain: &component.QueryExplain{Query: "{ root { name } }"},
+		},
+		{
+			desc:    "explain analyze",
+			param:   "?explain=analyze",
+			code:    http.StatusOK,
+			explain: &component.QueryExplain{Query: "{ root { name } }", Analyze: true},
+		},
+		{
+			desc:  "invalid explain",
+			param: "?explain=yes",
+			code:  http.StatusBadRequest,
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.desc, func(t *testing.T) {
+			bCtx := env.NewBubblyContext()
+			s, err := New(bCtx)
+			require.NoError(t, err)
+			c := &queryClient{}
+			s.Client = c
+
+			r := gofight.New()
+			r.POST("/api/v1/graphql"+tc.param).
+				SetJSON(gofight.D{"query": "{ root { name } }"}).
+				Run(s.setupRouter(), func(r gofight.HTTPResponse, rq gofight.HTTPRequest) {
+					assert.Equal(t, tc.code, r.Code)
+				})
+			assert.Equal(t, tc.explain, c.explain)
+		})
+	}
+}
+
+func TestQueryOrdered(t *testing.T) {
+	// The store encodes the fields of a result sorted by name
+	const result = `{"data":{"root":[{"_id":"1","name":"bubbly"},{"_id":"2","name":"other"}]}}`
+	tcs := []struct {
+		desc     string
+		param    string
+		code     int
+		expected string
+	}{
+		{
+			desc:     "not ordered",
+			param:    "",
+			code:     http.StatusOK,
+			expected: result,
+		},
+		{
+			desc:     "ordered",
+			param:    "?ordered=true",
+			code:     http.StatusOK,
+			expected: `{"data":{"root":[{"name":"bubbly","_id":"1"},{"name":"other","_id":"2"}]}}`,
+		},
+		{
+			desc:     "ordered with explain",
+			param:    "?ordered=true&explain=true",
+			code:     http.StatusOK,
+			expected: `{"data":{"root":[{"name":"bubbly","_id":"1"},{"name":"other","_id":"2"}]}}`,
+		},
+		{
+			desc:  "invalid ordered",
+			param: "?ordered=yes",
+			code:  http.StatusBadRequest,
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.desc, func(t *testing.T) {
+			bCtx := env.NewBubblyContext()
+			s, err := New(bCtx)
+			require.NoError(t, err)
+			s.Client = &queryClient{result: []byte(result)}
+
+			// Repeated queries give the same bytes
+			for i := 0; i < 5; i++ {
+				r := gofight.New()
+				r.POST("/api/v1/graphql"+tc.param).
+					SetJSON(gofight.D{"query": "{ root { name _id } }"}).
+					Run(s.setupRouter(), func(r gofight.HTTPResponse, rq gofight.HTTPRequest) {
+						assert.Equal(t, tc.code, r.Code)
+						if tc.expected != "" {
+							assert.Equal(t, tc.expected, r.Body.String())
+						}
+					})
+			}
+		})
+	}
+}
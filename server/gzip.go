@@ -0,0 +1,109 @@
+package server
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+const gzipEncoding = "gzip"
+
+// gzipMiddleware compresses responses with gzip for clients that accept it,
+// as given by the Accept-Encoding header. Responses smaller than the
+// configured minimum size are not compressed, as it is not worth it
+func (s *Server) gzipMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		res := c.Response()
+		res.Header().Add(echo.HeaderVary, echo.HeaderAcceptEncoding)
+		if !strings.Contains(c.Request().Header.Get(echo.HeaderAcceptEncoding), gzipEncoding) {
+			return next(c)
+		}
+
+		w := &gzipResponseWriter{
+			ResponseWriter: res.Writer,
+			minSize:        s.Config.CompressionMinSize,
+		}
+		res.Writer = w
+		err := next(c)
+		// Restore the writer, so that the error handler can write the error
+		// response without compression
+		res.Writer = w.ResponseWriter
+		if closeErr := w.close(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+		return err
+	}
+}
+
+// gzipResponseWriter buffers the response until it reaches the minimum size
+// for compression, and then compresses the rest of the response. If the
+// response is complete before reaching the minimum size, it is written
+// uncompressed
+type gzipResponseWriter struct {
+	http.ResponseWriter
+	minSize int
+
+	// status is the status code of the response, which is only written once
+	// it is known whether the response is compressed
+	status int
+	buf    bytes.Buffer
+	// out is where the rest of the response is written, once it is known
+	// whether the response is compressed
+	out io.Writer
+	gz  *gzip.Writer
+}
+
+func (w *gzipResponseWriter) WriteHeader(code int) {
+	w.status = code
+}
+
+func (w *gzipResponseWriter) Write(b []byte) (int, error) {
+	if w.out != nil {
+		return w.out.Write(b)
+	}
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	w.buf.Write(b)
+	if w.buf.Len() < w.minSize {
+		return len(b), nil
+	}
+
+	// The response is large enough to compress, unless the handler has
+	// already encoded it
+	header := w.Header()
+	if header.Get(echo.HeaderContentEncoding) == "" {
+		// The length of the compressed response is not known up front
+		header.Del(echo.HeaderContentLength)
+		header.Set(echo.HeaderContentEncoding, gzipEncoding)
+		w.gz = gzip.NewWriter(w.ResponseWriter)
+		w.out = w.gz
+	} else {
+		w.out = w.ResponseWriter
+	}
+	w.ResponseWriter.WriteHeader(w.status)
+	if _, err := w.out.Write(w.buf.Bytes()); err != nil {
+		return 0, err
+	}
+	w.buf.Reset()
+	return len(b), nil
+}
+
+// close finishes writing the response, either by flushing the compressed
+// response, or by writing the buffered response uncompressed
+func (w *gzipResponseWriter) close() error {
+	if w.gz != nil {
+		return w.gz.Close()
+	}
+	if w.out != nil || w.status == 0 {
+		// The response is already written, or nothing was written
+		return nil
+	}
+	w.ResponseWriter.WriteHeader(w.status)
+	_, err := w.ResponseWriter.Write(w.buf.Bytes())
+	return err
+}
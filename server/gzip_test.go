@@ -0,0 +1,117 @@
+package server
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/valocode/bubbly/agent/component"
+	"github.com/valocode/bubbly/client"
+	"github.com/valocode/bubbly/env"
+)
+
+// resultClient is a client.Client that returns the same result for every
+// query
+type resultClient struct {
+	fakeClient
+	result string
+}
+
+func (c *resultClient) Query(*env.BubblyContext, *component.MessageAuth, string, ...client.QueryOption) ([]byte, error) {
+	return []byte(c.result), nil
+}
+
+func TestGzip(t *testing.T) {
+	const minSize = 1024
+	smallResult := `{"data":{"root":[{"name":"root"}]}}`
+	largeResult := `{"data":{"root":[` + strings.Repeat(`{"name":"root"},`, minSize) + `{"name":"root"}]}}`
+	tcs := []struct {
+		desc           string
+		result         string
+		acceptEncoding string
+		compressed     bool
+	}{
+		{
+			desc:           "large response",
+			result:         largeResult,
+			acceptEncoding: "gzip, deflate",
+			compressed:     true,
+		},
+		{
+			desc:           "small response",
+			result:         smallResult,
+			acceptEncoding: "gzip, deflate",
+		},
+		{
+			desc:   "gzip not accepted",
+			result: largeResult,
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.desc, func(t *testing.T) {
+			bCtx := env.NewBubblyContext()
+			bCtx.ServerConfig.CompressionMinSize = minSize
+			s := NewWithClient(bCtx, &resultClient{result: tc.result})
+
+			req, err := http.NewRequest(http.MethodPost, "/api/v1/graphql", strings.NewReader(`{"query":"{ root { name } }"}`))
+			require.NoError(t, err)
+			req.Header.Set("Content-Type", "application/json")
+			if tc.acceptEncoding != "" {
+				req.Header.Set("Accept-Encoding", tc.acceptEncoding)
+			}
+
+			w := httptest.NewRecorder()
+			s.setupRouter().ServeHTTP(w, req)
+			require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+			assert.Equal(t, "Accept-Encoding", w.Header().Get("Vary"))
+
+			if !tc.compressed {
+				assert.Empty(t, w.Header().Get("Content-Encoding"))
+				assert.Equal(t, tc.result, w.Body.String())
+				return
+			}
+			assert.Equal(t, "gzip", w.Header().Get("Content-Encoding"))
+			assert.Less(t, w.Body.Len(), len(tc.result))
+			gz, err := gzip.NewReader(w.Body)
+			require.NoError(t, err)
+			body, err := io.ReadAll(gz)
+			require.NoError(t, err)
+			assert.Equal(t, tc.result, string(body))
+		})
+	}
+}
+
+// TestGzipClient verifies that the HTTP client decodes a compressed query
+// response from the server
+func TestGzipClient(t *testing.T) {
+	bCtx := env.NewBubblyContext()
+	bCtx.ServerConfig.CompressionMinSize = 1024
+	result := `{"data":{"root":[` + strings.Repeat(`{"name":"root"},`, 1024) + `{"name":"root"}]}}`
+	s := NewWithClient(bCtx, &resultClient{result: result})
+
+	// Record whether the server compressed the response
+	var contentEncoding string
+	router := s.setupRouter()
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		router.ServeHTTP(w, r)
+		contentEncoding = w.Header().Get("Content-Encoding")
+	}))
+	defer ts.Close()
+
+	clientCtx := env.NewBubblyContext()
+	clientCtx.ClientConfig.BubblyAddr = ts.URL + "/api/v1"
+	c, err := client.New(clientCtx)
+	require.NoError(t, err)
+	defer c.Close()
+
+	actual, err := c.Query(clientCtx, nil, "{ root { name } }")
+	require.NoError(t, err)
+	assert.Equal(t, "gzip", contentEncoding)
+	assert.Equal(t, result, string(actual))
+}
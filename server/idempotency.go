@@ -0,0 +1,185 @@
+package server
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/valocode/bubbly/client"
+)
+
+// idempotencyKeyTTL is how long the response to a request with an
+// idempotency key is kept, and returned for retries of the request
+const idempotencyKeyTTL = 10 * time.Minute
+
+// idempotencyCache keeps the responses to requests with an idempotency key,
+// so that retries of a request get the original response instead of being
+// applied again
+type idempotencyCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[string]*idempotencyEntry
+}
+
+// idempotencyEntry is the response to a request with an idempotency key
+type idempotencyEntry struct {
+	// bodyHash is the SHA-256 hash of the body of the original request, so
+	// that a request that reuses the key with another body can be rejected
+	bodyHash [sha256.Size]byte
+	// done is closed once the original request has completed
+	done    chan struct{}
+	expires time.Time
+
+	status      int
+	contentType string
+	body        []byte
+}
+
+func newIdempotencyCache(ttl time.Duration) *idempotencyCache {
+	return &idempotencyCache{
+		ttl:     ttl,
+		entries: make(map[string]*idempotencyEntry),
+	}
+}
+
+// start returns the entry for the key, and whether the request is new. If it
+// is new, the entry is for a request with the body hash bodyHash, and the
+// caller must complete it with either finish or abort
+func (i *idempotencyCache) start(key string, bodyHash [sha256.Size]byte) (*idempotencyEntry, bool) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	now := time.Now()
+	// Remove the expired entries, so that the cache does not grow forever
+	for k, e := range i.entries {
+		if !e.expires.IsZero() && !now.Before(e.expires) {
+			delete(i.entries, k)
+		}
+	}
+	if e, ok := i.entries[key]; ok {
+		return e, false
+	}
+	e := &idempotencyEntry{bodyHash: bodyHash, done: make(chan struct{})}
+	i.entries[key] = e
+	return e, true
+}
+
+// finish stores the response of the original request for the retries
+func (i *idempotencyCache) finish(e *idempotencyEntry, status int, contentType string, body []byte) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	e.status = status
+	e.contentType = contentType
+	e.body = body
+	e.expires = time.Now().Add(i.ttl)
+	close(e.done)
+}
+
+// abort removes the entry for a request that failed, so that a retry of the
+// request is applied again
+func (i *idempotencyCache) abort(key string, e *idempotencyEntry) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	delete(i.entries, key)
+	close(e.done)
+}
+
+// idempotencyMiddleware makes requests with an idempotency key happen at most
+// once: a retry of a successful request returns the original response. A
+// retry that arrives while the original request is still in progress waits
+// for it to complete. Requests that fail are not remembered, so that they can
+// be retried. A request that reuses the key of a request with another body is
+// not a retry, and is rejected as unprocessable
+func (s *Server) idempotencyMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		key := c.Request().Header.Get(client.HeaderIdempotencyKey)
+		if key == "" {
+			return next(c)
+		}
+		// Keys are only unique per organization and store
+		key = c.Param("organization") + "/" + storeName(c) + "/" + key
+
+		// Read the body to hash it, and put it back for the handler
+		req := c.Request()
+		body, err := io.ReadAll(req.Body)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("failed to read request body: %s", err))
+		}
+		req.Body = io.NopCloser(bytes.NewReader(body))
+		bodyHash := sha256.Sum256(body)
+
+		for {
+			e, isNew := s.idempotency.start(key, bodyHash)
+			if !isNew {
+				if e.bodyHash != bodyHash {
+					return echo.NewHTTPError(
+						http.StatusUnprocessableEntity,
+						fmt.Sprintf("%s was already used for a request with a different body", client.HeaderIdempotencyKey),
+					)
+				}
+				<-e.done
+				if e.status == 0 {
+					// The original request failed, so try to apply this one
+					continue
+				}
+				return c.Blob(e.status, e.contentType, e.body)
+			}
+
+			return s.handleIdempotent(c, next, key, e)
+		}
+	}
+}
+
+// handleIdempotent handles the original request for an idempotency key, and
+// stores its response if it succeeds
+func (s *Server) handleIdempotent(c echo.Context, next echo.HandlerFunc, key string, e *idempotencyEntry) error {
+	res := c.Response()
+	w := &recordingResponseWriter{ResponseWriter: res.Writer}
+	res.Writer = w
+
+	succeeded := false
+	defer func() {
+		res.Writer = w.ResponseWriter
+		// This also releases the waiting retries if the handler panics
+		if !succeeded {
+			s.idempotency.abort(key, e)
+		}
+	}()
+
+	if err := next(c); err != nil {
+		return err
+	}
+	if w.status < 200 || w.status >= 300 {
+		return nil
+	}
+	succeeded = true
+	s.idempotency.finish(e, w.status, res.Header().Get(echo.HeaderContentType), w.body.Bytes())
+	return nil
+}
+
+// recordingResponseWriter records the status and body of a response while
+// writing it
+type recordingResponseWriter struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (w *recordingResponseWriter) WriteHeader(code int) {
+	w.status = code
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *recordingResponseWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
@@ -0,0 +1,124 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/valocode/bubbly/agent/component"
+	"github.com/valocode/bubbly/client"
+	"github.com/valocode/bubbly/env"
+)
+
+// postingClient is a client.Client that counts the posted resources, and
+// fails to post them while err is set
+type postingClient struct {
+	fakeClient
+	posted int
+	err    error
+}
+
+func (c *postingClient) PostResource(*env.BubblyContext, *component.MessageAuth, []byte) error {
+	if c.err != nil {
+		return c.err
+	}
+	c.posted++
+	return nil
+}
+
+func TestIdempotencyKey(t *testing.T) {
+	const resource = `{"kind":"extract","name":"junit","spec":"input \"file\" {}"}`
+	// postBody posts body to the server, with the idempotency key if it is
+	// not empty
+	postBody := func(t *testing.T, s *Server, key string, body string) *httptest.ResponseRecorder {
+		t.Helper()
+		req, err := http.NewRequest(http.MethodPost, "/api/v1/resource", strings.NewReader(body))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+		if key != "" {
+			req.Header.Set(client.HeaderIdempotencyKey, key)
+		}
+		w := httptest.NewRecorder()
+		s.setupRouter().ServeHTTP(w, req)
+		return w
+	}
+	// post posts a resource to the server, with the idempotency key if it is
+	// not empty
+	post := func(t *testing.T, s *Server, key string) *httptest.ResponseRecorder {
+		t.Helper()
+		return postBody(t, s, key, resource)
+	}
+
+	t.Run("same key", func(t *testing.T) {
+		c := &postingClient{}
+		s := NewWithClient(env.NewBubblyContext(), c)
+
+		first := post(t, s, "key-1")
+		second := post(t, s, "key-1")
+		require.Equal(t, http.StatusOK, first.Code, first.Body.String())
+		assert.Equal(t, 1, c.posted)
+		assert.Equal(t, first.Code, second.Code)
+		assert.Equal(t, first.Body.String(), second.Body.String())
+		assert.Equal(t, first.Header().Get("Content-Type"), second.Header().Get("Content-Type"))
+	})
+
+	t.Run("same key with a different body", func(t *testing.T) {
+		c := &postingClient{}
+		s := NewWithClient(env.NewBubblyContext(), c)
+
+		first := post(t, s, "key-1")
+		require.Equal(t, http.StatusOK, first.Code, first.Body.String())
+		other := postBody(t, s, "key-1", `{"kind":"extract","name":"other","spec":"input \"file\" {}"}`)
+		assert.Equal(t, http.StatusUnprocessableEntity, other.Code)
+		assert.Contains(t, other.Body.String(), "Idempotency-Key was already used for a request with a different body")
+		// A retry with the original body still gets the original response
+		retry := post(t, s, "key-1")
+		assert.Equal(t, first.Code, retry.Code)
+		assert.Equal(t, first.Body.String(), retry.Body.String())
+		assert.Equal(t, 1, c.posted)
+	})
+
+	t.Run("different keys", func(t *testing.T) {
+		c := &postingClient{}
+		s := NewWithClient(env.NewBubblyContext(), c)
+
+		assert.Equal(t, http.StatusOK, post(t, s, "key-1").Code)
+		assert.Equal(t, http.StatusOK, post(t, s, "key-2").Code)
+		assert.Equal(t, 2, c.posted)
+	})
+
+	t.Run("no key", func(t *testing.T) {
+		c := &postingClient{}
+		s := NewWithClient(env.NewBubblyContext(), c)
+
+		assert.Equal(t, http.StatusOK, post(t, s, "").Code)
+		assert.Equal(t, http.StatusOK, post(t, s, "").Code)
+		assert.Equal(t, 2, c.posted)
+	})
+
+	t.Run("failed request is not remembered", func(t *testing.T) {
+		c := &postingClient{err: errors.New("store unavailable")}
+		s := NewWithClient(env.NewBubblyContext(), c)
+
+		assert.Equal(t, http.StatusBadRequest, post(t, s, "key-1").Code)
+		c.err = nil
+		assert.Equal(t, http.StatusOK, post(t, s, "key-1").Code)
+		assert.Equal(t, http.StatusOK, post(t, s, "key-1").Code)
+		assert.Equal(t, 1, c.posted)
+	})
+
+	t.Run("expired key", func(t *testing.T) {
+		c := &postingClient{}
+		s := NewWithClient(env.NewBubblyContext(), c)
+		s.idempotency = newIdempotencyCache(0)
+
+		assert.Equal(t, http.StatusOK, post(t, s, "key-1").Code)
+		assert.Equal(t, http.StatusOK, post(t, s, "key-1").Code)
+		assert.Equal(t, 2, c.posted)
+	})
+}
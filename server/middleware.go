@@ -2,6 +2,8 @@ package server
 
 import (
 	"encoding/json"
+	"fmt"
+	"io"
 	"net/http"
 	"strings"
 
@@ -15,8 +17,9 @@ func (s *Server) authMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
 			authHeader = c.Request().Header.Get(echo.HeaderAuthorization)
 			client     = &http.Client{}
 		)
-		// Make an exception that /healthz does not require authentication
-		if c.Path() == "/healthz" {
+		// Make an exception that /healthz and /readyz do not require
+		// authentication
+		if c.Path() == "/healthz" || c.Path() == "/readyz" {
 			return next(c)
 		}
 		// Ignore requests to swagger documentation
@@ -64,3 +67,51 @@ func (s *Server) authMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
 		return next(c)
 	}
 }
+
+// bodyLimitMiddleware limits the size of the request body to the configured
+// maximum, so that a huge request cannot exhaust the server's memory. Requests
+// with a larger body get the status 413 Request Entity Too Large
+func (s *Server) bodyLimitMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		limit := s.Config.MaxRequestBodySize
+		if limit <= 0 {
+			return next(c)
+		}
+		tooLarge := echo.NewHTTPError(
+			http.StatusRequestEntityTooLarge,
+			fmt.Sprintf("request body exceeds the maximum size of %d bytes", limit),
+		)
+		req := c.Request()
+		// Fail fast if the request says up front that its body is too large
+		if req.ContentLength > limit {
+			return tooLarge
+		}
+		// Otherwise the body is limited while the handler reads it, e.g. for
+		// a chunked request without a content length
+		body := &limitedBody{ReadCloser: http.MaxBytesReader(c.Response(), req.Body, limit)}
+		req.Body = body
+		err := next(c)
+		// The handlers report errors reading the body as bad requests
+		if body.exceeded {
+			return tooLarge
+		}
+		return err
+	}
+}
+
+// limitedBody is a request body limited by http.MaxBytesReader, which records
+// whether the limit was exceeded
+type limitedBody struct {
+	io.ReadCloser
+	exceeded bool
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	// http.MaxBytesReader does not return a typed error, so the message is
+	// the only way to tell that the limit was exceeded
+	if err != nil && err.Error() == "http: request body too large" {
+		b.exceeded = true
+	}
+	return n, err
+}
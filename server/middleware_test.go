@@ -0,0 +1,145 @@
+package server
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/valocode/bubbly/agent/component"
+	"github.com/valocode/bubbly/client"
+	"github.com/valocode/bubbly/env"
+)
+
+// bodyClient is a client.Client that accepts every request with a body
+type bodyClient struct {
+	fakeClient
+}
+
+func (c *bodyClient) PostResource(*env.BubblyContext, *component.MessageAuth, []byte) error {
+	return nil
+}
+
+func (c *bodyClient) Load(*env.BubblyContext, *component.MessageAuth, []byte, ...client.LoadOption) error {
+	return nil
+}
+
+// QueryType returns a schema with the table "a", so that the uploaded data
+// blocks of that table are valid
+func (c *bodyClient) QueryType(_ *env.BubblyContext, _ *component.MessageAuth, _ string, ptr interface{}) error {
+	return json.Unmarshal([]byte(`{"_schema":[{"tables":"{\"a\":{\"name\":\"a\"}}"}]}`), ptr)
+}
+
+func (c *bodyClient) Query(*env.BubblyContext, *component.MessageAuth, string, ...client.QueryOption) ([]byte, error) {
+	return []byte(`{"data":{}}`), nil
+}
+
+func TestBodyLimit(t *testing.T) {
+	const limit = 64
+	// jsonBody returns a JSON body of the given size, with a single string
+	// attribute
+	jsonBody := func(attr string, size int) string {
+		prefix := `{"` + attr + `":"`
+		suffix := `"}`
+		return prefix + strings.Repeat("a", size-len(prefix)-len(suffix)) + suffix
+	}
+	// dataBody returns a valid list of data blocks of the given size, padded
+	// with whitespace, so that the handler accepts it when it is under the
+	// limit
+	dataBody := func(size int) string {
+		data := `[{"table":"a"}]`
+		return data + strings.Repeat(" ", size-len(data))
+	}
+	// resourceBody returns a valid resource of the given size, whose spec is
+	// an HCL comment, so that the handler accepts it when it is under the
+	// limit
+	resourceBody := func(size int) string {
+		prefix := `{"kind":"extract","name":"a","api_version":"v1","spec":"#`
+		suffix := `"}`
+		return prefix + strings.Repeat("a", size-len(prefix)-len(suffix)) + suffix
+	}
+	tcs := []struct {
+		desc string
+		path string
+		body string
+		// chunked is whether the request has no content length, so that the
+		// limit is enforced while reading the body
+		chunked bool
+		code    int
+	}{
+		{
+			desc: "graphql under limit",
+			path: "/api/v1/graphql",
+			body: jsonBody("query", limit),
+			code: http.StatusOK,
+		},
+		{
+			desc: "graphql over limit",
+			path: "/api/v1/graphql",
+			body: jsonBody("query", limit+1),
+			code: http.StatusRequestEntityTooLarge,
+		},
+		{
+			desc:    "graphql chunked over limit",
+			path:    "/api/v1/graphql",
+			body:    jsonBody("query", limit+1),
+			chunked: true,
+			code:    http.StatusRequestEntityTooLarge,
+		},
+		{
+			desc: "resource under limit",
+			path: "/api/v1/resource",
+			body: resourceBody(limit),
+			code: http.StatusOK,
+		},
+		{
+			desc: "resource over limit",
+			path: "/api/v1/resource",
+			body: resourceBody(limit + 1),
+			code: http.StatusRequestEntityTooLarge,
+		},
+		{
+			desc: "upload under limit",
+			path: "/api/v1/upload",
+			body: dataBody(limit),
+			code: http.StatusOK,
+		},
+		{
+			desc:    "upload chunked over limit",
+			path:    "/api/v1/upload",
+			body:    dataBody(limit + 1),
+			chunked: true,
+			code:    http.StatusRequestEntityTooLarge,
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.desc, func(t *testing.T) {
+			bCtx := env.NewBubblyContext()
+			bCtx.ServerConfig.MaxRequestBodySize = limit
+			s := NewWithClient(bCtx, &bodyClient{})
+
+			var body io.Reader = strings.NewReader(tc.body)
+			if tc.chunked {
+				// Hide the type of the reader so that the content length is
+				// unknown
+				body = io.MultiReader(body)
+			}
+			req, err := http.NewRequest(http.MethodPost, tc.path, body)
+			require.NoError(t, err)
+			req.Header.Set("Content-Type", "application/json")
+			if tc.chunked {
+				require.Equal(t, int64(0), req.ContentLength)
+				req.ContentLength = -1
+			}
+
+			w := httptest.NewRecorder()
+			s.setupRouter().ServeHTTP(w, req)
+			assert.Equal(t, tc.code, w.Code, w.Body.String())
+		})
+	}
+}
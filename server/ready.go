@@ -0,0 +1,56 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/valocode/bubbly/agent/component"
+)
+
+// readyzHandler returns whether the server is ready to handle requests, which
+// it is once the default store has loaded a schema. With multitenancy, each
+// organization has its own schema, so the store only needs to be reachable
+func (s *Server) readyzHandler(c echo.Context) error {
+	if s.bCtx.AuthConfig.MultiTenancy {
+		if _, err := s.Client.Version(s.bCtx); err != nil {
+			return newAPIError(http.StatusServiceUnavailable, errCodeStoreNotReady, fmt.Sprintf("store is not reachable: %s", err))
+		}
+		return c.String(http.StatusOK, "ready")
+	}
+	if err := s.checkReady(c, nil); err != nil {
+		return err
+	}
+	return c.String(http.StatusOK, "ready")
+}
+
+// readyMiddleware rejects the requests to a store that has not loaded a schema
+// for the caller yet, e.g. because the server started before the store, with
+// a 503 error so that the caller can retry later
+func (s *Server) readyMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if err := s.checkReady(c, s.getAuthFromContext(c)); err != nil {
+			return err
+		}
+		return next(c)
+	}
+}
+
+// checkReady returns an error if the store of the request has not loaded a
+// schema for the given auth. The store is asked for its status until it has,
+// and as a schema is never unloaded, not after that
+func (s *Server) checkReady(c echo.Context, auth *component.MessageAuth) error {
+	key := storeName(c)
+	if auth != nil {
+		key += "/" + auth.Organization
+	}
+	if _, ok := s.ready.Load(key); ok {
+		return nil
+	}
+	if _, err := s.storeClient(c).GetStatus(s.bCtx, auth); err != nil {
+		return newAPIError(http.StatusServiceUnavailable, errCodeStoreNotReady, fmt.Sprintf("store is not ready: %s", err))
+	}
+	s.ready.Store(key, struct{}{})
+	return nil
+}
@@ -0,0 +1,68 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/valocode/bubbly/agent/component"
+	"github.com/valocode/bubbly/env"
+)
+
+// readyClient is a client.Client of a store that has no schema until it is
+// made ready, like a store before its tenant is created
+type readyClient struct {
+	fakeClient
+	ready bool
+}
+
+func (c *readyClient) GetStatus(*env.BubblyContext, *component.MessageAuth) ([]byte, error) {
+	if !c.ready {
+		return nil, errors.New("no schema exists for tenant default")
+	}
+	return []byte(`{"provider":"postgres","tables":1}`), nil
+}
+
+func TestReady(t *testing.T) {
+	bCtx := env.NewBubblyContext()
+	s, err := New(bCtx)
+	require.NoError(t, err)
+	c := &readyClient{}
+	s.Client = c
+	router := s.setupRouter()
+
+	serve := func(method, path, body string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest(method, path, strings.NewReader(body))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	requireNotReady := func(w *httptest.ResponseRecorder) {
+		require.Equal(t, http.StatusServiceUnavailable, w.Code, w.Body.String())
+		var body HTTPError
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		assert.Equal(t, errCodeStoreNotReady, body.Err.Code)
+	}
+	const query = `{"query":"{ root { name } }"}`
+
+	// Before the store has a schema, queries are rejected
+	requireNotReady(serve(http.MethodGet, "/readyz", ""))
+	requireNotReady(serve(http.MethodPost, "/api/v1/graphql", query))
+
+	// Once the store has a schema, queries are accepted
+	c.ready = true
+	w := serve(http.MethodGet, "/readyz", "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, "ready", w.Body.String())
+	w = serve(http.MethodPost, "/api/v1/graphql", query)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, `{"data":{}}`, w.Body.String())
+}
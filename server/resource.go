@@ -3,12 +3,17 @@ package server
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/labstack/echo/v4"
 
 	"github.com/valocode/bubbly/api/core"
+	"github.com/valocode/bubbly/client"
 )
 
 // PostResource godoc
@@ -19,8 +24,9 @@ import (
 // @Param resource body core.ResourceBlock true "Resource Body"
 // @Accept  json
 // @Produce  json
-// @Success 200 {object} apiResponse
-// @Failure 400 {object} apiResponse
+// @Success 200 {object} Status
+// @Failure 400 {object} HTTPError
+// @Failure 422 {object} HTTPError
 // @Router /resource [post]
 func (s *Server) PostResource(c echo.Context) error {
 	// read the resource into a ResourceBlockJSON which keeps the spec{} block
@@ -46,7 +52,38 @@ func (s *Server) PostResource(c echo.Context) error {
 	}
 
 	auth := s.getAuthFromContext(c)
-	if err := s.Client.PostResource(s.bCtx, auth, dBytes); err != nil {
+	if err := s.storeClient(c).PostResource(s.bCtx, auth, dBytes); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	return c.JSON(http.StatusOK, &Status{"uploaded"})
+}
+
+// PostResources godoc
+// @Summary Takes a POST request to upload a list of resources, which are either all saved or none are
+// @Description The resources are saved together, so if any of them cannot be saved then none of them are
+// @ID Post-resources
+// @Tags resource
+// @Param resources body []core.ResourceBlock true "Resources Body"
+// @Accept  json
+// @Produce  json
+// @Success 200 {object} Status
+// @Failure 400 {object} HTTPError
+// @Failure 422 {object} HTTPError
+// @Router /resources [post]
+func (s *Server) PostResources(c echo.Context) error {
+	body, err := io.ReadAll(c.Request().Body)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	// Convert all the resources before saving any of them, so that an invalid
+	// resource is reported without saving anything
+	dBytes, err := client.ResourcesData(body)
+	if err != nil {
+		return newAPIError(http.StatusBadRequest, errCodeInvalidResource, err.Error())
+	}
+
+	auth := s.getAuthFromContext(c)
+	if err := s.storeClient(c).PostResource(s.bCtx, auth, dBytes); err != nil {
 		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
 	}
 	return c.JSON(http.StatusOK, &Status{"uploaded"})
@@ -62,10 +99,9 @@ func (s *Server) PostResource(c echo.Context) error {
 // @Param name path string true "Run Resource Name"
 // @Accept  mpfd
 // @Produce  json
-// @Success 200 {object} apiResponse
-// @Failure 400 {object} apiResponse
-// @Failure 415 {object} apiResponse
-// @Failure 500 {object} apiResponse
+// @Success 200 {string} string
+// @Failure 400 {object} HTTPError
+// @Failure 415 {object} HTTPError
 // @Router /run/{name} [post]
 func (s *Server) RunResource(c echo.Context) error {
 
@@ -93,27 +129,178 @@ func (s *Server) RunResource(c echo.Context) error {
 
 // GetResource godoc
 // @Summary GetResource Fetches a resource via GET
-// @Description Will fetch a resource based on the given ID
+// @Description Will fetch a resource based on the given kind and name. A resource in a namespace other than the default namespace is at /resource/{namespace}/{kind}/{name}.
+// A previous version of the resource is fetched with the version query parameter
 // @ID Get-resource
 // @Tags resource
-// @Param id path string true "Resource ID"
+// @Param kind path string true "Resource Kind"
+// @Param name path string true "Resource Name"
+// @Param version query int false "Resource Version"
 // @Accept  json
-// @Produce  json
-// @Success 200 {object} apiResponse
-// @Failure 400 {object} apiResponse
-// @Router /resource/{id} [get]
+// @Produce  json,application/x-yaml
+// @Success 200 {object} core.ResourceBlock
+// @Failure 400 {object} HTTPError
+// @Failure 404 {object} HTTPError
+// @Failure 500 {object} HTTPError
+// @Router /resource/{kind}/{name} [get]
 func (s *Server) GetResource(c echo.Context) error {
-	resBlock := core.ResourceBlock{
-		ResourceName: c.Param("name"),
-		Metadata:     &core.Metadata{},
-		ResourceKind: c.Param("kind"),
+	resID := resourceIDParam(c)
+
+	var (
+		auth        = s.getAuthFromContext(c)
+		resultBytes []byte
+		err         error
+	)
+	if v := c.QueryParam("version"); v != "" {
+		version, convErr := strconv.Atoi(v)
+		if convErr != nil || version < 1 {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("invalid resource version: %s", v))
+		}
+		resultBytes, err = s.storeClient(c).GetResourceVersion(s.bCtx, auth, resID, version)
+		if errors.Is(err, client.ErrResourceNotFound) {
+			return newAPIError(http.StatusNotFound, errCodeResourceNotFound, err.Error())
+		}
+	} else {
+		resultBytes, err = s.storeClient(c).GetResource(s.bCtx, auth, resID)
+	}
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("error getting resource: %s", err.Error()))
 	}
 
+	// If the resource was requested as YAML, convert it before responding
+	if strings.Contains(c.Request().Header.Get(echo.HeaderAccept), core.MIMEApplicationYAML) {
+		yamlBytes, err := core.ResourceJSONToYAML(resultBytes)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("error converting resource to YAML: %s", err.Error()))
+		}
+		return c.Blob(http.StatusOK, core.MIMEApplicationYAML, yamlBytes)
+	}
+
+	return c.JSONBlob(http.StatusOK, resultBytes)
+}
+
+// GetResourceVersions godoc
+// @Summary GetResourceVersions fetches the versions of a resource via GET
+// @Description Will fetch the versions of a resource based on the given namespace, kind and name, oldest first. A version is saved each time that the resource is saved.
+// The namespace of a resource in the default namespace is "default"
+// @ID Get-resource-versions
+// @Tags resource
+// @Param namespace path string true "Resource Namespace"
+// @Param kind path string true "Resource Kind"
+// @Param name path string true "Resource Name"
+// @Produce  json
+// @Success 200 {array} client.ResourceVersion
+// @Failure 400 {object} HTTPError
+// @Failure 404 {object} HTTPError
+// @Router /resource/{namespace}/{kind}/{name}/versions [get]
+func (s *Server) GetResourceVersions(c echo.Context) error {
+	resID := resourceIDParam(c)
+
 	auth := s.getAuthFromContext(c)
-	resultBytes, err := s.Client.GetResource(s.bCtx, auth, resBlock.String())
+	resultBytes, err := s.storeClient(c).GetResourceVersions(s.bCtx, auth, resID)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("error getting resource: %s", err.Error()))
+		if errors.Is(err, client.ErrResourceNotFound) {
+			return newAPIError(http.StatusNotFound, errCodeResourceNotFound, err.Error())
+		}
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("error getting resource versions: %s", err.Error()))
+	}
+
+	return c.JSONBlob(http.StatusOK, resultBytes)
+}
+
+// GetResourcesByKind godoc
+// @Summary GetResourcesByKind fetches the resources of a kind via GET
+// @Description Will fetch the resources of the given kind in a namespace, ordered by name. The namespace is the default namespace unless one is given
+// @ID Get-resources-by-kind
+// @Tags resource
+// @Param kind query string true "Resource Kind"
+// @Param namespace query string false "Resource Namespace"
+// @Produce  json
+// @Success 200 {array} core.ResourceBlock
+// @Failure 400 {object} HTTPError
+// @Router /resources [get]
+func (s *Server) GetResourcesByKind(c echo.Context) error {
+	kind := c.QueryParam("kind")
+	if kind == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "the kind of the resources must be given")
+	}
+
+	auth := s.getAuthFromContext(c)
+	resultBytes, err := s.storeClient(c).GetResourcesByKind(s.bCtx, auth, c.QueryParam("namespace"), kind)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("error getting resources: %s", err.Error()))
 	}
 
 	return c.JSONBlob(http.StatusOK, resultBytes)
 }
+
+// DeleteResource godoc
+// @Summary DeleteResource deletes a resource via DELETE
+// @Description Will delete a resource, and its events, based on the given kind and name. A resource in a namespace other than the default namespace is at /resource/{namespace}/{kind}/{name}
+// @ID Delete-resource
+// @Tags resource
+// @Param kind path string true "Resource Kind"
+// @Param name path string true "Resource Name"
+// @Produce  json
+// @Success 200 {object} Status
+// @Failure 400 {object} HTTPError
+// @Failure 404 {object} HTTPError
+// @Router /resource/{kind}/{name} [delete]
+func (s *Server) DeleteResource(c echo.Context) error {
+	resID := resourceIDParam(c)
+
+	auth := s.getAuthFromContext(c)
+	if err := s.storeClient(c).DeleteResource(s.bCtx, auth, resID); err != nil {
+		if errors.Is(err, client.ErrResourceNotFound) {
+			return newAPIError(http.StatusNotFound, errCodeResourceNotFound, err.Error())
+		}
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("error deleting resource: %s", err.Error()))
+	}
+
+	return c.JSON(http.StatusOK, &Status{"deleted"})
+}
+
+// PatchResource godoc
+// @Summary PatchResource updates part of a resource via PATCH
+// @Description Will apply a JSON Merge Patch (RFC 7386) to the resource with the given kind and name. A resource in a namespace other than the default namespace is at /resource/{namespace}/{kind}/{name}.
+// The spec of a resource is a string, and is therefore replaced as a whole
+// @ID Patch-resource
+// @Tags resource
+// @Param kind path string true "Resource Kind"
+// @Param name path string true "Resource Name"
+// @Param patch body object true "JSON Merge Patch"
+// @Accept  json
+// @Produce  json
+// @Success 200 {object} Status
+// @Failure 400 {object} HTTPError
+// @Failure 404 {object} HTTPError
+// @Failure 422 {object} HTTPError
+// @Router /resource/{kind}/{name} [patch]
+func (s *Server) PatchResource(c echo.Context) error {
+	resID := resourceIDParam(c)
+
+	patch, err := io.ReadAll(c.Request().Body)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("error reading patch: %s", err.Error()))
+	}
+
+	auth := s.getAuthFromContext(c)
+	if err := s.storeClient(c).PatchResource(s.bCtx, auth, resID, patch); err != nil {
+		switch {
+		case errors.Is(err, client.ErrResourceNotFound):
+			return newAPIError(http.StatusNotFound, errCodeResourceNotFound, err.Error())
+		case errors.Is(err, client.ErrInvalidResource):
+			return newAPIError(http.StatusUnprocessableEntity, errCodeInvalidResource, err.Error())
+		}
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("error patching resource: %s", err.Error()))
+	}
+
+	return c.JSON(http.StatusOK, &Status{"patched"})
+}
+
+// resourceIDParam returns the ID of the resource addressed by the path of the
+// request. The namespace is not in the path of the resources in the default
+// namespace
+func resourceIDParam(c echo.Context) string {
+	return core.ResourceID(c.Param("namespace"), c.Param("kind"), c.Param("name"))
+}
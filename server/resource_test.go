@@ -0,0 +1,379 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v2"
+
+	"github.com/valocode/bubbly/agent/component"
+	"github.com/valocode/bubbly/api/core"
+	"github.com/valocode/bubbly/client"
+	"github.com/valocode/bubbly/env"
+)
+
+// resourceClient is a client.Client that returns a fixed resource
+type resourceClient struct {
+	fakeClient
+	resource []byte
+}
+
+func (c *resourceClient) GetResource(*env.BubblyContext, *component.MessageAuth, string, ...client.ResourceOption) ([]byte, error) {
+	return c.resource, nil
+}
+
+func TestGetResource(t *testing.T) {
+	const resJSON = `{"kind":"extract","name":"junit","api_version":"v1","metadata":{"labels":{"env":"test"}},"spec":"\n  input \"file\" {}\n  type = \"xml\"\n"}`
+
+	tcs := []struct {
+		desc        string
+		accept      string
+		contentType string
+		unmarshal   func([]byte, interface{}) error
+	}{
+		{
+			desc:        "resource as json",
+			accept:      "",
+			contentType: "application/json",
+			unmarshal:   json.Unmarshal,
+		},
+		{
+			desc:        "resource as yaml",
+			accept:      core.MIMEApplicationYAML,
+			contentType: core.MIMEApplicationYAML,
+			unmarshal:   yaml.Unmarshal,
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.desc, func(t *testing.T) {
+			bCtx := env.NewBubblyContext()
+			s, err := New(bCtx)
+			require.NoError(t, err)
+			s.Client = &resourceClient{resource: []byte(resJSON)}
+
+			router := s.setupRouter()
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(http.MethodGet, "/api/v1/resource/extract/junit", nil)
+			if tc.accept != "" {
+				req.Header.Set("Accept", tc.accept)
+			}
+			router.ServeHTTP(w, req)
+
+			require.Equal(t, http.StatusOK, w.Code)
+			assert.Contains(t, w.Header().Get("Content-Type"), tc.contentType)
+
+			// Both formats should contain exactly the same resource. The
+			// expected resource is decoded with the same decoder as the
+			// response, as JSON is also valid YAML, so that the types of
+			// the values match
+			var (
+				expected = make(map[string]interface{})
+				actual   = make(map[string]interface{})
+			)
+			require.NoError(t, tc.unmarshal([]byte(resJSON), &expected))
+			require.NoError(t, tc.unmarshal(w.Body.Bytes(), &actual))
+			assert.Equal(t, expected, actual)
+		})
+	}
+}
+
+// kindClient is a client.Client that returns a fixed list of resources, and
+// records the namespace and kind that they were requested for
+type kindClient struct {
+	fakeClient
+	resources string
+
+	namespace string
+	kind      string
+}
+
+func (c *kindClient) GetResourcesByKind(_ *env.BubblyContext, _ *component.MessageAuth, namespace string, kind string) ([]byte, error) {
+	c.namespace = namespace
+	c.kind = kind
+	return []byte(c.resources), nil
+}
+
+func TestGetResourcesByKind(t *testing.T) {
+	const resources = `[{"kind":"run","name":"nightly","api_version":"v1","metadata":null,"spec":"remote {}\n"}]`
+	tcs := []struct {
+		desc      string
+		path      string
+		code      int
+		namespace string
+		kind      string
+	}{
+		{desc: "default namespace", path: "/api/v1/resources?kind=run", code: http.StatusOK, kind: "run"},
+		{desc: "other namespace", path: "/api/v1/resources?kind=run&namespace=team-a", code: http.StatusOK, namespace: "team-a", kind: "run"},
+		{desc: "no kind", path: "/api/v1/resources", code: http.StatusBadRequest},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.desc, func(t *testing.T) {
+			bCtx := env.NewBubblyContext()
+			s, err := New(bCtx)
+			require.NoError(t, err)
+			c := &kindClient{resources: resources}
+			s.Client = c
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(http.MethodGet, tc.path, nil)
+			s.setupRouter().ServeHTTP(w, req)
+
+			require.Equal(t, tc.code, w.Code)
+			if tc.code != http.StatusOK {
+				return
+			}
+			assert.JSONEq(t, resources, w.Body.String())
+			assert.Equal(t, tc.namespace, c.namespace)
+			assert.Equal(t, tc.kind, c.kind)
+		})
+	}
+}
+
+// versionClient is a client.Client that returns the versions of a resource
+// from a fixed list, oldest first
+type versionClient struct {
+	fakeClient
+	id       string
+	versions []string
+}
+
+func (c *versionClient) GetResourceVersions(_ *env.BubblyContext, _ *component.MessageAuth, id string) ([]byte, error) {
+	if id != c.id {
+		return nil, fmt.Errorf("%w: %s", client.ErrResourceNotFound, id)
+	}
+	return []byte("[" + strings.Join(c.versions, ",") + "]"), nil
+}
+
+func (c *versionClient) GetResourceVersion(_ *env.BubblyContext, _ *component.MessageAuth, id string, version int, _ ...client.ResourceOption) ([]byte, error) {
+	if id != c.id || version > len(c.versions) {
+		return nil, fmt.Errorf("%w: %s version %d", client.ErrResourceNotFound, id, version)
+	}
+	return []byte(c.versions[version-1]), nil
+}
+
+func TestGetResourceVersion(t *testing.T) {
+	versions := []string{
+		`{"kind":"extract","name":"junit","api_version":"v1","metadata":null,"spec":"type = \"json\"\n"}`,
+		`{"kind":"extract","name":"junit","api_version":"v1","metadata":null,"spec":"type = \"xml\"\n"}`,
+	}
+	tcs := []struct {
+		desc     string
+		path     string
+		code     int
+		expected string
+	}{
+		{desc: "first version", path: "/api/v1/resource/extract/junit?version=1", code: http.StatusOK, expected: versions[0]},
+		{desc: "second version", path: "/api/v1/resource/team-a/extract/junit?version=2", code: http.StatusOK, expected: versions[1]},
+		{desc: "missing version", path: "/api/v1/resource/extract/junit?version=3", code: http.StatusNotFound},
+		{desc: "invalid version", path: "/api/v1/resource/extract/junit?version=latest", code: http.StatusBadRequest},
+		{desc: "version zero", path: "/api/v1/resource/extract/junit?version=0", code: http.StatusBadRequest},
+		{desc: "versions", path: "/api/v1/resource/default/extract/junit/versions", code: http.StatusOK, expected: "[" + strings.Join(versions, ",") + "]"},
+		{desc: "versions of missing resource", path: "/api/v1/resource/default/extract/other/versions", code: http.StatusNotFound},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.desc, func(t *testing.T) {
+			bCtx := env.NewBubblyContext()
+			s, err := New(bCtx)
+			require.NoError(t, err)
+			id := "extract/junit"
+			if strings.Contains(tc.path, "team-a") {
+				id = "team-a/extract/junit"
+			}
+			s.Client = &versionClient{id: id, versions: versions}
+
+			router := s.setupRouter()
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(http.MethodGet, tc.path, nil)
+			router.ServeHTTP(w, req)
+
+			require.Equal(t, tc.code, w.Code)
+			if tc.expected != "" {
+				assert.JSONEq(t, tc.expected, w.Body.String())
+			}
+		})
+	}
+}
+
+// deleteClient is a client.Client that deletes resources from a fixed set
+type deleteClient struct {
+	fakeClient
+	resources map[string]struct{}
+}
+
+func (c *deleteClient) DeleteResource(_ *env.BubblyContext, _ *component.MessageAuth, id string) error {
+	if _, ok := c.resources[id]; !ok {
+		return fmt.Errorf("%w: %s", client.ErrResourceNotFound, id)
+	}
+	delete(c.resources, id)
+	return nil
+}
+
+func TestDeleteResource(t *testing.T) {
+	tcs := []struct {
+		desc string
+		path string
+		code int
+	}{
+		{
+			desc: "existing resource",
+			path: "/api/v1/resource/extract/junit",
+			code: http.StatusOK,
+		},
+		{
+			desc: "missing resource",
+			path: "/api/v1/resource/extract/other",
+			code: http.StatusNotFound,
+		},
+		{
+			desc: "default namespace",
+			path: "/api/v1/resource/default/extract/junit",
+			code: http.StatusOK,
+		},
+		{
+			desc: "namespace",
+			path: "/api/v1/resource/team-a/extract/junit",
+			code: http.StatusOK,
+		},
+		{
+			desc: "missing namespace",
+			path: "/api/v1/resource/team-b/extract/junit",
+			code: http.StatusNotFound,
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.desc, func(t *testing.T) {
+			bCtx := env.NewBubblyContext()
+			s, err := New(bCtx)
+			require.NoError(t, err)
+			c := &deleteClient{resources: map[string]struct{}{"extract/junit": {}, "team-a/extract/junit": {}}}
+			s.Client = c
+
+			router := s.setupRouter()
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(http.MethodDelete, tc.path, nil)
+			router.ServeHTTP(w, req)
+
+			require.Equal(t, tc.code, w.Code)
+			if tc.code == http.StatusOK {
+				assert.Len(t, c.resources, 1)
+			}
+		})
+	}
+}
+
+// patchClient is a client.Client that patches resources from a fixed set, and
+// records the patched resource that is posted
+type patchClient struct {
+	fakeClient
+	resources map[string][]byte
+	posted    []byte
+}
+
+func (c *patchClient) GetResource(_ *env.BubblyContext, _ *component.MessageAuth, id string, _ ...client.ResourceOption) ([]byte, error) {
+	res, ok := c.resources[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", client.ErrResourceNotFound, id)
+	}
+	return res, nil
+}
+
+func (c *patchClient) PostResource(_ *env.BubblyContext, _ *component.MessageAuth, data []byte) error {
+	c.posted = data
+	return nil
+}
+
+func (c *patchClient) PatchResource(bCtx *env.BubblyContext, auth *component.MessageAuth, id string, patch []byte) error {
+	return client.PatchStoredResource(bCtx, c, auth, id, patch)
+}
+
+func TestPatchResource(t *testing.T) {
+	const resJSON = `{"kind":"extract","name":"junit","api_version":"v1","metadata":{"labels":{"env":"test"}},"spec":"\n  input \"file\" {}\n  type = \"xml\"\n"}`
+	const nsResJSON = `{"kind":"extract","name":"junit","api_version":"v1","metadata":{"labels":{"env":"test"},"namespace":"team-a"},"spec":"\n  input \"file\" {}\n  type = \"xml\"\n"}`
+
+	tcs := []struct {
+		desc  string
+		path  string
+		patch string
+		code  int
+	}{
+		{
+			desc:  "merge labels",
+			path:  "/api/v1/resource/extract/junit",
+			patch: `{"metadata":{"labels":{"env":"prod"}}}`,
+			code:  http.StatusOK,
+		},
+		{
+			desc:  "missing resource",
+			path:  "/api/v1/resource/extract/other",
+			patch: `{"metadata":{"labels":{"env":"prod"}}}`,
+			code:  http.StatusNotFound,
+		},
+		{
+			desc:  "invalid spec",
+			path:  "/api/v1/resource/extract/junit",
+			patch: `{"spec":"input \"file\" {"}`,
+			code:  http.StatusUnprocessableEntity,
+		},
+		{
+			desc:  "change name",
+			path:  "/api/v1/resource/extract/junit",
+			patch: `{"name":"other"}`,
+			code:  http.StatusUnprocessableEntity,
+		},
+		{
+			desc:  "namespace",
+			path:  "/api/v1/resource/team-a/extract/junit",
+			patch: `{"metadata":{"labels":{"env":"prod"}}}`,
+			code:  http.StatusOK,
+		},
+		{
+			desc:  "change namespace",
+			path:  "/api/v1/resource/team-a/extract/junit",
+			patch: `{"metadata":{"namespace":"team-b"}}`,
+			code:  http.StatusUnprocessableEntity,
+		},
+		{
+			desc:  "malformed patch",
+			path:  "/api/v1/resource/extract/junit",
+			patch: `{"metadata":`,
+			code:  http.StatusBadRequest,
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.desc, func(t *testing.T) {
+			bCtx := env.NewBubblyContext()
+			s, err := New(bCtx)
+			require.NoError(t, err)
+			c := &patchClient{resources: map[string][]byte{
+				"extract/junit":        []byte(resJSON),
+				"team-a/extract/junit": []byte(nsResJSON),
+			}}
+			s.Client = c
+
+			router := s.setupRouter()
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(http.MethodPatch, tc.path, strings.NewReader(tc.patch))
+			req.Header.Set("Content-Type", "application/json")
+			router.ServeHTTP(w, req)
+
+			require.Equal(t, tc.code, w.Code, w.Body.String())
+			if tc.code != http.StatusOK {
+				assert.Nil(t, c.posted)
+				return
+			}
+			require.NotNil(t, c.posted)
+			assert.Contains(t, string(c.posted), "prod")
+			assert.NotContains(t, string(c.posted), `"test"`)
+		})
+	}
+}
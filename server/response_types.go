@@ -1,8 +1,41 @@
 package server
 
+import "time"
+
+// HTTPError is the body of every error response from the API server, as
+// written by the server's HTTP error handler
+type HTTPError struct {
+	Err HTTPErrorBody `json:"error"`
+}
+
+// HTTPErrorBody describes the error of an error response
+type HTTPErrorBody struct {
+	// Code identifies the kind of error, e.g. "not_found", so that clients
+	// do not have to rely on the message
+	Code    string   `json:"code" example:"bad_request"`
+	Message string   `json:"message" example:"error message"`
+	Details []string `json:"details,omitempty"`
+}
+
 type Error struct {
 	ErrMessage string `json:"error"`
 }
 type Status struct {
 	Status string `json:"status"`
 }
+
+// StoreStatus is the body of the response with the status of the data store
+type StoreStatus struct {
+	// Provider is the type of the data store's provider
+	Provider string `json:"provider" example:"postgres"`
+	// Tables is the number of tables in the schema, including the builtin
+	// tables
+	Tables int `json:"tables"`
+	// SchemaUpdatedAt is when the data store last updated the schema
+	SchemaUpdatedAt time.Time `json:"schema_updated_at"`
+}
+
+// Version is the body of the response with the version of the API server
+type Version struct {
+	Version string `json:"version"`
+}
@@ -14,6 +14,8 @@ func (s *Server) initializeRoutes(router *echo.Echo) {
 	router.GET("/healthz", func(c echo.Context) error {
 		return c.String(http.StatusOK, "pong")
 	})
+	// Readiness Test, which fails until the store has loaded a schema
+	router.GET("/readyz", s.readyzHandler)
 
 	api := router.Group("/api/v1")
 
@@ -35,13 +37,39 @@ func (s *Server) initializeRoutes(router *echo.Echo) {
 		api.POST("/user/token/delete", s.deleteUserToken)
 	}
 
+	api.GET("/version", s.versionHandler)
 	api.POST("/run/:name", s.RunResource)
-	api.POST("/resource", s.PostResource)
-	api.GET("/resource/:kind/:name", s.GetResource)
-	api.POST("/graphql", s.Query)
-	api.POST("/schema", s.PostSchema)
-	api.POST("/upload", s.upload)
+	s.initializeStoreRoutes(api)
+	// The store can also be named in the path instead of the header
+	s.initializeStoreRoutes(api.Group("/stores/:" + storeParam))
 
 	// Serve Swagger files
 	router.GET("/swagger/*", echoSwagger.WrapHandler)
 }
+
+// initializeStoreRoutes adds the endpoints that are handled by a data store,
+// which is the store named in the request, if any
+func (s *Server) initializeStoreRoutes(g *echo.Group) {
+	g.GET("/status", s.statusHandler, s.storeMiddleware)
+	g.POST("/resource", s.PostResource, s.storeMiddleware, s.bodyLimitMiddleware, s.idempotencyMiddleware)
+	g.POST("/resources", s.PostResources, s.storeMiddleware, s.bodyLimitMiddleware, s.idempotencyMiddleware)
+	g.GET("/resources", s.GetResourcesByKind, s.storeMiddleware)
+	g.GET("/resource/:kind/:name", s.GetResource, s.storeMiddleware)
+	g.DELETE("/resource/:kind/:name", s.DeleteResource, s.storeMiddleware)
+	g.PATCH("/resource/:kind/:name", s.PatchResource, s.storeMiddleware, s.bodyLimitMiddleware)
+	// The resources that are not in the default namespace are addressed with
+	// their namespace
+	g.GET("/resource/:namespace/:kind/:name", s.GetResource, s.storeMiddleware)
+	// The versions of a resource are only addressed with the namespace, as
+	// the path would otherwise be that of a resource named "versions"
+	g.GET("/resource/:namespace/:kind/:name/versions", s.GetResourceVersions, s.storeMiddleware)
+	g.DELETE("/resource/:namespace/:kind/:name", s.DeleteResource, s.storeMiddleware)
+	g.PATCH("/resource/:namespace/:kind/:name", s.PatchResource, s.storeMiddleware, s.bodyLimitMiddleware)
+	g.POST("/graphql", s.Query, s.storeMiddleware, s.readyMiddleware, s.bodyLimitMiddleware)
+	g.GET("/graphql/schema.graphql", s.GetSchemaSDL, s.storeMiddleware)
+	g.GET("/schema", s.GetSchema, s.storeMiddleware)
+	g.GET("/schema/describe", s.DescribeSchema, s.storeMiddleware)
+	g.POST("/schema", s.PostSchema, s.storeMiddleware)
+	g.POST("/schema/preview", s.PreviewSchema, s.storeMiddleware)
+	g.POST("/upload", s.upload, s.storeMiddleware, s.bodyLimitMiddleware)
+}
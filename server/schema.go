@@ -1,9 +1,12 @@
 package server
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
 
 	"github.com/labstack/echo/v4"
 )
@@ -11,11 +14,12 @@ import (
 // PostSchema godoc
 // @Summary PostSchema uploads the schema for bubbly
 // @ID schema
-// @Tag schema
+// @Tags schema
+// @Param schema body object true "Schema Tables"
 // @Accept json
 // @Produce json
-// @Success 200 {object} apiResponse
-// @Failure 400 {object} apiResponse
+// @Success 200 {object} Status
+// @Failure 400 {object} HTTPError
 // @Router /schema [post]
 func (s *Server) PostSchema(c echo.Context) error {
 
@@ -25,9 +29,128 @@ func (s *Server) PostSchema(c echo.Context) error {
 	}
 
 	auth := s.getAuthFromContext(c)
-	if err := s.Client.PostSchema(s.bCtx, auth, body); err != nil {
+	if err := s.storeClient(c).PostSchema(s.bCtx, auth, body); err != nil {
 		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
 	}
 
 	return c.JSON(http.StatusOK, &Status{"schema created!"})
 }
+
+// PreviewSchema godoc
+// @Summary PreviewSchema returns the SQL statements that applying the schema would run
+// @Description The statements are not run, so the schema is not changed
+// @ID preview-schema
+// @Tags schema
+// @Param schema body object true "Schema Tables"
+// @Accept json
+// @Produce json
+// @Success 200 {array} string
+// @Failure 400 {object} HTTPError
+// @Router /schema/preview [post]
+func (s *Server) PreviewSchema(c echo.Context) error {
+	body, err := io.ReadAll(c.Request().Body)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Errorf("failed to read body of request: %w", err))
+	}
+
+	auth := s.getAuthFromContext(c)
+	stmts, err := s.storeClient(c).PreviewSchema(s.bCtx, auth, body)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	return c.JSONBlob(http.StatusOK, stmts)
+}
+
+// GetSchema godoc
+// @Summary GetSchema returns the tables of the current schema
+// @Description The tables are returned by name, including the builtin tables. If no schema has been applied there are no tables
+// @ID get-schema
+// @Tags schema
+// @Produce json
+// @Success 200 {object} object
+// @Failure 500 {object} HTTPError
+// @Router /schema [get]
+func (s *Server) GetSchema(c echo.Context) error {
+	auth := s.getAuthFromContext(c)
+	tables, err := s.storeClient(c).GetSchema(s.bCtx, auth)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSONBlob(http.StatusOK, tables)
+}
+
+// DescribeSchema godoc
+// @Summary DescribeSchema returns a description of the tables of the current schema
+// @Description The tables are sorted by name, including the builtin tables, with their fields and their relationships to other tables
+// @ID describe-schema
+// @Tags schema
+// @Produce json
+// @Success 200 {object} object
+// @Failure 500 {object} HTTPError
+// @Router /schema/describe [get]
+func (s *Server) DescribeSchema(c echo.Context) error {
+	auth := s.getAuthFromContext(c)
+	desc, err := s.storeClient(c).DescribeSchema(s.bCtx, auth)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSONBlob(http.StatusOK, desc)
+}
+
+// GetSchemaSDL godoc
+// @Summary GetSchemaSDL returns the GraphQL schema as SDL
+// @ID schema-sdl
+// @Tags graphql
+// @Description The response has an ETag of the SDL, and a request with an
+// @Description If-None-Match header of the same ETag gets a 304 response
+// @Description without a body, so that clients can cache the SDL
+// @Produce plain
+// @Param If-None-Match header string false "ETag of the SDL that the client has"
+// @Success 200 {string} string
+// @Success 304 {string} string
+// @Failure 500 {object} HTTPError
+// @Router /graphql/schema.graphql [get]
+func (s *Server) GetSchemaSDL(c echo.Context) error {
+	auth := s.getAuthFromContext(c)
+	sdl, err := s.storeClient(c).GetSchemaSDL(s.bCtx, auth)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	etag := sdlETag(sdl)
+	c.Response().Header().Set(headerETag, etag)
+	if etagMatches(c.Request().Header.Get(headerIfNoneMatch), etag) {
+		return c.NoContent(http.StatusNotModified)
+	}
+	return c.Blob(http.StatusOK, echo.MIMETextPlainCharsetUTF8, sdl)
+}
+
+const (
+	headerETag        = "ETag"
+	headerIfNoneMatch = "If-None-Match"
+)
+
+// sdlETag returns the ETag of a schema SDL, which changes whenever the schema
+// changes
+func sdlETag(sdl []byte) string {
+	sum := sha256.Sum256(sdl)
+	return `"` + hex.EncodeToString(sum[:]) + `"`
+}
+
+// etagMatches returns whether the value of an If-None-Match header matches
+// the ETag, using the weak comparison of RFC 7232
+func etagMatches(ifNoneMatch string, etag string) bool {
+	if ifNoneMatch == "" {
+		return false
+	}
+	for _, tag := range strings.Split(ifNoneMatch, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" || strings.TrimPrefix(tag, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
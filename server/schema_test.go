@@ -0,0 +1,201 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/appleboy/gofight/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/valocode/bubbly/agent/component"
+	"github.com/valocode/bubbly/env"
+)
+
+// schemaClient is a client.Client that returns a fixed schema SDL, tables,
+// description and preview, and records the schema posted to it
+type schemaClient struct {
+	fakeClient
+	sdl    string
+	tables string
+	desc   string
+	stmts  string
+
+	posted  string
+	postErr error
+}
+
+func (c *schemaClient) PostSchema(_ *env.BubblyContext, _ *component.MessageAuth, schema []byte) error {
+	c.posted = string(schema)
+	return c.postErr
+}
+
+func (c *schemaClient) PreviewSchema(_ *env.BubblyContext, _ *component.MessageAuth, schema []byte) ([]byte, error) {
+	c.posted = string(schema)
+	if c.postErr != nil {
+		return nil, c.postErr
+	}
+	return []byte(c.stmts), nil
+}
+
+func (c *schemaClient) GetSchema(*env.BubblyContext, *component.MessageAuth) ([]byte, error) {
+	return []byte(c.tables), nil
+}
+
+func (c *schemaClient) DescribeSchema(*env.BubblyContext, *component.MessageAuth) ([]byte, error) {
+	return []byte(c.desc), nil
+}
+
+func (c *schemaClient) GetSchemaSDL(*env.BubblyContext, *component.MessageAuth) ([]byte, error) {
+	return []byte(c.sdl), nil
+}
+
+func TestGetSchemaSDL(t *testing.T) {
+	bCtx := env.NewBubblyContext()
+	s, err := New(bCtx)
+	require.NoError(t, err)
+	sdl := "schema {\n  query: query\n}\n"
+	s.Client = &schemaClient{sdl: sdl}
+
+	r := gofight.New()
+	r.GET("/api/v1/graphql/schema.graphql").
+		Run(s.setupRouter(), func(r gofight.HTTPResponse, rq gofight.HTTPRequest) {
+			assert.Equal(t, http.StatusOK, r.Code)
+			assert.Equal(t, sdl, r.Body.String())
+		})
+}
+
+// TestGetSchemaSDLETag verifies that the SDL has an ETag, and that a request
+// with the ETag of the current SDL gets no body
+func TestGetSchemaSDLETag(t *testing.T) {
+	bCtx := env.NewBubblyContext()
+	s, err := New(bCtx)
+	require.NoError(t, err)
+	sdl := "schema {\n  query: query\n}\n"
+	s.Client = &schemaClient{sdl: sdl}
+	etag := sdlETag([]byte(sdl))
+
+	tcs := []struct {
+		desc        string
+		ifNoneMatch string
+		code        int
+		body        string
+	}{
+		{desc: "no etag", code: http.StatusOK, body: sdl},
+		{desc: "current etag", ifNoneMatch: etag, code: http.StatusNotModified},
+		{desc: "weak etag", ifNoneMatch: "W/" + etag, code: http.StatusNotModified},
+		{desc: "one of etags", ifNoneMatch: `"old", ` + etag, code: http.StatusNotModified},
+		{desc: "old etag", ifNoneMatch: `"old"`, code: http.StatusOK, body: sdl},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.desc, func(t *testing.T) {
+			r := gofight.New()
+			r.GET("/api/v1/graphql/schema.graphql").
+				SetHeader(gofight.H{"If-None-Match": tc.ifNoneMatch}).
+				Run(s.setupRouter(), func(r gofight.HTTPResponse, rq gofight.HTTPRequest) {
+					assert.Equal(t, tc.code, r.Code)
+					assert.Equal(t, etag, r.HeaderMap.Get("ETag"))
+					assert.Equal(t, tc.body, r.Body.String())
+				})
+		})
+	}
+}
+
+func TestGetSchema(t *testing.T) {
+	bCtx := env.NewBubblyContext()
+	s, err := New(bCtx)
+	require.NoError(t, err)
+	tables := `{"project":{"name":"project","fields":[{"name":"name","type":"string"}]}}`
+	s.Client = &schemaClient{tables: tables}
+
+	r := gofight.New()
+	r.GET("/api/v1/schema").
+		Run(s.setupRouter(), func(r gofight.HTTPResponse, rq gofight.HTTPRequest) {
+			assert.Equal(t, http.StatusOK, r.Code)
+			assert.JSONEq(t, tables, r.Body.String())
+		})
+}
+
+func TestDescribeSchema(t *testing.T) {
+	bCtx := env.NewBubblyContext()
+	s, err := New(bCtx)
+	require.NoError(t, err)
+	desc := `{"tables":[{"name":"project","fields":[{"name":"name","type":"string"}],"edges":[]}]}`
+	s.Client = &schemaClient{desc: desc}
+
+	r := gofight.New()
+	r.GET("/api/v1/schema/describe").
+		Run(s.setupRouter(), func(r gofight.HTTPResponse, rq gofight.HTTPRequest) {
+			assert.Equal(t, http.StatusOK, r.Code)
+			assert.JSONEq(t, desc, r.Body.String())
+		})
+}
+
+func TestPostSchema(t *testing.T) {
+	tcs := []struct {
+		desc    string
+		postErr error
+		code    int
+	}{
+		{desc: "created", code: http.StatusOK},
+		{desc: "store error", postErr: errors.New("failed to apply schema"), code: http.StatusBadRequest},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.desc, func(t *testing.T) {
+			bCtx := env.NewBubblyContext()
+			s, err := New(bCtx)
+			require.NoError(t, err)
+			c := &schemaClient{postErr: tc.postErr}
+			s.Client = c
+			schema := `[{"name":"project","fields":[{"name":"name","type":"string"}]}]`
+
+			r := gofight.New()
+			r.POST("/api/v1/schema").
+				SetBody(schema).
+				Run(s.setupRouter(), func(r gofight.HTTPResponse, rq gofight.HTTPRequest) {
+					assert.Equal(t, tc.code, r.Code)
+					if tc.postErr != nil {
+						assert.Contains(t, r.Body.String(), tc.postErr.Error())
+					}
+				})
+			// The body is passed to the store as it is
+			assert.Equal(t, schema, c.posted)
+		})
+	}
+}
+
+func TestPreviewSchema(t *testing.T) {
+	tcs := []struct {
+		desc    string
+		postErr error
+		code    int
+	}{
+		{desc: "previewed", code: http.StatusOK},
+		{desc: "store error", postErr: errors.New("cannot modify builtin table"), code: http.StatusBadRequest},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.desc, func(t *testing.T) {
+			bCtx := env.NewBubblyContext()
+			s, err := New(bCtx)
+			require.NoError(t, err)
+			stmts := `["ALTER TABLE IF EXISTS \"default\".\"product\" ADD COLUMN IF NOT EXISTS url TEXT;"]`
+			c := &schemaClient{stmts: stmts, postErr: tc.postErr}
+			s.Client = c
+			schema := `[{"name":"product","fields":[{"name":"url","type":"string"}]}]`
+
+			r := gofight.New()
+			r.POST("/api/v1/schema/preview").
+				SetBody(schema).
+				Run(s.setupRouter(), func(r gofight.HTTPResponse, rq gofight.HTTPRequest) {
+					assert.Equal(t, tc.code, r.Code)
+					if tc.postErr != nil {
+						assert.Contains(t, r.Body.String(), tc.postErr.Error())
+						return
+					}
+					assert.JSONEq(t, stmts, r.Body.String())
+				})
+			assert.Equal(t, schema, c.posted)
+		})
+	}
+}
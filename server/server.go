@@ -1,8 +1,15 @@
 package server
 
 import (
+	"context"
 	"fmt"
+	"net"
 	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
 
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
@@ -19,6 +26,18 @@ type Server struct {
 	Server *http.Server
 	Client client.Client
 	bCtx   *env.BubblyContext
+
+	// idempotency keeps the responses to requests with an idempotency key
+	idempotency *idempotencyCache
+	// stores are the clients of the named stores, which handle the requests
+	// that name a store (see NewWithStores)
+	stores map[string]client.Client
+	// ready has the stores, and organizations, that have loaded a schema
+	// (see checkReady)
+	ready sync.Map
+	// closeOnce makes sure that the clients are closed once, as both
+	// Shutdown and the caller can close the server
+	closeOnce sync.Once
 }
 
 func New(bCtx *env.BubblyContext) (*Server, error) {
@@ -26,20 +45,27 @@ func New(bCtx *env.BubblyContext) (*Server, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to create NATS client: %w", err)
 	}
+	return NewWithClient(bCtx, client), nil
+}
+
+// NewWithClient creates a new server which uses the given client to handle
+// requests. This is used when the server should not talk to the other bubbly
+// components via NATS, e.g. when running standalone with an in-process store
+func NewWithClient(bCtx *env.BubblyContext, client client.Client) *Server {
 	// create the http server
 	server := &Server{
 		Config: bCtx.ServerConfig,
 		Server: &http.Server{
-			// TODO: maybe we should use the bCtx Host here, unless it's localhost?
-			Addr: fmt.Sprintf(":%s", bCtx.ServerConfig.Port),
+			Addr: net.JoinHostPort(bCtx.ServerConfig.BindAddr, bCtx.ServerConfig.Port),
 		},
-		Client: client,
-		bCtx:   bCtx,
+		Client:      client,
+		bCtx:        bCtx,
+		idempotency: newIdempotencyCache(idempotencyKeyTTL),
 	}
 
 	server.Server.Handler = server.setupRouter()
 
-	return server, nil
+	return server
 }
 
 // SetupRouter returns a pointer to an instance of our echo server with all the
@@ -52,6 +78,7 @@ func (s *Server) setupRouter() *echo.Echo {
 		middleware.Recover(),
 		middleware.RequestID(), // Generate a request IDs
 		VersionMiddleware,
+		s.gzipMiddleware,
 		// Setup CORS middleware to allow local docker-compose setup.
 		// TODO: make this more restrictive (default "*") or come up with an
 		// approach to avoid this
@@ -73,8 +100,9 @@ func (s *Server) setupRouter() *echo.Echo {
 			Err(err).
 			Msg("API server error")
 
-		// Call the default handler to return the HTTP response
-		router.DefaultHTTPErrorHandler(err, c)
+		// Every error response has the same body, so that clients can
+		// decode the errors of all handlers in the same way
+		writeError(c, err)
 	}
 
 	// Initialize HTTP Routes
@@ -96,6 +124,57 @@ func (s *Server) ListenAndServe() error {
 	return g.Wait()
 }
 
+// Run runs the server until either the given context is cancelled or the
+// process receives an interrupt or terminate signal. On either of those, the
+// server is shutdown gracefully, allowing in-flight requests to complete
+// within the configured shutdown timeout
+func (s *Server) Run(ctx context.Context) error {
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	ch := make(chan error, 1)
+	go func() {
+		ch <- s.ListenAndServe()
+	}()
+
+	select {
+	case err := <-ch:
+		return err
+	case <-ctx.Done():
+		s.bCtx.Logger.Debug().Msg("shutting down API server")
+	}
+
+	return s.Shutdown()
+}
+
+// Shutdown gracefully shuts down the server, waiting at most the configured
+// shutdown timeout for in-flight requests to complete, and then closes the
+// server's client
+func (s *Server) Shutdown() error {
+	defer s.Close()
+
+	ctx, cancel := context.WithTimeout(
+		context.Background(),
+		time.Duration(s.Config.ShutdownTimeout)*time.Second,
+	)
+	defer cancel()
+
+	if err := s.Server.Shutdown(ctx); err != nil {
+		return fmt.Errorf("failed to shutdown server gracefully: %w", err)
+	}
+	return nil
+}
+
+// Close closes the server's client and the clients of its stores. It can be
+// called more than once, e.g. after Shutdown, and only closes them the first
+// time
 func (s *Server) Close() {
-	s.Client.Close()
+	s.closeOnce.Do(func() {
+		s.Client.Close()
+		for _, storeClient := range s.stores {
+			if storeClient != s.Client {
+				storeClient.Close()
+			}
+		}
+	})
 }
@@ -1,13 +1,22 @@
 package server
 
 import (
+	"context"
+	"fmt"
+	"io"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"strconv"
+	"syscall"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/valocode/bubbly/client"
 	"github.com/valocode/bubbly/env"
 )
 
@@ -25,3 +34,113 @@ func TestHealth(t *testing.T) {
 	assert.Equal(t, http.StatusOK, w.Code)
 	assert.Equal(t, "pong", w.Body.String())
 }
+
+func TestGracefulShutdown(t *testing.T) {
+	bCtx := env.NewBubblyContext()
+
+	// Get a free port for the server to listen on
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	port := l.Addr().(*net.TCPAddr).Port
+	require.NoError(t, l.Close())
+	bCtx.ServerConfig.Port = strconv.Itoa(port)
+
+	s, err := New(bCtx)
+	require.NoError(t, err)
+
+	// Replace the handler with one that takes some time to complete so that
+	// we can shutdown the server while the request is in-flight
+	started := make(chan struct{})
+	s.Server.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		time.Sleep(500 * time.Millisecond)
+		w.Write([]byte("done"))
+	})
+
+	runErr := make(chan error, 1)
+	go func() {
+		runErr <- s.Run(context.Background())
+	}()
+
+	var (
+		url     = fmt.Sprintf("http://127.0.0.1:%d/slow", port)
+		respErr = make(chan error, 1)
+		body    = make(chan string, 1)
+	)
+	go func() {
+		// Retry until the server is listening
+		var (
+			resp *http.Response
+			err  error
+		)
+		for i := 0; i < 50; i++ {
+			resp, err = http.Get(url)
+			if err == nil {
+				break
+			}
+			time.Sleep(50 * time.Millisecond)
+		}
+		if err != nil {
+			respErr <- err
+			return
+		}
+		defer resp.Body.Close()
+		b, err := io.ReadAll(resp.Body)
+		respErr <- err
+		body <- string(b)
+	}()
+
+	// Wait for the request to be in-flight and then send the signal
+	select {
+	case <-started:
+	case <-time.After(5 * time.Second):
+		t.Fatal("request did not reach the server")
+	}
+	require.NoError(t, syscall.Kill(os.Getpid(), syscall.SIGTERM))
+
+	// The in-flight request should complete successfully
+	require.NoError(t, <-respErr)
+	assert.Equal(t, "done", <-body)
+
+	// And the server should have shutdown without error
+	select {
+	case err := <-runErr:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("server did not shutdown")
+	}
+
+	// The listener should be closed
+	_, err = http.Get(url)
+	assert.Error(t, err)
+}
+
+// closingClient is a client.Client that counts how many times it is closed
+type closingClient struct {
+	fakeClient
+	closed int
+}
+
+func (c *closingClient) Close() {
+	c.closed++
+}
+
+func TestCloseAfterShutdown(t *testing.T) {
+	bCtx := env.NewBubblyContext()
+	var (
+		defaultClient = &closingClient{}
+		storeClient   = &closingClient{}
+	)
+	s := NewWithStores(bCtx, defaultClient, map[string]client.Client{
+		"default": defaultClient,
+		"other":   storeClient,
+	})
+
+	// Shutdown closes the server, and closing it again should not close the
+	// clients twice
+	require.NoError(t, s.Shutdown())
+	s.Close()
+
+	assert.Equal(t, 1, defaultClient.closed)
+	assert.Equal(t, 1, storeClient.closed)
+}
@@ -0,0 +1,30 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+// statusHandler godoc
+// @Summary Returns the status of the data store, such as the number of tables in the schema
+// @ID status
+// @Tags status
+// @Produce json
+// @Success 200 {object} StoreStatus
+// @Failure 500 {object} HTTPError
+// @Router /status [get]
+func (s *Server) statusHandler(c echo.Context) error {
+	auth := s.getAuthFromContext(c)
+	data, err := s.storeClient(c).GetStatus(s.bCtx, auth)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	var status StoreStatus
+	if err := json.Unmarshal(data, &status); err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("failed to decode status: %s", err))
+	}
+	return c.JSON(http.StatusOK, status)
+}
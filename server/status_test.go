@@ -0,0 +1,71 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/appleboy/gofight/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/valocode/bubbly/agent/component"
+	"github.com/valocode/bubbly/env"
+)
+
+// statusClient is a client.Client that returns a fixed status
+type statusClient struct {
+	fakeClient
+	status []byte
+	err    error
+}
+
+func (c *statusClient) GetStatus(*env.BubblyContext, *component.MessageAuth) ([]byte, error) {
+	return c.status, c.err
+}
+
+func TestStatus(t *testing.T) {
+	updated := time.Date(2021, 5, 1, 12, 0, 0, 0, time.UTC)
+	tcs := []struct {
+		desc   string
+		client *statusClient
+		code   int
+		status StoreStatus
+	}{
+		{
+			desc: "status",
+			client: &statusClient{
+				status: []byte(`{"provider":"postgres","tables":12,"schema_updated_at":"2021-05-01T12:00:00Z"}`),
+			},
+			code:   http.StatusOK,
+			status: StoreStatus{Provider: "postgres", Tables: 12, SchemaUpdatedAt: updated},
+		},
+		{
+			desc:   "store error",
+			client: &statusClient{err: errors.New("no schema exists for tenant default")},
+			code:   http.StatusInternalServerError,
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.desc, func(t *testing.T) {
+			bCtx := env.NewBubblyContext()
+			s, err := New(bCtx)
+			require.NoError(t, err)
+			s.Client = tc.client
+
+			r := gofight.New()
+			r.GET("/api/v1/status").
+				Run(s.setupRouter(), func(r gofight.HTTPResponse, rq gofight.HTTPRequest) {
+					require.Equal(t, tc.code, r.Code, r.Body.String())
+					if tc.code != http.StatusOK {
+						return
+					}
+					var status StoreStatus
+					require.NoError(t, json.Unmarshal(r.Body.Bytes(), &status))
+					assert.Equal(t, tc.status, status)
+				})
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/valocode/bubbly/client"
+	"github.com/valocode/bubbly/env"
+)
+
+const (
+	// storeHeader is the header of a request that names the store to handle
+	// the request, if the server has named stores
+	storeHeader = "X-Bubbly-Store"
+	// storeParam is the path parameter that names the store to handle the
+	// request, as an alternative to the header, e.g.
+	// /api/v1/stores/staging/graphql
+	storeParam = "store"
+	// storeContextKey is the key of the client for the store of a request in
+	// the echo context
+	storeContextKey = "store"
+)
+
+// NewWithStores creates a new server which routes the requests for the data
+// store, such as GraphQL queries and resources, to the client of the store
+// named in the request. Requests that do not name a store are handled by the
+// default client
+func NewWithStores(bCtx *env.BubblyContext, defaultClient client.Client, stores map[string]client.Client) *Server {
+	server := NewWithClient(bCtx, defaultClient)
+	server.stores = stores
+	return server
+}
+
+// storeName returns the name of the store for a request, which is given in the
+// path or in the store header, or an empty string for the default store
+func storeName(c echo.Context) string {
+	if name := c.Param(storeParam); name != "" {
+		return name
+	}
+	return c.Request().Header.Get(storeHeader)
+}
+
+// storeMiddleware gets the client for the store named in the request, which
+// the handlers get with storeClient
+func (s *Server) storeMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		name := storeName(c)
+		if name == "" {
+			return next(c)
+		}
+		storeClient, ok := s.stores[name]
+		if !ok {
+			return newAPIError(http.StatusNotFound, errCodeStoreNotFound, fmt.Sprintf("store does not exist: %s", name))
+		}
+		c.Set(storeContextKey, storeClient)
+		return next(c)
+	}
+}
+
+// storeClient returns the client for the store of the request, which is the
+// server's client unless the request names a store
+func (s *Server) storeClient(c echo.Context) client.Client {
+	if storeClient, ok := c.Get(storeContextKey).(client.Client); ok {
+		return storeClient
+	}
+	return s.Client
+}
@@ -0,0 +1,95 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/valocode/bubbly/client"
+	"github.com/valocode/bubbly/env"
+)
+
+func TestStoreRouting(t *testing.T) {
+	var (
+		defaultResult = `{"data":{"store":"default"}}`
+		stagingResult = `{"data":{"store":"staging"}}`
+		prodResult    = `{"data":{"store":"prod"}}`
+	)
+	tcs := []struct {
+		desc   string
+		path   string
+		header string
+		code   int
+		result string
+	}{
+		{
+			desc:   "no store",
+			path:   "/api/v1/graphql",
+			code:   http.StatusOK,
+			result: defaultResult,
+		},
+		{
+			desc:   "store header",
+			path:   "/api/v1/graphql",
+			header: "staging",
+			code:   http.StatusOK,
+			result: stagingResult,
+		},
+		{
+			desc:   "store path",
+			path:   "/api/v1/stores/prod/graphql",
+			code:   http.StatusOK,
+			result: prodResult,
+		},
+		{
+			desc:   "store path before header",
+			path:   "/api/v1/stores/prod/graphql",
+			header: "staging",
+			code:   http.StatusOK,
+			result: prodResult,
+		},
+		{
+			desc:   "unknown store header",
+			path:   "/api/v1/graphql",
+			header: "dev",
+			code:   http.StatusNotFound,
+		},
+		{
+			desc: "unknown store path",
+			path: "/api/v1/stores/dev/graphql",
+			code: http.StatusNotFound,
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.desc, func(t *testing.T) {
+			bCtx := env.NewBubblyContext()
+			s := NewWithStores(bCtx, &resultClient{result: defaultResult}, map[string]client.Client{
+				"staging": &resultClient{result: stagingResult},
+				"prod":    &resultClient{result: prodResult},
+			})
+
+			req, err := http.NewRequest(http.MethodPost, tc.path, strings.NewReader(`{"query":"{ store }"}`))
+			require.NoError(t, err)
+			req.Header.Set("Content-Type", "application/json")
+			if tc.header != "" {
+				req.Header.Set(storeHeader, tc.header)
+			}
+
+			w := httptest.NewRecorder()
+			s.setupRouter().ServeHTTP(w, req)
+			require.Equal(t, tc.code, w.Code, w.Body.String())
+			if tc.code != http.StatusOK {
+				var body HTTPError
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+				assert.Equal(t, errCodeStoreNotFound, body.Err.Code)
+				return
+			}
+			assert.Equal(t, tc.result, w.Body.String())
+		})
+	}
+}
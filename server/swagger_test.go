@@ -0,0 +1,53 @@
+package server
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/swaggo/swag"
+
+	// Register the generated swagger documentation
+	_ "github.com/valocode/bubbly/docs"
+)
+
+func TestSwaggerSpec(t *testing.T) {
+	doc, err := swag.ReadDoc()
+	require.NoError(t, err)
+
+	var spec struct {
+		Paths map[string]map[string]struct {
+			Responses map[string]interface{} `json:"responses"`
+		} `json:"paths"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(doc), &spec))
+
+	tcs := []struct {
+		path   string
+		method string
+		codes  []string
+	}{
+		{path: "/authorize", method: "get", codes: []string{"200", "403"}},
+		{path: "/graphql", method: "post", codes: []string{"200", "400", "503"}},
+		{path: "/resource", method: "post", codes: []string{"200", "400"}},
+		{path: "/resource/{kind}/{name}", method: "get", codes: []string{"200", "400"}},
+		{path: "/resources", method: "get", codes: []string{"200", "400"}},
+		{path: "/run/{name}", method: "post", codes: []string{"200", "400", "415"}},
+		{path: "/schema", method: "get", codes: []string{"200", "500"}},
+		{path: "/schema", method: "post", codes: []string{"200", "400"}},
+		{path: "/status", method: "get", codes: []string{"200", "500"}},
+		{path: "/upload", method: "post", codes: []string{"200", "400", "422"}},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.method+" "+tc.path, func(t *testing.T) {
+			methods, ok := spec.Paths[tc.path]
+			require.Truef(t, ok, "path %s is missing from spec", tc.path)
+			op, ok := methods[tc.method]
+			require.Truef(t, ok, "method %s is missing for path %s", tc.method, tc.path)
+			for _, code := range tc.codes {
+				assert.Containsf(t, op.Responses, code, "status code %s missing for %s %s", code, tc.method, tc.path)
+			}
+		})
+	}
+}
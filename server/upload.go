@@ -1,22 +1,41 @@
 package server
 
 import (
+	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 
 	"github.com/labstack/echo/v4"
+
+	"github.com/valocode/bubbly/agent/component"
+	"github.com/valocode/bubbly/api/core"
+	"github.com/valocode/bubbly/client"
 )
 
+// schemaTablesQuery gets the tables of the schema that is currently applied,
+// which are saved as JSON in the schema table
+var schemaTablesQuery = fmt.Sprintf(`
+{
+	%s(last:1) {
+		tables
+	}
+}
+`, core.SchemaTableName)
+
 // upload godoc
 // @Summary This function will upload core.DataBlocks
+// @Description The data blocks are validated against the current schema before they are saved.
+// @Description If any table, field or join does not exist in the schema, or a value is not of its field's type, nothing is saved and the details of the error list each invalid field
 // @ID upload data
 // @Tags datablocks
-// @Param data body []byte true "Datablocks"
+// @Param data body object true "Datablocks"
+// @Param X-Bubbly-Save-ID header string false "The ID of the data, with which data with the same ID is saved only once, so that the upload can be retried"
 // @Accept json
 // @Produce json
-// @Success 200 {object} apiResponse
-// @Failure 400 {object} apiResponse
+// @Success 200 {object} Status
+// @Failure 400 {object} HTTPError
+// @Failure 422 {object} HTTPError
 // @Router /upload [post]
 func (s *Server) upload(c echo.Context) error {
 
@@ -24,11 +43,53 @@ func (s *Server) upload(c echo.Context) error {
 	if err != nil {
 		return echo.NewHTTPError(http.StatusBadRequest, fmt.Errorf("failed to read body of request: %w", err))
 	}
+	var data core.DataBlocks
+	if err := json.Unmarshal(body, &data); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("failed to decode data blocks: %s", err.Error()))
+	}
 
 	auth := s.getAuthFromContext(c)
-	if err := s.Client.Load(s.bCtx, auth, body); err != nil {
+	tables, err := s.schemaTables(s.storeClient(c), auth)
+	if err != nil {
+		return fmt.Errorf("failed to get schema to validate data: %w", err)
+	}
+	if errs := core.ValidateData(tables, data); len(errs) > 0 {
+		details := make([]string, 0, len(errs))
+		for _, err := range errs {
+			details = append(details, err.Error())
+		}
+		return newAPIError(http.StatusUnprocessableEntity, errCodeInvalidData,
+			"data does not conform to the schema", details...)
+	}
+
+	var opts []client.LoadOption
+	if saveID := c.Request().Header.Get(client.HeaderSaveID); saveID != "" {
+		opts = append(opts, client.WithSaveID(saveID))
+	}
+	if err := s.storeClient(c).Load(s.bCtx, auth, body, opts...); err != nil {
 		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
 	}
 
 	return c.JSON(http.StatusOK, &Status{"uploaded"})
 }
+
+// schemaTables returns the tables of the schema that is currently applied,
+// by name, from the given store client. If no schema has been applied there
+// are no tables
+func (s *Server) schemaTables(storeClient client.Client, auth *component.MessageAuth) (map[string]core.Table, error) {
+	var result map[string][]struct {
+		Tables string `json:"tables"`
+	}
+	if err := storeClient.QueryType(s.bCtx, auth, schemaTablesQuery, &result); err != nil {
+		return nil, fmt.Errorf("failed to query schema: %w", err)
+	}
+	schemas := result[core.SchemaTableName]
+	tables := make(map[string]core.Table)
+	if len(schemas) == 0 {
+		return tables, nil
+	}
+	if err := json.Unmarshal([]byte(schemas[0].Tables), &tables); err != nil {
+		return nil, fmt.Errorf("failed to decode schema tables: %w", err)
+	}
+	return tables, nil
+}
@@ -1,12 +1,20 @@
 package server
 
 import (
+	"bytes"
+	"encoding/json"
 	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	"github.com/appleboy/gofight/v2"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/valocode/bubbly/agent/component"
+	"github.com/valocode/bubbly/api/core"
+	"github.com/valocode/bubbly/client"
 	"github.com/valocode/bubbly/env"
 	testData "github.com/valocode/bubbly/server/testdata/upload"
 )
@@ -45,3 +53,165 @@ func TestUploadFailing(t *testing.T) {
 			assert.Equal(t, http.StatusBadRequest, r.Code)
 		})
 }
+
+// uploadClient is a client.Client with a fixed schema, which records the data
+// that is loaded
+type uploadClient struct {
+	fakeClient
+	tables core.Tables
+	loaded []byte
+	saveID string
+}
+
+func (c *uploadClient) QueryType(_ *env.BubblyContext, _ *component.MessageAuth, _ string, ptr interface{}) error {
+	tables := make(map[string]core.Table, len(c.tables))
+	for _, table := range c.tables {
+		tables[table.Name] = table
+	}
+	tablesJSON, err := json.Marshal(tables)
+	if err != nil {
+		return err
+	}
+	result, err := json.Marshal(map[string]interface{}{
+		core.SchemaTableName: []map[string]string{{"tables": string(tablesJSON)}},
+	})
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(result, ptr)
+}
+
+func (c *uploadClient) Load(_ *env.BubblyContext, _ *component.MessageAuth, data []byte, opts ...client.LoadOption) error {
+	c.loaded = data
+	c.saveID = client.SaveID(opts...)
+	return nil
+}
+
+func TestUploadValidation(t *testing.T) {
+	tables := core.Tables{
+		{
+			Name: "product",
+			Fields: []core.TableField{
+				{Name: "name", Type: cty.String},
+			},
+		},
+		{
+			Name: "release",
+			Fields: []core.TableField{
+				{Name: "version", Type: cty.String},
+				{Name: "count", Type: cty.Number},
+			},
+			Joins: []core.TableJoin{{Table: "product"}},
+		},
+	}
+	tcs := []struct {
+		desc    string
+		data    core.DataBlocks
+		code    int
+		details []string
+	}{
+		{
+			desc: "valid data",
+			data: core.DataBlocks{
+				{
+					TableName: "product",
+					Fields: &core.DataFields{Values: map[string]cty.Value{
+						"name": cty.StringVal("bubbly"),
+					}},
+					Data: core.DataBlocks{
+						{
+							TableName: "release",
+							Fields: &core.DataFields{Values: map[string]cty.Value{
+								"version": cty.StringVal("v1"),
+								"count":   cty.NumberIntVal(1),
+							}},
+						},
+					},
+				},
+			},
+			code: http.StatusOK,
+		},
+		{
+			desc: "unknown field",
+			data: core.DataBlocks{
+				{
+					TableName: "product",
+					Fields: &core.DataFields{Values: map[string]cty.Value{
+						"name":  cty.StringVal("bubbly"),
+						"owner": cty.StringVal("me"),
+					}},
+				},
+			},
+			code:    http.StatusUnprocessableEntity,
+			details: []string{`data[0].fields.owner: field does not exist in table "product"`},
+		},
+		{
+			desc: "wrong type",
+			data: core.DataBlocks{
+				{
+					TableName: "release",
+					Fields: &core.DataFields{Values: map[string]cty.Value{
+						"version": cty.StringVal("v1"),
+						"count":   cty.StringVal("many"),
+					}},
+				},
+			},
+			code:    http.StatusUnprocessableEntity,
+			details: []string{"data[0].fields.count: expected value of type number, got string"},
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.desc, func(t *testing.T) {
+			bCtx := env.NewBubblyContext()
+			s, err := New(bCtx)
+			require.NoError(t, err)
+			c := &uploadClient{tables: tables}
+			s.Client = c
+
+			router := s.setupRouter()
+
+			body, err := json.Marshal(tc.data)
+			require.NoError(t, err)
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(http.MethodPost, "/api/v1/upload", bytes.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+			router.ServeHTTP(w, req)
+
+			require.Equal(t, tc.code, w.Code)
+			if tc.code == http.StatusOK {
+				assert.Equal(t, body, c.loaded)
+				return
+			}
+			// Nothing should be saved if the data is not valid
+			assert.Nil(t, c.loaded)
+			var resp HTTPError
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+			assert.Equal(t, errCodeInvalidData, resp.Err.Code)
+			assert.Equal(t, tc.details, resp.Err.Details)
+		})
+	}
+}
+
+// TestUploadSaveID tests that the save ID of an upload is passed to the store
+func TestUploadSaveID(t *testing.T) {
+	bCtx := env.NewBubblyContext()
+	s, err := New(bCtx)
+	require.NoError(t, err)
+	c := &uploadClient{}
+	s.Client = c
+
+	router := s.setupRouter()
+
+	for _, saveID := range []string{"", "run-1"} {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPost, "/api/v1/upload", bytes.NewReader([]byte("[]")))
+		req.Header.Set("Content-Type", "application/json")
+		if saveID != "" {
+			req.Header.Set(client.HeaderSaveID, saveID)
+		}
+		router.ServeHTTP(w, req)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, saveID, c.saveID)
+	}
+}
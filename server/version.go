@@ -1,16 +1,29 @@
 package server
 
 import (
+	"net/http"
+
 	"github.com/labstack/echo/v4"
-)
 
-var version string
+	"github.com/valocode/bubbly/env"
+)
 
 // VersionMiddleware : add version on header.
 func VersionMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
 	// Set out header value for each response
 	return func(c echo.Context) error {
-		c.Response().Header().Set("x-bubbly-version", version)
+		c.Response().Header().Set("x-bubbly-version", env.Version)
 		return next(c)
 	}
 }
+
+// versionHandler godoc
+// @Summary Returns the version of the bubbly server
+// @ID version
+// @Tags version
+// @Produce json
+// @Success 200 {object} Version
+// @Router /version [get]
+func (s *Server) versionHandler(c echo.Context) error {
+	return c.JSON(http.StatusOK, Version{Version: env.Version})
+}
@@ -0,0 +1,189 @@
+package store
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/valocode/bubbly/api/core"
+	"github.com/valocode/bubbly/env"
+	"github.com/valocode/bubbly/test"
+)
+
+// TestAddTables applies a schema and data, then adds a table to the schema and
+// checks that the existing data survived and that the new table can be used
+func TestAddTables(t *testing.T) {
+	bCtx := env.NewBubblyContext()
+	resource := test.RunPostgresDocker(bCtx, t)
+	bCtx.StoreConfig.PostgresAddr = fmt.Sprintf("localhost:%s", resource.GetPort("5432/tcp"))
+
+	s, err := New(bCtx)
+	require.NoErrorf(t, err, "failed to initialize store")
+
+	applySchemaOrDie(t, bCtx, s, filepath.Join("testdata", "tables.hcl"))
+	loadTestDataOrDie(t, bCtx, s, filepath.Join("testdata", "data.hcl"))
+
+	err = s.AddTables(DefaultTenantName, core.Tables{
+		{
+			Name: "root",
+			Tables: core.Tables{
+				{
+					Name: "added",
+					Fields: []core.TableField{
+						{Name: "name", Type: cty.String, Unique: true},
+					},
+				},
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	// The existing data should still be there
+	result, err := s.Query(DefaultTenantName, `{ root(name: "first_root") { name } }`)
+	require.NoError(t, err)
+	require.Empty(t, result.Errors)
+	assert.Equal(t, map[string]interface{}{
+		"root": []interface{}{
+			map[string]interface{}{"name": "first_root"},
+		},
+	}, result.Data)
+
+	// And the new table should be usable
+	err = s.Save(DefaultTenantName, core.DataBlocks{
+		{
+			TableName: "root",
+			Fields: &core.DataFields{Values: map[string]cty.Value{
+				"name": cty.StringVal("first_root"),
+			}},
+			Policy: core.ReferencePolicy,
+			Data: core.DataBlocks{
+				{
+					TableName: "added",
+					Fields: &core.DataFields{Values: map[string]cty.Value{
+						"name": cty.StringVal("first_added"),
+					}},
+				},
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	result, err = s.Query(DefaultTenantName, `{ root(name: "first_root") { name added { name } } }`)
+	require.NoError(t, err)
+	require.Empty(t, result.Errors)
+	assert.Equal(t, map[string]interface{}{
+		"root": []interface{}{
+			map[string]interface{}{
+				"name": "first_root",
+				"added": []interface{}{
+					map[string]interface{}{"name": "first_added"},
+				},
+			},
+		},
+	}, result.Data)
+}
+
+func TestExtendBubblySchema(t *testing.T) {
+	schema := &bubblySchema{Tables: map[string]core.Table{
+		"team": {
+			Name: "team",
+			Fields: []core.TableField{
+				{Name: "name", Type: cty.String, Unique: true},
+			},
+		},
+	}}
+	tcs := []struct {
+		desc   string
+		tables core.Tables
+		want   map[string]core.Table
+		err    string
+	}{
+		{
+			desc: "new table",
+			tables: core.Tables{
+				{Name: "member", Fields: []core.TableField{{Name: "name", Type: cty.String}}},
+			},
+			want: map[string]core.Table{
+				"team":   schema.Tables["team"],
+				"member": {Name: "member", Fields: []core.TableField{{Name: "name", Type: cty.String}}},
+			},
+		},
+		{
+			desc: "new field and nested table",
+			tables: core.Tables{
+				{
+					Name:   "team",
+					Fields: []core.TableField{{Name: "size", Type: cty.Number}},
+					Tables: core.Tables{{Name: "member"}},
+				},
+			},
+			want: map[string]core.Table{
+				"team": {
+					Name: "team",
+					Fields: []core.TableField{
+						{Name: "name", Type: cty.String, Unique: true},
+						{Name: "size", Type: cty.Number},
+					},
+				},
+				"member": {Name: "member", Joins: []core.TableJoin{{Table: "team"}}},
+			},
+		},
+		{
+			desc:   "builtin table as parent",
+			tables: core.Tables{{Name: "release", Tables: core.Tables{{Name: "member"}}}},
+			want: map[string]core.Table{
+				"team":   schema.Tables["team"],
+				"member": {Name: "member", Joins: []core.TableJoin{{Table: "release"}}},
+			},
+		},
+		{
+			desc:   "modify builtin table",
+			tables: core.Tables{{Name: "release", Fields: []core.TableField{{Name: "extra", Type: cty.String}}}},
+			err:    "cannot modify builtin table release",
+		},
+		{
+			desc:   "change field",
+			tables: core.Tables{{Name: "team", Fields: []core.TableField{{Name: "name", Type: cty.Number, Unique: true}}}},
+			err:    "cannot change field name of existing table team",
+		},
+		{
+			desc:   "add index",
+			tables: core.Tables{{Name: "team", Indexes: [][]string{{"name"}}}},
+			want: map[string]core.Table{
+				"team": {
+					Name:    "team",
+					Fields:  []core.TableField{{Name: "name", Type: cty.String, Unique: true}},
+					Indexes: [][]string{{"name"}},
+				},
+			},
+		},
+		{
+			desc:   "index on missing field",
+			tables: core.Tables{{Name: "team", Indexes: [][]string{{"code"}}}},
+			err:    "index field code does not exist in table team",
+		},
+		{
+			desc:   "add unique field",
+			tables: core.Tables{{Name: "team", Fields: []core.TableField{{Name: "code", Type: cty.String, Unique: true}}}},
+			err:    "cannot add unique field code to existing table team",
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.desc, func(t *testing.T) {
+			newSchema, err := extendBubblySchema(schema, tc.tables)
+			if tc.err != "" {
+				require.EqualError(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, newSchema.Tables)
+			// The existing schema should not have been changed
+			assert.Len(t, schema.Tables, 1)
+			assert.Len(t, schema.Tables["team"].Fields, 1)
+		})
+	}
+}
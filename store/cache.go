@@ -0,0 +1,202 @@
+package store
+
+import (
+	"container/list"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/graphql-go/graphql/language/printer"
+	"github.com/graphql-go/graphql/language/visitor"
+)
+
+// queryCache is an LRU cache of GraphQL query results per tenant.
+// Each cached result keeps the set of names used in the query, which is a
+// superset of the tables that the query reads. When data is saved to a table,
+// every result whose query used the table's name is invalidated.
+type queryCache struct {
+	mu   sync.Mutex
+	size int
+	ttl  time.Duration
+	// lru holds *queryCacheEntry, with the most recently used at the front
+	lru     *list.List
+	entries map[queryCacheKey]*list.Element
+	// generations is incremented for a tenant whenever results for the tenant
+	// are invalidated. It is used to avoid caching results of queries which
+	// were running while the invalidation happened, as they might be stale
+	generations map[string]uint64
+	// now is used to get the current time, and can be overridden for testing
+	now func() time.Time
+}
+
+type queryCacheKey struct {
+	tenant string
+	query  string
+}
+
+type queryCacheEntry struct {
+	key     queryCacheKey
+	names   map[string]struct{}
+	result  *graphql.Result
+	expires time.Time
+}
+
+// newQueryCache returns a new queryCache holding at most size results, each
+// for at most ttl. If size is not positive nil is returned, which is a valid
+// cache that never caches anything
+func newQueryCache(size int, ttl time.Duration) *queryCache {
+	if size <= 0 {
+		return nil
+	}
+	return &queryCache{
+		size:        size,
+		ttl:         ttl,
+		lru:         list.New(),
+		entries:     make(map[queryCacheKey]*list.Element),
+		generations: make(map[string]uint64),
+		now:         time.Now,
+	}
+}
+
+// cachedQuery is a query that has been prepared for the cache
+type cachedQuery struct {
+	key        queryCacheKey
+	names      map[string]struct{}
+	generation uint64
+}
+
+// prepare normalizes the query so that it can be used to get and set results
+// in the cache. If the query cannot be parsed, false is returned and the query
+// should not be cached
+func (c *queryCache) prepare(tenant string, query string) (*cachedQuery, bool) {
+	if c == nil {
+		return nil, false
+	}
+	doc, err := parser.Parse(parser.ParseParams{Source: query})
+	if err != nil {
+		return nil, false
+	}
+	normalized, ok := printer.Print(doc).(string)
+	if !ok {
+		return nil, false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return &cachedQuery{
+		key:        queryCacheKey{tenant: tenant, query: normalized},
+		names:      queryNames(doc),
+		generation: c.generations[tenant],
+	}, true
+}
+
+// get returns the cached result for the query, if there is one that has not
+// expired
+func (c *queryCache) get(q *cachedQuery) (*graphql.Result, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	elem, ok := c.entries[q.key]
+	if !ok {
+		return nil, false
+	}
+	entry := elem.Value.(*queryCacheEntry)
+	if c.ttl > 0 && c.now().After(entry.expires) {
+		c.remove(elem)
+		return nil, false
+	}
+	c.lru.MoveToFront(elem)
+	return entry.result, true
+}
+
+// set caches the result for the query, unless the tenant's results have been
+// invalidated since the query was prepared
+func (c *queryCache) set(q *cachedQuery, result *graphql.Result) {
+	// Do not cache errors, as they might be temporary
+	if result.HasErrors() {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.generations[q.key.tenant] != q.generation {
+		return
+	}
+	if elem, ok := c.entries[q.key]; ok {
+		c.remove(elem)
+	}
+	c.entries[q.key] = c.lru.PushFront(&queryCacheEntry{
+		key:     q.key,
+		names:   q.names,
+		result:  result,
+		expires: c.now().Add(c.ttl),
+	})
+	for c.lru.Len() > c.size {
+		c.remove(c.lru.Back())
+	}
+}
+
+// invalidate removes the cached results of the tenant that used any of the
+// given tables
+func (c *queryCache) invalidate(tenant string, tables map[string]struct{}) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.generations[tenant]++
+	for key, elem := range c.entries {
+		if key.tenant != tenant {
+			continue
+		}
+		for table := range tables {
+			if _, ok := elem.Value.(*queryCacheEntry).names[table]; ok {
+				c.remove(elem)
+				break
+			}
+		}
+	}
+}
+
+// invalidateTenant removes all the cached results of the tenant
+func (c *queryCache) invalidateTenant(tenant string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.generations[tenant]++
+	for key, elem := range c.entries {
+		if key.tenant == tenant {
+			c.remove(elem)
+		}
+	}
+}
+
+func (c *queryCache) remove(elem *list.Element) {
+	c.lru.Remove(elem)
+	delete(c.entries, elem.Value.(*queryCacheEntry).key)
+}
+
+// queryNames returns all the names used in a query document. As tables are
+// queried by their name, or the name of their aggregates, distinct values or
+// single rows by ID, and filtered on the existence of related rows by the name
+// of the related table, this includes every table that the query reads
+func queryNames(doc *ast.Document) map[string]struct{} {
+	names := make(map[string]struct{})
+	visitor.Visit(doc, &visitor.VisitorOptions{
+		Enter: func(p visitor.VisitFuncParams) (string, interface{}) {
+			if name, ok := p.Node.(*ast.Name); ok {
+				names[name.Value] = struct{}{}
+				names[strings.TrimSuffix(name.Value, aggregateSuffix)] = struct{}{}
+				names[strings.TrimSuffix(name.Value, distinctSuffix)] = struct{}{}
+				names[strings.TrimSuffix(name.Value, byIDSuffix)] = struct{}{}
+				names[strings.TrimSuffix(name.Value, filterExists)] = struct{}{}
+				names[strings.TrimSuffix(name.Value, filterNotExists)] = struct{}{}
+			}
+			return visitor.ActionNoChange, nil
+		},
+	}, nil)
+	return names
+}
@@ -0,0 +1,221 @@
+package store
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/graphql-go/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/valocode/bubbly/api/core"
+	"github.com/valocode/bubbly/env"
+	"github.com/valocode/bubbly/test"
+)
+
+func TestQueryCache(t *testing.T) {
+	const query = `{ root { name child_a { name } } }`
+	tcs := []struct {
+		desc string
+		// act is called after the query result has been cached
+		act    func(c *queryCache)
+		cached bool
+	}{
+		{
+			desc:   "repeated query",
+			act:    func(c *queryCache) {},
+			cached: true,
+		},
+		{
+			desc: "save to queried table",
+			act: func(c *queryCache) {
+				c.invalidate(DefaultTenantName, map[string]struct{}{"root": {}})
+			},
+			cached: false,
+		},
+		{
+			desc: "save to nested queried table",
+			act: func(c *queryCache) {
+				c.invalidate(DefaultTenantName, map[string]struct{}{"child_a": {}})
+			},
+			cached: false,
+		},
+		{
+			desc: "save to other table",
+			act: func(c *queryCache) {
+				c.invalidate(DefaultTenantName, map[string]struct{}{"child_b": {}})
+			},
+			cached: true,
+		},
+		{
+			desc: "save to other tenant",
+			act: func(c *queryCache) {
+				c.invalidate("other", map[string]struct{}{"root": {}})
+			},
+			cached: true,
+		},
+		{
+			desc: "schema change",
+			act: func(c *queryCache) {
+				c.invalidateTenant(DefaultTenantName)
+			},
+			cached: false,
+		},
+		{
+			desc: "expired",
+			act: func(c *queryCache) {
+				c.now = func() time.Time { return time.Now().Add(2 * time.Minute) }
+			},
+			cached: false,
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.desc, func(t *testing.T) {
+			c := newQueryCache(10, time.Minute)
+			q, ok := c.prepare(DefaultTenantName, query)
+			require.True(t, ok)
+			result := &graphql.Result{Data: "result"}
+			c.set(q, result)
+
+			tc.act(c)
+
+			// Use a differently formatted query, which should be normalized
+			q, ok = c.prepare(DefaultTenantName, "{\n\troot {\n\t\tname\n\t\tchild_a { name }\n\t}\n}")
+			require.True(t, ok)
+			cached, ok := c.get(q)
+			assert.Equal(t, tc.cached, ok)
+			if tc.cached {
+				assert.Same(t, result, cached)
+			}
+		})
+	}
+}
+
+// TestQueryCacheRelationFilter checks that a query which filters on the
+// existence of rows in a related table is invalidated by saves to that table
+func TestQueryCacheRelationFilter(t *testing.T) {
+	tcs := []struct {
+		desc   string
+		query  string
+		table  string
+		cached bool
+	}{
+		{
+			desc:  "exists",
+			query: `{ root(filter: {child_a_exists: {name_eq: "a"}}) { name } }`,
+			table: "child_a",
+		},
+		{
+			desc:  "not exists",
+			query: `{ root(filter: {child_a_not_exists: {name_eq: "a"}}) { name } }`,
+			table: "child_a",
+		},
+		{
+			desc:   "other table",
+			query:  `{ root(filter: {child_a_not_exists: {name_eq: "a"}}) { name } }`,
+			table:  "child_b",
+			cached: true,
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.desc, func(t *testing.T) {
+			c := newQueryCache(10, time.Minute)
+			q, ok := c.prepare(DefaultTenantName, tc.query)
+			require.True(t, ok)
+			c.set(q, &graphql.Result{Data: "result"})
+
+			c.invalidate(DefaultTenantName, map[string]struct{}{tc.table: {}})
+
+			q, ok = c.prepare(DefaultTenantName, tc.query)
+			require.True(t, ok)
+			_, ok = c.get(q)
+			assert.Equal(t, tc.cached, ok)
+		})
+	}
+}
+
+func TestQueryCacheInvalidatedWhileQuerying(t *testing.T) {
+	c := newQueryCache(10, time.Minute)
+	q, ok := c.prepare(DefaultTenantName, `{ root { name } }`)
+	require.True(t, ok)
+	// A save happens while the query is running, so the result of the query
+	// might be stale and should not be cached
+	c.invalidate(DefaultTenantName, map[string]struct{}{"root": {}})
+	c.set(q, &graphql.Result{Data: "stale"})
+	_, ok = c.get(q)
+	assert.False(t, ok)
+}
+
+func TestQueryCacheEviction(t *testing.T) {
+	c := newQueryCache(2, 0)
+	var queries []*cachedQuery
+	for i := 0; i < 3; i++ {
+		q, ok := c.prepare(DefaultTenantName, fmt.Sprintf(`{ root(name: "%d") { name } }`, i))
+		require.True(t, ok)
+		queries = append(queries, q)
+	}
+	c.set(queries[0], &graphql.Result{})
+	c.set(queries[1], &graphql.Result{})
+	// Use the first query so that the second is the least recently used
+	_, ok := c.get(queries[0])
+	require.True(t, ok)
+	c.set(queries[2], &graphql.Result{})
+
+	_, ok = c.get(queries[0])
+	assert.True(t, ok)
+	_, ok = c.get(queries[1])
+	assert.False(t, ok, "least recently used result should be evicted")
+	_, ok = c.get(queries[2])
+	assert.True(t, ok)
+}
+
+func TestQueryCacheDisabled(t *testing.T) {
+	c := newQueryCache(0, time.Minute)
+	_, ok := c.prepare(DefaultTenantName, `{ root { name } }`)
+	assert.False(t, ok)
+	// Invalidating a disabled cache should be a no-op
+	c.invalidate(DefaultTenantName, map[string]struct{}{"root": {}})
+	c.invalidateTenant(DefaultTenantName)
+}
+
+func TestStoreQueryCache(t *testing.T) {
+	bCtx := env.NewBubblyContext()
+	resource := test.RunPostgresDocker(bCtx, t)
+	bCtx.StoreConfig.PostgresAddr = fmt.Sprintf("localhost:%s", resource.GetPort("5432/tcp"))
+	bCtx.StoreConfig.QueryCacheSize = 10
+
+	s, err := New(bCtx)
+	require.NoError(t, err)
+	applySchemaOrDie(t, bCtx, s, filepath.FromSlash("testdata/tables.hcl"))
+	loadTestDataOrDie(t, bCtx, s, filepath.FromSlash("testdata/data.hcl"))
+
+	const query = `{ root { name } }`
+	first, err := s.Query(DefaultTenantName, query)
+	require.NoError(t, err)
+	require.Empty(t, first.Errors)
+	second, err := s.Query(DefaultTenantName, query)
+	require.NoError(t, err)
+	assert.Same(t, first, second, "repeated query should be served from the cache")
+
+	err = s.Save(DefaultTenantName, core.DataBlocks{
+		{
+			TableName: "root",
+			Fields: &core.DataFields{Values: map[string]cty.Value{
+				"name": cty.StringVal("cache_root"),
+			}},
+		},
+	})
+	require.NoError(t, err)
+
+	third, err := s.Query(DefaultTenantName, query)
+	require.NoError(t, err)
+	require.Empty(t, third.Errors)
+	assert.NotSame(t, first, third, "query should not be served from the cache after a save")
+	assert.Len(t,
+		third.Data.(map[string]interface{})["root"],
+		len(first.Data.(map[string]interface{})["root"].([]interface{}))+1,
+	)
+}
@@ -18,36 +18,45 @@ var _ provider = (*cockroachdb)(nil)
 // FIXME: because Roach provider was heavy dependent on Postgres,
 //        it now also uses pgpool connection pool. Is that ok?
 
-func newCockroachdb(bCtx *env.BubblyContext) (*cockroachdb, error) {
-	connStr := fmt.Sprintf(
-		"postgres://%s:%s@%s/%s",
-		bCtx.StoreConfig.CockroachUser,
-		bCtx.StoreConfig.CockroachPassword,
-		bCtx.StoreConfig.CockroachAddr,
-		bCtx.StoreConfig.CockroachDatabase,
-	)
-	pool, err := psqlNewPool(bCtx, connStr)
+func newCockroachdb(bCtx *env.BubblyContext, o *options) (*cockroachdb, error) {
+	connStr, err := bCtx.StoreConfig.CockroachConnString()
+	if err != nil {
+		return nil, err
+	}
+	pool, err := psqlNewPool(bCtx, connStr, o.queryHook)
 	if err != nil {
 		return nil, fmt.Errorf("failed to initialize connection to db: %w", err)
 	}
 
 	return &cockroachdb{
-		pool: pool,
+		pool:          pool,
+		limits:        newQueryLimits(bCtx),
+		rowFilterHook: o.rowFilterHook,
+		softDelete:    bCtx.StoreConfig.SoftDelete,
 	}, nil
 }
 
 type cockroachdb struct {
-	pool *pgxpool.Pool
+	pool          *pgxpool.Pool
+	limits        queryLimits
+	rowFilterHook RowFilterHook
+	// softDelete is whether deleted rows are marked as deleted instead of
+	// being removed
+	softDelete bool
 }
 
 func (c *cockroachdb) Close() {
 	c.pool.Close()
 }
 
+func (c *cockroachdb) Ping() error {
+	return psqlPing(c.pool)
+}
+
 func (c *cockroachdb) Apply(tenant string, schema *bubblySchema) error {
 
 	err := crdbpgx.ExecuteTx(context.Background(), c.pool, pgx.TxOptions{}, func(tx pgx.Tx) error {
-		return psqlApplySchema(tx, tenant, schema)
+		return psqlApplySchema(tx, tenant, schema, c.softDelete)
 	})
 	if err != nil {
 		return fmt.Errorf("failed to apply tables: %w", err)
@@ -57,13 +66,21 @@ func (c *cockroachdb) Apply(tenant string, schema *bubblySchema) error {
 }
 
 func (c *cockroachdb) Migrate(tenant string, schema *bubblySchema, cl schemaUpdates) error {
-	migration, err := psqlGenerateMigration(config.CockroachDBStore, tenant, schema, cl)
+	migration, err := c.MigrationSQL(tenant, schema, cl)
 	if err != nil {
-		return fmt.Errorf("failed to generate migration list: %w", err)
+		return err
 	}
 	return psqlMigrate(c.pool, tenant, schema, migration)
 }
 
+func (c *cockroachdb) MigrationSQL(tenant string, schema *bubblySchema, cl schemaUpdates) ([]string, error) {
+	migration, err := psqlGenerateMigration(config.CockroachDBStore, tenant, schema, cl, c.softDelete)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate migration list: %w", err)
+	}
+	return migration, nil
+}
+
 func (c *cockroachdb) Save(bCtx *env.BubblyContext, tenant string, graph *SchemaGraph, tree dataTree) error {
 
 	err := crdbpgx.ExecuteTx(context.Background(), c.pool, pgx.TxOptions{}, func(tx pgx.Tx) error {
@@ -74,7 +91,7 @@ func (c *cockroachdb) Save(bCtx *env.BubblyContext, tenant string, graph *Schema
 			if !ok {
 				return fmt.Errorf("data block refers to non-existing table: %s", node.Data.TableName)
 			}
-			return psqlSaveNode(tx, tenant, node, *tNode.Table)
+			return psqlSaveNode(tx, tenant, node, *tNode.Table, c.softDelete)
 		}
 
 		_, err := tree.traverse(bCtx, saveNode)
@@ -88,8 +105,22 @@ func (c *cockroachdb) Save(bCtx *env.BubblyContext, tenant string, graph *Schema
 	return nil
 }
 
+func (c *cockroachdb) DeleteResource(tenant string, id string) (bool, error) {
+	var deleted bool
+	err := crdbpgx.ExecuteTx(context.Background(), c.pool, pgx.TxOptions{}, func(tx pgx.Tx) error {
+		var err error
+		deleted, err = psqlDeleteResource(tx, tenant, id, c.softDelete)
+		return err
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to delete resource in cockroachdb: %w", err)
+	}
+
+	return deleted, nil
+}
+
 func (c *cockroachdb) ResolveQuery(tenant string, graph *SchemaGraph, params graphql.ResolveParams) (interface{}, error) {
-	return psqlResolveRootQueries(c.pool, tenant, graph, params)
+	return psqlResolveRootQueries(c.pool, tenant, graph, c.limits, c.rowFilterHook, c.softDelete, params)
 }
 
 func (c *cockroachdb) Tenants() ([]string, error) {
@@ -103,3 +134,7 @@ func (c *cockroachdb) CreateTenant(name string) error {
 func (c *cockroachdb) HasTable(tenant string, table string) (bool, error) {
 	return psqlHasTable(c.pool, tenant, table)
 }
+
+func (c *cockroachdb) Upgrade(tenant string) error {
+	return psqlUpgradeTables(c.pool, tenant, c.softDelete)
+}
@@ -0,0 +1,175 @@
+package store
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/valocode/bubbly/api/core"
+	"github.com/valocode/bubbly/config"
+	"github.com/valocode/bubbly/env"
+	testData "github.com/valocode/bubbly/store/testdata"
+	"github.com/valocode/bubbly/test"
+)
+
+// conformanceProviders are the providers that the conformance tests run
+// against. Each provider has a setup that starts its database and configures
+// the BubblyContext to use it, so that New creates a Store with the provider.
+//
+// A new provider opts in to the conformance tests by adding an entry here,
+// which makes the tests fail wherever it behaves differently from the other
+// providers, e.g. returning a list where they return a single object
+var conformanceProviders = []struct {
+	name  config.StoreProviderType
+	setup func(t *testing.T, bCtx *env.BubblyContext)
+}{
+	{
+		name: config.PostgresStore,
+		setup: func(t *testing.T, bCtx *env.BubblyContext) {
+			bCtx.StoreConfig.Provider = config.PostgresStore
+			resource := test.RunPostgresDocker(bCtx, t)
+			bCtx.StoreConfig.PostgresAddr = fmt.Sprintf("localhost:%s", resource.GetPort("5432/tcp"))
+		},
+	},
+	{
+		name: config.CockroachDBStore,
+		setup: func(t *testing.T, bCtx *env.BubblyContext) {
+			bCtx.StoreConfig.Provider = config.CockroachDBStore
+			resource := test.RunCockroachDocker(bCtx, t)
+			bCtx.StoreConfig.CockroachAddr = fmt.Sprintf("localhost:%s", resource.GetPort("26257/tcp"))
+		},
+	},
+}
+
+// conformanceTests are the queries whose results must be the same for every
+// provider, after the data of testdata/data.hcl and conformanceData are saved
+var conformanceTests = []struct {
+	desc     string
+	query    string
+	expected interface{}
+}{
+	{
+		desc:  "single child is an object",
+		query: `{ root(name: "first_root") { name child_b { name } } }`,
+		expected: map[string]interface{}{
+			"root": []interface{}{
+				map[string]interface{}{
+					"name":    "first_root",
+					"child_b": map[string]interface{}{"name": "only_child"},
+				},
+			},
+		},
+	},
+	{
+		desc:  "missing single child is null",
+		query: `{ root(name: "second_root") { name child_b { name } } }`,
+		expected: map[string]interface{}{
+			"root": []interface{}{
+				map[string]interface{}{
+					"name":    "second_root",
+					"child_b": nil,
+				},
+			},
+		},
+	},
+	{
+		desc:  "missing children are an empty list",
+		query: `{ root(name: "second_root") { name child_a { name } } }`,
+		expected: map[string]interface{}{
+			"root": []interface{}{
+				map[string]interface{}{
+					"name":    "second_root",
+					"child_a": []interface{}{},
+				},
+			},
+		},
+	},
+	{
+		desc:  "no rows is an empty list",
+		query: `{ root(name: "no_root") { name } }`,
+		expected: map[string]interface{}{
+			"root": []interface{}{},
+		},
+	},
+	{
+		desc:  "saving again updates instead of inserting",
+		query: `{ root_aggregate { count } }`,
+		expected: map[string]interface{}{
+			"root_aggregate": []interface{}{
+				map[string]interface{}{"count": 2},
+			},
+		},
+	},
+}
+
+// conformanceData is saved after testdata/data.hcl, and saves first_root
+// again with its single child
+var conformanceData = core.DataBlocks{
+	{
+		TableName: "root",
+		Fields: &core.DataFields{Values: map[string]cty.Value{
+			"name": cty.StringVal("first_root"),
+		}},
+		Data: core.DataBlocks{
+			{
+				TableName: "child_b",
+				Fields: &core.DataFields{Values: map[string]cty.Value{
+					"name": cty.StringVal("only_child"),
+				}},
+			},
+		},
+	},
+}
+
+// TestProviderConformance runs the same tests of applying schemas, saving data
+// and querying it against each of the conformanceProviders
+func TestProviderConformance(t *testing.T) {
+	for _, p := range conformanceProviders {
+		t.Run(string(p.name), func(t *testing.T) {
+			bCtx := env.NewBubblyContext()
+			p.setup(t, bCtx)
+
+			s, err := New(bCtx)
+			require.NoErrorf(t, err, "failed to initialize store")
+			t.Cleanup(s.Close)
+
+			applySchemaOrDie(t, bCtx, s, filepath.FromSlash("testdata/tables.hcl"))
+			loadTestDataOrDie(t, bCtx, s, filepath.FromSlash("testdata/data.hcl"))
+			require.NoError(t, s.Save(DefaultTenantName, conformanceData))
+
+			for _, tc := range conformanceTests {
+				t.Run(tc.desc, func(t *testing.T) {
+					result, err := s.Query(DefaultTenantName, tc.query)
+					require.NoError(t, err)
+					require.Empty(t, result.Errors)
+					assert.Equal(t, tc.expected, result.Data)
+				})
+			}
+			t.Run("tenant", func(t *testing.T) {
+				const tenant = "conformance"
+				require.NoError(t, s.CreateTenant(tenant))
+				// A new tenant does not have the tables of other tenants
+				result, err := s.Query(tenant, `{ root { name } }`)
+				require.NoError(t, err)
+				require.NotEmpty(t, result.Errors)
+
+				tables := testData.Tables(t, bCtx, filepath.FromSlash("testdata/tables.hcl"))
+				require.NoError(t, s.Apply(tenant, tables, true))
+				result, err = s.Query(tenant, `{ root { name } }`)
+				require.NoError(t, err)
+				require.Empty(t, result.Errors)
+				assert.Equal(t, map[string]interface{}{"root": []interface{}{}}, result.Data)
+			})
+
+			runQueryTestsOrDie(t, bCtx, s)
+			runResourceTestsOrDie(t, bCtx, s)
+			runEventTestsOrDie(t, bCtx, s)
+			runDeleteResourceTestsOrDie(t, bCtx, s)
+			runSaveRollbackTestsOrDie(t, bCtx, s)
+		})
+	}
+}
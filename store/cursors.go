@@ -0,0 +1,181 @@
+package store
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+// cursorsExtension is the key in the GraphQL result extensions that contains
+// the cursors of the tables that were queried with the `_since` argument
+const cursorsExtension = "cursors"
+
+// pageInfoExtension is the key in the GraphQL result extensions that contains
+// the page info of the root tables that were paginated with the `first` or
+// `after` arguments
+const pageInfoExtension = "pageInfo"
+
+// truncatedExtension is the key in the GraphQL result extensions that
+// contains the paths of the nested tables that were left out of the result,
+// because they are deeper than the maximum depth
+const truncatedExtension = "truncated"
+
+// clampedExtension is the key in the GraphQL result extensions that contains
+// the page size of the tables whose `first` or `last` argument was reduced to
+// the maximum page size, by their path
+const clampedExtension = "clamped"
+
+type cursorsContextKey struct{}
+
+// queryCursors collects the cursor of each root table that is queried with
+// the `_since` argument, so that clients can resume fetching the rows that
+// changed after it
+type queryCursors struct {
+	mu      sync.Mutex
+	cursors map[string]string
+	// pages are the page info of the paginated root tables
+	pages map[string]pageInfo
+	// truncated are the paths of the nested tables that were left out
+	truncated map[string]struct{}
+	// clamped are the page sizes of the tables whose `first` or `last`
+	// argument was reduced, by their path
+	clamped map[string]uint64
+}
+
+// pageInfo describes a page of rows of a root table, so that clients can
+// fetch the next page by passing the endCursor to the `after` argument
+type pageInfo struct {
+	// EndCursor is the cursor of the last row of the page, or empty if the
+	// page has no rows
+	EndCursor   string `json:"endCursor"`
+	HasNextPage bool   `json:"hasNextPage"`
+}
+
+func withQueryCursors(ctx context.Context, cursors *queryCursors) context.Context {
+	return context.WithValue(ctx, cursorsContextKey{}, cursors)
+}
+
+// queryCursorsFromContext returns the queryCursors from the context, or nil if
+// the cursors are not collected
+func queryCursorsFromContext(ctx context.Context) *queryCursors {
+	if ctx == nil {
+		return nil
+	}
+	cursors, _ := ctx.Value(cursorsContextKey{}).(*queryCursors)
+	return cursors
+}
+
+// add sets the cursor of a root table to the highest sequence number of its
+// rows, or to the given cursor if there are no rows after it
+func (c *queryCursors) add(table string, since int64, rows []map[string]interface{}) {
+	cursor := since
+	for _, row := range rows {
+		if seq, ok := row[tableSeqField].(int64); ok && seq > cursor {
+			cursor = seq
+		}
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.cursors == nil {
+		c.cursors = make(map[string]string)
+	}
+	c.cursors[table] = strconv.FormatInt(cursor, 10)
+}
+
+// values returns the cursors of the root tables, or nil if there are none
+func (c *queryCursors) values() map[string]string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.cursors) == 0 {
+		return nil
+	}
+	values := make(map[string]string, len(c.cursors))
+	for table, cursor := range c.cursors {
+		values[table] = cursor
+	}
+	return values
+}
+
+// addPage sets the page info of a paginated root table
+func (c *queryCursors) addPage(table string, info pageInfo) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.pages == nil {
+		c.pages = make(map[string]pageInfo)
+	}
+	c.pages[table] = info
+}
+
+// pageInfos returns the page info of the paginated root tables, or nil if
+// there are none
+func (c *queryCursors) pageInfos() map[string]pageInfo {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.pages) == 0 {
+		return nil
+	}
+	pages := make(map[string]pageInfo, len(c.pages))
+	for table, info := range c.pages {
+		pages[table] = info
+	}
+	return pages
+}
+
+// addTruncated adds the path of a nested table that was left out of the result
+func (c *queryCursors) addTruncated(path string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.truncated == nil {
+		c.truncated = make(map[string]struct{})
+	}
+	c.truncated[path] = struct{}{}
+}
+
+// truncatedPaths returns the sorted paths of the nested tables that were left
+// out of the result, or nil if there are none
+func (c *queryCursors) truncatedPaths() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return sortedPaths(c.truncated)
+}
+
+// addClamped sets the page size of a table whose `first` or `last` argument
+// was reduced to the maximum page size
+func (c *queryCursors) addClamped(path string, pageSize uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.clamped == nil {
+		c.clamped = make(map[string]uint64)
+	}
+	c.clamped[path] = pageSize
+}
+
+// clampedPages returns the page sizes of the tables whose `first` or `last`
+// argument was reduced, by their path, or nil if there are none
+func (c *queryCursors) clampedPages() map[string]uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.clamped) == 0 {
+		return nil
+	}
+	pages := make(map[string]uint64, len(c.clamped))
+	for path, pageSize := range c.clamped {
+		pages[path] = pageSize
+	}
+	return pages
+}
+
+// sortedPaths returns the sorted paths of a set of table paths, or nil if the
+// set is empty
+func sortedPaths(set map[string]struct{}) []string {
+	if len(set) == 0 {
+		return nil
+	}
+	paths := make([]string, 0, len(set))
+	for path := range set {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
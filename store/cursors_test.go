@@ -0,0 +1,136 @@
+package store
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/valocode/bubbly/api/core"
+	"github.com/valocode/bubbly/env"
+	"github.com/valocode/bubbly/test"
+)
+
+func TestQueryCursorsTruncated(t *testing.T) {
+	cursors := &queryCursors{}
+	assert.Nil(t, cursors.truncatedPaths())
+	cursors.addTruncated("team.member.badge")
+	cursors.addTruncated("team.office.city")
+	// A table can be left out of several root queries of the same table
+	cursors.addTruncated("team.member.badge")
+	assert.Equal(t, []string{"team.member.badge", "team.office.city"}, cursors.truncatedPaths())
+}
+
+func TestQueryCursorsAdd(t *testing.T) {
+	tcs := []struct {
+		desc     string
+		since    int64
+		rows     []map[string]interface{}
+		expected string
+	}{
+		{
+			desc:     "no rows",
+			since:    5,
+			expected: "5",
+		},
+		{
+			desc:     "highest sequence",
+			since:    5,
+			rows:     []map[string]interface{}{{tableSeqField: int64(8)}, {tableSeqField: int64(6)}},
+			expected: "8",
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.desc, func(t *testing.T) {
+			cursors := &queryCursors{}
+			assert.Nil(t, cursors.values())
+			cursors.add("t", tc.since, tc.rows)
+			assert.Equal(t, map[string]string{"t": tc.expected}, cursors.values())
+		})
+	}
+}
+
+func TestSinceQuery(t *testing.T) {
+	bCtx := env.NewBubblyContext()
+	resource := test.RunPostgresDocker(bCtx, t)
+	bCtx.StoreConfig.PostgresAddr = fmt.Sprintf("localhost:%s", resource.GetPort("5432/tcp"))
+
+	s, err := New(bCtx)
+	require.NoError(t, err)
+	err = s.Apply(DefaultTenantName, core.Tables{
+		{
+			Name: "item",
+			Fields: []core.TableField{
+				{Name: "name", Type: cty.String, Unique: true},
+				{Name: "status", Type: cty.String},
+			},
+		},
+	}, false)
+	require.NoError(t, err)
+
+	save := func(items map[string]string) {
+		t.Helper()
+		var data core.DataBlocks
+		for name, status := range items {
+			data = append(data, core.Data{
+				TableName: "item",
+				Fields: &core.DataFields{Values: map[string]cty.Value{
+					"name":   cty.StringVal(name),
+					"status": cty.StringVal(status),
+				}},
+			})
+		}
+		require.NoError(t, s.Save(DefaultTenantName, data))
+	}
+	// query returns the names of the items changed since the cursor, and the
+	// cursor to resume from
+	query := func(args string) ([]string, string) {
+		t.Helper()
+		result, err := s.Query(DefaultTenantName, `{ item(`+args+`) { name } }`)
+		require.NoError(t, err)
+		require.Empty(t, result.Errors)
+		var names []string
+		for _, item := range result.Data.(map[string]interface{})["item"].([]interface{}) {
+			names = append(names, item.(map[string]interface{})["name"].(string))
+		}
+		cursors, ok := result.Extensions[cursorsExtension].(map[string]string)
+		require.True(t, ok, "result has no cursors")
+		return names, cursors["item"]
+	}
+
+	save(map[string]string{"a": "new", "b": "new"})
+	names, cursor := query(`_since: "0"`)
+	assert.ElementsMatch(t, []string{"a", "b"}, names)
+	require.NotEmpty(t, cursor)
+
+	// Nothing changed after the cursor
+	names, next := query(`_since: "` + cursor + `"`)
+	assert.Empty(t, names)
+	assert.Equal(t, cursor, next)
+
+	// Updated and created rows are returned, but not the unchanged ones
+	save(map[string]string{"a": "done"})
+	save(map[string]string{"c": "new"})
+	names, next = query(`_since: "` + cursor + `"`)
+	assert.ElementsMatch(t, []string{"a", "c"}, names)
+	assert.NotEqual(t, cursor, next)
+
+	// Limiting the rows returns those that changed first, so that resuming
+	// from the cursor does not skip any
+	names, next = query(`_since: "` + cursor + `", first: 1`)
+	assert.Equal(t, []string{"a"}, names)
+	names, _ = query(`_since: "` + next + `"`)
+	assert.Equal(t, []string{"c"}, names)
+
+	// The cursors are only returned for tables queried with _since
+	result, err := s.Query(DefaultTenantName, `{ item { name } }`)
+	require.NoError(t, err)
+	require.Empty(t, result.Errors)
+	assert.NotContains(t, result.Extensions, cursorsExtension)
+
+	result, err = s.Query(DefaultTenantName, `{ item(_since: "a") { name } }`)
+	require.NoError(t, err)
+	assert.NotEmpty(t, result.Errors)
+}
@@ -29,6 +29,26 @@ func (t dataTree) traverse(bCtx *env.BubblyContext, fn visitFn) (core.DataBlocks
 	return blocks, nil
 }
 
+// tables adds the names of the tables of all the nodes in the tree to the
+// given set
+func (t dataTree) tables(names map[string]struct{}) {
+	visited := make(map[*dataNode]struct{})
+	var visit func(node *dataNode)
+	visit = func(node *dataNode) {
+		if _, ok := visited[node]; ok {
+			return
+		}
+		visited[node] = struct{}{}
+		names[node.Data.TableName] = struct{}{}
+		for _, child := range node.Children {
+			visit(child)
+		}
+	}
+	for _, node := range t {
+		visit(node)
+	}
+}
+
 // reset goes over the tree and resets the tree so that it can be traversed again
 func (t dataTree) reset() {
 	for _, n := range t {
@@ -0,0 +1,56 @@
+package store
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/valocode/bubbly/env"
+	"github.com/valocode/bubbly/test"
+
+	testData "github.com/valocode/bubbly/store/testdata"
+)
+
+// TestFieldDefaults saves data blocks which omit fields with default values,
+// and checks that the defaults are saved, unless the data block provides a
+// value
+func TestFieldDefaults(t *testing.T) {
+	bCtx := env.NewBubblyContext()
+	resource := test.RunPostgresDocker(bCtx, t)
+	bCtx.StoreConfig.PostgresAddr = fmt.Sprintf("localhost:%s", resource.GetPort("5432/tcp"))
+
+	tables := testData.Tables(t, bCtx, "./testdata/defaults/tables.hcl")
+	data := testData.DataBlocks(t, bCtx, "./testdata/defaults/data.hcl")
+	s, err := New(bCtx)
+	require.NoErrorf(t, err, "failed to initialize store")
+	err = s.Apply(DefaultTenantName, tables, true)
+	require.NoErrorf(t, err, "failed to apply schema from tables")
+	err = s.Save(DefaultTenantName, data)
+	require.NoErrorf(t, err, "failed to save data for data blocks")
+
+	result, err := s.Query(DefaultTenantName, "{ ticket { name status priority created_at } }")
+	require.NoError(t, err)
+	require.Empty(t, result.Errors)
+	tickets := result.Data.(map[string]interface{})["ticket"].([]interface{})
+	require.Len(t, tickets, 3)
+
+	expected := map[string]struct {
+		status   string
+		priority float64
+	}{
+		"no_status":   {status: "UNKNOWN", priority: 3},
+		"null_status": {status: "UNKNOWN", priority: 3},
+		"open":        {status: "OPEN", priority: 1},
+	}
+	for _, ticket := range tickets {
+		ticket := ticket.(map[string]interface{})
+		name := ticket["name"].(string)
+		t.Run(name, func(t *testing.T) {
+			require.Contains(t, expected, name)
+			assert.Equal(t, expected[name].status, ticket["status"])
+			assert.EqualValues(t, expected[name].priority, ticket["priority"])
+			assert.NotEmpty(t, ticket["created_at"])
+		})
+	}
+}
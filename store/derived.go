@@ -0,0 +1,157 @@
+package store
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/valocode/bubbly/api/core"
+)
+
+// derivedKeywords are the SQL keywords that the expression of a derived field
+// can use
+var derivedKeywords = map[string]struct{}{
+	"and": {}, "or": {}, "not": {}, "is": {}, "null": {}, "true": {}, "false": {},
+	"case": {}, "when": {}, "then": {}, "else": {}, "end": {},
+	"in": {}, "between": {}, "like": {}, "ilike": {}, "distinct": {}, "from": {},
+	"as": {}, "int": {}, "integer": {}, "bigint": {}, "numeric": {}, "text": {}, "boolean": {},
+}
+
+// derivedFunctions are the SQL functions that the expression of a derived
+// field can call
+var derivedFunctions = map[string]struct{}{
+	"abs": {}, "round": {}, "ceil": {}, "floor": {}, "trunc": {}, "mod": {}, "power": {},
+	"coalesce": {}, "nullif": {}, "greatest": {}, "least": {}, "cast": {},
+	"length": {}, "lower": {}, "upper": {}, "trim": {}, "concat": {},
+}
+
+// validateDerivedFields checks that the derived fields of a table have names
+// that are not used by its fields or joins, have a scalar type, and have an
+// expression that only refers to the fields and joins of the table
+func validateDerivedFields(table core.Table) error {
+	names := make(map[string]struct{}, len(table.DerivedFields))
+	for _, field := range table.DerivedFields {
+		if tableHasColumn(table, field.Name) {
+			return fmt.Errorf("derived field %s of table %s has the name of a field", field.Name, table.Name)
+		}
+		if _, ok := names[field.Name]; ok {
+			return fmt.Errorf("derived field %s of table %s is defined more than once", field.Name, table.Name)
+		}
+		names[field.Name] = struct{}{}
+		if _, ok := tableJoin(table, foreignKeyField(field.Name)); ok {
+			return fmt.Errorf("derived field %s of table %s has the name of a joined table", field.Name, table.Name)
+		}
+		if !field.Type.IsPrimitiveType() {
+			return fmt.Errorf("derived field %s of table %s must be a bool, number or string, not %s", field.Name, table.Name, field.Type.FriendlyName())
+		}
+		if _, err := derivedFieldSQL(table, field, tableAlias(table.Name, 0)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// tableDerivedField returns the derived field of a table with the given name
+func tableDerivedField(table core.Table, name string) (core.TableDerivedField, bool) {
+	for _, field := range table.DerivedFields {
+		if field.Name == name {
+			return field, true
+		}
+	}
+	return core.TableDerivedField{}, false
+}
+
+// derivedFieldSQL returns the SQL expression of a derived field, with the
+// columns that it refers to qualified with the alias of the table, cast to the
+// SQL type of the field.
+// The expression is part of the SQL text of queries, so it can only contain
+// the columns of the table, numbers, strings and the allowed keywords,
+// functions and operators
+func derivedFieldSQL(table core.Table, field core.TableDerivedField, alias string) (string, error) {
+	var (
+		expr = []rune(field.Expr)
+		sql  strings.Builder
+	)
+	invalid := func(format string, args ...interface{}) error {
+		return fmt.Errorf("invalid expression of derived field %s of table %s: %s", field.Name, table.Name, fmt.Sprintf(format, args...))
+	}
+	if strings.TrimSpace(field.Expr) == "" {
+		return "", invalid("expression is empty")
+	}
+	for i := 0; i < len(expr); {
+		c := expr[i]
+		switch {
+		case unicode.IsSpace(c):
+			sql.WriteRune(' ')
+			i++
+		case c == '_' || unicode.IsLetter(c):
+			start := i
+			for i < len(expr) && (expr[i] == '_' || unicode.IsLetter(expr[i]) || unicode.IsDigit(expr[i])) {
+				i++
+			}
+			word := string(expr[start:i])
+			lower := strings.ToLower(word)
+			// Skip the spaces to know whether the word is a function call
+			next := i
+			for next < len(expr) && unicode.IsSpace(expr[next]) {
+				next++
+			}
+			_, isKeyword := derivedKeywords[lower]
+			switch {
+			case next < len(expr) && expr[next] == '(':
+				if _, ok := derivedFunctions[lower]; !ok && !isKeyword {
+					return "", invalid("unknown function %s", word)
+				}
+				sql.WriteString(lower)
+			case tableHasColumn(table, word):
+				// Columns take precedence over keywords, e.g. a field
+				// named "text"
+				sql.WriteString(tableColumn(alias, word))
+			case isKeyword:
+				sql.WriteString(lower)
+			default:
+				return "", invalid("unknown column %s", word)
+			}
+		case unicode.IsDigit(c):
+			start := i
+			for i < len(expr) && (unicode.IsDigit(expr[i]) || expr[i] == '.') {
+				i++
+			}
+			sql.WriteString(string(expr[start:i]))
+		case c == '\'':
+			// A string literal, in which quotes are escaped by doubling them
+			start := i
+			i++
+			for {
+				if i >= len(expr) {
+					return "", invalid("unterminated string")
+				}
+				if expr[i] == '\'' {
+					if i+1 < len(expr) && expr[i+1] == '\'' {
+						i += 2
+						continue
+					}
+					i++
+					break
+				}
+				i++
+			}
+			sql.WriteString(string(expr[start:i]))
+		case strings.ContainsRune("+-*/%()<>=!|,", c):
+			if i+1 < len(expr) && (c == '-' && expr[i+1] == '-' || c == '/' && expr[i+1] == '*') {
+				return "", invalid("comments are not allowed")
+			}
+			sql.WriteRune(c)
+			i++
+		default:
+			return "", invalid("unexpected character %q", c)
+		}
+	}
+	// Cast the value to the type of the field, so that it is scanned like
+	// the value of a field of that type, e.g. a numeric is rounded to INT8
+	ty, err := psqlType(field.Type)
+	if err != nil {
+		return "", fmt.Errorf("derived field %s of table %s: %w", field.Name, table.Name, err)
+	}
+	return "CAST((" + sql.String() + ") AS " + ty + ")", nil
+}
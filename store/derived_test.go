@@ -0,0 +1,209 @@
+package store
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/valocode/bubbly/api/core"
+	"github.com/valocode/bubbly/env"
+	"github.com/valocode/bubbly/test"
+
+	testData "github.com/valocode/bubbly/store/testdata"
+)
+
+func TestDerivedFieldSQL(t *testing.T) {
+	table := core.Table{
+		Name: "run",
+		Fields: []core.TableField{
+			{Name: "passed", Type: cty.Number},
+			{Name: "total", Type: cty.Number},
+			{Name: "text", Type: cty.String},
+		},
+		Joins: []core.TableJoin{{Table: "project"}},
+	}
+	tcs := []struct {
+		desc     string
+		expr     string
+		ty       cty.Type
+		expected string
+		wantErr  bool
+	}{
+		{
+			desc:     "arithmetic",
+			expr:     "passed * 100 / NULLIF(total, 0)",
+			ty:       cty.Number,
+			expected: "CAST((r.passed * 100 / nullif(r.total, 0)) AS INT8)",
+		},
+		{
+			desc:     "keywords and strings",
+			expr:     "CASE WHEN passed = total THEN 'it''s ok' ELSE text END",
+			ty:       cty.String,
+			expected: "CAST((case when r.passed = r.total then 'it''s ok' else r.text end) AS TEXT)",
+		},
+		{
+			desc:     "id and join columns",
+			expr:     "project_id IS NOT NULL AND _id > 0",
+			ty:       cty.Bool,
+			expected: "CAST((r.project_id is not null and r._id > 0) AS BOOL)",
+		},
+		{
+			desc:    "unknown column",
+			expr:    "failed / total",
+			ty:      cty.Number,
+			wantErr: true,
+		},
+		{
+			desc:    "unknown function",
+			expr:    "pg_sleep(passed)",
+			ty:      cty.Number,
+			wantErr: true,
+		},
+		{
+			desc:    "statement separator",
+			expr:    "passed; DROP TABLE run",
+			ty:      cty.Number,
+			wantErr: true,
+		},
+		{
+			desc:    "comment",
+			expr:    "passed -- total",
+			ty:      cty.Number,
+			wantErr: true,
+		},
+		{
+			desc:    "unterminated string",
+			expr:    "'passed",
+			ty:      cty.String,
+			wantErr: true,
+		},
+		{
+			desc:    "empty",
+			expr:    " ",
+			ty:      cty.Number,
+			wantErr: true,
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.desc, func(t *testing.T) {
+			field := core.TableDerivedField{Name: "derived", Type: tc.ty, Expr: tc.expr}
+			sql, err := derivedFieldSQL(table, field, "r")
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, sql)
+		})
+	}
+}
+
+func TestValidateDerivedFields(t *testing.T) {
+	fields := []core.TableField{
+		{Name: "passed", Type: cty.Number},
+	}
+	tcs := []struct {
+		desc    string
+		derived []core.TableDerivedField
+		wantErr bool
+	}{
+		{
+			desc:    "valid",
+			derived: []core.TableDerivedField{{Name: "failed", Type: cty.Number, Expr: "10 - passed"}},
+		},
+		{
+			desc:    "name of a field",
+			derived: []core.TableDerivedField{{Name: "passed", Type: cty.Number, Expr: "passed"}},
+			wantErr: true,
+		},
+		{
+			desc: "defined twice",
+			derived: []core.TableDerivedField{
+				{Name: "failed", Type: cty.Number, Expr: "10 - passed"},
+				{Name: "failed", Type: cty.Number, Expr: "passed"},
+			},
+			wantErr: true,
+		},
+		{
+			desc:    "list type",
+			derived: []core.TableDerivedField{{Name: "failed", Type: cty.List(cty.Number), Expr: "passed"}},
+			wantErr: true,
+		},
+		{
+			desc:    "invalid expression",
+			derived: []core.TableDerivedField{{Name: "failed", Type: cty.Number, Expr: "10 - failed"}},
+			wantErr: true,
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.desc, func(t *testing.T) {
+			err := validateDerivedFields(core.Table{Name: "run", Fields: fields, DerivedFields: tc.derived})
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestDerivedFieldQuery(t *testing.T) {
+	bCtx := env.NewBubblyContext()
+	resource := test.RunPostgresDocker(bCtx, t)
+	bCtx.StoreConfig.PostgresAddr = fmt.Sprintf("localhost:%s", resource.GetPort("5432/tcp"))
+
+	tables := testData.Tables(t, bCtx, "./testdata/derived/tables.hcl")
+	data := testData.DataBlocks(t, bCtx, "./testdata/derived/data.hcl")
+	s, err := New(bCtx)
+	require.NoError(t, err)
+	require.NoError(t, s.Apply(DefaultTenantName, tables, false))
+	require.NoError(t, s.Save(DefaultTenantName, data))
+
+	tcs := []struct {
+		desc     string
+		query    string
+		expected interface{}
+		wantErr  bool
+	}{
+		{
+			desc:  "root table",
+			query: `{ suite_run(order_by: {total: desc}) { passed pass_rate ok } }`,
+			expected: []interface{}{
+				map[string]interface{}{"passed": 3, "pass_rate": 75, "ok": false},
+				map[string]interface{}{"passed": 0, "pass_rate": nil, "ok": true},
+			},
+		},
+		{
+			desc:  "nested table",
+			query: `{ suite { name suite_run(filter: {total_gt: 0}) { pass_rate } } }`,
+			expected: []interface{}{
+				map[string]interface{}{
+					"name":      "unit",
+					"suite_run": []interface{}{map[string]interface{}{"pass_rate": 75}},
+				},
+			},
+		},
+		{
+			desc:    "derived fields cannot be filtered on",
+			query:   `{ suite_run(filter: {pass_rate_gt: 50}) { pass_rate } }`,
+			wantErr: true,
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.desc, func(t *testing.T) {
+			result, err := s.Query(DefaultTenantName, tc.query)
+			require.NoError(t, err)
+			if tc.wantErr {
+				assert.NotEmpty(t, result.Errors)
+				return
+			}
+			require.Empty(t, result.Errors)
+			for key, val := range result.Data.(map[string]interface{}) {
+				assert.Equal(t, tc.expected, val, key)
+			}
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package store
+
+import (
+	"sort"
+)
+
+// SchemaDescription is a catalog of the tables of a tenant's schema, with
+// their fields and relationships, for users to read before writing queries
+// without having to read the GraphQL SDL
+type SchemaDescription struct {
+	Tables []TableDescription `json:"tables"`
+}
+
+// TableDescription describes a table of the schema
+type TableDescription struct {
+	Name          string             `json:"name"`
+	Fields        []FieldDescription `json:"fields"`
+	DerivedFields []FieldDescription `json:"derived_fields,omitempty"`
+	// Edges are the relationships of the table to other tables, which can be
+	// queried as nested fields of the table
+	Edges []EdgeDescription `json:"edges"`
+}
+
+// FieldDescription describes a field of a table
+type FieldDescription struct {
+	Name string `json:"name"`
+	// Type is the name of the field's type, e.g. "string" or "list of string"
+	Type   string   `json:"type"`
+	Unique bool     `json:"unique,omitempty"`
+	Enum   []string `json:"enum,omitempty"`
+	// Hidden fields are not in the GraphQL schema
+	Hidden bool `json:"hidden,omitempty"`
+}
+
+// EdgeDescription describes the relationship of a table to another table
+type EdgeDescription struct {
+	Table string `json:"table"`
+	// Rel is the type of the relationship, see RelType
+	Rel string `json:"rel"`
+}
+
+// relTypeNames are the names of the relationship types in a
+// SchemaDescription
+var relTypeNames = map[RelType]string{
+	OneToOne:  "one_to_one",
+	OneToMany: "one_to_many",
+	BelongsTo: "belongs_to",
+}
+
+// DescribeSchema returns the description of the schema that the store has
+// loaded for a tenant, including the builtin tables
+func (s *Store) DescribeSchema(tenant string) (*SchemaDescription, error) {
+	ts, err := s.tenantSchema(tenant)
+	if err != nil {
+		return nil, err
+	}
+	return describeSchema(ts.graph), nil
+}
+
+// describeSchema returns the description of the tables of a schema graph.
+// The tables and their edges are sorted by name, so that the description is
+// stable, and the fields are in the order that they are defined in
+func describeSchema(graph *SchemaGraph) *SchemaDescription {
+	desc := &SchemaDescription{
+		Tables: make([]TableDescription, 0, len(graph.NodeIndex)),
+	}
+	for _, node := range graph.NodeIndex {
+		table := TableDescription{
+			Name:   node.Table.Name,
+			Fields: make([]FieldDescription, 0, len(node.Table.Fields)),
+			Edges:  make([]EdgeDescription, 0, len(node.Edges)),
+		}
+		for _, field := range node.Table.Fields {
+			table.Fields = append(table.Fields, FieldDescription{
+				Name:   field.Name,
+				Type:   field.Type.FriendlyName(),
+				Unique: field.Unique,
+				Enum:   field.Enum,
+				Hidden: field.Hidden,
+			})
+		}
+		for _, field := range node.Table.DerivedFields {
+			table.DerivedFields = append(table.DerivedFields, FieldDescription{
+				Name: field.Name,
+				Type: field.Type.FriendlyName(),
+			})
+		}
+		for _, edge := range node.Edges {
+			table.Edges = append(table.Edges, EdgeDescription{
+				Table: edge.Node.Table.Name,
+				Rel:   relTypeNames[edge.Rel],
+			})
+		}
+		sort.Slice(table.Edges, func(i, j int) bool {
+			if table.Edges[i].Table != table.Edges[j].Table {
+				return table.Edges[i].Table < table.Edges[j].Table
+			}
+			return table.Edges[i].Rel < table.Edges[j].Rel
+		})
+		desc.Tables = append(desc.Tables, table)
+	}
+	sort.Slice(desc.Tables, func(i, j int) bool {
+		return desc.Tables[i].Name < desc.Tables[j].Name
+	})
+	return desc
+}
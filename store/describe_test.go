@@ -0,0 +1,99 @@
+package store
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/valocode/bubbly/api/core"
+)
+
+func TestDescribeSchema(t *testing.T) {
+	tables := core.Tables{
+		{
+			Name: "team",
+			Fields: []core.TableField{
+				{Name: "name", Type: cty.String, Unique: true},
+			},
+			Tables: core.Tables{
+				{
+					Name: "member",
+					Fields: []core.TableField{
+						{Name: "email", Type: cty.String},
+						{Name: "roles", Type: cty.List(cty.String)},
+						{Name: "status", Type: cty.String, Enum: []string{"ACTIVE", "LEFT"}},
+					},
+				},
+				{
+					Name:   "profile",
+					Single: true,
+					Fields: []core.TableField{
+						{Name: "bio", Type: cty.String},
+					},
+				},
+			},
+		},
+		{
+			Name: "project",
+			Fields: []core.TableField{
+				{Name: "name", Type: cty.String},
+			},
+			DerivedFields: []core.TableDerivedField{
+				{Name: "name_length", Type: cty.Number, Expr: "length(name)"},
+			},
+			Joins: []core.TableJoin{{Table: "team"}},
+		},
+	}
+	graph, err := NewSchemaGraph(FlattenTables(tables, nil))
+	require.NoError(t, err)
+
+	assert.Equal(t, &SchemaDescription{
+		Tables: []TableDescription{
+			{
+				Name: "member",
+				Fields: []FieldDescription{
+					{Name: "email", Type: "string"},
+					{Name: "roles", Type: "list of string"},
+					{Name: "status", Type: "string", Enum: []string{"ACTIVE", "LEFT"}},
+				},
+				Edges: []EdgeDescription{
+					{Table: "team", Rel: "belongs_to"},
+				},
+			},
+			{
+				Name: "profile",
+				Fields: []FieldDescription{
+					{Name: "bio", Type: "string"},
+				},
+				Edges: []EdgeDescription{
+					{Table: "team", Rel: "belongs_to"},
+				},
+			},
+			{
+				Name: "project",
+				Fields: []FieldDescription{
+					{Name: "name", Type: "string"},
+				},
+				DerivedFields: []FieldDescription{
+					{Name: "name_length", Type: "number"},
+				},
+				Edges: []EdgeDescription{
+					{Table: "team", Rel: "belongs_to"},
+				},
+			},
+			{
+				Name: "team",
+				Fields: []FieldDescription{
+					{Name: "name", Type: "string", Unique: true},
+				},
+				Edges: []EdgeDescription{
+					{Table: "member", Rel: "one_to_many"},
+					{Table: "profile", Rel: "one_to_one"},
+					{Table: "project", Rel: "one_to_many"},
+				},
+			},
+		},
+	}, describeSchema(graph))
+}
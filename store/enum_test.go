@@ -0,0 +1,190 @@
+package store
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/cornelk/hashmap"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/valocode/bubbly/api/core"
+	"github.com/valocode/bubbly/env"
+	"github.com/valocode/bubbly/test"
+
+	testData "github.com/valocode/bubbly/store/testdata"
+)
+
+func TestValidateFieldEnums(t *testing.T) {
+	tcs := []struct {
+		desc    string
+		field   core.TableField
+		wantErr bool
+	}{
+		{
+			desc:  "valid",
+			field: core.TableField{Name: "status", Type: cty.String, Enum: []string{"PASS", "FAIL", "_SKIP_2"}},
+		},
+		{
+			desc:  "valid default",
+			field: core.TableField{Name: "status", Type: cty.String, Enum: []string{"PASS", "FAIL"}, Default: cty.StringVal("FAIL")},
+		},
+		{
+			desc:    "default not in enum",
+			field:   core.TableField{Name: "status", Type: cty.String, Enum: []string{"PASS", "FAIL"}, Default: cty.StringVal("UNKNOWN")},
+			wantErr: true,
+		},
+		{
+			desc:    "number field",
+			field:   core.TableField{Name: "status", Type: cty.Number, Enum: []string{"PASS"}},
+			wantErr: true,
+		},
+		{
+			desc:    "invalid value",
+			field:   core.TableField{Name: "status", Type: cty.String, Enum: []string{"PASS", "NOT-RUN"}},
+			wantErr: true,
+		},
+		{
+			desc:    "reserved value",
+			field:   core.TableField{Name: "status", Type: cty.String, Enum: []string{"true", "false"}},
+			wantErr: true,
+		},
+		{
+			desc:    "value given twice",
+			field:   core.TableField{Name: "status", Type: cty.String, Enum: []string{"PASS", "PASS"}},
+			wantErr: true,
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.desc, func(t *testing.T) {
+			err := validateFieldEnums(core.Table{Name: "check", Fields: []core.TableField{tc.field}})
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+// TestEnumQueryValidation checks that the values of a field with an enum are
+// validated with the GraphQL schema, before the query is resolved
+func TestEnumQueryValidation(t *testing.T) {
+	s := &Store{
+		bCtx:    env.NewBubblyContext(),
+		p:       &schemaProvider{},
+		schemas: &hashmap.HashMap{},
+	}
+	require.NoError(t, s.CreateTenant(DefaultTenantName))
+	require.NoError(t, s.AddTables(DefaultTenantName, core.Tables{
+		{
+			Name: "check",
+			Fields: []core.TableField{
+				{Name: "name", Type: cty.String},
+				{Name: "status", Type: cty.String, Enum: []string{"PASS", "FAIL", "SKIP"}},
+			},
+		},
+	}))
+
+	tcs := []struct {
+		desc  string
+		query string
+		err   string
+	}{
+		{
+			desc:  "argument",
+			query: `{ check(status: PASS) { name } }`,
+		},
+		{
+			desc:  "filter",
+			query: `{ check(filter: {status_in: [PASS, SKIP]}) { name } }`,
+		},
+		{
+			desc:  "aggregate",
+			query: `{ check_aggregate(status: FAIL) { count } }`,
+		},
+		{
+			desc:  "typo in argument",
+			query: `{ check(status: PASSS) { name } }`,
+			err:   `Expected type "check_status_enum", found PASSS.`,
+		},
+		{
+			desc:  "typo in filter",
+			query: `{ check(filter: {status_eq: FAILED}) { name } }`,
+			err:   `Expected type "check_status_enum", found FAILED.`,
+		},
+		{
+			desc:  "string instead of enum value",
+			query: `{ check(status: "PASS") { name } }`,
+			err:   `Expected type "check_status_enum", found "PASS".`,
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.desc, func(t *testing.T) {
+			result, err := s.Query(DefaultTenantName, tc.query)
+			require.NoError(t, err)
+			if tc.err == "" {
+				assert.Empty(t, result.Errors)
+				return
+			}
+			require.Len(t, result.Errors, 1)
+			assert.Contains(t, result.Errors[0].Message, tc.err)
+		})
+	}
+}
+
+func TestEnumFilter(t *testing.T) {
+	bCtx := env.NewBubblyContext()
+	resource := test.RunPostgresDocker(bCtx, t)
+	bCtx.StoreConfig.PostgresAddr = fmt.Sprintf("localhost:%s", resource.GetPort("5432/tcp"))
+
+	tables := testData.Tables(t, bCtx, "./testdata/enum/tables.hcl")
+	data := testData.DataBlocks(t, bCtx, "./testdata/enum/data.hcl")
+	s, err := New(bCtx)
+	require.NoError(t, err)
+	require.NoError(t, s.Apply(DefaultTenantName, tables, false))
+	require.NoError(t, s.Save(DefaultTenantName, data))
+
+	tcs := []struct {
+		desc     string
+		query    string
+		expected interface{}
+		wantErr  bool
+	}{
+		{
+			desc:  "argument",
+			query: `{ check(status: FAIL) { name status } }`,
+			expected: []interface{}{
+				map[string]interface{}{"name": "unit", "status": "FAIL"},
+			},
+		},
+		{
+			desc:  "filter",
+			query: `{ check(filter: {status_in: [PASS, SKIP]}, order_by: {name: asc}) { name } }`,
+			expected: []interface{}{
+				map[string]interface{}{"name": "e2e"},
+				map[string]interface{}{"name": "lint"},
+			},
+		},
+		{
+			desc:    "typo",
+			query:   `{ check(status: FAILED) { name } }`,
+			wantErr: true,
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.desc, func(t *testing.T) {
+			result, err := s.Query(DefaultTenantName, tc.query)
+			require.NoError(t, err)
+			if tc.wantErr {
+				assert.NotEmpty(t, result.Errors)
+				return
+			}
+			require.Empty(t, result.Errors)
+			for key, val := range result.Data.(map[string]interface{}) {
+				assert.Equal(t, tc.expected, val, key)
+			}
+		})
+	}
+}
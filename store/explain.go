@@ -0,0 +1,92 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+// explainExtension is the key in the GraphQL result extensions that contains
+// the explained queries
+const explainExtension = "explain"
+
+type explainContextKey struct{}
+
+// queryExplain collects the SQL queries that are run to resolve a GraphQL
+// query, so that they can be returned to the user for debugging
+type queryExplain struct {
+	mu sync.Mutex
+	// analyze is whether to also run EXPLAIN ANALYZE for each query
+	analyze bool
+	queries []ExplainedQuery
+}
+
+// ExplainedQuery is a SQL query that was run to resolve a GraphQL query
+type ExplainedQuery struct {
+	// Field is the root GraphQL field that the SQL query resolves
+	Field string        `json:"field"`
+	SQL   string        `json:"sql"`
+	Args  []interface{} `json:"args,omitempty"`
+	// Plan is the output of EXPLAIN ANALYZE, if requested
+	Plan []string `json:"plan,omitempty"`
+}
+
+func withQueryExplain(ctx context.Context, explain *queryExplain) context.Context {
+	return context.WithValue(ctx, explainContextKey{}, explain)
+}
+
+// queryExplainFromContext returns the queryExplain from the context, or nil if
+// the query should not be explained
+func queryExplainFromContext(ctx context.Context) *queryExplain {
+	if ctx == nil {
+		return nil
+	}
+	explain, _ := ctx.Value(explainContextKey{}).(*queryExplain)
+	return explain
+}
+
+// add adds the SQL query to the explained queries, running EXPLAIN ANALYZE for
+// it if requested
+func (e *queryExplain) add(pool *pgxpool.Pool, field string, sqlStr string, sqlArgs []interface{}) error {
+	query := ExplainedQuery{
+		Field: field,
+		SQL:   sqlStr,
+		Args:  sqlArgs,
+	}
+	if e.analyze {
+		plan, err := psqlExplainAnalyze(pool, sqlStr, sqlArgs)
+		if err != nil {
+			return err
+		}
+		query.Plan = plan
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.queries = append(e.queries, query)
+	return nil
+}
+
+// psqlExplainAnalyze runs EXPLAIN ANALYZE for the SQL query and returns the
+// lines of the query plan
+func psqlExplainAnalyze(pool *pgxpool.Pool, sqlStr string, sqlArgs []interface{}) ([]string, error) {
+	rows, err := pool.Query(context.Background(), "EXPLAIN ANALYZE "+sqlStr, sqlArgs...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to explain SQL query: %s: %w", sqlStr, err)
+	}
+	defer rows.Close()
+
+	var plan []string
+	for rows.Next() {
+		var line string
+		if err := rows.Scan(&line); err != nil {
+			return nil, fmt.Errorf("failed scanning query plan: %w", err)
+		}
+		plan = append(plan, line)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed reading query plan: %w", err)
+	}
+	return plan, nil
+}
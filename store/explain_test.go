@@ -0,0 +1,60 @@
+package store
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/valocode/bubbly/env"
+	"github.com/valocode/bubbly/test"
+)
+
+func TestQueryExplain(t *testing.T) {
+	bCtx := env.NewBubblyContext()
+	resource := test.RunPostgresDocker(bCtx, t)
+	bCtx.StoreConfig.PostgresAddr = fmt.Sprintf("localhost:%s", resource.GetPort("5432/tcp"))
+
+	s, err := New(bCtx)
+	require.NoError(t, err)
+	applySchemaOrDie(t, bCtx, s, filepath.FromSlash("testdata/tables.hcl"))
+	loadTestDataOrDie(t, bCtx, s, filepath.FromSlash("testdata/data.hcl"))
+
+	const query = `{ root(name: "first_root") { name } }`
+
+	t.Run("disabled", func(t *testing.T) {
+		bCtx.StoreConfig.QueryExplain = false
+		_, err := s.QueryExplain(DefaultTenantName, query, false)
+		assert.Error(t, err)
+	})
+
+	tcs := []struct {
+		desc    string
+		analyze bool
+	}{
+		{desc: "sql", analyze: false},
+		{desc: "analyze", analyze: true},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.desc, func(t *testing.T) {
+			bCtx.StoreConfig.QueryExplain = true
+			result, err := s.QueryExplain(DefaultTenantName, query, tc.analyze)
+			require.NoError(t, err)
+			require.Empty(t, result.Errors)
+
+			queries, ok := result.Extensions[explainExtension].([]ExplainedQuery)
+			require.True(t, ok, "explain extension is missing")
+			require.Len(t, queries, 1)
+			assert.Equal(t, "root", queries[0].Field)
+			assert.Contains(t, queries[0].SQL, "SELECT")
+			assert.Contains(t, queries[0].Args, "first_root")
+			if tc.analyze {
+				assert.NotEmpty(t, queries[0].Plan)
+			} else {
+				assert.Empty(t, queries[0].Plan)
+			}
+		})
+	}
+}
@@ -28,6 +28,9 @@ type gqlField struct {
 
 // newGraphQLSchema creates a new GraphQL schema wrapping the given provider
 // with a schema that corresponds to the given set of tables.
+// A GraphQL schema must have at least one query field, so if there are no
+// tables the zero schema is returned, which the store treats as no schema
+// being loaded (see Store.tenantSchema)
 func newGraphQLSchema(graph *SchemaGraph, resolveFn graphql.FieldResolveFn) (graphql.Schema, error) {
 	var (
 		fields = make(map[string]gqlField)
@@ -60,8 +63,26 @@ func newGraphQLSchema(graph *SchemaGraph, resolveFn graphql.FieldResolveFn) (gra
 			Args:    field.Args,
 			Resolve: resolveFn,
 		}
+		// A single row can be queried by its ID, which returns null instead
+		// of an empty list if the row does not exist
+		queryFields[field.Type.Name()+byIDSuffix] = &graphql.Field{
+			Type: field.Type,
+			Args: graphql.FieldConfigArgument{
+				tableIDField: &graphql.ArgumentConfig{
+					Type: graphql.NewNonNull(graphql.String),
+				},
+			},
+			Resolve: resolveFn,
+		}
 	}
 
+	// Add the fields to query the aggregates and distinct values of each table
+	graph.Traverse(func(node *SchemaNode) error {
+		addAggregateField(*node.Table, queryFields, resolveFn)
+		addDistinctField(*node.Table, queryFields, resolveFn)
+		return nil
+	})
+
 	// This config is used to create a new query type
 	// that will be used to create the GraphQL schema.
 	// Note that this config only contains a query, and
@@ -91,27 +112,52 @@ func addGraphFields(t core.Table, fields map[string]gqlField) {
 		typeFields = make(graphql.Fields)
 		// gqlField is the graphql field which we are populating now
 		gqlField = fields[t.Name]
+		// filterArgs are the fields that the rows can be filtered on with
+		// the filter argument
+		filterArgs = make(graphql.FieldConfigArgument)
 	)
 	// Initialize the args
 	gqlField.Args = make(graphql.FieldConfigArgument)
 
 	// Set fields and args for the current table/field
 	for _, f := range t.Fields {
+		if f.Hidden {
+			continue
+		}
 		ft := graphQLFieldType(f)
 		typeFields[f.Name] = &graphql.Field{Type: ft}
-		gqlField.Args[f.Name] = &graphql.ArgumentConfig{Type: ft}
+		// Fields with an enum are filtered with a GraphQL enum, so that
+		// values that are not in the enum are rejected
+		var argType graphql.Input = ft
+		if len(f.Enum) > 0 {
+			argType = graphQLEnumType(t.Name, f)
+		}
+		filterArgs[f.Name] = &graphql.ArgumentConfig{Type: argType}
+		// List fields can only be filtered with the filter argument, e.g.
+		// on whether they contain some values
+		if !isListType(f.Type) {
+			gqlField.Args[f.Name] = &graphql.ArgumentConfig{Type: argType}
+		}
 	}
 
 	// Add the _id field to the schema
 	typeFields[tableIDField] = &graphql.Field{Type: graphql.String}
 	gqlField.Args[tableIDField] = &graphql.ArgumentConfig{Type: graphql.String}
+	filterArgs[tableIDField] = gqlField.Args[tableIDField]
 
 	gqlField.Args[filterID] = &graphql.ArgumentConfig{
-		Type: graphQLFilterType(t.Name, gqlField.Args),
+		Type: graphQLFilterType(t, filterArgs),
 	}
 	gqlField.Args[orderByID] = &graphql.ArgumentConfig{
 		Type: graphQLOrderType(t.Name, typeFields),
 	}
+	// Derived fields are computed when queried, so they are only added to
+	// the type, and cannot be filtered or ordered on
+	for _, f := range t.DerivedFields {
+		typeFields[f.Name] = &graphql.Field{
+			Type: graphQLFieldType(core.TableField{Name: f.Name, Type: f.Type}),
+		}
+	}
 	// filterOnID works like an INNER JOIN in SQL, that it filters the parent
 	// based on the child
 	gqlField.Args[filterOnID] = &graphql.ArgumentConfig{
@@ -123,6 +169,19 @@ func addGraphFields(t core.Table, fields map[string]gqlField) {
 	gqlField.Args[lastID] = &graphql.ArgumentConfig{
 		Type: graphql.Int,
 	}
+	// sinceID returns the rows that were created or updated after a cursor.
+	// The cursor is a sequence number, which can be larger than a graphql Int
+	gqlField.Args[sinceID] = &graphql.ArgumentConfig{
+		Type: graphql.String,
+	}
+	// afterID returns the page of rows after a cursor, which is the endCursor
+	// in the page info of the previous page
+	gqlField.Args[afterID] = &graphql.ArgumentConfig{
+		Type: graphql.String,
+	}
+	gqlField.Args[includeDeletedID] = &graphql.ArgumentConfig{
+		Type: graphql.Boolean,
+	}
 
 	// Create a GraphQL type for the current table so that we
 	// can set it in the query fields and return it to be used
@@ -154,11 +213,138 @@ func addGraphEdges(n *SchemaNode, fields map[string]gqlField) {
 			Type: dstFieldType,
 			Args: dstField.Args,
 		})
+		// The rows can be filtered on whether the related table has rows that
+		// match a filter for it
+		var (
+			filter    = field.Args[filterID].Type.(*graphql.InputObject)
+			dstFilter = dstField.Args[filterID].Type.(*graphql.InputObject)
+		)
+		for _, op := range []string{filterExists, filterNotExists} {
+			filter.AddFieldConfig(edge.Node.Table.Name+op, &graphql.InputObjectFieldConfig{
+				Type: dstFilter,
+			})
+		}
+	}
+}
+
+// addAggregateField adds the query field for the aggregates of the table `t`,
+// which are computed for groups of rows with the same values for the fields
+// given in the group_by argument. The groups can be filtered by the value of
+// their aggregates with the having argument, like a SQL HAVING clause.
+// The query field for the table must already be in the queryFields
+func addAggregateField(t core.Table, queryFields graphql.Fields, resolveFn graphql.FieldResolveFn) {
+	var (
+		typeFields = graphql.Fields{
+			aggregateCountID: &graphql.Field{Type: graphql.Int},
+		}
+		args = make(graphql.FieldConfigArgument)
+	)
+	// The fields of the table can be selected if the groups are grouped by
+	// them, and the arguments for them filter the rows before they are grouped
+	for _, f := range t.Fields {
+		if f.Hidden {
+			continue
+		}
+		ft := graphQLFieldType(f)
+		typeFields[f.Name] = &graphql.Field{Type: ft}
+		if !isListType(f.Type) {
+			// The argument has the same type as for the table, which is
+			// the enum of a field with an enum
+			args[f.Name] = &graphql.ArgumentConfig{Type: queryFields[t.Name].Args[f.Name].Type}
+		}
+	}
+	// The joins of the table are most useful for grouping, e.g. to count the
+	// rows that belong to each row of another table
+	for _, j := range t.Joins {
+		if j.Hidden {
+			continue
+		}
+		name := foreignKeyField(j.Table)
+		typeFields[name] = &graphql.Field{Type: graphql.String}
+		args[name] = &graphql.ArgumentConfig{Type: graphql.String}
+	}
+	args[groupByID] = &graphql.ArgumentConfig{
+		Type: graphql.NewList(graphql.String),
+	}
+	args[havingID] = &graphql.ArgumentConfig{
+		Type: graphQLHavingType(t.Name),
+	}
+
+	queryFields[t.Name+aggregateSuffix] = &graphql.Field{
+		Type: graphql.NewList(graphql.NewObject(
+			graphql.ObjectConfig{
+				Name:   t.Name + aggregateSuffix,
+				Fields: typeFields,
+			},
+		)),
+		Args:    args,
+		Resolve: resolveFn,
+	}
+}
+
+// addDistinctField adds the query field for the distinct values of a column
+// of the table `t`, which is given by the column argument. The rows can be
+// filtered with the same filter argument as the query field for the table,
+// which must already be in the queryFields
+func addDistinctField(t core.Table, queryFields graphql.Fields, resolveFn graphql.FieldResolveFn) {
+	columns := graphql.EnumValueConfigMap{
+		tableIDField: &graphql.EnumValueConfig{Value: tableIDField},
+	}
+	for _, f := range t.Fields {
+		if f.Hidden {
+			continue
+		}
+		columns[f.Name] = &graphql.EnumValueConfig{Value: f.Name}
+	}
+	for _, j := range t.Joins {
+		if j.Hidden {
+			continue
+		}
+		name := foreignKeyField(j.Table)
+		columns[name] = &graphql.EnumValueConfig{Value: name}
+	}
+
+	queryFields[t.Name+distinctSuffix] = &graphql.Field{
+		Type: graphql.NewList(valueScalar),
+		Args: graphql.FieldConfigArgument{
+			distinctColumnID: &graphql.ArgumentConfig{
+				Type: graphql.NewNonNull(graphql.NewEnum(graphql.EnumConfig{
+					Name:   t.Name + "_column",
+					Values: columns,
+				})),
+			},
+			filterID: queryFields[t.Name].Args[filterID],
+		},
+		Resolve: resolveFn,
 	}
 }
 
-// graphQLFieldType ???
-func graphQLFieldType(f core.TableField) *graphql.Scalar {
+// graphQLHavingType returns the input type that filters groups by the value
+// of their aggregates
+func graphQLHavingType(typeName string) *graphql.InputObject {
+	fields := graphql.InputObjectConfigFieldMap{
+		aggregateCountID: &graphql.InputObjectFieldConfig{
+			Type: graphql.Int,
+		},
+	}
+	for _, f := range scalarFilters {
+		fields[aggregateCountID+f] = &graphql.InputObjectFieldConfig{
+			Type: graphql.Int,
+		}
+	}
+
+	return graphql.NewInputObject(
+		graphql.InputObjectConfig{
+			Name:   typeName + "_having",
+			Fields: fields,
+		},
+	)
+}
+
+// graphQLFieldType returns the GraphQL type of a field. List and set fields
+// are lists of the type of their elements. The type is both an input and an
+// output type
+func graphQLFieldType(f core.TableField) graphql.Type {
 	switch ty := f.Type; {
 	case ty == cty.Bool:
 		return graphql.Boolean
@@ -170,11 +356,33 @@ func graphQLFieldType(f core.TableField) *graphql.Scalar {
 		return mapScalar
 	case ty.IsMapType():
 		return mapScalar
+	case isListType(ty):
+		return graphql.NewList(graphQLFieldType(core.TableField{Name: f.Name, Type: ty.ElementType()}))
 	default:
 		panic(fmt.Sprintf("Unsupported GraphQL conversion from cty.Type: %s", f.Type.GoString()))
 	}
 }
 
+// graphQLEnumType returns the GraphQL enum of the values of a field with an
+// enum, which is named after the table and field, e.g. test_run_status_enum
+func graphQLEnumType(table string, f core.TableField) *graphql.Enum {
+	values := make(graphql.EnumValueConfigMap, len(f.Enum))
+	for _, val := range f.Enum {
+		values[val] = &graphql.EnumValueConfig{Value: val}
+	}
+	return graphql.NewEnum(graphql.EnumConfig{
+		Name:        table + "_" + f.Name + enumSuffix,
+		Description: fmt.Sprintf("The values of the field `%s` of the table `%s`", f.Name, table),
+		Values:      values,
+	})
+}
+
+// isListType returns whether the type of a field is a list, which is a cty
+// list or set
+func isListType(ty cty.Type) bool {
+	return ty.IsListType() || ty.IsSetType()
+}
+
 const (
 	filterID     = "filter"
 	filterOnID   = "filter_on"
@@ -183,6 +391,36 @@ const (
 	orderByID    = "order_by"
 	orderByType  = "_order"
 	distinctOnID = "distinct_on"
+	groupByID    = "group_by"
+	havingID     = "having"
+	sinceID      = "_since"
+	afterID      = "after"
+	// includeDeletedID returns the soft-deleted rows as well, which are
+	// otherwise excluded
+	includeDeletedID = "include_deleted"
+)
+
+const (
+	// aggregateSuffix is the suffix of the query field for the aggregates of
+	// a table
+	aggregateSuffix = "_aggregate"
+	// aggregateCountID is the aggregate for the number of rows in a group
+	aggregateCountID = "count"
+
+	// distinctSuffix is the suffix of the query field for the distinct values
+	// of a column of a table
+	distinctSuffix = "_distinct"
+	// distinctColumnID is the argument for the column to get the distinct
+	// values of
+	distinctColumnID = "column"
+
+	// byIDSuffix is the suffix of the query field for a single row of a
+	// table, given its ID
+	byIDSuffix = "_by_id"
+
+	// enumSuffix is the suffix of the GraphQL enum of the values of a field
+	// with an enum
+	enumSuffix = "_enum"
 )
 
 const (
@@ -190,11 +428,29 @@ const (
 	filterLessThan             = "_lt"
 	filterGreaterThanOrEqualTo = "_gte"
 	filterLessThanOrEqualTo    = "_lte"
+	filterEqual                = "_eq"
 	filterIn                   = "_in"
 	filterNotIn                = "_not_in"
+	// filterIsNull filters on whether the value of a column is null, and is
+	// the only filter that matches the rows with a null value
+	filterIsNull = "_is_null"
+	// filterExists and filterNotExists filter on whether a related table has
+	// rows that match a filter for that table
+	filterExists    = "_exists"
+	filterNotExists = "_not_exists"
+	// filterContains and filterOverlaps filter on whether a list field
+	// contains all, or any, of the values in a list. They are the only
+	// filters of list fields, besides filterIsNull
+	filterContains = "_contains"
+	filterOverlaps = "_overlaps"
+	// filterRegex and filterIRegex filter on whether a string field matches
+	// a regular expression, case sensitively or not
+	filterRegex  = "_regex"
+	filterIRegex = "_iregex"
 )
 
 var scalarFilters = []string{
+	filterEqual,
 	filterGreaterThan,
 	filterLessThan,
 	filterGreaterThanOrEqualTo,
@@ -227,15 +483,27 @@ func graphQLOrderType(typeName string, args graphql.Fields) *graphql.InputObject
 	)
 }
 
-// graphQLFilterType ???
-func graphQLFilterType(typeName string, args graphql.FieldConfigArgument) *graphql.InputObject {
+// graphQLFilterType returns the type of the filter argument of a table, with
+// the filter operators of each of the args
+func graphQLFilterType(t core.Table, args graphql.FieldConfigArgument) *graphql.InputObject {
 	var (
 		// Micro-opt: we know the size of the field map is the total number
 		// of filter ops times the number of args we are given.
-		numFields = (len(scalarFilters) + len(listFilters)) * len(args)
+		numFields = (len(scalarFilters) + len(listFilters) + 1) * len(args)
 		fields    = make(graphql.InputObjectConfigFieldMap, numFields)
 	)
 	for n, a := range args {
+		if _, ok := a.Type.(*graphql.List); ok {
+			for _, f := range []string{filterContains, filterOverlaps} {
+				fields[n+f] = &graphql.InputObjectFieldConfig{
+					Type: a.Type,
+				}
+			}
+			fields[n+filterIsNull] = &graphql.InputObjectFieldConfig{
+				Type: graphql.Boolean,
+			}
+			continue
+		}
 		for _, f := range scalarFilters {
 			fields[n+f] = &graphql.InputObjectFieldConfig{
 				Type: a.Type,
@@ -246,11 +514,22 @@ func graphQLFilterType(typeName string, args graphql.FieldConfigArgument) *graph
 				Type: graphql.NewList(a.Type),
 			}
 		}
+		fields[n+filterIsNull] = &graphql.InputObjectFieldConfig{
+			Type: graphql.Boolean,
+		}
+		// The regular expressions are strings, also for fields with an enum
+		if field, ok := tableField(t, n); ok && field.Type == cty.String {
+			for _, f := range []string{filterRegex, filterIRegex} {
+				fields[n+f] = &graphql.InputObjectFieldConfig{
+					Type: graphql.String,
+				}
+			}
+		}
 	}
 
 	return graphql.NewInputObject(
 		graphql.InputObjectConfig{
-			Name:   typeName + "_filter",
+			Name:   t.Name + "_filter",
 			Fields: fields,
 		},
 	)
@@ -308,6 +587,20 @@ var mapScalar = graphql.NewScalar(graphql.ScalarConfig{
 	},
 })
 
+var valueScalar = graphql.NewScalar(graphql.ScalarConfig{
+	Name:        "Value",
+	Description: "The `Value` scalar type represents the value of any field",
+	Serialize: func(value interface{}) interface{} {
+		return value
+	},
+	ParseValue: func(value interface{}) interface{} {
+		return value
+	},
+	ParseLiteral: func(astValue ast.Value) interface{} {
+		return parseValueToMap(astValue)
+	},
+})
+
 var enumOrderBy = graphql.NewEnum(graphql.EnumConfig{
 	Name:        "Order",
 	Description: "The `Order` type is either `asc` or `desc`",
@@ -0,0 +1,87 @@
+package store
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/valocode/bubbly/env"
+	"github.com/valocode/bubbly/test"
+
+	testData "github.com/valocode/bubbly/store/testdata"
+)
+
+// TestHiddenQuery checks that hidden fields and the foreign key fields of
+// hidden joins cannot be queried, but are saved and the related tables can
+// still be queried through the hidden join
+func TestHiddenQuery(t *testing.T) {
+	bCtx := env.NewBubblyContext()
+	resource := test.RunPostgresDocker(bCtx, t)
+	bCtx.StoreConfig.PostgresAddr = fmt.Sprintf("localhost:%s", resource.GetPort("5432/tcp"))
+
+	tables := testData.Tables(t, bCtx, "./testdata/hidden/tables.hcl")
+	data := testData.DataBlocks(t, bCtx, "./testdata/hidden/data.hcl")
+	s, err := New(bCtx)
+	require.NoError(t, err)
+	require.NoError(t, s.Apply(DefaultTenantName, tables, false))
+	require.NoError(t, s.Save(DefaultTenantName, data))
+
+	tcs := []struct {
+		desc     string
+		query    string
+		expected interface{}
+		wantErr  bool
+	}{
+		{
+			desc:  "related table through hidden join",
+			query: `{ member { email team { name has_secret } } }`,
+			expected: []interface{}{
+				map[string]interface{}{
+					"email": "dev@example.com",
+					"team":  map[string]interface{}{"name": "core", "has_secret": true},
+				},
+			},
+		},
+		{
+			desc:  "parent table through hidden join",
+			query: `{ team { name member { email } } }`,
+			expected: []interface{}{
+				map[string]interface{}{
+					"name":   "core",
+					"member": []interface{}{map[string]interface{}{"email": "dev@example.com"}},
+				},
+			},
+		},
+		{
+			desc:    "hidden field",
+			query:   `{ team { name secret } }`,
+			wantErr: true,
+		},
+		{
+			desc:    "filter on hidden field",
+			query:   `{ team(secret: "hunter2") { name } }`,
+			wantErr: true,
+		},
+		{
+			desc:    "foreign key of hidden join",
+			query:   `{ member_aggregate(group_by: ["team_id"]) { count } }`,
+			wantErr: true,
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.desc, func(t *testing.T) {
+			result, err := s.Query(DefaultTenantName, tc.query)
+			require.NoError(t, err)
+			if tc.wantErr {
+				assert.NotEmpty(t, result.Errors)
+				return
+			}
+			require.Empty(t, result.Errors)
+			for key, val := range result.Data.(map[string]interface{}) {
+				assert.Equal(t, tc.expected, val, key)
+			}
+		})
+	}
+}
@@ -0,0 +1,272 @@
+package store
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/valocode/bubbly/env"
+	"github.com/valocode/bubbly/test"
+)
+
+func TestQueryLimitsTableLimit(t *testing.T) {
+	tcs := []struct {
+		desc     string
+		limits   queryLimits
+		expected uint64
+	}{
+		{desc: "no limits", limits: queryLimits{}, expected: 0},
+		{desc: "default only", limits: queryLimits{defaultLimit: 10}, expected: 10},
+		{desc: "default below max", limits: queryLimits{defaultLimit: 10, maxLimit: 100}, expected: 10},
+		{desc: "default above max", limits: queryLimits{defaultLimit: 100, maxLimit: 10}, expected: 10},
+		{desc: "max only", limits: queryLimits{maxLimit: 10}, expected: 10},
+		{desc: "default above max page size", limits: queryLimits{defaultLimit: 100, maxPageSize: 20, maxLimit: 50}, expected: 20},
+		{desc: "max page size only", limits: queryLimits{maxPageSize: 20}, expected: 20},
+		{desc: "default page size", limits: queryLimits{defaultLimit: 100, defaultPageSize: 10}, expected: 10},
+		{desc: "default page size above default", limits: queryLimits{defaultLimit: 10, defaultPageSize: 30}, expected: 30},
+		{desc: "default page size above max page size", limits: queryLimits{defaultPageSize: 30, maxPageSize: 20}, expected: 20},
+		{desc: "default page size above max", limits: queryLimits{defaultPageSize: 30, maxLimit: 25}, expected: 25},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.desc, func(t *testing.T) {
+			assert.Equal(t, tc.expected, tc.limits.tableLimit())
+		})
+	}
+}
+
+func TestLimitArg(t *testing.T) {
+	tcs := []struct {
+		desc     string
+		value    string
+		limits   queryLimits
+		expected uint64
+		clamped  bool
+		wantErr  bool
+	}{
+		{desc: "no max", value: "1000", limits: queryLimits{}, expected: 1000},
+		{desc: "below max", value: "10", limits: queryLimits{maxLimit: 10}, expected: 10},
+		{desc: "above max", value: "11", limits: queryLimits{maxLimit: 10}, wantErr: true},
+		{desc: "negative", value: "-1", limits: queryLimits{}, wantErr: true},
+		{desc: "max page size", value: "20", limits: queryLimits{maxPageSize: 20, maxLimit: 50}, expected: 20},
+		{desc: "above max page size", value: "40", limits: queryLimits{maxPageSize: 20, maxLimit: 50}, expected: 20, clamped: true},
+		{desc: "above max page size and max", value: "60", limits: queryLimits{maxPageSize: 20, maxLimit: 50}, expected: 20, clamped: true},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.desc, func(t *testing.T) {
+			arg := ast.NewArgument(&ast.Argument{
+				Name:  ast.NewName(&ast.Name{Value: firstID}),
+				Value: ast.NewIntValue(&ast.IntValue{Value: tc.value}),
+			})
+			cursors := &queryCursors{}
+			n, err := psqlLimitArg("t", "root.t", arg, queryOptions{limits: tc.limits, cursors: cursors})
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, n)
+			if tc.clamped {
+				assert.Equal(t, map[string]uint64{"root.t": tc.expected}, cursors.clampedPages())
+				return
+			}
+			assert.Nil(t, cursors.clampedPages())
+		})
+	}
+}
+
+// TestQueryLimits checks that the default limit is applied to tables in a
+// query, and that queries exceeding the maximum limit fail
+func TestQueryLimits(t *testing.T) {
+	bCtx := env.NewBubblyContext()
+	resource := test.RunPostgresDocker(bCtx, t)
+	bCtx.StoreConfig.PostgresAddr = fmt.Sprintf("localhost:%s", resource.GetPort("5432/tcp"))
+	bCtx.StoreConfig.QueryDefaultLimit = 1
+	bCtx.StoreConfig.QueryMaxLimit = 2
+
+	s, err := New(bCtx)
+	require.NoError(t, err)
+	applySchemaOrDie(t, bCtx, s, filepath.FromSlash("testdata/tables.hcl"))
+	loadTestDataOrDie(t, bCtx, s, filepath.FromSlash("testdata/data.hcl"))
+
+	tcs := []struct {
+		desc    string
+		query   string
+		numRoot int
+		wantErr bool
+	}{
+		{
+			desc:    "default limit",
+			query:   "{ root { name } }",
+			numRoot: 1,
+		},
+		{
+			desc:    "first within max",
+			query:   "{ root(first: 2) { name } }",
+			numRoot: 2,
+		},
+		{
+			desc:    "first exceeds max",
+			query:   "{ root(first: 3) { name } }",
+			wantErr: true,
+		},
+		{
+			desc:    "last exceeds max",
+			query:   "{ root(last: 3) { name } }",
+			wantErr: true,
+		},
+		{
+			// The first root has two grandchildren, and the second root has
+			// none, which makes three rows
+			desc:    "rows exceed max",
+			query:   "{ root(first: 2) { name child_a(first: 2) { grandchild_a(first: 2) { name } } } }",
+			wantErr: true,
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.desc, func(t *testing.T) {
+			result, err := s.Query(DefaultTenantName, tc.query)
+			require.NoError(t, err)
+			if tc.wantErr {
+				assert.NotEmpty(t, result.Errors)
+				return
+			}
+			require.Empty(t, result.Errors)
+			assert.Len(t, result.Data.(map[string]interface{})["root"], tc.numRoot)
+		})
+	}
+}
+
+// TestQueryMaxPageSize checks that the maximum page size limits the results
+// of tables without `first` or `last`, and that larger `first` and `last`
+// arguments are reduced to it, which the result notes
+func TestQueryMaxPageSize(t *testing.T) {
+	bCtx := env.NewBubblyContext()
+	resource := test.RunPostgresDocker(bCtx, t)
+	bCtx.StoreConfig.PostgresAddr = fmt.Sprintf("localhost:%s", resource.GetPort("5432/tcp"))
+	bCtx.StoreConfig.QueryDefaultLimit = 0
+	bCtx.StoreConfig.QueryMaxPageSize = 1
+	bCtx.StoreConfig.QueryMaxLimit = 10
+
+	s, err := New(bCtx)
+	require.NoError(t, err)
+	applySchemaOrDie(t, bCtx, s, filepath.FromSlash("testdata/tables.hcl"))
+	loadTestDataOrDie(t, bCtx, s, filepath.FromSlash("testdata/data.hcl"))
+
+	tcs := []struct {
+		desc    string
+		query   string
+		clamped map[string]uint64
+	}{
+		{
+			desc:  "default page size",
+			query: "{ root { name } }",
+		},
+		{
+			desc:    "first exceeds max page size",
+			query:   "{ root(first: 2) { name } }",
+			clamped: map[string]uint64{"root": 1},
+		},
+		{
+			desc:    "last exceeds max page size",
+			query:   "{ root(last: 2) { name } }",
+			clamped: map[string]uint64{"root": 1},
+		},
+		{
+			desc:    "nested table",
+			query:   `{ root(first: 1) { name child_a(first: 5) { name } } }`,
+			clamped: map[string]uint64{"root.child_a": 1},
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.desc, func(t *testing.T) {
+			result, err := s.Query(DefaultTenantName, tc.query)
+			require.NoError(t, err)
+			require.Empty(t, result.Errors)
+			assert.Len(t, result.Data.(map[string]interface{})["root"], 1)
+			if tc.clamped == nil {
+				assert.NotContains(t, result.Extensions, clampedExtension)
+				return
+			}
+			assert.Equal(t, tc.clamped, result.Extensions[clampedExtension])
+		})
+	}
+}
+
+// TestQueryDefaultPageSize checks that the default page size is applied to
+// the tables of a query without `first` or `last`, instead of the default limit
+func TestQueryDefaultPageSize(t *testing.T) {
+	bCtx := env.NewBubblyContext()
+	resource := test.RunPostgresDocker(bCtx, t)
+	bCtx.StoreConfig.PostgresAddr = fmt.Sprintf("localhost:%s", resource.GetPort("5432/tcp"))
+	bCtx.StoreConfig.QueryDefaultLimit = 100
+	bCtx.StoreConfig.QueryDefaultPageSize = 1
+
+	s, err := New(bCtx)
+	require.NoError(t, err)
+	applySchemaOrDie(t, bCtx, s, filepath.FromSlash("testdata/tables.hcl"))
+	loadTestDataOrDie(t, bCtx, s, filepath.FromSlash("testdata/data.hcl"))
+
+	tcs := []struct {
+		desc     string
+		query    string
+		expected int
+	}{
+		{
+			desc:     "default page size",
+			query:    "{ root { name } }",
+			expected: 1,
+		},
+		{
+			desc:     "first",
+			query:    "{ root(first: 2) { name } }",
+			expected: 2,
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.desc, func(t *testing.T) {
+			result, err := s.Query(DefaultTenantName, tc.query)
+			require.NoError(t, err)
+			require.Empty(t, result.Errors)
+			assert.Len(t, result.Data.(map[string]interface{})["root"], tc.expected)
+		})
+	}
+}
+
+// TestQueryMaxDepth checks that the tables nested deeper than the maximum
+// depth are left out of the result, and that the result says where
+func TestQueryMaxDepth(t *testing.T) {
+	bCtx := env.NewBubblyContext()
+	resource := test.RunPostgresDocker(bCtx, t)
+	bCtx.StoreConfig.PostgresAddr = fmt.Sprintf("localhost:%s", resource.GetPort("5432/tcp"))
+	bCtx.StoreConfig.QueryMaxDepth = 1
+
+	s, err := New(bCtx)
+	require.NoError(t, err)
+	applySchemaOrDie(t, bCtx, s, filepath.FromSlash("testdata/tables.hcl"))
+	loadTestDataOrDie(t, bCtx, s, filepath.FromSlash("testdata/data.hcl"))
+
+	result, err := s.Query(DefaultTenantName, `{ root(name: "first_root") { name child_a { name grandchild_a { name } } } }`)
+	require.NoError(t, err)
+	require.Empty(t, result.Errors)
+	assert.Equal(t, []string{"root.child_a.grandchild_a"}, result.Extensions[truncatedExtension])
+
+	roots := result.Data.(map[string]interface{})["root"].([]interface{})
+	require.Len(t, roots, 1)
+	children := roots[0].(map[string]interface{})["child_a"].([]interface{})
+	require.NotEmpty(t, children)
+	for _, child := range children {
+		child := child.(map[string]interface{})
+		assert.NotEmpty(t, child["name"])
+		assert.Nil(t, child["grandchild_a"])
+	}
+
+	// A query within the maximum depth is not truncated
+	result, err = s.Query(DefaultTenantName, `{ root(name: "first_root") { name child_a { name } } }`)
+	require.NoError(t, err)
+	require.Empty(t, result.Errors)
+	assert.NotContains(t, result.Extensions, truncatedExtension)
+}
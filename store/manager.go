@@ -0,0 +1,77 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// ErrStoreNotFound is returned by the StoreManager for a store name that it
+// does not have
+var ErrStoreNotFound = errors.New("store not found")
+
+// StoreManager holds named Store instances, e.g. to separate the data of
+// staging and production, so that requests can be routed to a store by its
+// name. The manager owns the stores, and closes them when it is closed
+type StoreManager struct {
+	mu     sync.RWMutex
+	stores map[string]*Store
+}
+
+// NewStoreManager creates a StoreManager without any stores
+func NewStoreManager() *StoreManager {
+	return &StoreManager{
+		stores: make(map[string]*Store),
+	}
+}
+
+// Add adds a store with the given name. The name cannot be empty, and there
+// cannot already be a store with that name
+func (m *StoreManager) Add(name string, s *Store) error {
+	if name == "" {
+		return errors.New("the name of a store cannot be empty")
+	}
+	if s == nil {
+		return fmt.Errorf("store %s is nil", name)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.stores[name]; ok {
+		return fmt.Errorf("store %s already exists", name)
+	}
+	m.stores[name] = s
+	return nil
+}
+
+// Get returns the store with the given name, or ErrStoreNotFound
+func (m *StoreManager) Get(name string) (*Store, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	s, ok := m.stores[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrStoreNotFound, name)
+	}
+	return s, nil
+}
+
+// Names returns the names of the stores, sorted
+func (m *StoreManager) Names() []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	names := make([]string, 0, len(m.stores))
+	for name := range m.stores {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Close closes all the stores
+func (m *StoreManager) Close() {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for _, s := range m.stores {
+		s.Close()
+	}
+}
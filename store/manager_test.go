@@ -0,0 +1,36 @@
+package store
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStoreManager(t *testing.T) {
+	var (
+		m       = NewStoreManager()
+		staging = &Store{}
+		prod    = &Store{}
+	)
+	require.NoError(t, m.Add("staging", staging))
+	require.NoError(t, m.Add("prod", prod))
+	assert.Equal(t, []string{"prod", "staging"}, m.Names())
+
+	s, err := m.Get("staging")
+	require.NoError(t, err)
+	assert.Same(t, staging, s)
+	s, err = m.Get("prod")
+	require.NoError(t, err)
+	assert.Same(t, prod, s)
+
+	_, err = m.Get("dev")
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, ErrStoreNotFound))
+
+	// The names must be unique and not empty
+	assert.Error(t, m.Add("prod", &Store{}))
+	assert.Error(t, m.Add("", &Store{}))
+	assert.Error(t, m.Add("dev", nil))
+}
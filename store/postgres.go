@@ -4,7 +4,9 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strconv"
 	"strings"
+	"time"
 
 	sq "github.com/Masterminds/squirrel"
 	"github.com/graphql-go/graphql"
@@ -17,6 +19,7 @@ import (
 	"github.com/valocode/bubbly/parser"
 	"github.com/zclconf/go-cty/cty"
 	"github.com/zclconf/go-cty/cty/gocty"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
 )
 
 var (
@@ -28,40 +31,54 @@ var (
 const (
 	psqlBubblySchemaPrefix        = "bb_"
 	psqlTableUniqueSuffix         = "_key"
+	psqlTableIndexSuffix          = "_idx"
 	defaultStoreConnRetryAttempts = 10
 	defaultStoreConnRetryTimeout  = "200ms"
+	psqlPingTimeout               = 5 * time.Second
+	// psqlTimeNow is the SQL expression for the current time as a string in
+	// RFC3339 format, in UTC
+	psqlTimeNow = `to_char(now() AT TIME ZONE 'UTC', 'YYYY-MM-DD"T"HH24:MI:SS"Z"')`
 )
 
 var _ provider = (*postgres)(nil)
 
-func newPostgres(bCtx *env.BubblyContext) (*postgres, error) {
+func newPostgres(bCtx *env.BubblyContext, o *options) (*postgres, error) {
 
-	connStr := fmt.Sprintf(
-		"postgres://%s:%s@%s/%s",
-		bCtx.StoreConfig.PostgresUser,
-		bCtx.StoreConfig.PostgresPassword,
-		bCtx.StoreConfig.PostgresAddr,
-		bCtx.StoreConfig.PostgresDatabase,
-	)
+	connStr, err := bCtx.StoreConfig.PostgresConnString()
+	if err != nil {
+		return nil, err
+	}
 
-	pool, err := psqlNewPool(bCtx, connStr)
+	pool, err := psqlNewPool(bCtx, connStr, o.queryHook)
 	if err != nil {
 		return nil, fmt.Errorf("failed to initialize connection to db: %w", err)
 	}
 
 	return &postgres{
-		pool: pool,
+		pool:          pool,
+		limits:        newQueryLimits(bCtx),
+		rowFilterHook: o.rowFilterHook,
+		softDelete:    bCtx.StoreConfig.SoftDelete,
 	}, nil
 }
 
 type postgres struct {
-	pool *pgxpool.Pool
+	pool          *pgxpool.Pool
+	limits        queryLimits
+	rowFilterHook RowFilterHook
+	// softDelete is whether deleted rows are marked as deleted instead of
+	// being removed
+	softDelete bool
 }
 
 func (p *postgres) Close() {
 	p.pool.Close()
 }
 
+func (p *postgres) Ping() error {
+	return psqlPing(p.pool)
+}
+
 func (p *postgres) Apply(tenant string, schema *bubblySchema) error {
 
 	tx, err := p.pool.Begin(context.Background())
@@ -70,7 +87,7 @@ func (p *postgres) Apply(tenant string, schema *bubblySchema) error {
 	}
 	defer tx.Rollback(context.Background())
 
-	err = psqlApplySchema(tx, tenant, schema)
+	err = psqlApplySchema(tx, tenant, schema, p.softDelete)
 	if err != nil {
 		return fmt.Errorf("failed to apply tables: %w", err)
 	}
@@ -79,13 +96,21 @@ func (p *postgres) Apply(tenant string, schema *bubblySchema) error {
 }
 
 func (p *postgres) Migrate(tenant string, schema *bubblySchema, cl schemaUpdates) error {
-	migration, err := psqlGenerateMigration(config.PostgresStore, tenant, schema, cl)
+	migration, err := p.MigrationSQL(tenant, schema, cl)
 	if err != nil {
-		return fmt.Errorf("failed to generate migration list: %w", err)
+		return err
 	}
 	return psqlMigrate(p.pool, tenant, schema, migration)
 }
 
+func (p *postgres) MigrationSQL(tenant string, schema *bubblySchema, cl schemaUpdates) ([]string, error) {
+	migration, err := psqlGenerateMigration(config.PostgresStore, tenant, schema, cl, p.softDelete)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate migration list: %w", err)
+	}
+	return migration, nil
+}
+
 func (p *postgres) Save(bCtx *env.BubblyContext, tenant string, graph *SchemaGraph, tree dataTree) error {
 
 	tx, err := p.pool.Begin(context.Background())
@@ -103,7 +128,7 @@ func (p *postgres) Save(bCtx *env.BubblyContext, tenant string, graph *SchemaGra
 		if !ok {
 			return fmt.Errorf("data block refers to non-existing table: %s", node.Data.TableName)
 		}
-		return psqlSaveNode(tx, tenant, node, *tNode.Table)
+		return psqlSaveNode(tx, tenant, node, *tNode.Table, p.softDelete)
 	}
 
 	_, err = tree.traverse(bCtx, saveNode)
@@ -115,8 +140,23 @@ func (p *postgres) Save(bCtx *env.BubblyContext, tenant string, graph *SchemaGra
 	return tx.Commit(context.Background())
 }
 
+func (p *postgres) DeleteResource(tenant string, id string) (bool, error) {
+	tx, err := p.pool.Begin(context.Background())
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(context.Background())
+
+	deleted, err := psqlDeleteResource(tx, tenant, id, p.softDelete)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete resource in postgres: %w", err)
+	}
+
+	return deleted, tx.Commit(context.Background())
+}
+
 func (p *postgres) ResolveQuery(tenant string, graph *SchemaGraph, params graphql.ResolveParams) (interface{}, error) {
-	return psqlResolveRootQueries(p.pool, tenant, graph, params)
+	return psqlResolveRootQueries(p.pool, tenant, graph, p.limits, p.rowFilterHook, p.softDelete, params)
 }
 
 func (p *postgres) Tenants() ([]string, error) {
@@ -131,15 +171,30 @@ func (p *postgres) HasTable(tenant string, table string) (bool, error) {
 	return psqlHasTable(p.pool, tenant, table)
 }
 
-func psqlNewPool(bCtx *env.BubblyContext, connStr string) (*pgxpool.Pool, error) {
-	config, err := pgxpool.ParseConfig(connStr)
+func (p *postgres) Upgrade(tenant string) error {
+	return psqlUpgradeTables(p.pool, tenant, p.softDelete)
+}
+
+// psqlPing checks that a connection from the pool can reach the database
+func psqlPing(pool *pgxpool.Pool) error {
+	ctx, cancel := context.WithTimeout(context.Background(), psqlPingTimeout)
+	defer cancel()
+	conn, err := pool.Acquire(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse db config: %w", err)
+		return fmt.Errorf("failed to ping database: %w", err)
 	}
+	defer conn.Release()
+	if err := conn.Conn().Ping(ctx); err != nil {
+		return fmt.Errorf("failed to ping database: %w", err)
+	}
+	return nil
+}
 
-	// TODO: log level should "scale" with bCtx.Logger level
-	config.ConnConfig.Logger = zerologadapter.NewLogger(*bCtx.Logger)
-	config.ConnConfig.LogLevel = pgx.LogLevelError
+func psqlNewPool(bCtx *env.BubblyContext, connStr string, hook QueryHook) (*pgxpool.Pool, error) {
+	config, err := psqlPoolConfig(bCtx, connStr, hook)
+	if err != nil {
+		return nil, err
+	}
 
 	pool, err := pgxpool.ConnectConfig(context.Background(), config)
 	if err != nil {
@@ -149,6 +204,51 @@ func psqlNewPool(bCtx *env.BubblyContext, connStr string) (*pgxpool.Pool, error)
 	return pool, nil
 }
 
+// psqlPoolConfig parses the connection string and returns the config used for
+// creating a new connection pool. If hook is not nil, it is called for every
+// SQL statement run on the pool
+func psqlPoolConfig(bCtx *env.BubblyContext, connStr string, hook QueryHook) (*pgxpool.Config, error) {
+	config, err := pgxpool.ParseConfig(connStr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse db config: %w", err)
+	}
+
+	// TODO: log level should "scale" with bCtx.Logger level
+	config.ConnConfig.Logger = &psqlLogger{
+		logger:  zerologadapter.NewLogger(*bCtx.Logger),
+		hook:    hook,
+		logArgs: bCtx.StoreConfig.LogQueryArgs,
+	}
+	config.ConnConfig.LogLevel = pgx.LogLevelError
+	// pgx logs the statements it runs at info level, so enable that if there
+	// is a hook to call for them
+	if hook != nil {
+		config.ConnConfig.LogLevel = pgx.LogLevelInfo
+	}
+
+	// Do not hand out connections that have been closed, e.g. because the
+	// database closed them, so that a new connection is made instead
+	config.BeforeAcquire = func(ctx context.Context, conn *pgx.Conn) bool {
+		return !conn.IsClosed()
+	}
+
+	// Set the statement_timeout as a runtime parameter so that every new
+	// connection in the pool gets it, and the database itself aborts any
+	// queries that run for too long
+	if bCtx.StoreConfig.StatementTimeout > 0 {
+		config.ConnConfig.RuntimeParams["statement_timeout"] = strconv.Itoa(bCtx.StoreConfig.StatementTimeout)
+	}
+
+	// Name the connections so that they can be told apart from those of
+	// other instances in pg_stat_activity, unless the connection string
+	// already names them
+	if _, ok := config.ConnConfig.RuntimeParams["application_name"]; !ok && bCtx.StoreConfig.ApplicationName != "" {
+		config.ConnConfig.RuntimeParams["application_name"] = bCtx.StoreConfig.ApplicationName
+	}
+
+	return config, nil
+}
+
 func psqlTenantSchemas(pool *pgxpool.Pool) ([]string, error) {
 	var (
 		sql = psql.Select("schema_name").
@@ -218,9 +318,79 @@ func psqlHasTable(pool *pgxpool.Pool, tenant string, table string) (bool, error)
 	return exists, nil
 }
 
-func psqlApplySchema(tx pgx.Tx, tenant string, schema *bubblySchema) error {
+// psqlColumn is a column that the store maintains itself, with its SQL type
+type psqlColumn struct {
+	name    string
+	sqlType string
+}
+
+// psqlStoreColumns returns the columns that the store maintains itself in
+// every table. The column for soft deletes is only added if softDelete is
+// true, so that stores which do not soft-delete keep their tables unchanged
+func psqlStoreColumns(softDelete bool) []psqlColumn {
+	columns := []psqlColumn{
+		{name: tableSeqField, sqlType: "BIGSERIAL"},
+	}
+	if softDelete {
+		columns = append(columns, psqlColumn{name: tableDeletedAtField, sqlType: "TIMESTAMPTZ"})
+	}
+	return columns
+}
+
+// psqlUpgradeTables adds the columns that the store maintains itself to the
+// tables of a tenant that were created before the columns existed, or before
+// soft deletes were enabled
+func psqlUpgradeTables(pool *pgxpool.Pool, tenant string, softDelete bool) error {
+	for _, column := range psqlStoreColumns(softDelete) {
+		tables, err := psqlTablesWithoutColumn(pool, tenant, column.name)
+		if err != nil {
+			return err
+		}
+		for _, table := range tables {
+			sqlStr := "ALTER TABLE " + psqlAbsTableName(tenant, table) + " ADD COLUMN IF NOT EXISTS " + column.name + " " + column.sqlType + ";"
+			if _, err := pool.Exec(context.Background(), sqlStr); err != nil {
+				return fmt.Errorf("failed to add column %s to table: %s: %w", column.name, table, err)
+			}
+		}
+	}
+	return nil
+}
+
+// psqlTablesWithoutColumn returns the tables of a tenant that do not have the
+// given column
+func psqlTablesWithoutColumn(pool *pgxpool.Pool, tenant string, column string) ([]string, error) {
+	sqlStr, sqlArgs, err := psql.Select("t.table_name").
+		From("information_schema.tables AS t").
+		Where(sq.Eq{"t.table_schema": psqlSchemaName(tenant)}).
+		Where(sq.Eq{"t.table_type": "BASE TABLE"}).
+		Where("NOT EXISTS (SELECT 1 FROM information_schema.columns AS c "+
+			"WHERE c.table_schema = t.table_schema AND c.table_name = t.table_name AND c.column_name = ?)", column).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create sql query: %w", err)
+	}
+	rows, err := pool.Query(context.Background(), sqlStr, sqlArgs...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tables without column %s: %w", column, err)
+	}
+	defer rows.Close()
+	var tables []string
+	for rows.Next() {
+		var table string
+		if err := rows.Scan(&table); err != nil {
+			return nil, fmt.Errorf("failed to scan table name: %w", err)
+		}
+		tables = append(tables, table)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get tables without column %s: %w", column, err)
+	}
+	return tables, nil
+}
+
+func psqlApplySchema(tx pgx.Tx, tenant string, schema *bubblySchema, softDelete bool) error {
 	for _, table := range schema.Tables {
-		if err := psqlApplyTable(tx, tenant, table); err != nil {
+		if err := psqlApplyTable(tx, tenant, table, softDelete); err != nil {
 			return err
 		}
 	}
@@ -234,15 +404,15 @@ func psqlApplySchema(tx pgx.Tx, tenant string, schema *bubblySchema) error {
 	node := newDataNode(&d)
 	schemaTable := schema.Tables[core.SchemaTableName]
 	// Save the data block node to the schemaTable
-	if err := psqlSaveNode(tx, tenant, node, schemaTable); err != nil {
+	if err := psqlSaveNode(tx, tenant, node, schemaTable, false); err != nil {
 		return fmt.Errorf("failed to save schema data block: %w", err)
 	}
 
 	return nil
 }
 
-func psqlApplyTable(tx pgx.Tx, tenant string, table core.Table) error {
-	sql, err := psqlTableCreate(tenant, table)
+func psqlApplyTable(tx pgx.Tx, tenant string, table core.Table, softDelete bool) error {
+	sql, err := psqlTableCreate(tenant, table, softDelete)
 	if err != nil {
 		return fmt.Errorf("failed to prepare SQL statement: %w", err)
 	}
@@ -257,25 +427,17 @@ func psqlApplyTable(tx pgx.Tx, tenant string, table core.Table) error {
 	if err != nil {
 		return fmt.Errorf("failed to add constraints on table: %s: %w", table.Name, err)
 	}
+	// Create the indexes
+	for _, sql := range psqlTableIndexes(tenant, table) {
+		if _, err := tx.Exec(context.Background(), sql); err != nil {
+			return fmt.Errorf("failed to create index on table: %s: %w", table.Name, err)
+		}
+	}
 	return nil
 }
 
 func psqlTableUniqueConstraints(tenant string, table core.Table) string {
-	var (
-		uniqueFields = make([]string, 0)
-	)
-	for _, field := range table.Fields {
-		if field.Unique {
-			uniqueFields = append(uniqueFields, field.Name)
-		}
-	}
-	// Add the joins as fields to the SQL table
-	for _, join := range table.Joins {
-		fieldName := join.Table + "_id"
-		if join.Unique {
-			uniqueFields = append(uniqueFields, fieldName)
-		}
-	}
+	uniqueFields := tableUniqueFields(table)
 
 	// First drop the existing constraint (IF EXISTS)
 	sql := "ALTER TABLE " + psqlAbsTableName(tenant, table.Name) +
@@ -288,19 +450,55 @@ func psqlTableUniqueConstraints(tenant string, table core.Table) string {
 	return sql + ";"
 }
 
-func psqlTableCreate(tenant string, table core.Table) (string, error) {
+// psqlTableIndexes returns the statements to create the indexes of a table,
+// if they do not exist
+func psqlTableIndexes(tenant string, table core.Table) []string {
+	indexes := tableIndexes(table)
+	stmts := make([]string, 0, len(indexes))
+	for _, index := range indexes {
+		stmts = append(stmts, psqlIndexCreate(tenant, table.Name, index))
+	}
+	return stmts
+}
+
+// psqlIndexCreate returns the statement to create an index on the fields of a
+// table, if it does not exist
+func psqlIndexCreate(tenant string, table string, fields []string) string {
+	return "CREATE INDEX IF NOT EXISTS " + psqlIndexName(table, fields) +
+		" ON " + psqlAbsTableName(tenant, table) + " (" + strings.Join(fields, ",") + ");"
+}
+
+// psqlIndexName returns the name of the index on the fields of a table.
+// Index names are unique within a postgres schema, so they contain the table
+func psqlIndexName(table string, fields []string) string {
+	return table + "_" + strings.Join(fields, "_") + psqlTableIndexSuffix
+}
+
+// psqlTableCreate returns the statement that creates the table, with the
+// columns that the store maintains itself
+func psqlTableCreate(tenant string, table core.Table, softDelete bool) (string, error) {
 	var (
 		fieldLen    = len(table.Fields) + len(table.Joins)
 		tableFields = make([]string, 0, fieldLen)
 	)
 
 	tableFields = append(tableFields, tableIDField+" SERIAL PRIMARY KEY")
+	for _, column := range psqlStoreColumns(softDelete) {
+		tableFields = append(tableFields, column.name+" "+column.sqlType)
+	}
 	// Add the fields to the SQL table
 	for _, field := range table.Fields {
 		sqlType, err := psqlType(field.Type)
 		if err != nil {
 			return "", fmt.Errorf("failed to create SQL statement for table: %s: %w", table.Name, err)
 		}
+		sqlDefault, err := psqlFieldDefault(field)
+		if err != nil {
+			return "", fmt.Errorf("failed to create SQL statement for table: %s: %w", table.Name, err)
+		}
+		if sqlDefault != "" {
+			sqlType += " DEFAULT " + sqlDefault
+		}
 		tableFields = append(tableFields, field.Name+" "+sqlType)
 	}
 	// Add the joins as fields to the SQL table
@@ -312,7 +510,10 @@ func psqlTableCreate(tenant string, table core.Table) (string, error) {
 	return "CREATE TABLE IF NOT EXISTS " + psqlAbsTableName(tenant, table.Name) + " ( " + strings.Join(tableFields, ",") + " );", nil
 }
 
-func psqlSaveNode(tx pgx.Tx, tenant string, node *dataNode, table core.Table) error {
+// psqlSaveNode saves a data node according to its policy. If softDelete is
+// true, the data nodes with the delete policy are marked as deleted instead of
+// being removed
+func psqlSaveNode(tx pgx.Tx, tenant string, node *dataNode, table core.Table, softDelete bool) error {
 	var (
 		retValues    []map[string]interface{}
 		uniqueFields map[string]struct{}
@@ -322,6 +523,7 @@ func psqlSaveNode(tx pgx.Tx, tenant string, node *dataNode, table core.Table) er
 	// Create vs CreateUpdate are very similar, except for with Create (only)
 	// we don't want to update, instead return a nice error
 	case core.CreatePolicy, core.CreateUpdatePolicy, core.EmptyPolicy:
+		psqlRemoveDefaultDataFields(table, node.Data)
 		uniqueFields, err = psqlAddUniqueDataFields(table, node.Data)
 		if err != nil {
 			return fmt.Errorf("error setting default unique values for data %s: %w", node.Data.TableName, err)
@@ -360,9 +562,20 @@ func psqlSaveNode(tx pgx.Tx, tenant string, node *dataNode, table core.Table) er
 		}
 		// Else, perform an update of the data block.
 		// The tableIdField should ALWAYS be returned, so we can skip any check here
-		retValues, err = psqlDataUpdate(tx, tenant, node, table, retValues[0][tableIDField])
+		retValues, err = psqlDataUpdate(tx, tenant, node, table, retValues[0][tableIDField], softDelete)
 	case core.ReferencePolicy, core.ReferenceIfExistsPolicy:
 		retValues, err = psqlDataSelect(tx, tenant, node, table)
+	case core.DeletePolicy:
+		retValues, err = psqlDataSelect(tx, tenant, node, table)
+		if err != nil {
+			break
+		}
+		// Deleting a data block that does not exist is not an error, so
+		// that deleting it again has no effect
+		if len(retValues) == 0 {
+			return nil
+		}
+		err = psqlDataDelete(tx, tenant, table, retValues, softDelete)
 	default:
 		return fmt.Errorf("data block refers to unsupported policy %s: %s", node.Data.TableName, node.Data.Policy)
 	}
@@ -387,10 +600,119 @@ func psqlSaveNode(tx pgx.Tx, tenant string, node *dataNode, table core.Table) er
 
 }
 
+// psqlDeleteResource deletes the resource with the given ID and the events
+// and versions that belong to it. It returns false if there was no resource
+// to delete.
+// If softDelete is true, the resource, its events and versions are marked as
+// deleted instead, and a resource that is already marked as deleted does not
+// exist
+func psqlDeleteResource(tx pgx.Tx, tenant string, id string, softDelete bool) (bool, error) {
+	var (
+		resourceTable = psqlAbsTableName(tenant, core.ResourceTableName)
+		sqlStr        string
+		sqlArgs       []interface{}
+		err           error
+	)
+	if softDelete {
+		sqlStr, sqlArgs, err = psqlSoftDelete(resourceTable).
+			Where(sq.Eq{"id": id}).
+			Suffix("RETURNING " + tableIDField).
+			ToSql()
+	} else {
+		sqlStr, sqlArgs, err = psql.Delete(resourceTable).
+			Where(sq.Eq{"id": id}).
+			Suffix("RETURNING " + tableIDField).
+			ToSql()
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to create sql query: %w", err)
+	}
+	rows, err := tx.Query(context.Background(), sqlStr, sqlArgs...)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete resource %s: %w", id, err)
+	}
+	var resourceIDs []int64
+	for rows.Next() {
+		var resourceID int64
+		if err := rows.Scan(&resourceID); err != nil {
+			rows.Close()
+			return false, fmt.Errorf("failed to scan id of deleted resource %s: %w", id, err)
+		}
+		resourceIDs = append(resourceIDs, resourceID)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return false, fmt.Errorf("failed to delete resource %s: %w", id, err)
+	}
+	if len(resourceIDs) == 0 {
+		return false, nil
+	}
+
+	// Joins are not managed by FK constraints, so delete the events and
+	// versions of the resource explicitly
+	ofResource := sq.Eq{core.ResourceTableName + tableJoinSuffix: resourceIDs}
+	for _, tableName := range []string{core.EventTableName, core.ResourceVersionTableName} {
+		absTable := psqlAbsTableName(tenant, tableName)
+		if softDelete {
+			sqlStr, sqlArgs, err = psqlSoftDelete(absTable).Where(ofResource).ToSql()
+		} else {
+			sqlStr, sqlArgs, err = psql.Delete(absTable).Where(ofResource).ToSql()
+		}
+		if err != nil {
+			return false, fmt.Errorf("failed to create sql query: %w", err)
+		}
+		if _, err := tx.Exec(context.Background(), sqlStr, sqlArgs...); err != nil {
+			return false, fmt.Errorf("failed to delete rows of table %s of resource %s: %w", tableName, id, err)
+		}
+	}
+	return true, nil
+}
+
+// psqlDataDelete deletes the rows of a table that were selected for a data
+// block with the delete policy. If softDelete is true, the rows are marked as
+// deleted instead.
+// Joins are not managed by FK constraints, so the rows that join to the
+// deleted rows are not deleted
+func psqlDataDelete(tx pgx.Tx, tenant string, table core.Table, rows []map[string]interface{}, softDelete bool) error {
+	ids := make([]interface{}, 0, len(rows))
+	for _, row := range rows {
+		ids = append(ids, row[tableIDField])
+	}
+	var (
+		absTable = psqlAbsTableName(tenant, table.Name)
+		sqlStr   string
+		sqlArgs  []interface{}
+		err      error
+	)
+	if softDelete {
+		sqlStr, sqlArgs, err = psqlSoftDelete(absTable).Where(sq.Eq{tableIDField: ids}).ToSql()
+	} else {
+		sqlStr, sqlArgs, err = psql.Delete(absTable).Where(sq.Eq{tableIDField: ids}).ToSql()
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create sql query: %w", err)
+	}
+	if _, err := tx.Exec(context.Background(), sqlStr, sqlArgs...); err != nil {
+		return fmt.Errorf("failed to delete rows of table %s: %w", table.Name, err)
+	}
+	return nil
+}
+
+// psqlSoftDelete returns the statement that marks the rows of a table as
+// deleted, which only changes the rows that are not already deleted.
+// The rows get the next sequence number, so that the rows that changed since
+// a cursor include the deleted rows
+func psqlSoftDelete(absTable string) sq.UpdateBuilder {
+	return psql.Update(absTable).
+		Set(tableDeletedAtField, sq.Expr("now()")).
+		Set(tableSeqField, sq.Expr("DEFAULT")).
+		Where(tableDeletedAtField + " IS NULL")
+}
+
 // psqlDataUpdate generates a sql query for performing an insert/update.
 // It requires that we first perform a SELECT to check if there are any conflicts
 // and then either UPDATE (on conflicts) or INSERT otherwise
-func psqlDataUpdate(tx pgx.Tx, tenant string, node *dataNode, table core.Table, id interface{}) ([]map[string]interface{}, error) {
+func psqlDataUpdate(tx pgx.Tx, tenant string, node *dataNode, table core.Table, id interface{}, softDelete bool) ([]map[string]interface{}, error) {
 	var (
 		data         = node.Data
 		sqlReturning = ""
@@ -401,6 +723,12 @@ func psqlDataUpdate(tx pgx.Tx, tenant string, node *dataNode, table core.Table,
 	sql := psql.Update(psqlAbsTableName(tenant, data.TableName)).
 		Where(sq.Eq{tableIDField: id}).
 		Suffix(sqlReturning)
+	// Give the row the next sequence number, as it has changed, and restore
+	// it if it was soft-deleted, as it is saved again
+	sql = sql.Set(tableSeqField, sq.Expr("DEFAULT"))
+	if softDelete {
+		sql = sql.Set(tableDeletedAtField, nil)
+	}
 	for name, value := range node.Data.Fields.Values {
 		v, err := psqlValue(node, value)
 		if err != nil {
@@ -584,6 +912,10 @@ func psqlValue(node *dataNode, val cty.Value) (interface{}, error) {
 }
 
 func valueFromCty(val cty.Value) (interface{}, error) {
+	// Null values, e.g. of a list field, are stored as NULL
+	if val.IsNull() {
+		return nil, nil
+	}
 	switch ty := val.Type(); {
 	case ty == cty.Bool:
 		return val.True(), nil
@@ -608,6 +940,19 @@ func valueFromCty(val cty.Value) (interface{}, error) {
 			}
 		}
 		return ret, nil
+	case ty.IsListType(), ty.IsSetType(), ty.IsTupleType():
+		// Lists are stored as JSONB arrays. The value of a list field can be
+		// a tuple, e.g. when it is given as ["a", "b"] in a data block
+		ret := make([]interface{}, 0, val.LengthInt())
+		for it := val.ElementIterator(); it.Next(); {
+			_, v := it.Element()
+			elem, err := valueFromCty(v)
+			if err != nil {
+				return nil, err
+			}
+			ret = append(ret, elem)
+		}
+		return ret, nil
 	case ty == cty.DynamicPseudoType:
 		// The DyanmicPseudo value is used when the cty has a NilVal, and thus
 		// no cty.Type can be assigned. There may be other cases too, but this is
@@ -636,6 +981,10 @@ func psqlType(ty cty.Type) (string, error) {
 		return "JSONB", nil
 	case ty.IsMapType():
 		return "JSONB", nil
+	case isListType(ty):
+		// Lists are stored as JSONB arrays, which can be filtered on their
+		// elements with the containment operators
+		return "JSONB", nil
 	default:
 		return "", fmt.Errorf("unsupported SQL type: %s", ty.GoString())
 	}
@@ -651,35 +1000,110 @@ func psqlAbsTableName(tenant string, table string) string {
 
 func psqlAddUniqueDataFields(table core.Table, data *core.Data) (map[string]struct{}, error) {
 	var uniqueFields = make(map[string]struct{})
-	for _, field := range table.Fields {
-		if field.Unique {
-			uniqueFields[field.Name] = struct{}{}
-			if _, ok := data.Fields.Values[field.Name]; !ok {
-				val, err := psqlDefaultFieldValue(field.Type)
-				if err != nil {
-					return nil, fmt.Errorf("failed to get default value for unique table field %s.%s: %w", table.Name, field.Name, err)
-				}
-				data.Fields.Values[field.Name] = val
-			}
+	for _, name := range tableUniqueFields(table) {
+		uniqueFields[name] = struct{}{}
+		if _, ok := data.Fields.Values[name]; ok {
+			continue
 		}
-	}
-	for _, join := range table.Joins {
-		if join.Unique {
-			fieldName := join.Table + tableJoinSuffix
-			uniqueFields[fieldName] = struct{}{}
-			if _, ok := data.Fields.Values[fieldName]; !ok {
-				// The forgeign key can never be -1, and so if we are generating
-				// a default value we want to make sure this will not actually
-				// create an unintential join!
-				// We need this default value because null is a unique value in
-				// postgres... which screws with our unique constraints
-				data.Fields.Values[fieldName] = cty.NumberIntVal(int64(psqlDefaultMissingJoinValue))
+		if field, ok := tableField(table, name); ok {
+			val, err := psqlUniqueFieldDefault(field)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get default value for unique table field %s.%s: %w", table.Name, field.Name, err)
 			}
+			data.Fields.Values[name] = val
+			continue
 		}
+		if _, ok := tableJoin(table, name); ok {
+			// The forgeign key can never be -1, and so if we are generating
+			// a default value we want to make sure this will not actually
+			// create an unintential join!
+			// We need this default value because null is a unique value in
+			// postgres... which screws with our unique constraints
+			data.Fields.Values[name] = cty.NumberIntVal(int64(psqlDefaultMissingJoinValue))
+			continue
+		}
+		return nil, fmt.Errorf("unique field %s does not exist in table %s", name, table.Name)
 	}
 	return uniqueFields, nil
 }
 
+// psqlRemoveDefaultDataFields removes the fields of the data which are null
+// and have a default value, so that they are not saved and take the default
+// value instead
+func psqlRemoveDefaultDataFields(table core.Table, data *core.Data) {
+	for _, field := range table.Fields {
+		if !field.HasDefault() {
+			continue
+		}
+		if val, ok := data.Fields.Values[field.Name]; ok && val.IsNull() {
+			delete(data.Fields.Values, field.Name)
+		}
+	}
+}
+
+// psqlUniqueFieldDefault returns the value for a unique field which is not
+// provided by a data block. This is the default value of the field, if it has
+// one, so that it matches what is saved
+func psqlUniqueFieldDefault(field core.TableField) (cty.Value, error) {
+	if !field.HasDefault() {
+		return psqlDefaultFieldValue(field.Type)
+	}
+	val, err := field.DefaultValue()
+	if err != nil {
+		return cty.NilVal, err
+	}
+	if val.Type() == cty.String && val.AsString() == core.TableFieldDefaultNow {
+		return cty.StringVal(time.Now().UTC().Format(time.RFC3339)), nil
+	}
+	return val, nil
+}
+
+// psqlFieldDefault returns the SQL expression for the default value of a
+// field, or an empty string if the field does not have a default
+func psqlFieldDefault(field core.TableField) (string, error) {
+	if !field.HasDefault() {
+		return "", nil
+	}
+	val, err := field.DefaultValue()
+	if err != nil {
+		return "", err
+	}
+	if !val.IsWhollyKnown() {
+		return "", fmt.Errorf("default value of field %s must be known", field.Name)
+	}
+	switch ty := val.Type(); {
+	case ty == cty.Bool:
+		if val.True() {
+			return "TRUE", nil
+		}
+		return "FALSE", nil
+	case ty == cty.Number:
+		bf := val.AsBigFloat()
+		if !bf.IsInt() {
+			return "", fmt.Errorf("default value of field %s must be a whole number", field.Name)
+		}
+		return bf.Text('f', 0), nil
+	case ty == cty.String:
+		if val.AsString() == core.TableFieldDefaultNow {
+			return psqlTimeNow, nil
+		}
+		return psqlQuoteLiteral(val.AsString()), nil
+	case ty.IsObjectType(), ty.IsMapType(), isListType(ty):
+		b, err := ctyjson.Marshal(val, ty)
+		if err != nil {
+			return "", fmt.Errorf("failed to marshal default value of field %s: %w", field.Name, err)
+		}
+		return psqlQuoteLiteral(string(b)) + "::JSONB", nil
+	default:
+		return "", fmt.Errorf("unsupported default value of field %s: %s", field.Name, ty.GoString())
+	}
+}
+
+// psqlQuoteLiteral quotes a string as an SQL string literal
+func psqlQuoteLiteral(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
 func psqlDefaultFieldValue(ty cty.Type) (cty.Value, error) {
 	switch {
 	case ty == cty.Bool:
@@ -693,6 +1117,10 @@ func psqlDefaultFieldValue(ty cty.Type) (cty.Value, error) {
 	case ty.IsMapType():
 		elType := ty.MapElementType()
 		return cty.MapValEmpty(*elType), nil
+	case ty.IsListType():
+		return cty.ListValEmpty(ty.ElementType()), nil
+	case ty.IsSetType():
+		return cty.SetValEmpty(ty.ElementType()), nil
 	default:
 		return cty.NilVal, fmt.Errorf("unsupported cty.Type: %s", ty.GoString())
 	}
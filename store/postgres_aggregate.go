@@ -0,0 +1,208 @@
+package store
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+// havingOps maps the filters of the having argument to their SQL operators
+var havingOps = map[string]string{
+	"":                         "=",
+	filterEqual:                "=",
+	filterGreaterThan:          ">",
+	filterLessThan:             "<",
+	filterGreaterThanOrEqualTo: ">=",
+	filterLessThanOrEqualTo:    "<=",
+}
+
+// psqlResolveAggregateQuery resolves a root graphql query for the aggregates
+// of a table, such as:
+//
+//	grandchild_a_aggregate(group_by: ["child_a_id"], having: {count_gt: 1}) {
+//		child_a_id
+//		count
+//	}
+//
+// The rows of the table are grouped by the fields in the group_by argument,
+// and the groups are filtered by the having argument.
+// If explain is not nil, the SQL query is added to it
+func psqlResolveAggregateQuery(pool *pgxpool.Pool, tenant string, graph *SchemaGraph, field *ast.Field, opts queryOptions, explain *queryExplain) (interface{}, error) {
+	var (
+		table  = strings.TrimSuffix(field.Name.Value, aggregateSuffix)
+		alias  = tableAlias(table, 0)
+		sql    = sq.Select().From(tableAsAlias(psqlAbsTableName(tenant, table), alias))
+		fields []string
+		// groupBy is the set of fields that the rows are grouped by
+		groupBy = make(map[string]struct{})
+	)
+	node, ok := graph.NodeIndex[table]
+	if !ok {
+		return nil, fmt.Errorf("unknown table for aggregate query: %s", table)
+	}
+
+	for _, arg := range field.Arguments {
+		name := arg.Name.Value
+		// Arguments for the columns of the table filter the rows before they
+		// are grouped
+		if tableHasColumn(*node.Table, name) {
+			sql = sql.Where(sq.Eq{tableColumn(alias, name): arg.Value.GetValue()})
+			continue
+		}
+		switch name {
+		case groupByID:
+			columns, err := groupByColumns(arg.Value)
+			if err != nil {
+				return nil, err
+			}
+			for _, column := range columns {
+				// The group_by argument is not validated by the GraphQL
+				// schema, so hidden fields must be rejected here
+				if !tableHasColumn(*node.Table, column) || tableColumnHidden(*node.Table, column) {
+					return nil, fmt.Errorf("unknown field in 'group_by' for table %s: %s", table, column)
+				}
+				if _, ok := groupBy[column]; ok {
+					continue
+				}
+				groupBy[column] = struct{}{}
+				sql = sql.GroupBy(tableColumn(alias, column)).
+					OrderBy(tableColumn(alias, column))
+			}
+		case havingID:
+			having, ok := arg.Value.(*ast.ObjectValue)
+			if !ok {
+				return nil, fmt.Errorf("invalid format for 'having' argument")
+			}
+			for _, f := range having.Fields {
+				op, ok := havingOps[strings.TrimPrefix(f.Name.Value, aggregateCountID)]
+				if !ok || !strings.HasPrefix(f.Name.Value, aggregateCountID) {
+					return nil, fmt.Errorf("unknown aggregate filter for 'having': %s", f.Name.Value)
+				}
+				n, err := strconv.ParseInt(fmt.Sprint(f.Value.GetValue()), 10, 64)
+				if err != nil {
+					return nil, fmt.Errorf("could not convert the value of %s to integer: %v", f.Name.Value, f.Value.GetValue())
+				}
+				sql = sql.Having(fmt.Sprintf("COUNT(*) %s ?", op), n)
+			}
+		default:
+			return nil, fmt.Errorf("unknown argument identifier for table %s: %s", field.Name.Value, name)
+		}
+	}
+
+	// Restrict the rows that are aggregated to those the caller is allowed
+	// to see, which are not soft-deleted
+	if opts.softDelete {
+		sql = sql.Where(psqlNotDeleted(alias))
+	}
+	if opts.rowFilter != nil {
+		filter, err := opts.rowFilter(table)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get row filter for table %s: %w", table, err)
+		}
+		if len(filter) > 0 {
+			eq, err := psqlRowFilter(*node.Table, alias, filter)
+			if err != nil {
+				return nil, err
+			}
+			sql = sql.Where(eq)
+		}
+	}
+
+	for _, selection := range field.SelectionSet.Selections {
+		subField, ok := selection.(*ast.Field)
+		if !ok {
+			return nil, fmt.Errorf("graphql query selection type not supported: %s", selection.GetSelectionSet().Kind)
+		}
+		fieldName := subField.Name.Value
+		if strings.HasPrefix(fieldName, "__") {
+			continue
+		}
+		switch _, grouped := groupBy[fieldName]; {
+		case fieldName == aggregateCountID:
+			sql = sql.Column("COUNT(*)")
+		case grouped:
+			sql = sql.Column(tableColumn(alias, fieldName))
+		default:
+			// The value of a field is only the same for all the rows in a
+			// group if the rows are grouped by it
+			return nil, fmt.Errorf("field %s of %s must be in 'group_by' to be selected", fieldName, field.Name.Value)
+		}
+		fields = append(fields, fieldName)
+	}
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("no fields selected for %s", field.Name.Value)
+	}
+	if limit := opts.limits.tableLimit(); limit > 0 {
+		sql = sql.Limit(limit)
+	}
+
+	sqlStr, sqlArgs, err := sql.ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create sql query: %w", err)
+	}
+	sqlStr, err = sq.Dollar.ReplacePlaceholders(sqlStr)
+	if err != nil {
+		return nil, fmt.Errorf("error replacing the SQL (squirrel) placeholders: %w", err)
+	}
+
+	if explain != nil {
+		if err := explain.add(pool, field.Name.Value, sqlStr, sqlArgs); err != nil {
+			return nil, err
+		}
+	}
+
+	rows, err := pool.Query(opts.ctx, sqlStr, sqlArgs...)
+	if err != nil {
+		if regexErr := psqlRegexError(err); regexErr != nil {
+			return nil, regexErr
+		}
+		return nil, fmt.Errorf("failed to execute SQL query: %s: %w", sqlStr, err)
+	}
+	defer rows.Close()
+
+	// Initialize with an empty slice to avoid returning just null
+	result := make([]interface{}, 0)
+	for rows.Next() {
+		values, err := rows.Values()
+		if err != nil {
+			return nil, fmt.Errorf("failed scanning row values: %w", err)
+		}
+		group := make(map[string]interface{}, len(fields))
+		for i, f := range fields {
+			group[f] = values[i]
+		}
+		result = append(result, group)
+	}
+	if err := rows.Err(); err != nil {
+		if regexErr := psqlRegexError(err); regexErr != nil {
+			return nil, regexErr
+		}
+		return nil, fmt.Errorf("failed reading the rows: %w", err)
+	}
+	return result, nil
+}
+
+// groupByColumns returns the columns of the group_by argument, which is
+// either a list of columns or a single column
+func groupByColumns(value ast.Value) ([]string, error) {
+	var values []ast.Value
+	switch v := value.(type) {
+	case *ast.ListValue:
+		values = v.Values
+	default:
+		values = []ast.Value{v}
+	}
+	columns := make([]string, 0, len(values))
+	for _, v := range values {
+		column, ok := v.(*ast.StringValue)
+		if !ok {
+			return nil, fmt.Errorf("invalid format for 'group_by' argument")
+		}
+		columns = append(columns, column.Value)
+	}
+	return columns, nil
+}
@@ -0,0 +1,98 @@
+package store
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/valocode/bubbly/env"
+	"github.com/valocode/bubbly/test"
+)
+
+func TestAggregateQuery(t *testing.T) {
+	bCtx := env.NewBubblyContext()
+	resource := test.RunPostgresDocker(bCtx, t)
+	bCtx.StoreConfig.PostgresAddr = fmt.Sprintf("localhost:%s", resource.GetPort("5432/tcp"))
+
+	s, err := New(bCtx)
+	require.NoError(t, err)
+	applySchemaOrDie(t, bCtx, s, filepath.FromSlash("testdata/tables.hcl"))
+	loadTestDataOrDie(t, bCtx, s, filepath.FromSlash("testdata/data.hcl"))
+
+	// The first child_a has both grandchild_a, so the grandchildren are all in
+	// the same group when grouped by their child_a
+	tcs := []struct {
+		desc     string
+		query    string
+		expected []interface{}
+		wantErr  bool
+	}{
+		{
+			desc:  "count without group_by",
+			query: `{ grandchild_a_aggregate { count } }`,
+			expected: []interface{}{
+				map[string]interface{}{"count": 2},
+			},
+		},
+		{
+			desc:  "group_by field",
+			query: `{ grandchild_a_aggregate(group_by: ["name"]) { name count } }`,
+			expected: []interface{}{
+				map[string]interface{}{"name": "first_grandchild", "count": 1},
+				map[string]interface{}{"name": "second_grandchild", "count": 1},
+			},
+		},
+		{
+			desc:  "having matches",
+			query: `{ grandchild_a_aggregate(group_by: "child_a_id", having: {count_gt: 1}) { count } }`,
+			expected: []interface{}{
+				map[string]interface{}{"count": 2},
+			},
+		},
+		{
+			desc:     "having does not match",
+			query:    `{ grandchild_a_aggregate(group_by: "child_a_id", having: {count_gt: 2}) { count } }`,
+			expected: []interface{}{},
+		},
+		{
+			desc:  "having with field filter",
+			query: `{ grandchild_a_aggregate(name: "first_grandchild", group_by: "child_a_id", having: {count: 1}) { count } }`,
+			expected: []interface{}{
+				map[string]interface{}{"count": 1},
+			},
+		},
+		{
+			desc:  "having without selecting count",
+			query: `{ grandchild_a_aggregate(group_by: ["name"], having: {count_lte: 1}) { name } }`,
+			expected: []interface{}{
+				map[string]interface{}{"name": "first_grandchild"},
+				map[string]interface{}{"name": "second_grandchild"},
+			},
+		},
+		{
+			desc:    "field not in group_by",
+			query:   `{ grandchild_a_aggregate(group_by: "child_a_id") { name count } }`,
+			wantErr: true,
+		},
+		{
+			desc:    "unknown group_by field",
+			query:   `{ grandchild_a_aggregate(group_by: "unknown") { count } }`,
+			wantErr: true,
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.desc, func(t *testing.T) {
+			result, err := s.Query(DefaultTenantName, tc.query)
+			require.NoError(t, err)
+			if tc.wantErr {
+				assert.NotEmpty(t, result.Errors)
+				return
+			}
+			require.Empty(t, result.Errors)
+			assert.Equal(t, tc.expected, result.Data.(map[string]interface{})["grandchild_a_aggregate"])
+		})
+	}
+}
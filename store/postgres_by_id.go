@@ -0,0 +1,48 @@
+package store
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+// psqlResolveByIDQuery resolves a root graphql query for a single row of a
+// table, given its ID, such as:
+//
+//	location_by_id(_id: "1") { name }
+//
+// The query is resolved like the query of the table with the same _id
+// argument, and returns the row or nil if there is no row with that ID.
+// If explain is not nil, the SQL query is added to it
+func psqlResolveByIDQuery(pool *pgxpool.Pool, tenant string, graph *SchemaGraph, field *ast.Field, opts queryOptions, explain *queryExplain) (interface{}, error) {
+	table := strings.TrimSuffix(field.Name.Value, byIDSuffix)
+	if _, ok := graph.NodeIndex[table]; !ok {
+		return nil, fmt.Errorf("unknown table for by ID query: %s", table)
+	}
+	var hasID bool
+	for _, arg := range field.Arguments {
+		if arg.Name.Value != tableIDField {
+			return nil, fmt.Errorf("unknown argument identifier for table %s: %s", field.Name.Value, arg.Name.Value)
+		}
+		hasID = true
+	}
+	if !hasID {
+		return nil, fmt.Errorf("missing '%s' argument for %s", tableIDField, field.Name.Value)
+	}
+
+	// Query the table with the same arguments and selections, which are
+	// valid for the table because the only argument is the ID
+	tableField := *field
+	tableField.Name = ast.NewName(&ast.Name{Value: table})
+	result, err := psqlResolveRootQuery(pool, tenant, graph, &tableField, opts, explain)
+	if err != nil {
+		return nil, err
+	}
+	rows, _ := result.([]map[string]interface{})
+	if len(rows) == 0 {
+		return nil, nil
+	}
+	return rows[0], nil
+}
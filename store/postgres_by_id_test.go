@@ -0,0 +1,70 @@
+package store
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/valocode/bubbly/env"
+	"github.com/valocode/bubbly/test"
+)
+
+func TestByIDQuery(t *testing.T) {
+	bCtx := env.NewBubblyContext()
+	resource := test.RunPostgresDocker(bCtx, t)
+	bCtx.StoreConfig.PostgresAddr = fmt.Sprintf("localhost:%s", resource.GetPort("5432/tcp"))
+
+	s, err := New(bCtx)
+	require.NoError(t, err)
+	applySchemaOrDie(t, bCtx, s, filepath.FromSlash("testdata/tables.hcl"))
+	loadTestDataOrDie(t, bCtx, s, filepath.FromSlash("testdata/data.hcl"))
+
+	// Get the ID of an existing row to query it by
+	result, err := s.Query(DefaultTenantName, `{ grandchild_a(filter: {name_eq: "first_grandchild"}) { _id } }`)
+	require.NoError(t, err)
+	require.Empty(t, result.Errors)
+	rows := result.Data.(map[string]interface{})["grandchild_a"].([]interface{})
+	require.Len(t, rows, 1)
+	id := rows[0].(map[string]interface{})[tableIDField]
+
+	tcs := []struct {
+		desc     string
+		query    string
+		expected interface{}
+		wantErr  bool
+	}{
+		{
+			desc:  "existing id",
+			query: fmt.Sprintf(`{ grandchild_a_by_id(_id: "%v") { name child_a { name } } }`, id),
+			expected: map[string]interface{}{
+				"name":    "first_grandchild",
+				"child_a": map[string]interface{}{"name": "first_child"},
+			},
+		},
+		{
+			desc:     "missing id",
+			query:    `{ grandchild_a_by_id(_id: "0") { name } }`,
+			expected: nil,
+		},
+		{
+			desc:    "missing id argument",
+			query:   `{ grandchild_a_by_id { name } }`,
+			wantErr: true,
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.desc, func(t *testing.T) {
+			result, err := s.Query(DefaultTenantName, tc.query)
+			require.NoError(t, err)
+			if tc.wantErr {
+				assert.NotEmpty(t, result.Errors)
+				return
+			}
+			require.Empty(t, result.Errors)
+			assert.Equal(t, tc.expected, result.Data.(map[string]interface{})["grandchild_a_by_id"])
+		})
+	}
+}
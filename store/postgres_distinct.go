@@ -0,0 +1,116 @@
+package store
+
+import (
+	"fmt"
+	"strings"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+// psqlResolveDistinctQuery resolves a root graphql query for the distinct
+// values of a column of a table, such as:
+//
+//	location_distinct(column: name, filter: {_id_gt: "1"})
+//
+// The values are ordered, and the filter argument filters the rows of the
+// table before the distinct values are taken.
+// If explain is not nil, the SQL query is added to it
+func psqlResolveDistinctQuery(pool *pgxpool.Pool, tenant string, graph *SchemaGraph, field *ast.Field, opts queryOptions, explain *queryExplain) (interface{}, error) {
+	var (
+		table  = strings.TrimSuffix(field.Name.Value, distinctSuffix)
+		alias  = tableAlias(table, 0)
+		sql    = sq.Select().Distinct().From(tableAsAlias(psqlAbsTableName(tenant, table), alias))
+		column string
+	)
+	node, ok := graph.NodeIndex[table]
+	if !ok {
+		return nil, fmt.Errorf("unknown table for distinct query: %s", table)
+	}
+
+	for _, arg := range field.Arguments {
+		switch arg.Name.Value {
+		case distinctColumnID:
+			column = fmt.Sprint(arg.Value.GetValue())
+			if !tableHasColumn(*node.Table, column) {
+				return nil, fmt.Errorf("unknown column for distinct query of table %s: %s", table, column)
+			}
+		case filterID:
+			cond, err := psqlFilter(tenant, node, alias, arg.Value, opts)
+			if err != nil {
+				return nil, err
+			}
+			sql = sql.Where(cond)
+		default:
+			return nil, fmt.Errorf("unknown argument identifier for table %s: %s", field.Name.Value, arg.Name.Value)
+		}
+	}
+	if column == "" {
+		return nil, fmt.Errorf("missing '%s' argument for %s", distinctColumnID, field.Name.Value)
+	}
+	sql = sql.Column(tableColumn(alias, column)).OrderBy(tableColumn(alias, column))
+
+	// Only the values of the rows the caller is allowed to see, which are not
+	// soft-deleted, are returned
+	if opts.softDelete {
+		sql = sql.Where(psqlNotDeleted(alias))
+	}
+	if opts.rowFilter != nil {
+		filter, err := opts.rowFilter(table)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get row filter for table %s: %w", table, err)
+		}
+		if len(filter) > 0 {
+			eq, err := psqlRowFilter(*node.Table, alias, filter)
+			if err != nil {
+				return nil, err
+			}
+			sql = sql.Where(eq)
+		}
+	}
+	if limit := opts.limits.tableLimit(); limit > 0 {
+		sql = sql.Limit(limit)
+	}
+
+	sqlStr, sqlArgs, err := sql.ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create sql query: %w", err)
+	}
+	sqlStr, err = sq.Dollar.ReplacePlaceholders(sqlStr)
+	if err != nil {
+		return nil, fmt.Errorf("error replacing the SQL (squirrel) placeholders: %w", err)
+	}
+
+	if explain != nil {
+		if err := explain.add(pool, field.Name.Value, sqlStr, sqlArgs); err != nil {
+			return nil, err
+		}
+	}
+
+	rows, err := pool.Query(opts.ctx, sqlStr, sqlArgs...)
+	if err != nil {
+		if regexErr := psqlRegexError(err); regexErr != nil {
+			return nil, regexErr
+		}
+		return nil, fmt.Errorf("failed to execute SQL query: %s: %w", sqlStr, err)
+	}
+	defer rows.Close()
+
+	// Initialize with an empty slice to avoid returning just null
+	result := make([]interface{}, 0)
+	for rows.Next() {
+		values, err := rows.Values()
+		if err != nil {
+			return nil, fmt.Errorf("failed scanning row values: %w", err)
+		}
+		result = append(result, values[0])
+	}
+	if err := rows.Err(); err != nil {
+		if regexErr := psqlRegexError(err); regexErr != nil {
+			return nil, regexErr
+		}
+		return nil, fmt.Errorf("failed reading the rows: %w", err)
+	}
+	return result, nil
+}
@@ -0,0 +1,82 @@
+package store
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/valocode/bubbly/env"
+	"github.com/valocode/bubbly/test"
+)
+
+func TestDistinctQuery(t *testing.T) {
+	bCtx := env.NewBubblyContext()
+	resource := test.RunPostgresDocker(bCtx, t)
+	bCtx.StoreConfig.PostgresAddr = fmt.Sprintf("localhost:%s", resource.GetPort("5432/tcp"))
+
+	s, err := New(bCtx)
+	require.NoError(t, err)
+	applySchemaOrDie(t, bCtx, s, filepath.FromSlash("testdata/tables.hcl"))
+	loadTestDataOrDie(t, bCtx, s, filepath.FromSlash("testdata/data.hcl"))
+
+	tcs := []struct {
+		desc     string
+		query    string
+		expected []interface{}
+		// length is checked instead of expected when the values are IDs
+		length  int
+		wantErr bool
+	}{
+		{
+			desc:     "field",
+			query:    `{ grandchild_a_distinct(column: name) }`,
+			expected: []interface{}{"first_grandchild", "second_grandchild"},
+		},
+		{
+			desc:     "field with filter",
+			query:    `{ grandchild_a_distinct(column: name, filter: {name_in: ["second_grandchild", "other"]}) }`,
+			expected: []interface{}{"second_grandchild"},
+		},
+		{
+			desc:     "filter matches nothing",
+			query:    `{ grandchild_a_distinct(column: name, filter: {name_not_in: ["first_grandchild", "second_grandchild"]}) }`,
+			expected: []interface{}{},
+		},
+		{
+			// Both grandchildren belong to the first child_a
+			desc:   "join field",
+			query:  `{ grandchild_a_distinct(column: child_a_id) }`,
+			length: 1,
+		},
+		{
+			desc:    "unknown column",
+			query:   `{ grandchild_a_distinct(column: unknown) }`,
+			wantErr: true,
+		},
+		{
+			desc:    "missing column",
+			query:   `{ grandchild_a_distinct }`,
+			wantErr: true,
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.desc, func(t *testing.T) {
+			result, err := s.Query(DefaultTenantName, tc.query)
+			require.NoError(t, err)
+			if tc.wantErr {
+				assert.NotEmpty(t, result.Errors)
+				return
+			}
+			require.Empty(t, result.Errors)
+			values := result.Data.(map[string]interface{})["grandchild_a_distinct"]
+			if tc.length > 0 {
+				assert.Len(t, values, tc.length)
+				return
+			}
+			assert.Equal(t, tc.expected, values)
+		})
+	}
+}
@@ -0,0 +1,369 @@
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/jackc/pgconn"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
+	"github.com/zclconf/go-cty/cty/gocty"
+
+	"github.com/valocode/bubbly/api/core"
+)
+
+// psqlFilter returns the SQL condition for the filter argument of a table in
+// a graphql query, such as:
+//
+//	location(filter: {_id_gt: 1, name_in: ["a", "b"]}) {...}
+//
+// The fields of the filter are the columns of the table, including "_id" and
+// its join fields, with one of the filter operators as suffix. All the
+// conditions must be true for a row to be returned.
+// Comparisons follow the three-valued logic of SQL, i.e. a null value is
+// neither equal nor not equal to any value, so that e.g. {ok_eq: false} and
+// {ok_not_in: [true]} do not return the rows where ok is null. Those rows are
+// filtered with the _is_null operator, e.g. {ok_is_null: true}.
+// The rows can also be filtered on whether a related table has rows that
+// match a filter, see psqlRelationFilter, and list fields on the values they
+// contain, see psqlListFilter. String fields can be filtered on whether they
+// match a regular expression, see psqlRegexFilter.
+// The values are converted to the type of their column, so that a number can
+// be given as a string, e.g. for _id, and an error is returned if a value
+// cannot be converted
+func psqlFilter(tenant string, node *SchemaNode, alias string, value ast.Value, opts queryOptions) (sq.And, error) {
+	table := *node.Table
+	filter, ok := value.(*ast.ObjectValue)
+	if !ok {
+		return nil, fmt.Errorf("invalid format for '%s' argument of table %s", filterID, table.Name)
+	}
+	and := make(sq.And, 0, len(filter.Fields))
+	for _, f := range filter.Fields {
+		if edge, exists, ok := splitRelationFilter(node, f.Name.Value); ok {
+			cond, err := psqlRelationFilter(tenant, node, alias, edge, exists, f.Value, opts)
+			if err != nil {
+				return nil, err
+			}
+			and = append(and, cond)
+			continue
+		}
+		column, op := splitFilterOp(f.Name.Value)
+		if op == "" || !tableHasColumn(table, column) {
+			return nil, fmt.Errorf("unknown field in '%s' for table %s: %s", filterID, table.Name, f.Name.Value)
+		}
+		var (
+			name = tableColumn(alias, column)
+			val  interface{}
+		)
+		if field, ok := tableField(table, column); ok && isListType(field.Type) && op != filterIsNull {
+			cond, err := psqlListFilter(field, name, op, f.Value)
+			if err != nil {
+				return nil, fmt.Errorf("the value of %s in '%s' for table %s: %w", f.Name.Value, filterID, table.Name, err)
+			}
+			and = append(and, cond)
+			continue
+		}
+		switch op {
+		case filterContains, filterOverlaps:
+			return nil, fmt.Errorf("the filter %s in '%s' for table %s is only supported for list fields", f.Name.Value, filterID, table.Name)
+		case filterRegex, filterIRegex:
+			cond, err := psqlRegexFilter(table, column, name, op, f.Value)
+			if err != nil {
+				return nil, fmt.Errorf("the value of %s in '%s' for table %s: %w", f.Name.Value, filterID, table.Name, err)
+			}
+			and = append(and, cond)
+			continue
+		case filterIsNull:
+			isNull, ok := f.Value.GetValue().(bool)
+			if !ok {
+				return nil, fmt.Errorf("the value of %s in '%s' for table %s must be a boolean", f.Name.Value, filterID, table.Name)
+			}
+			if isNull {
+				and = append(and, sq.Eq{name: nil})
+			} else {
+				and = append(and, sq.NotEq{name: nil})
+			}
+			continue
+		case filterIn, filterNotIn:
+			list, ok := f.Value.(*ast.ListValue)
+			if !ok {
+				return nil, fmt.Errorf("the value of %s in '%s' for table %s must be a list", f.Name.Value, filterID, table.Name)
+			}
+			vals := make([]interface{}, 0, len(list.Values))
+			for _, v := range list.Values {
+				colVal, err := psqlFilterValue(table, column, v.GetValue())
+				if err != nil {
+					return nil, fmt.Errorf("the value of %s in '%s' for table %s: %w", f.Name.Value, filterID, table.Name, err)
+				}
+				vals = append(vals, colVal)
+			}
+			val = vals
+		default:
+			colVal, err := psqlFilterValue(table, column, f.Value.GetValue())
+			if err != nil {
+				return nil, fmt.Errorf("the value of %s in '%s' for table %s: %w", f.Name.Value, filterID, table.Name, err)
+			}
+			val = colVal
+		}
+		switch op {
+		case filterEqual:
+			and = append(and, sq.Eq{name: val})
+		case filterGreaterThan:
+			and = append(and, sq.Gt{name: val})
+		case filterLessThan:
+			and = append(and, sq.Lt{name: val})
+		case filterGreaterThanOrEqualTo:
+			and = append(and, sq.GtOrEq{name: val})
+		case filterLessThanOrEqualTo:
+			and = append(and, sq.LtOrEq{name: val})
+		case filterIn:
+			and = append(and, sq.Eq{name: val})
+		case filterNotIn:
+			and = append(and, sq.NotEq{name: val})
+		}
+	}
+	return and, nil
+}
+
+// psqlRelationFilter returns the condition for a filter on the rows of a
+// related table, which is a parent or child of the table in the schema.
+// The filter is the name of the related table with the suffix "_exists" or
+// "_not_exists", and its value is a filter for the related table, such as:
+//
+//	test_run(filter: {test_case_not_exists: {result_eq: false}}) {...}
+//
+// which returns the test runs without a failing test case, or:
+//
+//	test_run(filter: {test_case_exists: {}}) {...}
+//
+// which returns the test runs with at least one test case. The condition is an
+// EXISTS or NOT EXISTS subquery, which only considers the rows of the related
+// table that the caller is allowed to see
+func psqlRelationFilter(tenant string, node *SchemaNode, alias string, edge *SchemaEdge, exists bool, value ast.Value, opts queryOptions) (sq.Sqlizer, error) {
+	var (
+		related      = edge.Node.Table.Name
+		relatedAlias = alias + "_" + related
+		sub          = sq.Select("1").From(tableAsAlias(psqlAbsTableName(tenant, related), relatedAlias))
+	)
+	if edge.Rel == BelongsTo {
+		sub = sub.Where(tableColumn(relatedAlias, tableIDField) + " = " + tableColumn(alias, foreignKeyField(related)))
+	} else {
+		sub = sub.Where(tableColumn(relatedAlias, foreignKeyField(node.Table.Name)) + " = " + tableColumn(alias, tableIDField))
+	}
+	cond, err := psqlFilter(tenant, edge.Node, relatedAlias, value, opts)
+	if err != nil {
+		return nil, err
+	}
+	if len(cond) > 0 {
+		sub = sub.Where(cond)
+	}
+	if opts.softDelete {
+		sub = sub.Where(psqlNotDeleted(relatedAlias))
+	}
+	if opts.rowFilter != nil {
+		filter, err := opts.rowFilter(related)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get row filter for table %s: %w", related, err)
+		}
+		if len(filter) > 0 {
+			eq, err := psqlRowFilter(*edge.Node.Table, relatedAlias, filter)
+			if err != nil {
+				return nil, err
+			}
+			sub = sub.Where(eq)
+		}
+	}
+	sqlStr, args, err := sub.ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create SQL for the filter on table %s: %w", related, err)
+	}
+	op := "EXISTS"
+	if !exists {
+		op = "NOT EXISTS"
+	}
+	return sq.Expr(op+" ("+sqlStr+")", args...), nil
+}
+
+// psqlListFilter returns the condition for a filter on a list field, which is
+// stored as a JSONB array. The value is a list, such as:
+//
+//	test_run(filter: {tags_contains: ["unit", "linux"]}) {...}
+//
+// which returns the rows whose list contains all of the values, or:
+//
+//	test_run(filter: {tags_overlaps: ["unit", "e2e"]}) {...}
+//
+// which returns the rows whose list contains any of the values
+func psqlListFilter(field core.TableField, name string, op string, value ast.Value) (sq.Sqlizer, error) {
+	if op != filterContains && op != filterOverlaps {
+		return nil, fmt.Errorf("list fields can only be filtered with %s, %s and %s", filterContains, filterOverlaps, filterIsNull)
+	}
+	list, ok := value.(*ast.ListValue)
+	if !ok {
+		return nil, fmt.Errorf("must be a list")
+	}
+	var (
+		elemType = field.Type.ElementType()
+		vals     = make([]interface{}, 0, len(list.Values))
+	)
+	for _, v := range list.Values {
+		// Values that are not primitive, e.g. objects, are compared as they
+		// are given
+		if !elemType.IsPrimitiveType() {
+			vals = append(vals, parseValueToMap(v))
+			continue
+		}
+		val, err := psqlConvertFilterValue(elemType, v.GetValue())
+		if err != nil {
+			return nil, err
+		}
+		vals = append(vals, val)
+	}
+
+	if op == filterContains {
+		b, err := json.Marshal(vals)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal values: %w", err)
+		}
+		return sq.Expr(name+" @> ?::JSONB", string(b)), nil
+	}
+	// A list overlaps the values if it contains any one of them
+	if len(vals) == 0 {
+		return sq.Expr("FALSE"), nil
+	}
+	or := make(sq.Or, 0, len(vals))
+	for _, val := range vals {
+		b, err := json.Marshal([]interface{}{val})
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal value: %w", err)
+		}
+		or = append(or, sq.Expr(name+" @> ?::JSONB", string(b)))
+	}
+	return or, nil
+}
+
+// splitRelationFilter returns the edge to the related table of a filter on
+// the rows of a related table, and whether the filter is for the existence of
+// the rows. It returns false if the name is not a filter on a related table
+func splitRelationFilter(node *SchemaNode, name string) (*SchemaEdge, bool, bool) {
+	// "_not_exists" is checked first, as it also ends with "_exists"
+	for _, op := range []string{filterNotExists, filterExists} {
+		if !strings.HasSuffix(name, op) {
+			continue
+		}
+		edge, err := node.Edge(strings.TrimSuffix(name, op))
+		if err != nil {
+			return nil, false, false
+		}
+		return edge, op == filterExists, true
+	}
+	return nil, false, false
+}
+
+// psqlRegexFilter returns the condition for a filter on whether a string
+// field matches a regular expression, such as:
+//
+//	test_case(filter: {name_regex: "^Test[A-Z]"}) {...}
+//
+// which uses the ~ operator of Postgres, or ~* for the _iregex operator, which
+// ignores case. The regular expression is in the syntax of Postgres, so it is
+// only checked by the database, and psqlRegexError turns the error of an
+// invalid one into a clear error
+func psqlRegexFilter(table core.Table, column string, name string, op string, value ast.Value) (sq.Sqlizer, error) {
+	if field, ok := tableField(table, column); !ok || field.Type != cty.String {
+		return nil, fmt.Errorf("%s is only supported for string fields", op)
+	}
+	// The graphql values of numbers are strings too, so the kind of the value
+	// is checked instead of the type of its Go value
+	str, ok := value.(*ast.StringValue)
+	if !ok {
+		return nil, errors.New("must be a string")
+	}
+	sqlOp := "~"
+	if op == filterIRegex {
+		sqlOp = "~*"
+	}
+	return sq.Expr(name+" "+sqlOp+" ?", str.Value), nil
+}
+
+// psqlInvalidRegex is the SQLSTATE of the error of Postgres for an invalid
+// regular expression
+const psqlInvalidRegex = "2201B"
+
+// psqlRegexError returns the error to report for a query that failed with err
+// because of the invalid regular expression of a _regex or _iregex filter, or
+// nil if that is not why the query failed
+func psqlRegexError(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == psqlInvalidRegex {
+		return fmt.Errorf("invalid filter: %s", pgErr.Message)
+	}
+	return nil
+}
+
+// splitFilterOp splits the name of a field in the filter argument into the
+// column and the filter operator. The operator is empty if the name has no
+// known operator as suffix
+func splitFilterOp(name string) (string, string) {
+	// The list filters are checked first, as "_not_in" also ends with "_in"
+	for _, ops := range [][]string{{filterNotIn, filterIn, filterIsNull, filterContains, filterOverlaps, filterIRegex, filterRegex}, scalarFilters} {
+		for _, op := range ops {
+			if strings.HasSuffix(name, op) {
+				return strings.TrimSuffix(name, op), op
+			}
+		}
+	}
+	return name, ""
+}
+
+// psqlFilterValue converts the value of a field in the filter argument to the
+// type of the column. The graphql values of numbers are strings, and the _id
+// and join columns are strings in the graphql schema, so a value can be a
+// string for a numeric column, or a number for a string column
+func psqlFilterValue(table core.Table, column string, val interface{}) (interface{}, error) {
+	// The _id and join columns are integers
+	ty := cty.Number
+	if field, ok := tableField(table, column); ok {
+		ty = field.Type
+	}
+	return psqlConvertFilterValue(ty, val)
+}
+
+// psqlConvertFilterValue converts the value of a field in the filter argument
+// to the type ty, like psqlFilterValue
+func psqlConvertFilterValue(ty cty.Type, val interface{}) (interface{}, error) {
+	// Objects and maps are given as they are
+	if !ty.IsPrimitiveType() {
+		return val, nil
+	}
+	var ctyVal cty.Value
+	switch v := val.(type) {
+	case string:
+		ctyVal = cty.StringVal(v)
+	case bool:
+		ctyVal = cty.BoolVal(v)
+	default:
+		return val, nil
+	}
+	ctyVal, err := convert.Convert(ctyVal, ty)
+	if err != nil {
+		return nil, fmt.Errorf("cannot convert %#v to %s", val, ty.FriendlyName())
+	}
+	switch ty {
+	case cty.Number:
+		// Numbers are stored as integers
+		var n int64
+		if err := gocty.FromCtyValue(ctyVal, &n); err != nil {
+			return nil, fmt.Errorf("cannot convert %#v to a whole number", val)
+		}
+		return n, nil
+	case cty.Bool:
+		return ctyVal.True(), nil
+	default:
+		return ctyVal.AsString(), nil
+	}
+}
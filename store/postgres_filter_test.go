@@ -0,0 +1,627 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/jackc/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/valocode/bubbly/api/core"
+	"github.com/valocode/bubbly/env"
+	"github.com/valocode/bubbly/test"
+)
+
+func TestPsqlFilter(t *testing.T) {
+	graph, err := NewSchemaGraph(core.Tables{
+		{
+			Name:   "j",
+			Fields: []core.TableField{{Name: "f1", Type: cty.String}},
+		},
+		{
+			Name: "t",
+			Fields: []core.TableField{
+				{Name: "f1", Type: cty.String},
+				{Name: "n", Type: cty.Number},
+				{Name: "b", Type: cty.Bool},
+				{Name: "tags", Type: cty.List(cty.String)},
+				{Name: "ns", Type: cty.Set(cty.Number)},
+			},
+			Joins: []core.TableJoin{{Table: "j"}},
+		},
+		{
+			Name:   "c",
+			Fields: []core.TableField{{Name: "ok", Type: cty.Bool}},
+			Joins:  []core.TableJoin{{Table: "t"}},
+		},
+	})
+	require.NoError(t, err)
+	tcs := []struct {
+		desc    string
+		filter  string
+		sql     string
+		args    []interface{}
+		wantErr bool
+	}{
+		{
+			desc:   "id",
+			filter: `{_id_gt: "1", _id_lte: "3"}`,
+			sql:    "(t_0._id > ? AND t_0._id <= ?)",
+			args:   []interface{}{int64(1), int64(3)},
+		},
+		{
+			desc:   "id list",
+			filter: `{_id_in: ["1", "2"]}`,
+			sql:    "(t_0._id IN (?,?))",
+			args:   []interface{}{int64(1), int64(2)},
+		},
+		{
+			desc:   "field and join",
+			filter: `{f1_not_in: ["a"], j_id_gte: "2"}`,
+			sql:    "(t_0.f1 NOT IN (?) AND t_0.j_id >= ?)",
+			args:   []interface{}{"a", int64(2)},
+		},
+		{
+			desc:   "number as string",
+			filter: `{n_gt: "10", n_in: ["1", "2"]}`,
+			sql:    "(t_0.n > ? AND t_0.n IN (?,?))",
+			args:   []interface{}{int64(10), int64(1), int64(2)},
+		},
+		{
+			desc:   "string as number",
+			filter: `{f1_in: [1, 2.5]}`,
+			sql:    "(t_0.f1 IN (?,?))",
+			args:   []interface{}{"1", "2.5"},
+		},
+		{
+			desc:   "bool as string",
+			filter: `{b_in: ["true", false]}`,
+			sql:    "(t_0.b IN (?,?))",
+			args:   []interface{}{true, false},
+		},
+		{
+			desc:   "bool equal",
+			filter: `{b_eq: false}`,
+			sql:    "(t_0.b = ?)",
+			args:   []interface{}{false},
+		},
+		{
+			desc:   "is null",
+			filter: `{b_is_null: true, f1_is_null: false}`,
+			sql:    "(t_0.b IS NULL AND t_0.f1 IS NOT NULL)",
+		},
+		{
+			desc:   "join is null",
+			filter: `{j_id_is_null: true}`,
+			sql:    "(t_0.j_id IS NULL)",
+		},
+		{
+			desc:    "is null not a bool",
+			filter:  `{b_is_null: "true"}`,
+			wantErr: true,
+		},
+		{
+			desc:   "child exists",
+			filter: `{c_exists: {}}`,
+			sql:    "(EXISTS (SELECT 1 FROM bb_default.c AS t_0_c WHERE t_0_c.t_id = t_0._id))",
+		},
+		{
+			desc:   "child not exists with filter",
+			filter: `{c_not_exists: {ok_eq: false}}`,
+			sql:    "(NOT EXISTS (SELECT 1 FROM bb_default.c AS t_0_c WHERE t_0_c.t_id = t_0._id AND (t_0_c.ok = ?)))",
+			args:   []interface{}{false},
+		},
+		{
+			desc:   "parent exists with filter",
+			filter: `{f1_in: ["a"], j_exists: {f1_eq: "b"}}`,
+			sql:    "(t_0.f1 IN (?) AND EXISTS (SELECT 1 FROM bb_default.j AS t_0_j WHERE t_0_j._id = t_0.j_id AND (t_0_j.f1 = ?)))",
+			args:   []interface{}{"a", "b"},
+		},
+		{
+			desc:    "unknown relation",
+			filter:  `{x_exists: {}}`,
+			wantErr: true,
+		},
+		{
+			desc:    "unknown field in relation",
+			filter:  `{c_exists: {f2_eq: "a"}}`,
+			wantErr: true,
+		},
+		{
+			desc:    "id not a number",
+			filter:  `{_id_gt: "a"}`,
+			wantErr: true,
+		},
+		{
+			desc:    "number not a number",
+			filter:  `{n_in: ["1", "a"]}`,
+			wantErr: true,
+		},
+		{
+			desc:    "number not whole",
+			filter:  `{n_lt: "1.5"}`,
+			wantErr: true,
+		},
+		{
+			desc:    "bool not a bool",
+			filter:  `{b_in: ["yes"]}`,
+			wantErr: true,
+		},
+		{
+			desc:    "unknown column",
+			filter:  `{f2_gt: "a"}`,
+			wantErr: true,
+		},
+		{
+			desc:    "no operator",
+			filter:  `{f1: "a"}`,
+			wantErr: true,
+		},
+		{
+			desc:    "list operator without list",
+			filter:  `{f1_in: "a"}`,
+			wantErr: true,
+		},
+		{
+			desc:   "list contains",
+			filter: `{tags_contains: ["a", "b"]}`,
+			sql:    "(t_0.tags @> ?::JSONB)",
+			args:   []interface{}{`["a","b"]`},
+		},
+		{
+			desc:   "list overlaps",
+			filter: `{tags_overlaps: ["a", "b"]}`,
+			sql:    "((t_0.tags @> ?::JSONB OR t_0.tags @> ?::JSONB))",
+			args:   []interface{}{`["a"]`, `["b"]`},
+		},
+		{
+			desc:   "list overlaps nothing",
+			filter: `{tags_overlaps: []}`,
+			sql:    "(FALSE)",
+		},
+		{
+			desc:   "set of numbers",
+			filter: `{ns_contains: ["1", 2]}`,
+			sql:    "(t_0.ns @> ?::JSONB)",
+			args:   []interface{}{`[1,2]`},
+		},
+		{
+			desc:   "list is null",
+			filter: `{tags_is_null: true}`,
+			sql:    "(t_0.tags IS NULL)",
+		},
+		{
+			desc:    "list contains not a list",
+			filter:  `{tags_contains: "a"}`,
+			wantErr: true,
+		},
+		{
+			desc:    "list element not a number",
+			filter:  `{ns_overlaps: ["a"]}`,
+			wantErr: true,
+		},
+		{
+			desc:    "scalar operator on list",
+			filter:  `{tags_eq: "a"}`,
+			wantErr: true,
+		},
+		{
+			desc:    "list operator on scalar",
+			filter:  `{f1_contains: ["a"]}`,
+			wantErr: true,
+		},
+		{
+			desc:   "regex",
+			filter: `{f1_regex: "^a.*z$"}`,
+			sql:    "(t_0.f1 ~ ?)",
+			args:   []interface{}{"^a.*z$"},
+		},
+		{
+			desc:   "regex ignoring case",
+			filter: `{f1_iregex: "^a", n_eq: 1}`,
+			sql:    "(t_0.f1 ~* ? AND t_0.n = ?)",
+			args:   []interface{}{"^a", int64(1)},
+		},
+		{
+			desc:   "regex with postgres syntax",
+			filter: `{f1_regex: "^(a)\\1(?=b)"}`,
+			sql:    "(t_0.f1 ~ ?)",
+			args:   []interface{}{`^(a)\1(?=b)`},
+		},
+		{
+			desc:    "regex not a string",
+			filter:  `{f1_regex: 1}`,
+			wantErr: true,
+		},
+		{
+			desc:    "regex a float",
+			filter:  `{f1_regex: 1.5}`,
+			wantErr: true,
+		},
+		{
+			desc:    "regex on number",
+			filter:  `{n_regex: "1"}`,
+			wantErr: true,
+		},
+		{
+			desc:    "regex on list",
+			filter:  `{tags_regex: "a"}`,
+			wantErr: true,
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.desc, func(t *testing.T) {
+			doc, err := parser.Parse(parser.ParseParams{Source: "{ t(filter: " + tc.filter + ") { f1 } }"})
+			require.NoError(t, err)
+			field := doc.Definitions[0].(*ast.OperationDefinition).SelectionSet.Selections[0].(*ast.Field)
+
+			cond, err := psqlFilter(DefaultTenantName, graph.NodeIndex["t"], "t_0", field.Arguments[0].Value, queryOptions{})
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			sql, args, err := sq.And(cond).ToSql()
+			require.NoError(t, err)
+			assert.Equal(t, tc.sql, sql)
+			assert.Equal(t, tc.args, args)
+		})
+	}
+}
+
+// TestRegexError tests that the error of Postgres for an invalid regular
+// expression is reported as such, and that other errors are not
+func TestRegexError(t *testing.T) {
+	err := fmt.Errorf("query failed: %w", &pgconn.PgError{
+		Code:    psqlInvalidRegex,
+		Message: "invalid regular expression: brackets [] not balanced",
+	})
+	regexErr := psqlRegexError(err)
+	require.Error(t, regexErr)
+	assert.Equal(t, "invalid filter: invalid regular expression: brackets [] not balanced", regexErr.Error())
+
+	assert.NoError(t, psqlRegexError(&pgconn.PgError{Code: "42P01"}))
+	assert.NoError(t, psqlRegexError(errors.New("connection refused")))
+}
+
+// TestBoolFilter tests the filters on a boolean column with null values,
+// which only the _is_null filter returns
+func TestBoolFilter(t *testing.T) {
+	bCtx := env.NewBubblyContext()
+	resource := test.RunPostgresDocker(bCtx, t)
+	bCtx.StoreConfig.PostgresAddr = fmt.Sprintf("localhost:%s", resource.GetPort("5432/tcp"))
+
+	s, err := New(bCtx)
+	require.NoError(t, err)
+	err = s.Apply(DefaultTenantName, core.Tables{
+		{
+			Name: "animal",
+			Fields: []core.TableField{
+				{Name: "name", Type: cty.String, Unique: true},
+				{Name: "happy", Type: cty.Bool},
+			},
+		},
+	}, false)
+	require.NoError(t, err)
+
+	animals := []struct {
+		name  string
+		happy cty.Value
+	}{
+		{name: "cat", happy: cty.True},
+		{name: "dog", happy: cty.False},
+		{name: "fish", happy: cty.NilVal},
+	}
+	var data core.DataBlocks
+	for _, a := range animals {
+		values := map[string]cty.Value{"name": cty.StringVal(a.name)}
+		// The happy field is null if it is not given
+		if a.happy != cty.NilVal {
+			values["happy"] = a.happy
+		}
+		data = append(data, core.Data{
+			TableName: "animal",
+			Fields:    &core.DataFields{Values: values},
+		})
+	}
+	require.NoError(t, s.Save(DefaultTenantName, data))
+
+	tcs := []struct {
+		filter   string
+		expected []string
+	}{
+		{filter: `{happy_eq: true}`, expected: []string{"cat"}},
+		{filter: `{happy_eq: false}`, expected: []string{"dog"}},
+		{filter: `{happy_in: [true, false]}`, expected: []string{"cat", "dog"}},
+		{filter: `{happy_not_in: [true]}`, expected: []string{"dog"}},
+		{filter: `{happy_is_null: true}`, expected: []string{"fish"}},
+		{filter: `{happy_is_null: false}`, expected: []string{"cat", "dog"}},
+		{filter: `{happy_is_null: false, happy_eq: true}`, expected: []string{"cat"}},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.filter, func(t *testing.T) {
+			result, err := s.Query(DefaultTenantName, `{ animal(filter: `+tc.filter+`, order_by: {name: asc}) { name } }`)
+			require.NoError(t, err)
+			require.Empty(t, result.Errors)
+			var names []string
+			for _, a := range result.Data.(map[string]interface{})["animal"].([]interface{}) {
+				names = append(names, a.(map[string]interface{})["name"].(string))
+			}
+			assert.Equal(t, tc.expected, names)
+		})
+	}
+}
+
+// TestRegexFilter tests the filters on whether a string column matches a
+// regular expression in the syntax of Postgres, and that an invalid one fails
+// with a clear error
+func TestRegexFilter(t *testing.T) {
+	bCtx := env.NewBubblyContext()
+	resource := test.RunPostgresDocker(bCtx, t)
+	bCtx.StoreConfig.PostgresAddr = fmt.Sprintf("localhost:%s", resource.GetPort("5432/tcp"))
+
+	s, err := New(bCtx)
+	require.NoError(t, err)
+	err = s.Apply(DefaultTenantName, core.Tables{
+		{
+			Name: "animal",
+			Fields: []core.TableField{
+				{Name: "name", Type: cty.String, Unique: true},
+			},
+		},
+	}, false)
+	require.NoError(t, err)
+
+	var data core.DataBlocks
+	for _, name := range []string{"Cat", "cow", "dog"} {
+		data = append(data, core.Data{
+			TableName: "animal",
+			Fields: &core.DataFields{Values: map[string]cty.Value{
+				"name": cty.StringVal(name),
+			}},
+		})
+	}
+	require.NoError(t, s.Save(DefaultTenantName, data))
+
+	tcs := []struct {
+		filter   string
+		expected []string
+	}{
+		{filter: `{name_regex: "^c"}`, expected: []string{"cow"}},
+		{filter: `{name_iregex: "^c"}`, expected: []string{"Cat", "cow"}},
+		{filter: `{name_regex: "o[gw]$"}`, expected: []string{"cow", "dog"}},
+		{filter: `{name_regex: "^x"}`, expected: nil},
+		// Lookahead is in the syntax of Postgres, but not of Go
+		{filter: `{name_regex: "^c(?=o)"}`, expected: []string{"cow"}},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.filter, func(t *testing.T) {
+			result, err := s.Query(DefaultTenantName, `{ animal(filter: `+tc.filter+`, order_by: {name: asc}) { name } }`)
+			require.NoError(t, err)
+			require.Empty(t, result.Errors)
+			var names []string
+			for _, a := range result.Data.(map[string]interface{})["animal"].([]interface{}) {
+				names = append(names, a.(map[string]interface{})["name"].(string))
+			}
+			assert.Equal(t, tc.expected, names)
+		})
+	}
+
+	t.Run("invalid regex", func(t *testing.T) {
+		result, err := s.Query(DefaultTenantName, `{ animal(filter: {name_regex: "[a-"}) { name } }`)
+		require.NoError(t, err)
+		require.NotEmpty(t, result.Errors)
+		assert.Contains(t, result.Errors[0].Message, "invalid regular expression")
+	})
+}
+
+// TestRelationFilter tests filtering on whether a related table has rows that
+// match a filter
+func TestRelationFilter(t *testing.T) {
+	bCtx := env.NewBubblyContext()
+	resource := test.RunPostgresDocker(bCtx, t)
+	bCtx.StoreConfig.PostgresAddr = fmt.Sprintf("localhost:%s", resource.GetPort("5432/tcp"))
+
+	s, err := New(bCtx)
+	require.NoError(t, err)
+	err = s.Apply(DefaultTenantName, core.Tables{
+		{
+			Name:   "suite_run",
+			Fields: []core.TableField{{Name: "name", Type: cty.String, Unique: true}},
+			Tables: core.Tables{
+				{
+					Name: "suite_case",
+					Fields: []core.TableField{
+						{Name: "name", Type: cty.String},
+						{Name: "result", Type: cty.Bool},
+					},
+				},
+			},
+		},
+	}, false)
+	require.NoError(t, err)
+
+	// The test runs with their test cases, by the result of each test case
+	runs := []struct {
+		name  string
+		cases map[string]bool
+	}{
+		{name: "passed", cases: map[string]bool{"a": true, "b": true}},
+		{name: "failed", cases: map[string]bool{"a": true, "b": false}},
+		{name: "empty"},
+	}
+	var data core.DataBlocks
+	for _, run := range runs {
+		var cases core.DataBlocks
+		for name, result := range run.cases {
+			cases = append(cases, core.Data{
+				TableName: "suite_case",
+				Fields: &core.DataFields{Values: map[string]cty.Value{
+					"name":   cty.StringVal(name),
+					"result": cty.BoolVal(result),
+				}},
+			})
+		}
+		data = append(data, core.Data{
+			TableName: "suite_run",
+			Fields:    &core.DataFields{Values: map[string]cty.Value{"name": cty.StringVal(run.name)}},
+			Data:      cases,
+		})
+	}
+	require.NoError(t, s.Save(DefaultTenantName, data))
+
+	tcs := []struct {
+		desc     string
+		query    string
+		table    string
+		expected []string
+	}{
+		{
+			desc:     "exists",
+			query:    `{ suite_run(filter: {suite_case_exists: {}}, order_by: {name: asc}) { name } }`,
+			table:    "suite_run",
+			expected: []string{"failed", "passed"},
+		},
+		{
+			desc:     "not exists",
+			query:    `{ suite_run(filter: {suite_case_not_exists: {}}) { name } }`,
+			table:    "suite_run",
+			expected: []string{"empty"},
+		},
+		{
+			desc:     "exists with child filter",
+			query:    `{ suite_run(filter: {suite_case_exists: {result_eq: false}}) { name } }`,
+			table:    "suite_run",
+			expected: []string{"failed"},
+		},
+		{
+			desc:     "not exists with child filter",
+			query:    `{ suite_run(filter: {suite_case_not_exists: {result_eq: false}}, order_by: {name: asc}) { name } }`,
+			table:    "suite_run",
+			expected: []string{"empty", "passed"},
+		},
+		{
+			desc:     "parent exists with filter",
+			query:    `{ suite_case(filter: {suite_run_exists: {name_eq: "failed"}, result_eq: true}) { name } }`,
+			table:    "suite_case",
+			expected: []string{"a"},
+		},
+		{
+			// The test cases of the test runs with a failing test case
+			desc:     "nested",
+			query:    `{ suite_case(filter: {suite_run_exists: {suite_case_exists: {result_eq: false}}}) { name } }`,
+			table:    "suite_case",
+			expected: []string{"a", "b"},
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.desc, func(t *testing.T) {
+			result, err := s.Query(DefaultTenantName, tc.query)
+			require.NoError(t, err)
+			require.Empty(t, result.Errors)
+			var names []string
+			for _, row := range result.Data.(map[string]interface{})[tc.table].([]interface{}) {
+				names = append(names, row.(map[string]interface{})["name"].(string))
+			}
+			assert.ElementsMatch(t, tc.expected, names)
+		})
+	}
+}
+
+// TestListFilter tests saving the values of list fields, and filtering on the
+// values they contain
+func TestListFilter(t *testing.T) {
+	bCtx := env.NewBubblyContext()
+	resource := test.RunPostgresDocker(bCtx, t)
+	bCtx.StoreConfig.PostgresAddr = fmt.Sprintf("localhost:%s", resource.GetPort("5432/tcp"))
+
+	s, err := New(bCtx)
+	require.NoError(t, err)
+	err = s.Apply(DefaultTenantName, core.Tables{
+		{
+			Name: "suite_run",
+			Fields: []core.TableField{
+				{Name: "name", Type: cty.String, Unique: true},
+				{Name: "tags", Type: cty.List(cty.String)},
+				{Name: "shards", Type: cty.Set(cty.Number)},
+			},
+		},
+	}, false)
+	require.NoError(t, err)
+
+	runs := []struct {
+		name   string
+		tags   cty.Value
+		shards cty.Value
+	}{
+		{
+			name:   "unit",
+			tags:   cty.ListVal([]cty.Value{cty.StringVal("fast"), cty.StringVal("linux")}),
+			shards: cty.SetVal([]cty.Value{cty.NumberIntVal(1), cty.NumberIntVal(2)}),
+		},
+		{
+			// The values of a data block are tuples, e.g. tags = ["slow"]
+			name:   "e2e",
+			tags:   cty.TupleVal([]cty.Value{cty.StringVal("slow"), cty.StringVal("linux")}),
+			shards: cty.TupleVal([]cty.Value{cty.NumberIntVal(3)}),
+		},
+		{
+			name:   "lint",
+			tags:   cty.ListValEmpty(cty.String),
+			shards: cty.NullVal(cty.Set(cty.Number)),
+		},
+	}
+	var data core.DataBlocks
+	for _, run := range runs {
+		data = append(data, core.Data{
+			TableName: "suite_run",
+			Fields: &core.DataFields{Values: map[string]cty.Value{
+				"name":   cty.StringVal(run.name),
+				"tags":   run.tags,
+				"shards": run.shards,
+			}},
+		})
+	}
+	require.NoError(t, s.Save(DefaultTenantName, data))
+
+	// The lists are returned as they were saved
+	result, err := s.Query(DefaultTenantName, `{ suite_run(name: "e2e") { tags shards } }`)
+	require.NoError(t, err)
+	require.Empty(t, result.Errors)
+	rows := result.Data.(map[string]interface{})["suite_run"].([]interface{})
+	require.Len(t, rows, 1)
+	assert.Equal(t, []interface{}{"slow", "linux"}, rows[0].(map[string]interface{})["tags"])
+	assert.Equal(t, []interface{}{3}, rows[0].(map[string]interface{})["shards"])
+
+	tcs := []struct {
+		filter   string
+		expected []string
+	}{
+		{filter: `{tags_contains: ["linux"]}`, expected: []string{"e2e", "unit"}},
+		{filter: `{tags_contains: ["linux", "fast"]}`, expected: []string{"unit"}},
+		{filter: `{tags_contains: []}`, expected: []string{"e2e", "lint", "unit"}},
+		{filter: `{tags_overlaps: ["fast", "slow"]}`, expected: []string{"e2e", "unit"}},
+		{filter: `{tags_overlaps: ["windows"]}`, expected: nil},
+		{filter: `{shards_contains: [2]}`, expected: []string{"unit"}},
+		{filter: `{shards_overlaps: ["1", "3"]}`, expected: []string{"e2e", "unit"}},
+		{filter: `{shards_is_null: true}`, expected: []string{"lint"}},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.filter, func(t *testing.T) {
+			result, err := s.Query(DefaultTenantName, `{ suite_run(filter: `+tc.filter+`, order_by: {name: asc}) { name } }`)
+			require.NoError(t, err)
+			require.Empty(t, result.Errors)
+			var names []string
+			for _, r := range result.Data.(map[string]interface{})["suite_run"].([]interface{}) {
+				names = append(names, r.(map[string]interface{})["name"].(string))
+			}
+			assert.Equal(t, tc.expected, names)
+		})
+	}
+}
@@ -11,14 +11,96 @@ import (
 	"github.com/graphql-go/graphql/language/ast"
 	"github.com/jackc/pgx/v4"
 	"github.com/jackc/pgx/v4/pgxpool"
+
+	"github.com/valocode/bubbly/env"
 )
 
 const (
-	orderAsc     string = "ASC"
-	orderDesc    string = "DESC"
-	defaultLimit uint64 = 100
+	orderAsc  string = "ASC"
+	orderDesc string = "DESC"
 )
 
+// queryLimits limits the number of rows that a GraphQL query returns, so that
+// a query cannot load a whole (huge) table into memory
+type queryLimits struct {
+	// defaultLimit is the limit on the results of a table in the query that
+	// has neither a `first` nor a `last` argument
+	defaultLimit uint64
+	// defaultPageSize is the number of results of a table in the query that
+	// has neither a `first` nor a `last` argument, instead of defaultLimit.
+	// A value of 0 means defaultLimit is used
+	defaultPageSize uint64
+	// maxLimit is the maximum value of the `first` and `last` arguments, and
+	// the maximum number of rows that a query can return.
+	// A value of 0 means there is no maximum
+	maxLimit uint64
+	// maxPageSize is the maximum number of results of a table. Larger `first`
+	// and `last` arguments are reduced to it. A value of 0 means there is no
+	// maximum
+	maxPageSize uint64
+	// maxDepth is the maximum depth of the nested tables that are resolved,
+	// where the root tables have depth 0. The deeper tables are left out of
+	// the result. A value of 0 means there is no maximum
+	maxDepth int
+}
+
+// newQueryLimits returns the query limits from the store config
+func newQueryLimits(bCtx *env.BubblyContext) queryLimits {
+	var limits queryLimits
+	if bCtx.StoreConfig.QueryDefaultLimit > 0 {
+		limits.defaultLimit = uint64(bCtx.StoreConfig.QueryDefaultLimit)
+	}
+	if bCtx.StoreConfig.QueryDefaultPageSize > 0 {
+		limits.defaultPageSize = uint64(bCtx.StoreConfig.QueryDefaultPageSize)
+	}
+	if bCtx.StoreConfig.QueryMaxLimit > 0 {
+		limits.maxLimit = uint64(bCtx.StoreConfig.QueryMaxLimit)
+	}
+	if bCtx.StoreConfig.QueryMaxPageSize > 0 {
+		limits.maxPageSize = uint64(bCtx.StoreConfig.QueryMaxPageSize)
+	}
+	if bCtx.StoreConfig.QueryMaxDepth > 0 {
+		limits.maxDepth = bCtx.StoreConfig.QueryMaxDepth
+	}
+	return limits
+}
+
+// tableLimit returns the limit on the results of a table in the query that
+// has neither a `first` nor a `last` argument, which is the default page size
+// or else the default limit, and cannot exceed the maximum page size or the
+// maximum limit
+func (l queryLimits) tableLimit() uint64 {
+	limit := l.defaultLimit
+	if l.defaultPageSize > 0 {
+		limit = l.defaultPageSize
+	}
+	for _, max := range []uint64{l.maxPageSize, l.maxLimit} {
+		if max > 0 && (limit == 0 || limit > max) {
+			limit = max
+		}
+	}
+	return limit
+}
+
+// queryOptions are the options for resolving a single GraphQL query
+type queryOptions struct {
+	// ctx is the context of the query, which cancels its SQL queries when it
+	// is done, e.g. when the caller of the query has stopped waiting for it
+	ctx    context.Context
+	limits queryLimits
+	// rowFilter returns the extra filter on the rows of each table, and is nil
+	// if rows are not filtered
+	rowFilter rowFilterFunc
+	// cursors collects the cursors of the root tables queried with the
+	// `_since` argument, and the tables left out of the result, and is nil if
+	// they are not collected
+	cursors *queryCursors
+	// softDelete is whether the store soft-deletes rows, which are then
+	// excluded from the results unless the table has the `include_deleted`
+	// argument
+	softDelete bool
+}
+
 // tableColumns is used to store the columns that are SELECT'd in a SQl
 // statement, within one single table.
 // This is quite a complex problem because of GraphQL queries have a hierarchy
@@ -30,9 +112,14 @@ type tableColumns struct {
 	alias   string
 	columns []string
 	scalar  bool
+	// path is the path of the table in the query, such as "team.member"
+	path string
 	// The GraphQL Field for this table
 	field    *ast.Field
 	children []*tableColumns
+	// page is the page of rows of a root table that is paginated, and is nil
+	// if the table is not paginated
+	page *pageQuery
 }
 
 // length returns the number of fields in this tableColumns, which includes
@@ -47,13 +134,24 @@ func (t *tableColumns) length() int {
 
 // psqlResolveRootQueries is called for each top-level query and iterates
 // through the fields in that root query and resolves them.
-func psqlResolveRootQueries(pool *pgxpool.Pool, tenant string, graph *SchemaGraph, params graphql.ResolveParams) (interface{}, error) {
+func psqlResolveRootQueries(pool *pgxpool.Pool, tenant string, graph *SchemaGraph, limits queryLimits, hook RowFilterHook, softDelete bool, params graphql.ResolveParams) (interface{}, error) {
 	var (
 		result interface{}
 		err    error
+		opts   = queryOptions{
+			ctx:        params.Context,
+			limits:     limits,
+			rowFilter:  newRowFilterFunc(params.Context, hook),
+			cursors:    queryCursorsFromContext(params.Context),
+			softDelete: softDelete,
+		}
 	)
+	if opts.ctx == nil {
+		opts.ctx = context.Background()
+	}
+	explain := queryExplainFromContext(params.Context)
 	for _, field := range params.Info.FieldASTs {
-		result, err = psqlResolveRootQuery(pool, tenant, graph, field)
+		result, err = psqlResolveRootQuery(pool, tenant, graph, field, opts, explain)
 		if err != nil {
 			return nil, fmt.Errorf("failed to resolve query: %s: %w", field.Name.Value, err)
 		}
@@ -61,42 +159,45 @@ func psqlResolveRootQueries(pool *pgxpool.Pool, tenant string, graph *SchemaGrap
 	return result, err
 }
 
-// psqlResolveRootQuery resolves a single root graphql query
-func psqlResolveRootQuery(pool *pgxpool.Pool, tenant string, graph *SchemaGraph, field *ast.Field) (interface{}, error) {
+// psqlResolveRootQuery resolves a single root graphql query.
+// If explain is not nil, the SQL query is added to it
+func psqlResolveRootQuery(pool *pgxpool.Pool, tenant string, graph *SchemaGraph, field *ast.Field, opts queryOptions, explain *queryExplain) (interface{}, error) {
 	var (
-		result      = make(map[string]interface{})
-		rootTable   = field.Name.Value
-		rootAlias   = tableAlias(rootTable, 0)
-		rootColumns = tableColumns{
-			table:  rootTable,
-			alias:  rootAlias,
-			field:  field,
-			scalar: false,
-		}
-		rootSQL = sq.Select()
+		result    = make(map[string]interface{})
+		rootTable = field.Name.Value
 	)
 
-	// Recursively go through the graphql query and resolve the sub-fields
-	err := psqlSubQuery(tenant, graph, &rootSQL, nil, &rootColumns, 0)
-	if err != nil {
-		return nil, fmt.Errorf("failed to process root query: %s: %w", rootTable, err)
+	// The aggregates and distinct values of a table are queried with
+	// different kinds of queries, and a single row by its ID with the query
+	// of the table
+	if _, ok := graph.NodeIndex[rootTable]; !ok {
+		switch {
+		case strings.HasSuffix(rootTable, aggregateSuffix):
+			return psqlResolveAggregateQuery(pool, tenant, graph, field, opts, explain)
+		case strings.HasSuffix(rootTable, distinctSuffix):
+			return psqlResolveDistinctQuery(pool, tenant, graph, field, opts, explain)
+		case strings.HasSuffix(rootTable, byIDSuffix):
+			return psqlResolveByIDQuery(pool, tenant, graph, field, opts, explain)
+		}
 	}
 
-	// Create the sql query and any arguments
-	sqlStr, sqlArgs, err := rootSQL.ToSql()
+	sqlStr, sqlArgs, rootColumns, err := psqlRootQuerySQL(tenant, graph, field, opts)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create sql query: %w", err)
+		return nil, err
 	}
 
-	// Change the default placeholder with $ for postgres
-	sqlStr, err = sq.Dollar.ReplacePlaceholders(sqlStr)
-	if err != nil {
-		return nil, fmt.Errorf("error replacing the SQL (squirrel) placeholders: %w", err)
+	if explain != nil {
+		if err := explain.add(pool, rootTable, sqlStr, sqlArgs); err != nil {
+			return nil, err
+		}
 	}
 
 	// Execute the query
-	rows, err := pool.Query(context.Background(), sqlStr, sqlArgs...)
+	rows, err := pool.Query(opts.ctx, sqlStr, sqlArgs...)
 	if err != nil {
+		if regexErr := psqlRegexError(err); regexErr != nil {
+			return nil, regexErr
+		}
 		return nil, fmt.Errorf("failed to execute SQL query: %s: %w", sqlStr, err)
 	}
 	defer rows.Close()
@@ -104,21 +205,126 @@ func psqlResolveRootQuery(pool *pgxpool.Pool, tenant string, graph *SchemaGraph,
 	// Iterate through the result set and append each row of results to the
 	// result value we are returning. We should check if there are no rows
 	// in which case we want to return at least an empty slice
-	var hasRows bool
+	var numRows uint64
 	for rows.Next() {
-		hasRows = true
+		numRows++
 		if err := psqlScanRowColumns(rows, result, rootColumns); err != nil {
 			return nil, fmt.Errorf("failed scanning row values: %w", err)
 		}
+		// The rows of a paginated table are ordered by the rows of the root
+		// table, so the first row of the row after the page is the last one
+		// that is needed
+		if rootColumns.page != nil && rootColumns.page.full(result[rootTable]) {
+			break
+		}
+		if opts.limits.maxLimit > 0 && numRows > opts.limits.maxLimit {
+			return nil, fmt.Errorf("query returns more than the maximum of %d rows, use the `first` or `last` arguments to return fewer results", opts.limits.maxLimit)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		if regexErr := psqlRegexError(err); regexErr != nil {
+			return nil, regexErr
+		}
+		return nil, fmt.Errorf("failed reading the rows: %w", err)
+	}
+	if rootColumns.page != nil {
+		rows, info, err := psqlPageRows(rootColumns.page, result[rootTable])
+		if err != nil {
+			return nil, fmt.Errorf("failed to get page of rows: %w", err)
+		}
+		result[rootTable] = rows
+		if opts.cursors != nil {
+			opts.cursors.addPage(rootTable, info)
+		}
 	}
-	if !hasRows {
+	if opts.cursors != nil {
+		if err := psqlAddCursor(opts.cursors, field, result[rootTable]); err != nil {
+			return nil, err
+		}
+	}
+	if numRows == 0 {
 		// Initialize with an empty slice to avoid returning just null
 		result[rootTable] = make([]interface{}, 0)
 	}
 	return result[rootTable], nil
 }
 
-func psqlSubQuery(tenant string, graph *SchemaGraph, sql *sq.SelectBuilder, parent *tableColumns, tc *tableColumns, depth int) error {
+// psqlNotDeleted returns the condition on the rows of a table that are not
+// soft-deleted
+func psqlNotDeleted(alias string) sq.Sqlizer {
+	return sq.Expr(tableColumn(alias, tableDeletedAtField) + " IS NULL")
+}
+
+// psqlAddCursor adds the cursor of a root table to the cursors, if the table
+// is queried with the `_since` argument
+func psqlAddCursor(cursors *queryCursors, field *ast.Field, rows interface{}) error {
+	for _, arg := range field.Arguments {
+		if arg.Name.Value != sinceID {
+			continue
+		}
+		since, err := psqlSinceArg(field.Name.Value, arg)
+		if err != nil {
+			return err
+		}
+		rowVals, _ := rows.([]map[string]interface{})
+		cursors.add(field.Name.Value, since, rowVals)
+	}
+	return nil
+}
+
+// psqlRootQuerySQL creates the SQL query for a root graphql query, and returns
+// the columns of the query to scan the rows into.
+// The values given by the user in the graphql query, such as the values of
+// arguments and filters, are never part of the SQL text: they are returned as
+// the arguments of the query for the $1, $2, ... placeholders
+func psqlRootQuerySQL(tenant string, graph *SchemaGraph, field *ast.Field, opts queryOptions) (string, []interface{}, tableColumns, error) {
+	var (
+		rootTable   = field.Name.Value
+		rootColumns = tableColumns{
+			table:  rootTable,
+			alias:  tableAlias(rootTable, 0),
+			path:   rootTable,
+			field:  field,
+			scalar: false,
+		}
+		rootSQL = sq.Select()
+	)
+
+	if node, ok := graph.NodeIndex[rootTable]; ok {
+		page, err := psqlPageArgs(*node.Table, field, opts)
+		if err != nil {
+			return "", nil, rootColumns, err
+		}
+		rootColumns.page = page
+	}
+
+	// Recursively go through the graphql query and resolve the sub-fields
+	err := psqlSubQuery(tenant, graph, &rootSQL, nil, &rootColumns, opts, 0)
+	if err != nil {
+		return "", nil, rootColumns, fmt.Errorf("failed to process root query: %s: %w", rootTable, err)
+	}
+	// The limits on each table do not limit the number of rows, because the
+	// rows of nested tables multiply. So fetch one more row than the maximum
+	// to know whether the maximum is exceeded, without fetching them all
+	if opts.limits.maxLimit > 0 {
+		rootSQL = rootSQL.Limit(opts.limits.maxLimit + 1)
+	}
+
+	// Create the sql query and any arguments
+	sqlStr, sqlArgs, err := rootSQL.ToSql()
+	if err != nil {
+		return "", nil, rootColumns, fmt.Errorf("failed to create sql query: %w", err)
+	}
+
+	// Change the default placeholder with $ for postgres
+	sqlStr, err = sq.Dollar.ReplacePlaceholders(sqlStr)
+	if err != nil {
+		return "", nil, rootColumns, fmt.Errorf("error replacing the SQL (squirrel) placeholders: %w", err)
+	}
+	return sqlStr, sqlArgs, rootColumns, nil
+}
+
+func psqlSubQuery(tenant string, graph *SchemaGraph, sql *sq.SelectBuilder, parent *tableColumns, tc *tableColumns, opts queryOptions, depth int) error {
 
 	// GraphQL fields are conceptually functions which return values,
 	// and occasionally accept arguments which alter their behaviour.
@@ -154,6 +360,11 @@ func psqlSubQuery(tenant string, graph *SchemaGraph, sql *sq.SelectBuilder, pare
 		firstArg *ast.Argument
 		// The `last` arg is a limit on the results in DESC order
 		lastArg *ast.Argument
+		// The `_since` arg returns the rows changed after a cursor, and
+		// orders the rows by when they changed
+		sinceArg *ast.Argument
+		// includeDeleted says whether to return the soft-deleted rows
+		includeDeleted bool
 	)
 
 	// Always return the ID field of a table as the first row as we need it when
@@ -200,23 +411,61 @@ func psqlSubQuery(tenant string, graph *SchemaGraph, sql *sq.SelectBuilder, pare
 			// }
 			filterOn = true
 			argIsResolved = true
+		case filterID:
+			cond, err := psqlFilter(tenant, node, tc.alias, arg.Value, opts)
+			if err != nil {
+				return err
+			}
+			nodeQuery = nodeQuery.Where(cond)
+			argIsResolved = true
 		case orderByID:
 			// The order_by argument is allowed only at the top level. Futhermore, it cannot be processed until
 			// all subfields had been processed, because at the top level the alias names of tables are not known.
 			// Therefore, defer the processing of this argument by saving a pointer to it for later processing.
 			orderByArg = arg
 			argIsResolved = true
+		case sinceID:
+			since, err := psqlSinceArg(tc.table, arg)
+			if err != nil {
+				return err
+			}
+			// Select the sequence number as well, so that the cursor can be
+			// returned
+			tc.columns = append(tc.columns, tableSeqField)
+			nodeQuery = nodeQuery.
+				Column(tableColumn(tc.alias, tableSeqField)).
+				Where(sq.Gt{tableColumn(tc.alias, tableSeqField): since})
+			*sql = sql.Column(tableColumn(tc.alias, tableSeqField))
+			sinceArg = arg
+			argIsResolved = true
 		case firstID:
 			firstArg = arg
 			argIsResolved = true
 		case lastID:
 			lastArg = arg
 			argIsResolved = true
+		case afterID:
+			// The page of rows after a cursor is resolved by the root query
+			// (see psqlPageArgs), as only root tables can be paginated
+			if parent != nil {
+				return fmt.Errorf("the '%s' argument can only be provided for root tables, not table %s", afterID, tc.table)
+			}
+			argIsResolved = true
+		case includeDeletedID:
+			include, ok := arg.Value.GetValue().(bool)
+			if !ok {
+				return fmt.Errorf("the '%s' argument for table %s must be a boolean", includeDeletedID, tc.table)
+			}
+			includeDeleted = include
+			argIsResolved = true
 		}
 
 		if firstArg != nil && lastArg != nil {
 			return fmt.Errorf("cannot provide both 'first' and 'last' arguments for table %s", tc.table)
 		}
+		if sinceArg != nil && lastArg != nil {
+			return fmt.Errorf("cannot provide both '%s' and 'last' arguments for table %s", sinceID, tc.table)
+		}
 
 		// The argument name which is not a column name is a mistake, raise error.
 		if !argIsResolved {
@@ -224,6 +473,29 @@ func psqlSubQuery(tenant string, graph *SchemaGraph, sql *sq.SelectBuilder, pare
 		}
 	}
 
+	// Exclude the soft-deleted rows, which like the row filter applies to
+	// both root and nested tables
+	if opts.softDelete && !includeDeleted {
+		nodeQuery = nodeQuery.Where(psqlNotDeleted(tc.alias))
+	}
+
+	// Restrict the rows of this table to those the caller is allowed to see.
+	// As this is added to the subquery for this node, it applies to both root
+	// and nested tables
+	if opts.rowFilter != nil {
+		filter, err := opts.rowFilter(tc.table)
+		if err != nil {
+			return fmt.Errorf("failed to get row filter for table %s: %w", tc.table, err)
+		}
+		if len(filter) > 0 {
+			eq, err := psqlRowFilter(*node.Table, tc.alias, filter)
+			if err != nil {
+				return err
+			}
+			nodeQuery = nodeQuery.Where(eq)
+		}
+	}
+
 	// Iterate over the fields in the selection set (if any) for the current `field`
 	for _, selection := range tc.field.SelectionSet.Selections {
 		// Only GraphQL `Field`s are supported at this point. http://spec.graphql.org/June2018/#sec-Language.Fields
@@ -258,6 +530,16 @@ func psqlSubQuery(tenant string, graph *SchemaGraph, sql *sq.SelectBuilder, pare
 		// at the end of the function
 		if subField.SelectionSet != nil {
 			subFields = append(subFields, subField)
+		} else if derived, ok := tableDerivedField(*node.Table, fieldName); ok {
+			// A derived field is computed by its expression in the subquery
+			// for this node, and selected by name from the subquery
+			expr, err := derivedFieldSQL(*node.Table, derived, tc.alias)
+			if err != nil {
+				return err
+			}
+			tc.columns = append(tc.columns, fieldName)
+			nodeQuery = nodeQuery.Column(expr + " AS " + fieldName)
+			*sql = sql.Column(tableColumn(tc.alias, fieldName))
 		} else {
 			// If subField did not have a selection set this it is just a column
 			// within the current table, so add it to the columns
@@ -267,6 +549,14 @@ func psqlSubQuery(tenant string, graph *SchemaGraph, sql *sq.SelectBuilder, pare
 		}
 	}
 
+	// The cursor of a page contains the value of the order_by field of the
+	// last row, so select the field even if the query does not
+	if tc.page != nil && !tableColumnsHas(tc, tc.page.orderField) {
+		tc.columns = append(tc.columns, tc.page.orderField)
+		nodeQuery = nodeQuery.Column(tableColumn(tc.alias, tc.page.orderField))
+		*sql = sql.Column(tableColumn(tc.alias, tc.page.orderField))
+	}
+
 	// Once we have processed this fields columns, proceed to the subFields.
 	// To ensure the correct ordering of both columns in the nodeQuery and also
 	// the order of JOINs in the root SQL query, we need to be a bit careful.
@@ -285,10 +575,20 @@ func psqlSubQuery(tenant string, graph *SchemaGraph, sql *sq.SelectBuilder, pare
 			return fmt.Errorf("no relationship found between tables: '%s', '%s'", node.Table.Name, fieldName)
 		}
 
+		// Leave out the tables that are nested deeper than the maximum
+		// depth, and note where the result is truncated
+		if opts.limits.maxDepth > 0 && depth+1 > opts.limits.maxDepth {
+			if opts.cursors != nil {
+				opts.cursors.addTruncated(tc.path + "." + fieldName)
+			}
+			continue
+		}
+
 		// Recursively resolve for the subField `B`, which may contain further nested fields.
 		subCol := &tableColumns{
 			table:  fieldName,
 			alias:  tableAlias(fieldName, depth),
+			path:   tc.path + "." + fieldName,
 			field:  subField,
 			scalar: edgeToRelatedNode.isScalar(),
 		}
@@ -331,10 +631,16 @@ func psqlSubQuery(tenant string, graph *SchemaGraph, sql *sq.SelectBuilder, pare
 			return fmt.Errorf("invalid format for 'order_by' argument")
 		}
 		for _, orderBy := range orderByFields {
+			// The ORDER BY cannot be a query argument, so only known columns
+			// and orders can become part of the SQL text
 			var (
-				field = orderBy.Name.Value
-				order = strings.ToUpper(orderBy.Value.GetValue().(string))
+				field    = orderBy.Name.Value
+				order, _ = orderBy.Value.GetValue().(string)
 			)
+			if !tableHasColumn(*node.Table, field) {
+				return fmt.Errorf("unknown field in 'order_by' for table %s: %s", tc.table, field)
+			}
+			order = strings.ToUpper(order)
 			if !(order == orderAsc || order == orderDesc) {
 				return fmt.Errorf("unknown order for 'order_by': %s", order)
 			}
@@ -343,6 +649,12 @@ func psqlSubQuery(tenant string, graph *SchemaGraph, sql *sq.SelectBuilder, pare
 			*sql = sql.OrderBy(tableColumn(tc.alias, field) + " " + order)
 		}
 	}
+	// The rows of a page with the same value of the order_by field are ordered
+	// by _id, before the rows of any nested tables, so that the next page
+	// starts after the last row of the page
+	if tc.page != nil && (orderByArg == nil || tc.page.orderField != tableIDField) {
+		*sql = sql.OrderBy(tableColumn(tc.alias, tableIDField) + " " + orderAsc)
+	}
 
 	//
 	// Limit
@@ -352,28 +664,38 @@ func psqlSubQuery(tenant string, graph *SchemaGraph, sql *sq.SelectBuilder, pare
 	// of other fields to make sure we respect the wishes of the user and then
 	// get first/last based on the given order
 	//
-	if firstArg != nil {
-		limitStr, ok := firstArg.Value.GetValue().(string)
-		if !ok {
-			return fmt.Errorf("could not convert the value of the argument `first`: %#v", firstArg.Value.GetValue())
+	// When fetching the rows changed since a cursor, the limits should return
+	// the rows that changed first, so that the next cursor does not skip any
+	limitOrderField := tableIDField
+	if sinceArg != nil {
+		limitOrderField = tableSeqField
+	}
+	// A paginated table is ordered by _id after the order_by, starts after the
+	// cursor of the previous page, and fetches one more row than the page to
+	// know whether there is a next page
+	if tc.page != nil {
+		if tc.page.after != nil {
+			nodeQuery = nodeQuery.Where(psqlKeysetCondition(tc.alias, tc.page))
 		}
-		n, err := strconv.ParseUint(limitStr, 10, 64)
+		nodeQuery = nodeQuery.OrderBy(tableColumn(tc.alias, tableIDField) + " " + orderAsc)
+		if tc.page.limit > 0 {
+			nodeQuery = nodeQuery.Limit(tc.page.limit + 1)
+		}
+	}
+	if firstArg != nil && tc.page == nil {
+		n, err := psqlLimitArg(tc.table, tc.path, firstArg, opts)
 		if err != nil {
-			return fmt.Errorf("could not convert the value to unsigned integer: %s", limitStr)
+			return err
 		}
 		// Order by ASC and then limit
 		nodeQuery = nodeQuery.
-			OrderBy(tableColumn(tc.alias, tableIDField) + " " + orderAsc).
+			OrderBy(tableColumn(tc.alias, limitOrderField) + " " + orderAsc).
 			Limit(n)
 	}
 	if lastArg != nil {
-		limitStr, ok := lastArg.Value.GetValue().(string)
-		if !ok {
-			return fmt.Errorf("could not convert the value of the argument `last`: %#v", lastArg.Value.GetValue())
-		}
-		n, err := strconv.ParseUint(limitStr, 10, 64)
+		n, err := psqlLimitArg(tc.table, tc.path, lastArg, opts)
 		if err != nil {
-			return fmt.Errorf("could not convert the value to unsigned integer: %s", limitStr)
+			return err
 		}
 		// Order by DESC and then limit
 		nodeQuery = nodeQuery.
@@ -381,11 +703,15 @@ func psqlSubQuery(tenant string, graph *SchemaGraph, sql *sq.SelectBuilder, pare
 			Limit(n)
 	}
 	// Add default orderBy and limit if there isn't one already
-	if lastArg == nil && firstArg == nil {
-		if orderByArg == nil {
+	if lastArg == nil && firstArg == nil && tc.page == nil {
+		if orderByArg == nil && sinceArg != nil {
+			nodeQuery = nodeQuery.OrderBy(tableColumn(tc.alias, tableSeqField) + " " + orderAsc)
+		} else if orderByArg == nil {
 			nodeQuery = nodeQuery.OrderBy(tableColumn(tc.alias, tableIDField) + " " + orderDesc)
 		}
-		nodeQuery = nodeQuery.Limit(defaultLimit)
+		if limit := opts.limits.tableLimit(); limit > 0 {
+			nodeQuery = nodeQuery.Limit(limit)
+		}
 	}
 
 	// Before processing any subFields (which are like "children" in GraphQL),
@@ -455,7 +781,7 @@ func psqlSubQuery(tenant string, graph *SchemaGraph, sql *sq.SelectBuilder, pare
 
 	// Create and add sub queries for the children to the root SQL query
 	for _, subCol := range subColumns {
-		err := psqlSubQuery(tenant, graph, sql, tc, subCol, depth+1)
+		err := psqlSubQuery(tenant, graph, sql, tc, subCol, opts, depth+1)
 		if err != nil {
 			return err
 		}
@@ -464,13 +790,62 @@ func psqlSubQuery(tenant string, graph *SchemaGraph, sql *sq.SelectBuilder, pare
 	// After we have processed the sub fields, if there was not orderBy given
 	// for this field then add a default one to "preserve" the natural order.
 	// IMPORTANT: this has to come AFTER we handle sub fields, so that we honour
-	// the requests made by sub children
-	if orderByArg == nil {
+	// the requests made by sub children. A paginated table is already ordered
+	if orderByArg == nil && tc.page == nil {
 		*sql = sql.OrderBy(tableColumn(tc.alias, tableIDField) + " " + orderAsc)
 	}
 	return nil
 }
 
+// psqlSinceArg returns the cursor of a `_since` argument, which is the sequence
+// number of a row
+func psqlSinceArg(table string, arg *ast.Argument) (int64, error) {
+	sinceStr, ok := arg.Value.GetValue().(string)
+	if !ok {
+		return 0, fmt.Errorf("could not convert the value of the argument `%s`: %#v", arg.Name.Value, arg.Value.GetValue())
+	}
+	since, err := strconv.ParseInt(sinceStr, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("the value of the argument `%s` for table %s is not a cursor: %s", arg.Name.Value, table, sinceStr)
+	}
+	return since, nil
+}
+
+// psqlLimitArg returns the value of a `first` or `last` argument of the table
+// at path. A value above the maximum page size is reduced to it, and noted in
+// the result. The value cannot exceed the maximum limit
+func psqlLimitArg(table string, path string, arg *ast.Argument, opts queryOptions) (uint64, error) {
+	limits := opts.limits
+	limitStr, ok := arg.Value.GetValue().(string)
+	if !ok {
+		return 0, fmt.Errorf("could not convert the value of the argument `%s`: %#v", arg.Name.Value, arg.Value.GetValue())
+	}
+	n, err := strconv.ParseUint(limitStr, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("could not convert the value to unsigned integer: %s", limitStr)
+	}
+	if limits.maxPageSize > 0 && n > limits.maxPageSize {
+		n = limits.maxPageSize
+		if opts.cursors != nil {
+			opts.cursors.addClamped(path, n)
+		}
+	}
+	if limits.maxLimit > 0 && n > limits.maxLimit {
+		return 0, fmt.Errorf("the value of the argument `%s` for table %s exceeds the maximum of %d", arg.Name.Value, table, limits.maxLimit)
+	}
+	return n, nil
+}
+
+// tableColumnsHas returns whether the column is selected for the table
+func tableColumnsHas(tc *tableColumns, column string) bool {
+	for _, col := range tc.columns {
+		if col == column {
+			return true
+		}
+	}
+	return false
+}
+
 func foreignKeyField(table string) string {
 	return table + tableJoinSuffix
 }
@@ -540,8 +915,11 @@ func psqlScanTableColumns(parentVal map[string]interface{}, tc tableColumns, sca
 
 		// If all values were nil and there are no children (hence no need for
 		// any values at all), then set the tColVal to nil as it's just a map
-		// of nil values with no children
-		if isNilTable && len(tc.children) == 0 {
+		// of nil values with no children.
+		// A scalar table with only nil values does not exist, e.g. the parent
+		// of a row whose foreign key is null, so its children do not exist
+		// either
+		if isNilTable && (len(tc.children) == 0 || tc.scalar) {
 			tColVal = nil
 		}
 		// Check if we expect the result to be a scalar value or a list.
@@ -615,6 +993,15 @@ func psqlScanTableColumns(parentVal map[string]interface{}, tc tableColumns, sca
 		parentVal[tc.table] = tListVal
 	}
 
+	// A scalar table that does not exist has no children to unpack, so skip
+	// over their values
+	if tc.scalar && tColVal == nil {
+		for _, child := range tc.children {
+			*index += child.length()
+		}
+		return
+	}
+
 	// Iterate through the children and unpack the remaining scanValues (starting
 	// from the given index) into the given tColVal (which holds the value for
 	// this tableColumns)
@@ -1,9 +1,16 @@
 package store
 
 import (
+	"strings"
 	"testing"
 
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/parser"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/valocode/bubbly/api/core"
 )
 
 // TestScanTableColumns tests the unpacking of SQL row results (flat list) into
@@ -188,6 +195,64 @@ func TestScanTableColumns(t *testing.T) {
 				},
 			},
 		},
+		{
+			// E.g. a child whose parent and grandparent are queried through
+			// the BelongsTo edges, where the second child has no parent
+			name: "nil scalar node with children",
+			tc: tableColumns{
+				table:   "a",
+				columns: []string{tableIDField, "name"},
+				children: []*tableColumns{
+					{
+						table:   "b",
+						columns: []string{tableIDField, "name"},
+						scalar:  true,
+						children: []*tableColumns{
+							{
+								table:   "c",
+								columns: []string{tableIDField, "name"},
+								scalar:  true,
+							},
+						},
+					},
+					{
+						table:   "d",
+						columns: []string{tableIDField, "name"},
+					},
+				},
+			},
+			values: [][]interface{}{
+				{
+					1, "a1", 1, "b1", 1, "c1", 1, "d1",
+				},
+				{
+					2, "a2", nil, nil, nil, nil, 2, "d2",
+				},
+			},
+			exp: map[string]interface{}{
+				"a": []map[string]interface{}{
+					{
+						tableIDField: 1, "name": "a1",
+						"b": map[string]interface{}{
+							tableIDField: 1, "name": "b1",
+							"c": map[string]interface{}{
+								tableIDField: 1, "name": "c1",
+							},
+						},
+						"d": []map[string]interface{}{
+							{tableIDField: 1, "name": "d1"},
+						},
+					},
+					{
+						tableIDField: 2, "name": "a2",
+						"b": map[string]interface{}(nil),
+						"d": []map[string]interface{}{
+							{tableIDField: 2, "name": "d2"},
+						},
+					},
+				},
+			},
+		},
 		{
 			name: "example release view",
 			tc: tableColumns{
@@ -327,3 +392,245 @@ func TestScanTableColumns(t *testing.T) {
 		})
 	}
 }
+
+// TestPsqlRootQuerySQL tests that the values given in a graphql query are
+// passed as arguments of the SQL query, and never become part of the SQL text
+func TestPsqlRootQuerySQL(t *testing.T) {
+	graph, err := NewSchemaGraph(core.Tables{
+		{
+			Name:   "location",
+			Fields: []core.TableField{{Name: "name", Type: cty.String}},
+		},
+		{
+			Name:   "testrun",
+			Fields: []core.TableField{{Name: "ok", Type: cty.Bool}},
+			Joins:  []core.TableJoin{{Table: "location"}},
+		},
+	})
+	require.NoError(t, err)
+
+	const injection = "'; DROP TABLE location; --"
+	tcs := []struct {
+		desc    string
+		query   string
+		args    []interface{}
+		wantErr bool
+	}{
+		{
+			desc:  "field argument",
+			query: `{ location(name: "'; DROP TABLE location; --") { name } }`,
+			args:  []interface{}{injection},
+		},
+		{
+			desc:  "id argument",
+			query: `{ location(_id: "'; DROP TABLE location; --") { name } }`,
+			args:  []interface{}{injection},
+		},
+		{
+			desc:  "filter",
+			query: `{ location(filter: {name_in: ["a", "'; DROP TABLE location; --"]}) { name } }`,
+			args:  []interface{}{"a", injection},
+		},
+		{
+			desc:  "nested argument",
+			query: `{ testrun(first: "1") { ok location(name: "'; DROP TABLE location; --") { name } } }`,
+			args:  []interface{}{injection},
+		},
+		{
+			desc:    "order by unknown field",
+			query:   `{ location(order_by: {password: asc}) { name } }`,
+			wantErr: true,
+		},
+		{
+			desc:    "order by unknown order",
+			query:   `{ location(order_by: {name: "ASC; DROP TABLE location; --"}) { name } }`,
+			wantErr: true,
+		},
+		{
+			desc:    "limit",
+			query:   `{ location(first: "1; DROP TABLE location; --") { name } }`,
+			wantErr: true,
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.desc, func(t *testing.T) {
+			doc, err := parser.Parse(parser.ParseParams{Source: tc.query})
+			require.NoError(t, err)
+			field := doc.Definitions[0].(*ast.OperationDefinition).SelectionSet.Selections[0].(*ast.Field)
+
+			sqlStr, sqlArgs, _, err := psqlRootQuerySQL(DefaultTenantName, graph, field, queryOptions{})
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.False(t, strings.Contains(sqlStr, "DROP"), "value is part of the SQL text: %s", sqlStr)
+			assert.False(t, strings.Contains(sqlStr, "?"), "placeholders are not replaced: %s", sqlStr)
+			assert.Equal(t, tc.args, sqlArgs)
+		})
+	}
+}
+
+// TestPsqlRootQuerySQLSoftDelete tests that the soft-deleted rows of every
+// table in a query are excluded, unless the table has the include_deleted
+// argument
+func TestPsqlRootQuerySQLSoftDelete(t *testing.T) {
+	graph, err := NewSchemaGraph(core.Tables{
+		{
+			Name:   "location",
+			Fields: []core.TableField{{Name: "name", Type: cty.String}},
+		},
+		{
+			Name:   "testrun",
+			Fields: []core.TableField{{Name: "ok", Type: cty.Bool}},
+			Joins:  []core.TableJoin{{Table: "location"}},
+		},
+	})
+	require.NoError(t, err)
+
+	tcs := []struct {
+		desc       string
+		query      string
+		softDelete bool
+		// excluded are the aliases of the tables whose deleted rows are
+		// excluded
+		excluded []string
+		wantErr  bool
+	}{
+		{
+			desc:  "soft delete disabled",
+			query: `{ testrun { ok location { name } } }`,
+		},
+		{
+			desc:       "root and nested tables",
+			query:      `{ testrun { ok location { name } } }`,
+			softDelete: true,
+			excluded:   []string{"testrun_0", "location_0"},
+		},
+		{
+			desc:       "include deleted",
+			query:      `{ testrun(include_deleted: true) { ok location { name } } }`,
+			softDelete: true,
+			excluded:   []string{"location_0"},
+		},
+		{
+			desc:       "include deleted false",
+			query:      `{ testrun(include_deleted: false) { ok } }`,
+			softDelete: true,
+			excluded:   []string{"testrun_0"},
+		},
+		{
+			desc:       "include deleted not a boolean",
+			query:      `{ testrun(include_deleted: "yes") { ok } }`,
+			softDelete: true,
+			wantErr:    true,
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.desc, func(t *testing.T) {
+			doc, err := parser.Parse(parser.ParseParams{Source: tc.query})
+			require.NoError(t, err)
+			field := doc.Definitions[0].(*ast.OperationDefinition).SelectionSet.Selections[0].(*ast.Field)
+
+			sqlStr, _, _, err := psqlRootQuerySQL(DefaultTenantName, graph, field, queryOptions{softDelete: tc.softDelete})
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, len(tc.excluded), strings.Count(sqlStr, tableDeletedAtField+" IS NULL"), sqlStr)
+			for _, alias := range tc.excluded {
+				assert.Contains(t, sqlStr, tableColumn(alias, tableDeletedAtField)+" IS NULL")
+			}
+		})
+	}
+}
+
+// TestPsqlRootQuerySQLMaxDepth tests that the tables nested deeper than the
+// maximum depth are left out of the query, and that their paths are noted
+func TestPsqlRootQuerySQLMaxDepth(t *testing.T) {
+	graph, err := NewSchemaGraph(FlattenTables(core.Tables{
+		{
+			Name:   "team",
+			Fields: []core.TableField{{Name: "name", Type: cty.String}},
+			Tables: core.Tables{
+				{
+					Name:   "member",
+					Fields: []core.TableField{{Name: "email", Type: cty.String}},
+					Tables: core.Tables{
+						{
+							Name:   "badge",
+							Fields: []core.TableField{{Name: "name", Type: cty.String}},
+						},
+					},
+				},
+				{
+					Name:   "office",
+					Fields: []core.TableField{{Name: "city", Type: cty.String}},
+				},
+			},
+		},
+	}, nil))
+	require.NoError(t, err)
+
+	const query = `{ team { name member { email badge { name } } office { city } } }`
+	tcs := []struct {
+		desc     string
+		maxDepth int
+		// aliases are the aliases of the nested tables in the query, and
+		// leftOut those of the nested tables that are left out of it
+		aliases   []string
+		leftOut   []string
+		truncated []string
+	}{
+		{
+			desc:    "no maximum",
+			aliases: []string{"member_0", "office_0", "badge_1"},
+		},
+		{
+			desc:     "maximum below the query",
+			maxDepth: 1,
+			aliases:  []string{"member_0", "office_0"},
+			leftOut:  []string{"badge_1"},
+			// The path is that of the table in the query
+			truncated: []string{"team.member.badge"},
+		},
+		{
+			desc:     "maximum of the query",
+			maxDepth: 2,
+			aliases:  []string{"member_0", "office_0", "badge_1"},
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.desc, func(t *testing.T) {
+			doc, err := parser.Parse(parser.ParseParams{Source: query})
+			require.NoError(t, err)
+			field := doc.Definitions[0].(*ast.OperationDefinition).SelectionSet.Selections[0].(*ast.Field)
+
+			cursors := &queryCursors{}
+			sqlStr, _, columns, err := psqlRootQuerySQL(DefaultTenantName, graph, field, queryOptions{
+				limits:  queryLimits{maxDepth: tc.maxDepth},
+				cursors: cursors,
+			})
+			require.NoError(t, err)
+			for _, alias := range tc.aliases {
+				assert.Contains(t, sqlStr, " AS "+alias+" ", sqlStr)
+			}
+			for _, alias := range tc.leftOut {
+				assert.NotContains(t, sqlStr, " AS "+alias+" ", sqlStr)
+			}
+			assert.Equal(t, len(tc.aliases)+1, countTableColumns(&columns))
+			assert.Equal(t, tc.truncated, cursors.truncatedPaths())
+		})
+	}
+}
+
+// countTableColumns returns the number of tables in the tableColumns,
+// including itself
+func countTableColumns(tc *tableColumns) int {
+	count := 1
+	for _, child := range tc.children {
+		count += countTableColumns(child)
+	}
+	return count
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"reflect"
+	"sort"
 
 	"github.com/jackc/pgx/v4/pgxpool"
 
@@ -16,7 +17,7 @@ import (
 type migration []string
 
 // generateMigration creates a list of sql statements to be executed based on a schemaUpdates
-func psqlGenerateMigration(provider config.StoreProviderType, tenant string, schema *bubblySchema, ch schemaUpdates) (migration, error) {
+func psqlGenerateMigration(provider config.StoreProviderType, tenant string, schema *bubblySchema, ch schemaUpdates, softDelete bool) (migration, error) {
 	var (
 		m migration
 		// Nearly all of the schema changes can be made incrementally (i.e. one by one
@@ -26,6 +27,9 @@ func psqlGenerateMigration(provider config.StoreProviderType, tenant string, sch
 		// Store the tables whose unique constraints have changed, so that we can
 		// handle these as a single command
 		tableUniqueChanges = make(map[string]struct{})
+		// Store the tables whose indexes have changed, so that the removed
+		// indexes can be dropped once we know all the indexes of the table
+		tableIndexChanges = make(map[string][][]string)
 	)
 	for _, change := range ch {
 		tableName := change.TableInfo.TableName
@@ -47,21 +51,39 @@ func psqlGenerateMigration(provider config.StoreProviderType, tenant string, sch
 			}
 		case update:
 			switch change.TableInfo.ElementType {
+			case fieldNameAttr:
+				stmt, err := renameColumnStatement(tenant, change.TableInfo, change.From)
+				if err != nil {
+					return nil, err
+				}
+				m = append(m, stmt)
 			case fieldType:
 				stmts, err := alterColumnStatement(provider, tenant, change.TableInfo, change.To)
 				if err != nil {
 					return nil, err
 				}
 				m = append(m, stmts...)
+			case fieldDefaultAttr:
+				stmt, err := alterColumnDefaultStatement(tenant, change.TableInfo, change.To)
+				if err != nil {
+					return nil, err
+				}
+				m = append(m, stmt)
 			case joinSingleAttr:
 				// The single attribute on a join does not affect the schema in
 				// postgres, as we cannot truly model a one-to-one relationship.
 				// It DOES affect the GraphQL schema, but that means we do
 				// nothing here
-			case fieldUniqueAttr, joinUniqueAttr:
+			case fieldUniqueAttr, joinUniqueAttr, tableUniqueFieldsAttr:
 				// Just mark that this table should have it's unique constraints
 				// modified - which needs to happen in one go
 				tableUniqueChanges[change.TableInfo.TableName] = struct{}{}
+			case tableIndexesAttr:
+				from, ok := (change.From).([][]string)
+				if !ok && change.From != nil {
+					return nil, fmt.Errorf("cannot assign type to indexes: %s", reflect.TypeOf(change.From).String())
+				}
+				tableIndexChanges[change.TableInfo.TableName] = from
 			default:
 				return nil, fmt.Errorf("unsupported element type for update on table %s: %s", change.TableInfo.TableName, change.TableInfo.ElementType)
 			}
@@ -72,13 +94,14 @@ func psqlGenerateMigration(provider config.StoreProviderType, tenant string, sch
 				if !ok {
 					return nil, fmt.Errorf("tableInterface not assignable to core.Table: %s", change.TableInfo.TableName)
 				}
-				stmt, err := psqlTableCreate(tenant, table)
+				stmt, err := psqlTableCreate(tenant, table, softDelete)
 				if err != nil {
 					return nil, fmt.Errorf("failed to create SQL statement to create table %s: %w", table.Name, err)
 				}
 				m = append(m, stmt)
 				stmt = psqlTableUniqueConstraints(tenant, table)
 				m = append(m, stmt)
+				m = append(m, psqlTableIndexes(tenant, table)...)
 			case fieldElement:
 				stmts, err := createFieldStatement(tenant, change.TableInfo, change.To)
 				if err != nil {
@@ -91,18 +114,46 @@ func psqlGenerateMigration(provider config.StoreProviderType, tenant string, sch
 					return nil, err
 				}
 				m = append(m, stmt)
+				// Joins are always indexed
+				join := change.To.(core.TableJoin)
+				m = append(m, psqlIndexCreate(tenant, tableName, []string{join.Table + tableJoinSuffix}))
 			default:
 				return nil, fmt.Errorf("unsupported element type for create on table %s: %s", change.TableInfo.TableName, change.TableInfo.ElementType)
 			}
 		}
 	}
 
-	// Check the unique constraints on tables and apply those
+	// Check the unique constraints on tables and apply those, in order of the
+	// tables' names so that the migration is always the same
+	uniqueTables := make([]string, 0, len(tableUniqueChanges))
 	for tableName := range tableUniqueChanges {
+		uniqueTables = append(uniqueTables, tableName)
+	}
+	sort.Strings(uniqueTables)
+	for _, tableName := range uniqueTables {
 		table := schema.Tables[tableName]
 		m = append(m, psqlTableUniqueConstraints(tenant, table))
 	}
 
+	// Drop the indexes that were removed, unless the fields are still indexed
+	// because they are a join, and create any new ones
+	indexTables := make([]string, 0, len(tableIndexChanges))
+	for tableName := range tableIndexChanges {
+		indexTables = append(indexTables, tableName)
+	}
+	sort.Strings(indexTables)
+	for _, tableName := range indexTables {
+		from := tableIndexChanges[tableName]
+		table := schema.Tables[tableName]
+		indexes := tableIndexes(table)
+		for _, index := range from {
+			if !hasIndex(indexes, index) {
+				m = append(m, "DROP INDEX IF EXISTS "+psqlSchemaName(tenant)+"."+psqlIndexName(tableName, index)+";")
+			}
+		}
+		m = append(m, psqlTableIndexes(tenant, table)...)
+	}
+
 	return m, nil
 }
 
@@ -129,7 +180,7 @@ func psqlMigrate(conn *pgxpool.Pool, tenant string, schema *bubblySchema, migr m
 	node := newDataNode(&d)
 	schemaTable := schema.Tables[core.SchemaTableName]
 	// Save the data block node to the schemaTable
-	if err := psqlSaveNode(tx, tenant, node, schemaTable); err != nil {
+	if err := psqlSaveNode(tx, tenant, node, schemaTable, false); err != nil {
 		return fmt.Errorf("failed to save schema data block: %w", err)
 	}
 
@@ -178,6 +229,34 @@ func alterColumnStatement(provider config.StoreProviderType, tenant string, info
 	}
 }
 
+// renameColumnStatement renames the column of a field that is renamed, so
+// that its values are kept
+func renameColumnStatement(tenant string, info tableInfo, fromInterface interface{}) (string, error) {
+	from, ok := (fromInterface).(string)
+	if !ok {
+		return "", fmt.Errorf("cannot assign type to string: %s", reflect.TypeOf(fromInterface).String())
+	}
+	return "ALTER TABLE IF EXISTS " + psqlAbsTableName(tenant, info.TableName) + " RENAME COLUMN " + from + " TO " + info.ElementName + ";", nil
+}
+
+// alterColumnDefaultStatement sets or drops the default of a column, so that
+// only new data gets the new default
+func alterColumnDefaultStatement(tenant string, info tableInfo, fieldInterface interface{}) (string, error) {
+	field, ok := (fieldInterface).(core.TableField)
+	if !ok {
+		return "", fmt.Errorf("cannot assign type to core.TableField: %s", reflect.TypeOf(fieldInterface).String())
+	}
+	sqlDefault, err := psqlFieldDefault(field)
+	if err != nil {
+		return "", fmt.Errorf("could not get postgres default for field %s: %w", field.Name, err)
+	}
+	stmt := "ALTER TABLE IF EXISTS " + psqlAbsTableName(tenant, info.TableName) + " ALTER COLUMN " + info.ElementName
+	if sqlDefault == "" {
+		return stmt + " DROP DEFAULT;", nil
+	}
+	return stmt + " SET DEFAULT " + sqlDefault + ";", nil
+}
+
 // this will create a column in a table, and then if specified add the UNIQUE constraint
 func createFieldStatement(tenant string, info tableInfo, fieldInterface interface{}) ([]string, error) {
 	field, ok := (fieldInterface).(core.TableField)
@@ -189,6 +268,14 @@ func createFieldStatement(tenant string, info tableInfo, fieldInterface interfac
 		return nil, fmt.Errorf("could not get postgres type for field %s: %w", field.Name, err)
 	}
 
+	sqlDefault, err := psqlFieldDefault(field)
+	if err != nil {
+		return nil, fmt.Errorf("could not get postgres default for field %s: %w", field.Name, err)
+	}
+	if sqlDefault != "" {
+		fieldElement += " DEFAULT " + sqlDefault
+	}
+
 	var statements = make([]string, 0, 1)
 	statements = append(statements, "ALTER TABLE IF EXISTS "+psqlAbsTableName(tenant, info.TableName)+" ADD COLUMN IF NOT EXISTS "+info.ElementName+" "+fieldElement)
 	if field.Unique {
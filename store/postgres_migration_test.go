@@ -8,7 +8,9 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/zclconf/go-cty/cty"
 
+	"github.com/valocode/bubbly/api/core"
 	"github.com/valocode/bubbly/env"
 	"github.com/valocode/bubbly/test"
 )
@@ -90,10 +92,16 @@ func TestApplyMigrationSchemaPostgres(t *testing.T) {
 						break
 					}
 				}
-				// Check if _id field
+				// Check if _id field, or a column that the store adds to
+				// every table
 				if !foundField && columnName == tableIDField {
 					foundField = true
 				}
+				for _, col := range psqlStoreColumns(bCtx.StoreConfig.SoftDelete) {
+					if !foundField && columnName == col.name {
+						foundField = true
+					}
+				}
 				// Check if foreign key field because of join
 				if !foundField && strings.HasSuffix(columnName, tableJoinSuffix) {
 					joinTableName := columnName[:len(columnName)-len(tableJoinSuffix)]
@@ -144,6 +152,182 @@ func TestApplyMigrationSchemaPostgres(t *testing.T) {
 					assert.Truef(t, foundJoin, "join to table %s in table %s exists in the bubbly schema but not in postgres after the migration", join.Table, table.Name)
 				}
 			}
+
+			// Check that the indexes of the tables, including those on the
+			// joins, exist and that removed indexes do not
+			idxRows, err := postgres.pool.Query(context.TODO(), "select indexname from pg_indexes where schemaname = '"+psqlSchemaName(DefaultTenantName)+"';")
+			require.NoError(t, err)
+			defer idxRows.Close()
+			var sqlIndexes = make(map[string]struct{})
+			for idxRows.Next() {
+				var indexName string
+				require.NoError(t, idxRows.Scan(&indexName))
+				sqlIndexes[indexName] = struct{}{}
+			}
+			var schemaIndexes = make(map[string]struct{})
+			for _, table := range s2.Tables {
+				for _, index := range tableIndexes(table) {
+					name := psqlIndexName(table.Name, index)
+					schemaIndexes[name] = struct{}{}
+					assert.Containsf(t, sqlIndexes, name, "index %s exists in the bubbly schema but not in postgres after the migration", name)
+				}
+			}
+			for name := range sqlIndexes {
+				if strings.HasSuffix(name, psqlTableIndexSuffix) {
+					assert.Containsf(t, schemaIndexes, name, "index %s exists in postgres but not in the bubbly schema after the migration", name)
+				}
+			}
 		})
 	}
 }
+
+func TestRenameFieldMigration(t *testing.T) {
+	bCtx := env.NewBubblyContext()
+	resource := test.RunPostgresDocker(bCtx, t)
+	bCtx.StoreConfig.PostgresAddr = fmt.Sprintf("localhost:%s", resource.GetPort("5432/tcp"))
+
+	s, err := New(bCtx)
+	require.NoError(t, err)
+	defer s.Close()
+
+	require.NoError(t, s.Apply(DefaultTenantName, core.Tables{
+		{
+			Name: "team",
+			Fields: []core.TableField{
+				{Name: "name", Type: cty.String, Unique: true},
+				{Name: "description", Type: cty.String},
+			},
+			Indexes: [][]string{{"description"}},
+		},
+	}, false))
+	require.NoError(t, s.Save(DefaultTenantName, core.DataBlocks{
+		{
+			TableName: "team",
+			Fields: &core.DataFields{Values: map[string]cty.Value{
+				"name":        cty.StringVal("bubbly"),
+				"description": cty.StringVal("keeps its value"),
+			}},
+		},
+	}))
+
+	// Renaming the field keeps its values, which are queried with the new name
+	renamed := core.Tables{
+		{
+			Name: "team",
+			Fields: []core.TableField{
+				{Name: "name", Type: cty.String, Unique: true},
+				{Name: "summary", Type: cty.String, RenamedFrom: "description"},
+			},
+			Indexes: [][]string{{"summary"}},
+		},
+	}
+	require.NoError(t, s.Apply(DefaultTenantName, renamed, false))
+	result, err := s.Query(DefaultTenantName, `{ team { name summary } }`)
+	require.NoError(t, err)
+	require.Empty(t, result.Errors)
+	assert.Equal(t, map[string]interface{}{
+		"team": []interface{}{
+			map[string]interface{}{"name": "bubbly", "summary": "keeps its value"},
+		},
+	}, result.Data)
+	result, err = s.Query(DefaultTenantName, `{ team { description } }`)
+	require.NoError(t, err)
+	assert.NotEmpty(t, result.Errors)
+
+	// Applying the schema again does not rename the field again
+	require.NoError(t, s.Apply(DefaultTenantName, renamed, false))
+	result, err = s.Query(DefaultTenantName, `{ team(summary: "keeps its value") { name } }`)
+	require.NoError(t, err)
+	require.Empty(t, result.Errors)
+	assert.Equal(t, map[string]interface{}{
+		"team": []interface{}{
+			map[string]interface{}{"name": "bubbly"},
+		},
+	}, result.Data)
+}
+
+func TestPreviewSchema(t *testing.T) {
+	bCtx := env.NewBubblyContext()
+	resource := test.RunPostgresDocker(bCtx, t)
+	bCtx.StoreConfig.PostgresAddr = fmt.Sprintf("localhost:%s", resource.GetPort("5432/tcp"))
+
+	s, err := New(bCtx)
+	require.NoError(t, err)
+	defer s.Close()
+
+	product := core.Table{
+		Name: "product",
+		Fields: []core.TableField{
+			{Name: "name", Type: cty.String, Unique: true},
+			{Name: "description", Type: cty.String},
+		},
+	}
+	require.NoError(t, s.Apply(DefaultTenantName, core.Tables{product}, false))
+
+	var (
+		productTable    = psqlAbsTableName(DefaultTenantName, "product")
+		testResultTable = psqlAbsTableName(DefaultTenantName, "test_result")
+	)
+	// Each of the tables is previewed and then applied to the schema that the
+	// previous tables were applied to
+	tcs := []struct {
+		desc     string
+		tables   core.Tables
+		expected []string
+	}{
+		{
+			desc: "additive",
+			tables: core.Tables{
+				{
+					Name:   "product",
+					Fields: append(product.Fields, core.TableField{Name: "url", Type: cty.String}),
+					Tables: core.Tables{
+						{
+							Name:   "test_result",
+							Fields: []core.TableField{{Name: "name", Type: cty.String}},
+						},
+					},
+				},
+			},
+			expected: []string{
+				"ALTER TABLE IF EXISTS " + productTable + " ADD COLUMN IF NOT EXISTS url TEXT",
+				"CREATE TABLE IF NOT EXISTS " + testResultTable + " ( _id SERIAL PRIMARY KEY,_seq BIGSERIAL,name TEXT,product_id INT8 );",
+				"ALTER TABLE " + testResultTable + " DROP CONSTRAINT IF EXISTS test_result_key;",
+				"CREATE INDEX IF NOT EXISTS test_result_product_id_idx ON " + testResultTable + " (product_id);",
+			},
+		},
+		{
+			desc:   "destructive",
+			tables: core.Tables{{Name: "product", Fields: product.Fields[:1]}},
+			expected: []string{
+				"ALTER TABLE IF EXISTS " + productTable + " DROP COLUMN IF EXISTS description",
+				"ALTER TABLE IF EXISTS " + productTable + " DROP COLUMN IF EXISTS url",
+				"DROP TABLE IF EXISTS " + testResultTable,
+			},
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.desc, func(t *testing.T) {
+			stmts, err := s.PreviewSchema(DefaultTenantName, tc.tables)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, stmts)
+
+			// Previewing does not change the schema, so the statements are
+			// the same the next time
+			stmts, err = s.PreviewSchema(DefaultTenantName, tc.tables)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, stmts)
+
+			// Applying the tables runs the statements, so there is nothing
+			// left to run
+			require.NoError(t, s.Apply(DefaultTenantName, tc.tables, false))
+			stmts, err = s.PreviewSchema(DefaultTenantName, tc.tables)
+			require.NoError(t, err)
+			assert.Empty(t, stmts)
+		})
+	}
+
+	// The schema is validated like when it is applied
+	_, err = s.PreviewSchema(DefaultTenantName, core.Tables{{Name: core.SchemaTableName}})
+	assert.Error(t, err)
+}
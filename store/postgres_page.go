@@ -0,0 +1,239 @@
+package store
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/graphql-go/graphql/language/ast"
+
+	"github.com/valocode/bubbly/api/core"
+)
+
+// pageQuery describes the page of rows of a root table that is paginated with
+// the `first` or `after` arguments. The rows are in a stable order, by the
+// order_by field and then by _id, so that a page starts right after the last
+// row of the previous page (keyset pagination)
+type pageQuery struct {
+	// limit is the number of rows in the page, or 0 if there is no limit
+	limit uint64
+	// orderField is the field that the rows are ordered by, which is _id if
+	// there is no order_by
+	orderField string
+	orderDesc  bool
+	// after is the cursor of the last row of the previous page, if any
+	after *pageCursor
+}
+
+// pageCursor is the position of a row in the order of a page. It is given to
+// clients as an opaque string
+type pageCursor struct {
+	Field string      `json:"f"`
+	Value interface{} `json:"v"`
+	ID    int64       `json:"id"`
+}
+
+// encode returns the cursor as an opaque string
+func (c pageCursor) encode() (string, error) {
+	b, err := json.Marshal(c)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode cursor: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// decodePageCursor decodes a cursor that was returned by encode
+func decodePageCursor(cursor string) (*pageCursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor: %s", cursor)
+	}
+	var c pageCursor
+	// Keep numbers as they are, so that large integers are not rounded
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	if err := dec.Decode(&c); err != nil {
+		return nil, fmt.Errorf("invalid cursor: %s", cursor)
+	}
+	if num, ok := c.Value.(json.Number); ok {
+		if i, err := num.Int64(); err == nil {
+			c.Value = i
+		} else if f, err := num.Float64(); err == nil {
+			c.Value = f
+		}
+	}
+	return &c, nil
+}
+
+// psqlPageArgs returns the page of a root table from the arguments of its
+// field, or nil if the table is not paginated.
+// The table is paginated if it has an `after` argument, or a `first` argument
+// without `_since` (which has its own cursor). The rows of a page can only be
+// ordered by one order_by field, so a table with more is not paginated,
+// unless it has an `after` argument, which is an error
+func psqlPageArgs(table core.Table, field *ast.Field, opts queryOptions) (*pageQuery, error) {
+	var firstArg, afterArg, sinceArg, lastArg, orderByArg *ast.Argument
+	for _, arg := range field.Arguments {
+		switch arg.Name.Value {
+		case firstID:
+			firstArg = arg
+		case afterID:
+			afterArg = arg
+		case sinceID:
+			sinceArg = arg
+		case lastID:
+			lastArg = arg
+		case orderByID:
+			orderByArg = arg
+		}
+	}
+	if afterArg == nil && (firstArg == nil || sinceArg != nil) {
+		return nil, nil
+	}
+	if afterArg != nil && sinceArg != nil {
+		return nil, fmt.Errorf("cannot provide both '%s' and '%s' arguments for table %s", afterID, sinceID, table.Name)
+	}
+	if afterArg != nil && lastArg != nil {
+		return nil, fmt.Errorf("cannot provide both '%s' and 'last' arguments for table %s", afterID, table.Name)
+	}
+
+	page := &pageQuery{
+		limit:      opts.limits.tableLimit(),
+		orderField: tableIDField,
+	}
+	if orderByArg != nil {
+		orderByFields, ok := orderByArg.Value.GetValue().([]*ast.ObjectField)
+		if !ok {
+			return nil, fmt.Errorf("invalid format for 'order_by' argument")
+		}
+		if len(orderByFields) > 1 {
+			if afterArg != nil {
+				return nil, fmt.Errorf("cannot provide '%s' argument for table %s ordered by more than one field", afterID, table.Name)
+			}
+			return nil, nil
+		}
+		if len(orderByFields) == 1 {
+			order, _ := orderByFields[0].Value.GetValue().(string)
+			page.orderField = orderByFields[0].Name.Value
+			page.orderDesc = strings.ToUpper(order) == orderDesc
+			if !tableHasColumn(table, page.orderField) {
+				return nil, fmt.Errorf("unknown field in 'order_by' for table %s: %s", table.Name, page.orderField)
+			}
+		}
+	}
+	if firstArg != nil {
+		// The root table's path is its name
+		n, err := psqlLimitArg(table.Name, table.Name, firstArg, opts)
+		if err != nil {
+			return nil, err
+		}
+		page.limit = n
+	}
+	if afterArg != nil {
+		cursor, ok := afterArg.Value.GetValue().(string)
+		if !ok {
+			return nil, fmt.Errorf("could not convert the value of the argument `%s`: %#v", afterID, afterArg.Value.GetValue())
+		}
+		after, err := decodePageCursor(cursor)
+		if err != nil {
+			return nil, fmt.Errorf("the value of the argument `%s` for table %s: %w", afterID, table.Name, err)
+		}
+		if after.Field != page.orderField {
+			return nil, fmt.Errorf("the cursor of the argument `%s` for table %s is for a different order_by", afterID, table.Name)
+		}
+		page.after = after
+	}
+	return page, nil
+}
+
+// psqlKeysetCondition returns the condition for the rows that come after the
+// cursor of the page, in the order of the page: by the order field, with nulls
+// last in ascending order (and first in descending order) as in postgres, and
+// then by ascending _id.
+// In ascending order this is WHERE (order_col, _id) > (?, ?)
+func psqlKeysetCondition(alias string, page *pageQuery) sq.Sqlizer {
+	var (
+		after = page.after
+		col   = tableColumn(alias, page.orderField)
+		id    = tableColumn(alias, tableIDField)
+	)
+	if page.orderField == tableIDField {
+		if page.orderDesc {
+			return sq.Lt{id: after.ID}
+		}
+		return sq.Gt{id: after.ID}
+	}
+	sameValueAfter := sq.And{sq.Eq{col: after.Value}, sq.Gt{id: after.ID}}
+	switch {
+	case after.Value == nil && page.orderDesc:
+		// The nulls come first, so all the values come after
+		return sq.Or{sq.NotEq{col: nil}, sameValueAfter}
+	case after.Value == nil:
+		// The nulls come last, so only nulls come after
+		return sameValueAfter
+	case page.orderDesc:
+		return sq.Or{sq.Lt{col: after.Value}, sameValueAfter}
+	default:
+		return sq.Or{
+			sq.Expr("("+col+", "+id+") > (?, ?)", after.Value, after.ID),
+			sq.Eq{col: nil},
+		}
+	}
+}
+
+// full returns whether the rows of a root table have more rows than the page,
+// which means there is a next page
+func (p *pageQuery) full(rows interface{}) bool {
+	rowVals, _ := rows.([]map[string]interface{})
+	return p.limit > 0 && uint64(len(rowVals)) > p.limit
+}
+
+// psqlPageRows returns the rows of the page from the rows of a root table,
+// which has one row more than the page if there is a next page, and the page
+// info for the rows
+func psqlPageRows(page *pageQuery, rows interface{}) (interface{}, pageInfo, error) {
+	var (
+		info       pageInfo
+		rowVals, _ = rows.([]map[string]interface{})
+	)
+	if page.full(rowVals) {
+		rowVals = rowVals[:page.limit]
+		info.HasNextPage = true
+	}
+	if len(rowVals) == 0 {
+		return rows, info, nil
+	}
+	last := rowVals[len(rowVals)-1]
+	id, err := psqlRowID(last[tableIDField])
+	if err != nil {
+		return nil, info, err
+	}
+	cursor := pageCursor{
+		Field: page.orderField,
+		Value: last[page.orderField],
+		ID:    id,
+	}
+	if page.orderField == tableIDField {
+		cursor.Value = id
+	}
+	info.EndCursor, err = cursor.encode()
+	if err != nil {
+		return nil, info, err
+	}
+	return rowVals, info, nil
+}
+
+// psqlRowID returns the _id of a row as an int64
+func psqlRowID(id interface{}) (int64, error) {
+	switch v := id.(type) {
+	case int32:
+		return int64(v), nil
+	case int64:
+		return v, nil
+	default:
+		return 0, fmt.Errorf("unexpected type for %s: %T", tableIDField, id)
+	}
+}
@@ -0,0 +1,220 @@
+package store
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/valocode/bubbly/api/core"
+	"github.com/valocode/bubbly/env"
+	"github.com/valocode/bubbly/test"
+)
+
+func TestPageCursor(t *testing.T) {
+	tcs := []struct {
+		desc   string
+		cursor pageCursor
+	}{
+		{desc: "id", cursor: pageCursor{Field: tableIDField, Value: int64(42), ID: 42}},
+		{desc: "string", cursor: pageCursor{Field: "name", Value: "a", ID: 3}},
+		{desc: "large integer", cursor: pageCursor{Field: "rank", Value: int64(1) << 60, ID: 3}},
+		{desc: "float", cursor: pageCursor{Field: "score", Value: 1.5, ID: 3}},
+		{desc: "null", cursor: pageCursor{Field: "name", Value: nil, ID: 3}},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.desc, func(t *testing.T) {
+			encoded, err := tc.cursor.encode()
+			require.NoError(t, err)
+			decoded, err := decodePageCursor(encoded)
+			require.NoError(t, err)
+			assert.Equal(t, tc.cursor, *decoded)
+		})
+	}
+
+	_, err := decodePageCursor("not a cursor!")
+	assert.Error(t, err)
+}
+
+func TestPsqlKeysetCondition(t *testing.T) {
+	tcs := []struct {
+		desc     string
+		page     pageQuery
+		expected string
+		args     []interface{}
+	}{
+		{
+			desc:     "id",
+			page:     pageQuery{orderField: tableIDField, after: &pageCursor{Field: tableIDField, Value: int64(4), ID: 4}},
+			expected: "t._id > ?",
+			args:     []interface{}{int64(4)},
+		},
+		{
+			desc:     "id desc",
+			page:     pageQuery{orderField: tableIDField, orderDesc: true, after: &pageCursor{Field: tableIDField, Value: int64(4), ID: 4}},
+			expected: "t._id < ?",
+			args:     []interface{}{int64(4)},
+		},
+		{
+			desc:     "asc",
+			page:     pageQuery{orderField: "name", after: &pageCursor{Field: "name", Value: "a", ID: 4}},
+			expected: "((t.name, t._id) > (?, ?) OR t.name IS NULL)",
+			args:     []interface{}{"a", int64(4)},
+		},
+		{
+			desc:     "asc null",
+			page:     pageQuery{orderField: "name", after: &pageCursor{Field: "name", ID: 4}},
+			expected: "(t.name IS NULL AND t._id > ?)",
+			args:     []interface{}{int64(4)},
+		},
+		{
+			desc:     "desc",
+			page:     pageQuery{orderField: "name", orderDesc: true, after: &pageCursor{Field: "name", Value: "a", ID: 4}},
+			expected: "(t.name < ? OR (t.name = ? AND t._id > ?))",
+			args:     []interface{}{"a", "a", int64(4)},
+		},
+		{
+			desc:     "desc null",
+			page:     pageQuery{orderField: "name", orderDesc: true, after: &pageCursor{Field: "name", ID: 4}},
+			expected: "(t.name IS NOT NULL OR (t.name IS NULL AND t._id > ?))",
+			args:     []interface{}{int64(4)},
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.desc, func(t *testing.T) {
+			sqlStr, args, err := psqlKeysetCondition("t", &tc.page).ToSql()
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, sqlStr)
+			assert.Equal(t, tc.args, args)
+		})
+	}
+}
+
+func TestPageQuery(t *testing.T) {
+	bCtx := env.NewBubblyContext()
+	resource := test.RunPostgresDocker(bCtx, t)
+	bCtx.StoreConfig.PostgresAddr = fmt.Sprintf("localhost:%s", resource.GetPort("5432/tcp"))
+
+	s, err := New(bCtx)
+	require.NoError(t, err)
+	err = s.Apply(DefaultTenantName, core.Tables{
+		{
+			Name: "item",
+			Fields: []core.TableField{
+				{Name: "name", Type: cty.String, Unique: true},
+				{Name: "rank", Type: cty.Number},
+			},
+		},
+	}, false)
+	require.NoError(t, err)
+
+	// Some of the items have the same rank, and some have no rank, so that
+	// the pages have to continue in the middle of the same value
+	ranks := map[string]int64{"a": 2, "b": 1, "c": 2, "e": 2, "g": 3}
+	var (
+		data  core.DataBlocks
+		names []string
+	)
+	for _, name := range []string{"a", "b", "c", "d", "e", "f", "g"} {
+		names = append(names, name)
+		values := map[string]cty.Value{"name": cty.StringVal(name)}
+		if rank, ok := ranks[name]; ok {
+			values["rank"] = cty.NumberIntVal(rank)
+		}
+		data = append(data, core.Data{
+			TableName: "item",
+			Fields:    &core.DataFields{Values: values},
+		})
+	}
+	require.NoError(t, s.Save(DefaultTenantName, data))
+
+	// query returns the names of the items in the page, and its page info
+	query := func(args string) ([]string, pageInfo) {
+		t.Helper()
+		result, err := s.Query(DefaultTenantName, `{ item(`+args+`) { name } }`)
+		require.NoError(t, err)
+		require.Empty(t, result.Errors)
+		var pageNames []string
+		for _, item := range result.Data.(map[string]interface{})["item"].([]interface{}) {
+			pageNames = append(pageNames, item.(map[string]interface{})["name"].(string))
+		}
+		pages, ok := result.Extensions[pageInfoExtension].(map[string]pageInfo)
+		require.True(t, ok, "result has no page info")
+		return pageNames, pages["item"]
+	}
+
+	tcs := []struct {
+		desc     string
+		orderBy  string
+		expected []string
+	}{
+		{desc: "no order", expected: names},
+		{desc: "id desc", orderBy: `order_by: {_id: desc}`, expected: []string{"g", "f", "e", "d", "c", "b", "a"}},
+		{desc: "asc", orderBy: `order_by: {rank: asc}`, expected: []string{"b", "a", "c", "e", "g", "d", "f"}},
+		{desc: "desc", orderBy: `order_by: {rank: desc}`, expected: []string{"d", "f", "g", "a", "c", "e", "b"}},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.desc, func(t *testing.T) {
+			var (
+				paged  []string
+				cursor string
+			)
+			// Page through the items, which should return every item once in
+			// the order of the query
+			for i := 0; ; i++ {
+				require.Less(t, i, len(names), "too many pages")
+				args := tc.orderBy + ` first: 2`
+				if cursor != "" {
+					args += ` after: "` + cursor + `"`
+				}
+				pageNames, info := query(args)
+				require.LessOrEqual(t, len(pageNames), 2)
+				paged = append(paged, pageNames...)
+				if !info.HasNextPage {
+					break
+				}
+				require.NotEmpty(t, info.EndCursor)
+				cursor = info.EndCursor
+			}
+			assert.Equal(t, tc.expected, paged)
+		})
+	}
+
+	// An empty page has no cursor and no next page
+	_, info := query(`first: 2`)
+	_, info = query(`first: 10, after: "` + info.EndCursor + `"`)
+	assert.False(t, info.HasNextPage)
+	require.NotEmpty(t, info.EndCursor)
+	pageNames, info := query(`after: "` + info.EndCursor + `"`)
+	assert.Empty(t, pageNames)
+	assert.Equal(t, pageInfo{}, info)
+
+	// The page info is only returned for paginated tables
+	result, err := s.Query(DefaultTenantName, `{ item { name } }`)
+	require.NoError(t, err)
+	require.Empty(t, result.Errors)
+	assert.NotContains(t, result.Extensions, pageInfoExtension)
+
+	invalid := []string{
+		`{ item(after: "not a cursor!") { name } }`,
+		`{ item(after: "` + encodeCursor(t, pageCursor{Field: "rank", ID: 1}) + `") { name } }`,
+		`{ item(first: 1, last: 1) { name } }`,
+		`{ item(after: "` + encodeCursor(t, pageCursor{Field: tableIDField, ID: 1}) + `", _since: "0") { name } }`,
+		`{ item(after: "` + encodeCursor(t, pageCursor{Field: tableIDField, ID: 1}) + `", order_by: {rank: asc, name: asc}) { name } }`,
+	}
+	for _, q := range invalid {
+		result, err := s.Query(DefaultTenantName, q)
+		require.NoError(t, err)
+		assert.NotEmpty(t, result.Errors, q)
+	}
+}
+
+// encodeCursor returns the cursor as the value of an `after` argument
+func encodeCursor(t *testing.T, c pageCursor) string {
+	t.Helper()
+	encoded, err := c.encode()
+	require.NoError(t, err)
+	return encoded
+}
@@ -6,13 +6,26 @@ import (
 )
 
 // Provider provides an interface for persisting readiness data.
+// Every provider should be added to the conformanceProviders of the store
+// tests, which check that the providers behave the same.
 type provider interface {
 	Tenants() ([]string, error)
 	CreateTenant(string) error
 	Close()
+	// Ping checks that the connection to the provider's database is alive
+	Ping() error
 	Apply(string, *bubblySchema) error
 	Migrate(string, *bubblySchema, schemaUpdates) error
+	// MigrationSQL returns the SQL statements that Migrate runs for the
+	// changes, without running them
+	MigrationSQL(string, *bubblySchema, schemaUpdates) ([]string, error)
 	Save(*env.BubblyContext, string, *SchemaGraph, dataTree) error
+	// DeleteResource deletes a resource and its events, and returns whether
+	// the resource existed
+	DeleteResource(string, string) (bool, error)
 	ResolveQuery(string, *SchemaGraph, graphql.ResolveParams) (interface{}, error)
 	HasTable(string, string) (bool, error)
+	// Upgrade adds the columns that the store maintains itself to the tables
+	// of a tenant that were created by an older version
+	Upgrade(string) error
 }
@@ -0,0 +1,110 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/rs/zerolog"
+
+	"github.com/valocode/bubbly/env"
+)
+
+// redactedArg replaces the values of SQL arguments in query logs when logging
+// of query arguments is disabled
+const redactedArg = "[redacted]"
+
+// QueryLog describes a SQL statement that was run by a store provider
+type QueryLog struct {
+	SQL string
+	// Args are the arguments of the statement, which are redacted unless
+	// logging of query arguments is enabled in the store config
+	Args     []interface{}
+	Duration time.Duration
+	Err      error
+}
+
+// QueryHook is called for each SQL statement that is run by a store provider,
+// e.g. when saving data, applying a schema or resolving a query
+type QueryHook func(QueryLog)
+
+// Option configures a Store
+type Option func(*options)
+
+type options struct {
+	queryHook     QueryHook
+	rowFilterHook RowFilterHook
+}
+
+// WithQueryHook sets the hook that is called for each SQL statement the store
+// runs. By default, statements are logged by the BubblyContext logger at debug
+// level
+func WithQueryHook(hook QueryHook) Option {
+	return func(o *options) {
+		o.queryHook = hook
+	}
+}
+
+func newOptions(bCtx *env.BubblyContext, opts []Option) *options {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	// Only default to logging the statements if they would be logged, so that
+	// the statements are not collected otherwise
+	if o.queryHook == nil && bCtx.Logger.GetLevel() <= zerolog.DebugLevel {
+		o.queryHook = func(q QueryLog) {
+			bCtx.Logger.Debug().
+				Str("sql", q.SQL).
+				Interface("args", q.Args).
+				Dur("duration", q.Duration).
+				AnErr("error", q.Err).
+				Msg("store provider ran SQL statement")
+		}
+	}
+	return &o
+}
+
+// psqlLogger is a pgx.Logger which calls the query hook for every SQL statement
+// that is run, and passes any other errors to the underlying logger
+type psqlLogger struct {
+	logger  pgx.Logger
+	hook    QueryHook
+	logArgs bool
+}
+
+func (l *psqlLogger) Log(ctx context.Context, level pgx.LogLevel, msg string, data map[string]interface{}) {
+	switch msg {
+	case "Query", "Exec":
+		if l.hook != nil {
+			l.hook(l.queryLog(data))
+		}
+	}
+	// Keep passing only errors to the underlying logger, as before statements
+	// were logged
+	if level <= pgx.LogLevelError {
+		l.logger.Log(ctx, level, msg, data)
+	}
+}
+
+func (l *psqlLogger) queryLog(data map[string]interface{}) QueryLog {
+	var q QueryLog
+	q.SQL, _ = data["sql"].(string)
+	q.Duration, _ = data["time"].(time.Duration)
+	if err, ok := data["err"].(error); ok {
+		q.Err = err
+	} else if data["err"] != nil {
+		q.Err = errors.New("unknown error")
+	}
+	args, _ := data["args"].([]interface{})
+	if l.logArgs {
+		q.Args = args
+	} else {
+		q.Args = make([]interface{}, len(args))
+		for idx := range args {
+			q.Args[idx] = redactedArg
+		}
+	}
+	return q
+}
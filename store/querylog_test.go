@@ -0,0 +1,140 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/valocode/bubbly/env"
+	"github.com/valocode/bubbly/test"
+)
+
+// recordLogger is a pgx.Logger that records the messages it logs
+type recordLogger struct {
+	msgs []string
+}
+
+func (l *recordLogger) Log(_ context.Context, _ pgx.LogLevel, msg string, _ map[string]interface{}) {
+	l.msgs = append(l.msgs, msg)
+}
+
+func TestPsqlLogger(t *testing.T) {
+	tcs := []struct {
+		desc     string
+		logArgs  bool
+		level    pgx.LogLevel
+		msg      string
+		expected []QueryLog
+		passed   []string
+	}{
+		{
+			desc:    "query with args",
+			logArgs: true,
+			level:   pgx.LogLevelInfo,
+			msg:     "Query",
+			expected: []QueryLog{
+				{SQL: "SELECT $1", Args: []interface{}{"secret"}, Duration: time.Second},
+			},
+		},
+		{
+			desc:    "query with redacted args",
+			logArgs: false,
+			level:   pgx.LogLevelInfo,
+			msg:     "Exec",
+			expected: []QueryLog{
+				{SQL: "SELECT $1", Args: []interface{}{redactedArg}, Duration: time.Second},
+			},
+		},
+		{
+			desc:    "other info message",
+			logArgs: true,
+			level:   pgx.LogLevelInfo,
+			msg:     "closed connection",
+		},
+		{
+			desc:    "other error message",
+			logArgs: true,
+			level:   pgx.LogLevelError,
+			msg:     "connection failed",
+			passed:  []string{"connection failed"},
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.desc, func(t *testing.T) {
+			var (
+				logs     []QueryLog
+				recorder = &recordLogger{}
+				logger   = &psqlLogger{
+					logger:  recorder,
+					hook:    func(q QueryLog) { logs = append(logs, q) },
+					logArgs: tc.logArgs,
+				}
+			)
+			logger.Log(context.Background(), tc.level, tc.msg, map[string]interface{}{
+				"sql":  "SELECT $1",
+				"args": []interface{}{"secret"},
+				"time": time.Second,
+			})
+			assert.Equal(t, tc.expected, logs)
+			assert.Equal(t, tc.passed, recorder.msgs)
+		})
+	}
+}
+
+func TestQueryHook(t *testing.T) {
+	bCtx := env.NewBubblyContext()
+	resource := test.RunPostgresDocker(bCtx, t)
+	bCtx.StoreConfig.PostgresAddr = fmt.Sprintf("localhost:%s", resource.GetPort("5432/tcp"))
+	bCtx.StoreConfig.LogQueryArgs = false
+
+	var (
+		mu   sync.Mutex
+		logs []QueryLog
+	)
+	s, err := New(bCtx, WithQueryHook(func(q QueryLog) {
+		mu.Lock()
+		defer mu.Unlock()
+		logs = append(logs, q)
+	}))
+	require.NoError(t, err)
+	applySchemaOrDie(t, bCtx, s, filepath.FromSlash("testdata/tables.hcl"))
+
+	// statements returns the logged statements that contain the given string
+	// and resets the logs
+	statements := func(contains string) []QueryLog {
+		mu.Lock()
+		defer mu.Unlock()
+		var matches []QueryLog
+		for _, q := range logs {
+			if strings.Contains(q.SQL, contains) {
+				matches = append(matches, q)
+			}
+		}
+		logs = nil
+		return matches
+	}
+	statements("")
+
+	loadTestDataOrDie(t, bCtx, s, filepath.FromSlash("testdata/data.hcl"))
+	saves := statements("INSERT INTO")
+	require.NotEmpty(t, saves, "no statements logged for save")
+	for _, q := range saves {
+		for _, arg := range q.Args {
+			assert.Equal(t, redactedArg, arg)
+		}
+	}
+
+	_, err = s.Query(DefaultTenantName, `{ root(name: "first_root") { name } }`)
+	require.NoError(t, err)
+	queries := statements("SELECT")
+	require.NotEmpty(t, queries, "no statements logged for query")
+	assert.Contains(t, queries[len(queries)-1].SQL, "root")
+}
@@ -0,0 +1,96 @@
+package store
+
+import (
+	"context"
+	"fmt"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/valocode/bubbly/agent/component"
+	"github.com/valocode/bubbly/api/core"
+)
+
+// RowFilter is an additional condition on the rows of a table that a query can
+// return. The keys are the columns of the table, which can be its fields, its
+// join fields (e.g. "project_id") or "_id", and the rows must have the given
+// values. If a value is a slice, the rows must have one of the values in it
+type RowFilter map[string]interface{}
+
+// RowFilterHook returns the filter on the rows of a table that a query can
+// return, e.g. based on the identity of the caller, which is in the context
+// given to Store.QueryContext (see AuthFromContext). A nil filter means that
+// all rows can be returned, and an error fails the query.
+// It is called for every table in a query, including nested tables, and also
+// for the internal queries of the store, in which case there is no auth in the
+// context
+type RowFilterHook func(ctx context.Context, table string) (RowFilter, error)
+
+// WithRowFilterHook sets the hook that is called to filter the rows of each
+// table in a query. Query results are not cached when a hook is set, because
+// the same query can return different results for different callers
+func WithRowFilterHook(hook RowFilterHook) Option {
+	return func(o *options) {
+		o.rowFilterHook = hook
+	}
+}
+
+type authContextKey struct{}
+
+// ContextWithAuth returns a context which contains the auth of the caller of a
+// query, to be used by the RowFilterHook
+func ContextWithAuth(ctx context.Context, auth *component.MessageAuth) context.Context {
+	return context.WithValue(ctx, authContextKey{}, auth)
+}
+
+// AuthFromContext returns the auth of the caller of a query from the context,
+// or nil if there is none
+func AuthFromContext(ctx context.Context) *component.MessageAuth {
+	if ctx == nil {
+		return nil
+	}
+	auth, _ := ctx.Value(authContextKey{}).(*component.MessageAuth)
+	return auth
+}
+
+// rowFilterFunc returns the filter on the rows of a table in a query, or nil
+// if the rows are not filtered
+type rowFilterFunc func(table string) (RowFilter, error)
+
+// newRowFilterFunc returns the rowFilterFunc for a query with the given context,
+// or nil if there is no hook
+func newRowFilterFunc(ctx context.Context, hook RowFilterHook) rowFilterFunc {
+	if hook == nil {
+		return nil
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return func(table string) (RowFilter, error) {
+		return hook(ctx, table)
+	}
+}
+
+// psqlRowFilter returns the SQL condition of a row filter for the given table,
+// which is aliased in the SQL query
+func psqlRowFilter(table core.Table, alias string, filter RowFilter) (sq.Eq, error) {
+	eq := make(sq.Eq, len(filter))
+	for column, value := range filter {
+		if !tableHasColumn(table, column) {
+			return nil, fmt.Errorf("row filter for table %s has unknown column %s", table.Name, column)
+		}
+		eq[tableColumn(alias, column)] = value
+	}
+	return eq, nil
+}
+
+// tableHasColumn returns whether the table has a column with the given name,
+// which includes its join fields and ID
+func tableHasColumn(table core.Table, column string) bool {
+	if column == tableIDField {
+		return true
+	}
+	if _, ok := tableField(table, column); ok {
+		return true
+	}
+	_, ok := tableJoin(table, column)
+	return ok
+}
@@ -0,0 +1,168 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/valocode/bubbly/agent/component"
+	"github.com/valocode/bubbly/api/core"
+	"github.com/valocode/bubbly/env"
+	"github.com/valocode/bubbly/test"
+)
+
+func TestPsqlRowFilter(t *testing.T) {
+	table := core.Table{
+		Name:   "t",
+		Fields: []core.TableField{{Name: "f1", Type: cty.String}},
+		Joins:  []core.TableJoin{{Table: "j"}},
+	}
+	tcs := []struct {
+		desc     string
+		filter   RowFilter
+		expected sq.Eq
+		wantErr  bool
+	}{
+		{
+			desc:     "field",
+			filter:   RowFilter{"f1": "a"},
+			expected: sq.Eq{"t_0.f1": "a"},
+		},
+		{
+			desc:     "join and id",
+			filter:   RowFilter{"j_id": 1, tableIDField: []int{1, 2}},
+			expected: sq.Eq{"t_0.j_id": 1, "t_0._id": []int{1, 2}},
+		},
+		{
+			desc:    "unknown column",
+			filter:  RowFilter{"f2": "a"},
+			wantErr: true,
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.desc, func(t *testing.T) {
+			eq, err := psqlRowFilter(table, "t_0", tc.filter)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, eq)
+		})
+	}
+}
+
+func TestNewRowFilterFunc(t *testing.T) {
+	assert.Nil(t, newRowFilterFunc(context.Background(), nil))
+
+	auth := &component.MessageAuth{Organization: "org"}
+	ctx := ContextWithAuth(context.Background(), auth)
+	rowFilter := newRowFilterFunc(ctx, func(ctx context.Context, table string) (RowFilter, error) {
+		return RowFilter{"organization": AuthFromContext(ctx).Organization}, nil
+	})
+	filter, err := rowFilter("t")
+	require.NoError(t, err)
+	assert.Equal(t, RowFilter{"organization": "org"}, filter)
+}
+
+// TestRowFilterHook checks that the rows returned by queries are restricted by
+// the row filter hook, for both root and nested tables
+func TestRowFilterHook(t *testing.T) {
+	bCtx := env.NewBubblyContext()
+	resource := test.RunPostgresDocker(bCtx, t)
+	bCtx.StoreConfig.PostgresAddr = fmt.Sprintf("localhost:%s", resource.GetPort("5432/tcp"))
+
+	s, err := New(bCtx)
+	require.NoError(t, err)
+	applySchemaOrDie(t, bCtx, s, filepath.FromSlash("testdata/tables.hcl"))
+	loadTestDataOrDie(t, bCtx, s, filepath.FromSlash("testdata/data.hcl"))
+
+	// The hook only lets the "org" organization see the first root, and
+	// none of the children
+	hook := func(ctx context.Context, table string) (RowFilter, error) {
+		auth := AuthFromContext(ctx)
+		if auth == nil {
+			return nil, nil
+		}
+		if auth.Organization == "fail" {
+			return nil, errors.New("unauthorized")
+		}
+		switch table {
+		case "root":
+			return RowFilter{"name": "first_root"}, nil
+		case "child_a":
+			return RowFilter{"name": "no_child"}, nil
+		}
+		return nil, nil
+	}
+	filteredStore, err := New(bCtx, WithRowFilterHook(hook))
+	require.NoError(t, err)
+
+	const query = "{ root { name child_a { name } } }"
+	tcs := []struct {
+		desc     string
+		store    *Store
+		auth     *component.MessageAuth
+		roots    []string
+		children int
+		wantErr  bool
+	}{
+		{
+			desc:     "no hook",
+			store:    s,
+			auth:     &component.MessageAuth{Organization: "org"},
+			roots:    []string{"first_root", "second_root"},
+			children: 1,
+		},
+		{
+			desc:     "hook without auth",
+			store:    filteredStore,
+			roots:    []string{"first_root", "second_root"},
+			children: 1,
+		},
+		{
+			desc:     "hook with auth",
+			store:    filteredStore,
+			auth:     &component.MessageAuth{Organization: "org"},
+			roots:    []string{"first_root"},
+			children: 0,
+		},
+		{
+			desc:    "hook error",
+			store:   filteredStore,
+			auth:    &component.MessageAuth{Organization: "fail"},
+			wantErr: true,
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.desc, func(t *testing.T) {
+			ctx := ContextWithAuth(context.Background(), tc.auth)
+			result, err := tc.store.QueryContext(ctx, DefaultTenantName, query)
+			require.NoError(t, err)
+			if tc.wantErr {
+				assert.NotEmpty(t, result.Errors)
+				return
+			}
+			require.Empty(t, result.Errors)
+
+			var (
+				roots    []string
+				children int
+			)
+			for _, root := range result.Data.(map[string]interface{})["root"].([]interface{}) {
+				root := root.(map[string]interface{})
+				roots = append(roots, root["name"].(string))
+				children += len(root["child_a"].([]interface{}))
+			}
+			assert.ElementsMatch(t, tc.roots, roots)
+			assert.Equal(t, tc.children, children)
+		})
+	}
+}
@@ -0,0 +1,111 @@
+package store
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/valocode/bubbly/api/core"
+)
+
+// saveIDTTL is how long the ID of a successful save is kept, so that a retry
+// of the save within that time is not saved again
+const saveIDTTL = time.Hour
+
+// saveIDCache keeps the IDs of the saves made with SaveOnce
+type saveIDCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[string]*saveIDEntry
+}
+
+// saveIDEntry is a save with an ID, which is in progress until done is closed
+type saveIDEntry struct {
+	done    chan struct{}
+	expires time.Time
+	// saved is whether the save succeeded, and can only be read after done
+	// is closed
+	saved bool
+}
+
+func newSaveIDCache(ttl time.Duration) *saveIDCache {
+	return &saveIDCache{
+		ttl:     ttl,
+		entries: make(map[string]*saveIDEntry),
+	}
+}
+
+// start returns the entry for the key, and whether the save is new. If it is
+// new, the caller must complete the entry with either finish or abort
+func (c *saveIDCache) start(key string) (*saveIDEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	// Remove the expired entries, so that the cache does not grow forever
+	for k, e := range c.entries {
+		if !e.expires.IsZero() && !now.Before(e.expires) {
+			delete(c.entries, k)
+		}
+	}
+	if e, ok := c.entries[key]; ok {
+		return e, false
+	}
+	e := &saveIDEntry{done: make(chan struct{})}
+	c.entries[key] = e
+	return e, true
+}
+
+// finish marks the entry as saved, for the retries of the save
+func (c *saveIDCache) finish(e *saveIDEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e.saved = true
+	e.expires = time.Now().Add(c.ttl)
+	close(e.done)
+}
+
+// abort removes the entry for a save that failed, so that a retry of the save
+// is saved again
+func (c *saveIDCache) abort(key string, e *saveIDEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entries, key)
+	close(e.done)
+}
+
+// SaveOnce saves the data like Save, unless data with the same ID has already
+// been saved for the tenant, and returns whether the data was saved.
+// The ID identifies the data, such as the run and the task of a pipeline that
+// produced it, so that a save that is retried after e.g. its reply was lost
+// does not save the data twice. This also works for tables without unique
+// fields, which Save cannot upsert.
+// A save that is retried while the original save is still in progress waits
+// for it. Saves that fail are not remembered, so that they can be retried.
+// The IDs are kept in memory for an hour, and not across restarts
+func (s *Store) SaveOnce(tenant string, id string, data core.DataBlocks) (bool, error) {
+	if id == "" {
+		return false, fmt.Errorf("the ID of the data to save cannot be empty")
+	}
+	key := tenant + "/" + id
+	for {
+		e, isNew := s.saveIDs.start(key)
+		if isNew {
+			if err := s.Save(tenant, data); err != nil {
+				s.saveIDs.abort(key, e)
+				return false, err
+			}
+			s.saveIDs.finish(e)
+			return true, nil
+		}
+		<-e.done
+		if e.saved {
+			s.bCtx.Logger.Debug().
+				Str("tenant", tenant).
+				Str("id", id).
+				Msg("data with the same ID has already been saved")
+			return false, nil
+		}
+		// The original save failed, so try to save the data again
+	}
+}
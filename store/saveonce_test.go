@@ -0,0 +1,135 @@
+package store
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/valocode/bubbly/api/core"
+	"github.com/valocode/bubbly/env"
+	"github.com/valocode/bubbly/test"
+)
+
+func TestSaveIDCache(t *testing.T) {
+	c := newSaveIDCache(time.Hour)
+
+	e, isNew := c.start("a")
+	require.True(t, isNew)
+	// A retry waits for the original save
+	retry, isNew := c.start("a")
+	require.False(t, isNew)
+	assert.Same(t, e, retry)
+	c.finish(e)
+	<-retry.done
+	assert.True(t, retry.saved)
+
+	// A failed save is not remembered
+	e, isNew = c.start("b")
+	require.True(t, isNew)
+	c.abort("b", e)
+	<-e.done
+	assert.False(t, e.saved)
+	_, isNew = c.start("b")
+	assert.True(t, isNew)
+
+	// Expired saves are removed
+	c = newSaveIDCache(0)
+	e, _ = c.start("a")
+	c.finish(e)
+	_, isNew = c.start("a")
+	assert.True(t, isNew)
+}
+
+// TestSaveOnce tests that retries of a save with the same ID store the data
+// only once, also for a table without unique fields
+func TestSaveOnce(t *testing.T) {
+	bCtx := env.NewBubblyContext()
+	resource := test.RunPostgresDocker(bCtx, t)
+	bCtx.StoreConfig.PostgresAddr = fmt.Sprintf("localhost:%s", resource.GetPort("5432/tcp"))
+
+	s, err := New(bCtx)
+	require.NoError(t, err)
+	err = s.Apply(DefaultTenantName, core.Tables{
+		{
+			Name: "test_result",
+			Fields: []core.TableField{
+				{Name: "name", Type: cty.String},
+				{Name: "passed", Type: cty.Bool},
+			},
+		},
+	}, false)
+	require.NoError(t, err)
+
+	results := func(run string) core.DataBlocks {
+		var data core.DataBlocks
+		for _, name := range []string{"a", "b"} {
+			data = append(data, core.Data{
+				TableName: "test_result",
+				Fields: &core.DataFields{Values: map[string]cty.Value{
+					"name":   cty.StringVal(run + "/" + name),
+					"passed": cty.True,
+				}},
+			})
+		}
+		return data
+	}
+	count := func() int {
+		t.Helper()
+		result, err := s.Query(DefaultTenantName, `{ test_result { name } }`)
+		require.NoError(t, err)
+		require.Empty(t, result.Errors)
+		return len(result.Data.(map[string]interface{})["test_result"].([]interface{}))
+	}
+
+	saved, err := s.SaveOnce(DefaultTenantName, "run-1", results("run-1"))
+	require.NoError(t, err)
+	assert.True(t, saved)
+	// The retry of the save is not saved again
+	saved, err = s.SaveOnce(DefaultTenantName, "run-1", results("run-1"))
+	require.NoError(t, err)
+	assert.False(t, saved)
+	assert.Equal(t, 2, count())
+
+	// Concurrent retries are saved once
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		nSaved  int
+		retries = 5
+	)
+	for i := 0; i < retries; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			saved, err := s.SaveOnce(DefaultTenantName, "run-2", results("run-2"))
+			assert.NoError(t, err)
+			if saved {
+				mu.Lock()
+				nSaved++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, 1, nSaved)
+	assert.Equal(t, 4, count())
+
+	// A save that fails can be retried
+	invalid := results("run-3")
+	invalid[0].TableName = "unknown"
+	_, err = s.SaveOnce(DefaultTenantName, "run-3", invalid)
+	require.Error(t, err)
+	saved, err = s.SaveOnce(DefaultTenantName, "run-3", results("run-3"))
+	require.NoError(t, err)
+	assert.True(t, saved)
+	assert.Equal(t, 6, count())
+
+	// The ID cannot be empty
+	_, err = s.SaveOnce(DefaultTenantName, "", results("run-4"))
+	assert.Error(t, err)
+}
@@ -3,6 +3,8 @@ package store
 import (
 	"encoding/json"
 	"fmt"
+	"regexp"
+	"strings"
 
 	"github.com/valocode/bubbly/api/core"
 	"github.com/valocode/bubbly/bubbly/builtin"
@@ -52,6 +54,24 @@ func newBubblySchemaFromTables(tables core.Tables, internal bool) (*bubblySchema
 				return nil, fmt.Errorf("cannot modify builtin table %s", table.Name)
 			}
 		}
+		if err := validateUniqueFields(table); err != nil {
+			return nil, err
+		}
+		if err := validateIndexes(table); err != nil {
+			return nil, err
+		}
+		if err := validateFieldDefaults(table); err != nil {
+			return nil, err
+		}
+		if err := validateDerivedFields(table); err != nil {
+			return nil, err
+		}
+		if err := validateFieldEnums(table); err != nil {
+			return nil, err
+		}
+		if err := validateFieldRenames(table); err != nil {
+			return nil, err
+		}
 		schemaTables[table.Name] = table
 	}
 	schema := &bubblySchema{
@@ -60,6 +80,120 @@ func newBubblySchemaFromTables(tables core.Tables, internal bool) (*bubblySchema
 	return schema, nil
 }
 
+// extendBubblySchema returns a new schema containing the tables of the given
+// schema, with the tables added to it. Tables that already exist can only have
+// fields and joins added to them, and builtin tables cannot be changed
+func extendBubblySchema(schema *bubblySchema, tables core.Tables) (*bubblySchema, error) {
+	builtinTables := make(map[string]struct{})
+	for _, table := range FlattenTables(builtin.BuiltinTables, nil) {
+		builtinTables[table.Name] = struct{}{}
+	}
+	schemaTables := make(map[string]core.Table, len(schema.Tables))
+	for name, table := range schema.Tables {
+		schemaTables[name] = table
+	}
+	for _, table := range FlattenTables(tables, nil) {
+		if _, ok := builtinTables[table.Name]; ok {
+			// Builtin tables can be given as parents of new tables, so long
+			// as they are not changed themselves
+			if len(table.Fields) > 0 || len(table.Joins) > 0 || len(table.UniqueFields) > 0 ||
+				len(table.Indexes) > 0 || len(table.DerivedFields) > 0 {
+				return nil, fmt.Errorf("cannot modify builtin table %s", table.Name)
+			}
+			continue
+		}
+		if existing, ok := schemaTables[table.Name]; ok {
+			var err error
+			table, err = extendTable(existing, table)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if err := validateUniqueFields(table); err != nil {
+			return nil, err
+		}
+		if err := validateIndexes(table); err != nil {
+			return nil, err
+		}
+		if err := validateFieldDefaults(table); err != nil {
+			return nil, err
+		}
+		if err := validateDerivedFields(table); err != nil {
+			return nil, err
+		}
+		if err := validateFieldEnums(table); err != nil {
+			return nil, err
+		}
+		schemaTables[table.Name] = table
+	}
+	return &bubblySchema{
+		Tables: schemaTables,
+	}, nil
+}
+
+// extendTable adds the fields, joins, indexes and derived fields of the added
+// table that do not exist in the existing table. Existing fields, joins and
+// derived fields cannot be changed, and nothing can be added to the unique
+// constraint of the existing table, as the existing data might not satisfy it
+func extendTable(existing core.Table, added core.Table) (core.Table, error) {
+	table := existing
+	// Limit the capacity so that appending does not modify the existing table
+	table.Fields = existing.Fields[:len(existing.Fields):len(existing.Fields)]
+	table.Joins = existing.Joins[:len(existing.Joins):len(existing.Joins)]
+	table.Indexes = existing.Indexes[:len(existing.Indexes):len(existing.Indexes)]
+	table.DerivedFields = existing.DerivedFields[:len(existing.DerivedFields):len(existing.DerivedFields)]
+	for _, field := range added.Fields {
+		if curField, ok := tableField(existing, field.Name); ok {
+			if curField.Unique != field.Unique || !curField.Type.Equals(field.Type) ||
+				!fieldDefaultsEqual(curField, field) || !stringsEqual(curField.Enum, field.Enum) ||
+				curField.Hidden != field.Hidden {
+				return core.Table{}, fmt.Errorf("cannot change field %s of existing table %s", field.Name, existing.Name)
+			}
+			continue
+		}
+		if field.Unique {
+			return core.Table{}, fmt.Errorf("cannot add unique field %s to existing table %s", field.Name, existing.Name)
+		}
+		table.Fields = append(table.Fields, field)
+	}
+	for _, join := range added.Joins {
+		if curJoin, ok := tableJoin(existing, join.Table+tableJoinSuffix); ok {
+			if curJoin != join {
+				return core.Table{}, fmt.Errorf("cannot change join %s of existing table %s", join.Table, existing.Name)
+			}
+			continue
+		}
+		if join.Unique {
+			return core.Table{}, fmt.Errorf("cannot add unique join %s to existing table %s", join.Table, existing.Name)
+		}
+		table.Joins = append(table.Joins, join)
+	}
+	uniqueFields := make(map[string]struct{}, len(existing.UniqueFields))
+	for _, name := range existing.UniqueFields {
+		uniqueFields[name] = struct{}{}
+	}
+	for _, name := range added.UniqueFields {
+		if _, ok := uniqueFields[name]; !ok {
+			return core.Table{}, fmt.Errorf("cannot add unique field %s to existing table %s", name, existing.Name)
+		}
+	}
+	for _, index := range added.Indexes {
+		if !hasIndex(table.Indexes, index) {
+			table.Indexes = append(table.Indexes, index)
+		}
+	}
+	for _, field := range added.DerivedFields {
+		if curField, ok := tableDerivedField(existing, field.Name); ok {
+			if curField.Expr != field.Expr || !curField.Type.Equals(field.Type) {
+				return core.Table{}, fmt.Errorf("cannot change derived field %s of existing table %s", field.Name, existing.Name)
+			}
+			continue
+		}
+		table.DerivedFields = append(table.DerivedFields, field)
+	}
+	return table, nil
+}
+
 // bubblySchema contains the bubblySchema in a useable form, which is currently
 // a map of the tables.
 // This should be extended in the future to accommodate for schema diffing
@@ -117,3 +251,242 @@ func FlattenTables(tables core.Tables, parent *core.Table) core.Tables {
 	}
 	return curTables
 }
+
+// tableUniqueFields returns the names of the fields that make up the unique
+// constraint of a table, which are the fields and joins marked as unique and
+// the table's unique fields
+func tableUniqueFields(table core.Table) []string {
+	var (
+		uniqueFields = make([]string, 0)
+		added        = make(map[string]struct{})
+	)
+	addField := func(name string) {
+		if _, ok := added[name]; ok {
+			return
+		}
+		added[name] = struct{}{}
+		uniqueFields = append(uniqueFields, name)
+	}
+	for _, field := range table.Fields {
+		if field.Unique {
+			addField(field.Name)
+		}
+	}
+	for _, join := range table.Joins {
+		if join.Unique {
+			addField(join.Table + tableJoinSuffix)
+		}
+	}
+	for _, name := range table.UniqueFields {
+		addField(name)
+	}
+	return uniqueFields
+}
+
+// tableField returns the field of a table with the given name
+func tableField(table core.Table, name string) (core.TableField, bool) {
+	for _, field := range table.Fields {
+		if field.Name == name {
+			return field, true
+		}
+	}
+	return core.TableField{}, false
+}
+
+// tableColumnHidden returns whether the table has a field or join with the
+// given column name which is hidden from the GraphQL schema
+func tableColumnHidden(table core.Table, column string) bool {
+	if field, ok := tableField(table, column); ok {
+		return field.Hidden
+	}
+	join, ok := tableJoin(table, column)
+	return ok && join.Hidden
+}
+
+// tableJoin returns the join of a table whose field has the given name
+func tableJoin(table core.Table, name string) (core.TableJoin, bool) {
+	for _, join := range table.Joins {
+		if join.Table+tableJoinSuffix == name {
+			return join, true
+		}
+	}
+	return core.TableJoin{}, false
+}
+
+// validateUniqueFields checks that the unique fields of a table refer to its
+// fields or joins. The table should already be flattened, so that the joins
+// implied by nesting exist
+func validateUniqueFields(table core.Table) error {
+	for _, name := range table.UniqueFields {
+		if _, ok := tableField(table, name); ok {
+			continue
+		}
+		if _, ok := tableJoin(table, name); ok {
+			continue
+		}
+		return fmt.Errorf("unique field %s does not exist in table %s", name, table.Name)
+	}
+	return nil
+}
+
+// validateIndexes checks that the indexes of a table are not empty and refer
+// to its fields or joins. The table should already be flattened, so that the
+// joins implied by nesting exist
+func validateIndexes(table core.Table) error {
+	for _, index := range table.Indexes {
+		if len(index) == 0 {
+			return fmt.Errorf("index without fields in table %s", table.Name)
+		}
+		for _, name := range index {
+			if _, ok := tableField(table, name); ok {
+				continue
+			}
+			if _, ok := tableJoin(table, name); ok {
+				continue
+			}
+			return fmt.Errorf("index field %s does not exist in table %s", name, table.Name)
+		}
+	}
+	return nil
+}
+
+// tableIndexes returns the sets of fields of a table that are indexed, which
+// are the table's indexes followed by each of its joins, so that joining
+// tables does not need to scan the whole table
+func tableIndexes(table core.Table) [][]string {
+	indexes := make([][]string, 0, len(table.Indexes)+len(table.Joins))
+	for _, index := range table.Indexes {
+		if !hasIndex(indexes, index) {
+			indexes = append(indexes, index)
+		}
+	}
+	for _, join := range table.Joins {
+		index := []string{join.Table + tableJoinSuffix}
+		if !hasIndex(indexes, index) {
+			indexes = append(indexes, index)
+		}
+	}
+	return indexes
+}
+
+// hasIndex returns whether the indexes contain an index on the given fields,
+// in the same order
+func hasIndex(indexes [][]string, fields []string) bool {
+	key := strings.Join(fields, ",")
+	for _, index := range indexes {
+		if strings.Join(index, ",") == key {
+			return true
+		}
+	}
+	return false
+}
+
+// validateFieldDefaults checks that the default values of the fields of a
+// table are known values of the fields' types
+func validateFieldDefaults(table core.Table) error {
+	for _, field := range table.Fields {
+		if !field.HasDefault() {
+			continue
+		}
+		if !field.Default.IsWhollyKnown() {
+			return fmt.Errorf("default value of field %s in table %s must be known", field.Name, table.Name)
+		}
+		// The TableFieldDefaultNow value is a string, so it cannot be converted
+		// to any other type
+		if _, err := field.DefaultValue(); err != nil {
+			return fmt.Errorf("invalid default for table %s: %w", table.Name, err)
+		}
+	}
+	return nil
+}
+
+// validateFieldRenames checks that the fields of a table that are renamed are
+// renamed from a name that no other field of the table has, and that no two
+// fields are renamed from the same name
+func validateFieldRenames(table core.Table) error {
+	renamed := make(map[string]string)
+	for _, field := range table.Fields {
+		if field.RenamedFrom == "" {
+			continue
+		}
+		if field.RenamedFrom == field.Name {
+			return fmt.Errorf("field %s in table %s cannot be renamed from itself", field.Name, table.Name)
+		}
+		if _, ok := tableField(table, field.RenamedFrom); ok {
+			return fmt.Errorf("field %s in table %s is renamed from %s, which is another field of the table", field.Name, table.Name, field.RenamedFrom)
+		}
+		if other, ok := renamed[field.RenamedFrom]; ok {
+			return fmt.Errorf("fields %s and %s in table %s are both renamed from %s", other, field.Name, table.Name, field.RenamedFrom)
+		}
+		renamed[field.RenamedFrom] = field.Name
+	}
+	return nil
+}
+
+// enumValueRegexp matches the values that a GraphQL enum can have
+var enumValueRegexp = regexp.MustCompile(`^[_A-Za-z][_0-9A-Za-z]*$`)
+
+// validateFieldEnums checks that the fields of a table with an enum are
+// string fields, and that the values of the enum are distinct values that a
+// GraphQL enum can have, including the default value of the field
+func validateFieldEnums(table core.Table) error {
+	for _, field := range table.Fields {
+		if len(field.Enum) == 0 {
+			continue
+		}
+		if field.Type != cty.String {
+			return fmt.Errorf("enum of field %s in table %s requires a string field, not %s", field.Name, table.Name, field.Type.FriendlyName())
+		}
+		values := make(map[string]struct{}, len(field.Enum))
+		for _, val := range field.Enum {
+			if !enumValueRegexp.MatchString(val) || val == "true" || val == "false" || val == "null" {
+				return fmt.Errorf("invalid enum value %q of field %s in table %s: must be a name of letters, digits and underscores, not starting with a digit", val, field.Name, table.Name)
+			}
+			if _, ok := values[val]; ok {
+				return fmt.Errorf("enum value %s of field %s in table %s is given more than once", val, field.Name, table.Name)
+			}
+			values[val] = struct{}{}
+		}
+		if !field.HasDefault() {
+			continue
+		}
+		def, err := field.DefaultValue()
+		if err != nil {
+			return fmt.Errorf("invalid default for table %s: %w", table.Name, err)
+		}
+		if _, ok := values[def.AsString()]; !ok && def.AsString() != core.TableFieldDefaultNow {
+			return fmt.Errorf("default value %s of field %s in table %s is not one of its enum values", def.AsString(), field.Name, table.Name)
+		}
+	}
+	return nil
+}
+
+// stringsEqual returns whether two slices have the same strings in the same
+// order
+func stringsEqual(s1, s2 []string) bool {
+	if len(s1) != len(s2) {
+		return false
+	}
+	for i := range s1 {
+		if s1[i] != s2[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// fieldDefaultsEqual returns whether two fields have the same default value
+func fieldDefaultsEqual(f1, f2 core.TableField) bool {
+	if f1.HasDefault() != f2.HasDefault() {
+		return false
+	}
+	if !f1.HasDefault() {
+		return true
+	}
+	v1, err1 := f1.DefaultValue()
+	v2, err2 := f2.DefaultValue()
+	if err1 != nil || err2 != nil {
+		return false
+	}
+	return v1.Equals(v2).True()
+}
@@ -0,0 +1,134 @@
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cornelk/hashmap"
+	"github.com/graphql-go/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/valocode/bubbly/api/core"
+	"github.com/valocode/bubbly/env"
+)
+
+// schemaProvider is a provider that keeps the applied schema in memory, and
+// otherwise does nothing
+type schemaProvider struct {
+	stubProvider
+	schemaMu sync.Mutex
+	schema   *bubblySchema
+}
+
+func (p *schemaProvider) setSchema(schema *bubblySchema) {
+	p.schemaMu.Lock()
+	defer p.schemaMu.Unlock()
+	p.schema = schema
+}
+
+func (p *schemaProvider) Apply(_ string, schema *bubblySchema) error {
+	p.setSchema(schema)
+	return nil
+}
+
+func (p *schemaProvider) Migrate(_ string, schema *bubblySchema, _ schemaUpdates) error {
+	// Take a while, so that concurrent migrations would overlap
+	time.Sleep(time.Millisecond)
+	p.setSchema(schema)
+	return nil
+}
+
+func (p *schemaProvider) HasTable(string, string) (bool, error) {
+	p.schemaMu.Lock()
+	defer p.schemaMu.Unlock()
+	return p.schema != nil, nil
+}
+
+// ResolveQuery returns the applied schema for queries of the schema table, and
+// no rows for any other table
+func (p *schemaProvider) ResolveQuery(_ string, _ *SchemaGraph, params graphql.ResolveParams) (interface{}, error) {
+	p.schemaMu.Lock()
+	defer p.schemaMu.Unlock()
+	if params.Info.FieldName != core.SchemaTableName || p.schema == nil {
+		return []interface{}{}, nil
+	}
+	b, err := json.Marshal(p.schema.Tables)
+	if err != nil {
+		return nil, err
+	}
+	var tables map[string]interface{}
+	if err := json.Unmarshal(b, &tables); err != nil {
+		return nil, err
+	}
+	return []interface{}{map[string]interface{}{"tables": tables}}, nil
+}
+
+// TestConcurrentSchemaUpdates adds tables while saving and querying, and is
+// meant to be run with -race. Each schema update should start from the schema
+// applied by the previous one, so that no added table is lost
+func TestConcurrentSchemaUpdates(t *testing.T) {
+	s := &Store{
+		bCtx:    env.NewBubblyContext(),
+		p:       &schemaProvider{},
+		schemas: &hashmap.HashMap{},
+	}
+	require.NoError(t, s.CreateTenant(DefaultTenantName))
+
+	const updates = 10
+	var (
+		wg   sync.WaitGroup
+		errs = make(chan error, 3*updates)
+	)
+	for i := 0; i < updates; i++ {
+		name := fmt.Sprintf("table_%d", i)
+		wg.Add(3)
+		go func() {
+			defer wg.Done()
+			errs <- s.AddTables(DefaultTenantName, core.Tables{
+				{
+					Name:   name,
+					Fields: []core.TableField{{Name: "name", Type: cty.String}},
+				},
+			})
+		}()
+		go func() {
+			defer wg.Done()
+			errs <- s.Save(DefaultTenantName, core.DataBlocks{
+				{
+					TableName: name,
+					Fields: &core.DataFields{Values: map[string]cty.Value{
+						"name": cty.StringVal(name),
+					}},
+				},
+			})
+		}()
+		go func() {
+			defer wg.Done()
+			result, err := s.Query(DefaultTenantName, fmt.Sprintf("{ %s { tables } }", core.SchemaTableName))
+			if err == nil && result.HasErrors() {
+				err = fmt.Errorf("query failed: %v", result.Errors)
+			}
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		assert.NoError(t, err)
+	}
+
+	tables, err := s.SchemaTables(DefaultTenantName)
+	require.NoError(t, err)
+	for i := 0; i < updates; i++ {
+		assert.Contains(t, tables, fmt.Sprintf("table_%d", i))
+	}
+	// The store should have loaded the schema that was applied last
+	status, err := s.Status(DefaultTenantName)
+	require.NoError(t, err)
+	assert.Equal(t, len(tables), status.Tables)
+}
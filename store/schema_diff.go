@@ -2,6 +2,10 @@ package store
 
 import (
 	"fmt"
+	"sort"
+
+	"github.com/zclconf/go-cty/cty"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
 
 	"github.com/valocode/bubbly/api/core"
 )
@@ -11,7 +15,10 @@ import (
 // this will be treated as a deletion.
 func compareSchema(s1 *bubblySchema, s2 *bubblySchema) (schemaUpdates, error) {
 	var changelog schemaUpdates
-	for tableName, table1 := range s1.Tables {
+	// The tables are compared in order of their names, so that the same
+	// schemas always give the same changes, and the same migration
+	for _, tableName := range sortedTableNames(s1.Tables) {
+		table1 := s1.Tables[tableName]
 		if tableName != table1.Name {
 			return nil, fmt.Errorf("map key and table name do not match for table %s", table1.Name)
 		}
@@ -19,7 +26,9 @@ func compareSchema(s1 *bubblySchema, s2 *bubblySchema) (schemaUpdates, error) {
 		// The key exists in both tables, and will be checked for updates
 		if ok {
 			// ALTER
-			compareTables(table1, s2.Tables[table1.Name], &changelog)
+			if err := compareTables(table1, s2.Tables[table1.Name], &changelog); err != nil {
+				return nil, err
+			}
 		} else {
 			// DELETE
 			// The key does not exist in the second table and will be removed
@@ -35,7 +44,8 @@ func compareSchema(s1 *bubblySchema, s2 *bubblySchema) (schemaUpdates, error) {
 			})
 		}
 	}
-	for _, table2 := range s2.Tables {
+	for _, tableName := range sortedTableNames(s2.Tables) {
+		table2 := s2.Tables[tableName]
 		_, ok := s1.Tables[table2.Name]
 		if !ok {
 			// CREATE
@@ -55,6 +65,16 @@ func compareSchema(s1 *bubblySchema, s2 *bubblySchema) (schemaUpdates, error) {
 	return changelog, nil
 }
 
+// sortedTableNames returns the names of the tables, sorted
+func sortedTableNames(tables map[string]core.Table) []string {
+	names := make([]string, 0, len(tables))
+	for name := range tables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
 type DiffAction string
 type Element string
 
@@ -70,6 +90,15 @@ var (
 	joinElement     Element = "join"
 	joinSingleAttr  Element = "joinSingle"
 	joinUniqueAttr  Element = "joinUnique"
+	// tableUniqueFieldsAttr is the unique fields of a table
+	tableUniqueFieldsAttr Element = "tableUniqueFields"
+	// tableIndexesAttr is the indexes of a table
+	tableIndexesAttr Element = "tableIndexes"
+	// fieldDefaultAttr is the default value of a field
+	fieldDefaultAttr Element = "fieldDefault"
+	// fieldNameAttr is the name of a field, which changes when a field is
+	// renamed from another field
+	fieldNameAttr Element = "fieldName"
 )
 
 // schemaUpdates is a list of expectedChanges that will be applied by the migration
@@ -94,17 +123,115 @@ type changeEntry struct {
 // field1: "hello world" -> field1: "lizards"
 // will be views as an update on field1, but if field1 has its name changed:
 // field1: "hello world" -> field2: "hello world"
-// These will be treated as 2 separate entities, field1 will be seen as deleted, and field2 will be added
-func compareTables(t1 core.Table, t2 core.Table, cl *schemaUpdates) {
-	compareFields(t1, t2, cl)
+// These will be treated as 2 separate entities, field1 will be seen as deleted, and field2 will be added,
+// unless field2 is declared as renamed from field1
+func compareTables(t1 core.Table, t2 core.Table, cl *schemaUpdates) error {
+	if err := compareFields(t1, t2, cl); err != nil {
+		return err
+	}
 	compareJoins(t1, t2, cl)
+	compareUniqueFields(t1, t2, cl)
+	compareIndexes(t1, t2, cl)
+	return nil
+}
+
+// compareUniqueFields adds an update to schemaUpdates if the unique fields of
+// the tables differ
+func compareUniqueFields(t1, t2 core.Table, cl *schemaUpdates) {
+	if len(t1.UniqueFields) == len(t2.UniqueFields) {
+		equal := true
+		for idx := range t1.UniqueFields {
+			if t1.UniqueFields[idx] != t2.UniqueFields[idx] {
+				equal = false
+				break
+			}
+		}
+		if equal {
+			return
+		}
+	}
+	*cl = append(*cl, changeEntry{
+		Action: update,
+		TableInfo: tableInfo{
+			TableName:   t2.Name,
+			ElementName: t2.Name,
+			ElementType: tableUniqueFieldsAttr,
+		},
+		From: t1.UniqueFields,
+		To:   t2.UniqueFields,
+	})
+}
+
+// compareIndexes adds an update to schemaUpdates if the indexes of the tables
+// differ
+func compareIndexes(t1, t2 core.Table, cl *schemaUpdates) {
+	if len(t1.Indexes) == len(t2.Indexes) {
+		equal := true
+		for _, index := range t1.Indexes {
+			if !hasIndex(t2.Indexes, index) {
+				equal = false
+				break
+			}
+		}
+		if equal {
+			return
+		}
+	}
+	*cl = append(*cl, changeEntry{
+		Action: update,
+		TableInfo: tableInfo{
+			TableName:   t2.Name,
+			ElementName: t2.Name,
+			ElementType: tableIndexesAttr,
+		},
+		From: t1.Indexes,
+		To:   t2.Indexes,
+	})
 }
 
-func compareFields(t1, t2 core.Table, cl *schemaUpdates) {
+// compareFields adds the differences between the fields of two tables to
+// schemaUpdates. A field of t2 that is renamed from a field of t1 is matched
+// to that field, and it is an error if the field does not exist in t1, or if
+// t1 already has a field with the new name
+func compareFields(t1, t2 core.Table, cl *schemaUpdates) error {
+	// renames are the fields of t2 by the names of the fields of t1 that they
+	// are renamed from
+	renames := make(map[string]core.TableField)
+	for _, field2 := range t2.Fields {
+		if field2.RenamedFrom == "" {
+			continue
+		}
+		if _, ok := tableField(t1, field2.Name); ok {
+			// The field exists, so it has already been renamed, unless the
+			// field it is renamed from also still exists
+			if _, ok := tableField(t1, field2.RenamedFrom); ok {
+				return fmt.Errorf("cannot rename field %s to %s in table %s: field %s already exists", field2.RenamedFrom, field2.Name, t2.Name, field2.Name)
+			}
+			continue
+		}
+		if _, ok := tableField(t1, field2.RenamedFrom); !ok {
+			return fmt.Errorf("cannot rename field %s to %s in table %s: field %s does not exist", field2.RenamedFrom, field2.Name, t2.Name, field2.RenamedFrom)
+		}
+		renames[field2.RenamedFrom] = field2
+	}
+
 	for _, field1 := range t1.Fields {
 		found := false
 		for _, field2 := range t2.Fields {
-			if field1.Name != field2.Name {
+			if renamed, ok := renames[field1.Name]; ok && renamed.Name == field2.Name {
+				// Rename the field first, so that the other changes to it
+				// are made to the field with its new name
+				*cl = append(*cl, changeEntry{
+					Action: update,
+					TableInfo: tableInfo{
+						TableName:   t2.Name,
+						ElementName: field2.Name,
+						ElementType: fieldNameAttr,
+					},
+					From: field1.Name,
+					To:   field2.Name,
+				})
+			} else if field1.Name != field2.Name {
 				continue
 			}
 			found = true
@@ -134,6 +261,20 @@ func compareFields(t1, t2 core.Table, cl *schemaUpdates) {
 					To:   field2.Unique,
 				})
 			}
+			// Check if same field but the default has changed. The whole
+			// field is needed to get the default for its type
+			if !fieldDefaultsEqual(field1, field2) {
+				*cl = append(*cl, changeEntry{
+					Action: update,
+					TableInfo: tableInfo{
+						TableName:   t2.Name,
+						ElementName: field2.Name,
+						ElementType: fieldDefaultAttr,
+					},
+					From: field1,
+					To:   field2,
+				})
+			}
 		}
 		if !found {
 			*cl = append(*cl, changeEntry{
@@ -156,6 +297,9 @@ func compareFields(t1, t2 core.Table, cl *schemaUpdates) {
 				break
 			}
 		}
+		if _, ok := renames[schema2Field.RenamedFrom]; ok {
+			found = true
+		}
 		if !found {
 			*cl = append(*cl, changeEntry{
 				Action: create,
@@ -169,6 +313,7 @@ func compareFields(t1, t2 core.Table, cl *schemaUpdates) {
 			})
 		}
 	}
+	return nil
 }
 
 // compareJoins takes two tables and adds any differences in the joins to schemaUpdates
@@ -245,3 +390,169 @@ func compareJoins(t1, t2 core.Table, cl *schemaUpdates) {
 		}
 	}
 }
+
+// SchemaChange is a change to a table of a schema, as returned by DiffSchema
+type SchemaChange struct {
+	// Action is either create, update or delete
+	Action DiffAction
+	Table  string
+	// Kind is the kind of element that is changed, i.e. a table, field or
+	// join
+	Kind string
+	// Name is the name of the element, which for a join is the name of the
+	// table that is joined to
+	Name string
+	// Attribute is the attribute of the element that is updated, such as the
+	// type of a field, and is empty when the element is created or deleted
+	Attribute string
+	From      string
+	To        string
+	// Destructive is whether the change can lose data that is stored, which
+	// is the case when deleting elements and changing the types of fields
+	Destructive bool
+}
+
+// String returns the change as a line of a diff
+func (c SchemaChange) String() string {
+	var (
+		prefix string
+		name   = c.Table
+	)
+	switch c.Action {
+	case create:
+		prefix = "+"
+	case remove:
+		prefix = "-"
+	default:
+		prefix = "~"
+	}
+	if c.Kind != string(tableElement) {
+		name += "." + c.Name
+	}
+	line := fmt.Sprintf("%s %s %s", prefix, c.Kind, name)
+	if c.Attribute != "" {
+		line += fmt.Sprintf(" %s: %s -> %s", c.Attribute, c.From, c.To)
+	} else if c.From != "" || c.To != "" {
+		line += " (" + c.From + c.To + ")"
+	}
+	return line
+}
+
+// DiffSchema returns the changes that applying the tables would make to the
+// schema with the current tables, by name, such as those returned by
+// Store.SchemaTables. The builtin tables are added to the tables like Apply
+// does, so that only the changes to the other tables are returned. The changes
+// are sorted by table
+func DiffSchema(current map[string]core.Table, tables core.Tables) ([]SchemaChange, error) {
+	newSchema, err := newBubblySchemaFromTables(tables, false)
+	if err != nil {
+		return nil, fmt.Errorf("invalid schema: %w", err)
+	}
+	cl, err := compareSchema(&bubblySchema{Tables: current}, newSchema)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compare schemas: %w", err)
+	}
+	changes := make([]SchemaChange, 0, len(cl))
+	for _, entry := range cl {
+		changes = append(changes, schemaChange(entry))
+	}
+	kindOrder := map[string]int{
+		string(tableElement): 0,
+		string(fieldElement): 1,
+		string(joinElement):  2,
+	}
+	sort.SliceStable(changes, func(i, j int) bool {
+		ci, cj := changes[i], changes[j]
+		if ci.Table != cj.Table {
+			return ci.Table < cj.Table
+		}
+		if ci.Kind != cj.Kind {
+			return kindOrder[ci.Kind] < kindOrder[cj.Kind]
+		}
+		if ci.Name != cj.Name {
+			return ci.Name < cj.Name
+		}
+		return ci.Attribute < cj.Attribute
+	})
+	return changes, nil
+}
+
+// schemaChange returns the change entry of a migration as a SchemaChange
+func schemaChange(entry changeEntry) SchemaChange {
+	change := SchemaChange{
+		Action:      entry.Action,
+		Table:       entry.TableInfo.TableName,
+		Name:        entry.TableInfo.ElementName,
+		Destructive: entry.Action == remove,
+	}
+	switch entry.TableInfo.ElementType {
+	case tableElement:
+		change.Kind = string(tableElement)
+	case fieldElement:
+		change.Kind = string(fieldElement)
+		// Show the type of the created or deleted field
+		if field, ok := entry.From.(core.TableField); ok {
+			change.From = field.Type.FriendlyName()
+		}
+		if field, ok := entry.To.(core.TableField); ok {
+			change.To = field.Type.FriendlyName()
+		}
+	case joinElement:
+		change.Kind = string(joinElement)
+	case fieldType:
+		change.Kind = string(fieldElement)
+		change.Attribute = "type"
+		change.From = entry.From.(cty.Type).FriendlyName()
+		change.To = entry.To.(cty.Type).FriendlyName()
+		// The values that are stored might not convert to the new type
+		change.Destructive = true
+	case fieldUniqueAttr:
+		change.Kind = string(fieldElement)
+		change.Attribute = "unique"
+	case fieldNameAttr:
+		change.Kind = string(fieldElement)
+		change.Attribute = "name"
+		change.From = entry.From.(string)
+		change.To = entry.To.(string)
+	case fieldDefaultAttr:
+		change.Kind = string(fieldElement)
+		change.Attribute = "default"
+		change.From = fieldDefaultString(entry.From.(core.TableField))
+		change.To = fieldDefaultString(entry.To.(core.TableField))
+	case joinSingleAttr:
+		change.Kind = string(joinElement)
+		change.Attribute = "single"
+	case joinUniqueAttr:
+		change.Kind = string(joinElement)
+		change.Attribute = "unique"
+	case tableUniqueFieldsAttr:
+		change.Kind = string(tableElement)
+		change.Attribute = "unique fields"
+	case tableIndexesAttr:
+		change.Kind = string(tableElement)
+		change.Attribute = "indexes"
+	default:
+		change.Kind = string(entry.TableInfo.ElementType)
+	}
+	if change.Attribute != "" && change.From == "" && change.To == "" {
+		change.From = fmt.Sprint(entry.From)
+		change.To = fmt.Sprint(entry.To)
+	}
+	return change
+}
+
+// fieldDefaultString returns the default value of a field as a string
+func fieldDefaultString(field core.TableField) string {
+	if !field.HasDefault() {
+		return "none"
+	}
+	val, err := field.DefaultValue()
+	if err != nil {
+		val = field.Default
+	}
+	b, err := ctyjson.Marshal(val, val.Type())
+	if err != nil {
+		return val.GoString()
+	}
+	return string(b)
+}
@@ -151,6 +151,86 @@ var schemaDiffTests = []struct {
 		},
 		wantErr: false,
 	},
+	{
+		name: "Add unique fields",
+		s1:   core.Tables{core.Table{Name: "a", Fields: []core.TableField{{Name: "a", Type: cty.String}}}},
+		s2:   core.Tables{core.Table{Name: "a", Fields: []core.TableField{{Name: "a", Type: cty.String}}, UniqueFields: []string{"a"}}},
+		want: schemaUpdates{
+			changeEntry{Action: update, TableInfo: tableInfo{TableName: "a", ElementName: "a", ElementType: tableUniqueFieldsAttr}, From: []string(nil), To: []string{"a"}},
+		},
+		wantErr: false,
+	},
+	{
+		name: "Add indexes",
+		s1:   core.Tables{core.Table{Name: "a", Fields: []core.TableField{{Name: "a", Type: cty.String}}}},
+		s2:   core.Tables{core.Table{Name: "a", Fields: []core.TableField{{Name: "a", Type: cty.String}}, Indexes: [][]string{{"a"}}}},
+		want: schemaUpdates{
+			changeEntry{Action: update, TableInfo: tableInfo{TableName: "a", ElementName: "a", ElementType: tableIndexesAttr}, From: [][]string(nil), To: [][]string{{"a"}}},
+		},
+		wantErr: false,
+	},
+	{
+		name: "Remove indexes",
+		s1:   core.Tables{core.Table{Name: "a", Fields: []core.TableField{{Name: "a", Type: cty.String}}, Tables: []core.Table{{Name: "b", Fields: []core.TableField{{Name: "b", Type: cty.String}}, Indexes: [][]string{{"b"}, {"a_id"}}}}}},
+		s2:   core.Tables{core.Table{Name: "a", Fields: []core.TableField{{Name: "a", Type: cty.String}}, Tables: []core.Table{{Name: "b", Fields: []core.TableField{{Name: "b", Type: cty.String}}}}}},
+		want: schemaUpdates{
+			changeEntry{Action: update, TableInfo: tableInfo{TableName: "b", ElementName: "b", ElementType: tableIndexesAttr}, From: [][]string{{"b"}, {"a_id"}}, To: [][]string(nil)},
+		},
+		wantErr: false,
+	},
+	{
+		name: "Change unique fields",
+		s1:   core.Tables{core.Table{Name: "a", Fields: []core.TableField{{Name: "a", Type: cty.String}}, Tables: []core.Table{{Name: "b", Fields: []core.TableField{{Name: "b", Type: cty.String}}, UniqueFields: []string{"b"}}}}},
+		s2:   core.Tables{core.Table{Name: "a", Fields: []core.TableField{{Name: "a", Type: cty.String}}, Tables: []core.Table{{Name: "b", Fields: []core.TableField{{Name: "b", Type: cty.String}}, UniqueFields: []string{"a_id", "b"}}}}},
+		want: schemaUpdates{
+			changeEntry{Action: update, TableInfo: tableInfo{TableName: "b", ElementName: "b", ElementType: tableUniqueFieldsAttr}, From: []string{"b"}, To: []string{"a_id", "b"}},
+		},
+		wantErr: false,
+	},
+	{
+		name: "Change field default",
+		s1:   core.Tables{core.Table{Name: "a", Fields: []core.TableField{{Name: "a", Type: cty.String}}}},
+		s2:   core.Tables{core.Table{Name: "a", Fields: []core.TableField{{Name: "a", Type: cty.String, Default: cty.StringVal("b")}}}},
+		want: schemaUpdates{
+			changeEntry{Action: update, TableInfo: tableInfo{TableName: "a", ElementName: "a", ElementType: fieldDefaultAttr}, From: core.TableField{Name: "a", Type: cty.String}, To: core.TableField{Name: "a", Type: cty.String, Default: cty.StringVal("b")}},
+		},
+		wantErr: false,
+	},
+	{
+		name: "Rename field",
+		s1:   core.Tables{core.Table{Name: "a", Fields: []core.TableField{{Name: "a", Type: cty.String}}}},
+		s2:   core.Tables{core.Table{Name: "a", Fields: []core.TableField{{Name: "b", Type: cty.String, RenamedFrom: "a"}}}},
+		want: schemaUpdates{
+			changeEntry{Action: update, TableInfo: tableInfo{TableName: "a", ElementName: "b", ElementType: fieldNameAttr}, From: "a", To: "b"},
+		},
+		wantErr: false,
+	},
+	{
+		name: "Rename field and update type",
+		s1:   core.Tables{core.Table{Name: "a", Fields: []core.TableField{{Name: "a", Type: cty.String}}}},
+		s2:   core.Tables{core.Table{Name: "a", Fields: []core.TableField{{Name: "b", Type: cty.Number, RenamedFrom: "a"}}}},
+		want: schemaUpdates{
+			changeEntry{Action: update, TableInfo: tableInfo{TableName: "a", ElementName: "b", ElementType: fieldNameAttr}, From: "a", To: "b"},
+			changeEntry{Action: update, TableInfo: tableInfo{TableName: "a", ElementName: "b", ElementType: fieldType}, From: cty.String, To: cty.Number},
+		},
+		wantErr: false,
+	},
+	{
+		name:    "Renamed field already renamed",
+		s1:      core.Tables{core.Table{Name: "a", Fields: []core.TableField{{Name: "b", Type: cty.String, RenamedFrom: "a"}}}},
+		s2:      core.Tables{core.Table{Name: "a", Fields: []core.TableField{{Name: "b", Type: cty.String, RenamedFrom: "a"}}}},
+		want:    nil,
+		wantErr: false,
+	},
+	{
+		name: "Create table with renamed field",
+		s1:   core.Tables{},
+		s2:   core.Tables{core.Table{Name: "a", Fields: []core.TableField{{Name: "b", Type: cty.String, RenamedFrom: "a"}}}},
+		want: schemaUpdates{
+			changeEntry{Action: create, TableInfo: tableInfo{TableName: "a", ElementName: "a", ElementType: tableElement}, From: nil, To: core.Table{Name: "a", Fields: []core.TableField{{Name: "b", Type: cty.String, RenamedFrom: "a"}}}},
+		},
+		wantErr: false,
+	},
 	{
 		name: "Add single attribute on join",
 		s1:   core.Tables{core.Table{Name: "a", Tables: []core.Table{{Name: "b"}}}},
@@ -185,6 +265,61 @@ func TestCompareSchema(t *testing.T) {
 	}
 }
 
+func TestRenameFieldErrors(t *testing.T) {
+	tcs := []struct {
+		desc    string
+		current []core.TableField
+		fields  []core.TableField
+		err     string
+	}{
+		{
+			desc:    "renamed from a field that does not exist",
+			current: []core.TableField{{Name: "a", Type: cty.String}},
+			fields:  []core.TableField{{Name: "c", Type: cty.String, RenamedFrom: "b"}},
+			err:     "cannot rename field b to c in table t: field b does not exist",
+		},
+		{
+			desc: "renamed to a field that exists",
+			current: []core.TableField{
+				{Name: "a", Type: cty.String},
+				{Name: "b", Type: cty.String},
+			},
+			fields: []core.TableField{{Name: "b", Type: cty.String, RenamedFrom: "a"}},
+			err:    "cannot rename field a to b in table t: field b already exists",
+		},
+		{
+			desc: "renamed from another field",
+			fields: []core.TableField{
+				{Name: "a", Type: cty.String},
+				{Name: "b", Type: cty.String, RenamedFrom: "a"},
+			},
+			err: "field b in table t is renamed from a, which is another field of the table",
+		},
+		{
+			desc:   "renamed from itself",
+			fields: []core.TableField{{Name: "a", Type: cty.String, RenamedFrom: "a"}},
+			err:    "field a in table t cannot be renamed from itself",
+		},
+		{
+			desc: "renamed from the same field",
+			fields: []core.TableField{
+				{Name: "b", Type: cty.String, RenamedFrom: "a"},
+				{Name: "c", Type: cty.String, RenamedFrom: "a"},
+			},
+			err: "fields b and c in table t are both renamed from a",
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.desc, func(t *testing.T) {
+			current, err := newBubblySchemaFromTables(core.Tables{{Name: "t", Fields: tc.current}}, false)
+			require.NoError(t, err)
+			_, err = DiffSchema(current.Tables, core.Tables{{Name: "t", Fields: tc.fields}})
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tc.err)
+		})
+	}
+}
+
 var schema1 = core.Tables{
 	core.Table{
 		Name: "table1",
@@ -261,3 +396,144 @@ var schema1 = core.Tables{
 		Single: false,
 	},
 }
+
+func TestDiffSchema(t *testing.T) {
+	product := core.Table{
+		Name: "product",
+		Fields: []core.TableField{
+			{Name: "name", Type: cty.String, Unique: true},
+			{Name: "description", Type: cty.String},
+		},
+	}
+	testResult := core.Table{
+		Name: "test_result",
+		Fields: []core.TableField{
+			{Name: "name", Type: cty.String},
+			{Name: "duration", Type: cty.Number},
+		},
+		Joins: []core.TableJoin{{Table: "product"}},
+	}
+
+	tcs := []struct {
+		desc        string
+		current     core.Tables
+		tables      core.Tables
+		expected    []string
+		destructive int
+	}{
+		{
+			desc:    "no changes",
+			current: core.Tables{product, testResult},
+			tables:  core.Tables{product, testResult},
+		},
+		{
+			desc:    "additive",
+			current: core.Tables{product},
+			tables: core.Tables{
+				{
+					Name: "product",
+					Fields: append([]core.TableField{
+						{Name: "url", Type: cty.String, Default: cty.StringVal("none")},
+					}, product.Fields...),
+				},
+				testResult,
+			},
+			expected: []string{
+				"+ field product.url (string)",
+				"+ table test_result",
+			},
+		},
+		{
+			desc: "destructive",
+			current: core.Tables{
+				{Name: "build", Fields: []core.TableField{{Name: "id", Type: cty.String}}},
+				{
+					Name: "product",
+					Fields: append([]core.TableField{
+						{Name: "owner", Type: cty.String},
+					}, product.Fields...),
+				},
+				{
+					Name: "test_result",
+					Fields: []core.TableField{
+						{Name: "name", Type: cty.String},
+						{Name: "duration", Type: cty.String},
+					},
+					Joins: []core.TableJoin{{Table: "build"}, {Table: "product"}},
+				},
+			},
+			tables: core.Tables{product, testResult},
+			expected: []string{
+				"- table build",
+				"- field product.owner (string)",
+				"~ field test_result.duration type: string -> number",
+				"- join test_result.build",
+			},
+			destructive: 4,
+		},
+		{
+			desc:    "changed attributes",
+			current: core.Tables{product, testResult},
+			tables: core.Tables{
+				{
+					Name: "product",
+					Fields: []core.TableField{
+						{Name: "name", Type: cty.String},
+						{Name: "description", Type: cty.String, Default: cty.StringVal("none")},
+					},
+				},
+				{
+					Name:   "test_result",
+					Fields: testResult.Fields,
+					Joins:  []core.TableJoin{{Table: "product", Single: true}},
+				},
+			},
+			expected: []string{
+				`~ field product.description default: none -> "none"`,
+				"~ field product.name unique: true -> false",
+				"~ join test_result.product single: false -> true",
+			},
+		},
+		{
+			desc:    "renamed field",
+			current: core.Tables{product},
+			tables: core.Tables{
+				{
+					Name: "product",
+					Fields: []core.TableField{
+						{Name: "name", Type: cty.String, Unique: true},
+						{Name: "summary", Type: cty.String, RenamedFrom: "description"},
+					},
+				},
+			},
+			expected: []string{
+				"~ field product.summary name: description -> summary",
+			},
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.desc, func(t *testing.T) {
+			current, err := newBubblySchemaFromTables(tc.current, false)
+			require.NoError(t, err)
+			changes, err := DiffSchema(current.Tables, tc.tables)
+			require.NoError(t, err)
+
+			var (
+				lines       []string
+				destructive int
+			)
+			for _, change := range changes {
+				lines = append(lines, change.String())
+				if change.Destructive {
+					destructive++
+				}
+			}
+			assert.Equal(t, tc.expected, lines)
+			assert.Equal(t, tc.destructive, destructive)
+		})
+	}
+
+	// Builtin tables cannot be changed
+	_, err := DiffSchema(map[string]core.Table{}, core.Tables{{Name: core.SchemaTableName}})
+	assert.Error(t, err)
+}
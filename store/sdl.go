@@ -0,0 +1,163 @@
+package store
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/graphql-go/graphql"
+)
+
+// SchemaSDL returns the GraphQL schema of the tenant as GraphQL SDL (schema
+// definition language), e.g. for generating typed clients
+func (s *Store) SchemaSDL(tenant string) (string, error) {
+	ts, err := s.tenantSchema(tenant)
+	if err != nil {
+		return "", err
+	}
+	schema := ts.schema
+	if schema.QueryType() == nil {
+		return "", fmt.Errorf("schema for tenant %s has no query type", tenant)
+	}
+	return schemaSDL(schema), nil
+}
+
+// builtinScalars are the scalars defined by GraphQL, which are not printed
+var builtinScalars = map[string]struct{}{
+	graphql.String.Name():  {},
+	graphql.Int.Name():     {},
+	graphql.Float.Name():   {},
+	graphql.Boolean.Name(): {},
+	graphql.ID.Name():      {},
+}
+
+// schemaSDL prints the types of the schema as SDL. The types, fields and
+// arguments are sorted by name so that the output is stable. The builtin
+// scalars and introspection types are not printed
+func schemaSDL(schema graphql.Schema) string {
+	var (
+		typeMap = schema.TypeMap()
+		names   = make([]string, 0, len(typeMap))
+		defs    = make([]string, 0, len(typeMap)+1)
+	)
+	for name := range typeMap {
+		if strings.HasPrefix(name, "__") {
+			continue
+		}
+		if _, ok := builtinScalars[name]; ok {
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	defs = append(defs, "schema {\n  query: "+schema.QueryType().Name()+"\n}")
+	for _, name := range names {
+		var def string
+		switch ty := typeMap[name].(type) {
+		case *graphql.Object:
+			def = sdlObject(ty)
+		case *graphql.InputObject:
+			def = sdlInputObject(ty)
+		case *graphql.Enum:
+			def = sdlEnum(ty)
+		case *graphql.Scalar:
+			def = sdlDescription(ty.Description(), "") + "scalar " + ty.Name()
+		default:
+			// The bubbly schema does not have any interfaces or unions
+			continue
+		}
+		defs = append(defs, def)
+	}
+	return strings.Join(defs, "\n\n") + "\n"
+}
+
+func sdlObject(ty *graphql.Object) string {
+	var (
+		fields = ty.Fields()
+		names  = make([]string, 0, len(fields))
+		sb     strings.Builder
+	)
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	sb.WriteString(sdlDescription(ty.Description(), ""))
+	sb.WriteString("type " + ty.Name() + " {\n")
+	for _, name := range names {
+		field := fields[name]
+		sb.WriteString(sdlDescription(field.Description, "  "))
+		sb.WriteString("  " + field.Name + sdlArgs(field.Args) + ": " + field.Type.String() + "\n")
+	}
+	sb.WriteString("}")
+	return sb.String()
+}
+
+func sdlArgs(args []*graphql.Argument) string {
+	if len(args) == 0 {
+		return ""
+	}
+	sorted := make([]*graphql.Argument, len(args))
+	copy(sorted, args)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Name() < sorted[j].Name()
+	})
+	strs := make([]string, 0, len(sorted))
+	for _, arg := range sorted {
+		strs = append(strs, arg.Name()+": "+arg.Type.String())
+	}
+	return "(" + strings.Join(strs, ", ") + ")"
+}
+
+func sdlInputObject(ty *graphql.InputObject) string {
+	var (
+		fields = ty.Fields()
+		names  = make([]string, 0, len(fields))
+		sb     strings.Builder
+	)
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	sb.WriteString(sdlDescription(ty.Description(), ""))
+	sb.WriteString("input " + ty.Name() + " {\n")
+	for _, name := range names {
+		field := fields[name]
+		sb.WriteString(sdlDescription(field.Description(), "  "))
+		sb.WriteString("  " + field.Name() + ": " + field.Type.String() + "\n")
+	}
+	sb.WriteString("}")
+	return sb.String()
+}
+
+func sdlEnum(ty *graphql.Enum) string {
+	var (
+		values = ty.Values()
+		names  = make([]string, 0, len(values))
+		sb     strings.Builder
+	)
+	for _, value := range values {
+		names = append(names, value.Name)
+	}
+	sort.Strings(names)
+
+	sb.WriteString(sdlDescription(ty.Description(), ""))
+	sb.WriteString("enum " + ty.Name() + " {\n")
+	for _, name := range names {
+		sb.WriteString("  " + name + "\n")
+	}
+	sb.WriteString("}")
+	return sb.String()
+}
+
+// sdlDescription returns the description as a block string on its own line,
+// or an empty string if there is no description
+func sdlDescription(desc string, indent string) string {
+	if desc == "" {
+		return ""
+	}
+	desc = strings.ReplaceAll(desc, `"""`, `\"""`)
+	return indent + `"""` + desc + `"""` + "\n"
+}
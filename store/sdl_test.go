@@ -0,0 +1,127 @@
+package store
+
+import (
+	"testing"
+
+	"github.com/graphql-go/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/valocode/bubbly/api/core"
+)
+
+func TestSchemaSDL(t *testing.T) {
+	tables := core.Tables{
+		{
+			Name: "team",
+			Fields: []core.TableField{
+				{Name: "name", Type: cty.String, Unique: true},
+			},
+			Tables: core.Tables{
+				{
+					Name: "member",
+					Fields: []core.TableField{
+						{Name: "email", Type: cty.String},
+						{Name: "age", Type: cty.Number},
+						{Name: "roles", Type: cty.List(cty.String)},
+					},
+				},
+			},
+		},
+	}
+	bSchema, err := newBubblySchemaFromTables(tables, false)
+	require.NoError(t, err)
+	graph, err := newSchemaGraphFromMap(bSchema.Tables)
+	require.NoError(t, err)
+	schema, err := newGraphQLSchema(graph, func(p graphql.ResolveParams) (interface{}, error) {
+		return nil, nil
+	})
+	require.NoError(t, err)
+
+	sdl := schemaSDL(schema)
+	// The table type, with its fields
+	assert.Contains(t, sdl, "type member {\n")
+	assert.Contains(t, sdl, "  age: Int\n")
+	assert.Contains(t, sdl, "  email: String\n")
+	// The member belongs to a team, which is a single object
+	assert.Regexp(t, `\n  team\(.*\): team\n`, sdl)
+	// The query field for the table, with its filter and order arguments
+	assert.Contains(t, sdl, "type query {\n")
+	assert.Regexp(t, `\n  member\(.*filter: member_filter.*order_by: member_order.*\): \[member\]\n`, sdl)
+	// The filter and order inputs for the table
+	assert.Contains(t, sdl, "input member_filter {\n")
+	assert.Contains(t, sdl, "  email_in: [String]\n")
+	assert.Contains(t, sdl, "  email_eq: String\n")
+	assert.Contains(t, sdl, "  email_is_null: Boolean\n")
+	// List fields are lists of their elements, with the list filters
+	assert.Contains(t, sdl, "  roles: [String]\n")
+	assert.Contains(t, sdl, "  roles_contains: [String]\n")
+	assert.Contains(t, sdl, "  roles_overlaps: [String]\n")
+	assert.Contains(t, sdl, "  roles_is_null: Boolean\n")
+	assert.NotContains(t, sdl, "  roles_eq:")
+	// The filters on the related tables
+	assert.Contains(t, sdl, "  team_exists: team_filter\n")
+	assert.Contains(t, sdl, "  team_not_exists: team_filter\n")
+	assert.Contains(t, sdl, "input member_order {\n")
+	assert.Contains(t, sdl, "  email: Order\n")
+	// The aggregate query field for the table, with its having input
+	assert.Contains(t, sdl, "type member_aggregate {\n")
+	assert.Contains(t, sdl, "  count: Int\n")
+	assert.Contains(t, sdl, "  team_id: String\n")
+	assert.Regexp(t, `\n  member_aggregate\(.*group_by: \[String\].*having: member_having.*\): \[member_aggregate\]\n`, sdl)
+	assert.Contains(t, sdl, "input member_having {\n")
+	assert.Contains(t, sdl, "  count_gt: Int\n")
+	// The distinct query field for the table, with its column enum
+	assert.Regexp(t, `\n  member_distinct\(column: member_column!, filter: member_filter\): \[Value\]\n`, sdl)
+	assert.Contains(t, sdl, "enum member_column {\n  _id\n  age\n  email\n  roles\n  team_id\n}")
+	assert.Contains(t, sdl, "scalar Value")
+	// The query field for a single row by its ID
+	assert.Contains(t, sdl, "\n  member_by_id(_id: String!): member\n")
+	// The order enum
+	assert.Contains(t, sdl, "enum Order {\n  asc\n  desc\n}")
+	assert.NotContains(t, sdl, "__Schema")
+	assert.NotContains(t, sdl, "scalar String")
+}
+
+// TestSchemaSDLHidden checks that hidden fields and the foreign key fields of
+// hidden joins are not in the GraphQL schema, but the relationships are
+func TestSchemaSDLHidden(t *testing.T) {
+	tables := core.Tables{
+		{
+			Name: "team",
+			Fields: []core.TableField{
+				{Name: "name", Type: cty.String, Unique: true},
+				{Name: "secret", Type: cty.String, Hidden: true},
+			},
+			Tables: core.Tables{
+				{
+					Name: "member",
+					Fields: []core.TableField{
+						{Name: "email", Type: cty.String},
+					},
+					Joins: []core.TableJoin{{Table: "team", Hidden: true}},
+				},
+			},
+		},
+	}
+	bSchema, err := newBubblySchemaFromTables(tables, false)
+	require.NoError(t, err)
+	graph, err := newSchemaGraphFromMap(bSchema.Tables)
+	require.NoError(t, err)
+	schema, err := newGraphQLSchema(graph, func(p graphql.ResolveParams) (interface{}, error) {
+		return nil, nil
+	})
+	require.NoError(t, err)
+
+	sdl := schemaSDL(schema)
+	assert.Contains(t, sdl, "  name: String\n")
+	assert.NotContains(t, sdl, "secret")
+	assert.NotContains(t, sdl, "team_id")
+	assert.Contains(t, sdl, "enum team_column {\n  _id\n  name\n}")
+	assert.Contains(t, sdl, "enum member_column {\n  _id\n  email\n}")
+	// The tables are still related in both directions
+	assert.Regexp(t, `\n  team\(.*\): team\n`, sdl)
+	assert.Regexp(t, `\n  member\(.*\): \[member\]\n`, sdl)
+	assert.Contains(t, sdl, "  team_exists: team_filter\n")
+}
@@ -0,0 +1,110 @@
+package store
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/valocode/bubbly/api/core"
+	"github.com/valocode/bubbly/env"
+	"github.com/valocode/bubbly/test"
+)
+
+func TestSoftDelete(t *testing.T) {
+	deleteChild := core.DataBlocks{
+		{
+			TableName: "child_c",
+			Fields: &core.DataFields{Values: map[string]cty.Value{
+				"name": cty.StringVal("sibling_child"),
+			}},
+			Policy: core.DeletePolicy,
+		},
+	}
+	const (
+		childQuery         = `{ root(name: "first_root") { child_c { name } } }`
+		childQueryAll      = `{ root(name: "first_root") { child_c(include_deleted: true) { name } } }`
+		resourceQuery      = `{ _resource(id: "kind/name") { id _event { status } } }`
+		resourceQueryAll   = `{ _resource(id: "kind/name", include_deleted: true) { id _event(include_deleted: true) { status } } }`
+		resourceCountQuery = `{ _resource_aggregate(id: "kind/name") { count } }`
+		resourceID         = "kind/name"
+	)
+	queryOrDie := func(t *testing.T, s *Store, query string) map[string]interface{} {
+		result, err := s.Query(DefaultTenantName, query)
+		require.NoError(t, err)
+		require.Empty(t, result.Errors)
+		return result.Data.(map[string]interface{})
+	}
+	children := func(data map[string]interface{}) []interface{} {
+		roots := data["root"].([]interface{})
+		require.Len(t, roots, 1)
+		return roots[0].(map[string]interface{})["child_c"].([]interface{})
+	}
+
+	tcs := []struct {
+		desc       string
+		softDelete bool
+	}{
+		{desc: "soft delete", softDelete: true},
+		{desc: "hard delete", softDelete: false},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.desc, func(t *testing.T) {
+			bCtx := env.NewBubblyContext()
+			bCtx.StoreConfig.SoftDelete = tc.softDelete
+			resource := test.RunPostgresDocker(bCtx, t)
+			bCtx.StoreConfig.PostgresAddr = fmt.Sprintf("localhost:%s", resource.GetPort("5432/tcp"))
+
+			s, err := New(bCtx)
+			require.NoError(t, err)
+			t.Cleanup(s.Close)
+			applySchemaOrDie(t, bCtx, s, filepath.FromSlash("testdata/tables.hcl"))
+			loadTestDataOrDie(t, bCtx, s, filepath.FromSlash("testdata/data.hcl"))
+			require.Len(t, children(queryOrDie(t, s, childQuery)), 1)
+
+			// Deleted data is hidden by default, and visible with
+			// include_deleted only if it was soft-deleted
+			require.NoError(t, s.Save(DefaultTenantName, deleteChild))
+			assert.Empty(t, children(queryOrDie(t, s, childQuery)))
+			if tc.softDelete {
+				assert.Equal(t, []interface{}{
+					map[string]interface{}{"name": "sibling_child"},
+				}, children(queryOrDie(t, s, childQueryAll)))
+			} else {
+				assert.Empty(t, children(queryOrDie(t, s, childQueryAll)))
+			}
+			// Deleting it again has no effect
+			require.NoError(t, s.Save(DefaultTenantName, deleteChild))
+
+			// Saving the data again restores it
+			loadTestDataOrDie(t, bCtx, s, filepath.FromSlash("testdata/data.hcl"))
+			assert.Len(t, children(queryOrDie(t, s, childQuery)), 1)
+
+			// Deleted resources and their events are hidden by default, and
+			// visible with include_deleted only if they were soft-deleted
+			require.NoError(t, s.Save(DefaultTenantName, core.DataBlocks{createResJSONOrDie(t)}))
+			deleted, err := s.DeleteResource(DefaultTenantName, resourceID)
+			require.NoError(t, err)
+			assert.True(t, deleted)
+			assert.Empty(t, queryOrDie(t, s, resourceQuery)[core.ResourceTableName])
+			assert.Equal(t, []interface{}{
+				map[string]interface{}{"count": 0},
+			}, queryOrDie(t, s, resourceCountQuery)[core.ResourceTableName+aggregateSuffix])
+			all := queryOrDie(t, s, resourceQueryAll)[core.ResourceTableName]
+			if tc.softDelete {
+				require.Len(t, all, 1)
+				assert.Equal(t, resourceID, all.([]interface{})[0].(map[string]interface{})["id"])
+			} else {
+				assert.Empty(t, all)
+			}
+
+			// A soft-deleted resource is not found when deleting it again
+			deleted, err = s.DeleteResource(DefaultTenantName, resourceID)
+			require.NoError(t, err)
+			assert.False(t, deleted)
+		})
+	}
+}
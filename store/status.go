@@ -0,0 +1,39 @@
+package store
+
+import (
+	"time"
+)
+
+// Status describes the schema that the store has loaded for a tenant, so that
+// operators can check what is loaded
+type Status struct {
+	// Provider is the type of the store's provider, e.g. postgres
+	Provider string `json:"provider"`
+	// Tables is the number of tables in the schema, including the builtin
+	// tables
+	Tables int `json:"tables"`
+	// SchemaUpdatedAt is when the store last updated the schema, either when
+	// a schema was applied or when the store loaded it on start
+	SchemaUpdatedAt time.Time `json:"schema_updated_at"`
+}
+
+// Status returns the status of the schema of a tenant
+func (s *Store) Status(tenant string) (*Status, error) {
+	ts, err := s.tenantSchema(tenant)
+	if err != nil {
+		return nil, err
+	}
+	return &Status{
+		Provider:        string(s.bCtx.StoreConfig.Provider),
+		Tables:          len(ts.graph.NodeIndex),
+		SchemaUpdatedAt: ts.updated,
+	}, nil
+}
+
+// Ready returns whether the store has loaded a schema for a tenant, which it
+// has once the tenant has been created, or the store has loaded the schema of
+// an existing tenant on start. Until then, the tenant cannot be queried
+func (s *Store) Ready(tenant string) bool {
+	_, err := s.tenantSchema(tenant)
+	return err == nil
+}
@@ -0,0 +1,100 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/cornelk/hashmap"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/valocode/bubbly/api/core"
+	"github.com/valocode/bubbly/bubbly/builtin"
+	"github.com/valocode/bubbly/env"
+	"github.com/valocode/bubbly/test"
+)
+
+func TestStatus(t *testing.T) {
+	bCtx := env.NewBubblyContext()
+	resource := test.RunPostgresDocker(bCtx, t)
+	bCtx.StoreConfig.PostgresAddr = fmt.Sprintf("localhost:%s", resource.GetPort("5432/tcp"))
+
+	s, err := New(bCtx)
+	require.NoError(t, err)
+	defer s.Close()
+
+	numBuiltin := len(FlattenTables(builtin.BuiltinTables, nil))
+	status, err := s.Status(DefaultTenantName)
+	require.NoError(t, err)
+	assert.Equal(t, string(bCtx.StoreConfig.Provider), status.Provider)
+	assert.Equal(t, numBuiltin, status.Tables)
+	assert.False(t, status.SchemaUpdatedAt.IsZero())
+
+	before := time.Now()
+	err = s.Apply(DefaultTenantName, core.Tables{
+		{
+			Name:   "status_a",
+			Fields: []core.TableField{{Name: "name", Type: cty.String}},
+		},
+		{
+			Name:   "status_b",
+			Fields: []core.TableField{{Name: "name", Type: cty.String}},
+		},
+	}, false)
+	require.NoError(t, err)
+
+	status, err = s.Status(DefaultTenantName)
+	require.NoError(t, err)
+	assert.Equal(t, numBuiltin+2, status.Tables)
+	assert.False(t, status.SchemaUpdatedAt.Before(before))
+
+	_, err = s.Status("unknown")
+	assert.Error(t, err)
+}
+
+func TestReady(t *testing.T) {
+	s := &Store{
+		bCtx:    env.NewBubblyContext(),
+		p:       &schemaProvider{},
+		schemas: &hashmap.HashMap{},
+	}
+	query := fmt.Sprintf("{ %s { tables } }", core.SchemaTableName)
+
+	// Queries are rejected until the tenant has a schema
+	assert.False(t, s.Ready(DefaultTenantName))
+	_, err := s.Query(DefaultTenantName, query)
+	require.Error(t, err)
+
+	require.NoError(t, s.CreateTenant(DefaultTenantName))
+	assert.True(t, s.Ready(DefaultTenantName))
+	result, err := s.Query(DefaultTenantName, query)
+	require.NoError(t, err)
+	assert.False(t, result.HasErrors(), result.Errors)
+
+	assert.False(t, s.Ready("unknown"))
+}
+
+// TestQueryNoTables checks that querying a tenant whose schema has no tables
+// fails with ErrNoSchema, instead of succeeding without any data
+func TestQueryNoTables(t *testing.T) {
+	s := &Store{
+		bCtx:    env.NewBubblyContext(),
+		p:       &schemaProvider{},
+		schemas: &hashmap.HashMap{},
+	}
+	query := fmt.Sprintf("{ %s { tables } }", core.SchemaTableName)
+
+	_, err := s.Query(DefaultTenantName, query)
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, ErrNoSchema))
+
+	require.NoError(t, s.updateSchema(DefaultTenantName, &bubblySchema{Tables: map[string]core.Table{}}))
+	assert.False(t, s.Ready(DefaultTenantName))
+	_, err = s.Query(DefaultTenantName, query)
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, ErrNoSchema))
+	assert.Contains(t, err.Error(), "the schema has no tables")
+}
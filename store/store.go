@@ -1,8 +1,11 @@
 package store
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/cornelk/hashmap"
@@ -10,7 +13,6 @@ import (
 
 	"github.com/valocode/bubbly/api/core"
 	"github.com/valocode/bubbly/bubbly/builtin"
-	"github.com/valocode/bubbly/config"
 	"github.com/valocode/bubbly/env"
 )
 
@@ -25,26 +27,36 @@ const DefaultTenantName = "default"
 //
 
 // New creates a new Store for the given config.
-func New(bCtx *env.BubblyContext) (*Store, error) {
+func New(bCtx *env.BubblyContext, opts ...Option) (*Store, error) {
 	var (
+		o = newOptions(bCtx, opts)
 		s = &Store{
-			bCtx:    bCtx,
-			graphs:  &hashmap.HashMap{},
-			schemas: &hashmap.HashMap{},
+			bCtx:         bCtx,
+			closing:      make(chan struct{}),
+			watchdogDone: make(chan struct{}),
+			schemas:      &hashmap.HashMap{},
+			saveIDs:      newSaveIDCache(saveIDTTL),
+			cache: newQueryCache(
+				bCtx.StoreConfig.QueryCacheSize,
+				time.Duration(bCtx.StoreConfig.QueryCacheTTL)*time.Second,
+			),
 		}
 		err error
 	)
+	// The results of the same query can differ between callers if the rows
+	// are filtered, so they cannot be cached
+	if o.rowFilterHook != nil && s.cache != nil {
+		bCtx.Logger.Warn().Msg("Disabling the query cache of the store, as the rows of queries are filtered")
+		s.cache = nil
+	}
+	s.newProvider, err = newProviderFactory(bCtx, o)
+	if err != nil {
+		return nil, err
+	}
 
 	// Connect to the provider's database RetryAttempts times, with a RetrySleep
 	for attempt := 1; attempt <= bCtx.StoreConfig.RetryAttempts; attempt++ {
-		switch bCtx.StoreConfig.Provider {
-		case config.PostgresStore:
-			s.p, err = newPostgres(bCtx)
-		case config.CockroachDBStore:
-			s.p, err = newCockroachdb(bCtx)
-		default:
-			return nil, fmt.Errorf("invalid provider: %s", bCtx.StoreConfig.Provider)
-		}
+		s.p, err = s.newProvider()
 		// If the connection succeeded then break out of the attempt loop
 		if err == nil {
 			break
@@ -62,26 +74,77 @@ func New(bCtx *env.BubblyContext) (*Store, error) {
 		return nil, fmt.Errorf("failed to initialize the store schemas: %w", err)
 	}
 
+	if bCtx.StoreConfig.HealthCheckInterval > 0 {
+		go s.runWatchdog(time.Duration(bCtx.StoreConfig.HealthCheckInterval) * time.Second)
+	} else {
+		close(s.watchdogDone)
+	}
+
 	return s, nil
 }
 
 // Store provides access to persisted readiness data.
 type Store struct {
 	bCtx *env.BubblyContext
-	p    provider
-
-	graphs  *hashmap.HashMap
+	// mu protects p, which is replaced by the watchdog when reconnecting.
+	// Use provider() to get it
+	mu          sync.RWMutex
+	p           provider
+	newProvider providerFactory
+	// closing is closed when the store is closed, to stop the watchdog
+	closing      chan struct{}
+	closeOnce    sync.Once
+	watchdogDone chan struct{}
+
+	// schemas stores the *tenantSchema of each tenant
 	schemas *hashmap.HashMap
+	// schemaMu serializes the operations that change the schema of a tenant,
+	// so that each of them migrates from the schema applied by the previous
+	// one
+	schemaMu sync.Mutex
+	// cache stores the results of queries, and is nil if caching is disabled
+	cache *queryCache
+	// saveIDs stores the IDs of the data saved with SaveOnce
+	saveIDs *saveIDCache
+}
+
+// tenantSchema is the schema of a tenant that the store has loaded. It is
+// replaced as a whole when the schema changes, so that the graph and GraphQL
+// schema that are read always belong to the same schema
+type tenantSchema struct {
+	graph  *SchemaGraph
+	schema graphql.Schema
+	// updated is when the schema was loaded
+	updated time.Time
+}
+
+// ErrNoSchema is returned when the store has not loaded a schema for a
+// tenant, or the schema has no tables and so cannot be queried
+var ErrNoSchema = errors.New("no schema exists")
+
+// tenantSchema returns the schema that the store has loaded for a tenant
+func (s *Store) tenantSchema(tenant string) (*tenantSchema, error) {
+	val, ok := s.schemas.GetStringKey(tenant)
+	if !ok {
+		return nil, fmt.Errorf("%w for tenant %s", ErrNoSchema, tenant)
+	}
+	ts := val.(*tenantSchema)
+	// The GraphQL schema of a schema without tables is the zero schema,
+	// against which every query would succeed without any data
+	if ts.schema.QueryType() == nil {
+		return nil, fmt.Errorf("%w for tenant %s: the schema has no tables", ErrNoSchema, tenant)
+	}
+	return ts, nil
 }
 
 // CreateTenant creates a tenant schema in the provider
 func (s *Store) CreateTenant(tenant string) error {
-	if err := s.p.CreateTenant(tenant); err != nil {
+	if err := s.provider().CreateTenant(tenant); err != nil {
 		return fmt.Errorf("error creating tenant %s: %w", tenant, err)
 	}
 	// We should check that a schema already exists, and if not, we should
 	// initialize one
-	ok, err := s.p.HasTable(tenant, core.SchemaTableName)
+	ok, err := s.provider().HasTable(tenant, core.SchemaTableName)
 	if err != nil {
 		return fmt.Errorf("error checking if provider has schema for tenant %s: %w", tenant, err)
 	}
@@ -97,14 +160,88 @@ func (s *Store) CreateTenant(tenant string) error {
 
 // Query queries the store.
 func (s *Store) Query(tenant string, query string) (*graphql.Result, error) {
-	schema, ok := s.schemas.GetStringKey(tenant)
-	if !ok {
-		return nil, fmt.Errorf("no schema exists for tenant %s", tenant)
+	return s.QueryContext(context.Background(), tenant, query)
+}
+
+// QueryContext queries the store like Query. The context is given to the
+// RowFilterHook, if the store has one, and should contain the auth of the
+// caller (see ContextWithAuth). The SQL queries are cancelled when the
+// context is done.
+// The tables queried with the `_since` argument have their cursor, the
+// sequence number of their last changed row, in the "cursors" extension of
+// the result.
+// The tables paginated with the `first` or `after` arguments have their page
+// info, the cursor of their last row and whether there are more rows after
+// it, in the "pageInfo" extension of the result.
+// The nested tables that are left out of the result, because they are deeper
+// than the maximum depth, have their path in the "truncated" extension.
+// The tables whose `first` or `last` argument is larger than the maximum page
+// size have the page size they were reduced to in the "clamped" extension
+func (s *Store) QueryContext(ctx context.Context, tenant string, query string) (*graphql.Result, error) {
+	ts, err := s.tenantSchema(tenant)
+	if err != nil {
+		return nil, err
+	}
+	cachedQuery, cacheable := s.cache.prepare(tenant, query)
+	if cacheable {
+		if result, ok := s.cache.get(cachedQuery); ok {
+			return result, nil
+		}
+	}
+	cursors := &queryCursors{}
+	result := graphql.Do(graphql.Params{
+		Schema:        ts.schema,
+		RequestString: query,
+		Context:       withQueryCursors(ctx, cursors),
+	})
+	if values := cursors.values(); values != nil {
+		setExtension(result, cursorsExtension, values)
 	}
-	return graphql.Do(graphql.Params{
-		Schema:        schema.(graphql.Schema),
+	if pages := cursors.pageInfos(); pages != nil {
+		setExtension(result, pageInfoExtension, pages)
+	}
+	if paths := cursors.truncatedPaths(); paths != nil {
+		setExtension(result, truncatedExtension, paths)
+	}
+	if pages := cursors.clampedPages(); pages != nil {
+		setExtension(result, clampedExtension, pages)
+	}
+	if cacheable {
+		s.cache.set(cachedQuery, result)
+	}
+	return result, nil
+}
+
+// setExtension sets an extension of the result, creating its extensions if it
+// has none
+func setExtension(result *graphql.Result, key string, value interface{}) {
+	if result.Extensions == nil {
+		result.Extensions = make(map[string]interface{})
+	}
+	result.Extensions[key] = value
+}
+
+// QueryExplain queries the store like Query, and adds the SQL queries that
+// were run to the "explain" extension of the result. If analyze is true, the
+// output of EXPLAIN ANALYZE is also added for each SQL query.
+// It is meant for debugging, and returns an error unless enabled in the
+// store config
+func (s *Store) QueryExplain(tenant string, query string, analyze bool) (*graphql.Result, error) {
+	if !s.bCtx.StoreConfig.QueryExplain {
+		return nil, errors.New("explaining queries is disabled in the store config")
+	}
+	ts, err := s.tenantSchema(tenant)
+	if err != nil {
+		return nil, err
+	}
+	explain := &queryExplain{analyze: analyze, queries: []ExplainedQuery{}}
+	result := graphql.Do(graphql.Params{
+		Schema:        ts.schema,
 		RequestString: query,
-	}), nil
+		Context:       withQueryExplain(context.Background(), explain),
+	})
+	setExtension(result, explainExtension, explain.queries)
+	return result, nil
 }
 
 // Apply applies a schema corresponding to a set of tables.
@@ -112,28 +249,111 @@ func (s *Store) Query(tenant string, query string) (*graphql.Result, error) {
 // modified or not. It is true when called internally, and false when an end
 // user has initiated the request
 func (s *Store) Apply(tenant string, tables core.Tables, internal bool) error {
-	var schema *bubblySchema
+	s.schemaMu.Lock()
+	defer s.schemaMu.Unlock()
+	return s.apply(tenant, tables, internal)
+}
+
+// apply applies the tables like Apply, and must be called with schemaMu held
+func (s *Store) apply(tenant string, tables core.Tables, internal bool) error {
+	schema, err := s.appliedBubblySchema(tenant)
+	if err != nil {
+		return err
+	}
+
+	newSchema, err := newBubblySchemaFromTables(tables, internal)
+	if err != nil {
+		return err
+	}
+	return s.migrate(tenant, schema, newSchema)
+}
+
+// PreviewSchema returns the SQL statements that applying the tables to the
+// schema of a tenant would run, such as creating, altering and dropping tables,
+// without running them. The statements are the same as those that Apply runs
+// if the schema does not change in the meantime
+func (s *Store) PreviewSchema(tenant string, tables core.Tables) ([]string, error) {
+	s.schemaMu.Lock()
+	defer s.schemaMu.Unlock()
+
+	schema, err := s.appliedBubblySchema(tenant)
+	if err != nil {
+		return nil, err
+	}
+	newSchema, err := newBubblySchemaFromTables(tables, false)
+	if err != nil {
+		return nil, err
+	}
+	cl, err := compareSchema(schema, newSchema)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compare schemas: %w", err)
+	}
+	return s.provider().MigrationSQL(tenant, newSchema, cl)
+}
+
+// AddTables adds tables to the schema of a tenant, keeping all the tables
+// that already exist. Existing tables can be given to add new fields and joins
+// to them. Only the new tables and columns are created, so existing data is
+// left untouched
+func (s *Store) AddTables(tenant string, tables core.Tables) error {
+	s.schemaMu.Lock()
+	defer s.schemaMu.Unlock()
+
+	schema, err := s.appliedBubblySchema(tenant)
+	if err != nil {
+		return err
+	}
+	// If there is no schema yet, there is nothing to extend
+	if len(schema.Tables) == 0 {
+		return s.apply(tenant, tables, false)
+	}
+
+	newSchema, err := extendBubblySchema(schema, tables)
+	if err != nil {
+		return err
+	}
+	return s.migrate(tenant, schema, newSchema)
+}
+
+// SchemaTables returns the tables of the schema currently applied for a
+// tenant, by name, including the builtin tables. If no schema has been applied
+// yet there are no tables
+func (s *Store) SchemaTables(tenant string) (map[string]core.Table, error) {
+	schema, err := s.appliedBubblySchema(tenant)
+	if err != nil {
+		return nil, err
+	}
+	if schema.Tables == nil {
+		return make(map[string]core.Table), nil
+	}
+	return schema.Tables, nil
+}
+
+// appliedBubblySchema returns the schema currently applied for a tenant, or
+// an empty schema if none has been applied yet
+func (s *Store) appliedBubblySchema(tenant string) (*bubblySchema, error) {
 	// We should check that a schema already exists, and if not, we should
 	// initialize one
-	ok, err := s.p.HasTable(tenant, core.SchemaTableName)
+	ok, err := s.provider().HasTable(tenant, core.SchemaTableName)
 	if err != nil {
-		return fmt.Errorf("error checking if provider has schema for tenant %s: %w", tenant, err)
+		return nil, fmt.Errorf("error checking if provider has schema for tenant %s: %w", tenant, err)
 	}
-	if ok {
-		var err error
-		schema, err = s.currentBubblySchema(tenant)
-		if err != nil {
-			return fmt.Errorf("failed to get current schema: %w", err)
-		}
+	if !ok {
+		return &bubblySchema{}, nil
+	}
+	schema, err := s.currentBubblySchema(tenant)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get current schema: %w", err)
 	}
 	if schema == nil {
 		schema = &bubblySchema{}
 	}
+	return schema, nil
+}
 
-	newSchema, err := newBubblySchemaFromTables(tables, internal)
-	if err != nil {
-		return err
-	}
+// migrate migrates the tenant from the schema to the new schema and updates
+// the store cache. It must be called with schemaMu held
+func (s *Store) migrate(tenant string, schema *bubblySchema, newSchema *bubblySchema) error {
 	// Calculate the schema diff
 	cl, err := compareSchema(schema, newSchema)
 	if err != nil {
@@ -142,7 +362,7 @@ func (s *Store) Apply(tenant string, tables core.Tables, internal bool) error {
 	newSchema.changelog = cl
 
 	// Perform the migration based on the schemaUpdates
-	if err := s.p.Migrate(tenant, newSchema, cl); err != nil {
+	if err := s.provider().Migrate(tenant, newSchema, cl); err != nil {
 		return fmt.Errorf("failed to migrate schema: %w", err)
 	}
 
@@ -162,12 +382,19 @@ func (s *Store) Save(tenant string, data core.DataBlocks) error {
 	if err != nil {
 		return fmt.Errorf("failed to create tree of data blocks for storing: %w", err)
 	}
-	graphVal, ok := s.graphs.GetStringKey(tenant)
-	if !ok {
-		return fmt.Errorf("no schema exists for tenant %s", tenant)
+	// Invalidate any cached query results for the tables that are saved to,
+	// also if saving fails as some of the data might have been saved
+	tables := make(map[string]struct{})
+	dataTree.tables(tables)
+	defer s.cache.invalidate(tenant, tables)
+
+	// Use the same graph throughout, even if the schema is updated meanwhile
+	ts, err := s.tenantSchema(tenant)
+	if err != nil {
+		return err
 	}
-	graph = graphVal.(*SchemaGraph)
-	if err := s.p.Save(s.bCtx, tenant, graph, dataTree); err != nil {
+	graph = ts.graph
+	if err := s.provider().Save(s.bCtx, tenant, graph, dataTree); err != nil {
 		return fmt.Errorf("falied to save data in provider: %w", err)
 	}
 
@@ -176,8 +403,9 @@ func (s *Store) Save(tenant string, data core.DataBlocks) error {
 	if err != nil {
 		return fmt.Errorf("data triggers failed: %w", err)
 	}
+	triggersTree.tables(tables)
 
-	if err := s.p.Save(s.bCtx, tenant, graph, triggersTree); err != nil {
+	if err := s.provider().Save(s.bCtx, tenant, graph, triggersTree); err != nil {
 		return fmt.Errorf("falied to save data in provider: %w", err)
 	}
 
@@ -189,10 +417,32 @@ func (s *Store) Save(tenant string, data core.DataBlocks) error {
 	return nil
 }
 
+// DeleteResource deletes the resource with the given ID, along with its
+// events and versions. It returns false if no such resource exists
+func (s *Store) DeleteResource(tenant string, id string) (bool, error) {
+	// Invalidate any cached query results for the tables that are deleted from
+	defer s.cache.invalidate(tenant, map[string]struct{}{
+		core.ResourceTableName:        {},
+		core.EventTableName:           {},
+		core.ResourceVersionTableName: {},
+	})
+
+	deleted, err := s.provider().DeleteResource(tenant, id)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete resource %s: %w", id, err)
+	}
+	return deleted, nil
+}
+
 // Close closes the connection to the store's own database and the provider
 func (s *Store) Close() {
-	// Close the provider's connection
-	s.p.Close()
+	s.closeOnce.Do(func() {
+		// Stop the watchdog so that it does not reconnect
+		close(s.closing)
+		<-s.watchdogDone
+		// Close the provider's connection
+		s.provider().Close()
+	})
 }
 
 func (s *Store) initStoreSchemas() error {
@@ -200,7 +450,7 @@ func (s *Store) initStoreSchemas() error {
 	// If multitenancy is enabled, fetch the tenants from the store
 	if s.bCtx.AuthConfig.MultiTenancy {
 		var err error
-		tenants, err = s.p.Tenants()
+		tenants, err = s.provider().Tenants()
 		if err != nil {
 			return fmt.Errorf("failed to get tenants from provider: %w", err)
 		}
@@ -217,17 +467,20 @@ func (s *Store) initStoreSchemas() error {
 	for _, tenant := range tenants {
 		// Check if the provider database has the schema table.
 		// If not, it indicates a fresh database that should be initialized
-		schemaExists, err := s.p.HasTable(tenant, core.SchemaTableName)
+		schemaExists, err := s.provider().HasTable(tenant, core.SchemaTableName)
 		if err != nil {
 			return fmt.Errorf("failed to check existing schema table: %w", err)
 		}
 		if !schemaExists {
 			// If the schema table does not exist yet we should create it
-			if err := s.p.Apply(tenant, newBubblySchema()); err != nil {
+			if err := s.provider().Apply(tenant, newBubblySchema()); err != nil {
 				return fmt.Errorf("failed to initialize the provider database with internal tables")
 			}
 		}
 
+		if err := s.provider().Upgrade(tenant); err != nil {
+			return fmt.Errorf("failed to upgrade tables of tenant %s: %w", tenant, err)
+		}
 		if err := s.syncSchema(tenant); err != nil {
 			return fmt.Errorf("failed to sync schema for tenant %s: %w", tenant, err)
 		}
@@ -252,21 +505,21 @@ func (s *Store) currentBubblySchema(tenant string) (*bubblySchema, error) {
 		schema graphql.Schema
 		err    error
 	)
-	schemaVal, ok := s.schemas.GetStringKey(tenant)
-	if !ok {
+	if ts, tsErr := s.tenantSchema(tenant); tsErr == nil {
+		schema = ts.schema
+	} else {
 		// If there was no schema for this tenant, it might be because we are
 		// initialising the store. In order to do this, we need at least some
 		// minimum viable graphq schema to query the provider for the existing
 		// schema
 		graph := internalSchemaGraph()
-		schemaVal, err = newGraphQLSchema(graph, func(p graphql.ResolveParams) (interface{}, error) {
-			return s.p.ResolveQuery(tenant, graph, p)
+		schema, err = newGraphQLSchema(graph, func(p graphql.ResolveParams) (interface{}, error) {
+			return s.provider().ResolveQuery(tenant, graph, p)
 		})
 		if err != nil {
 			return nil, fmt.Errorf("failed creating GraphQL schema of internal tables: %w", err)
 		}
 	}
-	schema = schemaVal.(graphql.Schema)
 
 	result := graphql.Do(graphql.Params{
 		Schema:        schema,
@@ -301,20 +554,34 @@ func (s *Store) updateSchema(tenant string, bubblySchema *bubblySchema) error {
 	}
 
 	schema, err := newGraphQLSchema(graph, func(p graphql.ResolveParams) (interface{}, error) {
-		return s.p.ResolveQuery(tenant, graph, p)
+		return s.provider().ResolveQuery(tenant, graph, p)
 	})
 	if err != nil {
 		return fmt.Errorf("failed to create GraphQL schema from graph: %w", err)
 	}
 
-	s.graphs.Set(tenant, graph)
-	s.schemas.Set(tenant, schema)
+	// Replace the graph and GraphQL schema together, so that they are never
+	// read from different schemas
+	s.schemas.Set(tenant, &tenantSchema{
+		graph:   graph,
+		schema:  schema,
+		updated: time.Now(),
+	})
+	// The schema has changed, so any cached query results might be invalid
+	s.cache.invalidateTenant(tenant)
 	return nil
 }
 
 const (
 	tableIDField    = "_id"
 	tableJoinSuffix = "_id"
+	// tableSeqField is a sequence number that is set whenever a row is
+	// created or updated, so that the rows changed since a given sequence
+	// number can be queried
+	tableSeqField = "_seq"
+	// tableDeletedAtField is the time at which a row was soft-deleted, and is
+	// NULL for rows that are not deleted
+	tableDeletedAtField = "_deleted_at"
 )
 
 // TODO: add "limit" arg to this query
@@ -2,6 +2,7 @@ package store
 
 import (
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"path/filepath"
@@ -17,6 +18,7 @@ import (
 	"github.com/zclconf/go-cty/cty"
 
 	"github.com/valocode/bubbly/api/core"
+	"github.com/valocode/bubbly/client"
 	"github.com/valocode/bubbly/config"
 	"github.com/valocode/bubbly/env"
 	"github.com/valocode/bubbly/events"
@@ -151,6 +153,35 @@ var queryTests = []struct {
 			},
 		},
 	},
+	{
+		name: "query up through two parents",
+		query: `
+			{
+				grandchild_a(name: "first_grandchild") {
+					name
+					child_a {
+						name
+						root {
+							name
+						}
+					}
+				}
+			}
+			`,
+		expected: map[string]interface{}{
+			"grandchild_a": []interface{}{
+				map[string]interface{}{
+					"name": "first_grandchild",
+					"child_a": map[string]interface{}{
+						"name": "first_child",
+						"root": map[string]interface{}{
+							"name": "first_root",
+						},
+					},
+				},
+			},
+		},
+	},
 	// TODO not jumping nodes anymore, move test to a new "must fail" test set
 	/*
 		{
@@ -864,6 +895,70 @@ var sqlGenTests = []struct {
 			},
 		},
 	},
+	{
+		name:   "graphql filter on _id",
+		schema: "tables5.hcl",
+		data:   "data5.hcl",
+		query: `
+		{
+			location(filter: {_id_gt: "1"}, order_by: {_id: asc}) {
+				name
+			}
+		}`,
+		want: map[string]interface{}{
+			"location": []interface{}{
+				map[string]interface{}{
+					"name": "Gold Coast City Skyline",
+				},
+				map[string]interface{}{
+					"name": "Secret Underground Facility on the Moon",
+				},
+			},
+		},
+	},
+	{
+		name:   "graphql filter on list and _id",
+		schema: "tables5.hcl",
+		data:   "data5.hcl",
+		query: `
+		{
+			location(filter: {
+				name_in: ["Deep Dark Wood", "Secret Underground Facility on the Moon"],
+				_id_not_in: ["3"]
+			}) {
+				name
+			}
+		}`,
+		want: map[string]interface{}{
+			"location": []interface{}{
+				map[string]interface{}{
+					"name": "Deep Dark Wood",
+				},
+			},
+		},
+	},
+	{
+		name:   "graphql argument with sql metacharacters",
+		schema: "tables5.hcl",
+		data:   "data5.hcl",
+		query: `
+		{
+			location(name: "'; DROP TABLE location; --") {
+				name
+			}
+			configuration(filter: {name_in: ["Primitive", "'); DROP TABLE configuration; --"]}) {
+				name
+			}
+		}`,
+		want: map[string]interface{}{
+			"location": []interface{}{},
+			"configuration": []interface{}{
+				map[string]interface{}{
+					"name": "Primitive",
+				},
+			},
+		},
+	},
 }
 
 func applySchemaOrDie(t *testing.T, bCtx *env.BubblyContext, s *Store, fromFile string) {
@@ -944,6 +1039,128 @@ func runResourceTestsOrDie(t *testing.T, bCtx *env.BubblyContext, s *Store) {
 	})
 }
 
+// runResourceVersionTestsOrDie updates a resource twice and checks that each
+// version of it can be fetched
+func runResourceVersionTestsOrDie(t *testing.T, bCtx *env.BubblyContext, s *Store) {
+	t.Helper()
+
+	t.Run("resource versions", func(t *testing.T) {
+		const resID = "kind/versioned"
+		specs := []string{"data {}", "data {\n  v = 2\n}", "data {\n  v = 3\n}"}
+		for _, spec := range specs {
+			res := core.ResourceBlock{
+				ResourceKind:       "kind",
+				ResourceName:       "versioned",
+				ResourceAPIVersion: "some version",
+				SpecRaw:            spec,
+			}
+			d, err := res.Data()
+			require.NoError(t, err)
+			require.NoError(t, s.Save(DefaultTenantName, core.DataBlocks{d}))
+		}
+
+		result, err := s.Query(DefaultTenantName, client.ResourceVersionsQuery(resID))
+		require.NoError(t, err)
+		require.Empty(t, result.Errors)
+		resultBytes, err := json.Marshal(result)
+		require.NoError(t, err)
+
+		vBytes, err := client.ResourceVersionsFromQueryResult(resID, resultBytes)
+		require.NoError(t, err)
+		var versions []client.ResourceVersion
+		require.NoError(t, json.Unmarshal(vBytes, &versions))
+		require.Len(t, versions, len(specs))
+		for i, spec := range specs {
+			assert.Equal(t, i+1, versions[i].Version)
+			assert.Equal(t, spec, versions[i].Resource.SpecRaw)
+			assert.NotEmpty(t, versions[i].Time)
+
+			resBytes, err := client.ResourceVersionFromQueryResult(resID, i+1, resultBytes)
+			require.NoError(t, err)
+			var res core.ResourceBlock
+			require.NoError(t, json.Unmarshal(resBytes, &res))
+			assert.Equal(t, resID, res.ID())
+			assert.Equal(t, spec, res.SpecRaw)
+		}
+
+		// The resource itself is the latest version
+		result, err = s.Query(DefaultTenantName, client.ResourceQuery(resID))
+		require.NoError(t, err)
+		resultBytes, err = json.Marshal(result)
+		require.NoError(t, err)
+		resBytes, err := client.ResourceFromQueryResult(resID, resultBytes)
+		require.NoError(t, err)
+		var res core.ResourceBlock
+		require.NoError(t, json.Unmarshal(resBytes, &res))
+		assert.Equal(t, specs[len(specs)-1], res.SpecRaw)
+	})
+}
+
+// runDeleteResourceTestsOrDie deletes a resource and checks that it, and its
+// events and versions, are gone
+func runDeleteResourceTestsOrDie(t *testing.T, bCtx *env.BubblyContext, s *Store) {
+	t.Helper()
+
+	t.Run("delete resource", func(t *testing.T) {
+		data := createResJSONOrDie(t)
+		err := s.Save(DefaultTenantName, core.DataBlocks{data})
+		require.NoError(t, err)
+
+		deleted, err := s.DeleteResource(DefaultTenantName, "kind/name")
+		require.NoError(t, err)
+		assert.True(t, deleted)
+
+		result, err := s.Query(DefaultTenantName, `{ _resource(id: "kind/name") { id } _event { status } _resource_version(name: "name") { name } }`)
+		require.NoError(t, err)
+		require.Empty(t, result.Errors)
+		assert.Empty(t, result.Data.(map[string]interface{})[core.ResourceTableName])
+		assert.Empty(t, result.Data.(map[string]interface{})[core.EventTableName])
+		assert.Empty(t, result.Data.(map[string]interface{})[core.ResourceVersionTableName])
+
+		// Deleting it again should not find it
+		deleted, err = s.DeleteResource(DefaultTenantName, "kind/name")
+		require.NoError(t, err)
+		assert.False(t, deleted)
+	})
+}
+
+// runSaveRollbackTestsOrDie saves several resources together, where the last
+// one fails, and checks that none of them are saved
+func runSaveRollbackTestsOrDie(t *testing.T, bCtx *env.BubblyContext, s *Store) {
+	t.Helper()
+
+	t.Run("save rollback", func(t *testing.T) {
+		var data core.DataBlocks
+		for _, name := range []string{"rollback_first", "rollback_second"} {
+			res := core.ResourceBlock{
+				ResourceKind:       "kind",
+				ResourceName:       name,
+				ResourceAPIVersion: "some version",
+				SpecRaw:            "data {}",
+			}
+			d, err := res.Data()
+			require.NoError(t, err)
+			data = append(data, d)
+		}
+		// The third resource has a field that does not exist, so it cannot be
+		// saved
+		data = append(data, core.Data{
+			TableName: core.ResourceTableName,
+			Fields: &core.DataFields{Values: map[string]cty.Value{
+				"id":      cty.StringVal("kind/rollback_third"),
+				"unknown": cty.StringVal("value"),
+			}},
+		})
+		err := s.Save(DefaultTenantName, data)
+		require.Error(t, err)
+
+		result, err := s.Query(DefaultTenantName, `{ _resource(filter: {id_in: ["kind/rollback_first", "kind/rollback_second", "kind/rollback_third"]}) { id } }`)
+		require.NoError(t, err)
+		require.Empty(t, result.Errors)
+		assert.Empty(t, result.Data.(map[string]interface{})[core.ResourceTableName])
+	})
+}
+
 // runEventTestsOrDie runs all event-related tests, or fails hard on error.
 func runEventTestsOrDie(t *testing.T, bCtx *env.BubblyContext, s *Store) {
 	t.Helper()
@@ -1236,7 +1453,10 @@ func TestPostgres(t *testing.T) {
 	// Run (sub)tests
 	runQueryTestsOrDie(t, bCtx, s)
 	runResourceTestsOrDie(t, bCtx, s)
+	runResourceVersionTestsOrDie(t, bCtx, s)
 	runEventTestsOrDie(t, bCtx, s)
+	runDeleteResourceTestsOrDie(t, bCtx, s)
+	runSaveRollbackTestsOrDie(t, bCtx, s)
 }
 
 // Tests that should bubbly go down, on reinitialisation the Store correctly
@@ -0,0 +1,20 @@
+data "ticket" {
+    fields {
+        name = "no_status"
+    }
+}
+
+data "ticket" {
+    fields {
+        name = "null_status"
+        status = null
+    }
+}
+
+data "ticket" {
+    fields {
+        name = "open"
+        status = "OPEN"
+        priority = 1
+    }
+}
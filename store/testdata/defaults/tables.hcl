@@ -0,0 +1,18 @@
+table "ticket" {
+    field "name" {
+        type = string
+        unique = true
+    }
+    field "status" {
+        type = string
+        default = "UNKNOWN"
+    }
+    field "priority" {
+        type = number
+        default = 3
+    }
+    field "created_at" {
+        type = string
+        default = "now()"
+    }
+}
@@ -0,0 +1,19 @@
+data "suite" {
+    fields {
+        name = "unit"
+    }
+    data "suite_run" {
+        fields {
+            passed = 3
+            total = 4
+        }
+    }
+}
+
+data "suite_run" {
+    fields {
+        passed = 0
+        total = 0
+    }
+    joins = ["suite"]
+}
@@ -0,0 +1,23 @@
+table "suite" {
+    field "name" {
+        type = string
+    }
+
+    table "suite_run" {
+        field "passed" {
+            type = number
+        }
+        field "total" {
+            type = number
+        }
+        // The percentage of tests that passed, or null if there were none
+        derived_field "pass_rate" {
+            type = number
+            expr = "passed * 100 / nullif(total, 0)"
+        }
+        derived_field "ok" {
+            type = bool
+            expr = "passed = total"
+        }
+    }
+}
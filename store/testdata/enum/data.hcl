@@ -0,0 +1,20 @@
+data "check" {
+    fields {
+        name = "lint"
+        status = "PASS"
+    }
+}
+
+data "check" {
+    fields {
+        name = "unit"
+        status = "FAIL"
+    }
+}
+
+data "check" {
+    fields {
+        name = "e2e"
+        status = "SKIP"
+    }
+}
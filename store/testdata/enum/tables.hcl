@@ -0,0 +1,10 @@
+table "check" {
+    field "name" {
+        type = string
+    }
+    // The status of a check can only be one of these values
+    field "status" {
+        type = string
+        enum = ["PASS", "FAIL", "SKIP"]
+    }
+}
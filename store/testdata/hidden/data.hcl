@@ -0,0 +1,11 @@
+data "team" {
+    fields {
+        name = "core"
+        secret = "hunter2"
+    }
+    data "member" {
+        fields {
+            email = "dev@example.com"
+        }
+    }
+}
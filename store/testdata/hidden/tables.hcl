@@ -0,0 +1,24 @@
+table "team" {
+    field "name" {
+        type = string
+        unique = true
+    }
+    // The secret is saved, but cannot be queried
+    field "secret" {
+        type = string
+        hidden = true
+    }
+    derived_field "has_secret" {
+        type = bool
+        expr = "secret IS NOT NULL"
+    }
+
+    table "member" {
+        field "email" {
+            type = string
+        }
+        join "team" {
+            hidden = true
+        }
+    }
+}
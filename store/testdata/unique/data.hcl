@@ -33,3 +33,14 @@ data "t9" {
     }
     joins = ["t1", "t3"]
 }
+
+data "test_set" {
+    fields { name = "test_set" }
+}
+data "test_case" {
+    fields {
+        name = "test_case"
+        result = true
+    }
+    joins = ["test_set"]
+}
@@ -96,3 +96,19 @@ table "t9" {
     join "t2" { unique = true }
     join "t3" { unique = true }
 }
+
+table "test_set" {
+    field "name" {
+        type = string
+        unique = true
+    }
+    table "test_case" {
+        unique_fields = ["test_set_id", "name"]
+        field "name" {
+            type = string
+        }
+        field "result" {
+            type = bool
+        }
+    }
+}
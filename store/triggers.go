@@ -87,7 +87,57 @@ func HandleTriggers(bCtx *env.BubblyContext, tree dataTree, triggers []*trigger,
 }
 
 func createInternalTriggers(tenant string) []*trigger {
-	return []*trigger{eventStoreTrigger(tenant), remoteRunTrigger(tenant)}
+	return []*trigger{eventStoreTrigger(tenant), resourceVersionTrigger(tenant), remoteRunTrigger(tenant)}
+}
+
+// resourceVersionTrigger appends a copy of a resource to the
+// _resource_version table each time that the resource is saved, because the
+// _resource table only has the latest version of a resource
+func resourceVersionTrigger(tenant string) *trigger {
+	return &trigger{
+		id:          "default/trigger/resource_version_trigger",
+		description: "save a version of a resource upon new/updated entry to resource store",
+		Kind:        Active,
+		visitFn: func(bCtx *env.BubblyContext, node *dataNode, blocks *core.DataBlocks) error {
+			if node.Data.TableName != core.ResourceTableName {
+				return nil
+			}
+			// Like for the event store trigger, a _resource that only has
+			// the "id" is a reference to a resource, not a new version of it
+			if len(node.Data.Fields.Values) == 1 || !node.Data.IsValidResource() {
+				return nil
+			}
+			fields := node.Data.Fields
+			id := fields.Values["id"]
+			if id.IsNull() {
+				return errors.New("DataBlock missing required field: id")
+			}
+			versionFields := map[string]cty.Value{
+				"time": cty.StringVal(events.TimeNow()),
+			}
+			for _, name := range []string{"name", "kind", "api_version", "spec", "metadata"} {
+				if val, ok := fields.Values[name]; ok {
+					versionFields[name] = val
+				}
+			}
+
+			*blocks = append(*blocks,
+				core.Data{
+					TableName: core.ResourceTableName,
+					Fields: &core.DataFields{Values: map[string]cty.Value{
+						"id": id,
+					}},
+					Policy: core.ReferencePolicy,
+				},
+				core.Data{
+					TableName: core.ResourceVersionTableName,
+					Fields:    &core.DataFields{Values: versionFields},
+					Joins:     []string{core.ResourceTableName},
+				},
+			)
+			return nil
+		},
+	}
 }
 
 func eventStoreTrigger(tenant string) *trigger {
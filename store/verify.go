@@ -0,0 +1,160 @@
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
+
+	"github.com/valocode/bubbly/api/core"
+	"github.com/valocode/bubbly/parser"
+)
+
+// VerifyAlias returns the alias of the root field in the query of
+// VerifyQuery that selects the rows of the data block with the given index.
+// The data blocks are indexed depth first, i.e. a data block comes before
+// its nested data blocks
+func VerifyAlias(idx int) string {
+	return "data_" + strconv.Itoa(idx)
+}
+
+// VerifyQuery returns a GraphQL query that selects the rows saved for the data
+// blocks, so that it can be checked that the data blocks were saved, e.g. in
+// tests.
+// The rows of each data block are selected by the values of its table's
+// unique fields, or by the values of all its fields if the table has no
+// unique fields or the data block does not give them. The query selects
+// the _id and the fields of each data block. Data blocks without any field
+// values that can be selected on, such as those with only references to other
+// data blocks, are skipped
+func VerifyQuery(graph *SchemaGraph, data core.DataBlocks) (string, error) {
+	var (
+		fields []string
+		idx    int
+	)
+	if err := verifyFields(graph, data, &idx, &fields); err != nil {
+		return "", err
+	}
+	if len(fields) == 0 {
+		return "", fmt.Errorf("no data blocks to verify")
+	}
+	return "{\n" + strings.Join(fields, "\n") + "\n}", nil
+}
+
+func verifyFields(graph *SchemaGraph, data core.DataBlocks, idx *int, fields *[]string) error {
+	for _, d := range data {
+		alias := VerifyAlias(*idx)
+		*idx++
+		node, ok := graph.NodeIndex[d.TableName]
+		if !ok {
+			return fmt.Errorf("table %s of data block %s does not exist", d.TableName, alias)
+		}
+		field, err := verifyField(*node.Table, d, alias)
+		if err != nil {
+			return err
+		}
+		if field != "" {
+			*fields = append(*fields, field)
+		}
+		if err := verifyFields(graph, d.Data, idx, fields); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// verifyField returns the root field of the query that selects the rows of
+// the data block, or an empty string if there are no values to select on
+func verifyField(table core.Table, d core.Data, alias string) (string, error) {
+	var (
+		// args are the values of the fields that can be selected on, by name
+		args   = make(map[string]string)
+		unique = make(map[string]struct{})
+		names  = []string{tableIDField}
+	)
+	for _, f := range table.Fields {
+		if f.Unique {
+			unique[f.Name] = struct{}{}
+		}
+	}
+	// The unique joins cannot be selected on, as the data blocks reference
+	// the rows that they join to
+	for _, name := range table.UniqueFields {
+		if _, ok := tableField(table, name); ok {
+			unique[name] = struct{}{}
+		}
+	}
+	if d.Fields != nil {
+		for name, val := range d.Fields.Values {
+			field, ok := tableField(table, name)
+			if !ok {
+				// Joins are given as fields, but are not fields of the table
+				// in the GraphQL schema
+				continue
+			}
+			names = append(names, name)
+			arg, ok, err := verifyValue(field, val)
+			if err != nil {
+				return "", fmt.Errorf("field %s of data block %s: %w", name, alias, err)
+			}
+			if ok {
+				args[name] = arg
+			}
+		}
+	}
+	// Select on the unique fields, if the data block gives them all
+	uniqueArgs := make(map[string]string, len(unique))
+	for name := range unique {
+		if arg, ok := args[name]; ok {
+			uniqueArgs[name] = arg
+		}
+	}
+	if len(uniqueArgs) > 0 && len(uniqueArgs) == len(unique) {
+		args = uniqueArgs
+	}
+	if len(args) == 0 {
+		return "", nil
+	}
+
+	argStrs := make([]string, 0, len(args))
+	for name, arg := range args {
+		argStrs = append(argStrs, name+": "+arg)
+	}
+	sort.Strings(argStrs)
+	sort.Strings(names[1:])
+	return fmt.Sprintf("  %s: %s(%s) { %s }", alias, table.Name, strings.Join(argStrs, ", "), strings.Join(names, " ")), nil
+}
+
+// verifyValue returns the value of a field as a GraphQL value, and whether
+// the rows can be selected on it. Only known, non-null values of primitive
+// types can be selected on, and not references to other data blocks
+func verifyValue(field core.TableField, val cty.Value) (string, bool, error) {
+	if val.IsNull() || !val.IsKnown() || val.Type() == parser.DataRefType || !field.Type.IsPrimitiveType() {
+		return "", false, nil
+	}
+	val, err := convert.Convert(val, field.Type)
+	if err != nil {
+		return "", false, fmt.Errorf("expected value of type %s: %w", field.Type.FriendlyName(), err)
+	}
+	switch field.Type {
+	case cty.Bool:
+		return strconv.FormatBool(val.True()), true, nil
+	case cty.Number:
+		bf := val.AsBigFloat()
+		if !bf.IsInt() {
+			return "", false, fmt.Errorf("expected a whole number: %s", bf.Text('f', -1))
+		}
+		return bf.Text('f', 0), true, nil
+	default:
+		// JSON strings are also valid GraphQL strings
+		b, err := json.Marshal(val.AsString())
+		if err != nil {
+			return "", false, err
+		}
+		return string(b), true, nil
+	}
+}
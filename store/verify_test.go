@@ -0,0 +1,214 @@
+package store
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/valocode/bubbly/api/core"
+	"github.com/valocode/bubbly/env"
+	"github.com/valocode/bubbly/test"
+)
+
+var verifyTables = core.Tables{
+	{
+		Name: "product",
+		Fields: []core.TableField{
+			{Name: "name", Type: cty.String, Unique: true},
+			{Name: "description", Type: cty.String},
+		},
+	},
+	{
+		Name: "test_result",
+		Fields: []core.TableField{
+			{Name: "name", Type: cty.String},
+			{Name: "result", Type: cty.Bool},
+			{Name: "duration", Type: cty.Number},
+		},
+		Joins: []core.TableJoin{{Table: "product"}},
+	},
+}
+
+func TestVerifyQuery(t *testing.T) {
+	graph, err := NewSchemaGraph(verifyTables)
+	require.NoError(t, err)
+
+	tcs := []struct {
+		desc     string
+		data     core.DataBlocks
+		expected string
+		wantErr  bool
+	}{
+		{
+			desc: "unique fields",
+			data: core.DataBlocks{
+				{
+					TableName: "product",
+					Fields: &core.DataFields{Values: map[string]cty.Value{
+						"name":        cty.StringVal(`a "quoted" name`),
+						"description": cty.StringVal("b"),
+					}},
+				},
+			},
+			expected: "{\n" +
+				`  data_0: product(name: "a \"quoted\" name") { _id description name }` +
+				"\n}",
+		},
+		{
+			desc: "nested data blocks",
+			data: core.DataBlocks{
+				{
+					TableName: "product",
+					Fields:    &core.DataFields{Values: map[string]cty.Value{"name": cty.StringVal("a")}},
+					Data: core.DataBlocks{
+						{
+							TableName: "test_result",
+							Fields: &core.DataFields{Values: map[string]cty.Value{
+								"name":     cty.StringVal("t"),
+								"result":   cty.True,
+								"duration": cty.NumberIntVal(3),
+							}},
+						},
+					},
+				},
+			},
+			expected: "{\n" +
+				`  data_0: product(name: "a") { _id name }` + "\n" +
+				`  data_1: test_result(duration: 3, name: "t", result: true) { _id duration name result }` +
+				"\n}",
+		},
+		{
+			desc: "skip null values",
+			data: core.DataBlocks{
+				{
+					TableName: "test_result",
+					Fields: &core.DataFields{Values: map[string]cty.Value{
+						"name":   cty.StringVal("t"),
+						"result": cty.NullVal(cty.Bool),
+					}},
+				},
+			},
+			expected: "{\n" +
+				`  data_0: test_result(name: "t") { _id name result }` +
+				"\n}",
+		},
+		{
+			desc: "no values",
+			data: core.DataBlocks{
+				{TableName: "product", Fields: &core.DataFields{}},
+			},
+			wantErr: true,
+		},
+		{
+			desc: "unknown table",
+			data: core.DataBlocks{
+				{TableName: "unknown", Fields: &core.DataFields{}},
+			},
+			wantErr: true,
+		},
+		{
+			desc: "not a whole number",
+			data: core.DataBlocks{
+				{
+					TableName: "test_result",
+					Fields:    &core.DataFields{Values: map[string]cty.Value{"duration": cty.NumberFloatVal(1.5)}},
+				},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.desc, func(t *testing.T) {
+			query, err := VerifyQuery(graph, tc.data)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, query)
+		})
+	}
+}
+
+// TestVerifyQuerySaved tests that the query returned by VerifyQuery selects
+// the rows that were saved for the data blocks
+func TestVerifyQuerySaved(t *testing.T) {
+	bCtx := env.NewBubblyContext()
+	resource := test.RunPostgresDocker(bCtx, t)
+	bCtx.StoreConfig.PostgresAddr = fmt.Sprintf("localhost:%s", resource.GetPort("5432/tcp"))
+
+	s, err := New(bCtx)
+	require.NoError(t, err)
+	require.NoError(t, s.Apply(DefaultTenantName, verifyTables, false))
+
+	data := core.DataBlocks{
+		{
+			TableName: "product",
+			Fields: &core.DataFields{Values: map[string]cty.Value{
+				"name":        cty.StringVal("bubbly"),
+				"description": cty.StringVal("a release readiness platform"),
+			}},
+			Data: core.DataBlocks{
+				{
+					TableName: "test_result",
+					Fields: &core.DataFields{Values: map[string]cty.Value{
+						"name":     cty.StringVal("TestVerifyQuery"),
+						"result":   cty.True,
+						"duration": cty.NumberIntVal(12),
+					}},
+				},
+				{
+					TableName: "test_result",
+					Fields: &core.DataFields{Values: map[string]cty.Value{
+						"name":   cty.StringVal("TestVerifyQuerySaved"),
+						"result": cty.False,
+					}},
+				},
+			},
+		},
+	}
+	require.NoError(t, s.Save(DefaultTenantName, data))
+
+	graph, err := NewSchemaGraph(verifyTables)
+	require.NoError(t, err)
+	query, err := VerifyQuery(graph, data)
+	require.NoError(t, err)
+
+	result, err := s.Query(DefaultTenantName, query)
+	require.NoError(t, err)
+	require.Empty(t, result.Errors)
+	rows := result.Data.(map[string]interface{})
+
+	expected := []map[string]interface{}{
+		{"name": "bubbly", "description": "a release readiness platform"},
+		{"name": "TestVerifyQuery", "result": true, "duration": float64(12)},
+		{"name": "TestVerifyQuerySaved", "result": false},
+	}
+	for i, exp := range expected {
+		alias := VerifyAlias(i)
+		require.Contains(t, rows, alias)
+		aliasRows := rows[alias].([]interface{})
+		require.Len(t, aliasRows, 1, alias)
+		row := aliasRows[0].(map[string]interface{})
+		assert.NotNil(t, row[tableIDField], alias)
+		for name, val := range exp {
+			assert.EqualValues(t, val, row[name], "%s: %s", alias, name)
+		}
+	}
+
+	// A data block that was not saved is not returned by the query
+	query, err = VerifyQuery(graph, core.DataBlocks{
+		{
+			TableName: "product",
+			Fields:    &core.DataFields{Values: map[string]cty.Value{"name": cty.StringVal("unsaved")}},
+		},
+	})
+	require.NoError(t, err)
+	result, err = s.Query(DefaultTenantName, query)
+	require.NoError(t, err)
+	require.Empty(t, result.Errors)
+	assert.Empty(t, result.Data.(map[string]interface{})[VerifyAlias(0)])
+}
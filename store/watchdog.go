@@ -0,0 +1,97 @@
+package store
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/valocode/bubbly/config"
+	"github.com/valocode/bubbly/env"
+)
+
+const (
+	// watchdogMinBackoff and watchdogMaxBackoff are the bounds of the time
+	// that the watchdog waits between attempts to reconnect to the provider
+	watchdogMinBackoff = time.Second
+	watchdogMaxBackoff = 30 * time.Second
+)
+
+// providerFactory creates a new provider, connected to its database
+type providerFactory func() (provider, error)
+
+// newProviderFactory returns the providerFactory for the provider in the store
+// config
+func newProviderFactory(bCtx *env.BubblyContext, o *options) (providerFactory, error) {
+	switch bCtx.StoreConfig.Provider {
+	case config.PostgresStore:
+		return func() (provider, error) {
+			return newPostgres(bCtx, o)
+		}, nil
+	case config.CockroachDBStore:
+		return func() (provider, error) {
+			return newCockroachdb(bCtx, o)
+		}, nil
+	default:
+		return nil, fmt.Errorf("invalid provider: %s", bCtx.StoreConfig.Provider)
+	}
+}
+
+// provider returns the store's current provider, which can be replaced by the
+// watchdog when reconnecting
+func (s *Store) provider() provider {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.p
+}
+
+// runWatchdog periodically pings the provider's database, and if the ping
+// fails it reconnects to the database by creating a new provider, backing off
+// between failed attempts. It returns when the store is closed
+func (s *Store) runWatchdog(interval time.Duration) {
+	defer close(s.watchdogDone)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.closing:
+			return
+		case <-ticker.C:
+		}
+		err := s.provider().Ping()
+		if err == nil {
+			continue
+		}
+		s.bCtx.Logger.Warn().Err(err).Msg("store connection is dead, reconnecting")
+		if !s.reconnect() {
+			return
+		}
+		s.bCtx.Logger.Info().Msg("store reconnected")
+	}
+}
+
+// reconnect creates a new provider, retrying with backoff until it succeeds
+// and replaces the store's provider with it. It returns false if the store was
+// closed before reconnecting
+func (s *Store) reconnect() bool {
+	backoff := watchdogMinBackoff
+	for {
+		p, err := s.newProvider()
+		if err == nil {
+			s.mu.Lock()
+			old := s.p
+			s.p = p
+			s.mu.Unlock()
+			old.Close()
+			return true
+		}
+		s.bCtx.Logger.Warn().Err(err).Dur("backoff", backoff).Msg("store reconnection attempt failed")
+		select {
+		case <-s.closing:
+			return false
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+		if backoff > watchdogMaxBackoff {
+			backoff = watchdogMaxBackoff
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package store
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cornelk/hashmap"
+	"github.com/graphql-go/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/valocode/bubbly/env"
+)
+
+var errConnDead = errors.New("connection is dead")
+
+// stubProvider is a provider whose connection can die
+type stubProvider struct {
+	mu     sync.Mutex
+	dead   bool
+	closed bool
+}
+
+func (p *stubProvider) err() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.dead || p.closed {
+		return errConnDead
+	}
+	return nil
+}
+
+func (p *stubProvider) kill() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.dead = true
+}
+
+func (p *stubProvider) isClosed() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.closed
+}
+
+func (p *stubProvider) Tenants() ([]string, error) { return nil, p.err() }
+func (p *stubProvider) CreateTenant(string) error  { return p.err() }
+func (p *stubProvider) Close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.closed = true
+}
+func (p *stubProvider) Ping() error                                        { return p.err() }
+func (p *stubProvider) Apply(string, *bubblySchema) error                  { return p.err() }
+func (p *stubProvider) Migrate(string, *bubblySchema, schemaUpdates) error { return p.err() }
+func (p *stubProvider) MigrationSQL(string, *bubblySchema, schemaUpdates) ([]string, error) {
+	return nil, p.err()
+}
+func (p *stubProvider) Save(*env.BubblyContext, string, *SchemaGraph, dataTree) error {
+	return p.err()
+}
+func (p *stubProvider) DeleteResource(string, string) (bool, error) { return false, p.err() }
+func (p *stubProvider) ResolveQuery(string, *SchemaGraph, graphql.ResolveParams) (interface{}, error) {
+	return nil, p.err()
+}
+func (p *stubProvider) HasTable(string, string) (bool, error) { return true, p.err() }
+func (p *stubProvider) Upgrade(string) error                  { return p.err() }
+
+func TestWatchdogReconnect(t *testing.T) {
+	var (
+		first     = &stubProvider{}
+		attempts  int
+		recovered = &stubProvider{}
+	)
+	s := &Store{
+		bCtx: env.NewBubblyContext(),
+		p:    first,
+		// The database is down for the first reconnection attempt
+		newProvider: func() (provider, error) {
+			attempts++
+			if attempts == 1 {
+				return nil, errConnDead
+			}
+			return recovered, nil
+		},
+		closing:      make(chan struct{}),
+		watchdogDone: make(chan struct{}),
+		schemas:      &hashmap.HashMap{},
+	}
+	go s.runWatchdog(10 * time.Millisecond)
+	defer s.Close()
+
+	require.NoError(t, s.CreateTenant(DefaultTenantName))
+
+	// The connection dies, and queries fail until the watchdog reconnects
+	first.kill()
+	err := s.CreateTenant(DefaultTenantName)
+	assert.True(t, errors.Is(err, errConnDead), "expected dead connection error, got: %v", err)
+
+	require.Eventually(t, func() bool {
+		return s.provider() == recovered
+	}, 5*time.Second, 10*time.Millisecond, "watchdog did not reconnect")
+
+	assert.NoError(t, s.CreateTenant(DefaultTenantName))
+	assert.True(t, first.isClosed(), "dead provider should be closed")
+	assert.Equal(t, 2, attempts)
+}
+
+func TestWatchdogStopsOnClose(t *testing.T) {
+	p := &stubProvider{}
+	s := &Store{
+		bCtx: env.NewBubblyContext(),
+		p:    p,
+		newProvider: func() (provider, error) {
+			return nil, errConnDead
+		},
+		closing:      make(chan struct{}),
+		watchdogDone: make(chan struct{}),
+	}
+	go s.runWatchdog(10 * time.Millisecond)
+	// Kill the connection so that the watchdog keeps trying to reconnect
+	p.kill()
+	time.Sleep(50 * time.Millisecond)
+
+	closed := make(chan struct{})
+	go func() {
+		s.Close()
+		close(closed)
+	}()
+	select {
+	case <-closed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("closing the store did not stop the watchdog")
+	}
+	assert.True(t, p.isClosed())
+}
@@ -37,22 +37,61 @@ func RunPostgresDocker(bCtx *env.BubblyContext, t *testing.T) *dockertest.Resour
 		}
 	})
 
-	err = waitUntilDatabaseIsReady(bCtx, t, pool, resource)
+	pgConnStr := fmt.Sprintf("postgresql://%s:%s@localhost:%s/%s?sslmode=disable",
+		bCtx.StoreConfig.PostgresUser, bCtx.StoreConfig.PostgresPassword,
+		resource.GetPort("5432/tcp"), bCtx.StoreConfig.PostgresDatabase)
+	err = waitUntilDatabaseIsReady(pool, pgConnStr)
 	require.NoErrorf(t, err, "error waiting for database to be ready")
 
 	return resource
 }
 
-func waitUntilDatabaseIsReady(bCtx *env.BubblyContext, t *testing.T, pool *dockertest.Pool, resource *dockertest.Resource) error {
-	pgConnStr := fmt.Sprintf("postgresql://%s:%s@localhost:%s/%s?sslmode=disable",
-		bCtx.StoreConfig.PostgresUser, bCtx.StoreConfig.PostgresPassword,
-		resource.GetPort("5432/tcp"), bCtx.StoreConfig.PostgresDatabase)
+// RunCockroachDocker runs a docker container for a single, insecure
+// CockroachDB node, with the database from the provided BubblyContext.
+// Like for RunPostgresDocker, the test should set the address of the database
+// in the BubblyContext from the port of the returned resource
+func RunCockroachDocker(bCtx *env.BubblyContext, t *testing.T) *dockertest.Resource {
+	pool, err := dockertest.NewPool("")
+	require.NoErrorf(t, err, "failed to create dockertest pool")
+
+	resource, err := pool.RunWithOptions(
+		&dockertest.RunOptions{
+			Repository: "cockroachdb/cockroach",
+			Tag:        "v20.2.10",
+			Cmd:        []string{"start-single-node", "--insecure"},
+		},
+	)
+	require.NoErrorf(t, err, "failed to start docker")
+
+	t.Cleanup(func() {
+		if err := pool.Purge(resource); err != nil {
+			t.Fatalf("Could not purge resource: %s", err)
+		}
+	})
+
+	// The insecure node trusts every user, so the password is not needed
+	rootConnStr := fmt.Sprintf("postgresql://root@localhost:%s/defaultdb?sslmode=disable",
+		resource.GetPort("26257/tcp"))
+	err = waitUntilDatabaseIsReady(pool, rootConnStr)
+	require.NoErrorf(t, err, "error waiting for database to be ready")
+
+	db, err := sql.Open("pgx", rootConnStr)
+	require.NoError(t, err)
+	defer db.Close()
+	_, err = db.Exec(fmt.Sprintf("CREATE DATABASE IF NOT EXISTS %s", bCtx.StoreConfig.CockroachDatabase))
+	require.NoErrorf(t, err, "failed to create database")
+
+	return resource
+}
 
+// waitUntilDatabaseIsReady retries connecting to the database with the
+// connection string, until it succeeds or the pool gives up
+func waitUntilDatabaseIsReady(pool *dockertest.Pool, connStr string) error {
 	return pool.Retry(func() error {
 
 		// Open does not necessarily establish the connection,
 		// it may just validate the arguments.
-		db, err := sql.Open("pgx", pgConnStr)
+		db, err := sql.Open("pgx", connStr)
 
 		// If Open failed miserably though, that's enough
 		// to conclude that a connection cannot be established